@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"it-app_user/internal/config"
+	"it-app_user/internal/server"
+)
+
+func main() {
+	// Sin argumentos levanta el servidor; reconcile-users ejecuta la
+	// reconciliación con Firebase una vez y termina
+	command := ""
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+		if command != "reconcile-users" {
+			log.Fatalf("Unknown command %q (available: reconcile-users)", command)
+		}
+	}
+
+	// Cargar y validar la configuración (defaults, CONFIG_FILE, entorno y CONFIG_SECRETS_DIR)
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+
+	srv, err := server.NewServer(cfg)
+	if err != nil {
+		log.Fatalf("Error initializing server: %v", err)
+	}
+
+	if command == "reconcile-users" {
+		code := reconcileUsers(srv, cfg, os.Args[2:])
+		srv.Close()
+		os.Exit(code)
+	}
+
+	// SIGINT/SIGTERM apagan el servidor de forma ordenada
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := srv.Start(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf("Server stopped: %v", err)
+	}
+}
+
+// reconcileUsers ejecuta la reconciliación con Firebase y escribe el reporte
+// en JSON. Sin -dry-run usa USERS_RECONCILE_DRY_RUN. Devuelve el código de salida.
+func reconcileUsers(srv *server.Server, cfg *config.Config, args []string) int {
+	flags := flag.NewFlagSet("reconcile-users", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", cfg.Users.ReconcileDryRun, "report differences without fixing them")
+	reportPath := flags.String("report", "", "write the JSON report to this file instead of stdout")
+	flags.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	report, runErr := srv.Reconciler().Run(ctx, *dryRun)
+	if report != nil {
+		if err := writeReport(*reportPath, report); err != nil {
+			log.Printf("Error writing report: %v", err)
+			return 1
+		}
+	}
+	if runErr != nil {
+		log.Printf("Reconciliation failed: %v", runErr)
+		return 1
+	}
+	return 0
+}
+
+// writeReport escribe el reporte en path, o en stdout si path está vacío
+func writeReport(path string, report interface{}) error {
+	var out io.Writer = os.Stdout
+	if path != "" {
+		file, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return fmt.Errorf("encode report: %w", err)
+	}
+	return nil
+}
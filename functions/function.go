@@ -3,69 +3,134 @@ package p
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
-	"os"
-
-	"github.com/GoogleCloudPlatform/functions-framework-go/funcframework"
-	"github.com/gorilla/mux"
-	
-	"functions/internal/config"
-	"functions/internal/database"
-	"functions/internal/handlers"
-	"functions/internal/middleware"
-	"functions/internal/routes"
-	"functions/pkg/firebase"
+	"sync"
+
+	"github.com/GoogleCloudPlatform/functions-framework-go/functions"
+	"github.com/cloudevents/sdk-go/v2/event"
+
+	"it-app_user/internal/config"
+	"it-app_user/internal/jobs"
+	"it-app_user/internal/server"
+	"it-app_user/pkg/firebase"
 )
 
-// API is the main HTTP Cloud Function entry point
-func API(w http.ResponseWriter, r *http.Request) {
-	// Configurar CORS para todas las respuestas
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-	
-	// Manejar preflight requests
-	if r.Method == "OPTIONS" {
-		w.WriteHeader(http.StatusOK)
-		return
+func init() {
+	// Las funciones CloudEvent se registran por nombre en el Functions Framework
+	functions.CloudEvent("AuthEvents", AuthEvents)
+}
+
+var (
+	srvMu sync.Mutex
+	srv   *server.Server
+)
+
+// getServer inicializa la configuración, la base de datos y Firebase una vez
+// por instancia; se reutilizan entre requests. Si la inicialización falla (p.
+// ej. la base de datos no responde en el arranque en frío) no se guarda el
+// error: la próxima request vuelve a intentarlo.
+func getServer() (*server.Server, error) {
+	srvMu.Lock()
+	defer srvMu.Unlock()
+	if srv != nil {
+		return srv, nil
 	}
 
-	// Cargar configuración
 	cfg, err := config.Load()
 	if err != nil {
-		log.Printf("Error loading config: %v", err)
+		return nil, err
+	}
+	s, err := server.NewServer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	srv = s
+	return srv, nil
+}
+
+// API is the main HTTP Cloud Function entry point
+func API(w http.ResponseWriter, r *http.Request) {
+	// CORS (incluidos los preflight) lo aplica la política configurada en SetupRoutes.
+	s, err := getServer()
+	if err != nil {
+		log.Printf("Error initializing service: %v", err)
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	// Inicializar base de datos
-	db, err := database.Initialize(cfg)
-	if err != nil {
-		log.Printf("Error initializing database: %v", err)
-		http.Error(w, "Database connection error", http.StatusInternalServerError)
+	// Servir la request
+	s.Handler().ServeHTTP(w, r)
+}
+
+// Jobs ejecuta una vez el job de mantenimiento indicado en ?name= (p. ej.
+// purge_deleted_users). Pensado para Cloud Scheduler: las Cloud Functions no
+// mantienen goroutines entre requests. Debe desplegarse sin acceso público,
+// invocable solo con el token OIDC de la cuenta de servicio del scheduler.
+func Jobs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	// Inicializar Firebase Auth
-	firebaseAuth, err := firebase.NewAuth()
+	s, err := getServer()
 	if err != nil {
-		log.Printf("Error initializing Firebase Auth: %v", err)
-		http.Error(w, "Firebase initialization error", http.StatusInternalServerError)
+		log.Printf("Error initializing service: %v", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	// Crear router
-	router := mux.NewRouter()
-	
-	// Aplicar middlewares globales
-	router.Use(middleware.LoggingMiddleware)
-	router.Use(middleware.CORSMiddleware)
-	router.Use(middleware.RateLimitMiddleware)
+	name := r.URL.Query().Get("name")
+	err = s.Jobs().RunNow(r.Context(), name)
+	switch {
+	case errors.Is(err, jobs.ErrUnknownJob):
+		http.Error(w, "Unknown job", http.StatusNotFound)
+		return
+	case errors.Is(err, jobs.ErrJobRunning):
+		http.Error(w, "Job already running", http.StatusConflict)
+		return
+	case err != nil:
+		// El detalle queda en el log del job
+		http.Error(w, "Job failed", http.StatusInternalServerError)
+		return
+	}
 
-	// Configurar rutas
-	routes.SetupRoutes(router, db, firebaseAuth, cfg)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"job":    name,
+		"status": "completed",
+	})
+}
 
-	// Servir la request
-	router.ServeHTTP(w, r)
-}
\ No newline at end of file
+// AuthEvents procesa los eventos de creación y eliminación de usuarios de
+// Firebase Auth (triggers providers/firebase.auth/eventTypes/user.create y
+// user.delete): crea o aplica el borrado lógico al usuario local. Los eventos
+// repetidos se ignoran por su ID. Devolver un error hace que se reintente si
+// la función se desplegó con --retry; los eventos inválidos no se reintentan.
+func AuthEvents(ctx context.Context, e event.Event) error {
+	s, err := getServer()
+	if err != nil {
+		log.Printf("Error initializing service: %v", err)
+		return err
+	}
+
+	var user firebase.AuthEventUser
+	if err := e.DataAs(&user); err != nil || user.UID == "" {
+		log.Printf("Invalid Firebase auth event %s: %v", e.ID(), err)
+		return nil
+	}
+
+	switch e.Type() {
+	case firebase.AuthEventUserCreated:
+		_, err = s.AuthEvents().UserCreated(ctx, e.ID(), &user)
+	case firebase.AuthEventUserDeleted:
+		_, err = s.AuthEvents().UserDeleted(ctx, e.ID(), &user)
+	default:
+		log.Printf("Ignoring unsupported event type %s", e.Type())
+		return nil
+	}
+	// El detalle del error queda en el log del servicio
+	return err
+}
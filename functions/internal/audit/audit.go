@@ -0,0 +1,263 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"time"
+	"unicode/utf8"
+
+	"it-app_user/internal/clientip"
+	"it-app_user/internal/logger"
+	"it-app_user/internal/models"
+	"it-app_user/internal/principal"
+	"it-app_user/internal/requestinfo"
+)
+
+// Acciones auditadas
+const (
+	ActionDataExportRequested  = "data_export.requested"
+	ActionDataExportCompleted  = "data_export.completed"
+	ActionDataExportFailed     = "data_export.failed"
+	ActionDataExportURLIssued  = "data_export.url_issued"
+	ActionDataExportDownloaded = "data_export.downloaded"
+
+	ActionAccountDeletionRequested    = "account_deletion.requested"
+	ActionAccountDeletionCodeRejected = "account_deletion.code_rejected"
+	ActionAccountDeletionScheduled    = "account_deletion.scheduled"
+	ActionAccountDeletionCancelled    = "account_deletion.cancelled"
+	ActionAccountDeletionCompleted    = "account_deletion.completed"
+	ActionAccountDeletionFailed       = "account_deletion.failed"
+
+	ActionUserCreated  = "user.created"
+	ActionUserUpdated  = "user.updated"
+	ActionUserDeleted  = "user.deleted"
+	ActionUserRestored = "user.restored"
+	ActionUserPurged   = "user.purged"
+	ActionUserReverted = "user.reverted"
+
+	// Acciones de administración sobre la cuenta (ver /admin/users)
+	ActionUserDisabled            = "user.disabled"
+	ActionUserEnabled             = "user.enabled"
+	ActionUserPasswordResetForced = "user.password_reset_forced"
+	ActionUserEmailVerified       = "user.email_verified"
+	ActionUserInviteSent          = "user.invite_sent"
+	// Consultas de administración: quién vio qué usuarios
+	ActionAdminUsersListed    = "admin.users_listed"
+	ActionAdminTimelineViewed = "admin.timeline_viewed"
+
+	ActionRoleCreated    = "role.created"
+	ActionRoleUpdated    = "role.updated"
+	ActionRoleDeleted    = "role.deleted"
+	ActionRoleAssigned   = "role.assigned"
+	ActionRoleUnassigned = "role.unassigned"
+
+	ActionTokensRevoked = "tokens.revoked"
+
+	ActionAPIClientCreated    = "api_client.created"
+	ActionAPIClientUpdated    = "api_client.updated"
+	ActionAPIClientKeyRotated = "api_client.key_rotated"
+	ActionAPIClientRevoked    = "api_client.revoked"
+
+	// Suplantación: el token emitido y cada request hecha con él
+	ActionImpersonationStarted = "impersonation.started"
+	ActionImpersonationRequest = "impersonation.request"
+
+	// ActionAuthBlocked es un registro o inicio de sesión rechazado por las blocking functions
+	ActionAuthBlocked = "auth.blocked"
+
+	ActionAuditPruned = "audit.pruned"
+)
+
+// Event es una acción sobre datos personales que debe quedar registrada
+type Event struct {
+	Action string
+	// ActorID es el Firebase UID de quien realiza la acción ("system" para jobs)
+	ActorID string
+	// TargetUserID es el ID local del usuario afectado
+	TargetUserID uint
+	// TargetFirebaseID identifica al usuario afectado cuando no hay ID local a mano
+	TargetFirebaseID string
+	IP               string
+	UserAgent        string
+	RequestID        string
+	// Changes es el diff de los campos modificados (ver Diff)
+	Changes    map[string]models.AuditChange
+	Metadata   map[string]interface{}
+	OccurredAt time.Time
+}
+
+// Store persiste los eventos de auditoría
+type Store interface {
+	Append(ctx context.Context, event *models.AuditEvent) error
+}
+
+var store Store
+
+// SetStore configura dónde se persisten los eventos. Sin store (p. ej. sin
+// base de datos) los eventos solo quedan en los logs.
+func SetStore(s Store) {
+	store = s
+}
+
+// ActorSystem identifica las acciones realizadas por jobs del servicio
+const ActorSystem = "system"
+
+// ActorClientPrefix antecede al nombre del cliente de API en el actor de sus acciones
+const ActorClientPrefix = principal.ActorClientPrefix
+
+// persistTimeout limita cuánto puede demorar una acción guardar su evento
+const persistTimeout = 5 * time.Second
+
+// Record guarda el evento en audit_events y lo registra como una línea de log
+// estructurada con audit=true, separable del resto de logs en Cloud Logging.
+// La IP, el User-Agent y el ID de la request se toman del contexto si el
+// evento no los trae. Un error al guardarlo se registra pero no se propaga: la
+// acción auditada ya ocurrió y la línea de log conserva el evento.
+func Record(ctx context.Context, event Event) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+	if event.IP == "" {
+		event.IP, _ = clientip.FromContext(ctx)
+	}
+	if info, ok := requestinfo.FromContext(ctx); ok {
+		if event.UserAgent == "" {
+			event.UserAgent = info.UserAgent
+		}
+		if event.RequestID == "" {
+			event.RequestID = info.ID
+		}
+	}
+
+	fields := map[string]interface{}{
+		"audit":          true,
+		"action":         event.Action,
+		"actor_id":       event.ActorID,
+		"target_user_id": event.TargetUserID,
+		"occurred_at":    event.OccurredAt.UTC().Format(time.RFC3339Nano),
+	}
+	if event.IP != "" {
+		fields["ip"] = event.IP
+	}
+	if event.TargetFirebaseID != "" {
+		fields["target_firebase_id"] = event.TargetFirebaseID
+	}
+	if event.RequestID != "" {
+		fields["request_id"] = event.RequestID
+	}
+	if len(event.Changes) > 0 {
+		fields["changes"] = event.Changes
+	}
+	if len(event.Metadata) > 0 {
+		fields["metadata"] = event.Metadata
+	}
+
+	log := logger.GetLogger().WithContext(ctx).WithFields(fields)
+	log.Info("Audit event")
+
+	if store == nil {
+		return
+	}
+	// El evento se guarda aunque la request se haya cancelado después de la acción
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), persistTimeout)
+	defer cancel()
+	err := store.Append(ctx, &models.AuditEvent{
+		OccurredAt:       event.OccurredAt,
+		Action:           event.Action,
+		ActorID:          event.ActorID,
+		TargetUserID:     event.TargetUserID,
+		TargetFirebaseID: event.TargetFirebaseID,
+		IP:               event.IP,
+		UserAgent:        truncate(event.UserAgent, 500),
+		RequestID:        event.RequestID,
+		Changes:          event.Changes,
+		Metadata:         event.Metadata,
+	})
+	if err != nil {
+		log.WithError(err).Error("Failed to persist audit event")
+	}
+}
+
+// FromRequest completa el actor, la IP y el User-Agent del evento a partir de
+// la request autenticada. Con un token de suplantación el actor es el
+// administrador y la metadata identifica la suplantación; con una API key es
+// el cliente (client:<nombre>).
+func FromRequest(r *http.Request, event Event) Event {
+	p, _ := principal.FromContext(r.Context())
+	if p.IsService() && event.ActorID == "" {
+		event.ActorID = ActorClientPrefix + p.ClientID
+	}
+	if p.Impersonated() && event.ActorID == "" {
+		event.ActorID = p.ImpersonatedBy
+		metadata := make(map[string]interface{}, len(event.Metadata)+2)
+		for k, v := range event.Metadata {
+			metadata[k] = v
+		}
+		metadata["impersonation_id"] = p.ImpersonationID
+		metadata["impersonated_user"] = p.UID
+		event.Metadata = metadata
+	}
+	if uid, ok := r.Context().Value("user_id").(string); ok && event.ActorID == "" {
+		event.ActorID = uid
+	}
+	if event.IP == "" {
+		event.IP = clientip.FromRequest(r)
+	}
+	if event.UserAgent == "" {
+		event.UserAgent = r.UserAgent()
+	}
+	return event
+}
+
+// Diff compara la representación JSON de before y after y devuelve los campos
+// de primer nivel que cambiaron. Los campos con json:"-" nunca aparecen;
+// updated_at se ignora porque cambia en cada modificación.
+func Diff(before, after interface{}) map[string]models.AuditChange {
+	from, err := toMap(before)
+	if err != nil {
+		return nil
+	}
+	to, err := toMap(after)
+	if err != nil {
+		return nil
+	}
+
+	changes := make(map[string]models.AuditChange)
+	for key, value := range to {
+		if key == "updated_at" {
+			continue
+		}
+		if old, ok := from[key]; !ok || !reflect.DeepEqual(old, value) {
+			changes[key] = models.AuditChange{From: from[key], To: value}
+		}
+	}
+	for key, old := range from {
+		if _, ok := to[key]; !ok && key != "updated_at" {
+			changes[key] = models.AuditChange{From: old, To: nil}
+		}
+	}
+	return changes
+}
+
+func toMap(v interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	err = json.Unmarshal(raw, &m)
+	return m, err
+}
+
+// truncate recorta value a max bytes sin cortar un carácter UTF-8
+func truncate(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	for max > 0 && !utf8.RuneStart(value[max]) {
+		max--
+	}
+	return value[:max]
+}
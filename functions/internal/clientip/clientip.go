@@ -0,0 +1,171 @@
+package clientip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Headers con la cadena de proxies que puede leer el Resolver
+const (
+	HeaderXForwardedFor = "X-Forwarded-For"
+	HeaderForwarded     = "Forwarded"
+)
+
+// Resolver obtiene la IP real del cliente. La cadena de proxies solo se lee
+// cuando la conexión viene de un proxy de confianza (p. ej. el balanceador de
+// Google delante de Cloud Run), y solo del header configurado: los proxies
+// agregan su salto a uno de los dos y dejan pasar el otro tal como lo envió
+// el cliente.
+type Resolver struct {
+	trusted []*net.IPNet
+	header  string
+}
+
+// NewResolver crea un resolver que confía en los proxies de los rangos dados
+// y lee la cadena del header indicado (X-Forwarded-For si está vacío).
+// Acepta CIDRs ("35.191.0.0/16") o IPs sueltas ("10.0.0.1").
+func NewResolver(trustedProxies []string, header string) (*Resolver, error) {
+	header = http.CanonicalHeaderKey(strings.TrimSpace(header))
+	switch header {
+	case "":
+		header = HeaderXForwardedFor
+	case HeaderXForwardedFor, HeaderForwarded:
+	default:
+		return nil, fmt.Errorf("unsupported client IP header %q", header)
+	}
+
+	trusted := make([]*net.IPNet, 0, len(trustedProxies))
+	for _, value := range trustedProxies {
+		value = strings.TrimSpace(value)
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", value)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 32
+			}
+			trusted = append(trusted, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", value, err)
+		}
+		trusted = append(trusted, network)
+	}
+
+	return &Resolver{trusted: trusted, header: header}, nil
+}
+
+// Resolve devuelve la IP del cliente de la request. Recorre la cadena de
+// proxies del header configurado de derecha a izquierda y se detiene en la
+// primera dirección que no es de confianza; el otro header se ignora.
+func (res *Resolver) Resolve(r *http.Request) string {
+	peer := hostOnly(r.RemoteAddr)
+	if !res.isTrusted(peer) {
+		return peer
+	}
+
+	var hops []string
+	if res.header == HeaderForwarded {
+		hops = parseForwarded(r.Header.Values(HeaderForwarded))
+	} else {
+		hops = parseXForwardedFor(r.Header.Values(HeaderXForwardedFor))
+	}
+
+	client := peer
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := hostOnly(hops[i])
+		if net.ParseIP(hop) == nil {
+			// Identificadores ofuscados o "unknown": no se puede seguir la cadena
+			break
+		}
+		client = hop
+		if !res.isTrusted(hop) {
+			break
+		}
+	}
+	return client
+}
+
+func (res *Resolver) isTrusted(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range res.trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseXForwardedFor une todas las apariciones del header en una lista de direcciones
+func parseXForwardedFor(values []string) []string {
+	var hops []string
+	for _, value := range values {
+		for _, hop := range strings.Split(value, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	return hops
+}
+
+// parseForwarded extrae los parámetros for= del header Forwarded, p. ej.
+// `for=192.0.2.60;proto=http, for="[2001:db8::1]:4711"`
+func parseForwarded(values []string) []string {
+	var hops []string
+	for _, value := range values {
+		for _, element := range strings.Split(value, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				name, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok || !strings.EqualFold(name, "for") {
+					continue
+				}
+				hops = append(hops, strings.Trim(val, `"`))
+			}
+		}
+	}
+	return hops
+}
+
+// hostOnly quita el puerto y los corchetes de una dirección ("1.2.3.4:80", "[::1]:80")
+func hostOnly(addr string) string {
+	addr = strings.TrimSpace(addr)
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+}
+
+type contextKey struct{}
+
+// WithClientIP devuelve un contexto que transporta la IP del cliente
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, contextKey{}, ip)
+}
+
+// FromContext obtiene la IP del cliente guardada en el contexto
+func FromContext(ctx context.Context) (string, bool) {
+	ip, ok := ctx.Value(contextKey{}).(string)
+	return ip, ok && ip != ""
+}
+
+// FromRequest obtiene la IP del cliente resuelta por el middleware o, si no
+// pasó por él, la dirección de la conexión sin el puerto
+func FromRequest(r *http.Request) string {
+	if ip, ok := FromContext(r.Context()); ok {
+		return ip
+	}
+	return hostOnly(r.RemoteAddr)
+}
@@ -0,0 +1,129 @@
+package clientip
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	trusted := []string{"35.191.0.0/16", "10.0.0.0/8", "2001:db8:ffff::/48"}
+
+	tests := []struct {
+		name    string
+		header  string
+		peer    string
+		headers map[string][]string
+		want    string
+	}{
+		{
+			name:    "untrusted peer ignores the chain",
+			peer:    "198.51.100.7:52000",
+			headers: map[string][]string{"X-Forwarded-For": {"203.0.113.9"}},
+			want:    "198.51.100.7",
+		},
+		{
+			name: "trusted peer without a chain",
+			peer: "35.191.3.4:80",
+			want: "35.191.3.4",
+		},
+		{
+			name:    "spoofed leftmost X-Forwarded-For entry",
+			peer:    "35.191.3.4:80",
+			headers: map[string][]string{"X-Forwarded-For": {"1.2.3.4, 203.0.113.9"}},
+			want:    "203.0.113.9",
+		},
+		{
+			name: "spoofed entry in an earlier X-Forwarded-For header",
+			peer: "35.191.3.4:80",
+			headers: map[string][]string{"X-Forwarded-For": {
+				"1.2.3.4",
+				"203.0.113.9, 10.1.2.3",
+			}},
+			want: "203.0.113.9",
+		},
+		{
+			name: "spoofed Forwarded header is ignored",
+			peer: "35.191.3.4:80",
+			headers: map[string][]string{
+				"Forwarded":       {"for=1.2.3.4"},
+				"X-Forwarded-For": {"203.0.113.9"},
+			},
+			want: "203.0.113.9",
+		},
+		{
+			name:   "spoofed X-Forwarded-For is ignored when reading Forwarded",
+			header: HeaderForwarded,
+			peer:   "35.191.3.4:80",
+			headers: map[string][]string{
+				"Forwarded":       {`for=1.2.3.4, for=203.0.113.9;proto=https`},
+				"X-Forwarded-For": {"5.6.7.8"},
+			},
+			want: "203.0.113.9",
+		},
+		{
+			name:    "IPv6 peer with port",
+			peer:    "[2001:db8::1]:443",
+			headers: map[string][]string{"X-Forwarded-For": {"203.0.113.9"}},
+			want:    "2001:db8::1",
+		},
+		{
+			name:    "IPv6 client with port behind an IPv6 proxy",
+			header:  HeaderForwarded,
+			peer:    "[2001:db8:ffff::2]:443",
+			headers: map[string][]string{"Forwarded": {`for="[2001:db8::5]:4711"`}},
+			want:    "2001:db8::5",
+		},
+		{
+			name:    "IPv6 client in X-Forwarded-For",
+			peer:    "10.0.0.2:80",
+			headers: map[string][]string{"X-Forwarded-For": {"2001:db8::5, 10.0.0.9"}},
+			want:    "2001:db8::5",
+		},
+		{
+			name:    "all-trusted chain resolves to its first hop",
+			peer:    "10.0.0.2:80",
+			headers: map[string][]string{"X-Forwarded-For": {"10.0.0.5, 35.191.0.9"}},
+			want:    "10.0.0.5",
+		},
+		{
+			name:    "unknown hop stops the walk",
+			header:  HeaderForwarded,
+			peer:    "10.0.0.2:80",
+			headers: map[string][]string{"Forwarded": {"for=1.2.3.4, for=unknown, for=10.0.0.9"}},
+			want:    "10.0.0.9",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := NewResolver(trusted, tt.header)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.peer
+			for name, values := range tt.headers {
+				for _, value := range values {
+					r.Header.Add(name, value)
+				}
+			}
+			if got := res.Resolve(r); got != tt.want {
+				t.Errorf("Resolve() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewResolver(t *testing.T) {
+	for _, header := range []string{"", "x-forwarded-for", "forwarded"} {
+		if _, err := NewResolver(nil, header); err != nil {
+			t.Errorf("NewResolver(%q): %v", header, err)
+		}
+	}
+	if _, err := NewResolver(nil, "X-Real-IP"); err == nil {
+		t.Error("NewResolver accepted an unsupported header")
+	}
+	if _, err := NewResolver([]string{"not-an-ip"}, ""); err == nil {
+		t.Error("NewResolver accepted an invalid trusted proxy")
+	}
+}
@@ -1,52 +1,370 @@
 package config
 
 import (
-	"os"
-	"strconv"
+	"fmt"
+	"strings"
+	"time"
 )
 
+// Config es la configuración completa del servicio. Se construye por capas
+// (ver Load): valores por defecto, archivo YAML/TOML, variables de entorno y
+// directorio de secretos. Es la única fuente de configuración: el resto de
+// subsistemas la reciben ya validada en lugar de leer variables de entorno.
 type Config struct {
-	DBHost            string
-	DBPort            string
-	DBUser            string
-	DBPassword        string
-	DBName            string
-	Port              string
-	FirebaseProjectID string
-	LogLevel          string
-	Environment       string
-	RateLimitRPS      int
-	RateLimitBurst    int
-}
-
-func LoadConfig() Config {
-	return Config{
-		DBHost:            getEnv("DB_HOST", "localhost"),
-		DBPort:            getEnv("DB_PORT", "5432"),
-		DBUser:            getEnv("DB_USER", "postgres"),
-		DBPassword:        getEnv("DB_PASSWORD", "postgres"),
-		DBName:            getEnv("DB_NAME", "itapp"),
-		Port:              getEnv("PORT", "8081"),
-		FirebaseProjectID: getEnv("FIREBASE_PROJECT_ID", ""),
-		LogLevel:          getEnv("LOG_LEVEL", "info"),
-		Environment:       getEnv("ENVIRONMENT", "development"),
-		RateLimitRPS:      getEnvAsInt("RATE_LIMIT_RPS", 100),
-		RateLimitBurst:    getEnvAsInt("RATE_LIMIT_BURST", 200),
-	}
-}
-
-func getEnv(key, defaultValue string) string {
-	if value, exists := os.LookupEnv(key); exists {
+	Environment string          `yaml:"environment" toml:"environment" env:"ENVIRONMENT"`
+	Server      ServerConfig    `yaml:"server" toml:"server"`
+	Database    DatabaseConfig  `yaml:"database" toml:"database"`
+	Firebase    FirebaseConfig  `yaml:"firebase" toml:"firebase"`
+	Log         LogConfig       `yaml:"log" toml:"log"`
+	RateLimit   RateLimitConfig `yaml:"rate_limit" toml:"rate_limit"`
+	Redis       RedisConfig     `yaml:"redis" toml:"redis"`
+	CORS        CORSConfig      `yaml:"cors" toml:"cors"`
+	Health      HealthConfig    `yaml:"health" toml:"health"`
+	Users       UsersConfig     `yaml:"users" toml:"users"`
+	Export      ExportConfig    `yaml:"export" toml:"export"`
+	Mail        MailConfig      `yaml:"mail" toml:"mail"`
+	Audit       AuditConfig     `yaml:"audit" toml:"audit"`
+	Security    SecurityConfig  `yaml:"security" toml:"security"`
+	// nil usa los proxies por defecto del entorno (ver defaultTrustedProxies)
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies" env:"TRUSTED_PROXIES"`
+	// Header con la cadena de proxies que se lee para la IP del cliente: X-Forwarded-For o Forwarded
+	ClientIPHeader string `yaml:"client_ip_header" toml:"client_ip_header" env:"CLIENT_IP_HEADER"`
+}
+
+type ServerConfig struct {
+	Port         int      `yaml:"port" toml:"port" env:"PORT"`
+	ReadTimeout  Duration `yaml:"read_timeout" toml:"read_timeout" env:"SERVER_READ_TIMEOUT"`
+	WriteTimeout Duration `yaml:"write_timeout" toml:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+	IdleTimeout  Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`
+	// Deadline del contexto de cada request (consultas a la base de datos y Firebase)
+	RequestTimeout Duration `yaml:"request_timeout" toml:"request_timeout" env:"REQUEST_TIMEOUT"`
+	// Timeouts por ruta; en variables de entorno van en JSON
+	RouteTimeouts []RouteTimeout `yaml:"route_timeouts" toml:"route_timeouts" env:"REQUEST_TIMEOUT_ROUTES"`
+}
+
+// RouteTimeout fija el deadline de ciertas rutas; el primero que coincida tiene prioridad
+type RouteTimeout struct {
+	Routes  []string `yaml:"routes" toml:"routes" json:"routes"`
+	Methods []string `yaml:"methods" toml:"methods" json:"methods,omitempty"`
+	Timeout Duration `yaml:"timeout" toml:"timeout" json:"timeout"`
+}
+
+type DatabaseConfig struct {
+	Host            string   `yaml:"host" toml:"host" env:"DB_HOST"`
+	Port            int      `yaml:"port" toml:"port" env:"DB_PORT"`
+	User            string   `yaml:"user" toml:"user" env:"DB_USER"`
+	Password        string   `yaml:"password" toml:"password" env:"DB_PASSWORD"`
+	Name            string   `yaml:"name" toml:"name" env:"DB_NAME"`
+	SSLMode         string   `yaml:"ssl_mode" toml:"ssl_mode" env:"DB_SSL_MODE"`
+	MaxIdleConns    int      `yaml:"max_idle_conns" toml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	MaxOpenConns    int      `yaml:"max_open_conns" toml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	ConnMaxLifetime Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+}
+
+// DSN devuelve la cadena de conexión de PostgreSQL
+func (c DatabaseConfig) DSN() string {
+	return fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		quoteDSN(c.Host), c.Port, quoteDSN(c.User), quoteDSN(c.Password), quoteDSN(c.Name), c.SSLMode)
+}
+
+// quoteDSN escapa un valor para el formato clave=valor de libpq
+func quoteDSN(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
 		return value
 	}
-	return defaultValue
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
+
+type FirebaseConfig struct {
+	ProjectID string `yaml:"project_id" toml:"project_id" env:"FIREBASE_PROJECT_ID"`
+	// Vacío (por defecto) para usar las credenciales por defecto del entorno
+	// (Cloud Functions/Cloud Run); el archivo de la cuenta de servicio solo se
+	// configura en local (.env.example, docker-compose.yml)
+	CredentialsFile string `yaml:"credentials_file" toml:"credentials_file" env:"FIREBASE_SERVICE_ACCOUNT_PATH"`
+	// URLs exactas con las que se registraron las blocking functions, que
+	// llegan como audiencia de sus JWT; vacío desactiva las blocking functions
+	BlockingAudiences []string `yaml:"blocking_audiences" toml:"blocking_audiences" env:"FIREBASE_BLOCKING_AUDIENCES"`
+}
+
+type LogConfig struct {
+	Level string `yaml:"level" toml:"level" env:"LOG_LEVEL"`
+}
+
+type RateLimitConfig struct {
+	// memory, postgres o redis; vacío usa el del entorno (ver defaultRateLimitStore)
+	Store string `yaml:"store" toml:"store" env:"RATE_LIMIT_STORE"`
+	RPS   int    `yaml:"rps" toml:"rps" env:"RATE_LIMIT_RPS"`
+	Burst int    `yaml:"burst" toml:"burst" env:"RATE_LIMIT_BURST"`
+	// Políticas por ruta; vacío usa las políticas por defecto. En variables de entorno van en JSON.
+	Policies     []RateLimitPolicy `yaml:"policies" toml:"policies" env:"RATE_LIMIT_POLICIES"`
+	PoliciesFile string            `yaml:"policies_file" toml:"policies_file" env:"RATE_LIMIT_POLICIES_FILE"`
+	// Rangos de IP de clientes de servicio exentos de rate limiting
+	ExemptCIDRs []string `yaml:"exempt_cidrs" toml:"exempt_cidrs" env:"RATE_LIMIT_EXEMPT_CIDRS"`
+}
+
+// RateLimitPolicy es la forma declarativa de una política de rate limiting
+type RateLimitPolicy struct {
+	Name    string   `yaml:"name" toml:"name" json:"name"`
+	Routes  []string `yaml:"routes" toml:"routes" json:"routes"`
+	Methods []string `yaml:"methods" toml:"methods" json:"methods,omitempty"`
+	Key     string   `yaml:"key" toml:"key" json:"key"` // ip, uid o body:<campo>
+	Rate    int      `yaml:"rate" toml:"rate" json:"rate"`
+	Period  Duration `yaml:"period" toml:"period" json:"period"`
+	Burst   int      `yaml:"burst" toml:"burst" json:"burst"` // 0 para usar Rate
+}
+
+type RedisConfig struct {
+	URL string `yaml:"url" toml:"url" env:"REDIS_URL"`
+}
+
+type CORSConfig struct {
+	// nil usa los orígenes por defecto del entorno (ver defaultCORSOrigins)
+	AllowedOrigins   []string `yaml:"allowed_origins" toml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+	AllowedMethods   []string `yaml:"allowed_methods" toml:"allowed_methods" env:"CORS_ALLOWED_METHODS"`
+	AllowedHeaders   []string `yaml:"allowed_headers" toml:"allowed_headers" env:"CORS_ALLOWED_HEADERS"`
+	ExposedHeaders   []string `yaml:"exposed_headers" toml:"exposed_headers" env:"CORS_EXPOSED_HEADERS"`
+	AllowCredentials bool     `yaml:"allow_credentials" toml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+	MaxAge           int      `yaml:"max_age" toml:"max_age" env:"CORS_MAX_AGE"`
+}
+
+type HealthConfig struct {
+	// Timeout de cada checker de /readyz
+	CheckTimeout Duration `yaml:"check_timeout" toml:"check_timeout" env:"HEALTH_CHECK_TIMEOUT"`
+	// Tiempo durante el cual se reutiliza el último resultado de /readyz
+	CacheTTL Duration `yaml:"cache_ttl" toml:"cache_ttl" env:"HEALTH_CACHE_TTL"`
+	// Verifica credenciales y claves públicas de Firebase en /readyz (hace llamadas a Google)
+	CheckFirebase bool `yaml:"check_firebase" toml:"check_firebase" env:"HEALTH_CHECK_FIREBASE"`
+}
+
+type UsersConfig struct {
+	// Aplica reglas por proveedor al email canónico (puntos y +etiquetas de Gmail,
+	// +etiquetas de Outlook, iCloud, etc.). Cambiarlo recalcula los emails canónicos al migrar.
+	EmailProviderRules bool `yaml:"email_provider_rules" toml:"email_provider_rules" env:"USERS_EMAIL_PROVIDER_RULES"`
+	// Tiempo durante el cual un usuario eliminado puede restaurarse antes de purgarlo
+	DeletionGracePeriod Duration `yaml:"deletion_grace_period" toml:"deletion_grace_period" env:"USERS_DELETION_GRACE_PERIOD"`
+	// Intervalo del job de purga en proceso; 0 lo desactiva (p. ej. si lo dispara Cloud Scheduler)
+	PurgeInterval Duration `yaml:"purge_interval" toml:"purge_interval" env:"USERS_PURGE_INTERVAL"`
+	// Usuarios purgados como máximo por ejecución
+	PurgeBatchSize int `yaml:"purge_batch_size" toml:"purge_batch_size" env:"USERS_PURGE_BATCH_SIZE"`
+	// Antigüedad máxima del último inicio de sesión (auth_time) para solicitar DELETE /me
+	DeletionReauthWindow Duration `yaml:"deletion_reauth_window" toml:"deletion_reauth_window" env:"USERS_DELETION_REAUTH_WINDOW"`
+	// Validez del código de confirmación enviado por email para DELETE /me
+	DeletionCodeTTL Duration `yaml:"deletion_code_ttl" toml:"deletion_code_ttl" env:"USERS_DELETION_CODE_TTL"`
+	// Tiempo durante el cual el usuario puede cancelar su propia eliminación
+	DeletionCancelWindow Duration `yaml:"deletion_cancel_window" toml:"deletion_cancel_window" env:"USERS_DELETION_CANCEL_WINDOW"`
+	// Intervalo del job de reconciliación con Firebase en proceso; 0 lo desactiva
+	ReconcileInterval Duration `yaml:"reconcile_interval" toml:"reconcile_interval" env:"USERS_RECONCILE_INTERVAL"`
+	// El job programado solo informa las diferencias, sin corregirlas
+	ReconcileDryRun bool `yaml:"reconcile_dry_run" toml:"reconcile_dry_run" env:"USERS_RECONCILE_DRY_RUN"`
+	// Borrados lógicos como máximo por ejecución de usuarios que ya no están en Firebase
+	ReconcileMaxDeletions int `yaml:"reconcile_max_deletions" toml:"reconcile_max_deletions" env:"USERS_RECONCILE_MAX_DELETIONS"`
+}
+
+type ExportConfig struct {
+	// Dónde se guardan los archivos de exportación de datos: local (solo desarrollo)
+	// o gcs; vacío usa el del entorno (ver defaultExportStorage)
+	Storage string `yaml:"storage" toml:"storage" env:"EXPORT_STORAGE"`
+	// Directorio del almacenamiento local
+	LocalDir string `yaml:"local_dir" toml:"local_dir" env:"EXPORT_LOCAL_DIR"`
+	// URL base con la que se construyen los links de descarga del almacenamiento local
+	PublicURL string `yaml:"public_url" toml:"public_url" env:"EXPORT_PUBLIC_URL"`
+	// Clave HMAC de los links de descarga locales; vacía genera una aleatoria al arrancar
+	SigningSecret string `yaml:"signing_secret" toml:"signing_secret" env:"EXPORT_SIGNING_SECRET"`
+	// Bucket de Cloud Storage cuando Storage es gcs
+	GCSBucket string `yaml:"gcs_bucket" toml:"gcs_bucket" env:"EXPORT_GCS_BUCKET"`
+	// Validez de cada link de descarga firmado
+	URLTTL Duration `yaml:"url_ttl" toml:"url_ttl" env:"EXPORT_URL_TTL"`
+	// Tiempo durante el cual se conserva el archivo antes de eliminarlo
+	Retention Duration `yaml:"retention" toml:"retention" env:"EXPORT_RETENTION"`
+	// Intervalo del job que procesa exportaciones pendientes y elimina las vencidas; 0 lo desactiva
+	ProcessInterval Duration `yaml:"process_interval" toml:"process_interval" env:"EXPORT_PROCESS_INTERVAL"`
+}
+
+type MailConfig struct {
+	// log (solo desarrollo: escribe los emails en el log) o smtp; vacío usa
+	// el del entorno (ver defaultMailDriver)
+	Driver   string `yaml:"driver" toml:"driver" env:"MAIL_DRIVER"`
+	From     string `yaml:"from" toml:"from" env:"MAIL_FROM"`
+	SMTPHost string `yaml:"smtp_host" toml:"smtp_host" env:"MAIL_SMTP_HOST"`
+	SMTPPort int    `yaml:"smtp_port" toml:"smtp_port" env:"MAIL_SMTP_PORT"`
+	// Vacío para enviar sin autenticación
+	SMTPUsername string `yaml:"smtp_username" toml:"smtp_username" env:"MAIL_SMTP_USERNAME"`
+	SMTPPassword string `yaml:"smtp_password" toml:"smtp_password" env:"MAIL_SMTP_PASSWORD"`
+}
+
+type AuditConfig struct {
+	// Tiempo durante el cual se conservan los eventos de auditoría; 0 los conserva para siempre
+	Retention Duration `yaml:"retention" toml:"retention" env:"AUDIT_RETENTION"`
+	// Intervalo del job que elimina los eventos vencidos; 0 lo desactiva
+	PruneInterval Duration `yaml:"prune_interval" toml:"prune_interval" env:"AUDIT_PRUNE_INTERVAL"`
+}
+
+type SecurityConfig struct {
+	// Dominios de email que no pueden registrarse ni iniciar sesión (también sus subdominios)
+	BlockedEmailDomains []string `yaml:"blocked_email_domains" toml:"blocked_email_domains" env:"SECURITY_BLOCKED_EMAIL_DOMAINS"`
+	// Rangos de IP desde los que se bloquean los registros y los inicios de sesión
+	BlockedCIDRs []string `yaml:"blocked_cidrs" toml:"blocked_cidrs" env:"SECURITY_BLOCKED_CIDRS"`
+}
+
+// Duration es un time.Duration que se lee como texto ("30s", "1h") desde YAML, TOML, JSON y variables de entorno
+type Duration time.Duration
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// Default devuelve la configuración por defecto, pensada para desarrollo local
+func Default() Config {
+	return Config{
+		Environment: "development",
+		Server: ServerConfig{
+			Port:         8081,
+			ReadTimeout:  Duration(15 * time.Second),
+			WriteTimeout: Duration(15 * time.Second),
+			IdleTimeout:  Duration(60 * time.Second),
+			// Menor que WriteTimeout para poder responder 503 antes de que se corte la conexión
+			RequestTimeout: Duration(10 * time.Second),
+		},
+		Database: DatabaseConfig{
+			Host:            "localhost",
+			Port:            5432,
+			User:            "postgres",
+			Password:        "postgres",
+			Name:            "itapp",
+			SSLMode:         "disable",
+			MaxIdleConns:    10,
+			MaxOpenConns:    100,
+			ConnMaxLifetime: Duration(30 * time.Minute),
+		},
+		Log: LogConfig{
+			Level: "info",
+		},
+		RateLimit: RateLimitConfig{
+			RPS:   100,
+			Burst: 200,
+		},
+		Redis: RedisConfig{
+			URL: "redis://localhost:6379/0",
+		},
+		CORS: CORSConfig{
+			AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+			AllowedHeaders:   []string{"Accept", "Accept-Language", "Authorization", "Content-Type", "X-Request-ID", "X-Requested-With"},
+			ExposedHeaders:   []string{"Content-Language", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After", "X-Request-ID"},
+			AllowCredentials: true,
+			MaxAge:           600,
+		},
+		Health: HealthConfig{
+			CheckTimeout:  Duration(2 * time.Second),
+			CacheTTL:      Duration(5 * time.Second),
+			CheckFirebase: true,
+		},
+		Users: UsersConfig{
+			DeletionGracePeriod: Duration(30 * 24 * time.Hour),
+			PurgeInterval:       Duration(time.Hour),
+			PurgeBatchSize:      100,
+			// Firebase exige un inicio de sesión reciente para operaciones sensibles; 5 minutos como su SDK
+			DeletionReauthWindow: Duration(5 * time.Minute),
+			DeletionCodeTTL:      Duration(15 * time.Minute),
+			DeletionCancelWindow: Duration(7 * 24 * time.Hour),
+			// La reconciliación corre a demanda y solo informa hasta habilitarla
+			ReconcileDryRun:       true,
+			ReconcileMaxDeletions: 100,
+		},
+		Export: ExportConfig{
+			LocalDir:        "exports",
+			PublicURL:       "http://localhost:8081",
+			URLTTL:          Duration(15 * time.Minute),
+			Retention:       Duration(72 * time.Hour),
+			ProcessInterval: Duration(time.Minute),
+		},
+		Mail: MailConfig{
+			From:     "no-reply@localhost",
+			SMTPPort: 587,
+		},
+		Audit: AuditConfig{
+			Retention:     Duration(365 * 24 * time.Hour),
+			PruneInterval: Duration(24 * time.Hour),
+		},
+		Security: SecurityConfig{
+			BlockedEmailDomains: []string{"tempmail.org", "10minutemail.com"},
+		},
+		// Los balanceadores de Google y Cloud Run agregan la IP a X-Forwarded-For
+		ClientIPHeader: "X-Forwarded-For",
+	}
+}
+
+// IsDevelopment indica si el servicio corre en un entorno local
+func (c Config) IsDevelopment() bool {
+	switch c.Environment {
+	case "development", "local", "test":
+		return true
+	default:
+		return false
+	}
+}
+
+// defaultExportStorage guarda las exportaciones en disco en desarrollo. En
+// otros entornos usa Cloud Storage, que exige configurar EXPORT_GCS_BUCKET.
+func defaultExportStorage(c Config) string {
+	if c.IsDevelopment() {
+		return "local"
+	}
+	return "gcs"
+}
+
+// defaultMailDriver escribe los emails en el log en desarrollo. En otros
+// entornos los envía por SMTP, que exige configurar MAIL_SMTP_HOST.
+func defaultMailDriver(c Config) string {
+	if c.IsDevelopment() {
+		return "log"
+	}
+	return "smtp"
 }
 
-func getEnvAsInt(key string, defaultValue int) int {
-	if value, exists := os.LookupEnv(key); exists {
-		if intValue, err := strconv.Atoi(value); err == nil {
-			return intValue
-		}
+// defaultCORSOrigins permite los servidores locales en desarrollo. En otros
+// entornos no hay orígenes por defecto y Validate exige CORS_ALLOWED_ORIGINS.
+func defaultCORSOrigins(c Config) []string {
+	if !c.IsDevelopment() {
+		return []string{}
 	}
-	return defaultValue
-}
\ No newline at end of file
+	return []string{
+		"http://localhost:*",
+		"http://127.0.0.1:*",
+		"https://localhost:*",
+		"https://127.0.0.1:*",
+	}
+}
+
+// defaultRateLimitStore guarda los contadores en memoria en desarrollo. En
+// otros entornos hay varias instancias, así que los comparte en Postgres.
+func defaultRateLimitStore(c Config) string {
+	if c.IsDevelopment() {
+		return "memory"
+	}
+	return "postgres"
+}
+
+// defaultTrustedProxies no confía en ningún proxy en desarrollo. En otros
+// entornos confía en los front-ends de Google que tienen delante Cloud Run y
+// Cloud Functions; sin ellos la IP de todos los clientes sería la del front-end.
+func defaultTrustedProxies(c Config) []string {
+	if c.IsDevelopment() {
+		return []string{}
+	}
+	return []string{"169.254.0.0/16", "35.191.0.0/16", "130.211.0.0/22"}
+}
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDSNQuoting(t *testing.T) {
+	tests := []struct {
+		name string
+		db   DatabaseConfig
+		want string
+	}{
+		{
+			name: "plain values",
+			db:   DatabaseConfig{Host: "localhost", Port: 5432, User: "postgres", Password: "postgres", Name: "itapp", SSLMode: "disable"},
+			want: "host=localhost port=5432 user=postgres password=postgres dbname=itapp sslmode=disable",
+		},
+		{
+			name: "empty password",
+			db:   DatabaseConfig{Host: "localhost", Port: 5432, User: "postgres", Name: "itapp", SSLMode: "disable"},
+			want: "host=localhost port=5432 user=postgres password='' dbname=itapp sslmode=disable",
+		},
+		{
+			name: "spaces, quotes and backslashes",
+			db:   DatabaseConfig{Host: "/cloudsql/project:region:db", Port: 5432, User: "app user", Password: `p'a ss\w`, Name: "itapp", SSLMode: "require"},
+			want: `host=/cloudsql/project:region:db port=5432 user='app user' password='p\'a ss\\w' dbname=itapp sslmode=require`,
+		},
+		{
+			name: "a password cannot inject options",
+			db:   DatabaseConfig{Host: "localhost", Port: 5432, User: "postgres", Password: "x sslmode=disable", Name: "itapp", SSLMode: "verify-full"},
+			want: "host=localhost port=5432 user=postgres password='x sslmode=disable' dbname=itapp sslmode=verify-full",
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.db.DSN(); got != tt.want {
+			t.Errorf("%s: DSN() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// loadDefaults carga la configuración por defecto de desarrollo, que es válida
+func loadDefaults(t *testing.T) Config {
+	t.Helper()
+	cfg, err := LoadWithOptions(LoadOptions{LookupEnv: envLookup(nil)})
+	if err != nil {
+		t.Fatalf("the default configuration is not valid: %v", err)
+	}
+	return *cfg
+}
+
+func TestValidateEnvironment(t *testing.T) {
+	cfg := loadDefaults(t)
+	cfg.Environment = "prod"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), `ENVIRONMENT must be one of development, local, test, staging or production (got "prod")`) {
+		t.Errorf("Validate() = %v, want an ENVIRONMENT problem", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(c *Config)
+		want   []string
+	}{
+		{name: "port", modify: func(c *Config) { c.Server.Port = 70000 }, want: []string{"PORT must be between 1 and 65535 (got 70000)"}},
+		{name: "timeouts", modify: func(c *Config) { c.Server.IdleTimeout = 0 }, want: []string{"SERVER_IDLE_TIMEOUT must be positive"}},
+		{name: "request timeout", modify: func(c *Config) { c.Server.RequestTimeout = c.Server.WriteTimeout }, want: []string{"REQUEST_TIMEOUT must be positive and lower than SERVER_WRITE_TIMEOUT"}},
+		{name: "route timeouts", modify: func(c *Config) {
+			c.Server.RouteTimeouts = []RouteTimeout{
+				{Routes: []string{"/me/export"}, Timeout: Duration(time.Second)},
+				{Timeout: 0},
+			}
+		}, want: []string{"route timeout #1: at least one route is required", "route timeout #1: timeout must be positive"}},
+		{name: "database", modify: func(c *Config) {
+			c.Database.Host = ""
+			c.Database.User = ""
+			c.Database.SSLMode = "on"
+		}, want: []string{"DB_HOST is required", "DB_USER is required", "DB_SSL_MODE must be one of"}},
+		{name: "connections", modify: func(c *Config) { c.Database.MaxIdleConns = c.Database.MaxOpenConns + 1 }, want: []string{"DB_MAX_IDLE_CONNS must be between 0 and DB_MAX_OPEN_CONNS"}},
+		{name: "log level", modify: func(c *Config) { c.Log.Level = "verbose" }, want: []string{"LOG_LEVEL must be one of"}},
+		{name: "rate limit store", modify: func(c *Config) { c.RateLimit.Store = "memcached" }, want: []string{"RATE_LIMIT_STORE must be memory, postgres or redis"}},
+		{name: "redis URL", modify: func(c *Config) {
+			c.RateLimit.Store = "redis"
+			c.Redis.URL = "localhost:6379"
+		}, want: []string{"REDIS_URL must be a redis:// or rediss:// URL"}},
+		{name: "exempt CIDRs", modify: func(c *Config) { c.RateLimit.ExemptCIDRs = []string{"10.0.0.1"} }, want: []string{`RATE_LIMIT_EXEMPT_CIDRS: invalid CIDR "10.0.0.1"`}},
+		{name: "trusted proxies", modify: func(c *Config) { c.TrustedProxies = []string{"10.0.0.1", "10.0.0.0/8", "proxy"} }, want: []string{`TRUSTED_PROXIES: invalid CIDR or IP "proxy"`}},
+		{name: "CORS wildcard with credentials", modify: func(c *Config) { c.CORS.AllowedOrigins = []string{"*"} }, want: []string{"CORS_ALLOWED_ORIGINS=* cannot be combined with CORS_ALLOW_CREDENTIALS=true"}},
+		{name: "CORS origin", modify: func(c *Config) { c.CORS.AllowedOrigins = []string{"app.example.com"} }, want: []string{`CORS_ALLOWED_ORIGINS: invalid origin "app.example.com"`}},
+		{name: "CORS methods", modify: func(c *Config) { c.CORS.AllowedMethods = nil }, want: []string{"CORS_ALLOWED_METHODS cannot be empty"}},
+		{name: "export storage", modify: func(c *Config) { c.Export.Storage = "s3" }, want: []string{`EXPORT_STORAGE must be local or gcs (got "s3")`}},
+		{name: "export bucket", modify: func(c *Config) { c.Export.Storage = "gcs" }, want: []string{"EXPORT_GCS_BUCKET is required when EXPORT_STORAGE=gcs"}},
+		{name: "local export storage", modify: func(c *Config) {
+			c.Export.LocalDir = ""
+			c.Export.PublicURL = "/files"
+		}, want: []string{"EXPORT_LOCAL_DIR is required", `EXPORT_PUBLIC_URL must be an absolute URL when EXPORT_STORAGE=local (got "/files")`}},
+		{name: "export URL TTL", modify: func(c *Config) { c.Export.URLTTL = 0 }, want: []string{"EXPORT_URL_TTL must be positive and at most 168h"}},
+		{name: "export retention", modify: func(c *Config) { c.Export.Retention = Duration(time.Minute) }, want: []string{"EXPORT_RETENTION must be at least EXPORT_URL_TTL"}},
+		{name: "account deletion", modify: func(c *Config) {
+			c.Users.DeletionReauthWindow = 0
+			c.Users.DeletionCodeTTL = 0
+			c.Users.DeletionCancelWindow = Duration(-time.Hour)
+		}, want: []string{"USERS_DELETION_REAUTH_WINDOW must be positive", "USERS_DELETION_CODE_TTL must be positive", "USERS_DELETION_CANCEL_WINDOW cannot be negative"}},
+		{name: "mail driver", modify: func(c *Config) { c.Mail.Driver = "sendgrid" }, want: []string{`MAIL_DRIVER must be log or smtp (got "sendgrid")`}},
+		{name: "SMTP", modify: func(c *Config) {
+			c.Mail.Driver = "smtp"
+			c.Mail.SMTPPort = 0
+		}, want: []string{"MAIL_SMTP_HOST is required when MAIL_DRIVER=smtp", "MAIL_SMTP_PORT must be between 1 and 65535 (got 0)"}},
+		{name: "mail sender", modify: func(c *Config) { c.Mail.From = "no-reply" }, want: []string{`MAIL_FROM must be a valid email address (got "no-reply")`}},
+		{name: "policies", modify: func(c *Config) {
+			c.RateLimit.Policies = []RateLimitPolicy{
+				{Name: "login", Routes: []string{"/login"}, Key: "ip", Rate: 5, Period: Duration(time.Minute)},
+				{Name: "login", Routes: []string{"/login"}, Key: "body:", Rate: 0, Period: 0, Burst: -1},
+				{Key: "uid", Rate: 1, Period: Duration(time.Second)},
+			}
+		}, want: []string{
+			`rate limit policy "login": duplicated name`,
+			`rate limit policy "login": invalid key "body:"`,
+			`rate limit policy "login": rate must be positive`,
+			`rate limit policy "login": period must be positive`,
+			`rate limit policy "login": burst cannot be negative`,
+			"rate limit policy #2: name is required",
+			`rate limit policy "#2": at least one route is required`,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := loadDefaults(t)
+			tt.modify(&cfg)
+
+			var verr *ValidationError
+			if err := cfg.Validate(); !errors.As(err, &verr) {
+				t.Fatalf("Validate() = %v, want a ValidationError", err)
+			}
+			// Se informan todos los problemas, y solo esos
+			if len(verr.Problems) != len(tt.want) {
+				t.Errorf("problems = %q, want %d", verr.Problems, len(tt.want))
+			}
+			for _, want := range tt.want {
+				found := false
+				for _, problem := range verr.Problems {
+					if strings.Contains(problem, want) {
+						found = true
+						break
+					}
+				}
+				if !found {
+					t.Errorf("problems = %q, want one containing %q", verr.Problems, want)
+				}
+			}
+		})
+	}
+}
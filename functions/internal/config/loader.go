@@ -0,0 +1,218 @@
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadOptions indica de dónde leer cada capa de configuración
+type LoadOptions struct {
+	// File es un archivo .yaml, .yml o .toml (opcional)
+	File string
+	// SecretsDir es un directorio con un archivo por variable, p. ej.
+	// /run/secrets/DB_PASSWORD (opcional; también se acepta el nombre en minúsculas)
+	SecretsDir string
+	// LookupEnv obtiene las variables de entorno; por defecto os.LookupEnv
+	LookupEnv func(key string) (string, bool)
+}
+
+// Load carga la configuración usando CONFIG_FILE y CONFIG_SECRETS_DIR del entorno
+func Load() (*Config, error) {
+	return LoadWithOptions(LoadOptions{
+		File:       os.Getenv("CONFIG_FILE"),
+		SecretsDir: os.Getenv("CONFIG_SECRETS_DIR"),
+	})
+}
+
+// LoadWithOptions aplica, en orden de menor a mayor prioridad: valores por
+// defecto, archivo de configuración, variables de entorno y directorio de
+// secretos. Devuelve un error con todos los problemas encontrados al validar.
+func LoadWithOptions(opts LoadOptions) (*Config, error) {
+	lookupEnv := opts.LookupEnv
+	if lookupEnv == nil {
+		lookupEnv = os.LookupEnv
+	}
+
+	cfg := Default()
+
+	if opts.File != "" {
+		if err := decodeFile(opts.File, &cfg); err != nil {
+			return nil, fmt.Errorf("failed to load config file %s: %w", opts.File, err)
+		}
+	}
+
+	if err := applyLookup(reflect.ValueOf(&cfg).Elem(), lookupEnv, "environment variable"); err != nil {
+		return nil, err
+	}
+
+	if opts.SecretsDir != "" {
+		if err := applyLookup(reflect.ValueOf(&cfg).Elem(), secretLookup(opts.SecretsDir), "secret"); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.RateLimit.PoliciesFile != "" {
+		var file struct {
+			Policies []RateLimitPolicy `yaml:"policies" toml:"policies" json:"policies"`
+		}
+		if err := decodeFile(cfg.RateLimit.PoliciesFile, &file); err != nil {
+			return nil, fmt.Errorf("failed to load rate limit policies file %s: %w", cfg.RateLimit.PoliciesFile, err)
+		}
+		cfg.RateLimit.Policies = file.Policies
+	}
+
+	if cfg.TrustedProxies == nil {
+		cfg.TrustedProxies = defaultTrustedProxies(cfg)
+	}
+	if cfg.CORS.AllowedOrigins == nil {
+		cfg.CORS.AllowedOrigins = defaultCORSOrigins(cfg)
+	}
+	if cfg.RateLimit.Store == "" {
+		cfg.RateLimit.Store = defaultRateLimitStore(cfg)
+	}
+	if cfg.Export.Storage == "" {
+		cfg.Export.Storage = defaultExportStorage(cfg)
+	}
+	if cfg.Mail.Driver == "" {
+		cfg.Mail.Driver = defaultMailDriver(cfg)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &cfg, nil
+}
+
+// decodeFile lee un archivo YAML, TOML o JSON según su extensión. Las claves
+// desconocidas son un error para detectar errores de tipeo.
+func decodeFile(path string, out interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(out); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		return nil
+	case ".toml":
+		err := toml.NewDecoder(bytes.NewReader(data)).DisallowUnknownFields().Decode(out)
+		var strict *toml.StrictMissingError
+		if errors.As(err, &strict) {
+			return fmt.Errorf("unknown keys:\n%s", strict.String())
+		}
+		return err
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		return decoder.Decode(out)
+	default:
+		return fmt.Errorf("unsupported format %q (expected .yaml, .yml, .toml or .json)", filepath.Ext(path))
+	}
+}
+
+// secretLookup lee secretos montados como archivos (Docker/Kubernetes secrets)
+func secretLookup(dir string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		for _, name := range []string{key, strings.ToLower(key)} {
+			data, err := os.ReadFile(filepath.Join(dir, name))
+			if err == nil {
+				return strings.TrimRight(string(data), "\r\n"), true
+			}
+		}
+		return "", false
+	}
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// applyLookup recorre los campos con tag `env` y los sobrescribe con los valores encontrados
+func applyLookup(v reflect.Value, lookup func(string) (string, bool), source string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		key := t.Field(i).Tag.Get("env")
+
+		if key == "" {
+			if field.Kind() == reflect.Struct {
+				if err := applyLookup(field, lookup, source); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		value, ok := lookup(key)
+		if !ok {
+			continue
+		}
+		// El valor no se incluye en el error porque puede ser un secreto
+		if err := setField(field, value); err != nil {
+			return fmt.Errorf("invalid %s %s: %w", source, key, err)
+		}
+	}
+	return nil
+}
+
+func setField(field reflect.Value, value string) error {
+	if field.CanAddr() && field.Addr().Type().Implements(textUnmarshalerType) {
+		return field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("expected an integer")
+		}
+		field.SetInt(int64(n))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("expected true or false")
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() == reflect.String {
+			// Lista separada por comas; una variable vacía es una lista vacía
+			values := []string{}
+			for _, item := range strings.Split(value, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					values = append(values, item)
+				}
+			}
+			field.Set(reflect.ValueOf(values))
+			return nil
+		}
+		// Listas de estructuras (p. ej. políticas) en JSON
+		ptr := reflect.New(field.Type())
+		if strings.TrimSpace(value) != "" {
+			if err := json.Unmarshal([]byte(value), ptr.Interface()); err != nil {
+				return fmt.Errorf("expected a JSON array: %w", err)
+			}
+		}
+		field.Set(ptr.Elem())
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,296 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// envLookup simula las variables de entorno para no depender del entorno real
+func envLookup(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+// writeFile crea un archivo en un directorio temporal de la prueba
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadLayers(t *testing.T) {
+	dir := t.TempDir()
+	file := writeFile(t, dir, "config.yaml", `
+server:
+  port: 9000
+  read_timeout: 5s
+database:
+  host: file-host
+  user: file-user
+  password: file-password
+log:
+  level: debug
+`)
+	secrets := t.TempDir()
+	writeFile(t, secrets, "DB_PASSWORD", "secret-password\n")
+	writeFile(t, secrets, "db_user", "secret-user")
+
+	cfg, err := LoadWithOptions(LoadOptions{
+		File:       file,
+		SecretsDir: secrets,
+		LookupEnv: envLookup(map[string]string{
+			"DB_HOST":     "env-host",
+			"DB_PASSWORD": "env-password",
+			"LOG_LEVEL":   "warn",
+		}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{name: "default", got: cfg.Database.Name, want: "itapp"},
+		{name: "file", got: cfg.Server.Port, want: 9000},
+		{name: "file duration", got: time.Duration(cfg.Server.ReadTimeout), want: 5 * time.Second},
+		{name: "environment over file", got: cfg.Database.Host, want: "env-host"},
+		{name: "environment over file", got: cfg.Log.Level, want: "warn"},
+		{name: "secret over environment", got: cfg.Database.Password, want: "secret-password"},
+		{name: "lowercase secret over file", got: cfg.Database.User, want: "secret-user"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLoadFileFormats(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "config.yml", content: "server:\n  port: 9001\n"},
+		{name: "config.toml", content: "[server]\nport = 9001\n"},
+		{name: "config.json", content: `{"Server": {"Port": 9001}}`},
+		{name: "empty.yaml", content: ""},
+		{name: "unknown.yaml", content: "server:\n  prot: 9001\n", wantErr: "prot"},
+		{name: "unknown.toml", content: "[server]\nprot = 9001\n", wantErr: "prot"},
+		{name: "config.ini", content: "port=9001", wantErr: "unsupported format"},
+	}
+	for _, tt := range tests {
+		cfg, err := LoadWithOptions(LoadOptions{
+			File:      writeFile(t, dir, tt.name, tt.content),
+			LookupEnv: envLookup(nil),
+		})
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: err = %v, want it to mention %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if want := 9001; tt.name != "empty.yaml" && cfg.Server.Port != want {
+			t.Errorf("%s: port = %d, want %d", tt.name, cfg.Server.Port, want)
+		}
+	}
+
+	if _, err := LoadWithOptions(LoadOptions{File: filepath.Join(dir, "missing.yaml"), LookupEnv: envLookup(nil)}); err == nil {
+		t.Error("missing config file: expected an error")
+	}
+}
+
+func TestLoadEnvironmentValues(t *testing.T) {
+	cfg, err := LoadWithOptions(LoadOptions{LookupEnv: envLookup(map[string]string{
+		"SERVER_WRITE_TIMEOUT":   "1m",
+		"CORS_ALLOW_CREDENTIALS": " false ",
+		"CORS_ALLOWED_ORIGINS":   "https://a.example.com, ,https://b.example.com",
+		"TRUSTED_PROXIES":        "",
+		"RATE_LIMIT_POLICIES":    `[{"name":"login","routes":["/login"],"key":"ip","rate":5,"period":"1m"}]`,
+	})})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := time.Duration(cfg.Server.WriteTimeout); got != time.Minute {
+		t.Errorf("write timeout = %v, want 1m", got)
+	}
+	if cfg.CORS.AllowCredentials {
+		t.Error("CORS_ALLOW_CREDENTIALS=false was not applied")
+	}
+	if want := []string{"https://a.example.com", "https://b.example.com"}; !reflect.DeepEqual(cfg.CORS.AllowedOrigins, want) {
+		t.Errorf("allowed origins = %v, want %v", cfg.CORS.AllowedOrigins, want)
+	}
+	// Una variable vacía es una lista vacía, no la lista por defecto
+	if cfg.TrustedProxies == nil || len(cfg.TrustedProxies) != 0 {
+		t.Errorf("trusted proxies = %#v, want an empty list", cfg.TrustedProxies)
+	}
+	want := []RateLimitPolicy{{Name: "login", Routes: []string{"/login"}, Key: "ip", Rate: 5, Period: Duration(time.Minute)}}
+	if !reflect.DeepEqual(cfg.RateLimit.Policies, want) {
+		t.Errorf("policies = %+v, want %+v", cfg.RateLimit.Policies, want)
+	}
+}
+
+func TestLoadInvalidValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		secret  string
+		wantErr string
+	}{
+		{name: "integer", env: map[string]string{"PORT": "eighty"}, wantErr: "invalid environment variable PORT: expected an integer"},
+		{name: "boolean", env: map[string]string{"CORS_ALLOW_CREDENTIALS": "yes please"}, wantErr: "invalid environment variable CORS_ALLOW_CREDENTIALS: expected true or false"},
+		{name: "duration", env: map[string]string{"SERVER_IDLE_TIMEOUT": "soon"}, wantErr: "invalid environment variable SERVER_IDLE_TIMEOUT"},
+		{name: "policies", env: map[string]string{"RATE_LIMIT_POLICIES": "login"}, wantErr: "expected a JSON array"},
+		{name: "secret", secret: "not-a-number", wantErr: "invalid secret DB_PORT"},
+	}
+	for _, tt := range tests {
+		opts := LoadOptions{LookupEnv: envLookup(tt.env)}
+		if tt.secret != "" {
+			opts.SecretsDir = t.TempDir()
+			writeFile(t, opts.SecretsDir, "DB_PORT", tt.secret)
+		}
+		_, err := LoadWithOptions(opts)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.wantErr)
+		}
+		// El valor no aparece en el error porque puede ser un secreto
+		if err != nil && tt.secret != "" && strings.Contains(err.Error(), tt.secret) {
+			t.Errorf("%s: error %q leaks the value", tt.name, err)
+		}
+	}
+}
+
+func TestLoadPoliciesFile(t *testing.T) {
+	dir := t.TempDir()
+	file := writeFile(t, dir, "policies.yaml", `
+policies:
+  - name: export
+    routes: ["/me/export"]
+    methods: ["POST"]
+    key: uid
+    rate: 5
+    period: 24h
+    burst: 3
+`)
+	cfg, err := LoadWithOptions(LoadOptions{LookupEnv: envLookup(map[string]string{"RATE_LIMIT_POLICIES_FILE": file})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []RateLimitPolicy{{Name: "export", Routes: []string{"/me/export"}, Methods: []string{"POST"}, Key: "uid", Rate: 5, Period: Duration(24 * time.Hour), Burst: 3}}
+	if !reflect.DeepEqual(cfg.RateLimit.Policies, want) {
+		t.Errorf("policies = %+v, want %+v", cfg.RateLimit.Policies, want)
+	}
+}
+
+func TestDefaultCORSOrigins(t *testing.T) {
+	cfg := loadDefaults(t)
+	if !reflect.DeepEqual(cfg.CORS.AllowedOrigins, defaultCORSOrigins(Default())) || len(cfg.CORS.AllowedOrigins) == 0 {
+		t.Errorf("development origins = %v, want the local servers", cfg.CORS.AllowedOrigins)
+	}
+
+	production := Default()
+	production.Environment = "production"
+	if origins := defaultCORSOrigins(production); len(origins) != 0 {
+		t.Errorf("production origins = %v, want none", origins)
+	}
+
+	// Fuera de desarrollo los orígenes son obligatorios
+	_, err := LoadWithOptions(LoadOptions{LookupEnv: envLookup(map[string]string{"ENVIRONMENT": "staging"})})
+	if err == nil || !strings.Contains(err.Error(), "CORS_ALLOWED_ORIGINS is required outside development") {
+		t.Errorf("LoadWithOptions() = %v, want a missing CORS_ALLOWED_ORIGINS problem", err)
+	}
+	_, err = LoadWithOptions(LoadOptions{LookupEnv: envLookup(map[string]string{"ENVIRONMENT": "staging", "CORS_ALLOWED_ORIGINS": "https://app.example.com"})})
+	if err != nil && strings.Contains(err.Error(), "CORS_ALLOWED_ORIGINS") {
+		t.Errorf("LoadWithOptions() = %v, want the origins accepted", err)
+	}
+}
+
+func TestDefaultTrustedProxies(t *testing.T) {
+	if proxies := loadDefaults(t).TrustedProxies; len(proxies) != 0 {
+		t.Errorf("development trusted proxies = %v, want none", proxies)
+	}
+
+	// Fuera de desarrollo se confía en los front-ends de Google delante de Cloud Run
+	production := Default()
+	production.Environment = "production"
+	want := []string{"169.254.0.0/16", "35.191.0.0/16", "130.211.0.0/22"}
+	if proxies := defaultTrustedProxies(production); !reflect.DeepEqual(proxies, want) {
+		t.Errorf("production trusted proxies = %v, want %v", proxies, want)
+	}
+}
+
+func TestDefaultRateLimitStore(t *testing.T) {
+	if store := loadDefaults(t).RateLimit.Store; store != "memory" {
+		t.Errorf("development rate limit store = %q, want memory", store)
+	}
+
+	// Fuera de desarrollo los contadores se comparten entre instancias
+	production := Default()
+	production.Environment = "production"
+	if store := defaultRateLimitStore(production); store != "postgres" {
+		t.Errorf("production rate limit store = %q, want postgres", store)
+	}
+}
+
+func TestDefaultExportStorage(t *testing.T) {
+	if storage := loadDefaults(t).Export.Storage; storage != "local" {
+		t.Errorf("development export storage = %q, want local", storage)
+	}
+
+	// Fuera de desarrollo se exige Cloud Storage y su bucket
+	_, err := LoadWithOptions(LoadOptions{LookupEnv: envLookup(map[string]string{"ENVIRONMENT": "staging"})})
+	if err == nil || !strings.Contains(err.Error(), "EXPORT_GCS_BUCKET is required") {
+		t.Errorf("LoadWithOptions() = %v, want a missing EXPORT_GCS_BUCKET problem", err)
+	}
+}
+
+func TestDefaultMailDriver(t *testing.T) {
+	if driver := loadDefaults(t).Mail.Driver; driver != "log" {
+		t.Errorf("development mail driver = %q, want log", driver)
+	}
+
+	// Fuera de desarrollo los códigos se envían por SMTP
+	_, err := LoadWithOptions(LoadOptions{LookupEnv: envLookup(map[string]string{"ENVIRONMENT": "staging"})})
+	if err == nil || !strings.Contains(err.Error(), "MAIL_SMTP_HOST is required") {
+		t.Errorf("LoadWithOptions() = %v, want a missing MAIL_SMTP_HOST problem", err)
+	}
+	_, err = LoadWithOptions(LoadOptions{LookupEnv: envLookup(map[string]string{"ENVIRONMENT": "staging", "MAIL_DRIVER": "log"})})
+	if err == nil || !strings.Contains(err.Error(), "MAIL_DRIVER=log is only allowed in development") {
+		t.Errorf("LoadWithOptions() = %v, want MAIL_DRIVER=log rejected", err)
+	}
+}
+
+func TestLoadSMTPPasswordSecret(t *testing.T) {
+	// En el deploy la contraseña SMTP llega de Secret Manager montada en CONFIG_SECRETS_DIR
+	secrets := t.TempDir()
+	writeFile(t, secrets, "MAIL_SMTP_PASSWORD", "smtp-password\n")
+
+	cfg, err := LoadWithOptions(LoadOptions{
+		LookupEnv:  envLookup(map[string]string{"MAIL_SMTP_PASSWORD": "from-env"}),
+		SecretsDir: secrets,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Mail.SMTPPassword != "smtp-password" {
+		t.Errorf("SMTP password = %q, want the secret", cfg.Mail.SMTPPassword)
+	}
+}
@@ -0,0 +1,296 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ValidationError agrupa todos los problemas de configuración encontrados,
+// para poder corregirlos de una vez en lugar de uno por arranque
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Validate comprueba que la configuración sea utilizable
+func (c Config) Validate() error {
+	v := &ValidationError{}
+
+	switch c.Environment {
+	case "development", "local", "test", "staging", "production":
+	default:
+		v.add("ENVIRONMENT must be one of development, local, test, staging or production (got %q)", c.Environment)
+	}
+
+	// Servidor
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		v.add("PORT must be between 1 and 65535 (got %d)", c.Server.Port)
+	}
+	if c.Server.ReadTimeout <= 0 || c.Server.WriteTimeout <= 0 || c.Server.IdleTimeout <= 0 {
+		v.add("SERVER_READ_TIMEOUT, SERVER_WRITE_TIMEOUT and SERVER_IDLE_TIMEOUT must be positive")
+	}
+	if c.Server.RequestTimeout <= 0 || c.Server.RequestTimeout >= c.Server.WriteTimeout {
+		v.add("REQUEST_TIMEOUT must be positive and lower than SERVER_WRITE_TIMEOUT (got %s)", c.Server.RequestTimeout)
+	}
+	for i, rt := range c.Server.RouteTimeouts {
+		if len(rt.Routes) == 0 {
+			v.add("route timeout #%d: at least one route is required", i)
+		}
+		if rt.Timeout <= 0 || rt.Timeout >= c.Server.WriteTimeout {
+			v.add("route timeout #%d: timeout must be positive and lower than SERVER_WRITE_TIMEOUT (got %s)", i, rt.Timeout)
+		}
+	}
+
+	// Base de datos
+	if c.Database.Host == "" {
+		v.add("DB_HOST is required")
+	}
+	if c.Database.Port < 1 || c.Database.Port > 65535 {
+		v.add("DB_PORT must be between 1 and 65535 (got %d)", c.Database.Port)
+	}
+	if c.Database.User == "" {
+		v.add("DB_USER is required")
+	}
+	if c.Database.Name == "" {
+		v.add("DB_NAME is required")
+	}
+	switch c.Database.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		v.add("DB_SSL_MODE must be one of disable, allow, prefer, require, verify-ca or verify-full (got %q)", c.Database.SSLMode)
+	}
+	if c.Database.MaxOpenConns < 1 {
+		v.add("DB_MAX_OPEN_CONNS must be positive (got %d)", c.Database.MaxOpenConns)
+	}
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		v.add("DB_MAX_IDLE_CONNS must be between 0 and DB_MAX_OPEN_CONNS (got %d)", c.Database.MaxIdleConns)
+	}
+	if c.Database.ConnMaxLifetime < 0 {
+		v.add("DB_CONN_MAX_LIFETIME cannot be negative")
+	}
+
+	// Logs
+	if _, err := logrus.ParseLevel(c.Log.Level); err != nil {
+		v.add("LOG_LEVEL must be one of debug, info, warn or error (got %q)", c.Log.Level)
+	}
+
+	// Rate limiting
+	if c.RateLimit.RPS < 1 {
+		v.add("RATE_LIMIT_RPS must be positive (got %d)", c.RateLimit.RPS)
+	}
+	if c.RateLimit.Burst < 1 {
+		v.add("RATE_LIMIT_BURST must be positive (got %d)", c.RateLimit.Burst)
+	}
+	switch c.RateLimit.Store {
+	case "memory", "postgres":
+	case "redis":
+		if _, err := url.Parse(c.Redis.URL); err != nil || !strings.HasPrefix(c.Redis.URL, "redis") {
+			v.add("REDIS_URL must be a redis:// or rediss:// URL when RATE_LIMIT_STORE=redis")
+		}
+	default:
+		v.add("RATE_LIMIT_STORE must be memory, postgres or redis (got %q)", c.RateLimit.Store)
+	}
+	c.validatePolicies(v)
+	for _, cidr := range c.RateLimit.ExemptCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			v.add("RATE_LIMIT_EXEMPT_CIDRS: invalid CIDR %q", cidr)
+		}
+	}
+
+	// Proxies
+	for _, proxy := range c.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			v.add("TRUSTED_PROXIES: invalid CIDR or IP %q", proxy)
+		}
+	}
+	if header := http.CanonicalHeaderKey(c.ClientIPHeader); header != "X-Forwarded-For" && header != "Forwarded" {
+		v.add("CLIENT_IP_HEADER must be X-Forwarded-For or Forwarded (got %q)", c.ClientIPHeader)
+	}
+
+	// CORS
+	// Sin orígenes el frontend no puede llamar a la API y el error solo se ve en el navegador
+	if len(c.CORS.AllowedOrigins) == 0 && !c.IsDevelopment() {
+		v.add("CORS_ALLOWED_ORIGINS is required outside development")
+	}
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" {
+			if c.CORS.AllowCredentials {
+				v.add("CORS_ALLOWED_ORIGINS=* cannot be combined with CORS_ALLOW_CREDENTIALS=true")
+			}
+			continue
+		}
+		if scheme, rest, ok := strings.Cut(origin, "://"); !ok || scheme == "" || rest == "" || strings.Contains(rest, "/") {
+			v.add("CORS_ALLOWED_ORIGINS: invalid origin %q (expected scheme://host[:port])", origin)
+		}
+	}
+	if len(c.CORS.AllowedMethods) == 0 {
+		v.add("CORS_ALLOWED_METHODS cannot be empty")
+	}
+	if c.CORS.MaxAge < 0 {
+		v.add("CORS_MAX_AGE cannot be negative")
+	}
+
+	// Health checks
+	if c.Health.CheckTimeout <= 0 {
+		v.add("HEALTH_CHECK_TIMEOUT must be positive")
+	}
+	if c.Health.CacheTTL < 0 {
+		v.add("HEALTH_CACHE_TTL cannot be negative")
+	}
+
+	// Usuarios
+	if c.Users.DeletionGracePeriod < 0 {
+		v.add("USERS_DELETION_GRACE_PERIOD cannot be negative")
+	}
+	if c.Users.PurgeInterval < 0 {
+		v.add("USERS_PURGE_INTERVAL cannot be negative")
+	}
+	if c.Users.PurgeBatchSize <= 0 {
+		v.add("USERS_PURGE_BATCH_SIZE must be positive (got %d)", c.Users.PurgeBatchSize)
+	}
+
+	if c.Users.DeletionReauthWindow <= 0 {
+		v.add("USERS_DELETION_REAUTH_WINDOW must be positive")
+	}
+	if c.Users.DeletionCodeTTL <= 0 {
+		v.add("USERS_DELETION_CODE_TTL must be positive")
+	}
+	if c.Users.DeletionCancelWindow < 0 {
+		v.add("USERS_DELETION_CANCEL_WINDOW cannot be negative")
+	}
+	if c.Users.ReconcileInterval < 0 {
+		v.add("USERS_RECONCILE_INTERVAL cannot be negative")
+	}
+	if c.Users.ReconcileMaxDeletions < 0 {
+		v.add("USERS_RECONCILE_MAX_DELETIONS cannot be negative (got %d)", c.Users.ReconcileMaxDeletions)
+	}
+
+	// Exportación de datos
+	switch c.Export.Storage {
+	case "local":
+		// El disco de Cloud Run/Functions es efímero y no se comparte entre instancias
+		if !c.IsDevelopment() {
+			v.add("EXPORT_STORAGE=local is only allowed in development; use gcs")
+		}
+		if c.Export.LocalDir == "" {
+			v.add("EXPORT_LOCAL_DIR is required when EXPORT_STORAGE=local")
+		}
+		if u, err := url.Parse(c.Export.PublicURL); err != nil || u.Scheme == "" || u.Host == "" {
+			v.add("EXPORT_PUBLIC_URL must be an absolute URL when EXPORT_STORAGE=local (got %q)", c.Export.PublicURL)
+		}
+	case "gcs":
+		if c.Export.GCSBucket == "" {
+			v.add("EXPORT_GCS_BUCKET is required when EXPORT_STORAGE=gcs (the default outside development)")
+		}
+	default:
+		v.add("EXPORT_STORAGE must be local or gcs (got %q)", c.Export.Storage)
+	}
+	// Las URLs firmadas V4 de Cloud Storage duran como máximo 7 días
+	if c.Export.URLTTL <= 0 || time.Duration(c.Export.URLTTL) > 7*24*time.Hour {
+		v.add("EXPORT_URL_TTL must be positive and at most 168h (got %s)", c.Export.URLTTL)
+	}
+	if c.Export.Retention < c.Export.URLTTL {
+		v.add("EXPORT_RETENTION must be at least EXPORT_URL_TTL (got %s)", c.Export.Retention)
+	}
+	if c.Export.ProcessInterval < 0 {
+		v.add("EXPORT_PROCESS_INTERVAL cannot be negative")
+	}
+
+	// Email
+	switch c.Mail.Driver {
+	case "log":
+		// Los códigos de confirmación quedarían en los logs en lugar de llegar al usuario
+		if !c.IsDevelopment() {
+			v.add("MAIL_DRIVER=log is only allowed in development; use smtp")
+		}
+	case "smtp":
+		if c.Mail.SMTPHost == "" {
+			v.add("MAIL_SMTP_HOST is required when MAIL_DRIVER=smtp (the default outside development)")
+		}
+		if c.Mail.SMTPPort < 1 || c.Mail.SMTPPort > 65535 {
+			v.add("MAIL_SMTP_PORT must be between 1 and 65535 (got %d)", c.Mail.SMTPPort)
+		}
+	default:
+		v.add("MAIL_DRIVER must be log or smtp (got %q)", c.Mail.Driver)
+	}
+	if _, err := mail.ParseAddress(c.Mail.From); err != nil {
+		v.add("MAIL_FROM must be a valid email address (got %q)", c.Mail.From)
+	}
+
+	// Auditoría
+	if c.Audit.Retention != 0 && time.Duration(c.Audit.Retention) < 24*time.Hour {
+		v.add("AUDIT_RETENTION must be 0 (keep forever) or at least 24h (got %s)", c.Audit.Retention)
+	}
+	if c.Audit.PruneInterval < 0 {
+		v.add("AUDIT_PRUNE_INTERVAL cannot be negative")
+	}
+
+	// Blocking functions y políticas de acceso
+	for _, audience := range c.Firebase.BlockingAudiences {
+		if u, err := url.Parse(audience); err != nil || u.Scheme != "https" || u.Host == "" {
+			v.add("FIREBASE_BLOCKING_AUDIENCES: invalid URL %q (expected the exact https:// functionUri)", audience)
+		}
+	}
+	for _, domain := range c.Security.BlockedEmailDomains {
+		if domain == "" || strings.ContainsAny(domain, "@/ ") {
+			v.add("SECURITY_BLOCKED_EMAIL_DOMAINS: invalid domain %q", domain)
+		}
+	}
+	for _, cidr := range c.Security.BlockedCIDRs {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			v.add("SECURITY_BLOCKED_CIDRS: invalid CIDR %q", cidr)
+		}
+	}
+
+	if len(v.Problems) > 0 {
+		return v
+	}
+	return nil
+}
+
+func (c Config) validatePolicies(v *ValidationError) {
+	names := make(map[string]bool)
+	for i, p := range c.RateLimit.Policies {
+		name := p.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i)
+			v.add("rate limit policy %s: name is required", name)
+		} else if names[name] {
+			v.add("rate limit policy %q: duplicated name", name)
+		}
+		names[name] = true
+
+		if len(p.Routes) == 0 {
+			v.add("rate limit policy %q: at least one route is required", name)
+		}
+		switch {
+		case p.Key == "ip", p.Key == "uid":
+		case strings.HasPrefix(p.Key, "body:") && len(p.Key) > len("body:"):
+		default:
+			v.add("rate limit policy %q: invalid key %q (expected ip, uid or body:<field>)", name, p.Key)
+		}
+		if p.Rate < 1 {
+			v.add("rate limit policy %q: rate must be positive", name)
+		}
+		if p.Period <= 0 {
+			v.add("rate limit policy %q: period must be positive (e.g. \"1m\")", name)
+		}
+		if p.Burst < 0 {
+			v.add("rate limit policy %q: burst cannot be negative", name)
+		}
+	}
+}
+
+func (v *ValidationError) add(format string, args ...interface{}) {
+	v.Problems = append(v.Problems, fmt.Sprintf(format, args...))
+}
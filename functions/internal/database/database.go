@@ -3,44 +3,40 @@ package database
 import (
 	"fmt"
 	"log"
-	"os"
+	"time"
+
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+
+	"it-app_user/internal/config"
 )
 
 var DB *gorm.DB
 
 // ConnectDB establece la conexión con la base de datos PostgreSQL
-func ConnectDB() {
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-		os.Getenv("DB_PORT"),
-	)
-
-	var err error
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
-	
+func ConnectDB(cfg config.DatabaseConfig) error {
+	db, err := gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{})
 	if err != nil {
-		log.Fatalf("Error al conectar con la base de datos: %v", err)
+		return fmt.Errorf("error al conectar con la base de datos: %w", err)
 	}
 
 	// Configurar pool de conexiones
-	sqlDB, err := DB.DB()
+	sqlDB, err := db.DB()
 	if err != nil {
-		log.Fatalf("Error al obtener la instancia de base de datos: %v", err)
+		return fmt.Errorf("error al obtener la instancia de base de datos: %w", err)
 	}
 
 	// Configuraciones del pool
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetConnMaxLifetime(time.Duration(cfg.ConnMaxLifetime))
 
+	DB = db
 	log.Println("Conexión a base de datos establecida exitosamente")
+	return nil
 }
 
 // GetDB retorna la instancia de la base de datos
 func GetDB() *gorm.DB {
 	return DB
-}
\ No newline at end of file
+}
@@ -0,0 +1,37 @@
+// Package dbtest contiene utilidades para probar código que usa gorm sin una
+// base de datos real
+package dbtest
+
+import (
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// NewMockDB abre gorm con el dialecto de Postgres sobre sqlmock. Las
+// consultas esperadas se comparan como expresiones regulares; al terminar la
+// prueba se verifica que se cumplieron todas.
+func NewMockDB(t testing.TB) (*gorm.DB, sqlmock.Sqlmock) {
+	t.Helper()
+	sqlDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+		sqlDB.Close()
+	})
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, mock
+}
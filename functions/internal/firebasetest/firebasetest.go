@@ -0,0 +1,195 @@
+// Package firebasetest contiene un emulador de Firebase Auth falso para
+// probar con el SDK real el código que usa pkg/firebase
+package firebasetest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"it-app_user/pkg/firebase"
+)
+
+// ProjectID es el proyecto de Firebase de las pruebas
+const ProjectID = "innovatech-test"
+
+// Account es una cuenta de Firebase como la devuelve el emulador.
+// CustomAttributes es el JSON de los custom claims y Password la última
+// contraseña asignada, que el emulador no devuelve.
+type Account struct {
+	LocalID          string     `json:"localId"`
+	Email            string     `json:"email,omitempty"`
+	EmailVerified    bool       `json:"emailVerified,omitempty"`
+	Disabled         bool       `json:"disabled,omitempty"`
+	ValidSince       string     `json:"validSince,omitempty"`
+	CustomAttributes string     `json:"customAttributes,omitempty"`
+	Providers        []Provider `json:"providerUserInfo,omitempty"`
+	Password         string     `json:"-"`
+}
+
+// Provider es un proveedor vinculado a la cuenta, p. ej. "password"
+type Provider struct {
+	ProviderID string `json:"providerId"`
+}
+
+// Emulator responde las consultas (accounts:lookup) y actualizaciones
+// (accounts:update) de las cuentas dadas y genera links de restablecimiento
+// de contraseña (accounts:sendOobCode). Una cuenta nil hace fallar sus
+// consultas; FailUpdates hace fallar todas las actualizaciones.
+type Emulator struct {
+	mu          sync.Mutex
+	accounts    map[string]*Account
+	FailUpdates bool
+}
+
+// NewAuth apunta el SDK de Firebase a un emulador falso con las cuentas dadas
+// y devuelve el cliente de ProjectID, para que la verificación de tokens y
+// los errores sean los reales
+func NewAuth(t testing.TB, accounts map[string]*Account) (*firebase.Auth, *Emulator) {
+	t.Helper()
+	emulator := &Emulator{accounts: accounts}
+	if emulator.accounts == nil {
+		emulator.accounts = make(map[string]*Account)
+	}
+	server := httptest.NewServer(emulator)
+	t.Cleanup(server.Close)
+	t.Setenv("FIREBASE_AUTH_EMULATOR_HOST", strings.TrimPrefix(server.URL, "http://"))
+
+	firebaseAuth, err := firebase.NewAuth("", ProjectID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return firebaseAuth, emulator
+}
+
+// Account devuelve una copia de la cuenta, o nil si no existe
+func (e *Emulator) Account(uid string) *Account {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	account, ok := e.accounts[uid]
+	if !ok || account == nil {
+		return nil
+	}
+	found := *account
+	return &found
+}
+
+func (e *Emulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/projects/"+ProjectID+"/accounts:lookup"):
+		var req struct {
+			LocalID []string `json:"localId"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.LocalID) != 1 {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		account, ok := e.accounts[req.LocalID[0]]
+		if ok && account == nil {
+			http.Error(w, `{"error":{"message":"INTERNAL"}}`, http.StatusInternalServerError)
+			return
+		}
+		resp := map[string][]*Account{}
+		if ok {
+			resp["users"] = []*Account{account}
+		}
+		json.NewEncoder(w).Encode(resp)
+
+	case strings.HasSuffix(r.URL.Path, "/projects/"+ProjectID+"/accounts:update"):
+		var req struct {
+			LocalID          string  `json:"localId"`
+			CustomAttributes *string `json:"customAttributes"`
+			DisableUser      *bool   `json:"disableUser"`
+			EmailVerified    *bool   `json:"emailVerified"`
+			Password         *string `json:"password"`
+			ValidSince       *string `json:"validSince"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if e.FailUpdates {
+			http.Error(w, `{"error":{"message":"INTERNAL_ERROR"}}`, http.StatusInternalServerError)
+			return
+		}
+		account := e.accounts[req.LocalID]
+		if account == nil {
+			http.Error(w, `{"error":{"message":"USER_NOT_FOUND"}}`, http.StatusBadRequest)
+			return
+		}
+		if req.CustomAttributes != nil {
+			account.CustomAttributes = *req.CustomAttributes
+		}
+		if req.DisableUser != nil {
+			account.Disabled = *req.DisableUser
+		}
+		if req.EmailVerified != nil {
+			account.EmailVerified = *req.EmailVerified
+		}
+		if req.Password != nil {
+			account.Password = *req.Password
+		}
+		if req.ValidSince != nil {
+			account.ValidSince = *req.ValidSince
+		}
+		json.NewEncoder(w).Encode(map[string]string{"localId": req.LocalID})
+
+	case strings.HasSuffix(r.URL.Path, "/projects/"+ProjectID+"/accounts:sendOobCode"):
+		var req struct {
+			Email string `json:"email"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{
+			"email":   req.Email,
+			"oobLink": ResetLink(req.Email),
+		})
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// ResetLink es el link de restablecimiento de contraseña que genera el emulador para email
+func ResetLink(email string) string {
+	return "https://" + ProjectID + ".firebaseapp.com/__/auth/action?mode=resetPassword&oobCode=" + url.QueryEscape(email)
+}
+
+// IDToken arma un ID token sin firmar de ProjectID, como los que acepta el
+// emulador, emitido hace diez minutos. claims se agregan o reemplazan a los
+// estándar. En modo emulador el SDK consulta la cuenta al verificar el token,
+// así que uid tiene que estar entre las cuentas del emulador.
+func IDToken(t testing.TB, uid string, claims map[string]interface{}) string {
+	t.Helper()
+	issuedAt := time.Now().Add(-10 * time.Minute).Unix()
+	payload := map[string]interface{}{
+		"iss":       "https://securetoken.google.com/" + ProjectID,
+		"aud":       ProjectID,
+		"sub":       uid,
+		"user_id":   uid,
+		"iat":       issuedAt,
+		"auth_time": issuedAt,
+		"exp":       time.Now().Add(50 * time.Minute).Unix(),
+		"firebase":  map[string]interface{}{"sign_in_provider": "password"},
+	}
+	for key, value := range claims {
+		payload[key] = value
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encode := base64.RawURLEncoding.EncodeToString
+	return encode([]byte(`{"alg":"none","typ":"JWT"}`)) + "." + encode(body) + "."
+}
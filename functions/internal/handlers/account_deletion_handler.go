@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+
+	"it-app_user/internal/clientip"
+	"it-app_user/internal/i18n"
+	"it-app_user/internal/logger"
+	"it-app_user/internal/models"
+	"it-app_user/internal/services"
+	"it-app_user/internal/validator"
+)
+
+type AccountDeletionHandler struct {
+	accountDeletionService *services.AccountDeletionService
+}
+
+func NewAccountDeletionHandler(accountDeletionService *services.AccountDeletionService) *AccountDeletionHandler {
+	return &AccountDeletionHandler{accountDeletionService: accountDeletionService}
+}
+
+// DeleteMe maneja DELETE /me. Sin body envía un código de confirmación al
+// email del usuario; con {"code": "..."} confirma y programa la eliminación.
+// Ambos pasos requieren un inicio de sesión reciente.
+func (h *AccountDeletionHandler) DeleteMe(w http.ResponseWriter, r *http.Request) {
+	log := logger.GetLogger()
+
+	uid, ok := r.Context().Value("user_id").(string)
+	if !ok || uid == "" {
+		http.Error(w, i18n.T(r.Context(), "Authentication required"), http.StatusUnauthorized)
+		return
+	}
+	authTime, _ := r.Context().Value("auth_time").(time.Time)
+
+	var req models.ConfirmAccountDeletionRequest
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, i18n.T(r.Context(), "Error reading request body"), http.StatusBadRequest)
+		return
+	}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
+			return
+		}
+		if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	ip := clientip.FromRequest(r)
+	var request *models.AccountDeletionRequest
+	message := "Confirmation code sent to your email"
+	if req.Code == "" {
+		request, err = h.accountDeletionService.Request(r.Context(), uid, authTime, ip)
+	} else {
+		request, err = h.accountDeletionService.Confirm(r.Context(), uid, authTime, req.Code, ip)
+		message = "Account deletion scheduled"
+	}
+	if err != nil {
+		h.writeError(w, r, request, err)
+		return
+	}
+
+	log.WithFields(map[string]interface{}{
+		"user_id": request.UserID,
+		"status":  request.Status,
+	}).Info("Account deletion request updated")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    request,
+		"message": i18n.T(r.Context(), message),
+	})
+}
+
+// GetDeletionStatus maneja GET /me/deletion: estado de la última solicitud de eliminación
+func (h *AccountDeletionHandler) GetDeletionStatus(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value("user_id").(string)
+	if !ok || uid == "" {
+		http.Error(w, i18n.T(r.Context(), "Authentication required"), http.StatusUnauthorized)
+		return
+	}
+
+	request, err := h.accountDeletionService.Status(r.Context(), uid)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, i18n.T(r.Context(), "No account deletion requested"), http.StatusNotFound)
+			return
+		}
+		logger.GetLogger().WithError(err).Error("Failed to get account deletion status")
+		http.Error(w, i18n.T(r.Context(), "Error retrieving account deletion status"), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    request,
+		"message": i18n.T(r.Context(), "Account deletion status retrieved successfully"),
+	})
+}
+
+// CancelDeletion maneja POST /me/deletion/cancel
+func (h *AccountDeletionHandler) CancelDeletion(w http.ResponseWriter, r *http.Request) {
+	uid, ok := r.Context().Value("user_id").(string)
+	if !ok || uid == "" {
+		http.Error(w, i18n.T(r.Context(), "Authentication required"), http.StatusUnauthorized)
+		return
+	}
+
+	request, err := h.accountDeletionService.Cancel(r.Context(), uid, clientip.FromRequest(r))
+	if err != nil {
+		h.writeError(w, r, nil, err)
+		return
+	}
+
+	logger.GetLogger().WithField("user_id", request.UserID).Info("Account deletion cancelled")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    request,
+		"message": i18n.T(r.Context(), "Account deletion cancelled"),
+	})
+}
+
+// writeError traduce los errores del servicio a respuestas HTTP
+func (h *AccountDeletionHandler) writeError(w http.ResponseWriter, r *http.Request, request *models.AccountDeletionRequest, err error) {
+	switch {
+	case errors.Is(err, services.ErrReauthenticationRequired):
+		// Step-up authentication (RFC 9470): el cliente debe volver a iniciar sesión
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_user_authentication", max_age=%d`,
+			int(h.accountDeletionService.ReauthWindow().Seconds())))
+		http.Error(w, i18n.T(r.Context(), "Recent authentication required"), http.StatusUnauthorized)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, i18n.T(r.Context(), "User not found"), http.StatusNotFound)
+	case errors.Is(err, services.ErrDeletionAlreadyScheduled):
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data":    request,
+			"message": i18n.T(r.Context(), "Account deletion already scheduled"),
+		})
+	case errors.Is(err, services.ErrDeletionNotRequested):
+		http.Error(w, i18n.T(r.Context(), "Request a confirmation code first"), http.StatusBadRequest)
+	case errors.Is(err, services.ErrDeletionCodeInvalid):
+		http.Error(w, i18n.T(r.Context(), "Invalid confirmation code"), http.StatusBadRequest)
+	case errors.Is(err, services.ErrDeletionCodeExpired):
+		http.Error(w, i18n.T(r.Context(), "Confirmation code has expired"), http.StatusGone)
+	case errors.Is(err, services.ErrDeletionTooManyAttempts):
+		http.Error(w, i18n.T(r.Context(), "Too many attempts, request a new code"), http.StatusTooManyRequests)
+	case errors.Is(err, services.ErrDeletionNotScheduled):
+		http.Error(w, i18n.T(r.Context(), "No scheduled account deletion"), http.StatusNotFound)
+	case errors.Is(err, services.ErrDeletionInProgress):
+		http.Error(w, i18n.T(r.Context(), "Account deletion already in progress"), http.StatusConflict)
+	default:
+		logger.GetLogger().WithError(err).Error("Failed to process account deletion")
+		http.Error(w, i18n.T(r.Context(), "Error processing account deletion"), http.StatusInternalServerError)
+	}
+}
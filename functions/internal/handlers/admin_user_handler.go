@@ -0,0 +1,411 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"it-app_user/internal/audit"
+	"it-app_user/internal/i18n"
+	"it-app_user/internal/logger"
+	"it-app_user/internal/models"
+	"it-app_user/internal/repositories"
+	"it-app_user/internal/services"
+	"it-app_user/internal/validator"
+)
+
+type AdminUserHandler struct {
+	adminService *services.AdminUserService
+}
+
+func NewAdminUserHandler(adminService *services.AdminUserService) *AdminUserHandler {
+	return &AdminUserHandler{adminService: adminService}
+}
+
+// ListUsers maneja GET /admin/users: usuarios filtrados por texto, estado,
+// proveedor, rol, fechas de alta y de último login, con orden y paginación
+func (h *AdminUserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	log := logger.GetLogger()
+	query := r.URL.Query()
+
+	filter := repositories.UserFilter{
+		Query:    query.Get("q"),
+		Status:   query.Get("status"),
+		Provider: query.Get("provider"),
+		Role:     query.Get("role"),
+		Sort:     repositories.UserSortCreatedAt,
+		Desc:     true,
+		Limit:    50,
+	}
+
+	var err error
+	if filter.Disabled, err = parseBoolFilter(query.Get("disabled")); err != nil {
+		http.Error(w, i18n.T(r.Context(), "Invalid filter value"), http.StatusBadRequest)
+		return
+	}
+	if filter.EmailVerified, err = parseBoolFilter(query.Get("email_verified")); err != nil {
+		http.Error(w, i18n.T(r.Context(), "Invalid filter value"), http.StatusBadRequest)
+		return
+	}
+	for name, target := range map[string]*bool{"deleted": &filter.Deleted, "never_logged_in": &filter.NeverLoggedIn} {
+		value, err := parseBoolFilter(query.Get(name))
+		if err != nil {
+			http.Error(w, i18n.T(r.Context(), "Invalid filter value"), http.StatusBadRequest)
+			return
+		}
+		*target = value != nil && *value
+	}
+	for name, target := range map[string]*time.Time{
+		"created_from":    &filter.CreatedFrom,
+		"created_to":      &filter.CreatedTo,
+		"last_login_from": &filter.LastLoginFrom,
+		"last_login_to":   &filter.LastLoginTo,
+	} {
+		if v := query.Get(name); v != "" {
+			if *target, err = time.Parse(time.RFC3339, v); err != nil {
+				http.Error(w, i18n.T(r.Context(), "Invalid date, expected RFC 3339"), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	if v := query.Get("sort"); v != "" {
+		switch v {
+		case repositories.UserSortCreatedAt, repositories.UserSortLastLoginAt, repositories.UserSortEmail, repositories.UserSortUsername:
+			filter.Sort = v
+		default:
+			http.Error(w, i18n.T(r.Context(), "Invalid sort field"), http.StatusBadRequest)
+			return
+		}
+	}
+	switch query.Get("order") {
+	case "", "desc":
+	case "asc":
+		filter.Desc = false
+	default:
+		http.Error(w, i18n.T(r.Context(), "Invalid sort field"), http.StatusBadRequest)
+		return
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 200 {
+			filter.Limit = l
+		}
+	}
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			filter.Offset = o
+		}
+	}
+
+	users, total, err := h.adminService.List(r.Context(), filter)
+	if err != nil {
+		log.WithError(err).Error("Failed to list users for admin")
+		http.Error(w, i18n.T(r.Context(), "Error fetching users"), http.StatusInternalServerError)
+		return
+	}
+
+	audit.Record(r.Context(), audit.FromRequest(r, audit.Event{
+		Action:   audit.ActionAdminUsersListed,
+		Metadata: map[string]interface{}{"query": query.Encode(), "count": len(users), "total": total},
+	}))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    users,
+		"count":   len(users),
+		"total":   total,
+		"limit":   filter.Limit,
+		"offset":  filter.Offset,
+		"message": i18n.T(r.Context(), "Users retrieved successfully"),
+	})
+}
+
+// DisableUser maneja POST /admin/users/{id}/disable
+func (h *AdminUserHandler) DisableUser(w http.ResponseWriter, r *http.Request) {
+	h.setDisabled(w, r, true)
+}
+
+// EnableUser maneja POST /admin/users/{id}/enable
+func (h *AdminUserHandler) EnableUser(w http.ResponseWriter, r *http.Request) {
+	h.setDisabled(w, r, false)
+}
+
+func (h *AdminUserHandler) setDisabled(w http.ResponseWriter, r *http.Request, disabled bool) {
+	id, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+	req, ok := decodeAdminAction(w, r)
+	if !ok {
+		return
+	}
+
+	before, user, err := h.adminService.SetDisabled(r.Context(), id, disabled)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to change user status", id)
+		return
+	}
+
+	action, message := audit.ActionUserDisabled, "User disabled successfully"
+	if !disabled {
+		action, message = audit.ActionUserEnabled, "User enabled successfully"
+	}
+	h.record(r, action, user, req, audit.Diff(before, user), nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    user,
+		"message": i18n.T(r.Context(), message),
+	})
+}
+
+// ForcePasswordReset maneja POST /admin/users/{id}/password-reset: invalida
+// la contraseña, cierra las sesiones y envía un link para elegir otra
+func (h *AdminUserHandler) ForcePasswordReset(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+	req, ok := decodeAdminAction(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.adminService.ForcePasswordReset(r.Context(), id)
+	// Con el email fallido la contraseña igual cambió, así que se audita
+	if user != nil {
+		h.record(r, audit.ActionUserPasswordResetForced, user, req, nil, map[string]interface{}{
+			"email_sent": err == nil,
+		})
+	}
+	if err != nil {
+		h.writeError(w, r, err, "Failed to force password reset", id)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    map[string]interface{}{"user_id": user.ID, "email": user.Email},
+		"message": i18n.T(r.Context(), "Password reset forced and email sent"),
+	})
+}
+
+// RevokeSessions maneja POST /admin/users/{id}/revoke-sessions
+func (h *AdminUserHandler) RevokeSessions(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+	req, ok := decodeAdminAction(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.adminService.RevokeSessions(r.Context(), id)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to revoke user sessions", id)
+		return
+	}
+	h.record(r, audit.ActionTokensRevoked, user, req, nil, map[string]interface{}{"endpoint": "admin"})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    map[string]interface{}{"user_id": user.ID},
+		"message": i18n.T(r.Context(), "All sessions terminated successfully"),
+	})
+}
+
+// VerifyEmail maneja POST /admin/users/{id}/verify-email: marca el email como
+// verificado sin que el usuario complete la verificación
+func (h *AdminUserHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+	req, ok := decodeAdminAction(w, r)
+	if !ok {
+		return
+	}
+
+	before, user, err := h.adminService.VerifyEmail(r.Context(), id)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to verify user email", id)
+		return
+	}
+	h.record(r, audit.ActionUserEmailVerified, user, req, audit.Diff(before, user), nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    user,
+		"message": i18n.T(r.Context(), "Email verified successfully"),
+	})
+}
+
+// ResendInvite maneja POST /admin/users/{id}/invite: reenvía el link para
+// definir la contraseña a un usuario que todavía no inició sesión
+func (h *AdminUserHandler) ResendInvite(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+	req, ok := decodeAdminAction(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.adminService.ResendInvite(r.Context(), id)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to resend invite", id)
+		return
+	}
+	h.record(r, audit.ActionUserInviteSent, user, req, nil, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    map[string]interface{}{"user_id": user.ID, "email": user.Email},
+		"message": i18n.T(r.Context(), "Invite sent successfully"),
+	})
+}
+
+// GetSecurityTimeline maneja GET /admin/users/{id}/timeline
+func (h *AdminUserHandler) GetSecurityTimeline(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+	limit := 50
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 200 {
+			limit = l
+		}
+	}
+
+	timeline, err := h.adminService.Timeline(r.Context(), id, limit)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to build security timeline", id)
+		return
+	}
+	h.record(r, audit.ActionAdminTimelineViewed, timeline.User, models.AdminActionRequest{}, nil, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    timeline,
+		"message": i18n.T(r.Context(), "Security timeline retrieved successfully"),
+	})
+}
+
+// Impersonate maneja POST /admin/impersonate/{id}: emite un custom token de
+// corta duración para actuar como el usuario. El motivo es obligatorio y
+// cada request hecha con el token queda en la auditoría.
+func (h *AdminUserHandler) Impersonate(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+	var req models.ImpersonateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
+		return
+	}
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	adminUID, _ := r.Context().Value("user_id").(string)
+	ttl := time.Duration(req.TTLMinutes) * time.Minute
+	impersonation, err := h.adminService.Impersonate(r.Context(), id, adminUID, req.Scope, ttl)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to start impersonation", id)
+		return
+	}
+	h.record(r, audit.ActionImpersonationStarted, impersonation.User, models.AdminActionRequest{Reason: req.Reason}, nil, map[string]interface{}{
+		"impersonation_id": impersonation.ID,
+		"scope":            impersonation.Scope,
+		"expires_at":       impersonation.ExpiresAt,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    impersonation,
+		"message": i18n.T(r.Context(), "Impersonation token created successfully"),
+	})
+}
+
+// record audita una acción sobre el usuario con el motivo indicado por el admin
+func (h *AdminUserHandler) record(r *http.Request, action string, user *models.User, req models.AdminActionRequest, changes map[string]models.AuditChange, metadata map[string]interface{}) {
+	if req.Reason != "" {
+		if metadata == nil {
+			metadata = map[string]interface{}{}
+		}
+		metadata["reason"] = req.Reason
+	}
+	audit.Record(r.Context(), audit.FromRequest(r, audit.Event{
+		Action:           action,
+		TargetUserID:     user.ID,
+		TargetFirebaseID: user.FirebaseID,
+		Changes:          changes,
+		Metadata:         metadata,
+	}))
+}
+
+// writeError traduce los errores del AdminUserService a la respuesta HTTP
+func (h *AdminUserHandler) writeError(w http.ResponseWriter, r *http.Request, err error, logMessage string, userID uint) {
+	log := logger.GetLogger().WithField("user_id", userID)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, i18n.T(r.Context(), "User not found"), http.StatusNotFound)
+	case errors.Is(err, services.ErrFirebaseAccountMissing):
+		http.Error(w, i18n.T(r.Context(), "User has no Firebase account"), http.StatusConflict)
+	case errors.Is(err, services.ErrNoPasswordProvider):
+		http.Error(w, i18n.T(r.Context(), "User does not sign in with a password"), http.StatusConflict)
+	case errors.Is(err, services.ErrInviteAccepted):
+		http.Error(w, i18n.T(r.Context(), "User has already signed in"), http.StatusConflict)
+	case errors.Is(err, services.ErrImpersonationNotAllowed):
+		http.Error(w, i18n.T(r.Context(), "This user cannot be impersonated"), http.StatusForbidden)
+	case errors.Is(err, services.ErrFirebaseNotConfigured):
+		http.Error(w, i18n.T(r.Context(), "Authentication service not available"), http.StatusServiceUnavailable)
+	case errors.Is(err, services.ErrEmailNotSent):
+		log.WithError(err).Error(logMessage)
+		http.Error(w, i18n.T(r.Context(), "The email could not be sent"), http.StatusBadGateway)
+	case errors.Is(err, services.ErrFirebaseUpdateFailed):
+		log.WithError(err).Error(logMessage)
+		http.Error(w, i18n.T(r.Context(), "Firebase account could not be updated"), http.StatusBadGateway)
+	default:
+		log.WithError(err).Error(logMessage)
+		http.Error(w, i18n.T(r.Context(), "Error updating user"), http.StatusInternalServerError)
+	}
+}
+
+// decodeAdminAction lee el cuerpo opcional de una acción de administración
+func decodeAdminAction(w http.ResponseWriter, r *http.Request) (models.AdminActionRequest, bool) {
+	var req models.AdminActionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
+		return req, false
+	}
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return req, false
+	}
+	return req, true
+}
+
+// parseBoolFilter interpreta un filtro booleano opcional; vacío es nil
+func parseBoolFilter(value string) (*bool, error) {
+	if value == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+
+	"it-app_user/internal/audit"
+	"it-app_user/internal/dbtest"
+	"it-app_user/internal/firebasetest"
+	"it-app_user/internal/mail"
+	"it-app_user/internal/models"
+	"it-app_user/internal/repositories"
+	"it-app_user/internal/services"
+)
+
+func (r *blockingUserRepo) GetByID(ctx context.Context, id uint) (*models.User, error) {
+	for i := range r.users {
+		if r.users[i].ID == id {
+			found := r.users[i]
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *blockingUserRepo) GetDeletedByID(ctx context.Context, id uint) (*models.User, error) {
+	for i := range r.deleted {
+		if r.deleted[i].ID == id {
+			return &r.deleted[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *blockingUserRepo) Update(ctx context.Context, user *models.User) error {
+	for i := range r.users {
+		if r.users[i].ID == user.ID {
+			r.users[i] = *user
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+func (r *blockingUserRepo) List(ctx context.Context, filter repositories.UserFilter) ([]models.User, int64, error) {
+	return r.users, int64(len(r.users)), nil
+}
+
+// userRoleRepo devuelve los roles locales de las pruebas
+type userRoleRepo struct {
+	repositories.RoleRepositoryInterface
+	roles map[uint][]models.Role
+}
+
+func (r *userRoleRepo) ListByUserID(ctx context.Context, userID uint) ([]models.Role, error) {
+	return r.roles[userID], nil
+}
+
+// adminSettingsRepo simula usuarios sin configuraciones guardadas
+type adminSettingsRepo struct {
+	repositories.UserSettingsRepositoryInterface
+}
+
+func (r *adminSettingsRepo) GetByUserID(ctx context.Context, userID uint) (*models.UserSettings, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+// adminVerificationRepo simula usuarios sin estado de verificación de email
+type adminVerificationRepo struct {
+	repositories.EmailVerificationRepositoryInterface
+}
+
+func (r *adminVerificationRepo) GetByUserID(ctx context.Context, userID uint) (*models.EmailVerification, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+// adminAuditRepo simula una auditoría sin eventos previos
+type adminAuditRepo struct {
+	repositories.AuditEventRepositoryInterface
+}
+
+func (r *adminAuditRepo) List(ctx context.Context, filter repositories.AuditEventFilter) ([]models.AuditEvent, error) {
+	return nil, nil
+}
+
+// failingMailer simula un proveedor de email caído
+type failingMailer struct{}
+
+func (failingMailer) Send(ctx context.Context, msg mail.Message) error {
+	return errors.New("smtp unavailable")
+}
+
+// newTestAdminUserHandler arma el handler para el usuario 5 (uid-ana), con
+// contraseña y sin logins, y guarda la auditoría en el recorder devuelto
+func newTestAdminUserHandler(t *testing.T, mailer mail.Sender) (*AdminUserHandler, sqlmock.Sqlmock, *auditRecorder) {
+	t.Helper()
+	firebaseAuth, _ := firebasetest.NewAuth(t, map[string]*firebasetest.Account{
+		"uid-ana": {LocalID: "uid-ana", Email: "ana@example.com", Providers: []firebasetest.Provider{{ProviderID: "password"}}},
+	})
+	db, mock := dbtest.NewMockDB(t)
+	repos := &repositories.Repositories{
+		Users:              &blockingUserRepo{users: []models.User{{ID: 5, FirebaseID: "uid-ana", Email: "ana@example.com"}}},
+		Settings:           &adminSettingsRepo{},
+		EmailVerifications: &adminVerificationRepo{},
+		Roles:              &userRoleRepo{},
+	}
+	auditService := services.NewAuditService(&adminAuditRepo{}, 0)
+
+	recorder := &auditRecorder{}
+	audit.SetStore(recorder)
+	t.Cleanup(func() { audit.SetStore(nil) })
+	return NewAdminUserHandler(services.NewAdminUserService(repos, repositories.NewTxManager(db), firebaseAuth, mailer, auditService)), mock, recorder
+}
+
+func adminRequest(method, path, body string) *http.Request {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r = mux.SetURLVars(r, map[string]string{"id": "5"})
+	return r.WithContext(context.WithValue(r.Context(), "user_id", "uid-admin"))
+}
+
+func TestAdminUserHandlerAudits(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(h *AdminUserHandler) http.HandlerFunc
+		method  string
+		path    string
+		body    string
+		expect  func(mock sqlmock.Sqlmock)
+		action  string
+		target  uint
+		reason  string
+	}{
+		{
+			name:    "list",
+			handler: func(h *AdminUserHandler) http.HandlerFunc { return h.ListUsers },
+			method:  http.MethodGet,
+			path:    "/admin/users?status=active",
+			action:  audit.ActionAdminUsersListed,
+		},
+		{
+			name:    "disable",
+			handler: func(h *AdminUserHandler) http.HandlerFunc { return h.DisableUser },
+			method:  http.MethodPost,
+			path:    "/admin/users/5/disable",
+			body:    `{"reason":"ticket 42"}`,
+			action:  audit.ActionUserDisabled,
+			target:  5,
+			reason:  "ticket 42",
+		},
+		{
+			name:    "enable",
+			handler: func(h *AdminUserHandler) http.HandlerFunc { return h.EnableUser },
+			method:  http.MethodPost,
+			path:    "/admin/users/5/enable",
+			action:  audit.ActionUserEnabled,
+			target:  5,
+		},
+		{
+			name:    "force password reset",
+			handler: func(h *AdminUserHandler) http.HandlerFunc { return h.ForcePasswordReset },
+			method:  http.MethodPost,
+			path:    "/admin/users/5/password-reset",
+			body:    `{"reason":"ticket 42"}`,
+			action:  audit.ActionUserPasswordResetForced,
+			target:  5,
+			reason:  "ticket 42",
+		},
+		{
+			name:    "revoke sessions",
+			handler: func(h *AdminUserHandler) http.HandlerFunc { return h.RevokeSessions },
+			method:  http.MethodPost,
+			path:    "/admin/users/5/revoke-sessions",
+			action:  audit.ActionTokensRevoked,
+			target:  5,
+		},
+		{
+			name:    "verify email",
+			handler: func(h *AdminUserHandler) http.HandlerFunc { return h.VerifyEmail },
+			method:  http.MethodPost,
+			path:    "/admin/users/5/verify-email",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`UPDATE "users" SET .*"email_verified"=\$\d+`).WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(`SELECT \* FROM "email_verifications" WHERE user_id = \$1`).
+					WithArgs(5).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectCommit()
+			},
+			action: audit.ActionUserEmailVerified,
+			target: 5,
+		},
+		{
+			name:    "resend invite",
+			handler: func(h *AdminUserHandler) http.HandlerFunc { return h.ResendInvite },
+			method:  http.MethodPost,
+			path:    "/admin/users/5/invite",
+			action:  audit.ActionUserInviteSent,
+			target:  5,
+		},
+		{
+			name:    "timeline",
+			handler: func(h *AdminUserHandler) http.HandlerFunc { return h.GetSecurityTimeline },
+			method:  http.MethodGet,
+			path:    "/admin/users/5/timeline",
+			action:  audit.ActionAdminTimelineViewed,
+			target:  5,
+		},
+		{
+			name:    "impersonate",
+			handler: func(h *AdminUserHandler) http.HandlerFunc { return h.Impersonate },
+			method:  http.MethodPost,
+			path:    "/admin/impersonate/5",
+			body:    `{"reason":"reproduce ticket 42"}`,
+			action:  audit.ActionImpersonationStarted,
+			target:  5,
+			reason:  "reproduce ticket 42",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mock, recorder := newTestAdminUserHandler(t, mail.NewLogSender())
+			if tt.expect != nil {
+				tt.expect(mock)
+			}
+
+			w := httptest.NewRecorder()
+			tt.handler(handler)(w, adminRequest(tt.method, tt.path, tt.body))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body = %q, want 200", w.Code, w.Body.String())
+			}
+
+			if len(recorder.events) != 1 {
+				t.Fatalf("recorded %d audit events, want 1", len(recorder.events))
+			}
+			event := recorder.events[0]
+			if event.Action != tt.action || event.TargetUserID != tt.target {
+				t.Errorf("event = %s for user %d, want %s for user %d", event.Action, event.TargetUserID, tt.action, tt.target)
+			}
+			if reason, _ := event.Metadata["reason"].(string); reason != tt.reason {
+				t.Errorf("reason = %q, want %q", reason, tt.reason)
+			}
+		})
+	}
+}
+
+func TestAdminForcePasswordResetEmailNotSent(t *testing.T) {
+	handler, _, recorder := newTestAdminUserHandler(t, failingMailer{})
+
+	w := httptest.NewRecorder()
+	handler.ForcePasswordReset(w, adminRequest(http.MethodPost, "/admin/users/5/password-reset", ""))
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("status = %d, want 502", w.Code)
+	}
+	// La contraseña cambió aunque el email falló, así que igual se audita
+	if len(recorder.events) != 1 || recorder.events[0].Action != audit.ActionUserPasswordResetForced ||
+		recorder.events[0].Metadata["email_sent"] != false {
+		t.Errorf("events = %+v, want the forced reset with email_sent false", recorder.events)
+	}
+}
+
+func TestAdminUserHandlerErrorsAreNotAudited(t *testing.T) {
+	handler, _, recorder := newTestAdminUserHandler(t, mail.NewLogSender())
+
+	r := adminRequest(http.MethodPost, "/admin/users/9/disable", "")
+	r = mux.SetURLVars(r, map[string]string{"id": "9"})
+	w := httptest.NewRecorder()
+	handler.DisableUser(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404", w.Code)
+	}
+	if len(recorder.events) != 0 {
+		t.Errorf("recorded %d audit events for a failed action, want none", len(recorder.events))
+	}
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"it-app_user/internal/audit"
+	"it-app_user/internal/i18n"
+	"it-app_user/internal/logger"
+	"it-app_user/internal/models"
+	"it-app_user/internal/services"
+	"it-app_user/internal/validator"
+)
+
+type APIClientHandler struct {
+	clientService *services.APIClientService
+}
+
+func NewAPIClientHandler(clientService *services.APIClientService) *APIClientHandler {
+	return &APIClientHandler{clientService: clientService}
+}
+
+// ListAPIClients maneja GET /admin/api-clients
+func (h *APIClientHandler) ListAPIClients(w http.ResponseWriter, r *http.Request) {
+	clients, err := h.clientService.List(r.Context())
+	if err != nil {
+		logger.GetLogger().WithError(err).Error("Failed to list API clients")
+		http.Error(w, i18n.T(r.Context(), "Error retrieving API clients"), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    clients,
+		"count":   len(clients),
+		"message": i18n.T(r.Context(), "API clients retrieved successfully"),
+	})
+}
+
+// GetAPIClient maneja GET /admin/api-clients/{id}
+func (h *APIClientHandler) GetAPIClient(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseAPIClientID(w, r)
+	if !ok {
+		return
+	}
+
+	client, err := h.clientService.Get(r.Context(), id)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to get API client", id)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    client,
+		"message": i18n.T(r.Context(), "API client retrieved successfully"),
+	})
+}
+
+// CreateAPIClient maneja POST /admin/api-clients. La respuesta incluye la
+// API key, que no vuelve a mostrarse.
+func (h *APIClientHandler) CreateAPIClient(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateAPIClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
+		return
+	}
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	createdBy, _ := r.Context().Value("user_id").(string)
+	client, key, err := h.clientService.Create(r.Context(), req, createdBy)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to create API client", 0)
+		return
+	}
+
+	h.record(r, audit.ActionAPIClientCreated, client, nil, map[string]interface{}{
+		"scopes":     client.Scopes,
+		"key_prefix": client.Keys[0].Prefix,
+	})
+	logger.GetLogger().WithField("client", client.Name).Info("API client created")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    map[string]interface{}{"client": client, "api_key": key},
+		"message": i18n.T(r.Context(), "API client created successfully"),
+	})
+}
+
+// UpdateAPIClient maneja PUT /admin/api-clients/{id}: cambia la descripción
+// o reemplaza los scopes
+func (h *APIClientHandler) UpdateAPIClient(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseAPIClientID(w, r)
+	if !ok {
+		return
+	}
+	var req models.UpdateAPIClientRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
+		return
+	}
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	before, client, err := h.clientService.Update(r.Context(), id, req)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to update API client", id)
+		return
+	}
+	if changes := audit.Diff(apiClientAuditView(before), apiClientAuditView(client)); len(changes) > 0 {
+		h.record(r, audit.ActionAPIClientUpdated, client, changes, nil)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    client,
+		"message": i18n.T(r.Context(), "API client updated successfully"),
+	})
+}
+
+// RotateAPIClientKey maneja POST /admin/api-clients/{id}/rotate: crea una
+// key nueva y las anteriores vencen al terminar el período de gracia
+func (h *APIClientHandler) RotateAPIClientKey(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseAPIClientID(w, r)
+	if !ok {
+		return
+	}
+	var req models.RotateAPIClientKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
+		return
+	}
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	client, key, err := h.clientService.Rotate(r.Context(), id, req)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to rotate API client key", id)
+		return
+	}
+	// Las keys se ordenan por ID, así que la nueva es la última
+	metadata := map[string]interface{}{"key_prefix": client.Keys[len(client.Keys)-1].Prefix}
+	if req.GraceMinutes != nil {
+		metadata["grace_minutes"] = *req.GraceMinutes
+	}
+	h.record(r, audit.ActionAPIClientKeyRotated, client, nil, metadata)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    map[string]interface{}{"client": client, "api_key": key},
+		"message": i18n.T(r.Context(), "API key rotated successfully"),
+	})
+}
+
+// RevokeAPIClient maneja DELETE /admin/api-clients/{id}: revoca el cliente y
+// todas sus keys. El registro se conserva para la auditoría.
+func (h *APIClientHandler) RevokeAPIClient(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseAPIClientID(w, r)
+	if !ok {
+		return
+	}
+
+	client, err := h.clientService.Revoke(r.Context(), id)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to revoke API client", id)
+		return
+	}
+	h.record(r, audit.ActionAPIClientRevoked, client, nil, nil)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    client,
+		"message": i18n.T(r.Context(), "API client revoked successfully"),
+	})
+}
+
+// record audita una acción sobre el cliente de API
+func (h *APIClientHandler) record(r *http.Request, action string, client *models.APIClient, changes map[string]models.AuditChange, metadata map[string]interface{}) {
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	metadata["api_client_id"] = client.ID
+	metadata["client"] = client.Name
+	audit.Record(r.Context(), audit.FromRequest(r, audit.Event{
+		Action:   action,
+		Changes:  changes,
+		Metadata: metadata,
+	}))
+}
+
+// writeError traduce los errores del APIClientService a la respuesta HTTP
+func (h *APIClientHandler) writeError(w http.ResponseWriter, r *http.Request, err error, logMessage string, id uint) {
+	switch {
+	case errors.Is(err, services.ErrAPIClientNotFound):
+		http.Error(w, i18n.T(r.Context(), "API client not found"), http.StatusNotFound)
+	case errors.Is(err, services.ErrAPIClientExists):
+		http.Error(w, i18n.T(r.Context(), "API client already exists"), http.StatusConflict)
+	case errors.Is(err, services.ErrAPIClientRevoked):
+		http.Error(w, i18n.T(r.Context(), "API client has been revoked"), http.StatusConflict)
+	case errors.Is(err, services.ErrUnknownScope):
+		http.Error(w, i18n.T(r.Context(), "Unknown scope"), http.StatusBadRequest)
+	default:
+		logger.GetLogger().WithError(err).WithField("api_client_id", id).Error(logMessage)
+		http.Error(w, i18n.T(r.Context(), "Error saving API client"), http.StatusInternalServerError)
+	}
+}
+
+// apiClientAuditView son los campos de un cliente de API que se comparan en la auditoría
+func apiClientAuditView(client *models.APIClient) interface{} {
+	return struct {
+		Description string   `json:"description"`
+		Scopes      []string `json:"scopes"`
+	}{client.Description, client.Scopes}
+}
+
+// parseAPIClientID lee el ID del cliente de API de la ruta
+func parseAPIClientID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, i18n.T(r.Context(), "Invalid API client ID"), http.StatusBadRequest)
+		return 0, false
+	}
+	return uint(id), true
+}
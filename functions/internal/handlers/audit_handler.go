@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+
+	"it-app_user/internal/i18n"
+	"it-app_user/internal/logger"
+	"it-app_user/internal/repositories"
+	"it-app_user/internal/services"
+)
+
+type AuditHandler struct {
+	auditService *services.AuditService
+}
+
+func NewAuditHandler(auditService *services.AuditService) *AuditHandler {
+	return &AuditHandler{auditService: auditService}
+}
+
+// ListAuditEvents maneja GET /admin/audit: eventos del log de auditoría, del
+// más reciente al más antiguo, filtrados por acción, actor, usuario afectado,
+// request y rango de fechas
+func (h *AuditHandler) ListAuditEvents(w http.ResponseWriter, r *http.Request) {
+	log := logger.GetLogger()
+	query := r.URL.Query()
+
+	filter := repositories.AuditEventFilter{
+		Action:           query.Get("action"),
+		ActorID:          query.Get("actor_id"),
+		TargetFirebaseID: query.Get("target_firebase_id"),
+		RequestID:        query.Get("request_id"),
+		Limit:            50,
+	}
+
+	var err error
+	if v := query.Get("target_user_id"); v != "" {
+		var id uint64
+		if id, err = strconv.ParseUint(v, 10, 64); err != nil {
+			http.Error(w, i18n.T(r.Context(), "Invalid user ID"), http.StatusBadRequest)
+			return
+		}
+		filter.TargetUserID = uint(id)
+	}
+	if v := query.Get("from"); v != "" {
+		if filter.From, err = time.Parse(time.RFC3339, v); err != nil {
+			http.Error(w, i18n.T(r.Context(), "Invalid date, expected RFC 3339"), http.StatusBadRequest)
+			return
+		}
+	}
+	if v := query.Get("to"); v != "" {
+		if filter.To, err = time.Parse(time.RFC3339, v); err != nil {
+			http.Error(w, i18n.T(r.Context(), "Invalid date, expected RFC 3339"), http.StatusBadRequest)
+			return
+		}
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 200 {
+			filter.Limit = l
+		}
+	}
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			filter.Offset = o
+		}
+	}
+
+	events, err := h.auditService.List(r.Context(), filter)
+	if err != nil {
+		log.WithError(err).Error("Failed to list audit events")
+		http.Error(w, i18n.T(r.Context(), "Error retrieving audit events"), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    events,
+		"count":   len(events),
+		"limit":   filter.Limit,
+		"offset":  filter.Offset,
+		"message": i18n.T(r.Context(), "Audit events retrieved successfully"),
+	})
+}
+
+// VerifyAuditChain maneja GET /admin/audit/verify: recalcula los hashes de un
+// tramo de la cadena. Para verificarla completa se repite con after_id igual
+// al last_id anterior hasta que complete sea true.
+func (h *AuditHandler) VerifyAuditChain(w http.ResponseWriter, r *http.Request) {
+	log := logger.GetLogger()
+	query := r.URL.Query()
+
+	var afterID uint64
+	if v := query.Get("after_id"); v != "" {
+		var err error
+		if afterID, err = strconv.ParseUint(v, 10, 64); err != nil {
+			http.Error(w, i18n.T(r.Context(), "Invalid audit event ID"), http.StatusBadRequest)
+			return
+		}
+	}
+	limit := 10000
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 100000 {
+			limit = l
+		}
+	}
+
+	result, err := h.auditService.Verify(r.Context(), uint(afterID), limit)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, i18n.T(r.Context(), "Audit event not found"), http.StatusNotFound)
+			return
+		}
+		log.WithError(err).Error("Failed to verify audit chain")
+		http.Error(w, i18n.T(r.Context(), "Error verifying audit chain"), http.StatusInternalServerError)
+		return
+	}
+
+	if !result.Valid {
+		log.WithFields(map[string]interface{}{
+			"broken_at_id": result.BrokenAtID,
+			"reason":       result.Reason,
+		}).Error("Audit chain verification failed")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    result,
+		"message": i18n.T(r.Context(), "Audit chain verified"),
+	})
+}
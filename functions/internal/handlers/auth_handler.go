@@ -1,26 +1,32 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"strings"
 
 	"firebase.google.com/go/v4/auth"
+	"it-app_user/internal/audit"
+	"it-app_user/internal/clientip"
+	"it-app_user/internal/i18n"
 	"it-app_user/internal/logger"
 	"it-app_user/internal/models"
+	"it-app_user/internal/services"
 	"it-app_user/internal/validator"
 	"it-app_user/pkg/firebase"
 )
 
 type AuthHandler struct {
 	firebaseAuth *firebase.Auth
+	userService  *services.UserService
 }
 
-func NewAuthHandler(firebaseAuth *firebase.Auth) *AuthHandler {
+func NewAuthHandler(firebaseAuth *firebase.Auth, userService *services.UserService) *AuthHandler {
 	return &AuthHandler{
 		firebaseAuth: firebaseAuth,
+		userService:  userService,
 	}
 }
 
@@ -32,18 +38,18 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.WithError(err).Error("Failed to read request body")
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Error reading request body"), http.StatusBadRequest)
 		return
 	}
 
 	if err := json.Unmarshal(body, &req); err != nil {
 		log.WithError(err).Error("Failed to unmarshal JSON")
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
 		return
 	}
 
 	// Validar estructura
-	if err := validator.ValidateStruct(&req); err != nil {
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
 		log.WithError(err).Warn("Validation failed for login request")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -51,23 +57,23 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 
 	if h.firebaseAuth == nil {
 		log.Error("Firebase Auth not configured")
-		http.Error(w, "Authentication service not available", http.StatusServiceUnavailable)
+		http.Error(w, i18n.T(r.Context(), "Authentication service not available"), http.StatusServiceUnavailable)
 		return
 	}
 
 	// Verificar token de Firebase
-	token, err := h.firebaseAuth.VerifyIDToken(context.Background(), req.IDToken)
+	token, err := h.firebaseAuth.VerifyIDToken(r.Context(), req.IDToken)
 	if err != nil {
 		log.WithError(err).Warn("Invalid Firebase token")
-		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		http.Error(w, i18n.T(r.Context(), "Invalid token"), http.StatusUnauthorized)
 		return
 	}
 
 	// Obtener información del usuario de Firebase
-	userRecord, err := h.firebaseAuth.GetUser(context.Background(), token.UID)
+	userRecord, err := h.firebaseAuth.GetUser(r.Context(), token.UID)
 	if err != nil {
 		log.WithError(err).Error("Failed to get user from Firebase")
-		http.Error(w, "Failed to get user information", http.StatusInternalServerError)
+		http.Error(w, i18n.T(r.Context(), "Failed to get user information"), http.StatusInternalServerError)
 		return
 	}
 
@@ -77,20 +83,27 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	// Extraer nombres del display name si están disponibles
 	firstName, lastName := h.extractNames(userRecord.DisplayName)
 
+	// Crear o actualizar el usuario local y registrar el login
+	user, created, ok := h.provisionUser(w, r, &models.User{
+		FirebaseID:    token.UID,
+		Email:         userRecord.Email,
+		EmailVerified: userRecord.EmailVerified,
+		Username:      h.generateUsername(userRecord),
+		FirstName:     firstName,
+		LastName:      lastName,
+		PhotoURL:      userRecord.PhotoURL,
+		Provider:      provider,
+		Status:        "active",
+	})
+	if !ok {
+		return
+	}
+
 	// Crear respuesta de login
 	response := models.LoginResponse{
-		User: &models.User{
-			FirebaseID:    token.UID,
-			Email:         userRecord.Email,
-			EmailVerified: userRecord.EmailVerified,
-			Username:      h.generateUsername(userRecord),
-			FirstName:     firstName,
-			LastName:      lastName,
-			PhotoURL:      userRecord.PhotoURL,
-			Provider:      provider,
-			Status:        "active",
-		},
-		Message: "Login successful",
+		User:    user,
+		Created: created,
+		Message: i18n.T(r.Context(), "Login successful"),
 	}
 
 	log.WithFields(map[string]interface{}{
@@ -103,6 +116,40 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
+// provisionUser crea el usuario local en su primer login (o reutiliza el
+// existente) y registra el login. Si falla escribe la respuesta de error y
+// devuelve ok=false.
+func (h *AuthHandler) provisionUser(w http.ResponseWriter, r *http.Request, candidate *models.User) (*models.User, bool, bool) {
+	log := logger.GetLogger()
+
+	user, created, err := h.userService.ProvisionOnLogin(r.Context(), candidate, clientip.FromRequest(r), r.UserAgent())
+	if err != nil {
+		log.WithError(err).WithFields(map[string]interface{}{
+			"firebase_id": candidate.FirebaseID,
+			"email":       candidate.Email,
+		}).Error("Failed to provision user on login")
+
+		if errors.Is(err, services.ErrUserAlreadyExists) {
+			http.Error(w, i18n.T(r.Context(), "User with this email or username already exists"), http.StatusConflict)
+		} else if errors.Is(err, services.ErrUserDeleted) {
+			http.Error(w, i18n.T(r.Context(), "Account is scheduled for deletion"), http.StatusForbidden)
+		} else {
+			http.Error(w, i18n.T(r.Context(), "Error creating user"), http.StatusInternalServerError)
+		}
+		return nil, false, false
+	}
+
+	if created {
+		log.WithFields(map[string]interface{}{
+			"user_id":     user.ID,
+			"firebase_id": user.FirebaseID,
+			"username":    user.Username,
+		}).Info("User provisioned on first login")
+	}
+
+	return user, created, true
+}
+
 // Helper methods
 func (h *AuthHandler) determineProvider(userRecord *auth.UserRecord, requestProvider string) string {
 	// Si hay información de proveedores en Firebase, usar esa
@@ -143,31 +190,17 @@ func (h *AuthHandler) extractNames(displayName string) (string, string) {
 	return firstName, lastName
 }
 
+// generateUsername devuelve la sugerencia de username para el aprovisionamiento:
+// el nombre visible o, si no hay o no queda nada tras normalizarlo, la parte
+// local del email. El UserService la normaliza y busca una variante libre.
 func (h *AuthHandler) generateUsername(userRecord *auth.UserRecord) string {
-	if userRecord.DisplayName != "" {
-		// Limpiar el display name para usarlo como username
-		username := strings.ToLower(strings.ReplaceAll(userRecord.DisplayName, " ", ""))
-		// Remover caracteres especiales básicos
-		username = strings.ReplaceAll(username, ".", "")
-		username = strings.ReplaceAll(username, "-", "")
-		if len(username) >= 3 && len(username) <= 50 {
-			return username
-		}
+	if services.NormalizeUsername(userRecord.DisplayName) != "" {
+		return userRecord.DisplayName
 	}
-	
-	// Si no hay display name o no es válido, usar parte del email
-	if userRecord.Email != "" {
-		emailParts := strings.Split(userRecord.Email, "@")
-		if len(emailParts) > 0 {
-			username := emailParts[0]
-			if len(username) >= 3 && len(username) <= 50 {
-				return username
-			}
-		}
+	if local, _, found := strings.Cut(userRecord.Email, "@"); found {
+		return local
 	}
-	
-	// Fallback: generar username basado en UID
-	return "user" + userRecord.UID[:8]
+	return ""
 }
 
 // Logout maneja POST /auth/logout
@@ -181,7 +214,7 @@ func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Logout successful",
+		"message": i18n.T(r.Context(), "Logout successful"),
 	})
 }
 
@@ -195,7 +228,7 @@ func (h *AuthHandler) CheckAuthStatus(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"authenticated": false,
-			"message":       "No token provided",
+			"message":       i18n.T(r.Context(), "No token provided"),
 		})
 		return
 	}
@@ -206,30 +239,30 @@ func (h *AuthHandler) CheckAuthStatus(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"authenticated": false,
-			"message":       "Invalid token format",
+			"message":       i18n.T(r.Context(), "Invalid token format"),
 		})
 		return
 	}
 
 	if h.firebaseAuth == nil {
 		log.Error("Firebase Auth not configured")
-		http.Error(w, "Authentication service not available", http.StatusServiceUnavailable)
+		http.Error(w, i18n.T(r.Context(), "Authentication service not available"), http.StatusServiceUnavailable)
 		return
 	}
 
 	token := parts[1]
-	decodedToken, err := h.firebaseAuth.VerifyIDToken(context.Background(), token)
+	decodedToken, err := h.firebaseAuth.VerifyIDToken(r.Context(), token)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"authenticated": false,
-			"message":       "Invalid token",
+			"message":       i18n.T(r.Context(), "Invalid token"),
 		})
 		return
 	}
 
 	// Obtener información del usuario
-	userRecord, err := h.firebaseAuth.GetUser(context.Background(), decodedToken.UID)
+	userRecord, err := h.firebaseAuth.GetUser(r.Context(), decodedToken.UID)
 	if err != nil {
 		log.WithError(err).Error("Failed to get user from Firebase")
 		w.Header().Set("Content-Type", "application/json")
@@ -263,7 +296,7 @@ func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Token refresh should be handled on the client side using Firebase SDK",
+		"message": i18n.T(r.Context(), "Token refresh should be handled on the client side using Firebase SDK"),
 	})
 }
 
@@ -275,21 +308,21 @@ func (h *AuthHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value("user_id")
 	if userID == nil {
 		log.Warn("User ID not found in context")
-		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		http.Error(w, i18n.T(r.Context(), "Authentication required"), http.StatusUnauthorized)
 		return
 	}
 
 	if h.firebaseAuth == nil {
 		log.Error("Firebase Auth not configured")
-		http.Error(w, "Authentication service not available", http.StatusServiceUnavailable)
+		http.Error(w, i18n.T(r.Context(), "Authentication service not available"), http.StatusServiceUnavailable)
 		return
 	}
 
 	// Obtener información del usuario de Firebase
-	userRecord, err := h.firebaseAuth.GetUser(context.Background(), userID.(string))
+	userRecord, err := h.firebaseAuth.GetUser(r.Context(), userID.(string))
 	if err != nil {
 		log.WithError(err).Error("Failed to get user profile from Firebase")
-		http.Error(w, "Failed to get user profile", http.StatusInternalServerError)
+		http.Error(w, i18n.T(r.Context(), "Failed to get user profile"), http.StatusInternalServerError)
 		return
 	}
 
@@ -310,7 +343,7 @@ func (h *AuthHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"data":    profile,
-		"message": "Profile retrieved successfully",
+		"message": i18n.T(r.Context(), "Profile retrieved successfully"),
 	})
 }
 
@@ -322,7 +355,7 @@ func (h *AuthHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value("user_id")
 	if userID == nil {
 		log.Warn("User ID not found in context")
-		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		http.Error(w, i18n.T(r.Context(), "Authentication required"), http.StatusUnauthorized)
 		return
 	}
 
@@ -334,18 +367,18 @@ func (h *AuthHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.WithError(err).Error("Failed to read request body")
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Error reading request body"), http.StatusBadRequest)
 		return
 	}
 
 	if err := json.Unmarshal(body, &req); err != nil {
 		log.WithError(err).Error("Failed to unmarshal JSON")
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
 		return
 	}
 
 	// Validar estructura
-	if err := validator.ValidateStruct(&req); err != nil {
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
 		log.WithError(err).Warn("Validation failed for profile update")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -355,7 +388,7 @@ func (h *AuthHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Profile update functionality requires Firebase Admin SDK implementation",
+		"message": i18n.T(r.Context(), "Profile update functionality requires Firebase Admin SDK implementation"),
 	})
 }
 
@@ -367,7 +400,7 @@ func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value("user_id")
 	if userID == nil {
 		log.Warn("User ID not found in context")
-		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		http.Error(w, i18n.T(r.Context(), "Authentication required"), http.StatusUnauthorized)
 		return
 	}
 
@@ -375,7 +408,7 @@ func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Password change should be handled on the client side using Firebase SDK",
+		"message": i18n.T(r.Context(), "Password change should be handled on the client side using Firebase SDK"),
 	})
 }
 
@@ -387,29 +420,35 @@ func (h *AuthHandler) RevokeAllTokens(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value("user_id")
 	if userID == nil {
 		log.Warn("User ID not found in context")
-		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		http.Error(w, i18n.T(r.Context(), "Authentication required"), http.StatusUnauthorized)
 		return
 	}
 
 	if h.firebaseAuth == nil {
 		log.Error("Firebase Auth not configured")
-		http.Error(w, "Authentication service not available", http.StatusServiceUnavailable)
+		http.Error(w, i18n.T(r.Context(), "Authentication service not available"), http.StatusServiceUnavailable)
 		return
 	}
 
 	// Revocar todos los tokens del usuario
-	err := h.firebaseAuth.RevokeRefreshTokens(context.Background(), userID.(string))
+	err := h.firebaseAuth.RevokeRefreshTokens(r.Context(), userID.(string))
 	if err != nil {
 		log.WithError(err).Error("Failed to revoke tokens")
-		http.Error(w, "Failed to revoke tokens", http.StatusInternalServerError)
+		http.Error(w, i18n.T(r.Context(), "Failed to revoke tokens"), http.StatusInternalServerError)
 		return
 	}
 
+	audit.Record(r.Context(), audit.FromRequest(r, audit.Event{
+		Action:           audit.ActionTokensRevoked,
+		TargetFirebaseID: userID.(string),
+		Metadata:         map[string]interface{}{"endpoint": "auth"},
+	}))
+
 	log.WithField("user_id", userID).Info("All tokens revoked successfully")
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "All tokens revoked successfully",
+		"message": i18n.T(r.Context(), "All tokens revoked successfully"),
 	})
 }
 
@@ -421,7 +460,7 @@ func (h *AuthHandler) GetActiveSessions(w http.ResponseWriter, r *http.Request)
 	userID := r.Context().Value("user_id")
 	if userID == nil {
 		log.Warn("User ID not found in context")
-		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		http.Error(w, i18n.T(r.Context(), "Authentication required"), http.StatusUnauthorized)
 		return
 	}
 
@@ -429,7 +468,7 @@ func (h *AuthHandler) GetActiveSessions(w http.ResponseWriter, r *http.Request)
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Active sessions endpoint not implemented yet",
+		"message": i18n.T(r.Context(), "Active sessions endpoint not implemented yet"),
 		"data":    []interface{}{},
 	})
 }
@@ -442,7 +481,7 @@ func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value("user_id")
 	if userID == nil {
 		log.Warn("User ID not found in context")
-		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		http.Error(w, i18n.T(r.Context(), "Authentication required"), http.StatusUnauthorized)
 		return
 	}
 
@@ -450,7 +489,7 @@ func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Session revocation endpoint not implemented yet",
+		"message": i18n.T(r.Context(), "Session revocation endpoint not implemented yet"),
 	})
 }
 
@@ -464,17 +503,17 @@ func (h *AuthHandler) GoogleLogin(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.WithError(err).Error("Failed to read request body")
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Error reading request body"), http.StatusBadRequest)
 		return
 	}
 
 	if err := json.Unmarshal(body, &req); err != nil {
 		log.WithError(err).Error("Failed to unmarshal JSON")
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
 		return
 	}
 
-	if err := validator.ValidateStruct(&req); err != nil {
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
 		log.WithError(err).Warn("Validation failed for Google login")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -482,23 +521,23 @@ func (h *AuthHandler) GoogleLogin(w http.ResponseWriter, r *http.Request) {
 
 	if h.firebaseAuth == nil {
 		log.Error("Firebase Auth not configured")
-		http.Error(w, "Authentication service not available", http.StatusServiceUnavailable)
+		http.Error(w, i18n.T(r.Context(), "Authentication service not available"), http.StatusServiceUnavailable)
 		return
 	}
 
 	// Verificar token de Google a través de Firebase
-	token, err := h.firebaseAuth.VerifyIDToken(context.Background(), req.IDToken)
+	token, err := h.firebaseAuth.VerifyIDToken(r.Context(), req.IDToken)
 	if err != nil {
 		log.WithError(err).Warn("Invalid Google token")
-		http.Error(w, "Invalid Google token", http.StatusUnauthorized)
+		http.Error(w, i18n.T(r.Context(), "Invalid Google token"), http.StatusUnauthorized)
 		return
 	}
 
 	// Obtener información del usuario
-	userRecord, err := h.firebaseAuth.GetUser(context.Background(), token.UID)
+	userRecord, err := h.firebaseAuth.GetUser(r.Context(), token.UID)
 	if err != nil {
 		log.WithError(err).Error("Failed to get user from Firebase")
-		http.Error(w, "Failed to get user information", http.StatusInternalServerError)
+		http.Error(w, i18n.T(r.Context(), "Failed to get user information"), http.StatusInternalServerError)
 		return
 	}
 
@@ -513,26 +552,32 @@ func (h *AuthHandler) GoogleLogin(w http.ResponseWriter, r *http.Request) {
 
 	if !isGoogleProvider {
 		log.Warn("Token is not from Google provider")
-		http.Error(w, "Invalid Google authentication", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid Google authentication"), http.StatusBadRequest)
 		return
 	}
 
 	firstName, lastName := h.extractNames(userRecord.DisplayName)
 
+	user, created, ok := h.provisionUser(w, r, &models.User{
+		FirebaseID:    token.UID,
+		Email:         userRecord.Email,
+		EmailVerified: userRecord.EmailVerified,
+		Username:      h.generateUsername(userRecord),
+		FirstName:     firstName,
+		LastName:      lastName,
+		PhotoURL:      userRecord.PhotoURL,
+		Provider:      "google.com",
+		Status:        "active",
+	})
+	if !ok {
+		return
+	}
+
 	response := map[string]interface{}{
-		"user": map[string]interface{}{
-			"firebase_id":    token.UID,
-			"email":          userRecord.Email,
-			"email_verified": userRecord.EmailVerified,
-			"username":       h.generateUsername(userRecord),
-			"first_name":     firstName,
-			"last_name":      lastName,
-			"photo_url":      userRecord.PhotoURL,
-			"provider":       "google.com",
-			"status":         "active",
-		},
+		"user":     user,
+		"created":  created,
 		"provider": "google.com",
-		"message":  "Google login successful",
+		"message":  i18n.T(r.Context(), "Google login successful"),
 	}
 
 	log.WithFields(map[string]interface{}{
@@ -555,17 +600,17 @@ func (h *AuthHandler) FacebookLogin(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.WithError(err).Error("Failed to read request body")
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Error reading request body"), http.StatusBadRequest)
 		return
 	}
 
 	if err := json.Unmarshal(body, &req); err != nil {
 		log.WithError(err).Error("Failed to unmarshal JSON")
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
 		return
 	}
 
-	if err := validator.ValidateStruct(&req); err != nil {
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
 		log.WithError(err).Warn("Validation failed for Facebook login")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -573,23 +618,23 @@ func (h *AuthHandler) FacebookLogin(w http.ResponseWriter, r *http.Request) {
 
 	if h.firebaseAuth == nil {
 		log.Error("Firebase Auth not configured")
-		http.Error(w, "Authentication service not available", http.StatusServiceUnavailable)
+		http.Error(w, i18n.T(r.Context(), "Authentication service not available"), http.StatusServiceUnavailable)
 		return
 	}
 
 	// Verificar token de Facebook a través de Firebase
-	token, err := h.firebaseAuth.VerifyIDToken(context.Background(), req.IDToken)
+	token, err := h.firebaseAuth.VerifyIDToken(r.Context(), req.IDToken)
 	if err != nil {
 		log.WithError(err).Warn("Invalid Facebook token")
-		http.Error(w, "Invalid Facebook token", http.StatusUnauthorized)
+		http.Error(w, i18n.T(r.Context(), "Invalid Facebook token"), http.StatusUnauthorized)
 		return
 	}
 
 	// Obtener información del usuario
-	userRecord, err := h.firebaseAuth.GetUser(context.Background(), token.UID)
+	userRecord, err := h.firebaseAuth.GetUser(r.Context(), token.UID)
 	if err != nil {
 		log.WithError(err).Error("Failed to get user from Firebase")
-		http.Error(w, "Failed to get user information", http.StatusInternalServerError)
+		http.Error(w, i18n.T(r.Context(), "Failed to get user information"), http.StatusInternalServerError)
 		return
 	}
 
@@ -604,26 +649,32 @@ func (h *AuthHandler) FacebookLogin(w http.ResponseWriter, r *http.Request) {
 
 	if !isFacebookProvider {
 		log.Warn("Token is not from Facebook provider")
-		http.Error(w, "Invalid Facebook authentication", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid Facebook authentication"), http.StatusBadRequest)
 		return
 	}
 
 	firstName, lastName := h.extractNames(userRecord.DisplayName)
 
+	user, created, ok := h.provisionUser(w, r, &models.User{
+		FirebaseID:    token.UID,
+		Email:         userRecord.Email,
+		EmailVerified: userRecord.EmailVerified,
+		Username:      h.generateUsername(userRecord),
+		FirstName:     firstName,
+		LastName:      lastName,
+		PhotoURL:      userRecord.PhotoURL,
+		Provider:      "facebook.com",
+		Status:        "active",
+	})
+	if !ok {
+		return
+	}
+
 	response := map[string]interface{}{
-		"user": map[string]interface{}{
-			"firebase_id":    token.UID,
-			"email":          userRecord.Email,
-			"email_verified": userRecord.EmailVerified,
-			"username":       h.generateUsername(userRecord),
-			"first_name":     firstName,
-			"last_name":      lastName,
-			"photo_url":      userRecord.PhotoURL,
-			"provider":       "facebook.com",
-			"status":         "active",
-		},
+		"user":     user,
+		"created":  created,
 		"provider": "facebook.com",
-		"message":  "Facebook login successful",
+		"message":  i18n.T(r.Context(), "Facebook login successful"),
 	}
 
 	log.WithFields(map[string]interface{}{
@@ -646,17 +697,17 @@ func (h *AuthHandler) EmailPasswordLogin(w http.ResponseWriter, r *http.Request)
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.WithError(err).Error("Failed to read request body")
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Error reading request body"), http.StatusBadRequest)
 		return
 	}
 
 	if err := json.Unmarshal(body, &req); err != nil {
 		log.WithError(err).Error("Failed to unmarshal JSON")
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
 		return
 	}
 
-	if err := validator.ValidateStruct(&req); err != nil {
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
 		log.WithError(err).Warn("Validation failed for email login")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -664,23 +715,23 @@ func (h *AuthHandler) EmailPasswordLogin(w http.ResponseWriter, r *http.Request)
 
 	if h.firebaseAuth == nil {
 		log.Error("Firebase Auth not configured")
-		http.Error(w, "Authentication service not available", http.StatusServiceUnavailable)
+		http.Error(w, i18n.T(r.Context(), "Authentication service not available"), http.StatusServiceUnavailable)
 		return
 	}
 
 	// Verificar token de Firebase
-	token, err := h.firebaseAuth.VerifyIDToken(context.Background(), req.IDToken)
+	token, err := h.firebaseAuth.VerifyIDToken(r.Context(), req.IDToken)
 	if err != nil {
 		log.WithError(err).Warn("Invalid Firebase token")
-		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		http.Error(w, i18n.T(r.Context(), "Invalid token"), http.StatusUnauthorized)
 		return
 	}
 
 	// Obtener información del usuario
-	userRecord, err := h.firebaseAuth.GetUser(context.Background(), token.UID)
+	userRecord, err := h.firebaseAuth.GetUser(r.Context(), token.UID)
 	if err != nil {
 		log.WithError(err).Error("Failed to get user from Firebase")
-		http.Error(w, "Failed to get user information", http.StatusInternalServerError)
+		http.Error(w, i18n.T(r.Context(), "Failed to get user information"), http.StatusInternalServerError)
 		return
 	}
 
@@ -695,26 +746,32 @@ func (h *AuthHandler) EmailPasswordLogin(w http.ResponseWriter, r *http.Request)
 
 	if !isEmailProvider {
 		log.Warn("Token is not from email/password provider")
-		http.Error(w, "Invalid email/password authentication", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid email/password authentication"), http.StatusBadRequest)
 		return
 	}
 
 	firstName, lastName := h.extractNames(userRecord.DisplayName)
 
+	user, created, ok := h.provisionUser(w, r, &models.User{
+		FirebaseID:    token.UID,
+		Email:         userRecord.Email,
+		EmailVerified: userRecord.EmailVerified,
+		Username:      h.generateUsername(userRecord),
+		FirstName:     firstName,
+		LastName:      lastName,
+		PhotoURL:      userRecord.PhotoURL,
+		Provider:      "password",
+		Status:        "active",
+	})
+	if !ok {
+		return
+	}
+
 	response := map[string]interface{}{
-		"user": map[string]interface{}{
-			"firebase_id":    token.UID,
-			"email":          userRecord.Email,
-			"email_verified": userRecord.EmailVerified,
-			"username":       h.generateUsername(userRecord),
-			"first_name":     firstName,
-			"last_name":      lastName,
-			"photo_url":      userRecord.PhotoURL,
-			"provider":       "password",
-			"status":         "active",
-		},
+		"user":     user,
+		"created":  created,
 		"provider": "password",
-		"message":  "Email login successful",
+		"message":  i18n.T(r.Context(), "Email login successful"),
 	}
 
 	log.WithFields(map[string]interface{}{
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"it-app_user/internal/audit"
+	"it-app_user/internal/i18n"
+	"it-app_user/internal/logger"
+	"it-app_user/internal/metrics"
+	"it-app_user/internal/services"
+	"it-app_user/pkg/firebase"
+)
+
+// blockReasons traduce los rechazos de SignInPolicyService al motivo que
+// queda en la auditoría y en las métricas, y al mensaje que ve el usuario
+var blockReasons = []struct {
+	err     error
+	reason  string
+	message string
+}{
+	{services.ErrEmailDomainBlocked, "blocked_email_domain", "Email domain is not allowed"},
+	{services.ErrAccountDisabled, "account_disabled", "This account has been disabled"},
+	{services.ErrSignInRiskBlocked, "high_risk", "Sign-in blocked for security reasons"},
+}
+
+type BlockingHandler struct {
+	verifier *firebase.BlockingTokenVerifier
+	policy   *services.SignInPolicyService
+}
+
+func NewBlockingHandler(verifier *firebase.BlockingTokenVerifier, policy *services.SignInPolicyService) *BlockingHandler {
+	return &BlockingHandler{
+		verifier: verifier,
+		policy:   policy,
+	}
+}
+
+// BeforeCreate maneja POST /auth/blocking/before-create: blocking function
+// beforeCreate de Identity Platform
+func (h *BlockingHandler) BeforeCreate(w http.ResponseWriter, r *http.Request) {
+	h.handle(w, r, firebase.BlockingEventBeforeCreate, h.policy.BeforeCreate)
+}
+
+// BeforeSignIn maneja POST /auth/blocking/before-sign-in: blocking function
+// beforeSignIn de Identity Platform
+func (h *BlockingHandler) BeforeSignIn(w http.ResponseWriter, r *http.Request) {
+	h.handle(w, r, firebase.BlockingEventBeforeSignIn, h.policy.BeforeSignIn)
+}
+
+// handle verifica el JWT del evento, aplica la política y responde con el
+// formato de las blocking functions: los cambios al usuario si se permite, o
+// un error que Identity Platform devuelve al cliente si se rechaza
+func (h *BlockingHandler) handle(w http.ResponseWriter, r *http.Request, eventType string, apply func(context.Context, *firebase.BlockingEvent) (*services.SignInDecision, error)) {
+	log := logger.GetLogger().WithField("event_type", eventType)
+
+	var req firebase.BlockingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Data.JWT == "" {
+		metrics.BlockingDecisions.WithLabelValues(eventType, "invalid_request").Inc()
+		writeBlockingError(w, http.StatusBadRequest, "INVALID_ARGUMENT", i18n.T(r.Context(), "Invalid JSON format"))
+		return
+	}
+
+	event, err := h.verifier.Verify(r.Context(), req.Data.JWT, eventType)
+	if err != nil {
+		log.WithError(err).Warn("Invalid blocking function token")
+		metrics.BlockingDecisions.WithLabelValues(eventType, "invalid_token").Inc()
+		writeBlockingError(w, http.StatusUnauthorized, "UNAUTHENTICATED", i18n.T(r.Context(), "Invalid blocking function token"))
+		return
+	}
+
+	// El mensaje de rechazo lo ve el usuario: se traduce al idioma de su cliente
+	ctx := r.Context()
+	if event.Locale != "" && i18n.IsSupported(event.Locale) {
+		ctx = i18n.WithLanguage(ctx, i18n.Normalize(event.Locale))
+	}
+	log = log.WithFields(map[string]interface{}{
+		"event_id":       event.EventID,
+		"firebase_id":    event.Subject,
+		"sign_in_method": event.SignInMethod,
+	})
+
+	decision, err := apply(ctx, event)
+	if err != nil {
+		for _, block := range blockReasons {
+			if !errors.Is(err, block.err) {
+				continue
+			}
+			h.recordBlocked(ctx, event, decision, block.reason)
+			log.WithField("reason", block.reason).Info("Blocking function rejected the event")
+			metrics.BlockingDecisions.WithLabelValues(eventType, block.reason).Inc()
+			writeBlockingError(w, http.StatusForbidden, "PERMISSION_DENIED", i18n.T(ctx, block.message))
+			return
+		}
+
+		// Sin poder aplicar las políticas el evento se rechaza
+		log.WithError(err).Error("Failed to apply sign-in policies")
+		metrics.BlockingDecisions.WithLabelValues(eventType, "error").Inc()
+		writeBlockingError(w, http.StatusInternalServerError, "INTERNAL", i18n.T(ctx, "Error applying sign-in policies"))
+		return
+	}
+
+	log.WithField("risk_level", decision.Risk.Level).Info("Blocking function allowed the event")
+	metrics.BlockingDecisions.WithLabelValues(eventType, "allowed").Inc()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(firebase.BlockingResponse{UserRecord: decision.Update})
+}
+
+// recordBlocked audita un registro o inicio de sesión rechazado, con la IP y
+// el User-Agent del usuario (la request la hace Identity Platform)
+func (h *BlockingHandler) recordBlocked(ctx context.Context, event *firebase.BlockingEvent, decision *services.SignInDecision, reason string) {
+	metadata := map[string]interface{}{
+		"event_type":     event.EventType,
+		"event_id":       event.EventID,
+		"reason":         reason,
+		"sign_in_method": event.SignInMethod,
+	}
+	if decision.Risk.Level != "" {
+		metadata["risk_level"] = decision.Risk.Level
+		metadata["risk_reasons"] = decision.Risk.Reasons
+	}
+	if at := strings.LastIndex(event.UserRecord.Email, "@"); at >= 0 {
+		metadata["email_domain"] = strings.ToLower(event.UserRecord.Email[at+1:])
+	}
+
+	auditEvent := audit.Event{
+		Action:           audit.ActionAuthBlocked,
+		ActorID:          event.Subject,
+		TargetFirebaseID: event.Subject,
+		IP:               event.IPAddress,
+		UserAgent:        event.UserAgent,
+		Metadata:         metadata,
+	}
+	if decision.LocalUser != nil {
+		auditEvent.TargetUserID = decision.LocalUser.ID
+	}
+	audit.Record(ctx, auditEvent)
+}
+
+// writeBlockingError responde con el formato de error de las blocking functions
+func writeBlockingError(w http.ResponseWriter, code int, status, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]interface{}{
+			"status":  status,
+			"message": message,
+		},
+	})
+}
@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"gorm.io/gorm"
+
+	"it-app_user/internal/audit"
+	"it-app_user/internal/models"
+	"it-app_user/internal/repositories"
+	"it-app_user/internal/services"
+	"it-app_user/pkg/firebase"
+)
+
+const (
+	blockingTestProject = "innovatech-test"
+	blockingTestKeyID   = "test-key"
+	blockingTestURL     = "https://us-central1-innovatech-test.cloudfunctions.net/user-api/auth/blocking/"
+)
+
+var blockingTestKey = func() *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}()
+
+// blockingUserRepo devuelve los usuarios locales de las pruebas; el resto de
+// los métodos no se usan en las blocking functions
+type blockingUserRepo struct {
+	repositories.UserRepositoryInterface
+	users   []models.User
+	deleted []models.User
+}
+
+func (r *blockingUserRepo) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	for i := range r.users {
+		if r.users[i].Email == email {
+			return &r.users[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *blockingUserRepo) GetByFirebaseID(ctx context.Context, firebaseID string) (*models.User, error) {
+	for i := range r.users {
+		if r.users[i].FirebaseID == firebaseID {
+			return &r.users[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *blockingUserRepo) GetDeletedByFirebaseID(ctx context.Context, firebaseID string) (*models.User, error) {
+	for i := range r.deleted {
+		if r.deleted[i].FirebaseID == firebaseID {
+			return &r.deleted[i], nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// newTestBlockingHandler arma el handler con claves de prueba, el dominio
+// tempmail.org bloqueado y la red 198.51.100.0/24 de riesgo alto
+func newTestBlockingHandler(t *testing.T, repo *blockingUserRepo) (*BlockingHandler, *auditRecorder) {
+	t.Helper()
+	verifier := firebase.NewBlockingTokenVerifier(blockingTestProject, []string{
+		blockingTestURL + "before-create",
+		blockingTestURL + "before-sign-in",
+	})
+	verifier.SetKeySource(func(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+		return map[string]*rsa.PublicKey{blockingTestKeyID: &blockingTestKey.PublicKey}, nil
+	})
+	risk, err := services.NewRiskService(models.DefaultEmailVerificationSettings([]string{"tempmail.org"}), []string{"198.51.100.0/24"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := &auditRecorder{}
+	audit.SetStore(recorder)
+	t.Cleanup(func() { audit.SetStore(nil) })
+	return NewBlockingHandler(verifier, services.NewSignInPolicyService(repo, risk)), recorder
+}
+
+// blockingRequest firma un evento de Identity Platform y arma la request
+func blockingRequest(t *testing.T, eventType, path string, record firebase.BlockingUserRecord, ip string) *http.Request {
+	t.Helper()
+	now := time.Now()
+	event := &firebase.BlockingEvent{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "https://securetoken.google.com/" + blockingTestProject,
+			Audience:  jwt.ClaimStrings{blockingTestURL + path},
+			Subject:   record.UID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+		EventID:    "evt-1",
+		EventType:  eventType,
+		IPAddress:  ip,
+		UserAgent:  "Mozilla/5.0",
+		UserRecord: record,
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, event)
+	token.Header["kid"] = blockingTestKeyID
+	signed, err := token.SignedString(blockingTestKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var body bytes.Buffer
+	req := firebase.BlockingRequest{}
+	req.Data.JWT = signed
+	if err := json.NewEncoder(&body).Encode(req); err != nil {
+		t.Fatal(err)
+	}
+	return httptest.NewRequest(http.MethodPost, "/auth/blocking/"+path, &body)
+}
+
+func TestBlockingHandlerRejects(t *testing.T) {
+	repo := &blockingUserRepo{
+		users: []models.User{
+			{ID: 7, FirebaseID: "uid-disabled", Email: "eva@example.com", Disabled: true},
+		},
+		deleted: []models.User{
+			{ID: 9, FirebaseID: "uid-deleted", Email: "leo@example.com"},
+		},
+	}
+	tests := []struct {
+		name      string
+		signIn    bool
+		record    firebase.BlockingUserRecord
+		ip        string
+		reason    string
+		targetID  uint
+		riskLevel string
+	}{
+		{
+			name:      "blocked email domain",
+			record:    firebase.BlockingUserRecord{UID: "uid-new", Email: "bot@mail.tempmail.org"},
+			ip:        "203.0.113.9",
+			reason:    "blocked_email_domain",
+			riskLevel: services.RiskLevelHigh,
+		},
+		{
+			name:     "sign up with the email of a disabled local user",
+			record:   firebase.BlockingUserRecord{UID: "uid-new", Email: "eva@example.com"},
+			ip:       "203.0.113.9",
+			reason:   "account_disabled",
+			targetID: 7,
+		},
+		{
+			name:     "disabled local user",
+			signIn:   true,
+			record:   firebase.BlockingUserRecord{UID: "uid-disabled", Email: "eva@example.com"},
+			ip:       "203.0.113.9",
+			reason:   "account_disabled",
+			targetID: 7,
+		},
+		{
+			name:     "soft-deleted local user",
+			signIn:   true,
+			record:   firebase.BlockingUserRecord{UID: "uid-deleted", Email: "leo@example.com"},
+			ip:       "203.0.113.9",
+			reason:   "account_disabled",
+			targetID: 9,
+		},
+		{
+			name:      "risk block decision",
+			signIn:    true,
+			record:    firebase.BlockingUserRecord{UID: "uid-new", Email: "ana@example.com"},
+			ip:        "198.51.100.20",
+			reason:    "high_risk",
+			riskLevel: services.RiskLevelHigh,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, recorder := newTestBlockingHandler(t, repo)
+			serve, eventType, path := handler.BeforeCreate, firebase.BlockingEventBeforeCreate, "before-create"
+			if tt.signIn {
+				serve, eventType, path = handler.BeforeSignIn, firebase.BlockingEventBeforeSignIn, "before-sign-in"
+			}
+			w := httptest.NewRecorder()
+			serve(w, blockingRequest(t, eventType, path, tt.record, tt.ip))
+
+			if w.Code != http.StatusForbidden {
+				t.Fatalf("status = %d, want 403: %s", w.Code, w.Body)
+			}
+			var resp struct {
+				Error struct {
+					Status string `json:"status"`
+				} `json:"error"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil || resp.Error.Status != "PERMISSION_DENIED" {
+				t.Fatalf("error status = %q (%v), want PERMISSION_DENIED", resp.Error.Status, err)
+			}
+
+			if len(recorder.events) != 1 {
+				t.Fatalf("recorded %d audit events, want 1", len(recorder.events))
+			}
+			event := recorder.events[0]
+			if event.Action != audit.ActionAuthBlocked || event.Metadata["reason"] != tt.reason || event.TargetUserID != tt.targetID {
+				t.Errorf("audit event = %+v, want %s for user %d", event, tt.reason, tt.targetID)
+			}
+			if level, _ := event.Metadata["risk_level"].(string); tt.riskLevel != "" && level != tt.riskLevel {
+				t.Errorf("risk_level = %q, want %q", level, tt.riskLevel)
+			}
+		})
+	}
+}
+
+func TestBlockingHandlerInjectsClaims(t *testing.T) {
+	repo := &blockingUserRepo{
+		users: []models.User{{ID: 42, FirebaseID: "uid-ana", Email: "ana@example.com"}},
+	}
+	handler, recorder := newTestBlockingHandler(t, repo)
+
+	t.Run("beforeCreate keeps custom claims and adds the role", func(t *testing.T) {
+		record := firebase.BlockingUserRecord{
+			UID:          "uid-new",
+			Email:        "new@example.com",
+			CustomClaims: map[string]interface{}{"admin": true, "team": "ops"},
+		}
+		w := httptest.NewRecorder()
+		handler.BeforeCreate(w, blockingRequest(t, firebase.BlockingEventBeforeCreate, "before-create", record, "203.0.113.9"))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+		}
+
+		var resp firebase.BlockingResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		update := resp.UserRecord
+		if update == nil || update.UpdateMask != "customClaims" || update.SessionClaims != nil {
+			t.Fatalf("userRecord = %+v, want a customClaims update", update)
+		}
+		want := map[string]interface{}{"admin": true, "team": "ops", "role": "admin"}
+		if len(update.CustomClaims) != len(want) {
+			t.Fatalf("customClaims = %v, want %v", update.CustomClaims, want)
+		}
+		for key, value := range want {
+			if update.CustomClaims[key] != value {
+				t.Errorf("customClaims[%s] = %v, want %v", key, update.CustomClaims[key], value)
+			}
+		}
+	})
+
+	t.Run("beforeSignIn adds role and local user ID as session claims", func(t *testing.T) {
+		record := firebase.BlockingUserRecord{
+			UID:          "uid-ana",
+			Email:        "ana@example.com",
+			CustomClaims: map[string]interface{}{"role": "editor"},
+		}
+		w := httptest.NewRecorder()
+		handler.BeforeSignIn(w, blockingRequest(t, firebase.BlockingEventBeforeSignIn, "before-sign-in", record, "203.0.113.9"))
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+		}
+
+		var resp firebase.BlockingResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		update := resp.UserRecord
+		if update == nil || update.UpdateMask != "sessionClaims" || update.CustomClaims != nil {
+			t.Fatalf("userRecord = %+v, want a sessionClaims update", update)
+		}
+		// Los números llegan como float64 al decodificar el JSON
+		if update.SessionClaims["role"] != "editor" || update.SessionClaims["local_user_id"] != float64(42) {
+			t.Errorf("sessionClaims = %v, want role editor and local_user_id 42", update.SessionClaims)
+		}
+	})
+
+	if len(recorder.events) != 0 {
+		t.Errorf("recorded %d audit events for allowed events, want 0", len(recorder.events))
+	}
+}
+
+func TestBlockingHandlerRejectsTokenForOtherFunction(t *testing.T) {
+	handler, recorder := newTestBlockingHandler(t, &blockingUserRepo{})
+	record := firebase.BlockingUserRecord{UID: "uid-ana", Email: "ana@example.com"}
+
+	// Token de beforeSignIn enviado a before-create: el tipo de evento no coincide
+	w := httptest.NewRecorder()
+	handler.BeforeCreate(w, blockingRequest(t, firebase.BlockingEventBeforeSignIn, "before-sign-in", record, "203.0.113.9"))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status = %d, want 401", w.Code)
+	}
+	if len(recorder.events) != 0 {
+		t.Errorf("recorded %d audit events for an invalid token, want 0", len(recorder.events))
+	}
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+
+	"it-app_user/internal/clientip"
+	"it-app_user/internal/i18n"
+	"it-app_user/internal/logger"
+	"it-app_user/internal/services"
+)
+
+type ExportHandler struct {
+	exportService *services.DataExportService
+}
+
+func NewExportHandler(exportService *services.DataExportService) *ExportHandler {
+	return &ExportHandler{exportService: exportService}
+}
+
+// RequestExport maneja POST /me/export: solicita la exportación de los datos
+// del usuario autenticado. Responde 202 con la exportación creada o 200 con la
+// que ya estaba en curso o disponible.
+func (h *ExportHandler) RequestExport(w http.ResponseWriter, r *http.Request) {
+	log := logger.GetLogger()
+
+	uid, ok := r.Context().Value("user_id").(string)
+	if !ok || uid == "" {
+		http.Error(w, i18n.T(r.Context(), "Authentication required"), http.StatusUnauthorized)
+		return
+	}
+
+	export, created, err := h.exportService.Request(r.Context(), uid, clientip.FromRequest(r))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, i18n.T(r.Context(), "User not found"), http.StatusNotFound)
+			return
+		}
+		log.WithError(err).WithField("firebase_id", uid).Error("Failed to request data export")
+		http.Error(w, i18n.T(r.Context(), "Error requesting data export"), http.StatusInternalServerError)
+		return
+	}
+
+	status := http.StatusOK
+	message := "Data export already requested"
+	if created {
+		status = http.StatusAccepted
+		message = "Data export requested successfully"
+		log.WithField("export_id", export.ID).Info("Data export requested")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", fmt.Sprintf("/me/export/%d", export.ID))
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    export,
+		"message": i18n.T(r.Context(), message),
+	})
+}
+
+// GetExport maneja GET /me/export/{id}: devuelve el estado de la exportación
+// y, si está completa, un link de descarga firmado que expira
+func (h *ExportHandler) GetExport(w http.ResponseWriter, r *http.Request) {
+	log := logger.GetLogger()
+
+	uid, ok := r.Context().Value("user_id").(string)
+	if !ok || uid == "" {
+		http.Error(w, i18n.T(r.Context(), "Authentication required"), http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, i18n.T(r.Context(), "Invalid export ID"), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.exportService.Get(r.Context(), uid, uint(id), clientip.FromRequest(r))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, i18n.T(r.Context(), "Data export not found"), http.StatusNotFound)
+			return
+		}
+		log.WithError(err).WithField("export_id", id).Error("Failed to get data export")
+		http.Error(w, i18n.T(r.Context(), "Error retrieving data export"), http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]interface{}{
+		"id":           result.Export.ID,
+		"status":       result.Export.Status,
+		"size_bytes":   result.Export.SizeBytes,
+		"created_at":   result.Export.CreatedAt,
+		"completed_at": result.Export.CompletedAt,
+		"expires_at":   result.Export.ExpiresAt,
+	}
+	if result.DownloadURL != "" {
+		data["download_url"] = result.DownloadURL
+		data["download_url_expires_at"] = result.URLExpiresAt
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    data,
+		"message": i18n.T(r.Context(), "Data export retrieved successfully"),
+	})
+}
@@ -7,18 +7,24 @@ import (
 	"strconv"
 
 	"github.com/gorilla/mux"
+	"it-app_user/internal/audit"
+	"it-app_user/internal/clientip"
+	"it-app_user/internal/i18n"
 	"it-app_user/internal/logger"
+	"it-app_user/internal/services"
 	"it-app_user/internal/validator"
 	"it-app_user/pkg/firebase"
 )
 
 type LoginHandler struct {
 	firebaseAuth *firebase.Auth
+	riskService  *services.RiskService
 }
 
-func NewLoginHandler(firebaseAuth *firebase.Auth) *LoginHandler {
+func NewLoginHandler(firebaseAuth *firebase.Auth, riskService *services.RiskService) *LoginHandler {
 	return &LoginHandler{
 		firebaseAuth: firebaseAuth,
+		riskService:  riskService,
 	}
 }
 
@@ -30,41 +36,40 @@ func (h *LoginHandler) UpdateLoginInfo(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
 		log.WithError(err).Warn("Invalid user ID provided for login update")
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid user ID"), http.StatusBadRequest)
 		return
 	}
 
 	var req struct {
-		LoginIP     string `json:"login_ip" validate:"omitempty,ip"`
 		LoginDevice string `json:"login_device" validate:"max=255"`
 	}
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.WithError(err).Error("Failed to read request body")
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Error reading request body"), http.StatusBadRequest)
 		return
 	}
 
 	if err := json.Unmarshal(body, &req); err != nil {
 		log.WithError(err).Error("Failed to unmarshal JSON")
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
 		return
 	}
 
 	// Validar estructura
-	if err := validator.ValidateStruct(&req); err != nil {
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
 		log.WithError(err).Warn("Validation failed for login info update")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	// Mock login info update - en producción esto se enviaría a otro servicio
-	log.WithField("user_id", id).WithField("login_ip", req.LoginIP).WithField("login_device", req.LoginDevice).Info("Login info updated successfully (mock)")
+	log.WithField("user_id", id).WithField("login_ip", clientip.FromRequest(r)).WithField("login_device", req.LoginDevice).Info("Login info updated successfully (mock)")
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Login info updated successfully",
+		"message": i18n.T(r.Context(), "Login info updated successfully"),
 	})
 }
 
@@ -74,7 +79,6 @@ func (h *LoginHandler) TrackUserLogin(w http.ResponseWriter, r *http.Request) {
 	
 	var req struct {
 		UserID      int    `json:"user_id" validate:"required"`
-		LoginIP     string `json:"login_ip" validate:"omitempty,ip"`
 		LoginDevice string `json:"login_device" validate:"max=255"`
 		LoginMethod string `json:"login_method" validate:"required,oneof=firebase google email"`
 	}
@@ -82,18 +86,18 @@ func (h *LoginHandler) TrackUserLogin(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.WithError(err).Error("Failed to read request body")
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Error reading request body"), http.StatusBadRequest)
 		return
 	}
 
 	if err := json.Unmarshal(body, &req); err != nil {
 		log.WithError(err).Error("Failed to unmarshal JSON")
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
 		return
 	}
 
 	// Validar estructura
-	if err := validator.ValidateStruct(&req); err != nil {
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
 		log.WithError(err).Warn("Validation failed for track login request")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -102,14 +106,14 @@ func (h *LoginHandler) TrackUserLogin(w http.ResponseWriter, r *http.Request) {
 	// Mock login tracking - en producción esto se enviaría a otro servicio
 	log.WithFields(map[string]interface{}{
 		"user_id":      req.UserID,
-		"login_ip":     req.LoginIP,
+		"login_ip":     clientip.FromRequest(r),
 		"login_device": req.LoginDevice,
 		"login_method": req.LoginMethod,
 	}).Info("User login tracked successfully (mock)")
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Login tracked successfully",
+		"message": i18n.T(r.Context(), "Login tracked successfully"),
 	})
 }
 
@@ -121,7 +125,7 @@ func (h *LoginHandler) GetLoginHistory(w http.ResponseWriter, r *http.Request) {
 	userID, err := strconv.Atoi(userIDStr)
 	if err != nil {
 		log.WithError(err).Warn("Invalid user ID provided for login history")
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid user ID"), http.StatusBadRequest)
 		return
 	}
 
@@ -153,7 +157,7 @@ func (h *LoginHandler) GetLoginHistory(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"data":    loginHistory,
 		"count":   len(loginHistory),
-		"message": "Login history retrieved successfully",
+		"message": i18n.T(r.Context(), "Login history retrieved successfully"),
 	})
 }
 
@@ -164,7 +168,7 @@ func (h *LoginHandler) GetLoginAttempts(w http.ResponseWriter, r *http.Request)
 	email := vars["email"]
 	if email == "" {
 		log.Warn("Email is required for login attempts")
-		http.Error(w, "Email is required", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Email is required"), http.StatusBadRequest)
 		return
 	}
 
@@ -193,7 +197,7 @@ func (h *LoginHandler) GetLoginAttempts(w http.ResponseWriter, r *http.Request)
 		"data":    attempts,
 		"count":   len(attempts),
 		"email":   email,
-		"message": "Login attempts retrieved successfully",
+		"message": i18n.T(r.Context(), "Login attempts retrieved successfully"),
 	})
 }
 
@@ -210,39 +214,58 @@ func (h *LoginHandler) SecurityCheck(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.WithError(err).Error("Failed to read request body")
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Error reading request body"), http.StatusBadRequest)
 		return
 	}
 
 	if err := json.Unmarshal(body, &req); err != nil {
 		log.WithError(err).Error("Failed to unmarshal JSON")
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
 		return
 	}
 
 	// Validar estructura
-	if err := validator.ValidateStruct(&req); err != nil {
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
 		log.WithError(err).Warn("Validation failed for security check request")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Mock security check
+	// La evaluación de riesgo usa la IP real del cliente salvo que se indique otra
+	ipAddress := req.IPAddress
+	if ipAddress == "" {
+		ipAddress = clientip.FromRequest(r)
+	}
+
+	userAgent := req.UserAgent
+	if userAgent == "" {
+		userAgent = r.UserAgent()
+	}
+
+	// Mismas reglas que aplican las blocking functions, sin consultar al usuario
+	// local para no revelar datos de la cuenta a un cliente sin autenticar
+	assessment := h.riskService.Evaluate(services.RiskSignals{
+		Email:     req.Email,
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+	})
 	securityCheck := map[string]interface{}{
-		"is_safe":           true,
-		"risk_level":        "low",
-		"blocked":           false,
-		"requires_2fa":      false,
-		"suspicious_activity": false,
-		"recommendations":   []string{},
+		"ip_address":          ipAddress,
+		"is_safe":             assessment.Level == services.RiskLevelLow,
+		"risk_level":          assessment.Level,
+		"blocked":             assessment.Blocked,
+		"reasons":             assessment.Reasons,
+		"requires_2fa":        false,
+		"suspicious_activity": assessment.Level != services.RiskLevelLow,
+		"recommendations":     []string{},
 	}
 
-	log.WithField("email", req.Email).Info("Security check completed")
+	log.WithField("email", req.Email).WithField("ip_address", ipAddress).WithField("risk_level", assessment.Level).Info("Security check completed")
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"data":    securityCheck,
-		"message": "Security check completed",
+		"message": i18n.T(r.Context(), "Security check completed"),
 	})
 }
 
@@ -254,7 +277,7 @@ func (h *LoginHandler) GetMyLoginHistory(w http.ResponseWriter, r *http.Request)
 	userID := r.Context().Value("user_id")
 	if userID == nil {
 		log.Warn("User ID not found in context")
-		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		http.Error(w, i18n.T(r.Context(), "Authentication required"), http.StatusUnauthorized)
 		return
 	}
 
@@ -304,7 +327,7 @@ func (h *LoginHandler) GetMyLoginHistory(w http.ResponseWriter, r *http.Request)
 		"count":   len(loginHistory),
 		"limit":   limit,
 		"offset":  offset,
-		"message": "Login history retrieved successfully",
+		"message": i18n.T(r.Context(), "Login history retrieved successfully"),
 	})
 }
 
@@ -316,7 +339,7 @@ func (h *LoginHandler) GetActiveSessions(w http.ResponseWriter, r *http.Request)
 	userID := r.Context().Value("user_id")
 	if userID == nil {
 		log.Warn("User ID not found in context")
-		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		http.Error(w, i18n.T(r.Context(), "Authentication required"), http.StatusUnauthorized)
 		return
 	}
 
@@ -348,7 +371,7 @@ func (h *LoginHandler) GetActiveSessions(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"data":    activeSessions,
 		"count":   len(activeSessions),
-		"message": "Active sessions retrieved successfully",
+		"message": i18n.T(r.Context(), "Active sessions retrieved successfully"),
 	})
 }
 
@@ -360,7 +383,7 @@ func (h *LoginHandler) TerminateSession(w http.ResponseWriter, r *http.Request)
 	userID := r.Context().Value("user_id")
 	if userID == nil {
 		log.Warn("User ID not found in context")
-		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		http.Error(w, i18n.T(r.Context(), "Authentication required"), http.StatusUnauthorized)
 		return
 	}
 
@@ -368,7 +391,7 @@ func (h *LoginHandler) TerminateSession(w http.ResponseWriter, r *http.Request)
 	sessionID := vars["session_id"]
 	if sessionID == "" {
 		log.Warn("Session ID is required")
-		http.Error(w, "Session ID is required", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Session ID is required"), http.StatusBadRequest)
 		return
 	}
 
@@ -379,7 +402,7 @@ func (h *LoginHandler) TerminateSession(w http.ResponseWriter, r *http.Request)
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Session terminated successfully",
+		"message": i18n.T(r.Context(), "Session terminated successfully"),
 	})
 }
 
@@ -391,7 +414,7 @@ func (h *LoginHandler) TerminateAllSessions(w http.ResponseWriter, r *http.Reque
 	userID := r.Context().Value("user_id")
 	if userID == nil {
 		log.Warn("User ID not found in context")
-		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		http.Error(w, i18n.T(r.Context(), "Authentication required"), http.StatusUnauthorized)
 		return
 	}
 
@@ -400,16 +423,22 @@ func (h *LoginHandler) TerminateAllSessions(w http.ResponseWriter, r *http.Reque
 		err := h.firebaseAuth.RevokeRefreshTokens(r.Context(), userID.(string))
 		if err != nil {
 			log.WithError(err).Error("Failed to revoke Firebase tokens")
-			http.Error(w, "Failed to terminate sessions", http.StatusInternalServerError)
+			http.Error(w, i18n.T(r.Context(), "Failed to terminate sessions"), http.StatusInternalServerError)
 			return
 		}
+
+		audit.Record(r.Context(), audit.FromRequest(r, audit.Event{
+			Action:           audit.ActionTokensRevoked,
+			TargetFirebaseID: userID.(string),
+			Metadata:         map[string]interface{}{"endpoint": "login"},
+		}))
 	}
 
 	log.WithField("user_id", userID).Info("All sessions terminated successfully")
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "All sessions terminated successfully",
+		"message": i18n.T(r.Context(), "All sessions terminated successfully"),
 	})
 }
 
@@ -421,7 +450,7 @@ func (h *LoginHandler) GetSuspiciousActivity(w http.ResponseWriter, r *http.Requ
 	userID := r.Context().Value("user_id")
 	if userID == nil {
 		log.Warn("User ID not found in context")
-		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		http.Error(w, i18n.T(r.Context(), "Authentication required"), http.StatusUnauthorized)
 		return
 	}
 
@@ -453,6 +482,6 @@ func (h *LoginHandler) GetSuspiciousActivity(w http.ResponseWriter, r *http.Requ
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"data":    suspiciousActivity,
 		"count":   len(suspiciousActivity),
-		"message": "Suspicious activity retrieved successfully",
+		"message": i18n.T(r.Context(), "Suspicious activity retrieved successfully"),
 	})
 }
\ No newline at end of file
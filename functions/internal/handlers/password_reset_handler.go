@@ -1,11 +1,11 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
 	"io"
 	"net/http"
 
+	"it-app_user/internal/i18n"
 	"it-app_user/internal/logger"
 	"it-app_user/internal/models"
 	"it-app_user/internal/repositories"
@@ -33,18 +33,18 @@ func (h *PasswordResetHandler) RequestPasswordReset(w http.ResponseWriter, r *ht
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.WithError(err).Error("Failed to read request body")
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Error reading request body"), http.StatusBadRequest)
 		return
 	}
 
 	if err := json.Unmarshal(body, &req); err != nil {
 		log.WithError(err).Error("Failed to unmarshal JSON")
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
 		return
 	}
 
 	// Validar estructura
-	if err := validator.ValidateStruct(&req); err != nil {
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
 		log.WithError(err).Warn("Validation failed for password reset request")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -52,7 +52,7 @@ func (h *PasswordResetHandler) RequestPasswordReset(w http.ResponseWriter, r *ht
 
 	if h.firebaseAuth == nil {
 		log.Error("Firebase Auth not configured")
-		http.Error(w, "Authentication service not available", http.StatusServiceUnavailable)
+		http.Error(w, i18n.T(r.Context(), "Authentication service not available"), http.StatusServiceUnavailable)
 		return
 	}
 
@@ -62,7 +62,7 @@ func (h *PasswordResetHandler) RequestPasswordReset(w http.ResponseWriter, r *ht
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "If the email exists, a password reset link has been sent",
+		"message": i18n.T(r.Context(), "If the email exists, a password reset link has been sent"),
 	})
 }
 
@@ -74,18 +74,18 @@ func (h *PasswordResetHandler) ConfirmPasswordReset(w http.ResponseWriter, r *ht
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.WithError(err).Error("Failed to read request body")
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Error reading request body"), http.StatusBadRequest)
 		return
 	}
 
 	if err := json.Unmarshal(body, &req); err != nil {
 		log.WithError(err).Error("Failed to unmarshal JSON")
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
 		return
 	}
 
 	// Validar estructura
-	if err := validator.ValidateStruct(&req); err != nil {
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
 		log.WithError(err).Warn("Validation failed for password reset confirm request")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -93,7 +93,7 @@ func (h *PasswordResetHandler) ConfirmPasswordReset(w http.ResponseWriter, r *ht
 
 	if h.firebaseAuth == nil {
 		log.Error("Firebase Auth not configured")
-		http.Error(w, "Authentication service not available", http.StatusServiceUnavailable)
+		http.Error(w, i18n.T(r.Context(), "Authentication service not available"), http.StatusServiceUnavailable)
 		return
 	}
 
@@ -104,7 +104,7 @@ func (h *PasswordResetHandler) ConfirmPasswordReset(w http.ResponseWriter, r *ht
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Password reset completed successfully",
+		"message": i18n.T(r.Context(), "Password reset completed successfully"),
 	})
 }
 
@@ -116,18 +116,18 @@ func (h *PasswordResetHandler) ChangePassword(w http.ResponseWriter, r *http.Req
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.WithError(err).Error("Failed to read request body")
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Error reading request body"), http.StatusBadRequest)
 		return
 	}
 
 	if err := json.Unmarshal(body, &req); err != nil {
 		log.WithError(err).Error("Failed to unmarshal JSON")
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
 		return
 	}
 
 	// Validar estructura
-	if err := validator.ValidateStruct(&req); err != nil {
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
 		log.WithError(err).Warn("Validation failed for change password request")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -135,7 +135,7 @@ func (h *PasswordResetHandler) ChangePassword(w http.ResponseWriter, r *http.Req
 
 	if h.firebaseAuth == nil {
 		log.Error("Firebase Auth not configured")
-		http.Error(w, "Authentication service not available", http.StatusServiceUnavailable)
+		http.Error(w, i18n.T(r.Context(), "Authentication service not available"), http.StatusServiceUnavailable)
 		return
 	}
 
@@ -143,15 +143,15 @@ func (h *PasswordResetHandler) ChangePassword(w http.ResponseWriter, r *http.Req
 	userID := r.Context().Value("user_id")
 	if userID == nil {
 		log.Warn("User ID not found in context")
-		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		http.Error(w, i18n.T(r.Context(), "Authentication required"), http.StatusUnauthorized)
 		return
 	}
 
 	// Verificar token actual
-	_, err = h.firebaseAuth.VerifyIDToken(context.Background(), req.CurrentToken)
+	_, err = h.firebaseAuth.VerifyIDToken(r.Context(), req.CurrentToken)
 	if err != nil {
 		log.WithError(err).Warn("Invalid current token")
-		http.Error(w, "Invalid current token", http.StatusUnauthorized)
+		http.Error(w, i18n.T(r.Context(), "Invalid current token"), http.StatusUnauthorized)
 		return
 	}
 
@@ -159,7 +159,7 @@ func (h *PasswordResetHandler) ChangePassword(w http.ResponseWriter, r *http.Req
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Password changed successfully",
+		"message": i18n.T(r.Context(), "Password changed successfully"),
 	})
 }
 
@@ -173,28 +173,28 @@ func (h *PasswordResetHandler) VerifyResetCode(w http.ResponseWriter, r *http.Re
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.WithError(err).Error("Failed to read request body")
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Error reading request body"), http.StatusBadRequest)
 		return
 	}
 
 	if err := json.Unmarshal(body, &req); err != nil {
 		log.WithError(err).Error("Failed to unmarshal JSON")
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
 		return
 	}
 
 	// Validar estructura
-	if err := validator.ValidateStruct(&req); err != nil {
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
 		log.WithError(err).Warn("Validation failed for reset code verification")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	// Buscar token por código
-	resetToken, err := h.passwordRepo.GetByCode(req.Code)
+	resetToken, err := h.passwordRepo.GetByCode(r.Context(), req.Code)
 	if err != nil {
 		log.WithError(err).WithField("code", req.Code).Warn("Invalid or expired reset code")
-		http.Error(w, "Invalid or expired reset code", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid or expired reset code"), http.StatusBadRequest)
 		return
 	}
 
@@ -203,7 +203,7 @@ func (h *PasswordResetHandler) VerifyResetCode(w http.ResponseWriter, r *http.Re
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"valid":   true,
-		"message": "Reset code is valid",
+		"message": i18n.T(r.Context(), "Reset code is valid"),
 	})
 }
 
@@ -217,28 +217,28 @@ func (h *PasswordResetHandler) ValidateResetToken(w http.ResponseWriter, r *http
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.WithError(err).Error("Failed to read request body")
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Error reading request body"), http.StatusBadRequest)
 		return
 	}
 
 	if err := json.Unmarshal(body, &req); err != nil {
 		log.WithError(err).Error("Failed to unmarshal JSON")
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
 		return
 	}
 
 	// Validar estructura
-	if err := validator.ValidateStruct(&req); err != nil {
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
 		log.WithError(err).Warn("Validation failed for token validation")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	// Buscar token
-	resetToken, err := h.passwordRepo.GetByToken(req.Token)
+	resetToken, err := h.passwordRepo.GetByToken(r.Context(), req.Token)
 	if err != nil {
 		log.WithError(err).WithField("token", req.Token).Warn("Invalid or expired reset token")
-		http.Error(w, "Invalid or expired reset token", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid or expired reset token"), http.StatusBadRequest)
 		return
 	}
 
@@ -247,7 +247,7 @@ func (h *PasswordResetHandler) ValidateResetToken(w http.ResponseWriter, r *http
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"valid":   true,
-		"message": "Reset token is valid",
+		"message": i18n.T(r.Context(), "Reset token is valid"),
 	})
 }
 
@@ -261,18 +261,18 @@ func (h *PasswordResetHandler) CheckPasswordStrength(w http.ResponseWriter, r *h
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.WithError(err).Error("Failed to read request body")
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Error reading request body"), http.StatusBadRequest)
 		return
 	}
 
 	if err := json.Unmarshal(body, &req); err != nil {
 		log.WithError(err).Error("Failed to unmarshal JSON")
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
 		return
 	}
 
 	// Validar estructura
-	if err := validator.ValidateStruct(&req); err != nil {
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
 		log.WithError(err).Warn("Validation failed for password strength check")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -286,7 +286,7 @@ func (h *PasswordResetHandler) CheckPasswordStrength(w http.ResponseWriter, r *h
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"data":    strength,
-		"message": "Password strength checked",
+		"message": i18n.T(r.Context(), "Password strength checked"),
 	})
 }
 
@@ -298,7 +298,7 @@ func (h *PasswordResetHandler) GetPasswordHistory(w http.ResponseWriter, r *http
 	userID := r.Context().Value("user_id")
 	if userID == nil {
 		log.Warn("User ID not found in context")
-		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		http.Error(w, i18n.T(r.Context(), "Authentication required"), http.StatusUnauthorized)
 		return
 	}
 
@@ -306,7 +306,7 @@ func (h *PasswordResetHandler) GetPasswordHistory(w http.ResponseWriter, r *http
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Password history endpoint not implemented yet",
+		"message": i18n.T(r.Context(), "Password history endpoint not implemented yet"),
 		"data":    []interface{}{},
 	})
 }
@@ -331,7 +331,7 @@ func (h *PasswordResetHandler) GetPasswordPolicy(w http.ResponseWriter, r *http.
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"data":    policy,
-		"message": "Password policy retrieved successfully",
+		"message": i18n.T(r.Context(), "Password policy retrieved successfully"),
 	})
 }
 
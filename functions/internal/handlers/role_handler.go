@@ -0,0 +1,372 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+
+	"it-app_user/internal/audit"
+	"it-app_user/internal/i18n"
+	"it-app_user/internal/logger"
+	"it-app_user/internal/models"
+	"it-app_user/internal/services"
+	"it-app_user/internal/validator"
+)
+
+type RoleHandler struct {
+	roleService *services.RoleService
+}
+
+func NewRoleHandler(roleService *services.RoleService) *RoleHandler {
+	return &RoleHandler{roleService: roleService}
+}
+
+// ListRoles maneja GET /admin/roles
+func (h *RoleHandler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.roleService.List(r.Context())
+	if err != nil {
+		logger.GetLogger().WithError(err).Error("Failed to list roles")
+		http.Error(w, i18n.T(r.Context(), "Error retrieving roles"), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    roles,
+		"count":   len(roles),
+		"message": i18n.T(r.Context(), "Roles retrieved successfully"),
+	})
+}
+
+// ListPermissions maneja GET /admin/permissions
+func (h *RoleHandler) ListPermissions(w http.ResponseWriter, r *http.Request) {
+	permissions, err := h.roleService.ListPermissions(r.Context())
+	if err != nil {
+		logger.GetLogger().WithError(err).Error("Failed to list permissions")
+		http.Error(w, i18n.T(r.Context(), "Error retrieving roles"), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    permissions,
+		"count":   len(permissions),
+		"message": i18n.T(r.Context(), "Permissions retrieved successfully"),
+	})
+}
+
+// CreateRole maneja POST /admin/roles
+func (h *RoleHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
+	log := logger.GetLogger()
+
+	var req models.CreateRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
+		return
+	}
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	role, err := h.roleService.Create(r.Context(), req)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to create role", map[string]interface{}{"role": req.Name})
+		return
+	}
+
+	audit.Record(r.Context(), audit.FromRequest(r, audit.Event{
+		Action: audit.ActionRoleCreated,
+		Metadata: map[string]interface{}{
+			"role_id":     role.ID,
+			"role":        role.Name,
+			"permissions": role.PermissionNames(),
+		},
+	}))
+	log.WithField("role", role.Name).Info("Role created")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    role,
+		"message": i18n.T(r.Context(), "Role created successfully"),
+	})
+}
+
+// UpdateRole maneja PUT /admin/roles/{id}: cambia la descripción o reemplaza
+// los permisos. Se aplica a sus usuarios sin que refresquen el token.
+func (h *RoleHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	log := logger.GetLogger()
+
+	id, ok := parseRoleID(w, r, "id")
+	if !ok {
+		return
+	}
+	var req models.UpdateRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
+		return
+	}
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	before, role, err := h.roleService.Update(r.Context(), id, req)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to update role", map[string]interface{}{"role_id": id})
+		return
+	}
+
+	if changes := audit.Diff(roleAuditView(before), roleAuditView(role)); len(changes) > 0 {
+		audit.Record(r.Context(), audit.FromRequest(r, audit.Event{
+			Action:   audit.ActionRoleUpdated,
+			Changes:  changes,
+			Metadata: map[string]interface{}{"role_id": role.ID, "role": role.Name},
+		}))
+	}
+	log.WithField("role", role.Name).Info("Role updated")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    role,
+		"message": i18n.T(r.Context(), "Role updated successfully"),
+	})
+}
+
+// DeleteRole maneja DELETE /admin/roles/{id}: elimina el rol y se lo quita a sus usuarios
+func (h *RoleHandler) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	log := logger.GetLogger()
+
+	id, ok := parseRoleID(w, r, "id")
+	if !ok {
+		return
+	}
+
+	role, userIDs, err := h.roleService.Delete(r.Context(), id)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to delete role", map[string]interface{}{"role_id": id})
+		return
+	}
+
+	audit.Record(r.Context(), audit.FromRequest(r, audit.Event{
+		Action: audit.ActionRoleDeleted,
+		Metadata: map[string]interface{}{
+			"role_id":     role.ID,
+			"role":        role.Name,
+			"permissions": role.PermissionNames(),
+			"user_ids":    userIDs,
+		},
+	}))
+	log.WithFields(map[string]interface{}{
+		"role":  role.Name,
+		"users": len(userIDs),
+	}).Info("Role deleted")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": map[string]interface{}{
+			"id":             role.ID,
+			"name":           role.Name,
+			"users_affected": len(userIDs),
+		},
+		"message": i18n.T(r.Context(), "Role deleted successfully"),
+	})
+}
+
+// ListUserRoles maneja GET /admin/users/{id}/roles
+func (h *RoleHandler) ListUserRoles(w http.ResponseWriter, r *http.Request) {
+	userID, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	roles, err := h.roleService.UserRoles(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, r, err, "Failed to list user roles", map[string]interface{}{"user_id": userID})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    roles,
+		"count":   len(roles),
+		"message": i18n.T(r.Context(), "Roles retrieved successfully"),
+	})
+}
+
+// AssignRole maneja POST /admin/users/{id}/roles: asigna el rol y lo copia a
+// los custom claims. Es idempotente, así que sirve para reintentar una
+// sincronización fallida.
+func (h *RoleHandler) AssignRole(w http.ResponseWriter, r *http.Request) {
+	log := logger.GetLogger()
+
+	userID, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+	var req models.AssignRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
+		return
+	}
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	actor, _ := r.Context().Value("user_id").(string)
+	user, role, assigned, err := h.roleService.Assign(r.Context(), userID, req.Role, actor)
+	// La asignación queda guardada aunque falle la sincronización de los claims
+	if assigned {
+		audit.Record(r.Context(), audit.FromRequest(r, audit.Event{
+			Action:           audit.ActionRoleAssigned,
+			TargetUserID:     user.ID,
+			TargetFirebaseID: user.FirebaseID,
+			Metadata:         map[string]interface{}{"role_id": role.ID, "role": role.Name},
+		}))
+	}
+	if err != nil {
+		h.writeError(w, r, err, "Failed to assign role", map[string]interface{}{"user_id": userID, "role": req.Role})
+		return
+	}
+
+	log.WithFields(map[string]interface{}{
+		"user_id":  userID,
+		"role":     role.Name,
+		"assigned": assigned,
+	}).Info("Role assigned")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": map[string]interface{}{
+			"user_id":  user.ID,
+			"role":     role,
+			"assigned": assigned,
+		},
+		"message": i18n.T(r.Context(), "Role assigned successfully"),
+	})
+}
+
+// UnassignRole maneja DELETE /admin/users/{id}/roles/{role_id}
+func (h *RoleHandler) UnassignRole(w http.ResponseWriter, r *http.Request) {
+	log := logger.GetLogger()
+
+	userID, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+	roleID, ok := parseRoleID(w, r, "role_id")
+	if !ok {
+		return
+	}
+
+	user, role, err := h.roleService.Unassign(r.Context(), userID, roleID)
+	if user != nil && role != nil {
+		audit.Record(r.Context(), audit.FromRequest(r, audit.Event{
+			Action:           audit.ActionRoleUnassigned,
+			TargetUserID:     user.ID,
+			TargetFirebaseID: user.FirebaseID,
+			Metadata:         map[string]interface{}{"role_id": role.ID, "role": role.Name},
+		}))
+	}
+	if err != nil {
+		h.writeError(w, r, err, "Failed to remove role", map[string]interface{}{"user_id": userID, "role_id": roleID})
+		return
+	}
+
+	log.WithFields(map[string]interface{}{
+		"user_id": userID,
+		"role":    role.Name,
+	}).Info("Role removed")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": map[string]interface{}{
+			"user_id": user.ID,
+			"role_id": role.ID,
+		},
+		"message": i18n.T(r.Context(), "Role removed successfully"),
+	})
+}
+
+// SyncRoleClaims maneja POST /admin/users/{id}/roles/sync: vuelve a copiar
+// los roles del usuario a sus custom claims
+func (h *RoleHandler) SyncRoleClaims(w http.ResponseWriter, r *http.Request) {
+	userID, ok := parseUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.roleService.SyncClaims(r.Context(), userID); err != nil {
+		h.writeError(w, r, err, "Failed to sync role claims", map[string]interface{}{"user_id": userID})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    map[string]interface{}{"user_id": userID},
+		"message": i18n.T(r.Context(), "Role claims synced successfully"),
+	})
+}
+
+// writeError traduce los errores del RoleService a la respuesta HTTP
+func (h *RoleHandler) writeError(w http.ResponseWriter, r *http.Request, err error, logMessage string, fields map[string]interface{}) {
+	log := logger.GetLogger().WithFields(fields)
+	switch {
+	case errors.Is(err, services.ErrRoleNotFound):
+		http.Error(w, i18n.T(r.Context(), "Role not found"), http.StatusNotFound)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, i18n.T(r.Context(), "User not found"), http.StatusNotFound)
+	case errors.Is(err, services.ErrRoleNotAssigned):
+		http.Error(w, i18n.T(r.Context(), "Role not assigned to user"), http.StatusNotFound)
+	case errors.Is(err, services.ErrRoleExists):
+		http.Error(w, i18n.T(r.Context(), "Role already exists"), http.StatusConflict)
+	case errors.Is(err, services.ErrProtectedRole):
+		http.Error(w, i18n.T(r.Context(), "The admin role cannot be deleted or change permissions"), http.StatusConflict)
+	case errors.Is(err, services.ErrUnknownPermission):
+		http.Error(w, i18n.T(r.Context(), "Unknown permission"), http.StatusBadRequest)
+	case errors.Is(err, services.ErrClaimsTooLarge):
+		http.Error(w, i18n.T(r.Context(), "Too many roles for the token size limit"), http.StatusUnprocessableEntity)
+	case errors.Is(err, services.ErrFirebaseNotConfigured):
+		http.Error(w, i18n.T(r.Context(), "Authentication service not available"), http.StatusServiceUnavailable)
+	case errors.Is(err, services.ErrClaimsSyncFailed):
+		log.WithError(err).Error(logMessage)
+		http.Error(w, i18n.T(r.Context(), "Roles saved but token claims could not be updated"), http.StatusBadGateway)
+	default:
+		log.WithError(err).Error(logMessage)
+		http.Error(w, i18n.T(r.Context(), "Error saving role"), http.StatusInternalServerError)
+	}
+}
+
+// roleAuditView son los campos de un rol que se comparan en la auditoría
+func roleAuditView(role *models.Role) interface{} {
+	return struct {
+		Description string   `json:"description"`
+		Permissions []string `json:"permissions"`
+	}{role.Description, role.PermissionNames()}
+}
+
+// parseUserID lee el ID de usuario de la ruta
+func parseUserID(w http.ResponseWriter, r *http.Request) (uint, bool) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, i18n.T(r.Context(), "Invalid user ID"), http.StatusBadRequest)
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// parseRoleID lee el ID de rol de la variable de ruta name
+func parseRoleID(w http.ResponseWriter, r *http.Request, name string) (uint, bool) {
+	id, err := strconv.ParseUint(mux.Vars(r)[name], 10, 64)
+	if err != nil {
+		http.Error(w, i18n.T(r.Context(), "Invalid role ID"), http.StatusBadRequest)
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -1,11 +1,12 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
 	"io"
 	"net/http"
 
+	"it-app_user/internal/audit"
+	"it-app_user/internal/i18n"
 	"it-app_user/internal/logger"
 	"it-app_user/internal/models"
 	"it-app_user/internal/validator"
@@ -30,18 +31,18 @@ func (h *TokenHandler) VerifyToken(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.WithError(err).Error("Failed to read request body")
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Error reading request body"), http.StatusBadRequest)
 		return
 	}
 
 	if err := json.Unmarshal(body, &req); err != nil {
 		log.WithError(err).Error("Failed to unmarshal JSON")
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
 		return
 	}
 
 	// Validar estructura
-	if err := validator.ValidateStruct(&req); err != nil {
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
 		log.WithError(err).Warn("Validation failed for token verify request")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -49,23 +50,23 @@ func (h *TokenHandler) VerifyToken(w http.ResponseWriter, r *http.Request) {
 
 	if h.firebaseAuth == nil {
 		log.Error("Firebase Auth not configured")
-		http.Error(w, "Authentication service not available", http.StatusServiceUnavailable)
+		http.Error(w, i18n.T(r.Context(), "Authentication service not available"), http.StatusServiceUnavailable)
 		return
 	}
 
 	// Verificar token de Firebase
-	token, err := h.firebaseAuth.VerifyIDToken(context.Background(), req.IDToken)
+	token, err := h.firebaseAuth.VerifyIDToken(r.Context(), req.IDToken)
 	if err != nil {
 		log.WithError(err).Warn("Invalid Firebase token")
-		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		http.Error(w, i18n.T(r.Context(), "Invalid token"), http.StatusUnauthorized)
 		return
 	}
 
 	// Obtener información del usuario de Firebase
-	userRecord, err := h.firebaseAuth.GetUser(context.Background(), token.UID)
+	userRecord, err := h.firebaseAuth.GetUser(r.Context(), token.UID)
 	if err != nil {
 		log.WithError(err).Error("Failed to get user from Firebase")
-		http.Error(w, "Failed to get user information", http.StatusInternalServerError)
+		http.Error(w, i18n.T(r.Context(), "Failed to get user information"), http.StatusInternalServerError)
 		return
 	}
 
@@ -97,18 +98,18 @@ func (h *TokenHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.WithError(err).Error("Failed to read request body")
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Error reading request body"), http.StatusBadRequest)
 		return
 	}
 
 	if err := json.Unmarshal(body, &req); err != nil {
 		log.WithError(err).Error("Failed to unmarshal JSON")
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
 		return
 	}
 
 	// Validar estructura
-	if err := validator.ValidateStruct(&req); err != nil {
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
 		log.WithError(err).Warn("Validation failed for token refresh request")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -116,7 +117,7 @@ func (h *TokenHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 
 	if h.firebaseAuth == nil {
 		log.Error("Firebase Auth not configured")
-		http.Error(w, "Authentication service not available", http.StatusServiceUnavailable)
+		http.Error(w, i18n.T(r.Context(), "Authentication service not available"), http.StatusServiceUnavailable)
 		return
 	}
 
@@ -127,7 +128,7 @@ func (h *TokenHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Token refresh should be handled on the client side",
+		"message": i18n.T(r.Context(), "Token refresh should be handled on the client side"),
 		"info":    "Use Firebase SDK to refresh tokens automatically",
 	})
 }
@@ -140,18 +141,18 @@ func (h *TokenHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.WithError(err).Error("Failed to read request body")
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Error reading request body"), http.StatusBadRequest)
 		return
 	}
 
 	if err := json.Unmarshal(body, &req); err != nil {
 		log.WithError(err).Error("Failed to unmarshal JSON")
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
 		return
 	}
 
 	// Validar estructura
-	if err := validator.ValidateStruct(&req); err != nil {
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
 		log.WithError(err).Warn("Validation failed for token revoke request")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -159,7 +160,7 @@ func (h *TokenHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
 
 	if h.firebaseAuth == nil {
 		log.Error("Firebase Auth not configured")
-		http.Error(w, "Authentication service not available", http.StatusServiceUnavailable)
+		http.Error(w, i18n.T(r.Context(), "Authentication service not available"), http.StatusServiceUnavailable)
 		return
 	}
 
@@ -167,23 +168,29 @@ func (h *TokenHandler) RevokeToken(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value("user_id")
 	if userID == nil {
 		log.Warn("User ID not found in context")
-		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		http.Error(w, i18n.T(r.Context(), "Authentication required"), http.StatusUnauthorized)
 		return
 	}
 
 	// En Firebase, podemos revocar todos los tokens de un usuario
-	err = h.firebaseAuth.RevokeRefreshTokens(context.Background(), userID.(string))
+	err = h.firebaseAuth.RevokeRefreshTokens(r.Context(), userID.(string))
 	if err != nil {
 		log.WithError(err).Error("Failed to revoke tokens")
-		http.Error(w, "Failed to revoke tokens", http.StatusInternalServerError)
+		http.Error(w, i18n.T(r.Context(), "Failed to revoke tokens"), http.StatusInternalServerError)
 		return
 	}
 
+	audit.Record(r.Context(), audit.FromRequest(r, audit.Event{
+		Action:           audit.ActionTokensRevoked,
+		TargetFirebaseID: userID.(string),
+		Metadata:         map[string]interface{}{"endpoint": "tokens"},
+	}))
+
 	log.WithField("user_id", userID).Info("Tokens revoked successfully")
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "All tokens revoked successfully",
+		"message": i18n.T(r.Context(), "All tokens revoked successfully"),
 	})
 }
 
@@ -195,18 +202,18 @@ func (h *TokenHandler) CreateCustomToken(w http.ResponseWriter, r *http.Request)
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.WithError(err).Error("Failed to read request body")
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Error reading request body"), http.StatusBadRequest)
 		return
 	}
 
 	if err := json.Unmarshal(body, &req); err != nil {
 		log.WithError(err).Error("Failed to unmarshal JSON")
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
 		return
 	}
 
 	// Validar estructura
-	if err := validator.ValidateStruct(&req); err != nil {
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
 		log.WithError(err).Warn("Validation failed for custom token request")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -214,7 +221,7 @@ func (h *TokenHandler) CreateCustomToken(w http.ResponseWriter, r *http.Request)
 
 	if h.firebaseAuth == nil {
 		log.Error("Firebase Auth not configured")
-		http.Error(w, "Authentication service not available", http.StatusServiceUnavailable)
+		http.Error(w, i18n.T(r.Context(), "Authentication service not available"), http.StatusServiceUnavailable)
 		return
 	}
 
@@ -222,7 +229,7 @@ func (h *TokenHandler) CreateCustomToken(w http.ResponseWriter, r *http.Request)
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Custom token creation requires Firebase Admin SDK implementation",
+		"message": i18n.T(r.Context(), "Custom token creation requires Firebase Admin SDK implementation"),
 	})
 }
 
@@ -234,29 +241,35 @@ func (h *TokenHandler) RevokeAllTokens(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value("user_id")
 	if userID == nil {
 		log.Warn("User ID not found in context")
-		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		http.Error(w, i18n.T(r.Context(), "Authentication required"), http.StatusUnauthorized)
 		return
 	}
 
 	if h.firebaseAuth == nil {
 		log.Error("Firebase Auth not configured")
-		http.Error(w, "Authentication service not available", http.StatusServiceUnavailable)
+		http.Error(w, i18n.T(r.Context(), "Authentication service not available"), http.StatusServiceUnavailable)
 		return
 	}
 
 	// Revocar todos los tokens del usuario
-	err := h.firebaseAuth.RevokeRefreshTokens(context.Background(), userID.(string))
+	err := h.firebaseAuth.RevokeRefreshTokens(r.Context(), userID.(string))
 	if err != nil {
 		log.WithError(err).Error("Failed to revoke all tokens")
-		http.Error(w, "Failed to revoke tokens", http.StatusInternalServerError)
+		http.Error(w, i18n.T(r.Context(), "Failed to revoke tokens"), http.StatusInternalServerError)
 		return
 	}
 
+	audit.Record(r.Context(), audit.FromRequest(r, audit.Event{
+		Action:           audit.ActionTokensRevoked,
+		TargetFirebaseID: userID.(string),
+		Metadata:         map[string]interface{}{"endpoint": "tokens"},
+	}))
+
 	log.WithField("user_id", userID).Info("All tokens revoked successfully")
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "All tokens revoked successfully",
+		"message": i18n.T(r.Context(), "All tokens revoked successfully"),
 	})
 }
 
@@ -268,21 +281,21 @@ func (h *TokenHandler) GetTokenInfo(w http.ResponseWriter, r *http.Request) {
 	userID := r.Context().Value("user_id")
 	if userID == nil {
 		log.Warn("User ID not found in context")
-		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		http.Error(w, i18n.T(r.Context(), "Authentication required"), http.StatusUnauthorized)
 		return
 	}
 
 	if h.firebaseAuth == nil {
 		log.Error("Firebase Auth not configured")
-		http.Error(w, "Authentication service not available", http.StatusServiceUnavailable)
+		http.Error(w, i18n.T(r.Context(), "Authentication service not available"), http.StatusServiceUnavailable)
 		return
 	}
 
 	// Obtener información del usuario de Firebase
-	userRecord, err := h.firebaseAuth.GetUser(context.Background(), userID.(string))
+	userRecord, err := h.firebaseAuth.GetUser(r.Context(), userID.(string))
 	if err != nil {
 		log.WithError(err).Error("Failed to get user from Firebase")
-		http.Error(w, "Failed to get user information", http.StatusInternalServerError)
+		http.Error(w, i18n.T(r.Context(), "Failed to get user information"), http.StatusInternalServerError)
 		return
 	}
 
@@ -301,7 +314,7 @@ func (h *TokenHandler) GetTokenInfo(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"data":    tokenInfo,
-		"message": "Token info retrieved successfully",
+		"message": i18n.T(r.Context(), "Token info retrieved successfully"),
 	})
 }
 
@@ -313,18 +326,18 @@ func (h *TokenHandler) ValidateToken(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.WithError(err).Error("Failed to read request body")
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Error reading request body"), http.StatusBadRequest)
 		return
 	}
 
 	if err := json.Unmarshal(body, &req); err != nil {
 		log.WithError(err).Error("Failed to unmarshal JSON")
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
 		return
 	}
 
 	// Validar estructura
-	if err := validator.ValidateStruct(&req); err != nil {
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
 		log.WithError(err).Warn("Validation failed for token validate request")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -332,18 +345,18 @@ func (h *TokenHandler) ValidateToken(w http.ResponseWriter, r *http.Request) {
 
 	if h.firebaseAuth == nil {
 		log.Error("Firebase Auth not configured")
-		http.Error(w, "Authentication service not available", http.StatusServiceUnavailable)
+		http.Error(w, i18n.T(r.Context(), "Authentication service not available"), http.StatusServiceUnavailable)
 		return
 	}
 
 	// Verificar token de Firebase
-	token, err := h.firebaseAuth.VerifyIDToken(context.Background(), req.IDToken)
+	token, err := h.firebaseAuth.VerifyIDToken(r.Context(), req.IDToken)
 	if err != nil {
 		log.WithError(err).Warn("Token validation failed")
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"valid":   false,
-			"message": "Invalid token",
+			"message": i18n.T(r.Context(), "Invalid token"),
 		})
 		return
 	}
@@ -356,6 +369,6 @@ func (h *TokenHandler) ValidateToken(w http.ResponseWriter, r *http.Request) {
 		"user_id":    token.UID,
 		"expires_at": token.Expires,
 		"issued_at":  token.IssuedAt,
-		"message":    "Token is valid",
+		"message":    i18n.T(r.Context(), "Token is valid"),
 	})
 }
\ No newline at end of file
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"it-app_user/internal/i18n"
+	"it-app_user/internal/logger"
+	"it-app_user/internal/principal"
+	"it-app_user/internal/services"
+)
+
+type TokenIntrospectionHandler struct {
+	introspectionService *services.TokenIntrospectionService
+}
+
+func NewTokenIntrospectionHandler(introspectionService *services.TokenIntrospectionService) *TokenIntrospectionHandler {
+	return &TokenIntrospectionHandler{introspectionService: introspectionService}
+}
+
+// IntrospectToken maneja POST /tokens/introspect (RFC 7662). Recibe el token
+// como formulario (token y token_type_hint, que se ignora: solo hay ID
+// tokens) y responde {"active": false} para cualquier token que no sirva.
+func (h *TokenIntrospectionHandler) IntrospectToken(w http.ResponseWriter, r *http.Request) {
+	log := logger.GetLogger()
+	if err := r.ParseForm(); err != nil {
+		writeIntrospectionError(w, http.StatusBadRequest, "invalid_request", i18n.T(r.Context(), "Invalid form data"))
+		return
+	}
+	token := r.PostForm.Get("token")
+	if token == "" {
+		writeIntrospectionError(w, http.StatusBadRequest, "invalid_request", i18n.T(r.Context(), "Token is required"))
+		return
+	}
+
+	// Quien consulta queda en el log y en session.requested_by; el token y su titular no se loguean
+	p, _ := principal.FromContext(r.Context())
+	result, err := h.introspectionService.Introspect(r.Context(), token, p)
+	if err != nil {
+		log.WithError(err).Error("Failed to introspect token")
+		writeIntrospectionError(w, http.StatusServiceUnavailable, "temporarily_unavailable", i18n.T(r.Context(), "Authentication service not available"))
+		return
+	}
+
+	log.WithFields(map[string]interface{}{
+		"client":  p.ClientID,
+		"user_id": r.Context().Value("user_id"),
+		"active":  result.Active,
+	}).Info("Token introspected")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(result)
+}
+
+// writeIntrospectionError responde con el formato de error de OAuth 2.0
+// (RFC 6749, sección 5.2) que esperan los clientes de introspección
+func writeIntrospectionError(w http.ResponseWriter, status int, code, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":             code,
+		"error_description": description,
+	})
+}
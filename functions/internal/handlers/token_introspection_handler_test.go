@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"it-app_user/internal/firebasetest"
+	"it-app_user/internal/models"
+	"it-app_user/internal/principal"
+	"it-app_user/internal/services"
+)
+
+// newTestIntrospectionHandler apunta el SDK de Firebase a un emulador de
+// Auth falso con las cuentas dadas, para que la verificación y sus errores
+// sean los reales. Una cuenta nil hace fallar la consulta.
+func newTestIntrospectionHandler(t *testing.T, accounts map[string]*firebasetest.Account, users *blockingUserRepo, roles *userRoleRepo) *TokenIntrospectionHandler {
+	t.Helper()
+	firebaseAuth, _ := firebasetest.NewAuth(t, accounts)
+	return NewTokenIntrospectionHandler(services.NewTokenIntrospectionService(firebaseAuth, users, roles))
+}
+
+func introspectRequest(token string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/tokens/introspect", strings.NewReader(url.Values{"token": {token}}.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return r
+}
+
+func TestIntrospectTokenInactive(t *testing.T) {
+	validSince := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+	accounts := map[string]*firebasetest.Account{
+		"uid-revoked":        {LocalID: "uid-revoked", ValidSince: validSince},
+		"uid-disabled":       {LocalID: "uid-disabled", Disabled: true},
+		"uid-local-disabled": {LocalID: "uid-local-disabled"},
+		"uid-deleted":        {LocalID: "uid-deleted"},
+		"uid-ana":            {LocalID: "uid-ana"},
+	}
+	users := &blockingUserRepo{
+		users:   []models.User{{ID: 7, FirebaseID: "uid-local-disabled", Disabled: true}},
+		deleted: []models.User{{ID: 9, FirebaseID: "uid-deleted", DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}}},
+	}
+	handler := newTestIntrospectionHandler(t, accounts, users, &userRoleRepo{})
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "garbage", token: "not-a-token"},
+		{name: "another project", token: firebasetest.IDToken(t, "uid-ana", map[string]interface{}{"aud": "other-project"})},
+		{name: "revoked in Firebase", token: firebasetest.IDToken(t, "uid-revoked", nil)},
+		{name: "disabled in Firebase", token: firebasetest.IDToken(t, "uid-disabled", nil)},
+		{name: "deleted in Firebase", token: firebasetest.IDToken(t, "uid-unknown", nil)},
+		{name: "disabled locally", token: firebasetest.IDToken(t, "uid-local-disabled", nil)},
+		{name: "soft-deleted locally", token: firebasetest.IDToken(t, "uid-deleted", nil)},
+		{name: "ended impersonation", token: firebasetest.IDToken(t, "uid-ana", map[string]interface{}{
+			principal.ClaimImpersonatedBy:         "uid-admin",
+			principal.ClaimImpersonationID:        "imp-1",
+			principal.ClaimImpersonationExpiresAt: time.Now().Add(-time.Minute).Unix(),
+		})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.IntrospectToken(w, introspectRequest(tt.token))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+			}
+			// Un token inactivo no revela nada más que active
+			if body := strings.TrimSpace(w.Body.String()); body != `{"active":false}` {
+				t.Errorf("body = %s, want exactly {\"active\":false}", body)
+			}
+		})
+	}
+}
+
+func TestIntrospectTokenActive(t *testing.T) {
+	accounts := map[string]*firebasetest.Account{"uid-ana": {LocalID: "uid-ana"}}
+	users := &blockingUserRepo{
+		users: []models.User{{ID: 42, FirebaseID: "uid-ana", Username: "ana"}},
+	}
+	roles := &userRoleRepo{roles: map[uint][]models.Role{
+		42: {{Name: "support", Permissions: []models.Permission{{Name: "users:read"}, {Name: "audit:read"}}}},
+	}}
+	handler := newTestIntrospectionHandler(t, accounts, users, roles)
+
+	w := httptest.NewRecorder()
+	handler.IntrospectToken(w, introspectRequest(firebasetest.IDToken(t, "uid-ana", nil)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+	}
+	if cache := w.Header().Get("Cache-Control"); cache != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store", cache)
+	}
+
+	var resp map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"active":    true,
+		"sub":       "uid-ana",
+		"aud":       firebasetest.ProjectID,
+		"client_id": firebasetest.ProjectID,
+		"username":  "ana",
+		"user_id":   float64(42),
+		"scope":     "audit:read users:read",
+	}
+	for key, value := range want {
+		if resp[key] != value {
+			t.Errorf("%s = %v, want %v", key, resp[key], value)
+		}
+	}
+	session, _ := resp["session"].(map[string]interface{})
+	if session["status"] != services.SessionStatusActive || session["sign_in_provider"] != "password" {
+		t.Errorf("session = %v, want an active password session", session)
+	}
+}
+
+func TestIntrospectTokenFirebaseUnavailable(t *testing.T) {
+	accounts := map[string]*firebasetest.Account{"uid-ana": nil}
+	handler := newTestIntrospectionHandler(t, accounts, &blockingUserRepo{}, &userRoleRepo{})
+
+	// Si no se pudo consultar la revocación, el token no se informa como inactivo
+	w := httptest.NewRecorder()
+	handler.IntrospectToken(w, introspectRequest(firebasetest.IDToken(t, "uid-ana", nil)))
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "temporarily_unavailable") {
+		t.Fatalf("status = %d, body = %s, want 503 temporarily_unavailable", w.Code, w.Body)
+	}
+}
+
+func TestIntrospectTokenCallerAndImpersonator(t *testing.T) {
+	accounts := map[string]*firebasetest.Account{"uid-ana": {LocalID: "uid-ana"}}
+	handler := newTestIntrospectionHandler(t, accounts, &blockingUserRepo{}, &userRoleRepo{})
+	impersonation := firebasetest.IDToken(t, "uid-ana", map[string]interface{}{
+		principal.ClaimImpersonatedBy:         "uid-admin",
+		principal.ClaimImpersonationID:        "imp-1",
+		principal.ClaimImpersonationExpiresAt: time.Now().Add(time.Hour).Unix(),
+	})
+
+	// client_id es siempre el proyecto que emitió el token; quien consulta y
+	// quien suplanta van en sus propios campos de session
+	tests := []struct {
+		name             string
+		caller           principal.Principal
+		token            string
+		wantRequestedBy  string
+		wantImpersonator string
+	}{
+		{name: "service caller", caller: principal.Principal{ClientID: "api-gateway"}, token: firebasetest.IDToken(t, "uid-ana", nil), wantRequestedBy: "client:api-gateway"},
+		{name: "user caller", caller: principal.Principal{UID: "uid-support"}, token: firebasetest.IDToken(t, "uid-ana", nil), wantRequestedBy: "uid-support"},
+		{name: "impersonation token", caller: principal.Principal{UID: "uid-support"}, token: impersonation, wantRequestedBy: "uid-support", wantImpersonator: "uid-admin"},
+		{name: "impersonation token from a service", caller: principal.Principal{ClientID: "api-gateway"}, token: impersonation, wantRequestedBy: "client:api-gateway", wantImpersonator: "uid-admin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := introspectRequest(tt.token)
+			r = r.WithContext(principal.With(r.Context(), tt.caller))
+			w := httptest.NewRecorder()
+			handler.IntrospectToken(w, r)
+
+			var resp services.TokenIntrospection
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if !resp.Active || resp.ClientID != firebasetest.ProjectID {
+				t.Fatalf("active = %v, client_id = %q, want active with %q", resp.Active, resp.ClientID, firebasetest.ProjectID)
+			}
+			if resp.Session.RequestedBy != tt.wantRequestedBy || resp.Session.ImpersonatedBy != tt.wantImpersonator {
+				t.Errorf("requested_by = %q, impersonated_by = %q, want %q and %q", resp.Session.RequestedBy, resp.Session.ImpersonatedBy, tt.wantRequestedBy, tt.wantImpersonator)
+			}
+		})
+	}
+}
@@ -2,49 +2,42 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+	"it-app_user/internal/audit"
+	"it-app_user/internal/clientip"
+	"it-app_user/internal/i18n"
 	"it-app_user/internal/logger"
 	"it-app_user/internal/models"
 	"it-app_user/internal/repositories"
+	"it-app_user/internal/services"
 	"it-app_user/internal/validator"
 )
 
 type UserHandler struct {
-	userRepo repositories.UserRepositoryInterface
+	userRepo        repositories.UserRepositoryInterface
+	userService     *services.UserService
+	usernameService *services.UsernameService
+	deletionService *services.UserDeletionService
 }
 
-func NewUserHandler(userRepo repositories.UserRepositoryInterface) *UserHandler {
+func NewUserHandler(userRepo repositories.UserRepositoryInterface, userService *services.UserService, usernameService *services.UsernameService, deletionService *services.UserDeletionService) *UserHandler {
 	return &UserHandler{
-		userRepo: userRepo,
+		userRepo:        userRepo,
+		userService:     userService,
+		usernameService: usernameService,
+		deletionService: deletionService,
 	}
 }
 
-// HealthCheck maneja GET /health
-func (h *UserHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	log := logger.GetLogger()
-	
-	// 🔍 LOG: Health check con detalles
-	log.WithFields(map[string]interface{}{
-		"method":      r.Method,
-		"url":         r.URL.String(),
-		"remote_addr": r.RemoteAddr,
-		"user_agent":  r.Header.Get("User-Agent"),
-	}).Info("🏥 [HEALTH CHECK] Request received")
-	
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"status": "ok", "service": "user-service", "timestamp": "` + 
-		fmt.Sprintf("%d", time.Now().Unix()) + `"}`))
-	
-	log.Info("✅ [HEALTH CHECK] Response sent successfully")
-}
-
 // Ping maneja GET /ping - Endpoint simple para probar conectividad
 func (h *UserHandler) Ping(w http.ResponseWriter, r *http.Request) {
 	log := logger.GetLogger()
@@ -53,7 +46,7 @@ func (h *UserHandler) Ping(w http.ResponseWriter, r *http.Request) {
 	log.WithFields(map[string]interface{}{
 		"method":      r.Method,
 		"url":         r.URL.String(),
-		"remote_addr": r.RemoteAddr,
+		"client_ip":   clientip.FromRequest(r),
 		"user_agent":  r.Header.Get("User-Agent"),
 	}).Info("🏓 [PING] Request received")
 	
@@ -102,10 +95,10 @@ func (h *UserHandler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	
-	users, err := h.userRepo.GetAll(limit, offset)
+	users, err := h.userRepo.GetAll(r.Context(), limit, offset)
 	if err != nil {
 		log.WithError(err).Error("Failed to fetch users")
-		http.Error(w, "Error fetching users", http.StatusInternalServerError)
+		http.Error(w, i18n.T(r.Context(), "Error fetching users"), http.StatusInternalServerError)
 		return
 	}
 
@@ -117,7 +110,7 @@ func (h *UserHandler) GetAllUsers(w http.ResponseWriter, r *http.Request) {
 		"count":   len(users),
 		"limit":   limit,
 		"offset":  offset,
-		"message": "Users retrieved successfully",
+		"message": i18n.T(r.Context(), "Users retrieved successfully"),
 	})
 }
 
@@ -129,14 +122,14 @@ func (h *UserHandler) GetUserByID(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
 		log.WithError(err).Warn("Invalid user ID provided")
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid user ID"), http.StatusBadRequest)
 		return
 	}
 
-	user, err := h.userRepo.GetByID(uint(id))
+	user, err := h.userRepo.GetByID(r.Context(), uint(id))
 	if err != nil {
 		log.WithError(err).WithField("user_id", id).Error("Failed to fetch user")
-		http.Error(w, "User not found", http.StatusNotFound)
+		http.Error(w, i18n.T(r.Context(), "User not found"), http.StatusNotFound)
 		return
 	}
 
@@ -145,7 +138,7 @@ func (h *UserHandler) GetUserByID(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"data":    user,
-		"message": "User retrieved successfully",
+		"message": i18n.T(r.Context(), "User retrieved successfully"),
 	})
 }
 
@@ -157,7 +150,7 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	log.WithFields(map[string]interface{}{
 		"method":      r.Method,
 		"url":         r.URL.String(),
-		"remote_addr": r.RemoteAddr,
+		"client_ip":   clientip.FromRequest(r),
 		"user_agent":  r.Header.Get("User-Agent"),
 		"content_type": r.Header.Get("Content-Type"),
 	}).Info("📥 [CREATE USER] Request received")
@@ -180,7 +173,7 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.WithError(err).Error("❌ [CREATE USER] Failed to read request body")
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Error reading request body"), http.StatusBadRequest)
 		return
 	}
 
@@ -191,7 +184,7 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	// 🔍 LOG: Unmarshal JSON
 	if err := json.Unmarshal(body, &req); err != nil {
 		log.WithError(err).WithField("raw_body", string(body)).Error("❌ [CREATE USER] Failed to unmarshal JSON")
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
 		return
 	}
 
@@ -219,7 +212,7 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	log.Info("🔍 [CREATE USER] Starting validation")
 
 	// Validar estructura
-	if err := validator.ValidateStruct(&req); err != nil {
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
 		log.WithError(err).WithFields(map[string]interface{}{
 			"firebase_id": req.FirebaseID,
 			"email":       req.Email,
@@ -235,7 +228,7 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	// 🔍 LOG: Verificar si el usuario ya existe
 	log.WithField("firebase_id", req.FirebaseID).Info("🔍 [CREATE USER] Checking if user already exists")
 	
-	existingUser, err := h.userRepo.GetByFirebaseID(req.FirebaseID)
+	existingUser, err := h.userRepo.GetByFirebaseID(r.Context(), req.FirebaseID)
 	if err == nil && existingUser != nil {
 		log.WithFields(map[string]interface{}{
 			"existing_user_id": existingUser.ID,
@@ -247,11 +240,16 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK) // 200 en lugar de 201 para usuario existente
 		json.NewEncoder(w).Encode(map[string]interface{}{
 			"data":    existingUser,
-			"message": "User already exists, returning existing user",
+			"message": i18n.T(r.Context(), "User already exists, returning existing user"),
 		})
 		return
 	}
 
+	// Rechazar usernames reservados u ocupados antes de llegar al índice único
+	if !h.usernameAvailable(w, r, req.Username) {
+		return
+	}
+
 	// 🔍 LOG: Crear usuario usando el repositorio
 	log.Info("🔧 [CREATE USER] Creating new user in database")
 	
@@ -280,7 +278,8 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		"status":         user.Status,
 	}).Info("📝 [CREATE USER] User object created, attempting database insert")
 
-	if err := h.userRepo.Create(user); err != nil {
+	// Usuario, perfil, configuraciones, estadísticas y verificación de email en una transacción
+	if err := h.userService.Signup(r.Context(), user); err != nil {
 		log.WithError(err).WithFields(map[string]interface{}{
 			"firebase_id": req.FirebaseID,
 			"email":       req.Email,
@@ -288,10 +287,10 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 		}).Error("❌ [CREATE USER] Failed to create user in database")
 		
 		// Verificar si es error de duplicado
-		if err.Error() == "user already exists" {
-			http.Error(w, "User with this email or username already exists", http.StatusConflict)
+		if errors.Is(err, services.ErrUserAlreadyExists) {
+			http.Error(w, i18n.T(r.Context(), "User with this email or username already exists"), http.StatusConflict)
 		} else {
-			http.Error(w, "Error creating user", http.StatusInternalServerError)
+			http.Error(w, i18n.T(r.Context(), "Error creating user"), http.StatusInternalServerError)
 		}
 		return
 	}
@@ -308,7 +307,7 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	// 🔍 LOG: Preparando respuesta
 	response := map[string]interface{}{
 		"data":    user,
-		"message": "User created successfully",
+		"message": i18n.T(r.Context(), "User created successfully"),
 	}
 	
 	log.WithField("response_data", response).Info("📤 [CREATE USER] Sending success response")
@@ -326,7 +325,7 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
 		log.WithError(err).Warn("Invalid user ID provided")
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid user ID"), http.StatusBadRequest)
 		return
 	}
 
@@ -335,33 +334,38 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.WithError(err).Error("Failed to read request body")
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Error reading request body"), http.StatusBadRequest)
 		return
 	}
 
 	if err := json.Unmarshal(body, &req); err != nil {
 		log.WithError(err).Error("Failed to unmarshal JSON")
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
 		return
 	}
 
 	// Validar estructura
-	if err := validator.ValidateStruct(&req); err != nil {
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
 		log.WithError(err).Warn("Validation failed for update user request")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	// Obtener usuario existente
-	user, err := h.userRepo.GetByID(uint(id))
+	user, err := h.userRepo.GetByID(r.Context(), uint(id))
 	if err != nil {
 		log.WithError(err).WithField("user_id", id).Error("User not found for update")
-		http.Error(w, "User not found", http.StatusNotFound)
+		http.Error(w, i18n.T(r.Context(), "User not found"), http.StatusNotFound)
 		return
 	}
+	before := *user
 
 	// Actualizar campos
 	if req.Username != "" {
+		// Cambiar solo mayúsculas/minúsculas del propio username no requiere comprobarlo
+		if !strings.EqualFold(req.Username, user.Username) && !h.usernameAvailable(w, r, req.Username) {
+			return
+		}
 		user.Username = req.Username
 	}
 	if req.FirstName != "" {
@@ -381,18 +385,27 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Guardar cambios
-	if err := h.userRepo.Update(user); err != nil {
+	if err := h.userRepo.Update(r.Context(), user); err != nil {
 		log.WithError(err).WithField("user_id", id).Error("Failed to update user")
-		http.Error(w, "Error updating user", http.StatusInternalServerError)
+		http.Error(w, i18n.T(r.Context(), "Error updating user"), http.StatusInternalServerError)
 		return
 	}
 
+	if changes := audit.Diff(before, user); len(changes) > 0 {
+		audit.Record(r.Context(), audit.FromRequest(r, audit.Event{
+			Action:           audit.ActionUserUpdated,
+			TargetUserID:     user.ID,
+			TargetFirebaseID: user.FirebaseID,
+			Changes:          changes,
+		}))
+	}
+
 	log.WithField("user_id", id).Info("User updated successfully")
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"data":    user,
-		"message": "User updated successfully",
+		"message": i18n.T(r.Context(), "User updated successfully"),
 	})
 }
 
@@ -404,30 +417,80 @@ func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
 		log.WithError(err).Warn("Invalid user ID provided")
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid user ID"), http.StatusBadRequest)
 		return
 	}
 
-	// Verificar que el usuario existe antes de eliminarlo
-	_, err = h.userRepo.GetByID(uint(id))
-	if err != nil {
+	// Borrado lógico: el usuario puede restaurarse hasta que venza el período de gracia
+	user, err := h.deletionService.SoftDelete(r.Context(), uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
 		log.WithError(err).WithField("user_id", id).Error("User not found for deletion")
-		http.Error(w, "User not found", http.StatusNotFound)
+		http.Error(w, i18n.T(r.Context(), "User not found"), http.StatusNotFound)
 		return
 	}
-
-	// Eliminar usuario
-	if err := h.userRepo.Delete(uint(id)); err != nil {
+	if err != nil {
 		log.WithError(err).WithField("user_id", id).Error("Failed to delete user")
-		http.Error(w, "Error deleting user", http.StatusInternalServerError)
+		http.Error(w, i18n.T(r.Context(), "Error deleting user"), http.StatusInternalServerError)
 		return
 	}
 
+	audit.Record(r.Context(), audit.FromRequest(r, audit.Event{
+		Action:           audit.ActionUserDeleted,
+		TargetUserID:     user.ID,
+		TargetFirebaseID: user.FirebaseID,
+		Metadata:         map[string]interface{}{"purge_after": h.deletionService.PurgeAfter(user.DeletedAt.Time).UTC()},
+	}))
+
 	log.WithField("user_id", id).Info("User deleted successfully")
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "User deleted successfully",
+		"data": map[string]interface{}{
+			"id":          user.ID,
+			"deleted_at":  user.DeletedAt.Time,
+			"purge_after": h.deletionService.PurgeAfter(user.DeletedAt.Time),
+		},
+		"message": i18n.T(r.Context(), "User deleted successfully"),
+	})
+}
+
+// RestoreUser maneja POST /users/{id}/restore
+func (h *UserHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	log := logger.GetLogger()
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		log.WithError(err).Warn("Invalid user ID provided")
+		http.Error(w, i18n.T(r.Context(), "Invalid user ID"), http.StatusBadRequest)
+		return
+	}
+
+	user, err := h.deletionService.Restore(r.Context(), uint(id))
+	if err != nil {
+		log.WithError(err).WithField("user_id", id).Warn("Failed to restore user")
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			http.Error(w, i18n.T(r.Context(), "Deleted user not found"), http.StatusNotFound)
+		case errors.Is(err, services.ErrRestoreWindowExpired):
+			http.Error(w, i18n.T(r.Context(), "Restore window has expired"), http.StatusGone)
+		default:
+			http.Error(w, i18n.T(r.Context(), "Error restoring user"), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	audit.Record(r.Context(), audit.FromRequest(r, audit.Event{
+		Action:           audit.ActionUserRestored,
+		TargetUserID:     user.ID,
+		TargetFirebaseID: user.FirebaseID,
+	}))
+
+	log.WithField("user_id", id).Info("User restored successfully")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    user,
+		"message": i18n.T(r.Context(), "User restored successfully"),
 	})
 }
 
@@ -439,7 +502,7 @@ func (h *UserHandler) GetUserByFirebaseID(w http.ResponseWriter, r *http.Request
 	log.WithFields(map[string]interface{}{
 		"method":      r.Method,
 		"url":         r.URL.String(),
-		"remote_addr": r.RemoteAddr,
+		"client_ip":   clientip.FromRequest(r),
 	}).Info("🔍 [GET USER BY FIREBASE ID] Request received")
 
 	vars := mux.Vars(r)
@@ -450,17 +513,17 @@ func (h *UserHandler) GetUserByFirebaseID(w http.ResponseWriter, r *http.Request
 	
 	if firebaseID == "" {
 		log.Warn("❌ [GET USER BY FIREBASE ID] Firebase ID is required but not provided")
-		http.Error(w, "Firebase ID is required", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Firebase ID is required"), http.StatusBadRequest)
 		return
 	}
 
 	// 🔍 LOG: Buscando usuario en base de datos
 	log.WithField("firebase_id", firebaseID).Info("🔍 [GET USER BY FIREBASE ID] Searching user in database")
 
-	user, err := h.userRepo.GetByFirebaseID(firebaseID)
+	user, err := h.userRepo.GetByFirebaseID(r.Context(), firebaseID)
 	if err != nil {
 		log.WithError(err).WithField("firebase_id", firebaseID).Info("ℹ️ [GET USER BY FIREBASE ID] User not found in database (this is normal for new users)")
-		http.Error(w, "User not found", http.StatusNotFound)
+		http.Error(w, i18n.T(r.Context(), "User not found"), http.StatusNotFound)
 		return
 	}
 
@@ -476,7 +539,7 @@ func (h *UserHandler) GetUserByFirebaseID(w http.ResponseWriter, r *http.Request
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"data":    user,
-		"message": "User retrieved successfully",
+		"message": i18n.T(r.Context(), "User retrieved successfully"),
 	})
 }
 
@@ -487,14 +550,14 @@ func (h *UserHandler) GetUserByUsername(w http.ResponseWriter, r *http.Request)
 	username := vars["username"]
 	if username == "" {
 		log.Warn("Username is required but not provided")
-		http.Error(w, "Username is required", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Username is required"), http.StatusBadRequest)
 		return
 	}
 
-	user, err := h.userRepo.GetByUsername(username)
+	user, err := h.userRepo.GetByUsername(r.Context(), username)
 	if err != nil {
 		log.WithError(err).WithField("username", username).Error("Failed to fetch user by username")
-		http.Error(w, "User not found", http.StatusNotFound)
+		http.Error(w, i18n.T(r.Context(), "User not found"), http.StatusNotFound)
 		return
 	}
 
@@ -503,8 +566,69 @@ func (h *UserHandler) GetUserByUsername(w http.ResponseWriter, r *http.Request)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"data":    user,
-		"message": "User retrieved successfully",
+		"message": i18n.T(r.Context(), "User retrieved successfully"),
+	})
+}
+
+// CheckUsernameAvailability maneja GET /users/username-availability?u=
+func (h *UserHandler) CheckUsernameAvailability(w http.ResponseWriter, r *http.Request) {
+	log := logger.GetLogger()
+	username := r.URL.Query().Get("u")
+	if username == "" {
+		log.Warn("Username is required but not provided")
+		http.Error(w, i18n.T(r.Context(), "Username is required"), http.StatusBadRequest)
+		return
+	}
+
+	check, err := h.usernameService.Check(r.Context(), username)
+	if err != nil {
+		log.WithError(err).WithField("username", username).Error("Failed to check username availability")
+		http.Error(w, i18n.T(r.Context(), "Error checking username availability"), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    check,
+		"message": i18n.T(r.Context(), "Username availability checked"),
+	})
+}
+
+// usernameAvailable comprueba el username con el UsernameService y, si no
+// puede usarse, responde 400 (inválido o reservado) o 409 (ocupado) con
+// alternativas libres. Devuelve false si ya se respondió.
+func (h *UserHandler) usernameAvailable(w http.ResponseWriter, r *http.Request, username string) bool {
+	log := logger.GetLogger()
+
+	check, err := h.usernameService.Check(r.Context(), username)
+	if err != nil {
+		log.WithError(err).WithField("username", username).Error("Failed to check username availability")
+		http.Error(w, i18n.T(r.Context(), "Error checking username availability"), http.StatusInternalServerError)
+		return false
+	}
+	if check.Available {
+		return true
+	}
+
+	log.WithFields(map[string]interface{}{
+		"username": username,
+		"reason":   check.Reason,
+	}).Warn("Username not available")
+
+	code := http.StatusBadRequest
+	message := "Username is not allowed"
+	if check.Reason == services.UsernameTaken {
+		code = http.StatusConflict
+		message = "Username is already taken"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    check,
+		"message": i18n.T(r.Context(), message),
 	})
+	return false
 }
 
 // GetUserByEmail maneja GET /users/email/{email}
@@ -514,14 +638,14 @@ func (h *UserHandler) GetUserByEmail(w http.ResponseWriter, r *http.Request) {
 	email := vars["email"]
 	if email == "" {
 		log.Warn("Email is required but not provided")
-		http.Error(w, "Email is required", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Email is required"), http.StatusBadRequest)
 		return
 	}
 
-	user, err := h.userRepo.GetByEmail(email)
+	user, err := h.userRepo.GetByEmail(r.Context(), email)
 	if err != nil {
 		log.WithError(err).WithField("email", email).Error("Failed to fetch user by email")
-		http.Error(w, "User not found", http.StatusNotFound)
+		http.Error(w, i18n.T(r.Context(), "User not found"), http.StatusNotFound)
 		return
 	}
 
@@ -530,7 +654,7 @@ func (h *UserHandler) GetUserByEmail(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"data":    user,
-		"message": "User retrieved successfully",
+		"message": i18n.T(r.Context(), "User retrieved successfully"),
 	})
 }
 
@@ -540,7 +664,7 @@ func (h *UserHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query().Get("q")
 	if query == "" {
 		log.Warn("Search query is required")
-		http.Error(w, "Search query is required", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Search query is required"), http.StatusBadRequest)
 		return
 	}
 
@@ -560,10 +684,10 @@ func (h *UserHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	users, err := h.userRepo.SearchUsers(query, limit, offset)
+	users, err := h.userRepo.SearchUsers(r.Context(), query, limit, offset)
 	if err != nil {
 		log.WithError(err).WithField("query", query).Error("Failed to search users")
-		http.Error(w, "Error searching users", http.StatusInternalServerError)
+		http.Error(w, i18n.T(r.Context(), "Error searching users"), http.StatusInternalServerError)
 		return
 	}
 
@@ -579,7 +703,7 @@ func (h *UserHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
 		"query":   query,
 		"limit":   limit,
 		"offset":  offset,
-		"message": "Search completed successfully",
+		"message": i18n.T(r.Context(), "Search completed successfully"),
 	})
 }
 
@@ -587,10 +711,10 @@ func (h *UserHandler) SearchUsers(w http.ResponseWriter, r *http.Request) {
 func (h *UserHandler) CountUsers(w http.ResponseWriter, r *http.Request) {
 	log := logger.GetLogger()
 	
-	count, err := h.userRepo.CountUsers()
+	count, err := h.userRepo.CountUsers(r.Context())
 	if err != nil {
 		log.WithError(err).Error("Failed to count users")
-		http.Error(w, "Error counting users", http.StatusInternalServerError)
+		http.Error(w, i18n.T(r.Context(), "Error counting users"), http.StatusInternalServerError)
 		return
 	}
 
@@ -599,7 +723,7 @@ func (h *UserHandler) CountUsers(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"total":   count,
-		"message": "Users counted successfully",
+		"message": i18n.T(r.Context(), "Users counted successfully"),
 	})
 }
 
@@ -607,10 +731,10 @@ func (h *UserHandler) CountUsers(w http.ResponseWriter, r *http.Request) {
 func (h *UserHandler) GetActiveUsers(w http.ResponseWriter, r *http.Request) {
 	log := logger.GetLogger()
 	
-	users, err := h.userRepo.GetActiveUsers()
+	users, err := h.userRepo.GetActiveUsers(r.Context())
 	if err != nil {
 		log.WithError(err).Error("Failed to fetch active users")
-		http.Error(w, "Error fetching active users", http.StatusInternalServerError)
+		http.Error(w, i18n.T(r.Context(), "Error fetching active users"), http.StatusInternalServerError)
 		return
 	}
 
@@ -620,7 +744,7 @@ func (h *UserHandler) GetActiveUsers(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"data":    users,
 		"count":   len(users),
-		"message": "Active users retrieved successfully",
+		"message": i18n.T(r.Context(), "Active users retrieved successfully"),
 	})
 }
 
@@ -632,39 +756,39 @@ func (h *UserHandler) UpdateLoginInfo(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
 		log.WithError(err).Warn("Invalid user ID provided for login update")
-		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid user ID"), http.StatusBadRequest)
 		return
 	}
 
+	// La IP de login no se acepta del body: se usa la resuelta a partir de la conexión
 	var req struct {
-		LoginIP     string `json:"login_ip" validate:"omitempty,ip"`
 		LoginDevice string `json:"login_device" validate:"max=255"`
 	}
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.WithError(err).Error("Failed to read request body")
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Error reading request body"), http.StatusBadRequest)
 		return
 	}
 
 	if err := json.Unmarshal(body, &req); err != nil {
 		log.WithError(err).Error("Failed to unmarshal JSON")
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
 		return
 	}
 
 	// Validar estructura
-	if err := validator.ValidateStruct(&req); err != nil {
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
 		log.WithError(err).Warn("Validation failed for login info update")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	// Actualizar información de login
-	if err := h.userRepo.UpdateLoginInfo(uint(id), req.LoginIP, req.LoginDevice); err != nil {
+	if err := h.userRepo.UpdateLoginInfo(r.Context(), uint(id), clientip.FromRequest(r), req.LoginDevice); err != nil {
 		log.WithError(err).WithField("user_id", id).Error("Failed to update login info")
-		http.Error(w, "Error updating login info", http.StatusInternalServerError)
+		http.Error(w, i18n.T(r.Context(), "Error updating login info"), http.StatusInternalServerError)
 		return
 	}
 
@@ -672,7 +796,7 @@ func (h *UserHandler) UpdateLoginInfo(w http.ResponseWriter, r *http.Request) {
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Login info updated successfully",
+		"message": i18n.T(r.Context(), "Login info updated successfully"),
 	})
 }
 
@@ -686,7 +810,7 @@ func (h *UserHandler) GetUserProfile(w http.ResponseWriter, r *http.Request) {
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "User profile endpoint not implemented yet",
+		"message": i18n.T(r.Context(), "User profile endpoint not implemented yet"),
 		"user_id": idStr,
 	})
 }
@@ -701,7 +825,7 @@ func (h *UserHandler) GetUserSettings(w http.ResponseWriter, r *http.Request) {
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "User settings endpoint not implemented yet",
+		"message": i18n.T(r.Context(), "User settings endpoint not implemented yet"),
 		"user_id": idStr,
 	})
 }
@@ -716,7 +840,7 @@ func (h *UserHandler) GetUserStats(w http.ResponseWriter, r *http.Request) {
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "User stats endpoint not implemented yet",
+		"message": i18n.T(r.Context(), "User stats endpoint not implemented yet"),
 		"user_id": idStr,
 	})
 }
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+
+	"it-app_user/internal/audit"
+	"it-app_user/internal/i18n"
+	"it-app_user/internal/logger"
+	"it-app_user/internal/models"
+	"it-app_user/internal/repositories"
+	"it-app_user/internal/services"
+	"it-app_user/internal/validator"
+)
+
+type UserHistoryHandler struct {
+	historyService *services.UserHistoryService
+}
+
+func NewUserHistoryHandler(historyService *services.UserHistoryService) *UserHistoryHandler {
+	return &UserHistoryHandler{historyService: historyService}
+}
+
+// ListUserHistory maneja GET /admin/users/{id}/history: versiones del usuario
+// y de su perfil, de la más reciente a la más antigua
+func (h *UserHistoryHandler) ListUserHistory(w http.ResponseWriter, r *http.Request) {
+	log := logger.GetLogger()
+	query := r.URL.Query()
+
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, i18n.T(r.Context(), "Invalid user ID"), http.StatusBadRequest)
+		return
+	}
+
+	filter := repositories.RecordVersionFilter{
+		UserID: uint(id),
+		Entity: query.Get("entity"),
+		Limit:  50,
+	}
+	if filter.Entity != "" && filter.Entity != models.VersionEntityUser && filter.Entity != models.VersionEntityProfile {
+		http.Error(w, i18n.T(r.Context(), "Invalid entity, expected users or user_profiles"), http.StatusBadRequest)
+		return
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 200 {
+			filter.Limit = l
+		}
+	}
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
+			filter.Offset = o
+		}
+	}
+
+	versions, err := h.historyService.List(r.Context(), filter)
+	if err != nil {
+		log.WithError(err).WithField("user_id", id).Error("Failed to list user history")
+		http.Error(w, i18n.T(r.Context(), "Error retrieving user history"), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    versions,
+		"count":   len(versions),
+		"limit":   filter.Limit,
+		"offset":  filter.Offset,
+		"message": i18n.T(r.Context(), "User history retrieved successfully"),
+	})
+}
+
+// GetUserAsOf maneja GET /admin/users/{id}/as-of?at=...: el usuario y su
+// perfil tal como estaban en ese momento
+func (h *UserHistoryHandler) GetUserAsOf(w http.ResponseWriter, r *http.Request) {
+	log := logger.GetLogger()
+
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, i18n.T(r.Context(), "Invalid user ID"), http.StatusBadRequest)
+		return
+	}
+	at, err := time.Parse(time.RFC3339, r.URL.Query().Get("at"))
+	if err != nil {
+		http.Error(w, i18n.T(r.Context(), "Invalid date, expected RFC 3339"), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.historyService.AsOf(r.Context(), uint(id), at)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, i18n.T(r.Context(), "No version found at that date"), http.StatusNotFound)
+			return
+		}
+		log.WithError(err).WithField("user_id", id).Error("Failed to get user as of date")
+		http.Error(w, i18n.T(r.Context(), "Error retrieving user history"), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":    result,
+		"message": i18n.T(r.Context(), "User history retrieved successfully"),
+	})
+}
+
+// RevertUser maneja POST /admin/users/{id}/revert: vuelve los campos indicados
+// a los valores de una versión anterior y lo registra en la auditoría
+func (h *UserHistoryHandler) RevertUser(w http.ResponseWriter, r *http.Request) {
+	log := logger.GetLogger()
+
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		http.Error(w, i18n.T(r.Context(), "Invalid user ID"), http.StatusBadRequest)
+		return
+	}
+
+	var req models.RevertVersionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
+		return
+	}
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.historyService.Revert(r.Context(), uint(id), req.VersionID, req.Fields)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrVersionNotFound):
+			http.Error(w, i18n.T(r.Context(), "Version not found"), http.StatusNotFound)
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			http.Error(w, i18n.T(r.Context(), "User not found"), http.StatusNotFound)
+		case errors.Is(err, services.ErrFieldNotRevertible):
+			http.Error(w, i18n.T(r.Context(), "Field cannot be reverted"), http.StatusBadRequest)
+		case errors.Is(err, services.ErrRevertConflict):
+			http.Error(w, i18n.T(r.Context(), "Reverted value is already in use"), http.StatusConflict)
+		default:
+			log.WithError(err).WithField("user_id", id).Error("Failed to revert user")
+			http.Error(w, i18n.T(r.Context(), "Error reverting user record"), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if changes := audit.Diff(result.Before, result.After); len(changes) > 0 {
+		event := audit.Event{
+			Action:       audit.ActionUserReverted,
+			TargetUserID: uint(id),
+			Changes:      changes,
+			Metadata: map[string]interface{}{
+				"entity":     result.Version.Entity,
+				"version_id": result.Version.ID,
+				"version":    result.Version.Version,
+			},
+		}
+		if user, ok := result.After.(*models.User); ok {
+			event.TargetFirebaseID = user.FirebaseID
+		}
+		audit.Record(r.Context(), audit.FromRequest(r, event))
+	}
+
+	log.WithFields(map[string]interface{}{
+		"user_id":    id,
+		"version_id": req.VersionID,
+	}).Info("User record reverted")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"data": map[string]interface{}{
+			"entity":     result.Version.Entity,
+			"version_id": result.Version.ID,
+			"record":     result.After,
+		},
+		"message": i18n.T(r.Context(), "User record reverted successfully"),
+	})
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gorilla/mux"
+
+	"it-app_user/internal/audit"
+	"it-app_user/internal/dbtest"
+	"it-app_user/internal/models"
+	"it-app_user/internal/repositories"
+	"it-app_user/internal/services"
+)
+
+// auditRecorder guarda los eventos de auditoría de la prueba
+type auditRecorder struct {
+	events []models.AuditEvent
+}
+
+func (r *auditRecorder) Append(ctx context.Context, event *models.AuditEvent) error {
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func newTestHistoryHandler(t *testing.T) (*UserHistoryHandler, sqlmock.Sqlmock, *auditRecorder) {
+	t.Helper()
+	db, mock := dbtest.NewMockDB(t)
+	recorder := &auditRecorder{}
+	audit.SetStore(recorder)
+	t.Cleanup(func() { audit.SetStore(nil) })
+	service := services.NewUserHistoryService(repositories.NewRecordVersionRepository(db), repositories.NewTxManager(db))
+	return NewUserHistoryHandler(service), mock, recorder
+}
+
+func historyRequest(method, path, body string) *http.Request {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r = mux.SetURLVars(r, map[string]string{"id": "5"})
+	return r.WithContext(context.WithValue(r.Context(), "user_id", "uid-admin"))
+}
+
+// expectHistoryVersion simula la lectura de la versión 9 del usuario 5
+func expectHistoryVersion(mock sqlmock.Sqlmock, entity, data string) {
+	mock.ExpectQuery(`SELECT \* FROM "record_versions" WHERE "record_versions"."id" = \$1`).
+		WithArgs(9).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "entity", "record_id", "user_id", "version", "operation", "data"}).
+			AddRow(9, entity, 5, 5, 2, models.VersionOperationUpdate, data))
+}
+
+func TestGetUserAsOfBeforeFirstVersion(t *testing.T) {
+	handler, mock, _ := newTestHistoryHandler(t)
+	mock.ExpectQuery(`SELECT \* FROM "record_versions" WHERE entity = \$1 AND user_id = \$2 AND valid_from <= \$3`).
+		WithArgs(models.VersionEntityUser, 5, time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	w := httptest.NewRecorder()
+	handler.GetUserAsOf(w, historyRequest(http.MethodGet, "/admin/users/5/as-of?at=2020-01-01T00:00:00Z", ""))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status = %d, want 404: %s", w.Code, w.Body)
+	}
+}
+
+func TestRevertUserRejectsFieldNotRevertible(t *testing.T) {
+	handler, mock, recorder := newTestHistoryHandler(t)
+	expectHistoryVersion(mock, models.VersionEntityUser, `{"username":"ana_old","email":"old@example.com"}`)
+
+	w := httptest.NewRecorder()
+	handler.RevertUser(w, historyRequest(http.MethodPost, "/admin/users/5/revert", `{"version_id":9,"fields":["username","email"]}`))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400: %s", w.Code, w.Body)
+	}
+	if len(recorder.events) != 0 {
+		t.Errorf("audit events = %+v, want none", recorder.events)
+	}
+}
+
+func TestRevertUserAuditsDiff(t *testing.T) {
+	tests := []struct {
+		name       string
+		entity     string
+		data       string
+		body       string
+		expect     func(mock sqlmock.Sqlmock)
+		want       map[string]models.AuditChange
+		firebaseID string
+	}{
+		{
+			name:   "user",
+			entity: models.VersionEntityUser,
+			data:   `{"username":"ana_old","first_name":"Ana","status":"inactive"}`,
+			// first_name ya tiene el valor de la versión: no aparece en el diff
+			body: `{"version_id":9,"fields":["username","first_name"]}`,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1`).
+					WithArgs(5).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "firebase_id", "username", "first_name", "status"}).
+						AddRow(5, "uid-ana", "ana", "Ana", "active"))
+				mock.ExpectExec(`UPDATE "users" SET`).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			want:       map[string]models.AuditChange{"username": {From: "ana", To: "ana_old"}},
+			firebaseID: "uid-ana",
+		},
+		{
+			name:   "profile",
+			entity: models.VersionEntityProfile,
+			data:   `{"birthday":"1990-05-01T00:00:00Z","bio":"old bio"}`,
+			body:   `{"version_id":9,"fields":["birthday"]}`,
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT \* FROM "user_profiles" WHERE user_id = \$1`).
+					WithArgs(5).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "bio", "birthday"}).
+						AddRow(3, 5, "new bio", time.Date(1991, 1, 1, 0, 0, 0, 0, time.UTC)))
+				mock.ExpectExec(`UPDATE "user_profiles" SET`).WillReturnResult(sqlmock.NewResult(0, 1))
+			},
+			want: map[string]models.AuditChange{"birthday": {From: "1991-01-01T00:00:00Z", To: "1990-05-01T00:00:00Z"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mock, recorder := newTestHistoryHandler(t)
+			expectHistoryVersion(mock, tt.entity, tt.data)
+			mock.ExpectBegin()
+			tt.expect(mock)
+			mock.ExpectCommit()
+
+			w := httptest.NewRecorder()
+			handler.RevertUser(w, historyRequest(http.MethodPost, "/admin/users/5/revert", tt.body))
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", w.Code, w.Body)
+			}
+
+			if len(recorder.events) != 1 {
+				t.Fatalf("audit events = %d, want 1", len(recorder.events))
+			}
+			event := recorder.events[0]
+			if event.Action != audit.ActionUserReverted || event.ActorID != "uid-admin" || event.TargetUserID != 5 || event.TargetFirebaseID != tt.firebaseID {
+				t.Errorf("event = %+v, want user.reverted of user 5 by uid-admin", event)
+			}
+			if !reflect.DeepEqual(event.Changes, tt.want) {
+				t.Errorf("changes = %+v, want %+v", event.Changes, tt.want)
+			}
+			if event.Metadata["entity"] != tt.entity || event.Metadata["version_id"] != uint(9) {
+				t.Errorf("metadata = %v, want entity %s and version 9", event.Metadata, tt.entity)
+			}
+		})
+	}
+}
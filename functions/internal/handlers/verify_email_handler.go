@@ -1,11 +1,11 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
 	"io"
 	"net/http"
 
+	"it-app_user/internal/i18n"
 	"it-app_user/internal/logger"
 	"it-app_user/internal/models"
 	"it-app_user/internal/repositories"
@@ -16,12 +16,14 @@ import (
 type VerifyEmailHandler struct {
 	firebaseAuth *firebase.Auth
 	emailRepo    repositories.EmailVerificationRepositoryInterface
+	settings     models.EmailVerificationSettings
 }
 
-func NewVerifyEmailHandler(firebaseAuth *firebase.Auth, emailRepo repositories.EmailVerificationRepositoryInterface) *VerifyEmailHandler {
+func NewVerifyEmailHandler(firebaseAuth *firebase.Auth, emailRepo repositories.EmailVerificationRepositoryInterface, settings models.EmailVerificationSettings) *VerifyEmailHandler {
 	return &VerifyEmailHandler{
 		firebaseAuth: firebaseAuth,
 		emailRepo:    emailRepo,
+		settings:     settings,
 	}
 }
 
@@ -33,18 +35,18 @@ func (h *VerifyEmailHandler) SendVerificationEmail(w http.ResponseWriter, r *htt
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.WithError(err).Error("Failed to read request body")
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Error reading request body"), http.StatusBadRequest)
 		return
 	}
 
 	if err := json.Unmarshal(body, &req); err != nil {
 		log.WithError(err).Error("Failed to unmarshal JSON")
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
 		return
 	}
 
 	// Validar estructura
-	if err := validator.ValidateStruct(&req); err != nil {
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
 		log.WithError(err).Warn("Validation failed for send verification email request")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -52,18 +54,18 @@ func (h *VerifyEmailHandler) SendVerificationEmail(w http.ResponseWriter, r *htt
 
 	if h.firebaseAuth == nil {
 		log.Error("Firebase Auth not configured")
-		http.Error(w, "Authentication service not available", http.StatusServiceUnavailable)
+		http.Error(w, i18n.T(r.Context(), "Authentication service not available"), http.StatusServiceUnavailable)
 		return
 	}
 
 	// Verificar que el usuario existe
-	userRecord, err := h.firebaseAuth.GetUserByEmail(context.Background(), req.Email)
+	userRecord, err := h.firebaseAuth.GetUserByEmail(r.Context(), req.Email)
 	if err != nil {
 		log.WithError(err).WithField("email", req.Email).Warn("User not found")
 		// Por seguridad, no revelamos si el email existe o no
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]interface{}{
-			"message": "If the email exists, a verification email has been sent",
+			"message": i18n.T(r.Context(), "If the email exists, a verification email has been sent"),
 		})
 		return
 	}
@@ -74,7 +76,7 @@ func (h *VerifyEmailHandler) SendVerificationEmail(w http.ResponseWriter, r *htt
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Verification email sent successfully",
+		"message": i18n.T(r.Context(), "Verification email sent successfully"),
 	})
 }
 
@@ -86,18 +88,18 @@ func (h *VerifyEmailHandler) VerifyEmail(w http.ResponseWriter, r *http.Request)
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.WithError(err).Error("Failed to read request body")
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Error reading request body"), http.StatusBadRequest)
 		return
 	}
 
 	if err := json.Unmarshal(body, &req); err != nil {
 		log.WithError(err).Error("Failed to unmarshal JSON")
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
 		return
 	}
 
 	// Validar estructura
-	if err := validator.ValidateStruct(&req); err != nil {
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
 		log.WithError(err).Warn("Validation failed for verify email request")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -105,30 +107,30 @@ func (h *VerifyEmailHandler) VerifyEmail(w http.ResponseWriter, r *http.Request)
 
 	if h.firebaseAuth == nil {
 		log.Error("Firebase Auth not configured")
-		http.Error(w, "Authentication service not available", http.StatusServiceUnavailable)
+		http.Error(w, i18n.T(r.Context(), "Authentication service not available"), http.StatusServiceUnavailable)
 		return
 	}
 
 	// Verificar el token de verificación
-	token, err := h.firebaseAuth.VerifyIDToken(context.Background(), req.IDToken)
+	token, err := h.firebaseAuth.VerifyIDToken(r.Context(), req.IDToken)
 	if err != nil {
 		log.WithError(err).Warn("Invalid Firebase token")
-		http.Error(w, "Invalid token", http.StatusUnauthorized)
+		http.Error(w, i18n.T(r.Context(), "Invalid token"), http.StatusUnauthorized)
 		return
 	}
 
 	// Obtener información actualizada del usuario
-	userRecord, err := h.firebaseAuth.GetUser(context.Background(), token.UID)
+	userRecord, err := h.firebaseAuth.GetUser(r.Context(), token.UID)
 	if err != nil {
 		log.WithError(err).Error("Failed to get user from Firebase")
-		http.Error(w, "Failed to get user information", http.StatusInternalServerError)
+		http.Error(w, i18n.T(r.Context(), "Failed to get user information"), http.StatusInternalServerError)
 		return
 	}
 
 	// Verificar si el email ya está verificado
 	if !userRecord.EmailVerified {
 		log.WithField("firebase_id", token.UID).Warn("Email not verified yet")
-		http.Error(w, "Email not verified yet", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Email not verified yet"), http.StatusBadRequest)
 		return
 	}
 
@@ -142,7 +144,7 @@ func (h *VerifyEmailHandler) VerifyEmail(w http.ResponseWriter, r *http.Request)
 			Username:      userRecord.DisplayName,
 			Status:        "active",
 		},
-		Message: "Email verified successfully",
+		Message: i18n.T(r.Context(), "Email verified successfully"),
 	}
 
 	log.WithField("firebase_id", token.UID).Info("Email verification confirmed")
@@ -157,7 +159,7 @@ func (h *VerifyEmailHandler) CheckEmailVerificationStatus(w http.ResponseWriter,
 
 	if h.firebaseAuth == nil {
 		log.Error("Firebase Auth not configured")
-		http.Error(w, "Authentication service not available", http.StatusServiceUnavailable)
+		http.Error(w, i18n.T(r.Context(), "Authentication service not available"), http.StatusServiceUnavailable)
 		return
 	}
 
@@ -165,15 +167,15 @@ func (h *VerifyEmailHandler) CheckEmailVerificationStatus(w http.ResponseWriter,
 	userID := r.Context().Value("user_id")
 	if userID == nil {
 		log.Warn("User ID not found in context")
-		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		http.Error(w, i18n.T(r.Context(), "Authentication required"), http.StatusUnauthorized)
 		return
 	}
 
 	// Obtener información del usuario de Firebase
-	userRecord, err := h.firebaseAuth.GetUser(context.Background(), userID.(string))
+	userRecord, err := h.firebaseAuth.GetUser(r.Context(), userID.(string))
 	if err != nil {
 		log.WithError(err).Error("Failed to get user from Firebase")
-		http.Error(w, "Failed to get user information", http.StatusInternalServerError)
+		http.Error(w, i18n.T(r.Context(), "Failed to get user information"), http.StatusInternalServerError)
 		return
 	}
 
@@ -196,28 +198,28 @@ func (h *VerifyEmailHandler) VerifyEmailWithCode(w http.ResponseWriter, r *http.
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.WithError(err).Error("Failed to read request body")
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Error reading request body"), http.StatusBadRequest)
 		return
 	}
 
 	if err := json.Unmarshal(body, &req); err != nil {
 		log.WithError(err).Error("Failed to unmarshal JSON")
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
 		return
 	}
 
 	// Validar estructura
-	if err := validator.ValidateStruct(&req); err != nil {
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
 		log.WithError(err).Warn("Validation failed for verify email with code request")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
 	// Buscar verificación por email
-	verification, err := h.emailRepo.GetByEmail(req.Email)
+	verification, err := h.emailRepo.GetByEmail(r.Context(), req.Email)
 	if err != nil {
 		log.WithError(err).WithField("email", req.Email).Warn("Email verification not found")
-		http.Error(w, "Invalid email or verification code", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid email or verification code"), http.StatusBadRequest)
 		return
 	}
 
@@ -225,15 +227,15 @@ func (h *VerifyEmailHandler) VerifyEmailWithCode(w http.ResponseWriter, r *http.
 	if verification.VerificationCode != req.VerificationCode {
 		log.WithField("email", req.Email).Warn("Invalid verification code")
 		// Incrementar intentos
-		h.emailRepo.IncrementAttempts(verification.UserID)
-		http.Error(w, "Invalid verification code", http.StatusBadRequest)
+		h.emailRepo.IncrementAttempts(r.Context(), verification.UserID)
+		http.Error(w, i18n.T(r.Context(), "Invalid verification code"), http.StatusBadRequest)
 		return
 	}
 
 	// Marcar como verificado
-	if err := h.emailRepo.MarkAsVerified(verification.UserID); err != nil {
+	if err := h.emailRepo.MarkAsVerified(r.Context(), verification.UserID); err != nil {
 		log.WithError(err).Error("Failed to mark email as verified")
-		http.Error(w, "Error verifying email", http.StatusInternalServerError)
+		http.Error(w, i18n.T(r.Context(), "Error verifying email"), http.StatusInternalServerError)
 		return
 	}
 
@@ -242,7 +244,7 @@ func (h *VerifyEmailHandler) VerifyEmailWithCode(w http.ResponseWriter, r *http.
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
-		"message": "Email verified successfully",
+		"message": i18n.T(r.Context(), "Email verified successfully"),
 	})
 }
 
@@ -254,18 +256,18 @@ func (h *VerifyEmailHandler) ResendVerificationEmail(w http.ResponseWriter, r *h
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.WithError(err).Error("Failed to read request body")
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Error reading request body"), http.StatusBadRequest)
 		return
 	}
 
 	if err := json.Unmarshal(body, &req); err != nil {
 		log.WithError(err).Error("Failed to unmarshal JSON")
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
 		return
 	}
 
 	// Validar estructura
-	if err := validator.ValidateStruct(&req); err != nil {
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
 		log.WithError(err).Warn("Validation failed for resend verification email request")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -275,7 +277,7 @@ func (h *VerifyEmailHandler) ResendVerificationEmail(w http.ResponseWriter, r *h
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "If the email exists, a verification email has been sent",
+		"message": i18n.T(r.Context(), "If the email exists, a verification email has been sent"),
 	})
 }
 
@@ -287,21 +289,21 @@ func (h *VerifyEmailHandler) GetMyVerificationStatus(w http.ResponseWriter, r *h
 	userID := r.Context().Value("user_id")
 	if userID == nil {
 		log.Warn("User ID not found in context")
-		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		http.Error(w, i18n.T(r.Context(), "Authentication required"), http.StatusUnauthorized)
 		return
 	}
 
 	if h.firebaseAuth == nil {
 		log.Error("Firebase Auth not configured")
-		http.Error(w, "Authentication service not available", http.StatusServiceUnavailable)
+		http.Error(w, i18n.T(r.Context(), "Authentication service not available"), http.StatusServiceUnavailable)
 		return
 	}
 
 	// Obtener información del usuario de Firebase
-	userRecord, err := h.firebaseAuth.GetUser(context.Background(), userID.(string))
+	userRecord, err := h.firebaseAuth.GetUser(r.Context(), userID.(string))
 	if err != nil {
 		log.WithError(err).Error("Failed to get user from Firebase")
-		http.Error(w, "Failed to get user information", http.StatusInternalServerError)
+		http.Error(w, i18n.T(r.Context(), "Failed to get user information"), http.StatusInternalServerError)
 		return
 	}
 
@@ -318,7 +320,7 @@ func (h *VerifyEmailHandler) GetMyVerificationStatus(w http.ResponseWriter, r *h
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"data":    response,
-		"message": "Verification status retrieved successfully",
+		"message": i18n.T(r.Context(), "Verification status retrieved successfully"),
 	})
 }
 
@@ -330,7 +332,7 @@ func (h *VerifyEmailHandler) UpdateEmail(w http.ResponseWriter, r *http.Request)
 	userID := r.Context().Value("user_id")
 	if userID == nil {
 		log.Warn("User ID not found in context")
-		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		http.Error(w, i18n.T(r.Context(), "Authentication required"), http.StatusUnauthorized)
 		return
 	}
 
@@ -341,18 +343,18 @@ func (h *VerifyEmailHandler) UpdateEmail(w http.ResponseWriter, r *http.Request)
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.WithError(err).Error("Failed to read request body")
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Error reading request body"), http.StatusBadRequest)
 		return
 	}
 
 	if err := json.Unmarshal(body, &req); err != nil {
 		log.WithError(err).Error("Failed to unmarshal JSON")
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
 		return
 	}
 
 	// Validar estructura
-	if err := validator.ValidateStruct(&req); err != nil {
+	if err := validator.ValidateStructCtx(r.Context(), &req); err != nil {
 		log.WithError(err).Warn("Validation failed for update email request")
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -362,7 +364,7 @@ func (h *VerifyEmailHandler) UpdateEmail(w http.ResponseWriter, r *http.Request)
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Email update functionality requires Firebase Admin SDK implementation",
+		"message": i18n.T(r.Context(), "Email update functionality requires Firebase Admin SDK implementation"),
 	})
 }
 
@@ -374,7 +376,7 @@ func (h *VerifyEmailHandler) GetVerificationHistory(w http.ResponseWriter, r *ht
 	userID := r.Context().Value("user_id")
 	if userID == nil {
 		log.Warn("User ID not found in context")
-		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		http.Error(w, i18n.T(r.Context(), "Authentication required"), http.StatusUnauthorized)
 		return
 	}
 
@@ -382,7 +384,7 @@ func (h *VerifyEmailHandler) GetVerificationHistory(w http.ResponseWriter, r *ht
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Verification history endpoint not implemented yet",
+		"message": i18n.T(r.Context(), "Verification history endpoint not implemented yet"),
 		"data":    []interface{}{},
 	})
 }
@@ -395,26 +397,16 @@ func (h *VerifyEmailHandler) GetEmailSettings(w http.ResponseWriter, r *http.Req
 	userID := r.Context().Value("user_id")
 	if userID == nil {
 		log.Warn("User ID not found in context")
-		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		http.Error(w, i18n.T(r.Context(), "Authentication required"), http.StatusUnauthorized)
 		return
 	}
 
-	// Configuraciones por defecto
-	settings := models.EmailVerificationSettings{
-		MaxAttempts:         5,
-		CodeExpirationTime:  3600, // 1 hora en segundos
-		ResendCooldownTime:  300,  // 5 minutos en segundos
-		RequireVerification: true,
-		AutoVerifyDomains:   []string{},
-		BlockedDomains:      []string{"tempmail.org", "10minutemail.com"},
-	}
-
 	log.WithField("user_id", userID).Info("Email settings retrieved")
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"data":    settings,
-		"message": "Email settings retrieved successfully",
+		"data":    h.settings,
+		"message": i18n.T(r.Context(), "Email settings retrieved successfully"),
 	})
 }
 
@@ -426,7 +418,7 @@ func (h *VerifyEmailHandler) UpdateEmailSettings(w http.ResponseWriter, r *http.
 	userID := r.Context().Value("user_id")
 	if userID == nil {
 		log.Warn("User ID not found in context")
-		http.Error(w, "Authentication required", http.StatusUnauthorized)
+		http.Error(w, i18n.T(r.Context(), "Authentication required"), http.StatusUnauthorized)
 		return
 	}
 
@@ -435,13 +427,13 @@ func (h *VerifyEmailHandler) UpdateEmailSettings(w http.ResponseWriter, r *http.
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.WithError(err).Error("Failed to read request body")
-		http.Error(w, "Error reading request body", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Error reading request body"), http.StatusBadRequest)
 		return
 	}
 
 	if err := json.Unmarshal(body, &req); err != nil {
 		log.WithError(err).Error("Failed to unmarshal JSON")
-		http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+		http.Error(w, i18n.T(r.Context(), "Invalid JSON format"), http.StatusBadRequest)
 		return
 	}
 
@@ -449,6 +441,6 @@ func (h *VerifyEmailHandler) UpdateEmailSettings(w http.ResponseWriter, r *http.
 	
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message": "Email settings update endpoint not implemented yet",
+		"message": i18n.T(r.Context(), "Email settings update endpoint not implemented yet"),
 	})
 }
\ No newline at end of file
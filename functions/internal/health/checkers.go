@@ -0,0 +1,122 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"it-app_user/internal/models"
+	"it-app_user/pkg/firebase"
+)
+
+// DatabaseChecker hace ping a PostgreSQL
+func DatabaseChecker(db *gorm.DB) Checker {
+	return Checker{
+		Name:     "database",
+		Critical: true,
+		Check: func(ctx context.Context) error {
+			if db == nil {
+				return errors.New("not connected")
+			}
+			sqlDB, err := db.DB()
+			if err != nil {
+				return err
+			}
+			// El error del driver incluye host y usuario; se registra en el log pero no se expone
+			if err := sqlDB.PingContext(ctx); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return errors.New("ping failed")
+			}
+			return nil
+		},
+	}
+}
+
+// MigrationChecker verifica que el esquema esté al menos en la versión que espera este binario
+func MigrationChecker(db *gorm.DB) Checker {
+	return Checker{
+		Name:     "migrations",
+		Critical: true,
+		Check: func(ctx context.Context) error {
+			if db == nil {
+				return errors.New("not connected")
+			}
+			version, err := models.CurrentSchemaVersion(db.WithContext(ctx))
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return errors.New("failed to read schema version")
+			}
+			if version < models.SchemaVersion {
+				return fmt.Errorf("schema version %d is behind expected %d", version, models.SchemaVersion)
+			}
+			return nil
+		},
+	}
+}
+
+// CanonicalIndexChecker marca el componente como degradado mientras falte algún
+// índice único de email o username canónico, es decir, mientras haya
+// colisiones sin resolver (ver models.FindCanonicalCollisions). No es crítico:
+// el servicio funciona, pero la unicidad solo la garantizan los índices originales.
+func CanonicalIndexChecker(db *gorm.DB) Checker {
+	return Checker{
+		Name:     "canonical_uniqueness",
+		Critical: false,
+		Check: func(ctx context.Context) error {
+			if db == nil {
+				return errors.New("not connected")
+			}
+			missing, err := models.MissingCanonicalIndexes(db.WithContext(ctx))
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return errors.New("failed to read indexes")
+			}
+			if len(missing) > 0 {
+				return fmt.Errorf("missing unique indexes %v: unresolved canonical collisions", missing)
+			}
+			return nil
+		},
+	}
+}
+
+// FirebaseChecker verifica las credenciales de Firebase y las claves públicas de los ID tokens.
+// Si Firebase no se pudo inicializar el componente figura como caído.
+func FirebaseChecker(firebaseAuth *firebase.Auth) Checker {
+	return Checker{
+		Name:     "firebase",
+		Critical: true,
+		Check: func(ctx context.Context) error {
+			if firebaseAuth == nil {
+				return errors.New("not initialized")
+			}
+			return firebaseAuth.HealthCheck(ctx)
+		},
+	}
+}
+
+// BacklogChecker marca el componente como degradado cuando la cantidad de
+// eventos pendientes (p. ej. un outbox) supera maxPending. No es crítico:
+// un backlog alto no impide atender requests.
+func BacklogChecker(name string, pending func(ctx context.Context) (int64, error), maxPending int64) Checker {
+	return Checker{
+		Name: name,
+		Check: func(ctx context.Context) error {
+			count, err := pending(ctx)
+			if err != nil {
+				return err
+			}
+			if count > maxPending {
+				return fmt.Errorf("%d pending events (max %d)", count, maxPending)
+			}
+			return nil
+		},
+	}
+}
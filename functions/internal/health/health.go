@@ -0,0 +1,174 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"it-app_user/internal/logger"
+	"it-app_user/internal/metrics"
+)
+
+// Estados posibles de un componente y del servicio
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded" // falló un checker no crítico: el servicio sigue recibiendo tráfico
+	StatusDown     = "down"
+)
+
+// Checker es una prueba de una dependencia del servicio
+type Checker struct {
+	Name string
+	// Critical indica si un fallo saca al servicio de rotación (503 en /readyz)
+	Critical bool
+	// Timeout de la prueba; 0 usa el timeout del registry
+	Timeout time.Duration
+	Check   func(ctx context.Context) error
+}
+
+// ComponentResult es el resultado de un checker
+type ComponentResult struct {
+	Status     string `json:"status"`
+	Critical   bool   `json:"critical"`
+	DurationMS int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Report es el resultado de ejecutar todos los checkers
+type Report struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentResult `json:"components"`
+	CheckedAt  time.Time                  `json:"checked_at"`
+}
+
+// Registry ejecuta los checkers registrados y cachea el resultado durante
+// cacheTTL, para que los probes frecuentes no saturen las dependencias
+type Registry struct {
+	checkers []Checker
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	mu     sync.Mutex
+	cached *Report
+}
+
+// NewRegistry crea un registry vacío
+func NewRegistry(timeout, cacheTTL time.Duration) *Registry {
+	return &Registry{
+		timeout:  timeout,
+		cacheTTL: cacheTTL,
+	}
+}
+
+// Register agrega un checker. Debe llamarse antes de servir tráfico.
+func (r *Registry) Register(checker Checker) {
+	r.checkers = append(r.checkers, checker)
+}
+
+// Run devuelve el último reporte si sigue vigente o ejecuta todos los checkers en paralelo.
+// Las requests concurrentes esperan al mismo reporte en lugar de repetir las pruebas.
+func (r *Registry) Run(ctx context.Context) Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.cached != nil && time.Since(r.cached.CheckedAt) < r.cacheTTL {
+		return *r.cached
+	}
+
+	report := Report{
+		Status:     StatusOK,
+		Components: make(map[string]ComponentResult, len(r.checkers)),
+		CheckedAt:  time.Now(),
+	}
+
+	results := make([]ComponentResult, len(r.checkers))
+	var wg sync.WaitGroup
+	for i, checker := range r.checkers {
+		wg.Add(1)
+		go func(i int, checker Checker) {
+			defer wg.Done()
+			results[i] = r.runChecker(ctx, checker)
+		}(i, checker)
+	}
+	wg.Wait()
+
+	for i, checker := range r.checkers {
+		result := results[i]
+		report.Components[checker.Name] = result
+		if result.Status == StatusOK {
+			continue
+		}
+		if checker.Critical {
+			report.Status = StatusDown
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+
+	r.cached = &report
+	return report
+}
+
+func (r *Registry) runChecker(ctx context.Context, checker Checker) ComponentResult {
+	timeout := checker.Timeout
+	if timeout == 0 {
+		timeout = r.timeout
+	}
+	// El probe no debe cancelar una prueba que también usarán otras requests
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := checker.Check(ctx)
+	result := ComponentResult{
+		Status:     StatusOK,
+		Critical:   checker.Critical,
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || ctx.Err() == context.DeadlineExceeded {
+			err = errors.New("timeout")
+		}
+		result.Status = StatusDown
+		result.Error = err.Error()
+		logger.GetLogger().WithError(err).WithField("component", checker.Name).Warn("Health check failed")
+		metrics.HealthCheckUp.WithLabelValues(checker.Name).Set(0)
+	} else {
+		metrics.HealthCheckUp.WithLabelValues(checker.Name).Set(1)
+	}
+
+	return result
+}
+
+// LivenessHandler maneja GET /healthz. Solo indica que el proceso responde:
+// no consulta dependencias para que una caída de Postgres no reinicie instancias.
+func LivenessHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    StatusOK,
+		"service":   "user-service",
+		"timestamp": time.Now().Unix(),
+	})
+}
+
+// ReadinessHandler maneja GET /readyz. Devuelve 503 si falla algún checker
+// crítico, para que Cloud Run/Kubernetes dejen de enviar tráfico a la instancia.
+func (r *Registry) ReadinessHandler(w http.ResponseWriter, req *http.Request) {
+	report := r.Run(req.Context())
+
+	code := http.StatusOK
+	if report.Status == StatusDown {
+		code = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,179 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// staticChecker devuelve siempre el mismo error y cuenta sus ejecuciones
+func staticChecker(name string, critical bool, err error, calls *int32) Checker {
+	return Checker{
+		Name:     name,
+		Critical: critical,
+		Check: func(ctx context.Context) error {
+			if calls != nil {
+				atomic.AddInt32(calls, 1)
+			}
+			return err
+		},
+	}
+}
+
+func TestRegistryStatus(t *testing.T) {
+	failure := errors.New("unavailable")
+	tests := []struct {
+		name     string
+		checkers []Checker
+		status   string
+		code     int
+	}{
+		{name: "no checkers", status: StatusOK, code: http.StatusOK},
+		{name: "all ok", checkers: []Checker{
+			staticChecker("database", true, nil, nil),
+			staticChecker("backlog", false, nil, nil),
+		}, status: StatusOK, code: http.StatusOK},
+		{name: "non-critical failure", checkers: []Checker{
+			staticChecker("database", true, nil, nil),
+			staticChecker("backlog", false, failure, nil),
+		}, status: StatusDegraded, code: http.StatusOK},
+		{name: "critical failure", checkers: []Checker{
+			staticChecker("database", true, failure, nil),
+			staticChecker("backlog", false, failure, nil),
+		}, status: StatusDown, code: http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry(time.Second, 0)
+			for _, checker := range tt.checkers {
+				registry.Register(checker)
+			}
+
+			w := httptest.NewRecorder()
+			registry.ReadinessHandler(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if w.Code != tt.code {
+				t.Errorf("status code = %d, want %d", w.Code, tt.code)
+			}
+			if cache := w.Header().Get("Cache-Control"); cache != "no-store" {
+				t.Errorf("Cache-Control = %q, want no-store", cache)
+			}
+
+			var report Report
+			if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+				t.Fatal(err)
+			}
+			if report.Status != tt.status {
+				t.Errorf("status = %q, want %q", report.Status, tt.status)
+			}
+			for _, checker := range tt.checkers {
+				component := report.Components[checker.Name]
+				if component.Critical != checker.Critical || (component.Status == StatusOK) != (component.Error == "") {
+					t.Errorf("component %s = %+v", checker.Name, component)
+				}
+			}
+		})
+	}
+}
+
+func TestRegistryCachesReport(t *testing.T) {
+	var calls int32
+	registry := NewRegistry(time.Second, time.Hour)
+	registry.Register(staticChecker("database", true, nil, &calls))
+
+	// Las requests concurrentes comparten una única ejecución de los checkers
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			registry.Run(context.Background())
+		}()
+	}
+	wg.Wait()
+	if calls != 1 {
+		t.Errorf("checker ran %d times within the cache TTL, want 1", calls)
+	}
+
+	uncached := NewRegistry(time.Second, 0)
+	uncached.Register(staticChecker("database", true, nil, &calls))
+	uncached.Run(context.Background())
+	uncached.Run(context.Background())
+	if calls != 3 {
+		t.Errorf("checker ran %d times without cache, want 3", calls)
+	}
+}
+
+func TestRegistryTimeout(t *testing.T) {
+	registry := NewRegistry(time.Hour, 0)
+	registry.Register(Checker{
+		Name:     "slow",
+		Critical: true,
+		Timeout:  10 * time.Millisecond,
+		Check: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+
+	// Cancelar la request no cancela la prueba: vence por su propio timeout
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report := registry.Run(ctx)
+	if component := report.Components["slow"]; component.Status != StatusDown || component.Error != "timeout" {
+		t.Errorf("component = %+v, want down with a timeout error", component)
+	}
+}
+
+func TestLivenessHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	LivenessHandler(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || body["status"] != StatusOK {
+		t.Errorf("status code = %d, body = %v, want 200 ok", w.Code, body)
+	}
+}
+
+func TestCheckersWithoutDependencies(t *testing.T) {
+	for _, checker := range []Checker{DatabaseChecker(nil), MigrationChecker(nil), FirebaseChecker(nil)} {
+		if !checker.Critical {
+			t.Errorf("%s checker is not critical", checker.Name)
+		}
+		if err := checker.Check(context.Background()); err == nil {
+			t.Errorf("%s checker passed without a dependency", checker.Name)
+		}
+	}
+}
+
+func TestBacklogChecker(t *testing.T) {
+	tests := []struct {
+		pending int64
+		err     error
+		ok      bool
+	}{
+		{pending: 0, ok: true},
+		{pending: 100, ok: true},
+		{pending: 101},
+		{err: errors.New("query failed")},
+	}
+	for _, tt := range tests {
+		checker := BacklogChecker("outbox", func(ctx context.Context) (int64, error) {
+			return tt.pending, tt.err
+		}, 100)
+		if checker.Critical {
+			t.Error("backlog checker is critical")
+		}
+		if err := checker.Check(context.Background()); (err == nil) != tt.ok {
+			t.Errorf("pending %d, err %v: Check() = %v, want ok = %v", tt.pending, tt.err, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package i18n
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// DefaultLanguage es el idioma usado cuando no se puede negociar otro.
+// El servicio está orientado primero a usuarios hispanohablantes.
+const DefaultLanguage = "es"
+
+// SupportedLanguages lista los idiomas disponibles, en orden de preferencia
+var SupportedLanguages = []string{"es", "en", "fr", "de", "it", "pt"}
+
+var matcher = language.NewMatcher([]language.Tag{
+	language.Spanish,
+	language.English,
+	language.French,
+	language.German,
+	language.Italian,
+	language.Portuguese,
+})
+
+type contextKey struct{}
+
+// WithLanguage devuelve un contexto que transporta el idioma indicado
+func WithLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, contextKey{}, Normalize(lang))
+}
+
+// FromContext obtiene el idioma del contexto o el idioma por defecto
+func FromContext(ctx context.Context) string {
+	if ctx != nil {
+		if lang, ok := ctx.Value(contextKey{}).(string); ok && lang != "" {
+			return lang
+		}
+	}
+	return DefaultLanguage
+}
+
+// IsSupported indica si el idioma (o su idioma base, p.ej. "pt-BR") está soportado
+func IsSupported(lang string) bool {
+	base := baseOf(lang)
+	for _, supported := range SupportedLanguages {
+		if supported == base {
+			return true
+		}
+	}
+	return false
+}
+
+// Normalize reduce un código de idioma a su base soportada, o al idioma por defecto
+func Normalize(lang string) string {
+	if IsSupported(lang) {
+		return baseOf(lang)
+	}
+	return DefaultLanguage
+}
+
+// FromAcceptLanguage negocia el idioma a partir de un header Accept-Language
+func FromAcceptLanguage(header string) string {
+	if strings.TrimSpace(header) == "" {
+		return DefaultLanguage
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(header)
+	if err != nil || len(tags) == 0 {
+		return DefaultLanguage
+	}
+
+	_, index, confidence := matcher.Match(tags...)
+	if confidence == language.No {
+		return DefaultLanguage
+	}
+	return SupportedLanguages[index]
+}
+
+// FromRequest negocia el idioma de una request HTTP
+func FromRequest(r *http.Request) string {
+	return FromAcceptLanguage(r.Header.Get("Accept-Language"))
+}
+
+// T traduce un mensaje al idioma del contexto.
+// Las claves del catálogo son los mensajes en inglés; si no existe
+// traducción se devuelve el mensaje original.
+func T(ctx context.Context, message string) string {
+	return Translate(FromContext(ctx), message)
+}
+
+// Translate traduce un mensaje al idioma indicado
+func Translate(lang string, message string) string {
+	if messages, ok := catalog[Normalize(lang)]; ok {
+		if translated, ok := messages[message]; ok {
+			return translated
+		}
+	}
+	return message
+}
+
+func baseOf(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	return lang
+}
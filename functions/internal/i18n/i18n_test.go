@@ -0,0 +1,104 @@
+package i18n
+
+import (
+	"context"
+	"testing"
+)
+
+func TestFromAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{header: "", want: "es"},
+		{header: "   ", want: "es"},
+		{header: "en", want: "en"},
+		{header: "en-US,en;q=0.9", want: "en"},
+		{header: "pt-BR", want: "pt"},
+		{header: "de-CH;q=0.8, fr;q=0.9", want: "fr"},
+		{header: "ja, it;q=0.5", want: "it"},
+		{header: "ja, zh", want: "es"},
+		{header: "*", want: "es"},
+		{header: ";;;", want: "es"},
+	}
+	for _, tt := range tests {
+		if got := FromAcceptLanguage(tt.header); got != tt.want {
+			t.Errorf("FromAcceptLanguage(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{lang: "en", want: "en"},
+		{lang: " EN ", want: "en"},
+		{lang: "pt-BR", want: "pt"},
+		{lang: "fr_CA", want: "fr"},
+		{lang: "ja", want: "es"},
+		{lang: "", want: "es"},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.lang); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		lang    string
+		message string
+		want    string
+	}{
+		{lang: "es", message: "User not found", want: "Usuario no encontrado"},
+		{lang: "es-AR", message: "User not found", want: "Usuario no encontrado"},
+		{lang: "ja", message: "User not found", want: "Usuario no encontrado"},
+		{lang: "en", message: "User not found", want: "User not found"},
+		{lang: "es", message: "message without translation", want: "message without translation"},
+	}
+	for _, tt := range tests {
+		if got := Translate(tt.lang, tt.message); got != tt.want {
+			t.Errorf("Translate(%q, %q) = %q, want %q", tt.lang, tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestTUsesContextLanguage(t *testing.T) {
+	if got := T(context.Background(), "User not found"); got != "Usuario no encontrado" {
+		t.Errorf("T without language = %q, want the default language", got)
+	}
+	ctx := WithLanguage(context.Background(), "fr-FR")
+	if got := FromContext(ctx); got != "fr" {
+		t.Errorf("FromContext = %q, want fr", got)
+	}
+	if got := T(ctx, "User not found"); got == "User not found" || got != catalog["fr"]["User not found"] {
+		t.Errorf("T in French = %q, want the French translation", got)
+	}
+}
+
+// Todos los idiomas traducen los mismos mensajes que el catálogo en español
+func TestCatalogIsComplete(t *testing.T) {
+	for _, lang := range SupportedLanguages {
+		if lang == "en" {
+			continue
+		}
+		messages, ok := catalog[lang]
+		if !ok {
+			t.Errorf("catalog has no %s messages", lang)
+			continue
+		}
+		for message := range catalog[DefaultLanguage] {
+			if messages[message] == "" {
+				t.Errorf("%s: missing translation for %q", lang, message)
+			}
+		}
+		for message := range messages {
+			if _, ok := catalog[DefaultLanguage][message]; !ok {
+				t.Errorf("%s: %q is not in the %s catalog", lang, message, DefaultLanguage)
+			}
+		}
+	}
+}
@@ -0,0 +1,1251 @@
+package i18n
+
+// catalog contiene las traducciones de los mensajes de la API.
+// La clave es el mensaje en inglés, que también se usa como respaldo.
+var catalog = map[string]map[string]string{
+	"es": {
+		// Generales
+		"validation failed":                    "la validación falló",
+		"Error reading request body":           "Error al leer el cuerpo de la solicitud",
+		"Invalid JSON format":                  "Formato JSON inválido",
+		"Authentication required":              "Se requiere autenticación",
+		"Authentication service not available": "El servicio de autenticación no está disponible",
+		"Authorization header required":        "Se requiere el header Authorization",
+		"Invalid Authorization header format":  "Formato del header Authorization inválido",
+		"Rate limit exceeded":                  "Límite de solicitudes excedido",
+		"Request timed out":                    "La solicitud excedió el tiempo máximo",
+
+		// Usuarios
+		"Users retrieved successfully":                    "Usuarios obtenidos correctamente",
+		"User retrieved successfully":                     "Usuario obtenido correctamente",
+		"User created successfully":                       "Usuario creado correctamente",
+		"User updated successfully":                       "Usuario actualizado correctamente",
+		"User deleted successfully":                       "Usuario eliminado correctamente",
+		"User restored successfully":                      "Usuario restaurado correctamente",
+		"Data export requested successfully":              "Exportación de datos solicitada correctamente",
+		"Data export already requested":                   "La exportación de datos ya fue solicitada",
+		"Data export retrieved successfully":              "Exportación de datos obtenida correctamente",
+		"Data export not found":                           "Exportación de datos no encontrada",
+		"Invalid export ID":                               "ID de exportación inválido",
+		"Error requesting data export":                    "Error al solicitar la exportación de datos",
+		"Error retrieving data export":                    "Error al obtener la exportación de datos",
+		"Deleted user not found":                          "Usuario eliminado no encontrado",
+		"Restore window has expired":                      "Venció el plazo para restaurar el usuario",
+		"Error restoring user":                            "Error al restaurar el usuario",
+		"Account is scheduled for deletion":               "La cuenta está programada para eliminarse",
+		"User already exists, returning existing user":    "El usuario ya existe, se devuelve el usuario existente",
+		"User with this email or username already exists": "Ya existe un usuario con este email o nombre de usuario",
+		"User not found":                                  "Usuario no encontrado",
+		"Invalid user ID":                                 "ID de usuario inválido",
+		"Firebase ID is required":                         "Se requiere el Firebase ID",
+		"Username is required":                            "Se requiere el nombre de usuario",
+		"Username availability checked":                   "Disponibilidad del nombre de usuario comprobada",
+		"Username is not allowed":                         "El nombre de usuario no está permitido",
+		"Username is already taken":                       "El nombre de usuario ya está en uso",
+		"Error checking username availability":            "Error al comprobar la disponibilidad del nombre de usuario",
+		"Email is required":                               "Se requiere el email",
+		"Search query is required":                        "Se requiere un término de búsqueda",
+		"Search completed successfully":                   "Búsqueda completada correctamente",
+		"Users counted successfully":                      "Usuarios contados correctamente",
+		"Active users retrieved successfully":             "Usuarios activos obtenidos correctamente",
+		"Login info updated successfully":                 "Información de login actualizada correctamente",
+		"Error fetching users":                            "Error al obtener usuarios",
+		"Error creating user":                             "Error al crear el usuario",
+		"Error updating user":                             "Error al actualizar el usuario",
+		"Error deleting user":                             "Error al eliminar el usuario",
+		"Error searching users":                           "Error al buscar usuarios",
+		"Error counting users":                            "Error al contar usuarios",
+		"Error fetching active users":                     "Error al obtener usuarios activos",
+		"Error updating login info":                       "Error al actualizar la información de login",
+		"User profile endpoint not implemented yet":       "El endpoint de perfil de usuario aún no está implementado",
+		"User settings endpoint not implemented yet":      "El endpoint de configuración de usuario aún no está implementado",
+		"User stats endpoint not implemented yet":         "El endpoint de estadísticas de usuario aún no está implementado",
+
+		// Autenticación
+		"Login successful":                                "Inicio de sesión correcto",
+		"Google login successful":                         "Inicio de sesión con Google correcto",
+		"Facebook login successful":                       "Inicio de sesión con Facebook correcto",
+		"Email login successful":                          "Inicio de sesión con email correcto",
+		"Logout successful":                               "Sesión cerrada correctamente",
+		"Invalid token":                                   "Token inválido",
+		"Invalid token format":                            "Formato de token inválido",
+		"No token provided":                               "No se proporcionó un token",
+		"Invalid Google token":                            "Token de Google inválido",
+		"Invalid Facebook token":                          "Token de Facebook inválido",
+		"Invalid Google authentication":                   "Autenticación de Google inválida",
+		"Invalid Facebook authentication":                 "Autenticación de Facebook inválida",
+		"Invalid email/password authentication":           "Autenticación por email/contraseña inválida",
+		"Failed to get user information":                  "No se pudo obtener la información del usuario",
+		"Failed to get user profile":                      "No se pudo obtener el perfil del usuario",
+		"Profile retrieved successfully":                  "Perfil obtenido correctamente",
+		"Failed to revoke tokens":                         "No se pudieron revocar los tokens",
+		"All tokens revoked successfully":                 "Todos los tokens fueron revocados correctamente",
+		"Active sessions endpoint not implemented yet":    "El endpoint de sesiones activas aún no está implementado",
+		"Session revocation endpoint not implemented yet": "El endpoint de revocación de sesión aún no está implementado",
+		"Token refresh should be handled on the client side using Firebase SDK":   "La renovación del token debe hacerse en el cliente con el SDK de Firebase",
+		"Token refresh should be handled on the client side":                      "La renovación del token debe hacerse en el cliente",
+		"Password change should be handled on the client side using Firebase SDK": "El cambio de contraseña debe hacerse en el cliente con el SDK de Firebase",
+		"Profile update functionality requires Firebase Admin SDK implementation": "La actualización del perfil requiere la implementación del SDK de administración de Firebase",
+
+		// Tokens
+		"Token is valid":                    "El token es válido",
+		"Token info retrieved successfully": "Información del token obtenida correctamente",
+		"Custom token creation requires Firebase Admin SDK implementation": "La creación de tokens personalizados requiere la implementación del SDK de administración de Firebase",
+
+		// Contraseñas
+		"If the email exists, a password reset link has been sent": "Si el email existe, se ha enviado un enlace para restablecer la contraseña",
+		"Password reset completed successfully":                    "Contraseña restablecida correctamente",
+		"Password changed successfully":                            "Contraseña cambiada correctamente",
+		"Invalid current token":                                    "Token actual inválido",
+		"Invalid or expired reset code":                            "Código de restablecimiento inválido o expirado",
+		"Invalid or expired reset token":                           "Token de restablecimiento inválido o expirado",
+		"Reset code is valid":                                      "El código de restablecimiento es válido",
+		"Reset token is valid":                                     "El token de restablecimiento es válido",
+		"Password strength checked":                                "Fortaleza de la contraseña verificada",
+		"Password history endpoint not implemented yet":            "El endpoint de historial de contraseñas aún no está implementado",
+		"Password policy retrieved successfully":                   "Política de contraseñas obtenida correctamente",
+
+		// Verificación de email
+		"If the email exists, a verification email has been sent":               "Si el email existe, se ha enviado un email de verificación",
+		"Verification email sent successfully":                                  "Email de verificación enviado correctamente",
+		"Email not verified yet":                                                "El email aún no está verificado",
+		"Email verified successfully":                                           "Email verificado correctamente",
+		"Invalid email or verification code":                                    "Email o código de verificación inválido",
+		"Invalid verification code":                                             "Código de verificación inválido",
+		"Error verifying email":                                                 "Error al verificar el email",
+		"Verification status retrieved successfully":                            "Estado de verificación obtenido correctamente",
+		"Verification history endpoint not implemented yet":                     "El endpoint de historial de verificaciones aún no está implementado",
+		"Email settings retrieved successfully":                                 "Configuración de email obtenida correctamente",
+		"Email settings update endpoint not implemented yet":                    "El endpoint de actualización de configuración de email aún no está implementado",
+		"Email update functionality requires Firebase Admin SDK implementation": "La actualización del email requiere la implementación del SDK de administración de Firebase",
+
+		// Login y sesiones
+		"Login tracked successfully":                 "Login registrado correctamente",
+		"Login history retrieved successfully":       "Historial de login obtenido correctamente",
+		"Login attempts retrieved successfully":      "Intentos de login obtenidos correctamente",
+		"Security check completed":                   "Verificación de seguridad completada",
+		"Active sessions retrieved successfully":     "Sesiones activas obtenidas correctamente",
+		"Session ID is required":                     "Se requiere el ID de sesión",
+		"Session terminated successfully":            "Sesión terminada correctamente",
+		"All sessions terminated successfully":       "Todas las sesiones fueron terminadas correctamente",
+		"Failed to terminate sessions":               "No se pudieron terminar las sesiones",
+		"Suspicious activity retrieved successfully": "Actividad sospechosa obtenida correctamente",
+
+		// Eliminación de cuenta
+		"Confirmation code sent to your email":           "Código de confirmación enviado a tu email",
+		"Account deletion scheduled":                     "Eliminación de la cuenta programada",
+		"Account deletion already scheduled":             "La eliminación de la cuenta ya está programada",
+		"Account deletion already in progress":           "La eliminación de la cuenta ya está en curso",
+		"Account deletion cancelled":                     "Eliminación de la cuenta cancelada",
+		"Account deletion status retrieved successfully": "Estado de la eliminación de la cuenta obtenido exitosamente",
+		"No account deletion requested":                  "No se solicitó la eliminación de la cuenta",
+		"No scheduled account deletion":                  "No hay una eliminación de la cuenta programada",
+		"Recent authentication required":                 "Se requiere haber iniciado sesión recientemente",
+		"Request a confirmation code first":              "Primero solicita un código de confirmación",
+		"Invalid confirmation code":                      "Código de confirmación inválido",
+		"Confirmation code has expired":                  "El código de confirmación expiró",
+		"Too many attempts, request a new code":          "Demasiados intentos, solicita un código nuevo",
+		"Error retrieving account deletion status":       "Error al obtener el estado de la eliminación de la cuenta",
+		"Error processing account deletion":              "Error al procesar la eliminación de la cuenta",
+
+		// Emails de eliminación de cuenta
+		"Confirm your account deletion": "Confirma la eliminación de tu cuenta",
+		"Your code to confirm the deletion of your account is %s. It expires in %d minutes.\n\nIf you did not request it, change your password: someone may have access to your account.": "Tu código para confirmar la eliminación de tu cuenta es %s. Expira en %d minutos.\n\nSi no lo solicitaste, cambia tu contraseña: alguien podría tener acceso a tu cuenta.",
+		"Your account deletion is scheduled": "La eliminación de tu cuenta está programada",
+		"Your account and all your data will be permanently deleted on %s.\n\nIf you change your mind, sign in and cancel the deletion before that date.": "Tu cuenta y todos tus datos se eliminarán definitivamente el %s.\n\nSi cambias de opinión, inicia sesión y cancela la eliminación antes de esa fecha.",
+		"Your account deletion was cancelled":                                           "La eliminación de tu cuenta fue cancelada",
+		"The deletion of your account has been cancelled. Your account remains active.": "La eliminación de tu cuenta fue cancelada. Tu cuenta sigue activa.",
+		"Your account has been deleted":                                                 "Tu cuenta fue eliminada",
+		"Your account and all your data have been permanently deleted.":                 "Tu cuenta y todos tus datos fueron eliminados definitivamente.",
+
+		// Administración y auditoría
+		"Audit events retrieved successfully": "Eventos de auditoría obtenidos exitosamente",
+		"Error retrieving audit events":       "Error al obtener los eventos de auditoría",
+		"Invalid date, expected RFC 3339":     "Fecha inválida, se espera RFC 3339",
+		"Invalid audit event ID":              "ID de evento de auditoría inválido",
+		"Audit event not found":               "Evento de auditoría no encontrado",
+		"Audit chain verified":                "Cadena de auditoría verificada",
+		"Error verifying audit chain":         "Error al verificar la cadena de auditoría",
+
+		// Historial de versiones
+		"User history retrieved successfully":             "Historial del usuario obtenido exitosamente",
+		"Error retrieving user history":                   "Error al obtener el historial del usuario",
+		"Invalid entity, expected users or user_profiles": "Entidad inválida, se esperaba users o user_profiles",
+		"No version found at that date":                   "No hay ninguna versión en esa fecha",
+		"Version not found":                               "Versión no encontrada",
+		"Field cannot be reverted":                        "El campo no se puede revertir",
+		"Reverted value is already in use":                "El valor a restaurar ya está en uso",
+		"User record reverted successfully":               "Registro del usuario revertido exitosamente",
+		"Error reverting user record":                     "Error al revertir el registro del usuario",
+
+		// Blocking functions (registro e inicio de sesión)
+		"Invalid blocking function token":      "Token de blocking function inválido",
+		"Email domain is not allowed":          "El dominio del email no está permitido",
+		"This account has been disabled":       "Esta cuenta fue deshabilitada",
+		"Sign-in blocked for security reasons": "Inicio de sesión bloqueado por motivos de seguridad",
+		"Error applying sign-in policies":      "Error al aplicar las políticas de inicio de sesión",
+
+		// Roles y permisos
+		"Permission denied":                                      "Permiso denegado",
+		"Error checking permissions":                             "Error al verificar los permisos",
+		"Roles retrieved successfully":                           "Roles obtenidos exitosamente",
+		"Permissions retrieved successfully":                     "Permisos obtenidos exitosamente",
+		"Error retrieving roles":                                 "Error al obtener los roles",
+		"Role created successfully":                              "Rol creado exitosamente",
+		"Role updated successfully":                              "Rol actualizado exitosamente",
+		"Role deleted successfully":                              "Rol eliminado exitosamente",
+		"Role assigned successfully":                             "Rol asignado exitosamente",
+		"Role removed successfully":                              "Rol quitado exitosamente",
+		"Role claims synced successfully":                        "Claims de roles sincronizados exitosamente",
+		"Role not found":                                         "Rol no encontrado",
+		"Invalid role ID":                                        "ID de rol inválido",
+		"Role not assigned to user":                              "El usuario no tiene ese rol",
+		"Role already exists":                                    "El rol ya existe",
+		"The admin role cannot be deleted or change permissions": "El rol admin no puede eliminarse ni cambiar sus permisos",
+		"Unknown permission":                                     "Permiso desconocido",
+		"Too many roles for the token size limit":                "Demasiados roles para el límite de tamaño del token",
+		"Roles saved but token claims could not be updated":      "Los roles se guardaron pero no se pudieron actualizar los claims del token",
+		"Error saving role":                                      "Error al guardar el rol",
+
+		// Administración de usuarios
+		"Invalid filter value":                     "Valor de filtro inválido",
+		"Invalid sort field":                       "Campo de orden inválido",
+		"User disabled successfully":               "Usuario deshabilitado exitosamente",
+		"User enabled successfully":                "Usuario habilitado exitosamente",
+		"Password reset forced and email sent":     "Contraseña restablecida y email enviado",
+		"Invite sent successfully":                 "Invitación enviada exitosamente",
+		"Security timeline retrieved successfully": "Historial de seguridad obtenido exitosamente",
+		"User has no Firebase account":             "El usuario no tiene cuenta de Firebase",
+		"User does not sign in with a password":    "El usuario no inicia sesión con contraseña",
+		"User has already signed in":               "El usuario ya inició sesión",
+		"The email could not be sent":              "No se pudo enviar el email",
+		"Firebase account could not be updated":    "No se pudo actualizar la cuenta de Firebase",
+		"Reset your password":                      "Restablece tu contraseña",
+		"An administrator has reset your password and closed your sessions. Choose a new password with this link:\n\n%s\n\nIf you did not expect this, contact support.": "Un administrador restableció tu contraseña y cerró tus sesiones. Elige una contraseña nueva con este link:\n\n%s\n\nSi no lo esperabas, contacta a soporte.",
+		"You have been invited": "Te invitaron a crear tu cuenta",
+		"An account has been created for you. Choose your password and sign in with this link:\n\n%s": "Se creó una cuenta para ti. Elige tu contraseña e inicia sesión con este link:\n\n%s",
+
+		// Suplantación de usuarios
+		"Impersonation token created successfully":                "Token de suplantación creado exitosamente",
+		"This user cannot be impersonated":                        "Este usuario no puede ser suplantado",
+		"Impersonation session expired":                           "La suplantación expiró",
+		"Not allowed while impersonating a user":                  "No permitido mientras se suplanta a un usuario",
+		"This action is not available while impersonating a user": "Esta acción no está disponible mientras se suplanta a un usuario",
+
+		// Clientes de API
+		"Invalid API key":                    "API key inválida",
+		"Invalid API client ID":              "ID de cliente de API inválido",
+		"Error retrieving API clients":       "Error al obtener los clientes de API",
+		"Error saving API client":            "Error al guardar el cliente de API",
+		"API clients retrieved successfully": "Clientes de API obtenidos exitosamente",
+		"API client retrieved successfully":  "Cliente de API obtenido exitosamente",
+		"API client created successfully":    "Cliente de API creado exitosamente",
+		"API client updated successfully":    "Cliente de API actualizado exitosamente",
+		"API key rotated successfully":       "API key rotada exitosamente",
+		"API client revoked successfully":    "Cliente de API revocado exitosamente",
+		"API client not found":               "Cliente de API no encontrado",
+		"API client already exists":          "El cliente de API ya existe",
+		"API client has been revoked":        "El cliente de API fue revocado",
+		"Unknown scope":                      "Scope desconocido",
+
+		// Introspección de tokens
+		"Invalid form data": "Datos de formulario inválidos",
+		"Token is required": "El token es obligatorio",
+	},
+	"fr": {
+		// Generales
+		"validation failed":                    "la validation a échoué",
+		"Error reading request body":           "Erreur lors de la lecture du corps de la requête",
+		"Invalid JSON format":                  "Format JSON invalide",
+		"Authentication required":              "Authentification requise",
+		"Authentication service not available": "Le service d'authentification n'est pas disponible",
+		"Authorization header required":        "L'en-tête Authorization est requis",
+		"Invalid Authorization header format":  "Format de l'en-tête Authorization invalide",
+		"Rate limit exceeded":                  "Limite de requêtes dépassée",
+		"Request timed out":                    "La requête a expiré",
+
+		// Usuarios
+		"Users retrieved successfully":                    "Utilisateurs récupérés avec succès",
+		"User retrieved successfully":                     "Utilisateur récupéré avec succès",
+		"User created successfully":                       "Utilisateur créé avec succès",
+		"User updated successfully":                       "Utilisateur mis à jour avec succès",
+		"User deleted successfully":                       "Utilisateur supprimé avec succès",
+		"User restored successfully":                      "Utilisateur restauré avec succès",
+		"Data export requested successfully":              "Export des données demandé avec succès",
+		"Data export already requested":                   "L'export des données a déjà été demandé",
+		"Data export retrieved successfully":              "Export des données récupéré avec succès",
+		"Data export not found":                           "Export des données introuvable",
+		"Invalid export ID":                               "ID d'export invalide",
+		"Error requesting data export":                    "Erreur lors de la demande d'export des données",
+		"Error retrieving data export":                    "Erreur lors de la récupération de l'export des données",
+		"Deleted user not found":                          "Utilisateur supprimé introuvable",
+		"Restore window has expired":                      "Le délai de restauration a expiré",
+		"Error restoring user":                            "Erreur lors de la restauration de l'utilisateur",
+		"Account is scheduled for deletion":               "Le compte est programmé pour suppression",
+		"User already exists, returning existing user":    "L'utilisateur existe déjà, l'utilisateur existant est renvoyé",
+		"User with this email or username already exists": "Un utilisateur avec cet e-mail ou ce nom d'utilisateur existe déjà",
+		"User not found":                                  "Utilisateur introuvable",
+		"Invalid user ID":                                 "ID utilisateur invalide",
+		"Firebase ID is required":                         "Le Firebase ID est requis",
+		"Username is required":                            "Le nom d'utilisateur est requis",
+		"Username availability checked":                   "Disponibilité du nom d'utilisateur vérifiée",
+		"Username is not allowed":                         "Ce nom d'utilisateur n'est pas autorisé",
+		"Username is already taken":                       "Ce nom d'utilisateur est déjà pris",
+		"Error checking username availability":            "Erreur lors de la vérification de la disponibilité du nom d'utilisateur",
+		"Email is required":                               "L'e-mail est requis",
+		"Search query is required":                        "Un terme de recherche est requis",
+		"Search completed successfully":                   "Recherche terminée avec succès",
+		"Users counted successfully":                      "Utilisateurs comptés avec succès",
+		"Active users retrieved successfully":             "Utilisateurs actifs récupérés avec succès",
+		"Login info updated successfully":                 "Informations de connexion mises à jour avec succès",
+		"Error fetching users":                            "Erreur lors de la récupération des utilisateurs",
+		"Error creating user":                             "Erreur lors de la création de l'utilisateur",
+		"Error updating user":                             "Erreur lors de la mise à jour de l'utilisateur",
+		"Error deleting user":                             "Erreur lors de la suppression de l'utilisateur",
+		"Error searching users":                           "Erreur lors de la recherche d'utilisateurs",
+		"Error counting users":                            "Erreur lors du comptage des utilisateurs",
+		"Error fetching active users":                     "Erreur lors de la récupération des utilisateurs actifs",
+		"Error updating login info":                       "Erreur lors de la mise à jour des informations de connexion",
+		"User profile endpoint not implemented yet":       "Le point de terminaison du profil n'est pas encore implémenté",
+		"User settings endpoint not implemented yet":      "Le point de terminaison des paramètres n'est pas encore implémenté",
+		"User stats endpoint not implemented yet":         "Le point de terminaison des statistiques n'est pas encore implémenté",
+
+		// Autenticación
+		"Login successful":                                "Connexion réussie",
+		"Google login successful":                         "Connexion avec Google réussie",
+		"Facebook login successful":                       "Connexion avec Facebook réussie",
+		"Email login successful":                          "Connexion par e-mail réussie",
+		"Logout successful":                               "Déconnexion réussie",
+		"Invalid token":                                   "Jeton invalide",
+		"Invalid token format":                            "Format de jeton invalide",
+		"No token provided":                               "Aucun jeton fourni",
+		"Invalid Google token":                            "Jeton Google invalide",
+		"Invalid Facebook token":                          "Jeton Facebook invalide",
+		"Invalid Google authentication":                   "Authentification Google invalide",
+		"Invalid Facebook authentication":                 "Authentification Facebook invalide",
+		"Invalid email/password authentication":           "Authentification e-mail/mot de passe invalide",
+		"Failed to get user information":                  "Impossible d'obtenir les informations de l'utilisateur",
+		"Failed to get user profile":                      "Impossible d'obtenir le profil de l'utilisateur",
+		"Profile retrieved successfully":                  "Profil récupéré avec succès",
+		"Failed to revoke tokens":                         "Impossible de révoquer les jetons",
+		"All tokens revoked successfully":                 "Tous les jetons ont été révoqués avec succès",
+		"Active sessions endpoint not implemented yet":    "Le point de terminaison des sessions actives n'est pas encore implémenté",
+		"Session revocation endpoint not implemented yet": "Le point de terminaison de révocation de session n'est pas encore implémenté",
+		"Token refresh should be handled on the client side using Firebase SDK":   "Le renouvellement du jeton doit être géré côté client avec le SDK Firebase",
+		"Token refresh should be handled on the client side":                      "Le renouvellement du jeton doit être géré côté client",
+		"Password change should be handled on the client side using Firebase SDK": "Le changement de mot de passe doit être géré côté client avec le SDK Firebase",
+		"Profile update functionality requires Firebase Admin SDK implementation": "La mise à jour du profil nécessite l'implémentation du SDK Admin Firebase",
+
+		// Tokens
+		"Token is valid":                    "Le jeton est valide",
+		"Token info retrieved successfully": "Informations du jeton récupérées avec succès",
+		"Custom token creation requires Firebase Admin SDK implementation": "La création de jetons personnalisés nécessite l'implémentation du SDK Admin Firebase",
+
+		// Contraseñas
+		"If the email exists, a password reset link has been sent": "Si l'e-mail existe, un lien de réinitialisation du mot de passe a été envoyé",
+		"Password reset completed successfully":                    "Mot de passe réinitialisé avec succès",
+		"Password changed successfully":                            "Mot de passe modifié avec succès",
+		"Invalid current token":                                    "Jeton actuel invalide",
+		"Invalid or expired reset code":                            "Code de réinitialisation invalide ou expiré",
+		"Invalid or expired reset token":                           "Jeton de réinitialisation invalide ou expiré",
+		"Reset code is valid":                                      "Le code de réinitialisation est valide",
+		"Reset token is valid":                                     "Le jeton de réinitialisation est valide",
+		"Password strength checked":                                "Robustesse du mot de passe vérifiée",
+		"Password history endpoint not implemented yet":            "Le point de terminaison de l'historique des mots de passe n'est pas encore implémenté",
+		"Password policy retrieved successfully":                   "Politique de mots de passe récupérée avec succès",
+
+		// Verificación de email
+		"If the email exists, a verification email has been sent":               "Si l'e-mail existe, un e-mail de vérification a été envoyé",
+		"Verification email sent successfully":                                  "E-mail de vérification envoyé avec succès",
+		"Email not verified yet":                                                "L'e-mail n'est pas encore vérifié",
+		"Email verified successfully":                                           "E-mail vérifié avec succès",
+		"Invalid email or verification code":                                    "E-mail ou code de vérification invalide",
+		"Invalid verification code":                                             "Code de vérification invalide",
+		"Error verifying email":                                                 "Erreur lors de la vérification de l'e-mail",
+		"Verification status retrieved successfully":                            "Statut de vérification récupéré avec succès",
+		"Verification history endpoint not implemented yet":                     "Le point de terminaison de l'historique des vérifications n'est pas encore implémenté",
+		"Email settings retrieved successfully":                                 "Paramètres d'e-mail récupérés avec succès",
+		"Email settings update endpoint not implemented yet":                    "Le point de terminaison de mise à jour des paramètres d'e-mail n'est pas encore implémenté",
+		"Email update functionality requires Firebase Admin SDK implementation": "La mise à jour de l'e-mail nécessite l'implémentation du SDK Admin Firebase",
+
+		// Login y sesiones
+		"Login tracked successfully":                 "Connexion enregistrée avec succès",
+		"Login history retrieved successfully":       "Historique de connexion récupéré avec succès",
+		"Login attempts retrieved successfully":      "Tentatives de connexion récupérées avec succès",
+		"Security check completed":                   "Vérification de sécurité terminée",
+		"Active sessions retrieved successfully":     "Sessions actives récupérées avec succès",
+		"Session ID is required":                     "L'ID de session est requis",
+		"Session terminated successfully":            "Session terminée avec succès",
+		"All sessions terminated successfully":       "Toutes les sessions ont été terminées avec succès",
+		"Failed to terminate sessions":               "Impossible de terminer les sessions",
+		"Suspicious activity retrieved successfully": "Activité suspecte récupérée avec succès",
+
+		// Eliminación de cuenta
+		"Confirmation code sent to your email":           "Code de confirmation envoyé à votre email",
+		"Account deletion scheduled":                     "Suppression du compte programmée",
+		"Account deletion already scheduled":             "La suppression du compte est déjà programmée",
+		"Account deletion already in progress":           "La suppression du compte est déjà en cours",
+		"Account deletion cancelled":                     "Suppression du compte annulée",
+		"Account deletion status retrieved successfully": "Statut de la suppression du compte récupéré avec succès",
+		"No account deletion requested":                  "Aucune suppression du compte demandée",
+		"No scheduled account deletion":                  "Aucune suppression du compte programmée",
+		"Recent authentication required":                 "Une connexion récente est requise",
+		"Request a confirmation code first":              "Demandez d'abord un code de confirmation",
+		"Invalid confirmation code":                      "Code de confirmation invalide",
+		"Confirmation code has expired":                  "Le code de confirmation a expiré",
+		"Too many attempts, request a new code":          "Trop de tentatives, demandez un nouveau code",
+		"Error retrieving account deletion status":       "Erreur lors de la récupération du statut de la suppression du compte",
+		"Error processing account deletion":              "Erreur lors du traitement de la suppression du compte",
+
+		// Emails de eliminación de cuenta
+		"Confirm your account deletion": "Confirmez la suppression de votre compte",
+		"Your code to confirm the deletion of your account is %s. It expires in %d minutes.\n\nIf you did not request it, change your password: someone may have access to your account.": "Votre code pour confirmer la suppression de votre compte est %s. Il expire dans %d minutes.\n\nSi vous ne l'avez pas demandé, changez votre mot de passe : quelqu'un pourrait avoir accès à votre compte.",
+		"Your account deletion is scheduled": "La suppression de votre compte est programmée",
+		"Your account and all your data will be permanently deleted on %s.\n\nIf you change your mind, sign in and cancel the deletion before that date.": "Votre compte et toutes vos données seront définitivement supprimés le %s.\n\nSi vous changez d'avis, connectez-vous et annulez la suppression avant cette date.",
+		"Your account deletion was cancelled":                                           "La suppression de votre compte a été annulée",
+		"The deletion of your account has been cancelled. Your account remains active.": "La suppression de votre compte a été annulée. Votre compte reste actif.",
+		"Your account has been deleted":                                                 "Votre compte a été supprimé",
+		"Your account and all your data have been permanently deleted.":                 "Votre compte et toutes vos données ont été définitivement supprimés.",
+
+		// Administración y auditoría
+		"Audit events retrieved successfully": "Événements d'audit récupérés avec succès",
+		"Error retrieving audit events":       "Erreur lors de la récupération des événements d'audit",
+		"Invalid date, expected RFC 3339":     "Date invalide, RFC 3339 attendu",
+		"Invalid audit event ID":              "ID d'événement d'audit invalide",
+		"Audit event not found":               "Événement d'audit introuvable",
+		"Audit chain verified":                "Chaîne d'audit vérifiée",
+		"Error verifying audit chain":         "Erreur lors de la vérification de la chaîne d'audit",
+
+		// Historial de versiones
+		"User history retrieved successfully":             "Historique de l'utilisateur récupéré avec succès",
+		"Error retrieving user history":                   "Erreur lors de la récupération de l'historique de l'utilisateur",
+		"Invalid entity, expected users or user_profiles": "Entité invalide, users ou user_profiles attendu",
+		"No version found at that date":                   "Aucune version trouvée à cette date",
+		"Version not found":                               "Version introuvable",
+		"Field cannot be reverted":                        "Ce champ ne peut pas être rétabli",
+		"Reverted value is already in use":                "La valeur à rétablir est déjà utilisée",
+		"User record reverted successfully":               "Enregistrement de l'utilisateur rétabli avec succès",
+		"Error reverting user record":                     "Erreur lors du rétablissement de l'enregistrement de l'utilisateur",
+
+		// Blocking functions (registro e inicio de sesión)
+		"Invalid blocking function token":      "Jeton de blocking function invalide",
+		"Email domain is not allowed":          "Le domaine de l'e-mail n'est pas autorisé",
+		"This account has been disabled":       "Ce compte a été désactivé",
+		"Sign-in blocked for security reasons": "Connexion bloquée pour des raisons de sécurité",
+		"Error applying sign-in policies":      "Erreur lors de l'application des politiques de connexion",
+
+		// Roles y permisos
+		"Permission denied":                                      "Permission refusée",
+		"Error checking permissions":                             "Erreur lors de la vérification des permissions",
+		"Roles retrieved successfully":                           "Rôles récupérés avec succès",
+		"Permissions retrieved successfully":                     "Permissions récupérées avec succès",
+		"Error retrieving roles":                                 "Erreur lors de la récupération des rôles",
+		"Role created successfully":                              "Rôle créé avec succès",
+		"Role updated successfully":                              "Rôle mis à jour avec succès",
+		"Role deleted successfully":                              "Rôle supprimé avec succès",
+		"Role assigned successfully":                             "Rôle attribué avec succès",
+		"Role removed successfully":                              "Rôle retiré avec succès",
+		"Role claims synced successfully":                        "Claims des rôles synchronisés avec succès",
+		"Role not found":                                         "Rôle introuvable",
+		"Invalid role ID":                                        "ID de rôle invalide",
+		"Role not assigned to user":                              "L'utilisateur n'a pas ce rôle",
+		"Role already exists":                                    "Le rôle existe déjà",
+		"The admin role cannot be deleted or change permissions": "Le rôle admin ne peut pas être supprimé ni changer de permissions",
+		"Unknown permission":                                     "Permission inconnue",
+		"Too many roles for the token size limit":                "Trop de rôles pour la taille maximale du jeton",
+		"Roles saved but token claims could not be updated":      "Rôles enregistrés mais les claims du jeton n'ont pas pu être mis à jour",
+		"Error saving role":                                      "Erreur lors de l'enregistrement du rôle",
+
+		// Administración de usuarios
+		"Invalid filter value":                     "Valeur de filtre invalide",
+		"Invalid sort field":                       "Champ de tri invalide",
+		"User disabled successfully":               "Utilisateur désactivé avec succès",
+		"User enabled successfully":                "Utilisateur activé avec succès",
+		"Password reset forced and email sent":     "Mot de passe réinitialisé et email envoyé",
+		"Invite sent successfully":                 "Invitation envoyée avec succès",
+		"Security timeline retrieved successfully": "Historique de sécurité récupéré avec succès",
+		"User has no Firebase account":             "L'utilisateur n'a pas de compte Firebase",
+		"User does not sign in with a password":    "L'utilisateur ne se connecte pas avec un mot de passe",
+		"User has already signed in":               "L'utilisateur s'est déjà connecté",
+		"The email could not be sent":              "L'email n'a pas pu être envoyé",
+		"Firebase account could not be updated":    "Le compte Firebase n'a pas pu être mis à jour",
+		"Reset your password":                      "Réinitialisez votre mot de passe",
+		"An administrator has reset your password and closed your sessions. Choose a new password with this link:\n\n%s\n\nIf you did not expect this, contact support.": "Un administrateur a réinitialisé votre mot de passe et fermé vos sessions. Choisissez un nouveau mot de passe avec ce lien :\n\n%s\n\nSi vous ne vous y attendiez pas, contactez le support.",
+		"You have been invited": "Vous avez été invité",
+		"An account has been created for you. Choose your password and sign in with this link:\n\n%s": "Un compte a été créé pour vous. Choisissez votre mot de passe et connectez-vous avec ce lien :\n\n%s",
+
+		// Suplantación de usuarios
+		"Impersonation token created successfully":                "Jeton d'usurpation créé avec succès",
+		"This user cannot be impersonated":                        "Cet utilisateur ne peut pas être usurpé",
+		"Impersonation session expired":                           "L'usurpation a expiré",
+		"Not allowed while impersonating a user":                  "Non autorisé pendant l'usurpation d'un utilisateur",
+		"This action is not available while impersonating a user": "Cette action n'est pas disponible pendant l'usurpation d'un utilisateur",
+
+		// Clientes de API
+		"Invalid API key":                    "Clé d'API invalide",
+		"Invalid API client ID":              "ID de client d'API invalide",
+		"Error retrieving API clients":       "Erreur lors de la récupération des clients d'API",
+		"Error saving API client":            "Erreur lors de l'enregistrement du client d'API",
+		"API clients retrieved successfully": "Clients d'API récupérés avec succès",
+		"API client retrieved successfully":  "Client d'API récupéré avec succès",
+		"API client created successfully":    "Client d'API créé avec succès",
+		"API client updated successfully":    "Client d'API mis à jour avec succès",
+		"API key rotated successfully":       "Clé d'API renouvelée avec succès",
+		"API client revoked successfully":    "Client d'API révoqué avec succès",
+		"API client not found":               "Client d'API introuvable",
+		"API client already exists":          "Le client d'API existe déjà",
+		"API client has been revoked":        "Le client d'API a été révoqué",
+		"Unknown scope":                      "Scope inconnu",
+
+		// Introspección de tokens
+		"Invalid form data": "Données de formulaire invalides",
+		"Token is required": "Le jeton est obligatoire",
+	},
+	"de": {
+		// Generales
+		"validation failed":                    "Validierung fehlgeschlagen",
+		"Error reading request body":           "Fehler beim Lesen des Anfragekörpers",
+		"Invalid JSON format":                  "Ungültiges JSON-Format",
+		"Authentication required":              "Authentifizierung erforderlich",
+		"Authentication service not available": "Der Authentifizierungsdienst ist nicht verfügbar",
+		"Authorization header required":        "Authorization-Header erforderlich",
+		"Invalid Authorization header format":  "Ungültiges Format des Authorization-Headers",
+		"Rate limit exceeded":                  "Anfragelimit überschritten",
+		"Request timed out":                    "Zeitüberschreitung der Anfrage",
+
+		// Usuarios
+		"Users retrieved successfully":                    "Benutzer erfolgreich abgerufen",
+		"User retrieved successfully":                     "Benutzer erfolgreich abgerufen",
+		"User created successfully":                       "Benutzer erfolgreich erstellt",
+		"User updated successfully":                       "Benutzer erfolgreich aktualisiert",
+		"User deleted successfully":                       "Benutzer erfolgreich gelöscht",
+		"User restored successfully":                      "Benutzer erfolgreich wiederhergestellt",
+		"Data export requested successfully":              "Datenexport erfolgreich angefordert",
+		"Data export already requested":                   "Der Datenexport wurde bereits angefordert",
+		"Data export retrieved successfully":              "Datenexport erfolgreich abgerufen",
+		"Data export not found":                           "Datenexport nicht gefunden",
+		"Invalid export ID":                               "Ungültige Export-ID",
+		"Error requesting data export":                    "Fehler beim Anfordern des Datenexports",
+		"Error retrieving data export":                    "Fehler beim Abrufen des Datenexports",
+		"Deleted user not found":                          "Gelöschter Benutzer nicht gefunden",
+		"Restore window has expired":                      "Die Frist zur Wiederherstellung ist abgelaufen",
+		"Error restoring user":                            "Fehler beim Wiederherstellen des Benutzers",
+		"Account is scheduled for deletion":               "Das Konto ist zur Löschung vorgemerkt",
+		"User already exists, returning existing user":    "Benutzer existiert bereits, der vorhandene Benutzer wird zurückgegeben",
+		"User with this email or username already exists": "Ein Benutzer mit dieser E-Mail oder diesem Benutzernamen existiert bereits",
+		"User not found":                                  "Benutzer nicht gefunden",
+		"Invalid user ID":                                 "Ungültige Benutzer-ID",
+		"Firebase ID is required":                         "Firebase-ID ist erforderlich",
+		"Username is required":                            "Benutzername ist erforderlich",
+		"Username availability checked":                   "Verfügbarkeit des Benutzernamens geprüft",
+		"Username is not allowed":                         "Dieser Benutzername ist nicht erlaubt",
+		"Username is already taken":                       "Dieser Benutzername ist bereits vergeben",
+		"Error checking username availability":            "Fehler beim Prüfen der Verfügbarkeit des Benutzernamens",
+		"Email is required":                               "E-Mail ist erforderlich",
+		"Search query is required":                        "Suchbegriff ist erforderlich",
+		"Search completed successfully":                   "Suche erfolgreich abgeschlossen",
+		"Users counted successfully":                      "Benutzer erfolgreich gezählt",
+		"Active users retrieved successfully":             "Aktive Benutzer erfolgreich abgerufen",
+		"Login info updated successfully":                 "Anmeldeinformationen erfolgreich aktualisiert",
+		"Error fetching users":                            "Fehler beim Abrufen der Benutzer",
+		"Error creating user":                             "Fehler beim Erstellen des Benutzers",
+		"Error updating user":                             "Fehler beim Aktualisieren des Benutzers",
+		"Error deleting user":                             "Fehler beim Löschen des Benutzers",
+		"Error searching users":                           "Fehler bei der Benutzersuche",
+		"Error counting users":                            "Fehler beim Zählen der Benutzer",
+		"Error fetching active users":                     "Fehler beim Abrufen der aktiven Benutzer",
+		"Error updating login info":                       "Fehler beim Aktualisieren der Anmeldeinformationen",
+		"User profile endpoint not implemented yet":       "Der Endpunkt für Benutzerprofile ist noch nicht implementiert",
+		"User settings endpoint not implemented yet":      "Der Endpunkt für Benutzereinstellungen ist noch nicht implementiert",
+		"User stats endpoint not implemented yet":         "Der Endpunkt für Benutzerstatistiken ist noch nicht implementiert",
+
+		// Autenticación
+		"Login successful":                                "Anmeldung erfolgreich",
+		"Google login successful":                         "Anmeldung mit Google erfolgreich",
+		"Facebook login successful":                       "Anmeldung mit Facebook erfolgreich",
+		"Email login successful":                          "Anmeldung per E-Mail erfolgreich",
+		"Logout successful":                               "Abmeldung erfolgreich",
+		"Invalid token":                                   "Ungültiges Token",
+		"Invalid token format":                            "Ungültiges Token-Format",
+		"No token provided":                               "Kein Token angegeben",
+		"Invalid Google token":                            "Ungültiges Google-Token",
+		"Invalid Facebook token":                          "Ungültiges Facebook-Token",
+		"Invalid Google authentication":                   "Ungültige Google-Authentifizierung",
+		"Invalid Facebook authentication":                 "Ungültige Facebook-Authentifizierung",
+		"Invalid email/password authentication":           "Ungültige E-Mail/Passwort-Authentifizierung",
+		"Failed to get user information":                  "Benutzerinformationen konnten nicht abgerufen werden",
+		"Failed to get user profile":                      "Benutzerprofil konnte nicht abgerufen werden",
+		"Profile retrieved successfully":                  "Profil erfolgreich abgerufen",
+		"Failed to revoke tokens":                         "Tokens konnten nicht widerrufen werden",
+		"All tokens revoked successfully":                 "Alle Tokens wurden erfolgreich widerrufen",
+		"Active sessions endpoint not implemented yet":    "Der Endpunkt für aktive Sitzungen ist noch nicht implementiert",
+		"Session revocation endpoint not implemented yet": "Der Endpunkt zum Widerrufen von Sitzungen ist noch nicht implementiert",
+		"Token refresh should be handled on the client side using Firebase SDK":   "Die Token-Erneuerung sollte clientseitig mit dem Firebase SDK erfolgen",
+		"Token refresh should be handled on the client side":                      "Die Token-Erneuerung sollte clientseitig erfolgen",
+		"Password change should be handled on the client side using Firebase SDK": "Die Passwortänderung sollte clientseitig mit dem Firebase SDK erfolgen",
+		"Profile update functionality requires Firebase Admin SDK implementation": "Die Profilaktualisierung erfordert die Implementierung des Firebase Admin SDK",
+
+		// Tokens
+		"Token is valid":                    "Das Token ist gültig",
+		"Token info retrieved successfully": "Token-Informationen erfolgreich abgerufen",
+		"Custom token creation requires Firebase Admin SDK implementation": "Das Erstellen benutzerdefinierter Tokens erfordert die Implementierung des Firebase Admin SDK",
+
+		// Contraseñas
+		"If the email exists, a password reset link has been sent": "Falls die E-Mail existiert, wurde ein Link zum Zurücksetzen des Passworts gesendet",
+		"Password reset completed successfully":                    "Passwort erfolgreich zurückgesetzt",
+		"Password changed successfully":                            "Passwort erfolgreich geändert",
+		"Invalid current token":                                    "Ungültiges aktuelles Token",
+		"Invalid or expired reset code":                            "Ungültiger oder abgelaufener Rücksetzcode",
+		"Invalid or expired reset token":                           "Ungültiges oder abgelaufenes Rücksetz-Token",
+		"Reset code is valid":                                      "Der Rücksetzcode ist gültig",
+		"Reset token is valid":                                     "Das Rücksetz-Token ist gültig",
+		"Password strength checked":                                "Passwortstärke geprüft",
+		"Password history endpoint not implemented yet":            "Der Endpunkt für den Passwortverlauf ist noch nicht implementiert",
+		"Password policy retrieved successfully":                   "Passwortrichtlinie erfolgreich abgerufen",
+
+		// Verificación de email
+		"If the email exists, a verification email has been sent":               "Falls die E-Mail existiert, wurde eine Bestätigungs-E-Mail gesendet",
+		"Verification email sent successfully":                                  "Bestätigungs-E-Mail erfolgreich gesendet",
+		"Email not verified yet":                                                "E-Mail ist noch nicht bestätigt",
+		"Email verified successfully":                                           "E-Mail erfolgreich bestätigt",
+		"Invalid email or verification code":                                    "Ungültige E-Mail oder ungültiger Bestätigungscode",
+		"Invalid verification code":                                             "Ungültiger Bestätigungscode",
+		"Error verifying email":                                                 "Fehler beim Bestätigen der E-Mail",
+		"Verification status retrieved successfully":                            "Bestätigungsstatus erfolgreich abgerufen",
+		"Verification history endpoint not implemented yet":                     "Der Endpunkt für den Bestätigungsverlauf ist noch nicht implementiert",
+		"Email settings retrieved successfully":                                 "E-Mail-Einstellungen erfolgreich abgerufen",
+		"Email settings update endpoint not implemented yet":                    "Der Endpunkt zum Aktualisieren der E-Mail-Einstellungen ist noch nicht implementiert",
+		"Email update functionality requires Firebase Admin SDK implementation": "Die E-Mail-Aktualisierung erfordert die Implementierung des Firebase Admin SDK",
+
+		// Login y sesiones
+		"Login tracked successfully":                 "Anmeldung erfolgreich erfasst",
+		"Login history retrieved successfully":       "Anmeldeverlauf erfolgreich abgerufen",
+		"Login attempts retrieved successfully":      "Anmeldeversuche erfolgreich abgerufen",
+		"Security check completed":                   "Sicherheitsprüfung abgeschlossen",
+		"Active sessions retrieved successfully":     "Aktive Sitzungen erfolgreich abgerufen",
+		"Session ID is required":                     "Sitzungs-ID ist erforderlich",
+		"Session terminated successfully":            "Sitzung erfolgreich beendet",
+		"All sessions terminated successfully":       "Alle Sitzungen wurden erfolgreich beendet",
+		"Failed to terminate sessions":               "Sitzungen konnten nicht beendet werden",
+		"Suspicious activity retrieved successfully": "Verdächtige Aktivitäten erfolgreich abgerufen",
+
+		// Eliminación de cuenta
+		"Confirmation code sent to your email":           "Bestätigungscode an Ihre E-Mail gesendet",
+		"Account deletion scheduled":                     "Kontolöschung geplant",
+		"Account deletion already scheduled":             "Die Kontolöschung ist bereits geplant",
+		"Account deletion already in progress":           "Die Kontolöschung läuft bereits",
+		"Account deletion cancelled":                     "Kontolöschung storniert",
+		"Account deletion status retrieved successfully": "Status der Kontolöschung erfolgreich abgerufen",
+		"No account deletion requested":                  "Keine Kontolöschung angefordert",
+		"No scheduled account deletion":                  "Keine geplante Kontolöschung",
+		"Recent authentication required":                 "Eine kürzlich erfolgte Anmeldung ist erforderlich",
+		"Request a confirmation code first":              "Fordern Sie zuerst einen Bestätigungscode an",
+		"Invalid confirmation code":                      "Ungültiger Bestätigungscode",
+		"Confirmation code has expired":                  "Der Bestätigungscode ist abgelaufen",
+		"Too many attempts, request a new code":          "Zu viele Versuche, fordern Sie einen neuen Code an",
+		"Error retrieving account deletion status":       "Fehler beim Abrufen des Status der Kontolöschung",
+		"Error processing account deletion":              "Fehler bei der Verarbeitung der Kontolöschung",
+
+		// Emails de eliminación de cuenta
+		"Confirm your account deletion": "Bestätigen Sie die Löschung Ihres Kontos",
+		"Your code to confirm the deletion of your account is %s. It expires in %d minutes.\n\nIf you did not request it, change your password: someone may have access to your account.": "Ihr Code zur Bestätigung der Löschung Ihres Kontos lautet %s. Er läuft in %d Minuten ab.\n\nWenn Sie ihn nicht angefordert haben, ändern Sie Ihr Passwort: Jemand könnte Zugriff auf Ihr Konto haben.",
+		"Your account deletion is scheduled": "Die Löschung Ihres Kontos ist geplant",
+		"Your account and all your data will be permanently deleted on %s.\n\nIf you change your mind, sign in and cancel the deletion before that date.": "Ihr Konto und alle Ihre Daten werden am %s endgültig gelöscht.\n\nWenn Sie es sich anders überlegen, melden Sie sich an und stornieren Sie die Löschung vor diesem Datum.",
+		"Your account deletion was cancelled":                                           "Die Löschung Ihres Kontos wurde storniert",
+		"The deletion of your account has been cancelled. Your account remains active.": "Die Löschung Ihres Kontos wurde storniert. Ihr Konto bleibt aktiv.",
+		"Your account has been deleted":                                                 "Ihr Konto wurde gelöscht",
+		"Your account and all your data have been permanently deleted.":                 "Ihr Konto und alle Ihre Daten wurden endgültig gelöscht.",
+
+		// Administración y auditoría
+		"Audit events retrieved successfully": "Audit-Ereignisse erfolgreich abgerufen",
+		"Error retrieving audit events":       "Fehler beim Abrufen der Audit-Ereignisse",
+		"Invalid date, expected RFC 3339":     "Ungültiges Datum, RFC 3339 erwartet",
+		"Invalid audit event ID":              "Ungültige Audit-Ereignis-ID",
+		"Audit event not found":               "Audit-Ereignis nicht gefunden",
+		"Audit chain verified":                "Audit-Kette überprüft",
+		"Error verifying audit chain":         "Fehler beim Überprüfen der Audit-Kette",
+
+		// Historial de versiones
+		"User history retrieved successfully":             "Benutzerverlauf erfolgreich abgerufen",
+		"Error retrieving user history":                   "Fehler beim Abrufen des Benutzerverlaufs",
+		"Invalid entity, expected users or user_profiles": "Ungültige Entität, users oder user_profiles erwartet",
+		"No version found at that date":                   "Zu diesem Datum wurde keine Version gefunden",
+		"Version not found":                               "Version nicht gefunden",
+		"Field cannot be reverted":                        "Dieses Feld kann nicht zurückgesetzt werden",
+		"Reverted value is already in use":                "Der wiederherzustellende Wert wird bereits verwendet",
+		"User record reverted successfully":               "Benutzerdatensatz erfolgreich zurückgesetzt",
+		"Error reverting user record":                     "Fehler beim Zurücksetzen des Benutzerdatensatzes",
+
+		// Blocking functions (registro e inicio de sesión)
+		"Invalid blocking function token":      "Ungültiges Blocking-Function-Token",
+		"Email domain is not allowed":          "Die E-Mail-Domain ist nicht erlaubt",
+		"This account has been disabled":       "Dieses Konto wurde deaktiviert",
+		"Sign-in blocked for security reasons": "Anmeldung aus Sicherheitsgründen blockiert",
+		"Error applying sign-in policies":      "Fehler beim Anwenden der Anmelderichtlinien",
+
+		// Roles y permisos
+		"Permission denied":                                      "Berechtigung verweigert",
+		"Error checking permissions":                             "Fehler beim Prüfen der Berechtigungen",
+		"Roles retrieved successfully":                           "Rollen erfolgreich abgerufen",
+		"Permissions retrieved successfully":                     "Berechtigungen erfolgreich abgerufen",
+		"Error retrieving roles":                                 "Fehler beim Abrufen der Rollen",
+		"Role created successfully":                              "Rolle erfolgreich erstellt",
+		"Role updated successfully":                              "Rolle erfolgreich aktualisiert",
+		"Role deleted successfully":                              "Rolle erfolgreich gelöscht",
+		"Role assigned successfully":                             "Rolle erfolgreich zugewiesen",
+		"Role removed successfully":                              "Rolle erfolgreich entfernt",
+		"Role claims synced successfully":                        "Rollen-Claims erfolgreich synchronisiert",
+		"Role not found":                                         "Rolle nicht gefunden",
+		"Invalid role ID":                                        "Ungültige Rollen-ID",
+		"Role not assigned to user":                              "Der Benutzer hat diese Rolle nicht",
+		"Role already exists":                                    "Die Rolle existiert bereits",
+		"The admin role cannot be deleted or change permissions": "Die Rolle admin kann weder gelöscht noch in ihren Berechtigungen geändert werden",
+		"Unknown permission":                                     "Unbekannte Berechtigung",
+		"Too many roles for the token size limit":                "Zu viele Rollen für die maximale Token-Größe",
+		"Roles saved but token claims could not be updated":      "Rollen gespeichert, aber die Token-Claims konnten nicht aktualisiert werden",
+		"Error saving role":                                      "Fehler beim Speichern der Rolle",
+
+		// Administración de usuarios
+		"Invalid filter value":                     "Ungültiger Filterwert",
+		"Invalid sort field":                       "Ungültiges Sortierfeld",
+		"User disabled successfully":               "Benutzer erfolgreich deaktiviert",
+		"User enabled successfully":                "Benutzer erfolgreich aktiviert",
+		"Password reset forced and email sent":     "Passwort zurückgesetzt und E-Mail gesendet",
+		"Invite sent successfully":                 "Einladung erfolgreich gesendet",
+		"Security timeline retrieved successfully": "Sicherheitsverlauf erfolgreich abgerufen",
+		"User has no Firebase account":             "Der Benutzer hat kein Firebase-Konto",
+		"User does not sign in with a password":    "Der Benutzer meldet sich nicht mit einem Passwort an",
+		"User has already signed in":               "Der Benutzer hat sich bereits angemeldet",
+		"The email could not be sent":              "Die E-Mail konnte nicht gesendet werden",
+		"Firebase account could not be updated":    "Das Firebase-Konto konnte nicht aktualisiert werden",
+		"Reset your password":                      "Setze dein Passwort zurück",
+		"An administrator has reset your password and closed your sessions. Choose a new password with this link:\n\n%s\n\nIf you did not expect this, contact support.": "Ein Administrator hat dein Passwort zurückgesetzt und deine Sitzungen beendet. Wähle mit diesem Link ein neues Passwort:\n\n%s\n\nWenn du das nicht erwartet hast, wende dich an den Support.",
+		"You have been invited": "Du wurdest eingeladen",
+		"An account has been created for you. Choose your password and sign in with this link:\n\n%s": "Für dich wurde ein Konto erstellt. Wähle dein Passwort und melde dich mit diesem Link an:\n\n%s",
+
+		// Suplantación de usuarios
+		"Impersonation token created successfully":                "Identitätswechsel-Token erfolgreich erstellt",
+		"This user cannot be impersonated":                        "Die Identität dieses Benutzers kann nicht übernommen werden",
+		"Impersonation session expired":                           "Der Identitätswechsel ist abgelaufen",
+		"Not allowed while impersonating a user":                  "Während eines Identitätswechsels nicht erlaubt",
+		"This action is not available while impersonating a user": "Diese Aktion ist während eines Identitätswechsels nicht verfügbar",
+
+		// Clientes de API
+		"Invalid API key":                    "Ungültiger API-Schlüssel",
+		"Invalid API client ID":              "Ungültige API-Client-ID",
+		"Error retrieving API clients":       "Fehler beim Abrufen der API-Clients",
+		"Error saving API client":            "Fehler beim Speichern des API-Clients",
+		"API clients retrieved successfully": "API-Clients erfolgreich abgerufen",
+		"API client retrieved successfully":  "API-Client erfolgreich abgerufen",
+		"API client created successfully":    "API-Client erfolgreich erstellt",
+		"API client updated successfully":    "API-Client erfolgreich aktualisiert",
+		"API key rotated successfully":       "API-Schlüssel erfolgreich rotiert",
+		"API client revoked successfully":    "API-Client erfolgreich widerrufen",
+		"API client not found":               "API-Client nicht gefunden",
+		"API client already exists":          "Der API-Client existiert bereits",
+		"API client has been revoked":        "Der API-Client wurde widerrufen",
+		"Unknown scope":                      "Unbekannter Scope",
+
+		// Introspección de tokens
+		"Invalid form data": "Ungültige Formulardaten",
+		"Token is required": "Das Token ist erforderlich",
+	},
+	"it": {
+		// Generales
+		"validation failed":                    "validazione non riuscita",
+		"Error reading request body":           "Errore durante la lettura del corpo della richiesta",
+		"Invalid JSON format":                  "Formato JSON non valido",
+		"Authentication required":              "Autenticazione richiesta",
+		"Authentication service not available": "Il servizio di autenticazione non è disponibile",
+		"Authorization header required":        "L'header Authorization è obbligatorio",
+		"Invalid Authorization header format":  "Formato dell'header Authorization non valido",
+		"Rate limit exceeded":                  "Limite di richieste superato",
+		"Request timed out":                    "La richiesta è scaduta",
+
+		// Usuarios
+		"Users retrieved successfully":                    "Utenti recuperati con successo",
+		"User retrieved successfully":                     "Utente recuperato con successo",
+		"User created successfully":                       "Utente creato con successo",
+		"User updated successfully":                       "Utente aggiornato con successo",
+		"User deleted successfully":                       "Utente eliminato con successo",
+		"User restored successfully":                      "Utente ripristinato con successo",
+		"Data export requested successfully":              "Esportazione dei dati richiesta con successo",
+		"Data export already requested":                   "L'esportazione dei dati è già stata richiesta",
+		"Data export retrieved successfully":              "Esportazione dei dati recuperata con successo",
+		"Data export not found":                           "Esportazione dei dati non trovata",
+		"Invalid export ID":                               "ID di esportazione non valido",
+		"Error requesting data export":                    "Errore durante la richiesta dell'esportazione dei dati",
+		"Error retrieving data export":                    "Errore durante il recupero dell'esportazione dei dati",
+		"Deleted user not found":                          "Utente eliminato non trovato",
+		"Restore window has expired":                      "Il periodo di ripristino è scaduto",
+		"Error restoring user":                            "Errore durante il ripristino dell'utente",
+		"Account is scheduled for deletion":               "L'account è programmato per l'eliminazione",
+		"User already exists, returning existing user":    "L'utente esiste già, viene restituito l'utente esistente",
+		"User with this email or username already exists": "Esiste già un utente con questa email o questo nome utente",
+		"User not found":                                  "Utente non trovato",
+		"Invalid user ID":                                 "ID utente non valido",
+		"Firebase ID is required":                         "Il Firebase ID è obbligatorio",
+		"Username is required":                            "Il nome utente è obbligatorio",
+		"Username availability checked":                   "Disponibilità del nome utente verificata",
+		"Username is not allowed":                         "Il nome utente non è consentito",
+		"Username is already taken":                       "Il nome utente è già in uso",
+		"Error checking username availability":            "Errore durante la verifica della disponibilità del nome utente",
+		"Email is required":                               "L'email è obbligatoria",
+		"Search query is required":                        "Il termine di ricerca è obbligatorio",
+		"Search completed successfully":                   "Ricerca completata con successo",
+		"Users counted successfully":                      "Utenti contati con successo",
+		"Active users retrieved successfully":             "Utenti attivi recuperati con successo",
+		"Login info updated successfully":                 "Informazioni di accesso aggiornate con successo",
+		"Error fetching users":                            "Errore durante il recupero degli utenti",
+		"Error creating user":                             "Errore durante la creazione dell'utente",
+		"Error updating user":                             "Errore durante l'aggiornamento dell'utente",
+		"Error deleting user":                             "Errore durante l'eliminazione dell'utente",
+		"Error searching users":                           "Errore durante la ricerca degli utenti",
+		"Error counting users":                            "Errore durante il conteggio degli utenti",
+		"Error fetching active users":                     "Errore durante il recupero degli utenti attivi",
+		"Error updating login info":                       "Errore durante l'aggiornamento delle informazioni di accesso",
+		"User profile endpoint not implemented yet":       "L'endpoint del profilo utente non è ancora implementato",
+		"User settings endpoint not implemented yet":      "L'endpoint delle impostazioni utente non è ancora implementato",
+		"User stats endpoint not implemented yet":         "L'endpoint delle statistiche utente non è ancora implementato",
+
+		// Autenticación
+		"Login successful":                                "Accesso effettuato con successo",
+		"Google login successful":                         "Accesso con Google effettuato con successo",
+		"Facebook login successful":                       "Accesso con Facebook effettuato con successo",
+		"Email login successful":                          "Accesso via email effettuato con successo",
+		"Logout successful":                               "Disconnessione effettuata con successo",
+		"Invalid token":                                   "Token non valido",
+		"Invalid token format":                            "Formato del token non valido",
+		"No token provided":                               "Nessun token fornito",
+		"Invalid Google token":                            "Token Google non valido",
+		"Invalid Facebook token":                          "Token Facebook non valido",
+		"Invalid Google authentication":                   "Autenticazione Google non valida",
+		"Invalid Facebook authentication":                 "Autenticazione Facebook non valida",
+		"Invalid email/password authentication":           "Autenticazione email/password non valida",
+		"Failed to get user information":                  "Impossibile ottenere le informazioni dell'utente",
+		"Failed to get user profile":                      "Impossibile ottenere il profilo dell'utente",
+		"Profile retrieved successfully":                  "Profilo recuperato con successo",
+		"Failed to revoke tokens":                         "Impossibile revocare i token",
+		"All tokens revoked successfully":                 "Tutti i token sono stati revocati con successo",
+		"Active sessions endpoint not implemented yet":    "L'endpoint delle sessioni attive non è ancora implementato",
+		"Session revocation endpoint not implemented yet": "L'endpoint di revoca della sessione non è ancora implementato",
+		"Token refresh should be handled on the client side using Firebase SDK":   "Il rinnovo del token deve essere gestito lato client con l'SDK di Firebase",
+		"Token refresh should be handled on the client side":                      "Il rinnovo del token deve essere gestito lato client",
+		"Password change should be handled on the client side using Firebase SDK": "Il cambio della password deve essere gestito lato client con l'SDK di Firebase",
+		"Profile update functionality requires Firebase Admin SDK implementation": "L'aggiornamento del profilo richiede l'implementazione dell'SDK Admin di Firebase",
+
+		// Tokens
+		"Token is valid":                    "Il token è valido",
+		"Token info retrieved successfully": "Informazioni sul token recuperate con successo",
+		"Custom token creation requires Firebase Admin SDK implementation": "La creazione di token personalizzati richiede l'implementazione dell'SDK Admin di Firebase",
+
+		// Contraseñas
+		"If the email exists, a password reset link has been sent": "Se l'email esiste, è stato inviato un link per reimpostare la password",
+		"Password reset completed successfully":                    "Password reimpostata con successo",
+		"Password changed successfully":                            "Password modificata con successo",
+		"Invalid current token":                                    "Token attuale non valido",
+		"Invalid or expired reset code":                            "Codice di reimpostazione non valido o scaduto",
+		"Invalid or expired reset token":                           "Token di reimpostazione non valido o scaduto",
+		"Reset code is valid":                                      "Il codice di reimpostazione è valido",
+		"Reset token is valid":                                     "Il token di reimpostazione è valido",
+		"Password strength checked":                                "Robustezza della password verificata",
+		"Password history endpoint not implemented yet":            "L'endpoint della cronologia delle password non è ancora implementato",
+		"Password policy retrieved successfully":                   "Criteri delle password recuperati con successo",
+
+		// Verificación de email
+		"If the email exists, a verification email has been sent":               "Se l'email esiste, è stata inviata un'email di verifica",
+		"Verification email sent successfully":                                  "Email di verifica inviata con successo",
+		"Email not verified yet":                                                "L'email non è ancora verificata",
+		"Email verified successfully":                                           "Email verificata con successo",
+		"Invalid email or verification code":                                    "Email o codice di verifica non valido",
+		"Invalid verification code":                                             "Codice di verifica non valido",
+		"Error verifying email":                                                 "Errore durante la verifica dell'email",
+		"Verification status retrieved successfully":                            "Stato di verifica recuperato con successo",
+		"Verification history endpoint not implemented yet":                     "L'endpoint della cronologia delle verifiche non è ancora implementato",
+		"Email settings retrieved successfully":                                 "Impostazioni email recuperate con successo",
+		"Email settings update endpoint not implemented yet":                    "L'endpoint di aggiornamento delle impostazioni email non è ancora implementato",
+		"Email update functionality requires Firebase Admin SDK implementation": "L'aggiornamento dell'email richiede l'implementazione dell'SDK Admin di Firebase",
+
+		// Login y sesiones
+		"Login tracked successfully":                 "Accesso registrato con successo",
+		"Login history retrieved successfully":       "Cronologia degli accessi recuperata con successo",
+		"Login attempts retrieved successfully":      "Tentativi di accesso recuperati con successo",
+		"Security check completed":                   "Controllo di sicurezza completato",
+		"Active sessions retrieved successfully":     "Sessioni attive recuperate con successo",
+		"Session ID is required":                     "L'ID della sessione è obbligatorio",
+		"Session terminated successfully":            "Sessione terminata con successo",
+		"All sessions terminated successfully":       "Tutte le sessioni sono state terminate con successo",
+		"Failed to terminate sessions":               "Impossibile terminare le sessioni",
+		"Suspicious activity retrieved successfully": "Attività sospette recuperate con successo",
+
+		// Eliminación de cuenta
+		"Confirmation code sent to your email":           "Codice di conferma inviato alla tua email",
+		"Account deletion scheduled":                     "Eliminazione dell'account programmata",
+		"Account deletion already scheduled":             "L'eliminazione dell'account è già programmata",
+		"Account deletion already in progress":           "L'eliminazione dell'account è già in corso",
+		"Account deletion cancelled":                     "Eliminazione dell'account annullata",
+		"Account deletion status retrieved successfully": "Stato dell'eliminazione dell'account ottenuto con successo",
+		"No account deletion requested":                  "Nessuna eliminazione dell'account richiesta",
+		"No scheduled account deletion":                  "Nessuna eliminazione dell'account programmata",
+		"Recent authentication required":                 "È richiesto un accesso recente",
+		"Request a confirmation code first":              "Richiedi prima un codice di conferma",
+		"Invalid confirmation code":                      "Codice di conferma non valido",
+		"Confirmation code has expired":                  "Il codice di conferma è scaduto",
+		"Too many attempts, request a new code":          "Troppi tentativi, richiedi un nuovo codice",
+		"Error retrieving account deletion status":       "Errore durante il recupero dello stato dell'eliminazione dell'account",
+		"Error processing account deletion":              "Errore durante l'elaborazione dell'eliminazione dell'account",
+
+		// Emails de eliminación de cuenta
+		"Confirm your account deletion": "Conferma l'eliminazione del tuo account",
+		"Your code to confirm the deletion of your account is %s. It expires in %d minutes.\n\nIf you did not request it, change your password: someone may have access to your account.": "Il tuo codice per confermare l'eliminazione del tuo account è %s. Scade tra %d minuti.\n\nSe non l'hai richiesto, cambia la tua password: qualcuno potrebbe avere accesso al tuo account.",
+		"Your account deletion is scheduled": "L'eliminazione del tuo account è programmata",
+		"Your account and all your data will be permanently deleted on %s.\n\nIf you change your mind, sign in and cancel the deletion before that date.": "Il tuo account e tutti i tuoi dati saranno eliminati definitivamente il %s.\n\nSe cambi idea, accedi e annulla l'eliminazione prima di quella data.",
+		"Your account deletion was cancelled":                                           "L'eliminazione del tuo account è stata annullata",
+		"The deletion of your account has been cancelled. Your account remains active.": "L'eliminazione del tuo account è stata annullata. Il tuo account rimane attivo.",
+		"Your account has been deleted":                                                 "Il tuo account è stato eliminato",
+		"Your account and all your data have been permanently deleted.":                 "Il tuo account e tutti i tuoi dati sono stati eliminati definitivamente.",
+
+		// Administración y auditoría
+		"Audit events retrieved successfully": "Eventi di audit ottenuti con successo",
+		"Error retrieving audit events":       "Errore durante il recupero degli eventi di audit",
+		"Invalid date, expected RFC 3339":     "Data non valida, previsto RFC 3339",
+		"Invalid audit event ID":              "ID evento di audit non valido",
+		"Audit event not found":               "Evento di audit non trovato",
+		"Audit chain verified":                "Catena di audit verificata",
+		"Error verifying audit chain":         "Errore durante la verifica della catena di audit",
+
+		// Historial de versiones
+		"User history retrieved successfully":             "Cronologia dell'utente recuperata con successo",
+		"Error retrieving user history":                   "Errore durante il recupero della cronologia dell'utente",
+		"Invalid entity, expected users or user_profiles": "Entità non valida, previsto users o user_profiles",
+		"No version found at that date":                   "Nessuna versione trovata in quella data",
+		"Version not found":                               "Versione non trovata",
+		"Field cannot be reverted":                        "Il campo non può essere ripristinato",
+		"Reverted value is already in use":                "Il valore da ripristinare è già in uso",
+		"User record reverted successfully":               "Record dell'utente ripristinato con successo",
+		"Error reverting user record":                     "Errore durante il ripristino del record dell'utente",
+
+		// Blocking functions (registro e inicio de sesión)
+		"Invalid blocking function token":      "Token di blocking function non valido",
+		"Email domain is not allowed":          "Il dominio dell'email non è consentito",
+		"This account has been disabled":       "Questo account è stato disattivato",
+		"Sign-in blocked for security reasons": "Accesso bloccato per motivi di sicurezza",
+		"Error applying sign-in policies":      "Errore nell'applicazione delle politiche di accesso",
+
+		// Roles y permisos
+		"Permission denied":                                      "Permesso negato",
+		"Error checking permissions":                             "Errore nella verifica dei permessi",
+		"Roles retrieved successfully":                           "Ruoli ottenuti con successo",
+		"Permissions retrieved successfully":                     "Permessi ottenuti con successo",
+		"Error retrieving roles":                                 "Errore nel recupero dei ruoli",
+		"Role created successfully":                              "Ruolo creato con successo",
+		"Role updated successfully":                              "Ruolo aggiornato con successo",
+		"Role deleted successfully":                              "Ruolo eliminato con successo",
+		"Role assigned successfully":                             "Ruolo assegnato con successo",
+		"Role removed successfully":                              "Ruolo rimosso con successo",
+		"Role claims synced successfully":                        "Claims dei ruoli sincronizzati con successo",
+		"Role not found":                                         "Ruolo non trovato",
+		"Invalid role ID":                                        "ID ruolo non valido",
+		"Role not assigned to user":                              "L'utente non ha questo ruolo",
+		"Role already exists":                                    "Il ruolo esiste già",
+		"The admin role cannot be deleted or change permissions": "Il ruolo admin non può essere eliminato né cambiare i suoi permessi",
+		"Unknown permission":                                     "Permesso sconosciuto",
+		"Too many roles for the token size limit":                "Troppi ruoli per il limite di dimensione del token",
+		"Roles saved but token claims could not be updated":      "Ruoli salvati ma non è stato possibile aggiornare i claims del token",
+		"Error saving role":                                      "Errore nel salvataggio del ruolo",
+
+		// Administración de usuarios
+		"Invalid filter value":                     "Valore del filtro non valido",
+		"Invalid sort field":                       "Campo di ordinamento non valido",
+		"User disabled successfully":               "Utente disabilitato con successo",
+		"User enabled successfully":                "Utente abilitato con successo",
+		"Password reset forced and email sent":     "Password reimpostata ed email inviata",
+		"Invite sent successfully":                 "Invito inviato con successo",
+		"Security timeline retrieved successfully": "Cronologia di sicurezza ottenuta con successo",
+		"User has no Firebase account":             "L'utente non ha un account Firebase",
+		"User does not sign in with a password":    "L'utente non accede con una password",
+		"User has already signed in":               "L'utente ha già effettuato l'accesso",
+		"The email could not be sent":              "Non è stato possibile inviare l'email",
+		"Firebase account could not be updated":    "Non è stato possibile aggiornare l'account Firebase",
+		"Reset your password":                      "Reimposta la tua password",
+		"An administrator has reset your password and closed your sessions. Choose a new password with this link:\n\n%s\n\nIf you did not expect this, contact support.": "Un amministratore ha reimpostato la tua password e chiuso le tue sessioni. Scegli una nuova password con questo link:\n\n%s\n\nSe non te lo aspettavi, contatta l'assistenza.",
+		"You have been invited": "Sei stato invitato",
+		"An account has been created for you. Choose your password and sign in with this link:\n\n%s": "È stato creato un account per te. Scegli la tua password e accedi con questo link:\n\n%s",
+
+		// Suplantación de usuarios
+		"Impersonation token created successfully":                "Token di impersonificazione creato con successo",
+		"This user cannot be impersonated":                        "Questo utente non può essere impersonato",
+		"Impersonation session expired":                           "L'impersonificazione è scaduta",
+		"Not allowed while impersonating a user":                  "Non consentito durante l'impersonificazione di un utente",
+		"This action is not available while impersonating a user": "Questa azione non è disponibile durante l'impersonificazione di un utente",
+
+		// Clientes de API
+		"Invalid API key":                    "Chiave API non valida",
+		"Invalid API client ID":              "ID del client API non valido",
+		"Error retrieving API clients":       "Errore durante il recupero dei client API",
+		"Error saving API client":            "Errore durante il salvataggio del client API",
+		"API clients retrieved successfully": "Client API recuperati con successo",
+		"API client retrieved successfully":  "Client API recuperato con successo",
+		"API client created successfully":    "Client API creato con successo",
+		"API client updated successfully":    "Client API aggiornato con successo",
+		"API key rotated successfully":       "Chiave API ruotata con successo",
+		"API client revoked successfully":    "Client API revocato con successo",
+		"API client not found":               "Client API non trovato",
+		"API client already exists":          "Il client API esiste già",
+		"API client has been revoked":        "Il client API è stato revocato",
+		"Unknown scope":                      "Scope sconosciuto",
+
+		// Introspección de tokens
+		"Invalid form data": "Dati del modulo non validi",
+		"Token is required": "Il token è obbligatorio",
+	},
+	"pt": {
+		// Generales
+		"validation failed":                    "a validação falhou",
+		"Error reading request body":           "Erro ao ler o corpo da requisição",
+		"Invalid JSON format":                  "Formato JSON inválido",
+		"Authentication required":              "Autenticação obrigatória",
+		"Authentication service not available": "O serviço de autenticação não está disponível",
+		"Authorization header required":        "O header Authorization é obrigatório",
+		"Invalid Authorization header format":  "Formato do header Authorization inválido",
+		"Rate limit exceeded":                  "Limite de requisições excedido",
+		"Request timed out":                    "A requisição excedeu o tempo limite",
+
+		// Usuarios
+		"Users retrieved successfully":                    "Usuários obtidos com sucesso",
+		"User retrieved successfully":                     "Usuário obtido com sucesso",
+		"User created successfully":                       "Usuário criado com sucesso",
+		"User updated successfully":                       "Usuário atualizado com sucesso",
+		"User deleted successfully":                       "Usuário excluído com sucesso",
+		"User restored successfully":                      "Usuário restaurado com sucesso",
+		"Data export requested successfully":              "Exportação de dados solicitada com sucesso",
+		"Data export already requested":                   "A exportação de dados já foi solicitada",
+		"Data export retrieved successfully":              "Exportação de dados obtida com sucesso",
+		"Data export not found":                           "Exportação de dados não encontrada",
+		"Invalid export ID":                               "ID de exportação inválido",
+		"Error requesting data export":                    "Erro ao solicitar a exportação de dados",
+		"Error retrieving data export":                    "Erro ao obter a exportação de dados",
+		"Deleted user not found":                          "Usuário excluído não encontrado",
+		"Restore window has expired":                      "O prazo para restaurar o usuário expirou",
+		"Error restoring user":                            "Erro ao restaurar o usuário",
+		"Account is scheduled for deletion":               "A conta está programada para exclusão",
+		"User already exists, returning existing user":    "O usuário já existe, retornando o usuário existente",
+		"User with this email or username already exists": "Já existe um usuário com este email ou nome de usuário",
+		"User not found":                                  "Usuário não encontrado",
+		"Invalid user ID":                                 "ID de usuário inválido",
+		"Firebase ID is required":                         "O Firebase ID é obrigatório",
+		"Username is required":                            "O nome de usuário é obrigatório",
+		"Username availability checked":                   "Disponibilidade do nome de usuário verificada",
+		"Username is not allowed":                         "O nome de usuário não é permitido",
+		"Username is already taken":                       "O nome de usuário já está em uso",
+		"Error checking username availability":            "Erro ao verificar a disponibilidade do nome de usuário",
+		"Email is required":                               "O email é obrigatório",
+		"Search query is required":                        "O termo de busca é obrigatório",
+		"Search completed successfully":                   "Busca concluída com sucesso",
+		"Users counted successfully":                      "Usuários contados com sucesso",
+		"Active users retrieved successfully":             "Usuários ativos obtidos com sucesso",
+		"Login info updated successfully":                 "Informações de login atualizadas com sucesso",
+		"Error fetching users":                            "Erro ao obter usuários",
+		"Error creating user":                             "Erro ao criar o usuário",
+		"Error updating user":                             "Erro ao atualizar o usuário",
+		"Error deleting user":                             "Erro ao excluir o usuário",
+		"Error searching users":                           "Erro ao buscar usuários",
+		"Error counting users":                            "Erro ao contar usuários",
+		"Error fetching active users":                     "Erro ao obter usuários ativos",
+		"Error updating login info":                       "Erro ao atualizar as informações de login",
+		"User profile endpoint not implemented yet":       "O endpoint de perfil de usuário ainda não foi implementado",
+		"User settings endpoint not implemented yet":      "O endpoint de configurações de usuário ainda não foi implementado",
+		"User stats endpoint not implemented yet":         "O endpoint de estatísticas de usuário ainda não foi implementado",
+
+		// Autenticación
+		"Login successful":                                "Login realizado com sucesso",
+		"Google login successful":                         "Login com Google realizado com sucesso",
+		"Facebook login successful":                       "Login com Facebook realizado com sucesso",
+		"Email login successful":                          "Login por email realizado com sucesso",
+		"Logout successful":                               "Logout realizado com sucesso",
+		"Invalid token":                                   "Token inválido",
+		"Invalid token format":                            "Formato de token inválido",
+		"No token provided":                               "Nenhum token fornecido",
+		"Invalid Google token":                            "Token do Google inválido",
+		"Invalid Facebook token":                          "Token do Facebook inválido",
+		"Invalid Google authentication":                   "Autenticação do Google inválida",
+		"Invalid Facebook authentication":                 "Autenticação do Facebook inválida",
+		"Invalid email/password authentication":           "Autenticação por email/senha inválida",
+		"Failed to get user information":                  "Não foi possível obter as informações do usuário",
+		"Failed to get user profile":                      "Não foi possível obter o perfil do usuário",
+		"Profile retrieved successfully":                  "Perfil obtido com sucesso",
+		"Failed to revoke tokens":                         "Não foi possível revogar os tokens",
+		"All tokens revoked successfully":                 "Todos os tokens foram revogados com sucesso",
+		"Active sessions endpoint not implemented yet":    "O endpoint de sessões ativas ainda não foi implementado",
+		"Session revocation endpoint not implemented yet": "O endpoint de revogação de sessão ainda não foi implementado",
+		"Token refresh should be handled on the client side using Firebase SDK":   "A renovação do token deve ser feita no cliente com o SDK do Firebase",
+		"Token refresh should be handled on the client side":                      "A renovação do token deve ser feita no cliente",
+		"Password change should be handled on the client side using Firebase SDK": "A troca de senha deve ser feita no cliente com o SDK do Firebase",
+		"Profile update functionality requires Firebase Admin SDK implementation": "A atualização do perfil requer a implementação do SDK Admin do Firebase",
+
+		// Tokens
+		"Token is valid":                    "O token é válido",
+		"Token info retrieved successfully": "Informações do token obtidas com sucesso",
+		"Custom token creation requires Firebase Admin SDK implementation": "A criação de tokens personalizados requer a implementação do SDK Admin do Firebase",
+
+		// Contraseñas
+		"If the email exists, a password reset link has been sent": "Se o email existir, um link para redefinir a senha foi enviado",
+		"Password reset completed successfully":                    "Senha redefinida com sucesso",
+		"Password changed successfully":                            "Senha alterada com sucesso",
+		"Invalid current token":                                    "Token atual inválido",
+		"Invalid or expired reset code":                            "Código de redefinição inválido ou expirado",
+		"Invalid or expired reset token":                           "Token de redefinição inválido ou expirado",
+		"Reset code is valid":                                      "O código de redefinição é válido",
+		"Reset token is valid":                                     "O token de redefinição é válido",
+		"Password strength checked":                                "Força da senha verificada",
+		"Password history endpoint not implemented yet":            "O endpoint de histórico de senhas ainda não foi implementado",
+		"Password policy retrieved successfully":                   "Política de senhas obtida com sucesso",
+
+		// Verificación de email
+		"If the email exists, a verification email has been sent":               "Se o email existir, um email de verificação foi enviado",
+		"Verification email sent successfully":                                  "Email de verificação enviado com sucesso",
+		"Email not verified yet":                                                "O email ainda não foi verificado",
+		"Email verified successfully":                                           "Email verificado com sucesso",
+		"Invalid email or verification code":                                    "Email ou código de verificação inválido",
+		"Invalid verification code":                                             "Código de verificação inválido",
+		"Error verifying email":                                                 "Erro ao verificar o email",
+		"Verification status retrieved successfully":                            "Status de verificação obtido com sucesso",
+		"Verification history endpoint not implemented yet":                     "O endpoint de histórico de verificações ainda não foi implementado",
+		"Email settings retrieved successfully":                                 "Configurações de email obtidas com sucesso",
+		"Email settings update endpoint not implemented yet":                    "O endpoint de atualização das configurações de email ainda não foi implementado",
+		"Email update functionality requires Firebase Admin SDK implementation": "A atualização do email requer a implementação do SDK Admin do Firebase",
+
+		// Login y sesiones
+		"Login tracked successfully":                 "Login registrado com sucesso",
+		"Login history retrieved successfully":       "Histórico de login obtido com sucesso",
+		"Login attempts retrieved successfully":      "Tentativas de login obtidas com sucesso",
+		"Security check completed":                   "Verificação de segurança concluída",
+		"Active sessions retrieved successfully":     "Sessões ativas obtidas com sucesso",
+		"Session ID is required":                     "O ID da sessão é obrigatório",
+		"Session terminated successfully":            "Sessão encerrada com sucesso",
+		"All sessions terminated successfully":       "Todas as sessões foram encerradas com sucesso",
+		"Failed to terminate sessions":               "Não foi possível encerrar as sessões",
+		"Suspicious activity retrieved successfully": "Atividade suspeita obtida com sucesso",
+
+		// Eliminación de cuenta
+		"Confirmation code sent to your email":           "Código de confirmação enviado para o seu email",
+		"Account deletion scheduled":                     "Exclusão da conta agendada",
+		"Account deletion already scheduled":             "A exclusão da conta já está agendada",
+		"Account deletion already in progress":           "A exclusão da conta já está em andamento",
+		"Account deletion cancelled":                     "Exclusão da conta cancelada",
+		"Account deletion status retrieved successfully": "Status da exclusão da conta obtido com sucesso",
+		"No account deletion requested":                  "Nenhuma exclusão da conta solicitada",
+		"No scheduled account deletion":                  "Nenhuma exclusão da conta agendada",
+		"Recent authentication required":                 "É necessário ter feito login recentemente",
+		"Request a confirmation code first":              "Solicite primeiro um código de confirmação",
+		"Invalid confirmation code":                      "Código de confirmação inválido",
+		"Confirmation code has expired":                  "O código de confirmação expirou",
+		"Too many attempts, request a new code":          "Muitas tentativas, solicite um novo código",
+		"Error retrieving account deletion status":       "Erro ao obter o status da exclusão da conta",
+		"Error processing account deletion":              "Erro ao processar a exclusão da conta",
+
+		// Emails de eliminación de cuenta
+		"Confirm your account deletion": "Confirme a exclusão da sua conta",
+		"Your code to confirm the deletion of your account is %s. It expires in %d minutes.\n\nIf you did not request it, change your password: someone may have access to your account.": "Seu código para confirmar a exclusão da sua conta é %s. Ele expira em %d minutos.\n\nSe você não o solicitou, altere sua senha: alguém pode ter acesso à sua conta.",
+		"Your account deletion is scheduled": "A exclusão da sua conta está agendada",
+		"Your account and all your data will be permanently deleted on %s.\n\nIf you change your mind, sign in and cancel the deletion before that date.": "Sua conta e todos os seus dados serão excluídos definitivamente em %s.\n\nSe mudar de ideia, faça login e cancele a exclusão antes dessa data.",
+		"Your account deletion was cancelled":                                           "A exclusão da sua conta foi cancelada",
+		"The deletion of your account has been cancelled. Your account remains active.": "A exclusão da sua conta foi cancelada. Sua conta continua ativa.",
+		"Your account has been deleted":                                                 "Sua conta foi excluída",
+		"Your account and all your data have been permanently deleted.":                 "Sua conta e todos os seus dados foram excluídos definitivamente.",
+
+		// Administración y auditoría
+		"Audit events retrieved successfully": "Eventos de auditoria obtidos com sucesso",
+		"Error retrieving audit events":       "Erro ao obter os eventos de auditoria",
+		"Invalid date, expected RFC 3339":     "Data inválida, esperado RFC 3339",
+		"Invalid audit event ID":              "ID de evento de auditoria inválido",
+		"Audit event not found":               "Evento de auditoria não encontrado",
+		"Audit chain verified":                "Cadeia de auditoria verificada",
+		"Error verifying audit chain":         "Erro ao verificar a cadeia de auditoria",
+
+		// Historial de versiones
+		"User history retrieved successfully":             "Histórico do usuário obtido com sucesso",
+		"Error retrieving user history":                   "Erro ao obter o histórico do usuário",
+		"Invalid entity, expected users or user_profiles": "Entidade inválida, esperado users ou user_profiles",
+		"No version found at that date":                   "Nenhuma versão encontrada nessa data",
+		"Version not found":                               "Versão não encontrada",
+		"Field cannot be reverted":                        "O campo não pode ser revertido",
+		"Reverted value is already in use":                "O valor a restaurar já está em uso",
+		"User record reverted successfully":               "Registro do usuário revertido com sucesso",
+		"Error reverting user record":                     "Erro ao reverter o registro do usuário",
+
+		// Blocking functions (registro e inicio de sesión)
+		"Invalid blocking function token":      "Token de blocking function inválido",
+		"Email domain is not allowed":          "O domínio do email não é permitido",
+		"This account has been disabled":       "Esta conta foi desativada",
+		"Sign-in blocked for security reasons": "Login bloqueado por motivos de segurança",
+		"Error applying sign-in policies":      "Erro ao aplicar as políticas de login",
+
+		// Roles y permisos
+		"Permission denied":                                      "Permissão negada",
+		"Error checking permissions":                             "Erro ao verificar as permissões",
+		"Roles retrieved successfully":                           "Funções obtidas com sucesso",
+		"Permissions retrieved successfully":                     "Permissões obtidas com sucesso",
+		"Error retrieving roles":                                 "Erro ao obter as funções",
+		"Role created successfully":                              "Função criada com sucesso",
+		"Role updated successfully":                              "Função atualizada com sucesso",
+		"Role deleted successfully":                              "Função excluída com sucesso",
+		"Role assigned successfully":                             "Função atribuída com sucesso",
+		"Role removed successfully":                              "Função removida com sucesso",
+		"Role claims synced successfully":                        "Claims das funções sincronizados com sucesso",
+		"Role not found":                                         "Função não encontrada",
+		"Invalid role ID":                                        "ID de função inválido",
+		"Role not assigned to user":                              "O usuário não tem essa função",
+		"Role already exists":                                    "A função já existe",
+		"The admin role cannot be deleted or change permissions": "A função admin não pode ser excluída nem alterar suas permissões",
+		"Unknown permission":                                     "Permissão desconhecida",
+		"Too many roles for the token size limit":                "Funções demais para o limite de tamanho do token",
+		"Roles saved but token claims could not be updated":      "As funções foram salvas, mas não foi possível atualizar os claims do token",
+		"Error saving role":                                      "Erro ao salvar a função",
+
+		// Administración de usuarios
+		"Invalid filter value":                     "Valor de filtro inválido",
+		"Invalid sort field":                       "Campo de ordenação inválido",
+		"User disabled successfully":               "Usuário desabilitado com sucesso",
+		"User enabled successfully":                "Usuário habilitado com sucesso",
+		"Password reset forced and email sent":     "Senha redefinida e email enviado",
+		"Invite sent successfully":                 "Convite enviado com sucesso",
+		"Security timeline retrieved successfully": "Histórico de segurança obtido com sucesso",
+		"User has no Firebase account":             "O usuário não tem conta do Firebase",
+		"User does not sign in with a password":    "O usuário não entra com senha",
+		"User has already signed in":               "O usuário já entrou",
+		"The email could not be sent":              "Não foi possível enviar o email",
+		"Firebase account could not be updated":    "Não foi possível atualizar a conta do Firebase",
+		"Reset your password":                      "Redefina sua senha",
+		"An administrator has reset your password and closed your sessions. Choose a new password with this link:\n\n%s\n\nIf you did not expect this, contact support.": "Um administrador redefiniu sua senha e encerrou suas sessões. Escolha uma nova senha com este link:\n\n%s\n\nSe você não esperava isso, entre em contato com o suporte.",
+		"You have been invited": "Você foi convidado",
+		"An account has been created for you. Choose your password and sign in with this link:\n\n%s": "Uma conta foi criada para você. Escolha sua senha e entre com este link:\n\n%s",
+
+		// Suplantación de usuarios
+		"Impersonation token created successfully":                "Token de personificação criado com sucesso",
+		"This user cannot be impersonated":                        "Este usuário não pode ser personificado",
+		"Impersonation session expired":                           "A personificação expirou",
+		"Not allowed while impersonating a user":                  "Não permitido durante a personificação de um usuário",
+		"This action is not available while impersonating a user": "Esta ação não está disponível durante a personificação de um usuário",
+
+		// Clientes de API
+		"Invalid API key":                    "Chave de API inválida",
+		"Invalid API client ID":              "ID de cliente de API inválido",
+		"Error retrieving API clients":       "Erro ao obter os clientes de API",
+		"Error saving API client":            "Erro ao salvar o cliente de API",
+		"API clients retrieved successfully": "Clientes de API obtidos com sucesso",
+		"API client retrieved successfully":  "Cliente de API obtido com sucesso",
+		"API client created successfully":    "Cliente de API criado com sucesso",
+		"API client updated successfully":    "Cliente de API atualizado com sucesso",
+		"API key rotated successfully":       "Chave de API rotacionada com sucesso",
+		"API client revoked successfully":    "Cliente de API revogado com sucesso",
+		"API client not found":               "Cliente de API não encontrado",
+		"API client already exists":          "O cliente de API já existe",
+		"API client has been revoked":        "O cliente de API foi revogado",
+		"Unknown scope":                      "Scope desconhecido",
+
+		// Introspección de tokens
+		"Invalid form data": "Dados de formulário inválidos",
+		"Token is required": "O token é obrigatório",
+	},
+}
@@ -0,0 +1,116 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"it-app_user/internal/logger"
+	"it-app_user/internal/metrics"
+)
+
+var (
+	// ErrUnknownJob indica que no hay ningún job registrado con ese nombre
+	ErrUnknownJob = errors.New("unknown job")
+	// ErrJobRunning indica que el job ya se está ejecutando en esta instancia
+	ErrJobRunning = errors.New("job already running")
+)
+
+// Job es una tarea periódica de mantenimiento. Run debe ser idempotente: el
+// mismo job puede ejecutarse a la vez en varias instancias del servicio.
+type Job struct {
+	Name string
+	// Interval entre ejecuciones del scheduler en proceso; 0 solo permite
+	// ejecutarlo a demanda (p. ej. desde Cloud Scheduler)
+	Interval time.Duration
+	// Timeout de cada ejecución; 0 sin límite
+	Timeout time.Duration
+	Run     func(ctx context.Context) error
+}
+
+// Scheduler ejecuta los jobs registrados periódicamente o a demanda, sin
+// solapar dos ejecuciones del mismo job en la instancia
+type Scheduler struct {
+	jobs map[string]Job
+
+	mu      sync.Mutex
+	running map[string]bool
+}
+
+// NewScheduler crea un scheduler vacío
+func NewScheduler() *Scheduler {
+	return &Scheduler{
+		jobs:    make(map[string]Job),
+		running: make(map[string]bool),
+	}
+}
+
+// Register agrega un job. Debe llamarse antes de Start.
+func (s *Scheduler) Register(job Job) {
+	s.jobs[job.Name] = job
+}
+
+// Start lanza una goroutine por cada job con Interval > 0 hasta que ctx se cancele
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, job := range s.jobs {
+		if job.Interval <= 0 {
+			continue
+		}
+		go func(job Job) {
+			ticker := time.NewTicker(job.Interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					// Los errores ya quedan registrados en RunNow
+					_ = s.RunNow(ctx, job.Name)
+				}
+			}
+		}(job)
+	}
+}
+
+// RunNow ejecuta el job una vez y espera a que termine
+func (s *Scheduler) RunNow(ctx context.Context, name string) error {
+	job, ok := s.jobs[name]
+	if !ok {
+		return ErrUnknownJob
+	}
+
+	s.mu.Lock()
+	if s.running[name] {
+		s.mu.Unlock()
+		return ErrJobRunning
+	}
+	s.running[name] = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.running, name)
+		s.mu.Unlock()
+	}()
+
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, job.Timeout)
+		defer cancel()
+	}
+
+	log := logger.GetLogger().WithField("job", name)
+	start := time.Now()
+	err := job.Run(ctx)
+	duration := time.Since(start)
+
+	if err != nil {
+		metrics.JobRuns.WithLabelValues(name, "error").Inc()
+		log.WithError(err).WithField("duration_ms", duration.Milliseconds()).Error("Job failed")
+		return err
+	}
+	metrics.JobRuns.WithLabelValues(name, "success").Inc()
+	log.WithField("duration_ms", duration.Milliseconds()).Info("Job completed")
+	return nil
+}
@@ -8,33 +8,26 @@ import (
 
 var Log *logrus.Logger
 
-func Init() {
+// Init configura el logger con el nivel indicado (debug, info, warn o error)
+func Init(level string) {
 	Log = logrus.New()
 	
 	// Configurar formato JSON para producción
 	Log.SetFormatter(&logrus.JSONFormatter{})
 	
-	// Configurar nivel de log desde variable de entorno
-	level := os.Getenv("LOG_LEVEL")
-	switch level {
-	case "debug":
-		Log.SetLevel(logrus.DebugLevel)
-	case "info":
-		Log.SetLevel(logrus.InfoLevel)
-	case "warn":
-		Log.SetLevel(logrus.WarnLevel)
-	case "error":
-		Log.SetLevel(logrus.ErrorLevel)
-	default:
-		Log.SetLevel(logrus.InfoLevel)
+	// El nivel ya viene validado por config; si no se reconoce se usa info
+	parsed, err := logrus.ParseLevel(level)
+	if err != nil {
+		parsed = logrus.InfoLevel
 	}
+	Log.SetLevel(parsed)
 	
 	Log.SetOutput(os.Stdout)
 }
 
 func GetLogger() *logrus.Logger {
 	if Log == nil {
-		Init()
+		Init("info")
 	}
 	return Log
 }
\ No newline at end of file
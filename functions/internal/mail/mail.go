@@ -0,0 +1,83 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"it-app_user/internal/logger"
+)
+
+// Message es un email de texto plano
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender envía emails transaccionales (códigos de confirmación y notificaciones)
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// LogSender escribe los emails en el log en lugar de enviarlos. Solo para
+// desarrollo: el cuerpo puede contener códigos de confirmación.
+type LogSender struct{}
+
+func NewLogSender() *LogSender {
+	return &LogSender{}
+}
+
+func (s *LogSender) Send(ctx context.Context, msg Message) error {
+	logger.GetLogger().WithFields(map[string]interface{}{
+		"to":      msg.To,
+		"subject": msg.Subject,
+		"body":    msg.Body,
+	}).Info("Email not sent (log mail driver)")
+	return nil
+}
+
+// SMTPSender envía los emails por SMTP con STARTTLS (si el servidor lo ofrece)
+// y autenticación PLAIN
+type SMTPSender struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTPSender(host string, port int, username, password, from string) *SMTPSender {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPSender{
+		addr: fmt.Sprintf("%s:%d", host, port),
+		auth: auth,
+		from: from,
+	}
+}
+
+func (s *SMTPSender) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return smtp.SendMail(s.addr, s.auth, s.from, []string{msg.To}, s.build(msg))
+}
+
+// build arma el mensaje RFC 5322; el asunto se codifica si tiene caracteres no ASCII
+func (s *SMTPSender) build(msg Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + s.from + "\r\n")
+	b.WriteString("To: " + msg.To + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("UTF-8", msg.Subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Registry agrupa todas las métricas del servicio. Se usa un registry propio
+// en lugar del global de Prometheus para exponer solo lo que registramos aquí.
+var Registry = prometheus.NewRegistry()
+
+var (
+	// RateLimitDecisions cuenta las decisiones de rate limiting por política y resultado
+	// (allowed, limited, exempt o error).
+	RateLimitDecisions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ratelimit_decisions_total",
+		Help: "Rate limit decisions by policy and result.",
+	}, []string{"policy", "result"})
+
+	// RateLimitPolicy expone las políticas cargadas (valor siempre 1)
+	RateLimitPolicy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "ratelimit_policy_info",
+		Help: "Configured rate limit policies.",
+	}, []string{"policy", "key", "routes", "methods", "rate", "period", "burst"})
+
+	// HealthCheckUp indica el último resultado de cada checker de /readyz (1 ok, 0 caído)
+	HealthCheckUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "health_check_up",
+		Help: "Result of the last readiness check per component.",
+	}, []string{"component"})
+
+	// JobRuns cuenta las ejecuciones de jobs de mantenimiento por resultado (success o error)
+	JobRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "job_runs_total",
+		Help: "Maintenance job runs by job and result.",
+	}, []string{"job", "result"})
+
+	// UsersPurged cuenta los usuarios eliminados definitivamente por el job de purga
+	UsersPurged = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "users_purged_total",
+		Help: "Soft-deleted users permanently purged after the grace period.",
+	})
+
+	// UsersReconciled cuenta las diferencias entre Firebase y la base de datos
+	// encontradas por el job de reconciliación, por tipo y resultado (fixed,
+	// reported o error)
+	UsersReconciled = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "users_reconciled_total",
+		Help: "Differences between Firebase Auth and local users by kind and result.",
+	}, []string{"kind", "result"})
+
+	// BlockingDecisions cuenta las respuestas de las blocking functions por
+	// evento (beforeCreate o beforeSignIn) y resultado (allowed, el motivo del
+	// rechazo, invalid_token, invalid_request o error)
+	BlockingDecisions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_blocking_decisions_total",
+		Help: "Identity Platform blocking function decisions by event and result.",
+	}, []string{"event", "result"})
+
+	// AuthEvents cuenta los eventos de Firebase Auth procesados, por tipo y
+	// resultado (created, existing, deleted, not_found, duplicate, skipped o error)
+	AuthEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "firebase_auth_events_total",
+		Help: "Firebase Auth user lifecycle events processed by type and result.",
+	}, []string{"type", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		RateLimitDecisions,
+		RateLimitPolicy,
+		HealthCheckUp,
+		JobRuns,
+		UsersPurged,
+		UsersReconciled,
+		BlockingDecisions,
+		AuthEvents,
+	)
+}
+
+// Handler devuelve el handler HTTP de /metrics en formato Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
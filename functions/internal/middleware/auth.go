@@ -4,22 +4,106 @@ import (
 	"context"
 	"net/http"
 	"strings"
+	"time"
 
+	"firebase.google.com/go/v4/auth"
+
+	"it-app_user/internal/audit"
+	"it-app_user/internal/clientip"
+	"it-app_user/internal/i18n"
 	"it-app_user/internal/logger"
+	"it-app_user/internal/models"
+	"it-app_user/internal/principal"
 	"it-app_user/pkg/firebase"
 )
 
+// LanguageLookup devuelve el idioma guardado en las configuraciones de un usuario
+type LanguageLookup func(ctx context.Context, firebaseID string) (string, bool)
+
+// PermissionLookup devuelve los permisos que otorgan los roles con esos IDs
+type PermissionLookup func(ctx context.Context, roleIDs []uint) (map[string]bool, error)
+
+// TokenVerifier verifica un ID token de Firebase y devuelve sus claims
+type TokenVerifier func(ctx context.Context, idToken string) (*auth.Token, error)
+
+// ClientLookup autentica la API key de un servicio y devuelve su principal;
+// ok es false si la key no es válida
+type ClientLookup func(ctx context.Context, key string) (p principal.Principal, ok bool, err error)
+
+// HeaderAPIKey es el header alternativo a Authorization para la API key de un servicio
+const HeaderAPIKey = "X-API-Key"
+
+// claimAdmin es el custom claim admin=true, asignado con el Admin SDK de
+// Firebase para el primer administrador; otorga todos los permisos (los roles
+// van en models.ClaimRoles)
+const claimAdmin = "admin"
+
 type AuthMiddleware struct {
-	firebaseAuth *firebase.Auth
+	verifyIDToken    TokenVerifier
+	languageLookup   LanguageLookup
+	permissionLookup PermissionLookup
+	clientLookup     ClientLookup
+	afterAuth        []func(http.Handler) http.Handler
 }
 
 func NewAuthMiddleware(firebaseAuth *firebase.Auth) *AuthMiddleware {
-	return &AuthMiddleware{
-		firebaseAuth: firebaseAuth,
+	a := &AuthMiddleware{}
+	if firebaseAuth != nil {
+		a.verifyIDToken = firebaseAuth.VerifyIDToken
 	}
+	return a
+}
+
+// SetTokenVerifier reemplaza la verificación de Firebase (p. ej. en pruebas)
+func (a *AuthMiddleware) SetTokenVerifier(verify TokenVerifier) {
+	a.verifyIDToken = verify
+}
+
+// SetLanguageLookup configura cómo obtener el idioma preferido del usuario autenticado
+func (a *AuthMiddleware) SetLanguageLookup(lookup LanguageLookup) {
+	a.languageLookup = lookup
+}
+
+// SetPermissionLookup configura cómo resolver los permisos de los roles del token
+func (a *AuthMiddleware) SetPermissionLookup(lookup PermissionLookup) {
+	a.permissionLookup = lookup
+}
+
+// SetClientLookup configura cómo autenticar las API keys de los servicios
+func (a *AuthMiddleware) SetClientLookup(lookup ClientLookup) {
+	a.clientLookup = lookup
+}
+
+// Use registra middlewares que se ejecutan después de autenticar al usuario,
+// con el Firebase UID ya disponible en el contexto
+func (a *AuthMiddleware) Use(mw ...func(http.Handler) http.Handler) {
+	a.afterAuth = append(a.afterAuth, mw...)
+}
+
+// chain envuelve el handler con los middlewares registrados en Use
+func (a *AuthMiddleware) chain(next http.Handler) http.Handler {
+	for i := len(a.afterAuth) - 1; i >= 0; i-- {
+		next = a.afterAuth[i](next)
+	}
+	return next
+}
+
+// withUserLanguage reemplaza el idioma negociado por el guardado en las configuraciones del usuario
+func (a *AuthMiddleware) withUserLanguage(ctx context.Context, w http.ResponseWriter, firebaseID string) context.Context {
+	if a.languageLookup == nil {
+		return ctx
+	}
+	lang, ok := a.languageLookup(ctx, firebaseID)
+	if !ok || !i18n.IsSupported(lang) {
+		return ctx
+	}
+	lang = i18n.Normalize(lang)
+	w.Header().Set("Content-Language", lang)
+	return i18n.WithLanguage(ctx, lang)
 }
 
 func (a *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
+	next = a.chain(next)
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		log := logger.GetLogger()
 		
@@ -27,14 +111,14 @@ func (a *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 		log.WithFields(map[string]interface{}{
 			"method":      r.Method,
 			"url":         r.URL.String(),
-			"remote_addr": r.RemoteAddr,
+			"client_ip":   clientip.FromRequest(r),
 			"user_agent":  r.Header.Get("User-Agent"),
 		}).Info("🔐 [AUTH MIDDLEWARE] Processing authentication")
 
 		// 🔍 LOG: Verificar si Firebase Auth está configurado
-		if a.firebaseAuth == nil {
+		if a.verifyIDToken == nil {
 			log.Error("❌ [AUTH MIDDLEWARE] Firebase Auth not configured")
-			http.Error(w, "Authentication service not available", http.StatusServiceUnavailable)
+			http.Error(w, i18n.T(r.Context(), "Authentication service not available"), http.StatusServiceUnavailable)
 			return
 		}
 
@@ -45,7 +129,7 @@ func (a *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 				"url":    r.URL.String(),
 				"method": r.Method,
 			}).Warn("❌ [AUTH MIDDLEWARE] Missing Authorization header")
-			http.Error(w, "Authorization header required", http.StatusUnauthorized)
+			http.Error(w, i18n.T(r.Context(), "Authorization header required"), http.StatusUnauthorized)
 			return
 		}
 
@@ -64,7 +148,7 @@ func (a *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 				"first_part":   parts[0],
 				"header_start": authHeader[:min(20, len(authHeader))],
 			}).Warn("❌ [AUTH MIDDLEWARE] Invalid Authorization header format")
-			http.Error(w, "Invalid Authorization header format", http.StatusUnauthorized)
+			http.Error(w, i18n.T(r.Context(), "Invalid Authorization header format"), http.StatusUnauthorized)
 			return
 		}
 
@@ -78,13 +162,13 @@ func (a *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 
 		// Verificar token con Firebase
 		log.Info("🔍 [AUTH MIDDLEWARE] Verifying token with Firebase")
-		decodedToken, err := a.firebaseAuth.VerifyIDToken(context.Background(), token)
+		decodedToken, err := a.verifyIDToken(r.Context(), token)
 		if err != nil {
 			log.WithError(err).WithFields(map[string]interface{}{
 				"token_length": len(token),
 				"token_start":  token[:min(20, len(token))],
 			}).Warn("❌ [AUTH MIDDLEWARE] Invalid Firebase token")
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
+			http.Error(w, i18n.T(r.Context(), "Invalid token"), http.StatusUnauthorized)
 			return
 		}
 
@@ -99,13 +183,208 @@ func (a *AuthMiddleware) RequireAuth(next http.Handler) http.Handler {
 		// Agregar información del usuario al contexto
 		ctx := context.WithValue(r.Context(), "user_id", decodedToken.UID)
 		ctx = context.WithValue(ctx, "user_email", decodedToken.Claims["email"])
+		// auth_time es el último inicio de sesión; a diferencia de iat no cambia al refrescar el token
+		ctx = context.WithValue(ctx, "auth_time", time.Unix(decodedToken.AuthTime, 0))
+		ctx = context.WithValue(ctx, "token_claims", decodedToken.Claims)
+		ctx = a.withUserLanguage(ctx, w, decodedToken.UID)
+		p := principal.FromClaims(decodedToken.UID, decodedToken.Claims)
+		ctx = principal.With(ctx, p)
 		
 		log.WithField("user_id", decodedToken.UID).Info("🚀 [AUTH MIDDLEWARE] Proceeding to next handler")
 		
+		if p.Impersonated() {
+			serveImpersonated(next, w, r.WithContext(ctx), p)
+			return
+		}
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// serveImpersonated atiende una request hecha con un token de suplantación:
+// rechaza las que están fuera de plazo o del alcance del token y audita
+// todas, con el código de respuesta
+func serveImpersonated(next http.Handler, w http.ResponseWriter, r *http.Request, p principal.Principal) {
+	log := logger.GetLogger().WithFields(map[string]interface{}{
+		"user_id":          p.UID,
+		"impersonated_by":  p.ImpersonatedBy,
+		"impersonation_id": p.ImpersonationID,
+	})
+	wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+	switch {
+	case p.Expired(time.Now()):
+		log.Warn("Impersonation token expired")
+		http.Error(wrapped, i18n.T(r.Context(), "Impersonation session expired"), http.StatusUnauthorized)
+	case !p.Allows(r.Method):
+		log.WithField("scope", p.Scope).Warn("Impersonation scope does not allow the request")
+		http.Error(wrapped, i18n.T(r.Context(), "Not allowed while impersonating a user"), http.StatusForbidden)
+	default:
+		next.ServeHTTP(wrapped, r)
+	}
+
+	audit.Record(r.Context(), audit.FromRequest(r, audit.Event{
+		Action:           audit.ActionImpersonationRequest,
+		TargetFirebaseID: p.UID,
+		Metadata: map[string]interface{}{
+			"method": r.Method,
+			"path":   r.URL.Path,
+			"status": wrapped.statusCode,
+			"scope":  p.Scope,
+		},
+	}))
+}
+
+// RequirePermission exige un usuario autenticado con un rol que otorgue el
+// permiso (p. ej. users:write). Los roles salen del claim roles del token y
+// sus permisos del PermissionLookup; el claim admin=true otorga todos.
+func (a *AuthMiddleware) RequirePermission(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return a.RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			log := logger.GetLogger().WithFields(map[string]interface{}{
+				"user_id":    r.Context().Value("user_id"),
+				"permission": permission,
+			})
+			claims, _ := r.Context().Value("token_claims").(map[string]interface{})
+
+			allowed, err := a.hasPermission(r.Context(), claims, permission)
+			if err != nil {
+				log.WithError(err).Error("Failed to resolve permissions")
+				http.Error(w, i18n.T(r.Context(), "Error checking permissions"), http.StatusInternalServerError)
+				return
+			}
+			if !allowed {
+				log.Warn("Permission denied")
+				http.Error(w, i18n.T(r.Context(), "Permission denied"), http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		}))
+	}
+}
+
+// RequireNoImpersonation exige un usuario autenticado que actúe por sí mismo.
+// Protege las acciones sensibles (contraseña, email, sesiones, borrado de la
+// cuenta) de los tokens de suplantación, también de los de escritura.
+func (a *AuthMiddleware) RequireNoImpersonation(next http.Handler) http.Handler {
+	return a.RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if impersonated(r.Context()) {
+			logger.GetLogger().WithField("user_id", r.Context().Value("user_id")).Warn("Sensitive action denied to impersonation token")
+			http.Error(w, i18n.T(r.Context(), "This action is not available while impersonating a user"), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}))
+}
+
+// RequireUserOrService acepta un usuario autenticado, como RequireAuth, o un
+// servicio con una API key (en X-API-Key o como Bearer) cuyo cliente tenga el
+// scope. Para los servicios el contexto lleva su principal.Principal pero no
+// user_id: no actúan en nombre de ningún usuario.
+func (a *AuthMiddleware) RequireUserOrService(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return a.serviceOr(scope, a.RequireAuth(next), next)
+	}
+}
+
+// RequirePermissionOrService acepta un usuario con el permiso, como
+// RequirePermission, o un servicio cuyo cliente lo tenga como scope
+func (a *AuthMiddleware) RequirePermissionOrService(permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return a.serviceOr(permission, a.RequirePermission(permission)(next), next)
+	}
+}
+
+// serviceOr autentica la API key del servicio y exige el scope; las requests
+// sin API key pasan a userAuth
+func (a *AuthMiddleware) serviceOr(scope string, userAuth, next http.Handler) http.Handler {
+	next = a.chain(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, ok := apiKeyFromRequest(r)
+		if !ok {
+			userAuth.ServeHTTP(w, r)
+			return
+		}
+
+		log := logger.GetLogger().WithField("scope", scope)
+		if a.clientLookup == nil {
+			log.Error("API client authentication not configured")
+			http.Error(w, i18n.T(r.Context(), "Authentication service not available"), http.StatusServiceUnavailable)
+			return
+		}
+		p, ok, err := a.clientLookup(r.Context(), key)
+		if err != nil {
+			log.WithError(err).Error("Failed to authenticate API key")
+			http.Error(w, i18n.T(r.Context(), "Authentication service not available"), http.StatusServiceUnavailable)
+			return
+		}
+		if !ok {
+			log.Warn("Invalid API key")
+			http.Error(w, i18n.T(r.Context(), "Invalid API key"), http.StatusUnauthorized)
+			return
+		}
+		log = log.WithField("client", p.ClientID)
+		if !p.HasClientScope(scope) {
+			log.Warn("API client scope denied")
+			http.Error(w, i18n.T(r.Context(), "Permission denied"), http.StatusForbidden)
+			return
+		}
+
+		log.Info("API client authenticated")
+		next.ServeHTTP(w, r.WithContext(principal.With(r.Context(), p)))
+	})
+}
+
+// apiKeyFromRequest obtiene la API key de X-API-Key o de un header
+// Authorization Bearer que empieza con models.APIKeyPrefix (los ID tokens
+// de Firebase son JWT y nunca empiezan así)
+func apiKeyFromRequest(r *http.Request) (string, bool) {
+	if key := r.Header.Get(HeaderAPIKey); key != "" {
+		return key, true
+	}
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if found && strings.HasPrefix(token, models.APIKeyPrefix) {
+		return token, true
+	}
+	return "", false
+}
+
+// hasPermission indica si los claims del token otorgan el permiso. Un claim
+// roles inválido no otorga nada, y un token de suplantación tampoco: el
+// administrador ve lo que ve el usuario, sin sus permisos.
+func (a *AuthMiddleware) hasPermission(ctx context.Context, claims map[string]interface{}, permission string) (bool, error) {
+	if impersonated(ctx) {
+		return false, nil
+	}
+	if isAdminClaims(claims) {
+		return true, nil
+	}
+	encoded, _ := claims[models.ClaimRoles].(string)
+	if encoded == "" || a.permissionLookup == nil {
+		return false, nil
+	}
+	roleIDs, err := models.DecodeRoleSet(encoded)
+	if err != nil {
+		return false, nil
+	}
+	granted, err := a.permissionLookup(ctx, roleIDs)
+	if err != nil {
+		return false, err
+	}
+	return granted[permission], nil
+}
+
+// impersonated indica si la request autenticada usa un token de suplantación
+func impersonated(ctx context.Context) bool {
+	p, _ := principal.FromContext(ctx)
+	return p.Impersonated()
+}
+
+// isAdminClaims indica si el token tiene el custom claim admin=true
+func isAdminClaims(claims map[string]interface{}) bool {
+	isAdmin, _ := claims[claimAdmin].(bool)
+	return isAdmin
+}
+
 // Helper function para min
 func min(a, b int) int {
 	if a < b {
@@ -116,15 +395,25 @@ func min(a, b int) int {
 
 // Middleware opcional para endpoints públicos
 func (a *AuthMiddleware) OptionalAuth(next http.Handler) http.Handler {
+	next = a.chain(next)
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
-		if authHeader != "" {
+		if authHeader != "" && a.verifyIDToken != nil {
 			parts := strings.Split(authHeader, " ")
 			if len(parts) == 2 && parts[0] == "Bearer" {
 				token := parts[1]
-				if decodedToken, err := a.firebaseAuth.VerifyIDToken(context.Background(), token); err == nil {
+				if decodedToken, err := a.verifyIDToken(r.Context(), token); err == nil {
 					ctx := context.WithValue(r.Context(), "user_id", decodedToken.UID)
 					ctx = context.WithValue(ctx, "user_email", decodedToken.Claims["email"])
+					ctx = context.WithValue(ctx, "auth_time", time.Unix(decodedToken.AuthTime, 0))
+					ctx = context.WithValue(ctx, "token_claims", decodedToken.Claims)
+					ctx = a.withUserLanguage(ctx, w, decodedToken.UID)
+					p := principal.FromClaims(decodedToken.UID, decodedToken.Claims)
+					ctx = principal.With(ctx, p)
+					if p.Impersonated() {
+						serveImpersonated(next, w, r.WithContext(ctx), p)
+						return
+					}
 					r = r.WithContext(ctx)
 				}
 			}
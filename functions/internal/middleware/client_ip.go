@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"net/http"
+
+	"it-app_user/internal/clientip"
+)
+
+// ClientIPMiddleware resuelve la IP real del cliente y la guarda en el
+// contexto. Debe ir antes que el resto de middlewares para que logs, rate
+// limiting y handlers usen la misma dirección vía clientip.FromRequest.
+func ClientIPMiddleware(resolver *clientip.Resolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := resolver.Resolve(r)
+			next.ServeHTTP(w, r.WithContext(clientip.WithClientIP(r.Context(), ip)))
+		})
+	}
+}
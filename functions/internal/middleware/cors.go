@@ -1,56 +1,221 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 )
 
-func CORSMiddleware(next http.Handler) http.Handler {
+// CORSConfig define qué orígenes pueden llamar a la API desde un navegador.
+// AllowedOrigins acepta orígenes exactos ("https://app.example.com"),
+// subdominios comodín ("https://*.example.com"), cualquier puerto
+// ("http://localhost:*") o "*" para cualquier origen (sin credenciales).
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           int // segundos que el navegador puede cachear el preflight
+}
+
+// CORSPolicy aplica una CORSConfig. Es el único lugar del servicio que escribe headers CORS.
+type CORSPolicy struct {
+	allowAll         bool
+	origins          []originPattern
+	methods          map[string]bool
+	headers          map[string]bool
+	allowMethods     string
+	allowHeaders     string
+	exposeHeaders    string
+	allowCredentials bool
+	maxAge           string
+}
+
+type originPattern struct {
+	scheme    string
+	host      string // sin el "*." en los patrones de subdominio
+	subdomain bool
+	port      string // "*" para cualquier puerto
+}
+
+// NewCORSPolicy valida la configuración y crea la política
+func NewCORSPolicy(cfg CORSConfig) (*CORSPolicy, error) {
+	p := &CORSPolicy{
+		methods:          make(map[string]bool),
+		headers:          make(map[string]bool),
+		allowCredentials: cfg.AllowCredentials,
+	}
+
+	for _, origin := range cfg.AllowedOrigins {
+		origin = strings.TrimSpace(origin)
+		if origin == "*" {
+			p.allowAll = true
+			continue
+		}
+		pattern, err := parseOriginPattern(origin)
+		if err != nil {
+			return nil, err
+		}
+		p.origins = append(p.origins, pattern)
+	}
+	if p.allowAll && p.allowCredentials {
+		return nil, fmt.Errorf("CORS: allowed origin \"*\" cannot be combined with credentials")
+	}
+
+	methods := make([]string, 0, len(cfg.AllowedMethods))
+	for _, m := range cfg.AllowedMethods {
+		m = strings.ToUpper(strings.TrimSpace(m))
+		p.methods[m] = true
+		methods = append(methods, m)
+	}
+	headers := make([]string, 0, len(cfg.AllowedHeaders))
+	for _, h := range cfg.AllowedHeaders {
+		h = http.CanonicalHeaderKey(strings.TrimSpace(h))
+		p.headers[h] = true
+		headers = append(headers, h)
+	}
+
+	p.allowMethods = strings.Join(methods, ", ")
+	p.allowHeaders = strings.Join(headers, ", ")
+	p.exposeHeaders = strings.Join(cfg.ExposedHeaders, ", ")
+	if cfg.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(cfg.MaxAge)
+	}
+
+	return p, nil
+}
+
+// parseOriginPattern interpreta "scheme://host[:port]" con los comodines soportados
+func parseOriginPattern(origin string) (originPattern, error) {
+	scheme, rest, ok := strings.Cut(strings.ToLower(origin), "://")
+	if !ok || scheme == "" || rest == "" || strings.Contains(rest, "/") {
+		return originPattern{}, fmt.Errorf("CORS: invalid allowed origin %q", origin)
+	}
+
+	pattern := originPattern{scheme: scheme, host: rest}
+	if strings.HasPrefix(rest, "[") {
+		// IPv6: "[::1]:3000"
+		host, port, _ := strings.Cut(strings.TrimPrefix(rest, "["), "]")
+		pattern.host = host
+		pattern.port = strings.TrimPrefix(port, ":")
+	} else if host, port, found := strings.Cut(rest, ":"); found {
+		pattern.host = host
+		pattern.port = port
+	}
+	if strings.HasPrefix(pattern.host, "*.") {
+		pattern.subdomain = true
+		pattern.host = strings.TrimPrefix(pattern.host, "*.")
+	}
+	if pattern.host == "" || strings.Contains(pattern.host, "*") {
+		return originPattern{}, fmt.Errorf("CORS: invalid allowed origin %q", origin)
+	}
+
+	return pattern, nil
+}
+
+func (o originPattern) matches(u *url.URL) bool {
+	if u.Scheme != o.scheme {
+		return false
+	}
+	if o.port != "*" && u.Port() != o.port {
+		return false
+	}
+
+	host := u.Hostname()
+	if o.subdomain {
+		return strings.HasSuffix(host, "."+o.host)
+	}
+	return host == o.host
+}
+
+// allowedOrigin devuelve el valor de Access-Control-Allow-Origin para el origen, o "" si no está permitido
+func (p *CORSPolicy) allowedOrigin(origin string) string {
+	if p.allowAll {
+		return "*"
+	}
+
+	u, err := url.Parse(strings.ToLower(origin))
+	if err != nil || u.Host == "" || u.Path != "" {
+		return ""
+	}
+	for _, pattern := range p.origins {
+		if pattern.matches(u) {
+			return origin
+		}
+	}
+	return ""
+}
+
+// Middleware agrega los headers CORS y responde los preflight. Debe estar
+// registrado también para OPTIONS (ver SetupRoutes), porque mux no ejecuta
+// middlewares cuando ninguna ruta acepta el método.
+func (p *CORSPolicy) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		origin := r.Header.Get("Origin")
-		
-		// Log para debugging
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+		// La respuesta depende del Origin salvo con "*", así que las caches deben distinguirlo
+		if !p.allowAll {
+			w.Header().Add("Vary", "Origin")
+		}
+		if preflight {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		allowed := ""
 		if origin != "" {
-			// Para desarrollo, permitir todos los orígenes de localhost y 127.0.0.1
-			if strings.HasPrefix(origin, "http://localhost:") || 
-			   strings.HasPrefix(origin, "http://127.0.0.1:") ||
-			   strings.HasPrefix(origin, "https://localhost:") || 
-			   strings.HasPrefix(origin, "https://127.0.0.1:") {
-				w.Header().Set("Access-Control-Allow-Origin", origin)
-			} else {
-				// Para otros orígenes, usar lista específica (producción)
-				allowedOrigins := []string{
-					"https://tudominio.com",
-					"https://www.tudominio.com",
+			allowed = p.allowedOrigin(origin)
+		}
+
+		if !preflight {
+			if allowed != "" {
+				w.Header().Set("Access-Control-Allow-Origin", allowed)
+				if p.allowCredentials {
+					w.Header().Set("Access-Control-Allow-Credentials", "true")
 				}
-				
-				for _, allowed := range allowedOrigins {
-					if origin == allowed {
-						w.Header().Set("Access-Control-Allow-Origin", origin)
-						break
-					}
+				if p.exposeHeaders != "" {
+					w.Header().Set("Access-Control-Expose-Headers", p.exposeHeaders)
 				}
 			}
-		} else {
-			// Si no hay Origin header, permitir para desarrollo
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-		}
-		
-		// Headers CORS más completos
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS, PATCH, HEAD")
-		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, X-Requested-With, Origin")
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
-		w.Header().Set("Access-Control-Max-Age", "86400")
-		
-		// Headers adicionales para evitar problemas
-		w.Header().Set("Access-Control-Expose-Headers", "Content-Length, Access-Control-Allow-Origin, Access-Control-Allow-Headers, Content-Type")
-
-		// Handle preflight requests (OPTIONS)
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
+			next.ServeHTTP(w, r)
 			return
 		}
 
-		next.ServeHTTP(w, r)
+		// Preflight: sin headers CORS el navegador bloquea la request real
+		if allowed == "" || !p.preflightAllowed(r) {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", allowed)
+		w.Header().Set("Access-Control-Allow-Methods", p.allowMethods)
+		if p.allowHeaders != "" {
+			w.Header().Set("Access-Control-Allow-Headers", p.allowHeaders)
+		}
+		if p.allowCredentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+		if p.maxAge != "" {
+			w.Header().Set("Access-Control-Max-Age", p.maxAge)
+		}
+		w.WriteHeader(http.StatusNoContent)
 	})
-}
\ No newline at end of file
+}
+
+// preflightAllowed verifica el método y los headers pedidos en el preflight
+func (p *CORSPolicy) preflightAllowed(r *http.Request) bool {
+	if !p.methods[strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))] {
+		return false
+	}
+	for _, h := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+		h = strings.TrimSpace(h)
+		if h != "" && !p.headers[http.CanonicalHeaderKey(h)] {
+			return false
+		}
+	}
+	return true
+}
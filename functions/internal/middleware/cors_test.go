@@ -0,0 +1,222 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewCORSPolicyRejectsInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  CORSConfig
+	}{
+		{name: "wildcard with credentials", cfg: CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true}},
+		{name: "without scheme", cfg: CORSConfig{AllowedOrigins: []string{"app.example.com"}}},
+		{name: "with path", cfg: CORSConfig{AllowedOrigins: []string{"https://app.example.com/"}}},
+		{name: "wildcard in the middle", cfg: CORSConfig{AllowedOrigins: []string{"https://app.*.example.com"}}},
+		{name: "bare wildcard host", cfg: CORSConfig{AllowedOrigins: []string{"https://*"}}},
+	}
+	for _, tt := range tests {
+		if _, err := NewCORSPolicy(tt.cfg); err == nil {
+			t.Errorf("%s: NewCORSPolicy accepted %v", tt.name, tt.cfg.AllowedOrigins)
+		}
+	}
+}
+
+func TestCORSAllowedOrigins(t *testing.T) {
+	policy, err := NewCORSPolicy(CORSConfig{
+		AllowedOrigins: []string{"https://app.example.com", "https://*.example.org", "http://localhost:*", "http://[::1]:3000"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		origin string
+		want   string
+	}{
+		{origin: "https://app.example.com", want: "https://app.example.com"},
+		{origin: "https://APP.example.com", want: "https://APP.example.com"},
+		{origin: "http://app.example.com", want: ""},
+		{origin: "https://app.example.com:8443", want: ""},
+		{origin: "https://evil-app.example.com", want: ""},
+		{origin: "https://a.b.example.org", want: "https://a.b.example.org"},
+		{origin: "https://example.org", want: ""},
+		{origin: "https://evilexample.org", want: ""},
+		{origin: "http://localhost:5173", want: "http://localhost:5173"},
+		{origin: "http://localhost", want: "http://localhost"},
+		{origin: "http://[::1]:3000", want: "http://[::1]:3000"},
+		{origin: "http://[::1]:3001", want: ""},
+		{origin: "https://app.example.com/path", want: ""},
+		{origin: "null", want: ""},
+	}
+	for _, tt := range tests {
+		if got := policy.allowedOrigin(tt.origin); got != tt.want {
+			t.Errorf("allowedOrigin(%q) = %q, want %q", tt.origin, got, tt.want)
+		}
+	}
+}
+
+func TestCORSMiddleware(t *testing.T) {
+	policy, err := NewCORSPolicy(CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com"},
+		AllowedMethods:   []string{"get", "POST"},
+		AllowedHeaders:   []string{"authorization", "Content-Type"},
+		ExposedHeaders:   []string{"RateLimit-Remaining"},
+		AllowCredentials: true,
+		MaxAge:           600,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reached bool
+	handler := policy.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+
+	tests := []struct {
+		name      string
+		method    string
+		headers   map[string]string
+		reached   bool
+		status    int
+		want      map[string]string
+		wantVary  []string
+		forbidden []string
+	}{
+		{
+			name:    "simple request",
+			method:  http.MethodGet,
+			headers: map[string]string{"Origin": "https://app.example.com"},
+			reached: true,
+			status:  http.StatusOK,
+			want: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Expose-Headers":    "RateLimit-Remaining",
+			},
+			wantVary:  []string{"Origin"},
+			forbidden: []string{"Access-Control-Allow-Methods"},
+		},
+		{
+			name:      "simple request from another origin",
+			method:    http.MethodGet,
+			headers:   map[string]string{"Origin": "https://evil.example.com"},
+			reached:   true,
+			status:    http.StatusOK,
+			wantVary:  []string{"Origin"},
+			forbidden: []string{"Access-Control-Allow-Origin", "Access-Control-Allow-Credentials"},
+		},
+		{
+			name:   "preflight",
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  "POST",
+				"Access-Control-Request-Headers": "content-type, Authorization",
+			},
+			status: http.StatusNoContent,
+			want: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Methods":     "GET, POST",
+				"Access-Control-Allow-Headers":     "Authorization, Content-Type",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Max-Age":           "600",
+			},
+			wantVary: []string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"},
+		},
+		{
+			name:   "preflight with a method not allowed",
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://app.example.com",
+				"Access-Control-Request-Method": "DELETE",
+			},
+			status:    http.StatusNoContent,
+			forbidden: []string{"Access-Control-Allow-Origin", "Access-Control-Allow-Methods"},
+		},
+		{
+			name:   "preflight with a header not allowed",
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                         "https://app.example.com",
+				"Access-Control-Request-Method":  "POST",
+				"Access-Control-Request-Headers": "X-Debug",
+			},
+			status:    http.StatusNoContent,
+			forbidden: []string{"Access-Control-Allow-Origin"},
+		},
+		{
+			name:   "preflight from another origin",
+			method: http.MethodOptions,
+			headers: map[string]string{
+				"Origin":                        "https://evil.example.com",
+				"Access-Control-Request-Method": "GET",
+			},
+			status:    http.StatusNoContent,
+			forbidden: []string{"Access-Control-Allow-Origin"},
+		},
+		{
+			name:    "OPTIONS without preflight headers",
+			method:  http.MethodOptions,
+			headers: map[string]string{"Origin": "https://app.example.com"},
+			reached: true,
+			status:  http.StatusOK,
+			want:    map[string]string{"Access-Control-Allow-Origin": "https://app.example.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reached = false
+			r := httptest.NewRequest(tt.method, "/users", nil)
+			for header, value := range tt.headers {
+				r.Header.Set(header, value)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if reached != tt.reached {
+				t.Errorf("handler reached = %v, want %v", reached, tt.reached)
+			}
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			for header, want := range tt.want {
+				if got := w.Header().Get(header); got != want {
+					t.Errorf("%s = %q, want %q", header, got, want)
+				}
+			}
+			for _, header := range tt.forbidden {
+				if got := w.Header().Get(header); got != "" {
+					t.Errorf("%s = %q, want no header", header, got)
+				}
+			}
+			if tt.wantVary != nil {
+				if got := strings.Join(w.Header().Values("Vary"), ", "); got != strings.Join(tt.wantVary, ", ") {
+					t.Errorf("Vary = %q, want %q", got, strings.Join(tt.wantVary, ", "))
+				}
+			}
+		})
+	}
+}
+
+func TestCORSAllowAll(t *testing.T) {
+	policy, err := NewCORSPolicy(CORSConfig{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodGet, "/users", nil)
+	r.Header.Set("Origin", "https://anyone.example.com")
+	w := httptest.NewRecorder()
+	policy.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(w, r)
+
+	// Con "*" la respuesta no depende del Origin
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want *", got)
+	}
+	if got := w.Header().Get("Vary"); got != "" {
+		t.Errorf("Vary = %q, want no header", got)
+	}
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"it-app_user/internal/i18n"
+)
+
+// LanguageMiddleware negocia el idioma de la respuesta a partir del header
+// Accept-Language. Para usuarios autenticados, AuthMiddleware puede
+// reemplazarlo por el idioma guardado en sus configuraciones.
+func LanguageMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang := i18n.FromRequest(r)
+
+		w.Header().Add("Vary", "Accept-Language")
+		w.Header().Set("Content-Language", lang)
+
+		next.ServeHTTP(w, r.WithContext(i18n.WithLanguage(r.Context(), lang)))
+	})
+}
+
+// languageCacheSize limita las entradas de CachedLanguageLookup; al llenarse
+// se descartan las vencidas y, si no alcanza, todas
+const languageCacheSize = 10000
+
+type cachedLanguage struct {
+	lang      string
+	ok        bool
+	expiresAt time.Time
+}
+
+// CachedLanguageLookup reutiliza durante ttl el idioma de cada usuario para no
+// consultar sus configuraciones en cada request autenticada. Un cambio de
+// idioma tarda como mucho ttl en aplicarse en cada instancia.
+func CachedLanguageLookup(lookup LanguageLookup, ttl time.Duration) LanguageLookup {
+	var (
+		mu      sync.Mutex
+		entries = make(map[string]cachedLanguage)
+	)
+	return func(ctx context.Context, firebaseID string) (string, bool) {
+		now := time.Now()
+		mu.Lock()
+		entry, found := entries[firebaseID]
+		mu.Unlock()
+		if found && now.Before(entry.expiresAt) {
+			return entry.lang, entry.ok
+		}
+
+		lang, ok := lookup(ctx, firebaseID)
+
+		mu.Lock()
+		defer mu.Unlock()
+		if len(entries) >= languageCacheSize {
+			for id, e := range entries {
+				if !now.Before(e.expiresAt) {
+					delete(entries, id)
+				}
+			}
+			if len(entries) >= languageCacheSize {
+				entries = make(map[string]cachedLanguage)
+			}
+		}
+		entries[firebaseID] = cachedLanguage{lang: lang, ok: ok, expiresAt: now.Add(ttl)}
+		return lang, ok
+	}
+}
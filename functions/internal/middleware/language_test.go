@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"it-app_user/internal/i18n"
+)
+
+func TestLanguageMiddleware(t *testing.T) {
+	var lang string
+	handler := LanguageMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang = i18n.FromContext(r.Context())
+	}))
+
+	for header, want := range map[string]string{"": "es", "en-US,en;q=0.9": "en", "ja": "es"} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header != "" {
+			r.Header.Set("Accept-Language", header)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if lang != want {
+			t.Errorf("Accept-Language %q: context language = %q, want %q", header, lang, want)
+		}
+		if got := w.Header().Get("Content-Language"); got != want {
+			t.Errorf("Accept-Language %q: Content-Language = %q, want %q", header, got, want)
+		}
+		if got := w.Header().Get("Vary"); got != "Accept-Language" {
+			t.Errorf("Vary = %q, want Accept-Language", got)
+		}
+	}
+}
+
+func TestCachedLanguageLookup(t *testing.T) {
+	calls := map[string]int{}
+	lookup := CachedLanguageLookup(func(ctx context.Context, firebaseID string) (string, bool) {
+		calls[firebaseID]++
+		return "fr", firebaseID == "uid-ana"
+	}, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		if lang, ok := lookup(context.Background(), "uid-ana"); lang != "fr" || !ok {
+			t.Fatalf("lookup(uid-ana) = %q, %v, want fr", lang, ok)
+		}
+		if _, ok := lookup(context.Background(), "uid-bob"); ok {
+			t.Fatal("lookup(uid-bob) ok, want the miss cached")
+		}
+	}
+	if calls["uid-ana"] != 1 || calls["uid-bob"] != 1 {
+		t.Errorf("calls = %v, want one lookup per user", calls)
+	}
+
+	// Vencido el TTL se vuelve a consultar
+	expiring := CachedLanguageLookup(func(ctx context.Context, firebaseID string) (string, bool) {
+		calls["expiring"]++
+		return "de", true
+	}, 0)
+	expiring(context.Background(), "uid-ana")
+	expiring(context.Background(), "uid-ana")
+	if calls["expiring"] != 2 {
+		t.Errorf("calls after TTL = %d, want 2", calls["expiring"])
+	}
+}
@@ -1,12 +1,19 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"time"
 
+	"it-app_user/internal/clientip"
 	"it-app_user/internal/logger"
+	"it-app_user/internal/requestinfo"
 )
 
+// StatusClientClosedRequest es el código (no estándar, de nginx) con el que se
+// registran las requests que el cliente canceló antes de recibir la respuesta
+const StatusClientClosedRequest = 499
+
 type responseWriter struct {
 	http.ResponseWriter
 	statusCode int
@@ -28,13 +35,26 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 		
 		next.ServeHTTP(wrapped, r)
 		
-		logger.GetLogger().WithFields(map[string]interface{}{
+		entry := logger.GetLogger().WithFields(map[string]interface{}{
 			"method":      r.Method,
 			"path":        r.URL.Path,
 			"status_code": wrapped.statusCode,
 			"duration":    time.Since(start).Milliseconds(),
-			"remote_addr": r.RemoteAddr,
+			"client_ip":   clientip.FromRequest(r),
 			"user_agent":  r.UserAgent(),
-		}).Info("HTTP Request")
+		})
+		if info, ok := requestinfo.FromContext(r.Context()); ok {
+			entry = entry.WithField("request_id", info.ID)
+		}
+
+		switch {
+		case r.Context().Err() == context.Canceled:
+			// El cliente cerró la conexión: lo que haya respondido el handler no le llegó
+			entry.WithField("status_code", StatusClientClosedRequest).Warn("HTTP Request canceled by client")
+		case wrapped.statusCode == http.StatusServiceUnavailable:
+			entry.Warn("HTTP Request")
+		default:
+			entry.Info("HTTP Request")
+		}
 	})
 }
\ No newline at end of file
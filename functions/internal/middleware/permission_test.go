@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"it-app_user/internal/firebasetest"
+	"it-app_user/internal/models"
+)
+
+// testRolePermissions son los permisos de los roles de las pruebas
+var testRolePermissions = map[uint][]string{
+	2: {models.PermissionUsersRead},
+	3: {models.PermissionUsersRead, models.PermissionUsersWrite},
+}
+
+func testPermissionLookup(ctx context.Context, roleIDs []uint) (map[string]bool, error) {
+	granted := make(map[string]bool)
+	for _, id := range roleIDs {
+		for _, permission := range testRolePermissions[id] {
+			granted[permission] = true
+		}
+	}
+	return granted, nil
+}
+
+func TestRequirePermission(t *testing.T) {
+	firebaseAuth, _ := firebasetest.NewAuth(t, map[string]*firebasetest.Account{"uid-ana": {LocalID: "uid-ana"}})
+
+	tests := []struct {
+		name     string
+		claims   map[string]interface{}
+		lookup   PermissionLookup
+		noLookup bool
+		status   int
+	}{
+		{name: "role with the permission", claims: map[string]interface{}{"roles": models.EncodeRoleSet([]uint{3})}, status: http.StatusOK},
+		{name: "one of several roles has it", claims: map[string]interface{}{"roles": models.EncodeRoleSet([]uint{2, 3})}, status: http.StatusOK},
+		{name: "admin claim", claims: map[string]interface{}{"admin": true}, status: http.StatusOK},
+		{name: "role without the permission", claims: map[string]interface{}{"roles": models.EncodeRoleSet([]uint{2})}, status: http.StatusForbidden},
+		{name: "role that no longer exists", claims: map[string]interface{}{"roles": models.EncodeRoleSet([]uint{9})}, status: http.StatusForbidden},
+		{name: "no roles", status: http.StatusForbidden},
+		{name: "invalid roles claim", claims: map[string]interface{}{"roles": "!!"}, status: http.StatusForbidden},
+		{name: "role names instead of the role set", claims: map[string]interface{}{"roles": []string{"admin"}}, status: http.StatusForbidden},
+		{name: "without permission lookup", claims: map[string]interface{}{"roles": models.EncodeRoleSet([]uint{3})}, noLookup: true, status: http.StatusForbidden},
+		{
+			name:   "lookup failure",
+			claims: map[string]interface{}{"roles": models.EncodeRoleSet([]uint{3})},
+			lookup: func(ctx context.Context, roleIDs []uint) (map[string]bool, error) {
+				return nil, errors.New("database unavailable")
+			},
+			status: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAuthMiddleware(firebaseAuth)
+			switch {
+			case tt.lookup != nil:
+				a.SetPermissionLookup(tt.lookup)
+			case !tt.noLookup:
+				a.SetPermissionLookup(testPermissionLookup)
+			}
+
+			reached := false
+			handler := a.RequirePermission(models.PermissionUsersWrite)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				reached = true
+			}))
+			r := httptest.NewRequest(http.MethodPut, "/users/5", nil)
+			r.Header.Set("Authorization", "Bearer "+firebasetest.IDToken(t, "uid-ana", tt.claims))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if reached != (tt.status == http.StatusOK) {
+				t.Errorf("handler reached = %v with status %d", reached, w.Code)
+			}
+		})
+	}
+}
+
+func TestRequirePermissionWithoutToken(t *testing.T) {
+	firebaseAuth, _ := firebasetest.NewAuth(t, map[string]*firebasetest.Account{"uid-ana": {LocalID: "uid-ana"}})
+	a := NewAuthMiddleware(firebaseAuth)
+	a.SetPermissionLookup(testPermissionLookup)
+	handler := a.RequirePermission(models.PermissionUsersWrite)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler reached without a token")
+	}))
+
+	for _, header := range []string{"", "Bearer not-a-token"} {
+		r := httptest.NewRequest(http.MethodPut, "/users/5", nil)
+		if header != "" {
+			r.Header.Set("Authorization", header)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: status = %d, want 401", header, w.Code)
+		}
+	}
+}
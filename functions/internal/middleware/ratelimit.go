@@ -1,68 +1,239 @@
 package middleware
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
 	"net/http"
-	"sync"
+	"strconv"
+	"strings"
 	"time"
 
-	"golang.org/x/time/rate"
+	"github.com/gorilla/mux"
+
+	"it-app_user/internal/clientip"
+	"it-app_user/internal/i18n"
 	"it-app_user/internal/logger"
+	"it-app_user/internal/metrics"
 )
 
+// maxRateLimitBody es el tamaño máximo del body que se inspecciona para las claves body:<campo>
+const maxRateLimitBody = 64 << 10
+
+// RateLimiter aplica las políticas de rate limiting usando un store compartido.
+// Middleware evalúa las políticas por IP y por campo del body; UserMiddleware
+// evalúa las políticas por usuario y debe ejecutarse después de autenticar.
 type RateLimiter struct {
-	visitors map[string]*rate.Limiter
-	mu       sync.RWMutex
-	rate     rate.Limit
-	burst    int
+	store    RateLimitStore
+	policies []RateLimitPolicy
+	exempt   ExemptFunc
 }
 
-func NewRateLimiter(r rate.Limit, b int) *RateLimiter {
+func NewRateLimiter(store RateLimitStore, policies []RateLimitPolicy) *RateLimiter {
+	for _, p := range policies {
+		metrics.RateLimitPolicy.WithLabelValues(
+			p.Name,
+			p.Key,
+			strings.Join(p.Routes, ","),
+			strings.Join(p.Methods, ","),
+			strconv.Itoa(p.Limit.Rate),
+			p.Limit.Period.String(),
+			strconv.Itoa(p.Limit.burst()),
+		).Set(1)
+	}
+
 	return &RateLimiter{
-		visitors: make(map[string]*rate.Limiter),
-		rate:     r,
-		burst:    b,
+		store:    store,
+		policies: policies,
 	}
 }
 
-func (rl *RateLimiter) getLimiter(ip string) *rate.Limiter {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
+// SetExemption configura qué requests (p. ej. clientes de servicio de confianza) no tienen límite
+func (rl *RateLimiter) SetExemption(exempt ExemptFunc) {
+	rl.exempt = exempt
+}
+
+// Policies devuelve las políticas configuradas
+func (rl *RateLimiter) Policies() []RateLimitPolicy {
+	return rl.policies
+}
+
+// Close cierra el store (ver RateLimitStore)
+func (rl *RateLimiter) Close() error {
+	return rl.store.Close()
+}
+
+func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rl.serve(w, r, next, false)
+	})
+}
+
+// UserMiddleware aplica las políticas con clave uid al usuario autenticado
+func (rl *RateLimiter) UserMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rl.serve(w, r, next, true)
+	})
+}
+
+func (rl *RateLimiter) serve(w http.ResponseWriter, r *http.Request, next http.Handler, userStage bool) {
+	template := routeTemplate(r)
+
+	var matched []RateLimitPolicy
+	for _, p := range rl.policies {
+		if (p.Key == RateLimitKeyUID) == userStage && p.matches(template, r.Method) {
+			matched = append(matched, p)
+		}
+	}
+	if len(matched) == 0 {
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	if rl.exempt != nil && rl.exempt(r) {
+		for _, p := range matched {
+			metrics.RateLimitDecisions.WithLabelValues(p.Name, "exempt").Inc()
+		}
+		next.ServeHTTP(w, r)
+		return
+	}
+
+	var body map[string]interface{}
+	var tightest, denied *RateLimitResult
+	var deniedBy string
+
+	for _, p := range matched {
+		if strings.HasPrefix(p.Key, RateLimitKeyBodyField) && body == nil {
+			body = readJSONBody(r)
+		}
+
+		key := rl.keyFor(p, r, body)
+		if key == "" {
+			continue
+		}
+
+		result, err := rl.store.Allow(r.Context(), "policy:"+p.Name+":"+key, p.Limit)
+		if err != nil {
+			// Si el store no está disponible se deja pasar la request
+			metrics.RateLimitDecisions.WithLabelValues(p.Name, "error").Inc()
+			logger.GetLogger().WithError(err).WithField("policy", p.Name).Error("Rate limit store unavailable")
+			continue
+		}
+
+		// La request ya se rechaza: las políticas siguientes no consumen cupo
+		if !result.Allowed {
+			metrics.RateLimitDecisions.WithLabelValues(p.Name, "limited").Inc()
+			denied = &result
+			deniedBy = p.Name
+			break
+		}
+
+		metrics.RateLimitDecisions.WithLabelValues(p.Name, "allowed").Inc()
+		if tightest == nil || result.Remaining < tightest.Remaining {
+			res := result
+			tightest = &res
+		}
+	}
 
-	limiter, exists := rl.visitors[ip]
-	if !exists {
-		limiter = rate.NewLimiter(rl.rate, rl.burst)
-		rl.visitors[ip] = limiter
+	if denied != nil {
+		setRateLimitHeaders(w, *denied)
+		logger.GetLogger().WithFields(map[string]interface{}{
+			"policy":    deniedBy,
+			"route":     template,
+			"client_ip": clientip.FromRequest(r),
+		}).Warn("Rate limit exceeded")
+		w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(denied.RetryAfter)))
+		http.Error(w, i18n.T(r.Context(), "Rate limit exceeded"), http.StatusTooManyRequests)
+		return
 	}
 
-	return limiter
+	if tightest != nil {
+		setRateLimitHeaders(w, *tightest)
+	}
+	next.ServeHTTP(w, r)
 }
 
-func (rl *RateLimiter) cleanupVisitors() {
-	for {
-		time.Sleep(time.Minute)
-		rl.mu.Lock()
-		for ip, limiter := range rl.visitors {
-			if limiter.Allow() {
-				delete(rl.visitors, ip)
-			}
+// keyFor devuelve la clave de la request para la política, o "" si no se puede determinar
+func (rl *RateLimiter) keyFor(p RateLimitPolicy, r *http.Request, body map[string]interface{}) string {
+	switch {
+	case p.Key == RateLimitKeyIP:
+		return "ip:" + clientip.FromRequest(r)
+	case p.Key == RateLimitKeyUID:
+		uid, ok := r.Context().Value("user_id").(string)
+		if !ok || uid == "" {
+			return ""
+		}
+		return "uid:" + uid
+	case strings.HasPrefix(p.Key, RateLimitKeyBodyField):
+		field := strings.TrimPrefix(p.Key, RateLimitKeyBodyField)
+		value, ok := body[field]
+		if !ok {
+			return ""
+		}
+		normalized := strings.ToLower(strings.TrimSpace(fmt.Sprint(value)))
+		if normalized == "" {
+			return ""
 		}
-		rl.mu.Unlock()
+		// Se guarda un hash para no dejar emails u otros datos personales en el store
+		sum := sha256.Sum256([]byte(normalized))
+		return field + ":" + hex.EncodeToString(sum[:])
 	}
+	return ""
 }
 
-func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
-	go rl.cleanupVisitors()
-	
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ip := r.RemoteAddr
-		limiter := rl.getLimiter(ip)
-		
-		if !limiter.Allow() {
-			logger.GetLogger().WithField("ip", ip).Warn("Rate limit exceeded")
-			http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+// routeTemplate devuelve la plantilla de la ruta de mux que atendió la request
+func routeTemplate(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return r.URL.Path
+}
+
+// readJSONBody lee el body como JSON sin consumirlo para el handler.
+// Devuelve un mapa vacío si no es un objeto JSON o supera maxRateLimitBody.
+func readJSONBody(r *http.Request) map[string]interface{} {
+	body := map[string]interface{}{}
+	if r.Body == nil {
+		return body
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxRateLimitBody+1))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), r.Body), r.Body}
+	if err != nil || len(data) > maxRateLimitBody {
+		return body
+	}
+
+	json.Unmarshal(data, &body)
+	return body
+}
+
+// setRateLimitHeaders agrega los headers RateLimit-* (draft IETF httpapi-ratelimit-headers).
+// Si una etapa anterior ya informó un límite más restrictivo se conserva.
+func setRateLimitHeaders(w http.ResponseWriter, result RateLimitResult) {
+	if current := w.Header().Get("RateLimit-Remaining"); current != "" {
+		if remaining, err := strconv.Atoi(current); err == nil && remaining < result.Remaining {
 			return
 		}
-		
-		next.ServeHTTP(w, r)
-	})
-}
\ No newline at end of file
+	}
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+}
+
+// ceilSeconds redondea una duración hacia arriba, con un mínimo de 1 segundo
+func ceilSeconds(d time.Duration) int {
+	seconds := int(math.Ceil(d.Seconds()))
+	if seconds < 1 {
+		return 1
+	}
+	return seconds
+}
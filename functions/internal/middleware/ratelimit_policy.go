@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"it-app_user/internal/clientip"
+)
+
+// Tipos de clave con los que se puede agrupar una política
+const (
+	RateLimitKeyIP        = "ip"    // dirección IP del cliente
+	RateLimitKeyUID       = "uid"   // Firebase UID del usuario autenticado
+	RateLimitKeyBodyField = "body:" // campo del body JSON, p. ej. "body:email"
+)
+
+// RateLimitPolicy aplica un límite a un conjunto de rutas, agrupando las
+// requests por IP, por usuario autenticado o por un campo del body.
+type RateLimitPolicy struct {
+	Name    string
+	Routes  []string // plantillas de ruta de mux; "*" para todas y "/prefijo/*" para un prefijo
+	Methods []string // vacío para todos los métodos
+	Key     string
+	Limit   RateLimit
+}
+
+// DefaultRateLimitPolicies son las políticas usadas cuando no se configura ninguna:
+// un límite global por IP y límites estrictos para reset de contraseña, verificación de
+// email y exportación de datos.
+func DefaultRateLimitPolicies(rps, burst int) []RateLimitPolicy {
+	passwordReset := []string{
+		"/password/reset/request",
+		"/password/reset/verify",
+		"/password/reset/confirm",
+		"/password/reset/validate-token",
+	}
+	emailVerification := []string{
+		"/email/send-verification",
+		"/email/verify",
+		"/email/verify-code",
+		"/email/resend",
+	}
+
+	return []RateLimitPolicy{
+		{
+			Name:   "global",
+			Routes: []string{"*"},
+			Key:    RateLimitKeyIP,
+			Limit:  RateLimit{Rate: rps, Period: time.Second, Burst: burst},
+		},
+		{
+			Name:   "user",
+			Routes: []string{"*"},
+			Key:    RateLimitKeyUID,
+			Limit:  RateLimit{Rate: 600, Period: time.Minute, Burst: 100},
+		},
+		{
+			Name:    "password-reset-ip",
+			Routes:  passwordReset,
+			Methods: []string{http.MethodPost},
+			Key:     RateLimitKeyIP,
+			Limit:   RateLimit{Rate: 20, Period: time.Hour, Burst: 10},
+		},
+		{
+			Name:    "password-reset-email",
+			Routes:  []string{"/password/reset/request"},
+			Methods: []string{http.MethodPost},
+			Key:     RateLimitKeyBodyField + "email",
+			Limit:   RateLimit{Rate: 5, Period: time.Hour, Burst: 3},
+		},
+		{
+			Name:    "email-verification-ip",
+			Routes:  emailVerification,
+			Methods: []string{http.MethodPost},
+			Key:     RateLimitKeyIP,
+			Limit:   RateLimit{Rate: 20, Period: time.Hour, Burst: 10},
+		},
+		{
+			Name:    "email-verification-email",
+			Routes:  []string{"/email/send-verification", "/email/resend", "/email/verify-code"},
+			Methods: []string{http.MethodPost},
+			Key:     RateLimitKeyBodyField + "email",
+			Limit:   RateLimit{Rate: 5, Period: time.Hour, Burst: 3},
+		},
+		{
+			Name:    "data-export",
+			Routes:  []string{"/me/export"},
+			Methods: []string{http.MethodPost},
+			Key:     RateLimitKeyUID,
+			Limit:   RateLimit{Rate: 5, Period: 24 * time.Hour, Burst: 3},
+		},
+		{
+			Name:    "account-deletion",
+			Routes:  []string{"/me", "/me/deletion/cancel"},
+			Methods: []string{http.MethodDelete, http.MethodPost},
+			Key:     RateLimitKeyUID,
+			Limit:   RateLimit{Rate: 10, Period: time.Hour, Burst: 5},
+		},
+	}
+}
+
+// matches indica si la política aplica a la plantilla de ruta y método dados
+func (p RateLimitPolicy) matches(template, method string) bool {
+	return routeMatches(p.Routes, p.Methods, template, method)
+}
+
+// routeMatches compara una plantilla de ruta y un método con una lista de
+// rutas ("*", "/prefijo/*" o plantillas exactas) y métodos (vacío para todos)
+func routeMatches(routes, methods []string, template, method string) bool {
+	if len(methods) > 0 {
+		found := false
+		for _, m := range methods {
+			if m == method {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	for _, route := range routes {
+		switch {
+		case route == "*":
+			return true
+		case strings.HasSuffix(route, "/*"):
+			if strings.HasPrefix(template, strings.TrimSuffix(route, "*")) {
+				return true
+			}
+		case route == template:
+			return true
+		}
+	}
+	return false
+}
+
+// ExemptFunc decide si una request queda fuera del rate limiting
+type ExemptFunc func(r *http.Request) bool
+
+// NewCIDRExemption exime a los clientes de servicio cuya IP pertenezca a alguno de los rangos dados
+func NewCIDRExemption(cidrs []string) (ExemptFunc, error) {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid exempt CIDR %q: %w", cidr, err)
+		}
+		networks = append(networks, network)
+	}
+
+	return func(r *http.Request) bool {
+		ip := net.ParseIP(clientip.FromRequest(r))
+		if ip == nil {
+			return false
+		}
+		for _, network := range networks {
+			if network.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
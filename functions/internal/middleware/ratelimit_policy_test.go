@@ -0,0 +1,269 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"it-app_user/internal/metrics"
+)
+
+// newPolicyTestRouter monta el rate limiter sobre un router de mux para que
+// las políticas vean las plantillas de ruta reales. El handler devuelve el body
+// recibido, para comprobar que el rate limiter no lo consume.
+func newPolicyTestRouter(rl *RateLimiter) *mux.Router {
+	router := mux.NewRouter()
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(w, r.Body)
+	})
+	router.Handle("/password/reset/request", echo).Methods(http.MethodGet, http.MethodPost)
+	router.Handle("/users/{id}", echo).Methods(http.MethodGet)
+	router.Handle("/health", echo).Methods(http.MethodGet)
+	router.Use(rl.Middleware)
+	return router
+}
+
+func policyTestRequest(method, path, body string) *http.Request {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	r.RemoteAddr = "203.0.113.7:41000"
+	return r
+}
+
+// oneRequestPolicy permite una única request por hora
+func oneRequestPolicy(name, key string, routes, methods []string) RateLimitPolicy {
+	return RateLimitPolicy{
+		Name:    name,
+		Routes:  routes,
+		Methods: methods,
+		Key:     key,
+		Limit:   RateLimit{Rate: 1, Period: time.Hour, Burst: 1},
+	}
+}
+
+func TestRateLimitPolicyRoutes(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RateLimitPolicy
+		method  string
+		path    string
+		limited bool
+	}{
+		{name: "all routes", policy: oneRequestPolicy("all", RateLimitKeyIP, []string{"*"}, nil), method: http.MethodGet, path: "/health", limited: true},
+		{name: "prefix", policy: oneRequestPolicy("prefix", RateLimitKeyIP, []string{"/password/*"}, nil), method: http.MethodGet, path: "/password/reset/request", limited: true},
+		{name: "other prefix", policy: oneRequestPolicy("prefix", RateLimitKeyIP, []string{"/password/*"}, nil), method: http.MethodGet, path: "/health"},
+		{name: "route template", policy: oneRequestPolicy("template", RateLimitKeyIP, []string{"/users/{id}"}, nil), method: http.MethodGet, path: "/users/5", limited: true},
+		{name: "concrete path is not a template", policy: oneRequestPolicy("template", RateLimitKeyIP, []string{"/users/5"}, nil), method: http.MethodGet, path: "/users/5"},
+		{name: "method", policy: oneRequestPolicy("method", RateLimitKeyIP, []string{"*"}, []string{http.MethodPost}), method: http.MethodPost, path: "/password/reset/request", limited: true},
+		{name: "other method", policy: oneRequestPolicy("method", RateLimitKeyIP, []string{"*"}, []string{http.MethodPost}), method: http.MethodGet, path: "/password/reset/request"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl := NewRateLimiter(newTestMemoryStore(newTestClock()), []RateLimitPolicy{tt.policy})
+			router := newPolicyTestRouter(rl)
+
+			var status int
+			for i := 0; i < 2; i++ {
+				w := httptest.NewRecorder()
+				router.ServeHTTP(w, policyTestRequest(tt.method, tt.path, ""))
+				status = w.Code
+			}
+			if limited := status == http.StatusTooManyRequests; limited != tt.limited {
+				t.Errorf("second request status = %d, limited = %v, want %v", status, limited, tt.limited)
+			}
+		})
+	}
+}
+
+func TestRateLimitBodyFieldKey(t *testing.T) {
+	rl := NewRateLimiter(newTestMemoryStore(newTestClock()), []RateLimitPolicy{
+		oneRequestPolicy("reset-email", RateLimitKeyBodyField+"email", []string{"/password/reset/request"}, nil),
+	})
+	router := newPolicyTestRouter(rl)
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+	}{
+		{name: "first request", body: `{"email":"ana@example.com"}`, status: http.StatusOK},
+		{name: "same email with other case and spaces", body: `{"email":" ANA@example.com "}`, status: http.StatusTooManyRequests},
+		{name: "other email", body: `{"email":"bob@example.com"}`, status: http.StatusOK},
+		{name: "without the field", body: `{"username":"ana"}`, status: http.StatusOK},
+		{name: "not JSON", body: `email=ana@example.com`, status: http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, policyTestRequest(http.MethodPost, "/password/reset/request", tt.body))
+
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.status)
+		}
+		if tt.status == http.StatusOK && w.Body.String() != tt.body {
+			t.Errorf("%s: handler read body %q, want %q", tt.name, w.Body, tt.body)
+		}
+	}
+
+	// La clave guarda un hash del campo, no el email
+	key := rl.keyFor(rl.Policies()[0], nil, map[string]interface{}{"email": "ana@example.com"})
+	if !strings.HasPrefix(key, "email:") || strings.Contains(key, "ana") {
+		t.Errorf("key = %q, want a hashed email key", key)
+	}
+}
+
+func TestRateLimitUserPolicies(t *testing.T) {
+	rl := NewRateLimiter(newTestMemoryStore(newTestClock()), []RateLimitPolicy{
+		oneRequestPolicy("user", RateLimitKeyUID, []string{"*"}, nil),
+	})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	serve := func(handler http.Handler, uid string) int {
+		r := policyTestRequest(http.MethodGet, "/users/me", "")
+		if uid != "" {
+			r = r.WithContext(context.WithValue(r.Context(), "user_id", uid))
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	// Las políticas por usuario no se evalúan antes de autenticar
+	for i := 0; i < 2; i++ {
+		if status := serve(rl.Middleware(ok), "uid-ana"); status != http.StatusOK {
+			t.Fatalf("Middleware applied a uid policy: status %d", status)
+		}
+	}
+
+	user := rl.UserMiddleware(ok)
+	for _, step := range []struct {
+		uid    string
+		status int
+	}{
+		{uid: "uid-ana", status: http.StatusOK},
+		{uid: "uid-ana", status: http.StatusTooManyRequests},
+		{uid: "uid-bob", status: http.StatusOK},
+		{uid: "", status: http.StatusOK},
+		{uid: "", status: http.StatusOK},
+	} {
+		if status := serve(user, step.uid); status != step.status {
+			t.Errorf("UserMiddleware for %q: status = %d, want %d", step.uid, status, step.status)
+		}
+	}
+}
+
+func TestRateLimitTightestHeaders(t *testing.T) {
+	rl := NewRateLimiter(newTestMemoryStore(newTestClock()), []RateLimitPolicy{
+		{Name: "loose", Routes: []string{"*"}, Key: RateLimitKeyIP, Limit: RateLimit{Rate: 10, Period: time.Second, Burst: 10}},
+		{Name: "strict", Routes: []string{"/password/*"}, Key: RateLimitKeyIP, Limit: RateLimit{Rate: 1, Period: time.Minute, Burst: 2}},
+	})
+	router := newPolicyTestRouter(rl)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, policyTestRequest(http.MethodGet, "/password/reset/request", ""))
+	if limit, remaining := w.Header().Get("RateLimit-Limit"), w.Header().Get("RateLimit-Remaining"); limit != "2" || remaining != "1" {
+		t.Errorf("RateLimit-Limit = %s, RateLimit-Remaining = %s, want the strict policy 2 and 1", limit, remaining)
+	}
+}
+
+func TestRateLimitDeniedRequestDoesNotConsumeOtherPolicies(t *testing.T) {
+	rl := NewRateLimiter(newTestMemoryStore(newTestClock()), []RateLimitPolicy{
+		oneRequestPolicy("strict", RateLimitKeyIP, []string{"/password/*"}, nil),
+		{Name: "loose", Routes: []string{"*"}, Key: RateLimitKeyIP, Limit: RateLimit{Rate: 1, Period: time.Hour, Burst: 2}},
+	})
+	router := newPolicyTestRouter(rl)
+
+	// La segunda request la rechaza strict; si consumiera cupo de loose, la
+	// tercera también sería rechazada
+	for i, step := range []struct {
+		path   string
+		status int
+	}{
+		{path: "/password/reset/request", status: http.StatusOK},
+		{path: "/password/reset/request", status: http.StatusTooManyRequests},
+		{path: "/health", status: http.StatusOK},
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, policyTestRequest(http.MethodGet, step.path, ""))
+		if w.Code != step.status {
+			t.Errorf("request %d to %s: status = %d, want %d", i+1, step.path, w.Code, step.status)
+		}
+	}
+}
+
+func TestRateLimitExemption(t *testing.T) {
+	if _, err := NewCIDRExemption([]string{"10.0.0.0/33"}); err == nil {
+		t.Fatal("NewCIDRExemption accepted an invalid CIDR")
+	}
+	exempt, err := NewCIDRExemption([]string{" 203.0.113.0/24 ", "2001:db8::/32"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rl := NewRateLimiter(newTestMemoryStore(newTestClock()), []RateLimitPolicy{
+		oneRequestPolicy("exemption-test", RateLimitKeyIP, []string{"*"}, nil),
+	})
+	rl.SetExemption(exempt)
+	router := newPolicyTestRouter(rl)
+
+	for addr, limited := range map[string]bool{
+		"203.0.113.7:41000":   false,
+		"[2001:db8::1]:41000": false,
+		"198.51.100.1:41000":  true,
+	} {
+		var status int
+		for i := 0; i < 2; i++ {
+			r := policyTestRequest(http.MethodGet, "/health", "")
+			r.RemoteAddr = addr
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			status = w.Code
+		}
+		if (status == http.StatusTooManyRequests) != limited {
+			t.Errorf("%s: second request status = %d, want limited = %v", addr, status, limited)
+		}
+	}
+}
+
+// failingRateLimitStore simula un store caído
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Allow(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	return RateLimitResult{}, errors.New("store unavailable")
+}
+
+func (failingRateLimitStore) Close() error {
+	return nil
+}
+
+func TestRateLimitStoreUnavailable(t *testing.T) {
+	rl := NewRateLimiter(failingRateLimitStore{}, []RateLimitPolicy{
+		oneRequestPolicy("store-down", RateLimitKeyIP, []string{"*"}, nil),
+	})
+	router := newPolicyTestRouter(rl)
+
+	// Sin store se deja pasar la request y se cuenta el error en /metrics
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, policyTestRequest(http.MethodGet, "/health", ""))
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: status = %d, want 200", i+1, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, want := range []string{
+		`ratelimit_decisions_total{policy="store-down",result="error"} 3`,
+		`ratelimit_policy_info{burst="1",key="ip",methods="",period="1h0m0s",policy="store-down",rate="1",routes="*"} 1`,
+	} {
+		if !strings.Contains(w.Body.String(), want) {
+			t.Errorf("/metrics does not contain %s", want)
+		}
+	}
+}
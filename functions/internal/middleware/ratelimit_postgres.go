@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"it-app_user/internal/logger"
+	"it-app_user/internal/models"
+)
+
+// PostgresRateLimitStore comparte los límites entre instancias usando la tabla rate_limit_buckets
+type PostgresRateLimitStore struct {
+	db *gorm.DB
+	// now lee el instante actual dentro de la transacción (ver databaseNow)
+	now    func(tx *gorm.DB) (time.Time, error)
+	cancel context.CancelFunc
+}
+
+// NewPostgresRateLimitStore crea un store respaldado por PostgreSQL; Close
+// detiene su limpieza
+func NewPostgresRateLimitStore(db *gorm.DB) *PostgresRateLimitStore {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &PostgresRateLimitStore{db: db, now: databaseNow, cancel: cancel}
+	go s.cleanup(ctx)
+	return s
+}
+
+// databaseNow devuelve el reloj de Postgres, el mismo para todas las instancias
+func databaseNow(tx *gorm.DB) (time.Time, error) {
+	var now time.Time
+	err := tx.Raw("SELECT now()").Row().Scan(&now)
+	return now, err
+}
+
+// Allow consume una request del límite para la clave dada.
+// La fila se bloquea con SELECT ... FOR UPDATE para que instancias
+// concurrentes no consuman el mismo hueco del bucket.
+func (s *PostgresRateLimitStore) Allow(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	var result RateLimitResult
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Asegurar que la fila exista antes de bloquearla
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.RateLimitBucket{BucketKey: key, TAT: time.Unix(0, 0)}).Error; err != nil {
+			return err
+		}
+
+		var bucket models.RateLimitBucket
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("bucket_key = ?", key).
+			First(&bucket).Error; err != nil {
+			return err
+		}
+
+		now, err := s.now(tx)
+		if err != nil {
+			return err
+		}
+		var newTat time.Time
+		newTat, result = gcra(now, bucket.TAT, limit)
+		if !result.Allowed {
+			return nil
+		}
+
+		return tx.Model(&models.RateLimitBucket{}).
+			Where("bucket_key = ?", key).
+			Update("tat", newTat).Error
+	})
+
+	return result, err
+}
+
+// DeleteExpired elimina los buckets que ya se vaciaron por completo
+func (s *PostgresRateLimitStore) DeleteExpired(ctx context.Context) error {
+	return s.db.WithContext(ctx).Where("tat < now()").Delete(&models.RateLimitBucket{}).Error
+}
+
+// Close detiene la limpieza de buckets vencidos; la conexión es de models
+func (s *PostgresRateLimitStore) Close() error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	return nil
+}
+
+func (s *PostgresRateLimitStore) cleanup(ctx context.Context) {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.DeleteExpired(ctx); err != nil && ctx.Err() == nil {
+				logger.GetLogger().WithError(err).Warn("Failed to clean expired rate limit buckets")
+			}
+		}
+	}
+}
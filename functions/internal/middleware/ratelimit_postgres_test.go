@@ -0,0 +1,38 @@
+//go:build integration
+
+package middleware
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+
+	"it-app_user/internal/models"
+)
+
+// TestPostgresRateLimitStore necesita una base de datos de pruebas:
+//
+//	TEST_DATABASE_URL=postgres://... go test -tags integration ./internal/middleware/
+func TestPostgresRateLimitStore(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.RateLimitBucket{}); err != nil {
+		t.Fatal(err)
+	}
+
+	testRateLimitStore(t, func(t *testing.T, clock *testClock) RateLimitStore {
+		if err := db.Where("1 = 1").Delete(&models.RateLimitBucket{}).Error; err != nil {
+			t.Fatal(err)
+		}
+		return &PostgresRateLimitStore{db: db, now: func(*gorm.DB) (time.Time, error) { return clock.Now(), nil }}
+	})
+}
@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// gcraScript implementa GCRA de forma atómica en Redis.
+// KEYS[1] = clave; ARGV = intervalo de emisión (en microsegundos) y burst.
+// El instante actual sale de TIME, el reloj de Redis, para que todas las
+// instancias usen el mismo; replicate_commands lo permite antes de escribir
+// en Redis anteriores a 7.
+var gcraScript = redis.NewScript(`
+redis.replicate_commands()
+local time = redis.call("TIME")
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+local interval = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+
+local tat = tonumber(redis.call("GET", KEYS[1]) or now)
+if tat < now then
+	tat = now
+end
+
+local new_tat = tat + interval
+local allow_at = new_tat - interval * burst
+
+if now < allow_at then
+	return {0, 0, tat - now, allow_at - now}
+end
+
+local ttl = math.max(1, math.ceil((new_tat - now) / 1000))
+redis.call("SET", KEYS[1], new_tat, "PX", ttl)
+return {1, math.floor((now - allow_at) / interval), new_tat - now, 0}
+`)
+
+// RedisRateLimitStore comparte los límites entre instancias usando Redis.
+// Acepta cualquier redis.Scripter, por lo que puede apuntarse a un Redis
+// local o a un sustituto en memoria como miniredis.
+type RedisRateLimitStore struct {
+	client redis.Scripter
+	prefix string
+}
+
+// NewRedisRateLimitStore crea un store respaldado por Redis; Close cierra el cliente
+func NewRedisRateLimitStore(client redis.Scripter) *RedisRateLimitStore {
+	return &RedisRateLimitStore{
+		client: client,
+		prefix: "ratelimit:",
+	}
+}
+
+// Allow consume una request del límite para la clave dada
+func (s *RedisRateLimitStore) Allow(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	interval := limit.emissionInterval()
+	burst := limit.burst()
+	if interval == 0 {
+		return RateLimitResult{Allowed: true, Limit: burst, Remaining: burst}, nil
+	}
+
+	values, err := gcraScript.Run(ctx, s.client, []string{s.prefix + key},
+		interval.Microseconds(),
+		burst,
+	).Int64Slice()
+	if err != nil {
+		return RateLimitResult{}, fmt.Errorf("failed to run rate limit script: %w", err)
+	}
+	if len(values) != 4 {
+		return RateLimitResult{}, fmt.Errorf("unexpected rate limit script result: %v", values)
+	}
+
+	return RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      burst,
+		Remaining:  int(values[1]),
+		ResetAfter: time.Duration(values[2]) * time.Microsecond,
+		RetryAfter: time.Duration(values[3]) * time.Microsecond,
+	}, nil
+}
+
+// Close cierra el cliente de Redis si puede cerrarse
+func (s *RedisRateLimitStore) Close() error {
+	if closer, ok := s.client.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimit describe un límite: Rate requests por Period, con ráfagas de hasta Burst
+type RateLimit struct {
+	Rate   int
+	Period time.Duration
+	Burst  int
+}
+
+// RateLimitResult es el resultado de consumir una request de un límite
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration // tiempo hasta que el bucket vuelve a estar lleno
+	RetryAfter time.Duration // tiempo hasta que se permita la siguiente request (0 si Allowed)
+}
+
+// RateLimitStore guarda el estado de los límites. Las implementaciones
+// compartidas (Postgres, Redis) permiten aplicar el mismo límite en todas
+// las instancias del servicio y toman el instante actual de su servidor, así
+// que la diferencia entre los relojes de las instancias no afecta al límite.
+// Close detiene la limpieza en segundo plano y libera las conexiones propias.
+type RateLimitStore interface {
+	Allow(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error)
+	Close() error
+}
+
+// emissionInterval es el tiempo que tarda en liberarse una request del bucket
+func (l RateLimit) emissionInterval() time.Duration {
+	if l.Rate <= 0 || l.Period <= 0 {
+		return 0
+	}
+	return l.Period / time.Duration(l.Rate)
+}
+
+func (l RateLimit) burst() int {
+	if l.Burst < 1 {
+		return 1
+	}
+	return l.Burst
+}
+
+// gcra aplica el Generic Cell Rate Algorithm. Recibe el "theoretical arrival
+// time" (TAT) guardado para la clave y devuelve el nuevo TAT a guardar si la
+// request está permitida.
+func gcra(now, tat time.Time, limit RateLimit) (time.Time, RateLimitResult) {
+	interval := limit.emissionInterval()
+	burst := limit.burst()
+	if interval == 0 {
+		return tat, RateLimitResult{Allowed: true, Limit: burst, Remaining: burst}
+	}
+
+	if tat.Before(now) {
+		tat = now
+	}
+	newTat := tat.Add(interval)
+	tolerance := interval * time.Duration(burst)
+	allowAt := newTat.Add(-tolerance)
+
+	if now.Before(allowAt) {
+		return tat, RateLimitResult{
+			Allowed:    false,
+			Limit:      burst,
+			Remaining:  0,
+			ResetAfter: tat.Sub(now),
+			RetryAfter: allowAt.Sub(now),
+		}
+	}
+
+	return newTat, RateLimitResult{
+		Allowed:    true,
+		Limit:      burst,
+		Remaining:  int(now.Sub(allowAt) / interval),
+		ResetAfter: newTat.Sub(now),
+	}
+}
+
+// MemoryRateLimitStore guarda los límites en memoria del proceso.
+// Solo es adecuado para desarrollo o para una única instancia.
+type MemoryRateLimitStore struct {
+	mu     sync.Mutex
+	tats   map[string]time.Time
+	now    func() time.Time
+	cancel context.CancelFunc
+}
+
+// NewMemoryRateLimitStore crea un store en memoria; Close detiene su limpieza
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &MemoryRateLimitStore{
+		tats:   make(map[string]time.Time),
+		now:    time.Now,
+		cancel: cancel,
+	}
+	go s.cleanup(ctx)
+	return s
+}
+
+// Allow consume una request del límite para la clave dada
+func (s *MemoryRateLimitStore) Allow(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	newTat, result := gcra(s.now(), s.tats[key], limit)
+	if result.Allowed {
+		s.tats[key] = newTat
+	}
+	return result, nil
+}
+
+// DeleteExpired elimina las claves cuyo bucket ya se vació por completo
+func (s *MemoryRateLimitStore) DeleteExpired() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for key, tat := range s.tats {
+		if tat.Before(now) {
+			delete(s.tats, key)
+		}
+	}
+}
+
+// Close detiene la limpieza de claves vencidas
+func (s *MemoryRateLimitStore) Close() error {
+	if s.cancel != nil {
+		s.cancel()
+	}
+	return nil
+}
+
+func (s *MemoryRateLimitStore) cleanup(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.DeleteExpired()
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// testClock es un reloj manual para los stores; onAdvance permite mover
+// también el reloj de un sustituto como miniredis
+type testClock struct {
+	now       time.Time
+	onAdvance []func(time.Duration)
+}
+
+func newTestClock() *testClock {
+	return &testClock{now: time.Unix(1700000000, 0)}
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+	for _, f := range c.onAdvance {
+		f(d)
+	}
+}
+
+// testRateLimitStore aplica la misma tabla de casos a cualquier RateLimitStore
+func testRateLimitStore(t *testing.T, newStore func(t *testing.T, clock *testClock) RateLimitStore) {
+	ctx := context.Background()
+	// Una request por segundo con ráfagas de 3
+	limit := RateLimit{Rate: 1, Period: time.Second, Burst: 3}
+
+	allow := func(t *testing.T, store RateLimitStore, key string) RateLimitResult {
+		t.Helper()
+		result, err := store.Allow(ctx, key, limit)
+		if err != nil {
+			t.Fatalf("Allow(%q): %v", key, err)
+		}
+		return result
+	}
+
+	t.Run("burst allowance", func(t *testing.T) {
+		store := newStore(t, newTestClock())
+		for i, want := range []RateLimitResult{
+			{Allowed: true, Limit: 3, Remaining: 2, ResetAfter: time.Second},
+			{Allowed: true, Limit: 3, Remaining: 1, ResetAfter: 2 * time.Second},
+			{Allowed: true, Limit: 3, Remaining: 0, ResetAfter: 3 * time.Second},
+			{Allowed: false, Limit: 3, Remaining: 0, ResetAfter: 3 * time.Second, RetryAfter: time.Second},
+			{Allowed: false, Limit: 3, Remaining: 0, ResetAfter: 3 * time.Second, RetryAfter: time.Second},
+		} {
+			if got := allow(t, store, "burst"); got != want {
+				t.Errorf("request %d: got %+v, want %+v", i+1, got, want)
+			}
+		}
+	})
+
+	t.Run("refill after emission interval", func(t *testing.T) {
+		clock := newTestClock()
+		store := newStore(t, clock)
+		for i := 0; i < 3; i++ {
+			allow(t, store, "refill")
+		}
+
+		clock.Advance(999 * time.Millisecond)
+		if got := allow(t, store, "refill"); got.Allowed || got.RetryAfter != time.Millisecond {
+			t.Fatalf("before the interval: got %+v, want denied with RetryAfter 1ms", got)
+		}
+
+		clock.Advance(time.Millisecond)
+		if got := allow(t, store, "refill"); !got.Allowed || got.Remaining != 0 {
+			t.Fatalf("after the interval: got %+v, want allowed with Remaining 0", got)
+		}
+
+		// Con el bucket vacío durante ResetAfter vuelve a estar la ráfaga completa
+		clock.Advance(3 * time.Second)
+		if got := allow(t, store, "refill"); !got.Allowed || got.Remaining != 2 {
+			t.Fatalf("after a full reset: got %+v, want allowed with Remaining 2", got)
+		}
+	})
+
+	t.Run("keys are independent", func(t *testing.T) {
+		store := newStore(t, newTestClock())
+		for i := 0; i < 4; i++ {
+			allow(t, store, "a")
+		}
+		if got := allow(t, store, "b"); !got.Allowed || got.Remaining != 2 {
+			t.Fatalf("got %+v, want a fresh bucket for another key", got)
+		}
+	})
+
+	t.Run("no rate means no limit", func(t *testing.T) {
+		store := newStore(t, newTestClock())
+		for i := 0; i < 10; i++ {
+			result, err := store.Allow(ctx, "unlimited", RateLimit{Burst: 5})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !result.Allowed || result.Remaining != 5 {
+				t.Fatalf("request %d: got %+v, want allowed with Remaining 5", i+1, result)
+			}
+		}
+	})
+}
+
+func newTestMemoryStore(clock *testClock) *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{tats: make(map[string]time.Time), now: clock.Now}
+}
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	testRateLimitStore(t, func(t *testing.T, clock *testClock) RateLimitStore {
+		return newTestMemoryStore(clock)
+	})
+}
+
+func TestMemoryRateLimitStoreClose(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Cerrar dos veces no falla: el servidor cierra el store al apagarse y
+	// también si su inicialización falla a mitad de camino
+	if err := store.Close(); err != nil {
+		t.Fatalf("second Close() = %v", err)
+	}
+}
+
+func TestMemoryRateLimitStoreDeleteExpired(t *testing.T) {
+	clock := newTestClock()
+	store := newTestMemoryStore(clock)
+	limit := RateLimit{Rate: 1, Period: time.Second, Burst: 3}
+	for i := 0; i < 3; i++ {
+		store.Allow(context.Background(), "expiry", limit)
+	}
+
+	clock.Advance(2 * time.Second)
+	store.DeleteExpired()
+	if _, ok := store.tats["expiry"]; !ok {
+		t.Fatal("key deleted before its bucket emptied")
+	}
+
+	clock.Advance(time.Second + time.Microsecond)
+	store.DeleteExpired()
+	if _, ok := store.tats["expiry"]; ok {
+		t.Fatal("key kept after its bucket emptied")
+	}
+}
+
+// newTestRedisStore apunta el store a un miniredis cuyo reloj (TIME y los
+// TTL) avanza con clock
+func newTestRedisStore(t *testing.T, clock *testClock) (*RedisRateLimitStore, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	mr.SetTime(clock.Now())
+	clock.onAdvance = append(clock.onAdvance, func(d time.Duration) {
+		mr.SetTime(clock.Now())
+		mr.FastForward(d)
+	})
+
+	return NewRedisRateLimitStore(client), mr
+}
+
+func TestRedisRateLimitStore(t *testing.T) {
+	testRateLimitStore(t, func(t *testing.T, clock *testClock) RateLimitStore {
+		store, _ := newTestRedisStore(t, clock)
+		return store
+	})
+}
+
+func TestRedisRateLimitStoreKeyExpiry(t *testing.T) {
+	clock := newTestClock()
+	store, mr := newTestRedisStore(t, clock)
+	limit := RateLimit{Rate: 1, Period: time.Second, Burst: 3}
+	for i := 0; i < 3; i++ {
+		store.Allow(context.Background(), "expiry", limit)
+	}
+
+	// La clave vive lo que tarda el bucket en vaciarse (ResetAfter)
+	if ttl := mr.TTL("ratelimit:expiry"); ttl != 3*time.Second {
+		t.Fatalf("TTL = %v, want 3s", ttl)
+	}
+	// Una request rechazada no extiende la vida de la clave
+	store.Allow(context.Background(), "expiry", limit)
+	if ttl := mr.TTL("ratelimit:expiry"); ttl != 3*time.Second {
+		t.Fatalf("TTL after a denied request = %v, want 3s", ttl)
+	}
+
+	clock.Advance(2 * time.Second)
+	if !mr.Exists("ratelimit:expiry") {
+		t.Fatal("key expired before its bucket emptied")
+	}
+	clock.Advance(time.Second)
+	if mr.Exists("ratelimit:expiry") {
+		t.Fatal("key kept after its bucket emptied")
+	}
+}
+
+func TestRedisRateLimitStoreUsesServerClock(t *testing.T) {
+	mr := miniredis.RunT(t)
+	store := NewRedisRateLimitStore(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	limit := RateLimit{Rate: 1, Period: time.Second, Burst: 1}
+
+	// El reloj local no interviene: solo avanzar el de Redis libera el bucket
+	mr.SetTime(time.Unix(1700000000, 0))
+	if got, _ := store.Allow(context.Background(), "clock", limit); !got.Allowed {
+		t.Fatalf("first request: got %+v, want allowed", got)
+	}
+	if got, _ := store.Allow(context.Background(), "clock", limit); got.Allowed {
+		t.Fatalf("second request: got %+v, want denied", got)
+	}
+	mr.SetTime(time.Unix(1700000001, 0))
+	if got, _ := store.Allow(context.Background(), "clock", limit); !got.Allowed {
+		t.Fatalf("after a second on the Redis clock: got %+v, want allowed", got)
+	}
+
+	// Close cierra el cliente que recibió
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Allow(context.Background(), "clock", limit); err == nil {
+		t.Error("Allow() after Close() succeeded, want a closed client error")
+	}
+}
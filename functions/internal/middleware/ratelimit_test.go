@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterHeaders(t *testing.T) {
+	clock := newTestClock()
+	rl := NewRateLimiter(newTestMemoryStore(clock), []RateLimitPolicy{{
+		Name:   "test",
+		Routes: []string{"*"},
+		Key:    RateLimitKeyIP,
+		Limit:  RateLimit{Rate: 1, Period: 10 * time.Second, Burst: 2},
+	}})
+	handler := rl.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name       string
+		advance    time.Duration
+		status     int
+		remaining  string
+		reset      string
+		retryAfter string
+	}{
+		{name: "first request", status: http.StatusOK, remaining: "1", reset: "10"},
+		{name: "burst used up", status: http.StatusOK, remaining: "0", reset: "20"},
+		{name: "limited", status: http.StatusTooManyRequests, remaining: "0", reset: "20", retryAfter: "10"},
+		{name: "partial wait rounds up", advance: 500 * time.Millisecond, status: http.StatusTooManyRequests, remaining: "0", reset: "20", retryAfter: "10"},
+		{name: "after the interval", advance: 9500 * time.Millisecond, status: http.StatusOK, remaining: "0", reset: "20"},
+	}
+	for _, tt := range tests {
+		clock.Advance(tt.advance)
+		r := httptest.NewRequest(http.MethodGet, "/users", nil)
+		r.RemoteAddr = "203.0.113.7:41000"
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.status)
+		}
+		for header, want := range map[string]string{
+			"RateLimit-Limit":     "2",
+			"RateLimit-Remaining": tt.remaining,
+			"RateLimit-Reset":     tt.reset,
+			"Retry-After":         tt.retryAfter,
+		} {
+			if got := w.Header().Get(header); got != want {
+				t.Errorf("%s: %s = %q, want %q", tt.name, header, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"net/http"
+
+	"it-app_user/internal/requestinfo"
+)
+
+// RequestInfoMiddleware asigna un ID a cada request (o reutiliza el
+// X-Request-ID recibido), lo devuelve en la respuesta y guarda el ID y el
+// User-Agent en el contexto para logs y eventos de auditoría
+func RequestInfoMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info := requestinfo.FromRequest(r)
+		w.Header().Set(requestinfo.HeaderRequestID, info.ID)
+		next.ServeHTTP(w, r.WithContext(requestinfo.With(r.Context(), info)))
+	})
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"it-app_user/internal/i18n"
+)
+
+// RouteTimeout fija el tiempo máximo de las requests a ciertas rutas. Routes y
+// Methods usan el mismo formato que las políticas de rate limiting.
+type RouteTimeout struct {
+	Routes  []string
+	Methods []string
+	Timeout time.Duration
+}
+
+// TimeoutMiddleware agrega un deadline al contexto de cada request. Los
+// repositorios y Firebase usan ese contexto, así que las consultas se cancelan
+// al vencer el deadline o cuando el cliente se desconecta.
+type TimeoutMiddleware struct {
+	defaultTimeout time.Duration
+	routes         []RouteTimeout
+}
+
+// NewTimeoutMiddleware crea el middleware; la primera RouteTimeout que
+// coincida tiene prioridad sobre defaultTimeout
+func NewTimeoutMiddleware(defaultTimeout time.Duration, routes []RouteTimeout) *TimeoutMiddleware {
+	return &TimeoutMiddleware{
+		defaultTimeout: defaultTimeout,
+		routes:         routes,
+	}
+}
+
+// timeoutFor devuelve el timeout de la ruta de la request
+func (t *TimeoutMiddleware) timeoutFor(r *http.Request) time.Duration {
+	template := routeTemplate(r)
+	for _, rt := range t.routes {
+		if routeMatches(rt.Routes, rt.Methods, template, r.Method) {
+			return rt.Timeout
+		}
+	}
+	return t.defaultTimeout
+}
+
+func (t *TimeoutMiddleware) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), t.timeoutFor(r))
+		defer cancel()
+
+		r = r.WithContext(ctx)
+		next.ServeHTTP(&deadlineWriter{ResponseWriter: w, r: r}, r)
+	})
+}
+
+// deadlineWriter responde 503 si el deadline venció antes de que el handler
+// escribiera su respuesta. Así un timeout de la base de datos no se reporta
+// como el 404 o 500 que el handler devuelve ante cualquier error.
+type deadlineWriter struct {
+	http.ResponseWriter
+	r *http.Request
+
+	mu          sync.Mutex
+	wroteHeader bool
+	timedOut    bool
+}
+
+func (w *deadlineWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writeHeaderLocked(code)
+}
+
+func (w *deadlineWriter) writeHeaderLocked(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	if w.r.Context().Err() == context.DeadlineExceeded {
+		w.timedOut = true
+		http.Error(w.ResponseWriter, i18n.T(w.r.Context(), "Request timed out"), http.StatusServiceUnavailable)
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *deadlineWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.writeHeaderLocked(http.StatusOK)
+	if w.timedOut {
+		// La respuesta del handler se descarta: ya se envió el 503
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
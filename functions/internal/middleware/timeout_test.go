@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestTimeoutForRoute(t *testing.T) {
+	timeouts := NewTimeoutMiddleware(10*time.Second, []RouteTimeout{
+		{Routes: []string{"/users/{id}/export"}, Methods: []string{http.MethodPost}, Timeout: time.Minute},
+		{Routes: []string{"/users/*"}, Timeout: 2 * time.Second},
+		{Routes: []string{"/users/{id}/export"}, Timeout: time.Hour},
+	})
+
+	var remaining time.Duration
+	router := mux.NewRouter()
+	router.HandleFunc("/users/{id}/export", func(w http.ResponseWriter, r *http.Request) {
+		deadline, _ := r.Context().Deadline()
+		remaining = time.Until(deadline)
+	})
+	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		deadline, _ := r.Context().Deadline()
+		remaining = time.Until(deadline)
+	})
+	router.Use(timeouts.Middleware)
+
+	tests := []struct {
+		method string
+		path   string
+		want   time.Duration
+	}{
+		{method: http.MethodPost, path: "/users/5/export", want: time.Minute},
+		{method: http.MethodGet, path: "/users/5/export", want: 2 * time.Second},
+		{method: http.MethodGet, path: "/health", want: 10 * time.Second},
+	}
+	for _, tt := range tests {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tt.method, tt.path, nil))
+		// El deadline es el timeout de la ruta, con margen para la ejecución de la prueba
+		if remaining > tt.want || remaining < tt.want-time.Second {
+			t.Errorf("%s %s: deadline in %v, want %v", tt.method, tt.path, remaining, tt.want)
+		}
+	}
+}
+
+func TestTimeoutResponses(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		status  int
+		body    string
+	}{
+		{
+			name: "response before the deadline",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte("created"))
+			},
+			status: http.StatusCreated,
+			body:   "created",
+		},
+		{
+			name: "error after the deadline",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				// Un repositorio devuelve el error del contexto y el handler responde 404
+				<-r.Context().Done()
+				http.Error(w, "User not found", http.StatusNotFound)
+			},
+			status: http.StatusServiceUnavailable,
+			body:   "La solicitud excedió el tiempo máximo",
+		},
+		{
+			name: "implicit 200 after the deadline",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+				w.Write([]byte(`{"success":true}`))
+			},
+			status: http.StatusServiceUnavailable,
+			body:   "La solicitud excedió el tiempo máximo",
+		},
+		{
+			name: "headers written before the deadline",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				<-r.Context().Done()
+				w.Write([]byte("partial"))
+			},
+			status: http.StatusOK,
+			body:   "partial",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewTimeoutMiddleware(20*time.Millisecond, nil).Middleware(tt.handler)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/5", nil))
+
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if body := strings.TrimSpace(w.Body.String()); body != tt.body {
+				t.Errorf("body = %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestTimeoutClientCanceled(t *testing.T) {
+	handler := NewTimeoutMiddleware(time.Minute, nil).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		http.Error(w, "canceled", http.StatusInternalServerError)
+	}))
+
+	// Si el cliente se desconecta no es un timeout del servicio
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/5", nil).WithContext(ctx))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want the handler's 500", w.Code)
+	}
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Estados de una solicitud de eliminación de cuenta
+const (
+	AccountDeletionAwaitingConfirmation = "awaiting_confirmation"
+	AccountDeletionPending              = "pending_deletion"
+	AccountDeletionCancelled            = "cancelled"
+	// AccountDeletionInProgress indica que el job está eliminando la cuenta; ya no se puede cancelar
+	AccountDeletionInProgress = "deleting"
+)
+
+// AccountDeletionRequest es una solicitud del propio usuario para eliminar su
+// cuenta (DELETE /me). Se confirma con un código enviado por email y, hasta
+// ScheduledFor, el usuario puede cancelarla. Al eliminarse la cuenta la fila
+// se borra junto con el resto de sus datos; el ciclo de vida completo queda
+// en el log de auditoría.
+type AccountDeletionRequest struct {
+	ID            uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID        uint       `json:"user_id" gorm:"not null;index"`
+	FirebaseID    string     `json:"-" gorm:"size:128;not null"`
+	Email         string     `json:"-" gorm:"size:255;not null"`
+	Language      string     `json:"-" gorm:"size:10"`
+	Status        string     `json:"status" gorm:"size:30;not null;index;check:status IN ('awaiting_confirmation','pending_deletion','deleting','cancelled')"`
+	CodeHash      string     `json:"-" gorm:"size:64"`
+	CodeExpiresAt *time.Time `json:"code_expires_at,omitempty"`
+	Attempts      int        `json:"-" gorm:"default:0"`
+	RequestedIP   string     `json:"-" gorm:"size:45"`
+	ConfirmedAt   *time.Time `json:"confirmed_at,omitempty"`
+	ScheduledFor  *time.Time `json:"scheduled_for,omitempty" gorm:"index"`
+	CancelledAt   *time.Time `json:"cancelled_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+	// StartedAt es cuándo el job tomó la solicitud para eliminar la cuenta
+	StartedAt *time.Time `json:"-"`
+}
+
+// ConfirmAccountDeletionRequest es el body opcional de DELETE /me
+type ConfirmAccountDeletionRequest struct {
+	Code string `json:"code" validate:"omitempty,len=6,numeric"`
+}
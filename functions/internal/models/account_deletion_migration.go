@@ -0,0 +1,24 @@
+package models
+
+import "gorm.io/gorm"
+
+// accountDeletionStatusSQL reemplaza el CHECK de status de
+// account_deletion_requests: AutoMigrate crea las restricciones que faltan
+// pero no modifica las que ya existen
+var accountDeletionStatusSQL = []string{
+	`ALTER TABLE account_deletion_requests DROP CONSTRAINT IF EXISTS chk_account_deletion_requests_status`,
+	`ALTER TABLE account_deletion_requests ADD CONSTRAINT chk_account_deletion_requests_status
+	CHECK (status IN ('awaiting_confirmation','pending_deletion','deleting','cancelled'))`,
+}
+
+// migrateAccountDeletionStatuses admite el estado deleting; es idempotente
+func migrateAccountDeletionStatuses(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, sql := range accountDeletionStatusSQL {
+			if err := tx.Exec(sql).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,74 @@
+package models
+
+import "time"
+
+// APIKeyPrefix inicia las API keys de los clientes de servicio, para
+// distinguirlas de los ID tokens de Firebase en el header Authorization
+const APIKeyPrefix = "svc_"
+
+// APIClient es un servicio interno que llama a la API con una API key en
+// lugar de un token de usuario. Name es su client_id; Scopes son nombres de
+// PermissionCatalog.
+type APIClient struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	Name        string         `json:"name" gorm:"size:100;not null;uniqueIndex"`
+	Description string         `json:"description" gorm:"size:255"`
+	Scopes      []string       `json:"scopes" gorm:"type:jsonb;serializer:json;not null"`
+	CreatedBy   string         `json:"created_by" gorm:"size:128"`
+	LastUsedAt  *time.Time     `json:"last_used_at"`
+	RevokedAt   *time.Time     `json:"revoked_at,omitempty" gorm:"index"`
+	Keys        []APIClientKey `json:"keys,omitempty" gorm:"constraint:OnDelete:CASCADE"`
+	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// HasScope indica si el cliente tiene el scope
+func (c *APIClient) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIClientKey es una API key de un cliente. Solo se guarda el hash SHA-256
+// de la key completa; Prefix es su parte pública, con la que se busca y se
+// identifica en los listados. Un cliente puede tener varias keys válidas a la
+// vez mientras dura la rotación.
+type APIClientKey struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	APIClientID uint       `json:"-" gorm:"not null;index"`
+	Prefix      string     `json:"prefix" gorm:"size:32;not null;uniqueIndex"`
+	Hash        string     `json:"-" gorm:"size:64;not null"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// Expired indica si la key ya no es válida en now
+func (k *APIClientKey) Expired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+type CreateAPIClientRequest struct {
+	Name        string   `json:"name" validate:"required,min=2,max=100,lowercase,excludesall= /:"`
+	Description string   `json:"description" validate:"max=255"`
+	Scopes      []string `json:"scopes" validate:"required,min=1,max=50,dive,required,max=100"`
+	// ExpiresInDays es la vigencia de la key; 0 es sin vencimiento
+	ExpiresInDays int `json:"expires_in_days" validate:"omitempty,min=1,max=730"`
+}
+
+type UpdateAPIClientRequest struct {
+	Description *string `json:"description" validate:"omitempty,max=255"`
+	// Scopes reemplaza los scopes del cliente; nil los deja como están
+	Scopes []string `json:"scopes" validate:"omitempty,min=1,max=50,dive,required,max=100"`
+}
+
+// RotateAPIClientKeyRequest pide una key nueva. Las anteriores siguen
+// valiendo GraceMinutes (60 por defecto, 0 las invalida en el momento) para
+// que el servicio pueda desplegar la nueva sin cortes.
+type RotateAPIClientKeyRequest struct {
+	GraceMinutes  *int `json:"grace_minutes" validate:"omitempty,min=0,max=10080"`
+	ExpiresInDays int  `json:"expires_in_days" validate:"omitempty,min=1,max=730"`
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// AuditGenesisHash es el prev_hash del primer evento de la cadena
+const AuditGenesisHash = "0000000000000000000000000000000000000000000000000000000000000000"
+
+// AuditEvent es un evento del log de auditoría. La tabla es append-only (un
+// trigger rechaza UPDATE y DELETE salvo en la purga por retención) y cada
+// evento guarda el hash del anterior, así que modificar o borrar una fila
+// rompe la cadena a partir de ella.
+type AuditEvent struct {
+	ID               uint                   `json:"id" gorm:"primaryKey;autoIncrement"`
+	OccurredAt       time.Time              `json:"occurred_at" gorm:"not null;index"`
+	Action           string                 `json:"action" gorm:"size:100;not null;index"`
+	ActorID          string                 `json:"actor_id" gorm:"size:128;not null;index"`
+	TargetUserID     uint                   `json:"target_user_id,omitempty" gorm:"index"`
+	TargetFirebaseID string                 `json:"target_firebase_id,omitempty" gorm:"size:128;index"`
+	IP               string                 `json:"ip,omitempty" gorm:"size:45"`
+	UserAgent        string                 `json:"user_agent,omitempty" gorm:"size:500"`
+	RequestID        string                 `json:"request_id,omitempty" gorm:"size:128;index"`
+	Changes          map[string]AuditChange `json:"changes,omitempty" gorm:"type:jsonb;serializer:json"`
+	Metadata         map[string]interface{} `json:"metadata,omitempty" gorm:"type:jsonb;serializer:json"`
+	PrevHash         string                 `json:"prev_hash" gorm:"size:64;not null"`
+	Hash             string                 `json:"hash" gorm:"size:64;not null;uniqueIndex"`
+}
+
+// AuditChange es el valor anterior y el nuevo de un campo modificado
+type AuditChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// ComputeHash calcula el hash del evento encadenado a PrevHash. Los campos se
+// serializan en forma canónica para que el resultado no dependa de cómo
+// Postgres devuelve el JSON, así que OccurredAt debe estar truncado a
+// microsegundos (la precisión de timestamptz).
+func (e *AuditEvent) ComputeHash() (string, error) {
+	changes, err := canonicalJSON(e.Changes)
+	if err != nil {
+		return "", err
+	}
+	metadata, err := canonicalJSON(e.Metadata)
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(struct {
+		PrevHash         string          `json:"prev_hash"`
+		OccurredAt       string          `json:"occurred_at"`
+		Action           string          `json:"action"`
+		ActorID          string          `json:"actor_id"`
+		TargetUserID     uint            `json:"target_user_id"`
+		TargetFirebaseID string          `json:"target_firebase_id"`
+		IP               string          `json:"ip"`
+		UserAgent        string          `json:"user_agent"`
+		RequestID        string          `json:"request_id"`
+		Changes          json.RawMessage `json:"changes"`
+		Metadata         json.RawMessage `json:"metadata"`
+	}{
+		PrevHash:         e.PrevHash,
+		OccurredAt:       e.OccurredAt.UTC().Format(time.RFC3339Nano),
+		Action:           e.Action,
+		ActorID:          e.ActorID,
+		TargetUserID:     e.TargetUserID,
+		TargetFirebaseID: e.TargetFirebaseID,
+		IP:               e.IP,
+		UserAgent:        e.UserAgent,
+		RequestID:        e.RequestID,
+		Changes:          changes,
+		Metadata:         metadata,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// canonicalJSON serializa v como lo haría tras leerlo de una columna jsonb:
+// objetos con las claves ordenadas, números como float64 y fechas como texto
+func canonicalJSON(v interface{}) (json.RawMessage, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic interface{}
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return nil, err
+	}
+	return json.Marshal(generic)
+}
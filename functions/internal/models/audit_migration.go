@@ -0,0 +1,35 @@
+package models
+
+import "gorm.io/gorm"
+
+// auditAppendOnlySQL crea el trigger que hace append-only a audit_events:
+// rechaza UPDATE y TRUNCATE siempre, y DELETE salvo que la transacción active
+// audit.allow_prune (solo lo hace la purga por retención)
+var auditAppendOnlySQL = []string{
+	`CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'DELETE' AND current_setting('audit.allow_prune', true) = 'on' THEN
+		RETURN OLD;
+	END IF;
+	RAISE EXCEPTION 'audit_events is append-only (% not allowed)', TG_OP;
+END;
+$$ LANGUAGE plpgsql`,
+	`DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events`,
+	`CREATE TRIGGER audit_events_append_only BEFORE UPDATE OR DELETE ON audit_events
+	FOR EACH ROW EXECUTE FUNCTION audit_events_append_only()`,
+	`DROP TRIGGER IF EXISTS audit_events_no_truncate ON audit_events`,
+	`CREATE TRIGGER audit_events_no_truncate BEFORE TRUNCATE ON audit_events
+	FOR EACH STATEMENT EXECUTE FUNCTION audit_events_append_only()`,
+}
+
+// migrateAuditEvents instala los triggers de audit_events; es idempotente
+func migrateAuditEvents(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, sql := range auditAppendOnlySQL {
+			if err := tx.Exec(sql).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -8,6 +8,7 @@ type LoginRequest struct {
 
 type LoginResponse struct {
 	User    *User  `json:"user"`
+	Created bool   `json:"created"` // true si el usuario local se creó en este login
 	Message string `json:"message"`
 }
 
@@ -0,0 +1,154 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"golang.org/x/text/unicode/norm"
+	"gorm.io/gorm"
+)
+
+// emailProviderRule describe cómo un proveedor entrega correo a variantes de
+// una misma dirección
+type emailProviderRule struct {
+	domain    string // dominio canónico (googlemail.com → gmail.com)
+	stripDots bool   // los puntos de la parte local se ignoran
+	stripPlus bool   // todo lo que sigue a "+" es una etiqueta
+}
+
+var emailProviderRules = map[string]emailProviderRule{
+	"gmail.com":      {domain: "gmail.com", stripDots: true, stripPlus: true},
+	"googlemail.com": {domain: "gmail.com", stripDots: true, stripPlus: true},
+	"outlook.com":    {domain: "outlook.com", stripPlus: true},
+	"hotmail.com":    {domain: "hotmail.com", stripPlus: true},
+	"live.com":       {domain: "live.com", stripPlus: true},
+	"icloud.com":     {domain: "icloud.com", stripPlus: true},
+	"fastmail.com":   {domain: "fastmail.com", stripPlus: true},
+	"proton.me":      {domain: "proton.me", stripPlus: true},
+	"protonmail.com": {domain: "protonmail.com", stripPlus: true},
+}
+
+var providerAwareEmails atomic.Bool
+
+// SetEmailProviderRules activa o desactiva las reglas por proveedor de
+// CanonicalEmail. Se llama al iniciar, antes de MigrateDB.
+func SetEmailProviderRules(enabled bool) {
+	providerAwareEmails.Store(enabled)
+}
+
+// EmailCanonicalRules identifica las reglas vigentes de CanonicalEmail; al
+// cambiar, MigrateDB recalcula todos los emails canónicos
+func EmailCanonicalRules() string {
+	if providerAwareEmails.Load() {
+		return "nfc-lowercase+provider-v1"
+	}
+	return "nfc-lowercase"
+}
+
+// UsernameCanonicalRules identifica las reglas vigentes de CanonicalUsername
+func UsernameCanonicalRules() string {
+	return "nfkc-lowercase"
+}
+
+// CanonicalEmail devuelve la forma del email con la que se comparan cuentas:
+// en NFC (la misma letra acentuada compuesta o con diacrítico combinante es
+// igual), en minúsculas y, si están activas las reglas por proveedor, sin
+// puntos ni +etiquetas donde el proveedor los ignora
+// ("J.Doe+news@GoogleMail.com" → "jdoe@gmail.com")
+func CanonicalEmail(email string) string {
+	email = strings.ToLower(norm.NFC.String(strings.TrimSpace(email)))
+	if !providerAwareEmails.Load() {
+		return email
+	}
+
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return email
+	}
+	local, domain := email[:at], email[at+1:]
+	rule, ok := emailProviderRules[domain]
+	if !ok {
+		return email
+	}
+
+	if rule.stripPlus {
+		if i := strings.Index(local, "+"); i > 0 {
+			local = local[:i]
+		}
+	}
+	if rule.stripDots {
+		local = strings.ReplaceAll(local, ".", "")
+	}
+	if local == "" {
+		return email
+	}
+	return local + "@" + rule.domain
+}
+
+// CanonicalUsername devuelve la forma del username con la que se comparan
+// cuentas: en NFKC (las variantes de ancho completo y ligaduras se reducen a
+// su letra, "ｂｏｂ" → "bob") y en minúsculas
+func CanonicalUsername(username string) string {
+	return strings.ToLower(norm.NFKC.String(strings.TrimSpace(username)))
+}
+
+// BeforeSave mantiene las columnas canónicas en todas las escrituras con
+// hooks: Create y Save del modelo, Updates con un struct y Update o Updates
+// con mapa. UpdateColumn y UpdateColumns no ejecutan hooks, así que no deben
+// usarse para email ni username.
+func (u *User) BeforeSave(tx *gorm.DB) error {
+	switch dest := tx.Statement.Dest.(type) {
+	case map[string]interface{}:
+		// Update("email", ...) y Updates(map): los valores nuevos solo están en el mapa
+		return setCanonicalUpdates(dest)
+	case *User:
+		if dest != u {
+			dest.setCanonicalFields()
+		}
+	case User:
+		dest.setCanonicalFields()
+		tx.Statement.Dest = &dest
+	}
+	u.setCanonicalFields()
+	return nil
+}
+
+// setCanonicalFields calcula las columnas canónicas de los valores que trae el struct
+func (u *User) setCanonicalFields() {
+	if u.Email != "" {
+		canonical := CanonicalEmail(u.Email)
+		u.EmailCanonical = &canonical
+	}
+	if u.Username != "" {
+		canonical := CanonicalUsername(u.Username)
+		u.UsernameCanonical = &canonical
+	}
+}
+
+// setCanonicalUpdates agrega las columnas canónicas a una actualización con
+// mapa que cambia email o username. Las claves pueden ser columnas o campos.
+func setCanonicalUpdates(updates map[string]interface{}) error {
+	for _, c := range []struct {
+		column, field, canonicalColumn string
+		canonical                      func(string) string
+	}{
+		{"email", "Email", "email_canonical", CanonicalEmail},
+		{"username", "Username", "username_canonical", CanonicalUsername},
+	} {
+		value, ok := updates[c.column]
+		if !ok {
+			value, ok = updates[c.field]
+		}
+		if !ok {
+			continue
+		}
+		s, ok := value.(string)
+		if !ok {
+			// Una expresión SQL dejaría la columna canónica desactualizada
+			return fmt.Errorf("users.%s must be updated with a string, got %T", c.column, value)
+		}
+		updates[c.canonicalColumn] = c.canonical(s)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package models
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// canonicalBatchSize es la cantidad de usuarios que se recalculan por lote
+const canonicalBatchSize = 500
+
+// CanonicalRuleSet registra con qué reglas se calcularon las columnas canónicas
+type CanonicalRuleSet struct {
+	Name      string    `json:"name" gorm:"primaryKey;size:64"`
+	Rules     string    `json:"rules" gorm:"size:64;not null"`
+	AppliedAt time.Time `json:"applied_at" gorm:"not null"`
+}
+
+// canonicalColumn describe una columna canónica de users y su índice único
+type canonicalColumn struct {
+	name      string
+	index     string
+	rules     func() string
+	canonical func(u *User) string
+	current   func(u *User) *string
+}
+
+var canonicalColumns = []canonicalColumn{
+	{
+		name:      "email_canonical",
+		index:     "idx_users_email_canonical",
+		rules:     EmailCanonicalRules,
+		canonical: func(u *User) string { return CanonicalEmail(u.Email) },
+		current:   func(u *User) *string { return u.EmailCanonical },
+	},
+	{
+		name:      "username_canonical",
+		index:     "idx_users_username_canonical",
+		rules:     UsernameCanonicalRules,
+		canonical: func(u *User) string { return CanonicalUsername(u.Username) },
+		current:   func(u *User) *string { return u.UsernameCanonical },
+	},
+}
+
+// CanonicalCollision es un grupo de usuarios que comparten email o username canónico
+type CanonicalCollision struct {
+	Column  string `json:"column"`
+	Value   string `json:"value"`
+	UserIDs []uint `json:"user_ids"`
+}
+
+// migrateCanonicalColumns completa las columnas canónicas y crea sus índices
+// únicos. Si las reglas cambiaron desde la última ejecución recalcula todas las
+// filas; si no, solo las que aún no tienen valor. Las colisiones existentes
+// (cuentas que solo difieren en mayúsculas, puntos de Gmail, etc.) no se
+// resuelven automáticamente: se informan y el índice de esa columna no se crea
+// hasta que se resuelvan.
+func migrateCanonicalColumns(db *gorm.DB) ([]CanonicalCollision, error) {
+	var collisions []CanonicalCollision
+
+	for _, col := range canonicalColumns {
+		var state CanonicalRuleSet
+		err := db.Where("name = ?", col.name).Limit(1).Find(&state).Error
+		if err != nil {
+			return nil, err
+		}
+
+		rules := col.rules()
+		rulesChanged := state.Rules != rules
+		if rulesChanged && state.Rules != "" {
+			// Con las reglas nuevas pueden aparecer colisiones que el índice rechazaría
+			if err := db.Exec("DROP INDEX IF EXISTS " + col.index).Error; err != nil {
+				return nil, err
+			}
+		}
+
+		if err := backfillCanonicalColumn(db, col, rulesChanged); err != nil {
+			return nil, fmt.Errorf("failed to backfill %s: %w", col.name, err)
+		}
+
+		found, err := findCanonicalCollisions(db, col.name)
+		if err != nil {
+			return nil, err
+		}
+		if len(found) > 0 {
+			collisions = append(collisions, found...)
+		} else {
+			sql := fmt.Sprintf("CREATE UNIQUE INDEX IF NOT EXISTS %s ON users (%s)", col.index, col.name)
+			if err := db.Exec(sql).Error; err != nil {
+				return nil, err
+			}
+		}
+
+		if rulesChanged {
+			err := db.Clauses(clause.OnConflict{UpdateAll: true}).
+				Create(&CanonicalRuleSet{Name: col.name, Rules: rules, AppliedAt: time.Now()}).Error
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return collisions, nil
+}
+
+// backfillCanonicalColumn calcula la columna canónica en lotes; con all=false
+// solo para las filas que no la tienen
+func backfillCanonicalColumn(db *gorm.DB, col canonicalColumn, all bool) error {
+	// Unscoped: los usuarios con borrado lógico también ocupan los índices únicos
+	query := db.Unscoped().Model(&User{}).Select("id", "email", "username", col.name)
+	if !all {
+		query = query.Where(col.name + " IS NULL")
+	}
+
+	var users []User
+	result := query.FindInBatches(&users, canonicalBatchSize, func(tx *gorm.DB, batch int) error {
+		for i := range users {
+			value := col.canonical(&users[i])
+			if current := col.current(&users[i]); current != nil && *current == value {
+				continue
+			}
+			// UpdateColumn no ejecuta hooks ni toca updated_at
+			err := db.Unscoped().Model(&User{}).Where("id = ?", users[i].ID).UpdateColumn(col.name, value).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return result.Error
+}
+
+// findCanonicalCollisions devuelve los valores de la columna compartidos por más de un usuario
+func findCanonicalCollisions(db *gorm.DB, column string) ([]CanonicalCollision, error) {
+	var rows []struct {
+		Value string
+		IDs   string `gorm:"column:ids"`
+	}
+	err := db.Unscoped().Model(&User{}).
+		Select(fmt.Sprintf("%s AS value, string_agg(id::text, ',' ORDER BY id) AS ids", column)).
+		Where(column + " IS NOT NULL").
+		Group(column).
+		Having("COUNT(*) > 1").
+		Order("value").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	collisions := make([]CanonicalCollision, 0, len(rows))
+	for _, row := range rows {
+		collision := CanonicalCollision{Column: column, Value: row.Value}
+		for _, id := range strings.Split(row.IDs, ",") {
+			n, err := strconv.ParseUint(id, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			collision.UserIDs = append(collision.UserIDs, uint(n))
+		}
+		collisions = append(collisions, collision)
+	}
+	return collisions, nil
+}
+
+// FindCanonicalCollisions devuelve todas las colisiones de email y username canónicos
+func FindCanonicalCollisions(db *gorm.DB) ([]CanonicalCollision, error) {
+	var collisions []CanonicalCollision
+	for _, col := range canonicalColumns {
+		found, err := findCanonicalCollisions(db, col.name)
+		if err != nil {
+			return nil, err
+		}
+		collisions = append(collisions, found...)
+	}
+	return collisions, nil
+}
+
+// MissingCanonicalIndexes devuelve los índices únicos canónicos que aún no existen
+func MissingCanonicalIndexes(db *gorm.DB) ([]string, error) {
+	indexes := make([]string, len(canonicalColumns))
+	for i, col := range canonicalColumns {
+		indexes[i] = col.index
+	}
+
+	var existing []string
+	err := db.Raw("SELECT indexname FROM pg_indexes WHERE tablename = 'users' AND indexname IN ?", indexes).
+		Scan(&existing).Error
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]bool, len(existing))
+	for _, e := range existing {
+		found[e] = true
+	}
+	var missing []string
+	for _, index := range indexes {
+		if !found[index] {
+			missing = append(missing, index)
+		}
+	}
+	return missing, nil
+}
+
+// reportCanonicalCollisions escribe en el log cada colisión para resolverla a mano
+func reportCanonicalCollisions(collisions []CanonicalCollision) {
+	for _, c := range collisions {
+		log.Printf("Colisión en users.%s: %q compartido por los usuarios %v", c.Column, c.Value, c.UserIDs)
+	}
+	if len(collisions) > 0 {
+		log.Printf("%d colisiones canónicas sin resolver: los índices únicos afectados no se crearon", len(collisions))
+	}
+}
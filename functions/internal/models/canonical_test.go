@@ -0,0 +1,169 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/gorm"
+
+	"it-app_user/internal/dbtest"
+)
+
+func TestCanonicalEmail(t *testing.T) {
+	tests := []struct {
+		name          string
+		email         string
+		providerRules bool
+		want          string
+	}{
+		{name: "case", email: "John.Doe@Example.COM", want: "john.doe@example.com"},
+		{name: "whitespace", email: "  jdoe@example.com\t\n", want: "jdoe@example.com"},
+		{name: "combining accent", email: "Jose\u0301@example.com", want: "jos\u00e9@example.com"},
+		{name: "precomposed accent", email: "JOS\u00c9@example.com", want: "jos\u00e9@example.com"},
+		{name: "compatibility forms are kept", email: "ｊｄｏｅ@example.com", want: "ｊｄｏｅ@example.com"},
+		{name: "provider rules off", email: "J.Doe+news@GoogleMail.com", want: "j.doe+news@googlemail.com"},
+		{name: "gmail dots and tags", email: "J.Doe+news@GoogleMail.com", providerRules: true, want: "jdoe@gmail.com"},
+		{name: "outlook tags only", email: "j.doe+news@outlook.com", providerRules: true, want: "j.doe@outlook.com"},
+		{name: "other providers untouched", email: "j.doe+news@example.com", providerRules: true, want: "j.doe+news@example.com"},
+	}
+	t.Cleanup(func() { SetEmailProviderRules(false) })
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetEmailProviderRules(tt.providerRules)
+			if got := CanonicalEmail(tt.email); got != tt.want {
+				t.Errorf("CanonicalEmail(%q) = %q, want %q", tt.email, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCanonicalUsername(t *testing.T) {
+	tests := []struct {
+		name     string
+		username string
+		want     string
+	}{
+		{name: "case", username: "Bob", want: "bob"},
+		{name: "whitespace", username: " bob \t", want: "bob"},
+		{name: "combining accent", username: "Jose\u0301", want: "jos\u00e9"},
+		{name: "full width", username: "Ｂｏｂ", want: "bob"},
+		{name: "ligature", username: "ﬁsh", want: "fish"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CanonicalUsername(tt.username); got != tt.want {
+				t.Errorf("CanonicalUsername(%q) = %q, want %q", tt.username, got, tt.want)
+			}
+		})
+	}
+}
+
+// dryRunSQL devuelve el SQL que gorm generaría para la escritura, con los hooks ya ejecutados
+func dryRunSQL(t *testing.T, write func(tx *gorm.DB) *gorm.DB) (string, []interface{}) {
+	t.Helper()
+	db, _ := dbtest.NewMockDB(t)
+	stmt := write(db.Session(&gorm.Session{DryRun: true, SkipDefaultTransaction: true})).Statement
+	if stmt.Error != nil {
+		t.Fatal(stmt.Error)
+	}
+	return stmt.SQL.String(), stmt.Vars
+}
+
+func TestBeforeSaveRecomputesCanonicalColumns(t *testing.T) {
+	tests := []struct {
+		name  string
+		write func(tx *gorm.DB) *gorm.DB
+		want  map[string]string
+	}{
+		{
+			name: "create",
+			write: func(tx *gorm.DB) *gorm.DB {
+				return tx.Create(&User{Email: "Ana@Example.com", Username: "Ana"})
+			},
+			want: map[string]string{"email_canonical": "ana@example.com", "username_canonical": "ana"},
+		},
+		{
+			name: "update single column",
+			write: func(tx *gorm.DB) *gorm.DB {
+				return tx.Model(&User{ID: 7}).Update("email", "Ana@Example.com")
+			},
+			want: map[string]string{"email_canonical": "ana@example.com"},
+		},
+		{
+			name: "updates with map",
+			write: func(tx *gorm.DB) *gorm.DB {
+				return tx.Model(&User{ID: 7}).Updates(map[string]interface{}{"Username": "Ａna", "first_name": "Ana"})
+			},
+			want: map[string]string{"username_canonical": "ana"},
+		},
+		{
+			name: "updates with struct",
+			write: func(tx *gorm.DB) *gorm.DB {
+				return tx.Model(&User{ID: 7}).Updates(User{Email: "ANA@example.com"})
+			},
+			want: map[string]string{"email_canonical": "ana@example.com"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, vars := dryRunSQL(t, tt.write)
+			for column, want := range tt.want {
+				if !strings.Contains(sql, `"`+column+`"`) {
+					t.Fatalf("%s missing from %s", column, sql)
+				}
+				if !containsVar(vars, want) {
+					t.Errorf("%s: %q not among the bound values %v", column, want, vars)
+				}
+			}
+		})
+	}
+}
+
+func TestBeforeSaveRejectsExpressionUpdates(t *testing.T) {
+	db, _ := dbtest.NewMockDB(t)
+	err := db.Session(&gorm.Session{DryRun: true, SkipDefaultTransaction: true}).
+		Model(&User{ID: 7}).
+		Update("email", gorm.Expr("lower(email)")).Error
+	if err == nil {
+		t.Fatal("an SQL expression for email was accepted")
+	}
+}
+
+// containsVar busca un valor entre los parámetros, sea string o *string
+func containsVar(vars []interface{}, want string) bool {
+	for _, v := range vars {
+		switch v := v.(type) {
+		case string:
+			if v == want {
+				return true
+			}
+		case *string:
+			if v != nil && *v == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestFindCanonicalCollisions(t *testing.T) {
+	db, mock := dbtest.NewMockDB(t)
+	mock.ExpectQuery(`SELECT email_canonical AS value, string_agg\(id::text, ',' ORDER BY id\) AS ids FROM "users" WHERE email_canonical IS NOT NULL GROUP BY "email_canonical" HAVING COUNT\(\*\) > 1 ORDER BY value`).
+		WillReturnRows(sqlmock.NewRows([]string{"value", "ids"}).
+			AddRow("bob@example.com", "12,57").
+			AddRow("josé@example.com", "3,9,40"))
+
+	got, err := findCanonicalCollisions(db, "email_canonical")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []CanonicalCollision{
+		{Column: "email_canonical", Value: "bob@example.com", UserIDs: []uint{12, 57}},
+		{Column: "email_canonical", Value: "josé@example.com", UserIDs: []uint{3, 9, 40}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("collisions = %+v, want %+v", got, want)
+	}
+}
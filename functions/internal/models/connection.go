@@ -3,12 +3,17 @@ package models
 import (
 	"log"
 	"gorm.io/gorm"
+	"it-app_user/internal/config"
 	"it-app_user/internal/database"
 )
 
 // ConnectDB establece la conexión con la base de datos PostgreSQL
-func ConnectDB() {
-	database.ConnectDB()
+func ConnectDB(cfg config.DatabaseConfig) error {
+	if err := database.ConnectDB(cfg); err != nil {
+		return err
+	}
+	// El historial de versiones necesita el actor de cada escritura
+	return registerVersionCallbacks(database.GetDB())
 }
 
 // MigrateDB ejecuta las migraciones automáticas
@@ -21,11 +26,51 @@ func MigrateDB() {
 		&UserProfile{},
 		&UserSettings{},
 		&UserStats{},
+		&RateLimitBucket{},
+		&SchemaMigration{},
+		&CanonicalRuleSet{},
+		&DataExport{},
+		&AccountDeletionRequest{},
+		&AuditEvent{},
+		&RecordVersion{},
+		&ProcessedEvent{},
+		&Permission{},
+		&Role{},
+		&UserRole{},
+		&APIClient{},
+		&APIClientKey{},
 	)
 	
 	if err != nil {
 		log.Fatalf("Error al ejecutar migraciones: %v", err)
 	}
+
+	// Emails y usernames canónicos: las colisiones se informan pero no detienen el servicio
+	collisions, err := migrateCanonicalColumns(db)
+	if err != nil {
+		log.Fatalf("Error al migrar las columnas canónicas: %v", err)
+	}
+	reportCanonicalCollisions(collisions)
+
+	if err := migrateAccountDeletionStatuses(db); err != nil {
+		log.Fatalf("Error al migrar los estados de eliminación de cuenta: %v", err)
+	}
+
+	if err := migrateAuditEvents(db); err != nil {
+		log.Fatalf("Error al instalar los triggers de auditoría: %v", err)
+	}
+
+	if err := migrateRecordVersions(db); err != nil {
+		log.Fatalf("Error al instalar el historial de versiones: %v", err)
+	}
+
+	if err := migrateRoles(db); err != nil {
+		log.Fatalf("Error al crear los roles y permisos: %v", err)
+	}
+
+	if err := recordSchemaVersion(db); err != nil {
+		log.Fatalf("Error al registrar la versión del esquema: %v", err)
+	}
 	
 	log.Println("Migraciones ejecutadas exitosamente")
 }
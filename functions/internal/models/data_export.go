@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Estados de una exportación de datos
+const (
+	DataExportPending    = "pending"
+	DataExportProcessing = "processing"
+	DataExportCompleted  = "completed"
+	DataExportFailed     = "failed"
+	DataExportExpired    = "expired"
+)
+
+// DataExport es una solicitud de exportación de los datos personales de un
+// usuario. El archivo ZIP se genera de forma asíncrona y se guarda en el
+// almacenamiento configurado bajo StorageKey hasta ExpiresAt.
+type DataExport struct {
+	ID          uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID      uint       `json:"user_id" gorm:"not null;index"`
+	FirebaseID  string     `json:"-" gorm:"size:128;not null"`
+	Status      string     `json:"status" gorm:"size:20;not null;default:'pending';index;check:status IN ('pending','processing','completed','failed','expired')"`
+	Error       string     `json:"-" gorm:"size:500"`
+	StorageKey  string     `json:"-" gorm:"size:255"`
+	SizeBytes   int64      `json:"size_bytes,omitempty"`
+	Attempts    int        `json:"-" gorm:"default:0"`
+	RequestedIP string     `json:"-" gorm:"size:45"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" gorm:"index"`
+	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// IsActive indica si la exportación todavía está en curso o su archivo puede descargarse
+func (e *DataExport) IsActive(now time.Time) bool {
+	switch e.Status {
+	case DataExportPending, DataExportProcessing:
+		return true
+	case DataExportCompleted:
+		return e.ExpiresAt != nil && now.Before(*e.ExpiresAt)
+	default:
+		return false
+	}
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // EmailVerification representa el estado de verificación de email
 type EmailVerification struct {
@@ -83,6 +86,36 @@ type EmailVerificationSettings struct {
 	BlockedDomains        []string      `json:"blocked_domains"`
 }
 
+// DefaultEmailVerificationSettings son las configuraciones de verificación del
+// servicio; los dominios bloqueados vienen de SECURITY_BLOCKED_EMAIL_DOMAINS
+func DefaultEmailVerificationSettings(blockedDomains []string) EmailVerificationSettings {
+	return EmailVerificationSettings{
+		MaxAttempts:         5,
+		CodeExpirationTime:  3600, // 1 hora en segundos
+		ResendCooldownTime:  300,  // 5 minutos en segundos
+		RequireVerification: true,
+		AutoVerifyDomains:   []string{},
+		BlockedDomains:      blockedDomains,
+	}
+}
+
+// IsBlockedDomain indica si el dominio del email, o uno del que es subdominio,
+// está en BlockedDomains
+func (s EmailVerificationSettings) IsBlockedDomain(email string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.TrimSuffix(strings.ToLower(email[at+1:]), ".")
+	for _, blocked := range s.BlockedDomains {
+		blocked = strings.ToLower(blocked)
+		if domain == blocked || strings.HasSuffix(domain, "."+blocked) {
+			return true
+		}
+	}
+	return false
+}
+
 // EmailTemplate representa una plantilla de email
 type EmailTemplate struct {
 	ID          int                    `json:"id"`
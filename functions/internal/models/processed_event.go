@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// ProcessedEvent registra un evento ya procesado (p. ej. un CloudEvent de
+// Firebase Auth) para ignorar sus reenvíos
+type ProcessedEvent struct {
+	EventID     string    `json:"event_id" gorm:"primaryKey;size:255"`
+	Type        string    `json:"type" gorm:"size:255;not null"`
+	ProcessedAt time.Time `json:"processed_at" gorm:"not null;index"`
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// RateLimitBucket guarda el estado GCRA de una clave de rate limiting
+type RateLimitBucket struct {
+	BucketKey string    `json:"bucket_key" gorm:"primaryKey;size:255"`
+	TAT       time.Time `json:"tat" gorm:"not null;index"` // theoretical arrival time
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"it-app_user/internal/principal"
+	"it-app_user/internal/requestinfo"
+)
+
+// Tablas con historial de versiones (valores de RecordVersion.Entity)
+const (
+	VersionEntityUser    = "users"
+	VersionEntityProfile = "user_profiles"
+)
+
+// Operaciones de RecordVersion. VersionOperationSnapshot es la versión
+// inicial que MigrateDB crea para las filas que existían antes del historial.
+const (
+	VersionOperationInsert   = "insert"
+	VersionOperationUpdate   = "update"
+	VersionOperationSnapshot = "snapshot"
+)
+
+// RecordVersion es una versión de una fila de users o user_profiles. Las
+// escribe un trigger en cada INSERT y en cada UPDATE que cambia algún campo
+// relevante, así que cubren todas las escrituras, pasen o no por GORM. El
+// borrado lógico queda como una versión con deleted_at; la purga elimina el
+// historial junto con el usuario.
+type RecordVersion struct {
+	ID        uint   `json:"id" gorm:"primaryKey;autoIncrement"`
+	Entity    string `json:"entity" gorm:"size:30;not null;uniqueIndex:idx_record_versions_entity_record_version,priority:1;index:idx_record_versions_entity_user,priority:1"`
+	RecordID  uint   `json:"record_id" gorm:"not null;uniqueIndex:idx_record_versions_entity_record_version,priority:2"`
+	UserID    uint   `json:"user_id" gorm:"not null;index;index:idx_record_versions_entity_user,priority:2"`
+	Version   int    `json:"version" gorm:"not null;uniqueIndex:idx_record_versions_entity_record_version,priority:3"`
+	Operation string `json:"operation" gorm:"size:10;not null"`
+	// Data es la fila completa en esta versión, sin las columnas que no se versionan
+	Data map[string]interface{} `json:"data" gorm:"type:jsonb;serializer:json;not null"`
+	// ChangedFields son las columnas que cambiaron respecto de la versión anterior
+	ChangedFields []string `json:"changed_fields,omitempty" gorm:"type:jsonb;serializer:json"`
+	// ActorID es quien hizo el cambio, como en la auditoría: el Firebase UID
+	// del usuario o del administrador que lo suplanta, o client:<nombre> para
+	// un servicio (vacío en jobs y requests sin autenticar)
+	ActorID   string    `json:"actor_id,omitempty" gorm:"size:128"`
+	RequestID string    `json:"request_id,omitempty" gorm:"size:128"`
+	ValidFrom time.Time `json:"valid_from" gorm:"not null;index:idx_record_versions_entity_user,priority:3"`
+}
+
+// versionedTables son las tablas cuyo trigger escribe RecordVersion
+var versionedTables = map[string]bool{
+	VersionEntityUser:    true,
+	VersionEntityProfile: true,
+}
+
+// setVersionContext pasa a la transacción el actor y el ID de la request para
+// que el trigger los guarde en la versión. set_config con is_local=true solo
+// vale hasta el fin de la transacción, así que no queda en la conexión del pool.
+func setVersionContext(db *gorm.DB) {
+	if db.Error != nil || db.DryRun || !versionedTables[db.Statement.Table] {
+		return
+	}
+
+	ctx := db.Statement.Context
+	// Con un token de suplantación user_id es el usuario suplantado; el
+	// principal identifica al administrador, igual que audit.FromRequest
+	actorID, _ := ctx.Value("user_id").(string)
+	if p, ok := principal.FromContext(ctx); ok {
+		actorID = p.Actor()
+	}
+	info, _ := requestinfo.FromContext(ctx)
+	if actorID == "" && info.ID == "" {
+		return
+	}
+
+	_, err := db.Statement.ConnPool.ExecContext(ctx,
+		"SELECT set_config('app.actor_id', $1, true), set_config('app.request_id', $2, true)",
+		actorID, info.ID)
+	if err != nil {
+		db.AddError(err)
+	}
+}
+
+// registerVersionCallbacks ejecuta setVersionContext dentro de la transacción
+// de cada escritura de GORM, antes de la sentencia que dispara el trigger
+func registerVersionCallbacks(db *gorm.DB) error {
+	const name = "versions:set_context"
+	callbacks := db.Callback()
+
+	if err := callbacks.Create().After("gorm:begin_transaction").Before("gorm:create").Register(name, setVersionContext); err != nil {
+		return err
+	}
+	if err := callbacks.Update().After("gorm:begin_transaction").Before("gorm:update").Register(name, setVersionContext); err != nil {
+		return err
+	}
+	// El borrado lógico de GORM es un UPDATE que pasa por los callbacks de Delete
+	return callbacks.Delete().After("gorm:begin_transaction").Before("gorm:delete").Register(name, setVersionContext)
+}
+
+// RevertVersionRequest son los campos a revertir a los valores de una versión
+type RevertVersionRequest struct {
+	VersionID uint     `json:"version_id" validate:"required"`
+	Fields    []string `json:"fields" validate:"required,min=1,max=20,dive,required,max=50"`
+}
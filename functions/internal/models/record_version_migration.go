@@ -0,0 +1,107 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// unversionedColumns son, por tabla, las columnas que no se guardan en las
+// versiones ni generan una versión nueva al cambiar: timestamps, datos de
+// login (cambian en cada inicio de sesión) y columnas derivadas
+var unversionedColumns = map[string][]string{
+	VersionEntityUser: {
+		"updated_at", "login_count", "last_login_at", "last_login_ip", "last_login_device",
+		"email_canonical", "username_canonical",
+	},
+	VersionEntityProfile: {"updated_at"},
+}
+
+// recordVersionFunctionSQL crea la función del trigger. Recibe como argumentos
+// las columnas que no se versionan y no escribe nada si solo cambiaron esas.
+// El actor y la request los completa setVersionContext con set_config.
+const recordVersionFunctionSQL = `CREATE OR REPLACE FUNCTION record_version() RETURNS trigger AS $$
+DECLARE
+	new_data jsonb := to_jsonb(NEW) - TG_ARGV;
+	old_data jsonb;
+	changed jsonb;
+	row_id bigint := (new_data->>'id')::bigint;
+BEGIN
+	IF TG_OP = 'UPDATE' THEN
+		old_data := to_jsonb(OLD) - TG_ARGV;
+		IF new_data = old_data THEN
+			RETURN NULL;
+		END IF;
+		SELECT jsonb_agg(n.key ORDER BY n.key) INTO changed
+		FROM jsonb_each(new_data) n
+		WHERE n.value IS DISTINCT FROM old_data->n.key;
+	END IF;
+
+	INSERT INTO record_versions
+		(entity, record_id, user_id, version, operation, data, changed_fields, actor_id, request_id, valid_from)
+	VALUES (
+		TG_TABLE_NAME,
+		row_id,
+		COALESCE(new_data->>'user_id', new_data->>'id')::bigint,
+		COALESCE((SELECT max(version) FROM record_versions WHERE entity = TG_TABLE_NAME AND record_id = row_id), 0) + 1,
+		lower(TG_OP),
+		new_data,
+		changed,
+		NULLIF(current_setting('app.actor_id', true), ''),
+		NULLIF(current_setting('app.request_id', true), ''),
+		now()
+	);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql`
+
+// migrateRecordVersions instala los triggers de historial y crea la versión
+// inicial de las filas que todavía no tienen ninguna; es idempotente
+func migrateRecordVersions(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(recordVersionFunctionSQL).Error; err != nil {
+			return err
+		}
+
+		for _, table := range []string{VersionEntityUser, VersionEntityProfile} {
+			columns := unversionedColumns[table]
+			trigger := table + "_record_version"
+
+			statements := []string{
+				fmt.Sprintf(`DROP TRIGGER IF EXISTS %s ON %s`, trigger, table),
+				fmt.Sprintf(`CREATE TRIGGER %s AFTER INSERT OR UPDATE ON %s
+	FOR EACH ROW EXECUTE FUNCTION record_version(%s)`, trigger, table, quoteLiterals(columns)),
+				fmt.Sprintf(`INSERT INTO record_versions (entity, record_id, user_id, version, operation, data, valid_from)
+	SELECT '%[1]s', t.id, %[2]s, 1, '%[3]s', to_jsonb(t) - ARRAY[%[4]s]::text[], now()
+	FROM %[1]s t
+	WHERE NOT EXISTS (SELECT 1 FROM record_versions v WHERE v.entity = '%[1]s' AND v.record_id = t.id)`,
+					table, userIDColumn(table), VersionOperationSnapshot, quoteLiterals(columns)),
+			}
+			for _, sql := range statements {
+				if err := tx.Exec(sql).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// userIDColumn es la columna con el ID del usuario dueño de la fila
+func userIDColumn(table string) string {
+	if table == VersionEntityUser {
+		return "t.id"
+	}
+	return "t.user_id"
+}
+
+// quoteLiterals arma una lista de literales SQL; solo se usa con nombres de
+// columnas fijos del código
+func quoteLiterals(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	}
+	return strings.Join(quoted, ", ")
+}
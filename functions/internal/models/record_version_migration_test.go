@@ -0,0 +1,65 @@
+package models
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"it-app_user/internal/dbtest"
+)
+
+func TestMigrateRecordVersions(t *testing.T) {
+	db, mock := dbtest.NewMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`CREATE OR REPLACE FUNCTION record_version() RETURNS trigger`)).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	// Los datos de login y las columnas derivadas no generan versiones
+	tables := []struct {
+		table   string
+		columns string
+		userID  string
+	}{
+		{
+			table:   VersionEntityUser,
+			columns: `'updated_at', 'login_count', 'last_login_at', 'last_login_ip', 'last_login_device', 'email_canonical', 'username_canonical'`,
+			userID:  "t.id",
+		},
+		{table: VersionEntityProfile, columns: `'updated_at'`, userID: "t.user_id"},
+	}
+	for _, tt := range tables {
+		mock.ExpectExec(regexp.QuoteMeta(`DROP TRIGGER IF EXISTS ` + tt.table + `_record_version ON ` + tt.table)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta(`CREATE TRIGGER `+tt.table+`_record_version AFTER INSERT OR UPDATE ON `+tt.table) +
+			`\s+` + regexp.QuoteMeta(`FOR EACH ROW EXECUTE FUNCTION record_version(`+tt.columns+`)`)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		// La versión inicial solo se crea para las filas que todavía no tienen ninguna
+		mock.ExpectExec(regexp.QuoteMeta(`SELECT '`+tt.table+`', t.id, `+tt.userID+`, 1, 'snapshot', to_jsonb(t) - ARRAY[`+tt.columns+`]::text[], now()`) +
+			`\s+` + regexp.QuoteMeta(`FROM `+tt.table+` t`) +
+			`\s+` + regexp.QuoteMeta(`WHERE NOT EXISTS (SELECT 1 FROM record_versions v WHERE v.entity = '`+tt.table+`' AND v.record_id = t.id)`)).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+	}
+	mock.ExpectCommit()
+
+	if err := migrateRecordVersions(db); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrateRecordVersionsRollsBack(t *testing.T) {
+	db, mock := dbtest.NewMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectExec(`CREATE OR REPLACE FUNCTION record_version`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DROP TRIGGER IF EXISTS users_record_version`).WillReturnError(sqlmock.ErrCancelled)
+	mock.ExpectRollback()
+
+	if err := migrateRecordVersions(db); err == nil {
+		t.Fatal("migrateRecordVersions() = nil, want the trigger error")
+	}
+}
+
+func TestQuoteLiterals(t *testing.T) {
+	if got, want := quoteLiterals([]string{"updated_at", "it's"}), `'updated_at', 'it''s'`; got != want {
+		t.Errorf("quoteLiterals() = %s, want %s", got, want)
+	}
+}
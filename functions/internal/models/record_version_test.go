@@ -0,0 +1,70 @@
+package models
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"it-app_user/internal/dbtest"
+	"it-app_user/internal/principal"
+)
+
+func TestSetVersionContextActor(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  func() context.Context
+		want string
+	}{
+		{
+			name: "user",
+			ctx: func() context.Context {
+				ctx := context.WithValue(context.Background(), "user_id", "uid-ana")
+				return principal.With(ctx, principal.Principal{UID: "uid-ana"})
+			},
+			want: "uid-ana",
+		},
+		{
+			// user_id es el usuario suplantado; el cambio lo hizo el administrador
+			name: "impersonation",
+			ctx: func() context.Context {
+				ctx := context.WithValue(context.Background(), "user_id", "uid-ana")
+				return principal.With(ctx, principal.Principal{UID: "uid-ana", ImpersonatedBy: "uid-admin"})
+			},
+			want: "uid-admin",
+		},
+		{
+			name: "service",
+			ctx: func() context.Context {
+				return principal.With(context.Background(), principal.Principal{ClientID: "billing"})
+			},
+			want: "client:billing",
+		},
+		{
+			name: "without principal",
+			ctx: func() context.Context {
+				return context.WithValue(context.Background(), "user_id", "uid-ana")
+			},
+			want: "uid-ana",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := dbtest.NewMockDB(t)
+			if err := registerVersionCallbacks(db); err != nil {
+				t.Fatal(err)
+			}
+
+			mock.ExpectBegin()
+			mock.ExpectExec(`SELECT set_config\('app.actor_id', \$1, true\), set_config\('app.request_id', \$2, true\)`).
+				WithArgs(tt.want, "").
+				WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(`UPDATE "users"`).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			if err := db.WithContext(tt.ctx()).Model(&User{ID: 7}).Update("first_name", "Ana").Error; err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package models
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Permisos del servicio, con formato recurso:acción. Los exige
+// AuthMiddleware.RequirePermission; los roles los agrupan y también son los
+// scopes de los clientes de API.
+const (
+	PermissionUsersRead   = "users:read"
+	PermissionUsersWrite  = "users:write"
+	PermissionUsersDelete = "users:delete"
+	// PermissionUsersImpersonate permite obtener tokens para actuar como otro usuario
+	PermissionUsersImpersonate = "users:impersonate"
+	PermissionRolesRead        = "roles:read"
+	PermissionRolesWrite       = "roles:write"
+	PermissionAuditRead        = "audit:read"
+	PermissionAPIClientsRead   = "api_clients:read"
+	PermissionAPIClientsWrite  = "api_clients:write"
+	// PermissionTokensIntrospect permite consultar el estado de los tokens de otros usuarios
+	PermissionTokensIntrospect = "tokens:introspect"
+	// PermissionMetricsRead permite leer las métricas de Prometheus en /metrics
+	PermissionMetricsRead = "metrics:read"
+)
+
+// RoleAdmin es el rol creado por las migraciones con todos los permisos
+const RoleAdmin = "admin"
+
+// ClaimRoles es el custom claim de Firebase con los IDs de los roles del
+// usuario, comprimidos con EncodeRoleSet
+const ClaimRoles = "roles"
+
+// PermissionCatalog son los permisos que existen, con su descripción. Las
+// migraciones los crean en la tabla permissions.
+var PermissionCatalog = []Permission{
+	{Name: PermissionUsersRead, Description: "Read any user"},
+	{Name: PermissionUsersWrite, Description: "Update and restore any user"},
+	{Name: PermissionUsersDelete, Description: "Delete any user"},
+	{Name: PermissionUsersImpersonate, Description: "Act as any user with a short-lived, audited token"},
+	{Name: PermissionRolesRead, Description: "Read roles and role assignments"},
+	{Name: PermissionRolesWrite, Description: "Manage roles and assign them to users"},
+	{Name: PermissionAuditRead, Description: "Read the audit log"},
+	{Name: PermissionAPIClientsRead, Description: "Read API clients and their keys"},
+	{Name: PermissionAPIClientsWrite, Description: "Register, update, rotate and revoke API clients"},
+	{Name: PermissionTokensIntrospect, Description: "Introspect any user's ID token"},
+	{Name: PermissionMetricsRead, Description: "Read the Prometheus metrics"},
+}
+
+// Permission es un permiso que puede incluirse en un rol
+type Permission struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"size:100;not null;uniqueIndex"`
+	Description string    `json:"description" gorm:"size:255"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// Role agrupa permisos que se asignan juntos a los usuarios
+type Role struct {
+	ID          uint         `json:"id" gorm:"primaryKey"`
+	Name        string       `json:"name" gorm:"size:50;not null;uniqueIndex"`
+	Description string       `json:"description" gorm:"size:255"`
+	Permissions []Permission `json:"permissions" gorm:"many2many:role_permissions;constraint:OnDelete:CASCADE"`
+	CreatedAt   time.Time    `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time    `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// PermissionNames devuelve los nombres de los permisos del rol
+func (r Role) PermissionNames() []string {
+	return permissionNames(r.Permissions)
+}
+
+// UserRole es la asignación de un rol a un usuario
+type UserRole struct {
+	UserID     uint      `json:"user_id" gorm:"primaryKey"`
+	RoleID     uint      `json:"role_id" gorm:"primaryKey;index"`
+	Role       Role      `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	AssignedBy string    `json:"assigned_by" gorm:"size:128"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" validate:"required,min=2,max=50,lowercase,excludesall= /:"`
+	Description string   `json:"description" validate:"max=255"`
+	Permissions []string `json:"permissions" validate:"max=100,dive,required,max=100"`
+}
+
+type UpdateRoleRequest struct {
+	Description *string `json:"description" validate:"omitempty,max=255"`
+	// Permissions reemplaza los permisos del rol; nil los deja como están
+	Permissions []string `json:"permissions" validate:"omitempty,max=100,dive,required,max=100"`
+}
+
+type AssignRoleRequest struct {
+	Role string `json:"role" validate:"required,max=50"`
+}
+
+// EncodeRoleSet comprime un conjunto de IDs de roles para los custom claims de
+// Firebase, que no pueden superar 1000 bytes: un bitmap (bit i = rol con ID i)
+// en base64url sin padding. Con IDs hasta 100 ocupa 18 caracteres.
+func EncodeRoleSet(ids []uint) string {
+	if len(ids) == 0 {
+		return ""
+	}
+	sorted := append([]uint(nil), ids...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	bitmap := make([]byte, sorted[len(sorted)-1]/8+1)
+	for _, id := range sorted {
+		bitmap[id/8] |= 1 << (id % 8)
+	}
+	return base64.RawURLEncoding.EncodeToString(bitmap)
+}
+
+// DecodeRoleSet devuelve los IDs de roles de un valor de EncodeRoleSet, en orden
+func DecodeRoleSet(encoded string) ([]uint, error) {
+	bitmap, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid role set: %w", err)
+	}
+	var ids []uint
+	for i, b := range bitmap {
+		for bit := uint(0); bit < 8; bit++ {
+			if b&(1<<bit) != 0 {
+				ids = append(ids, uint(i)*8+bit)
+			}
+		}
+	}
+	return ids, nil
+}
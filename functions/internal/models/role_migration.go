@@ -0,0 +1,44 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// migrateRoles crea los permisos de PermissionCatalog y el rol admin, y le
+// asigna todos los permisos (también los agregados en versiones nuevas). Es
+// idempotente y no modifica los demás roles.
+func migrateRoles(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		permissions := make([]Permission, len(PermissionCatalog))
+		copy(permissions, PermissionCatalog)
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "name"}},
+			DoUpdates: clause.AssignmentColumns([]string{"description"}),
+		}).Create(&permissions).Error; err != nil {
+			return err
+		}
+
+		admin := Role{Name: RoleAdmin, Description: "Full access to the service"}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&admin).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("name = ?", RoleAdmin).First(&admin).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("name IN ?", permissionNames(PermissionCatalog)).Find(&permissions).Error; err != nil {
+			return err
+		}
+		return tx.Model(&admin).Omit("Permissions.*").Association("Permissions").Append(permissions)
+	})
+}
+
+// permissionNames devuelve los nombres de los permisos
+func permissionNames(permissions []Permission) []string {
+	names := make([]string, len(permissions))
+	for i, permission := range permissions {
+		names[i] = permission.Name
+	}
+	return names
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEncodeRoleSet(t *testing.T) {
+	tests := []struct {
+		ids     []uint
+		encoded string
+		decoded []uint
+	}{
+		{ids: nil, encoded: ""},
+		{ids: []uint{1}, encoded: "Ag", decoded: []uint{1}},
+		{ids: []uint{3, 1, 2, 1}, encoded: "Dg", decoded: []uint{1, 2, 3}},
+		{ids: []uint{0, 8, 15}, encoded: "AYE", decoded: []uint{0, 8, 15}},
+	}
+	for _, tt := range tests {
+		encoded := EncodeRoleSet(tt.ids)
+		if encoded != tt.encoded {
+			t.Errorf("EncodeRoleSet(%v) = %q, want %q", tt.ids, encoded, tt.encoded)
+		}
+		decoded, err := DecodeRoleSet(encoded)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(decoded, tt.decoded) {
+			t.Errorf("DecodeRoleSet(%q) = %v, want %v", encoded, decoded, tt.decoded)
+		}
+	}
+}
+
+func TestEncodeRoleSetSize(t *testing.T) {
+	// Los claims tienen que entrar en los 1000 bytes de Firebase aun con muchos roles
+	ids := make([]uint, 0, 100)
+	for id := uint(1); id <= 100; id++ {
+		ids = append(ids, id)
+	}
+	if encoded := EncodeRoleSet(ids); len(encoded) != 18 {
+		t.Errorf("EncodeRoleSet(1..100) has %d characters, want 18", len(encoded))
+	}
+	if encoded := EncodeRoleSet([]uint{1000}); len(encoded) > 170 {
+		t.Errorf("EncodeRoleSet(1000) has %d characters", len(encoded))
+	}
+}
+
+func TestDecodeRoleSetInvalid(t *testing.T) {
+	for _, encoded := range []string{"!!", "Ag=="} {
+		if _, err := DecodeRoleSet(encoded); err == nil {
+			t.Errorf("DecodeRoleSet(%q) accepted an invalid value", encoded)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SchemaVersion es la versión del esquema que espera este binario. Incrementarla
+// al agregar o modificar modelos en MigrateDB.
+const SchemaVersion = 11
+
+// SchemaMigration registra cada versión de esquema aplicada por MigrateDB
+type SchemaMigration struct {
+	Version   int       `json:"version" gorm:"primaryKey;autoIncrement:false"`
+	AppliedAt time.Time `json:"applied_at" gorm:"not null"`
+}
+
+// CurrentSchemaVersion devuelve la versión de esquema más alta aplicada (0 si no hay ninguna)
+func CurrentSchemaVersion(db *gorm.DB) (int, error) {
+	var version int
+	err := db.Model(&SchemaMigration{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	return version, err
+}
+
+// recordSchemaVersion marca SchemaVersion como aplicada; es idempotente entre instancias
+func recordSchemaVersion(db *gorm.DB) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&SchemaMigration{Version: SchemaVersion, AppliedAt: time.Now()}).Error
+}
@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type User struct {
@@ -24,6 +26,15 @@ type User struct {
 	Disabled        bool       `json:"disabled" gorm:"default:false"`
 	Status          string     `json:"status" gorm:"size:20;default:'active';check:status IN ('active','inactive','pending')"`
 	PkAutUseID      string     `json:"pk_aut_use_id" gorm:"size:128"`
+
+	// Formas canónicas de email y username (ver CanonicalEmail y CanonicalUsername);
+	// sus índices únicos los crea MigrateDB cuando no hay colisiones
+	EmailCanonical    *string `json:"-" gorm:"size:255"`
+	UsernameCanonical *string `json:"-" gorm:"size:50"`
+
+	// Borrado lógico: GORM excluye estas filas de las consultas hasta que el
+	// job de purga las elimina al vencer el período de gracia
+	DeletedAt gorm.DeletedAt `json:"deleted_at" gorm:"index"`
 }
 
 type CreateUserRequest struct {
@@ -52,6 +63,20 @@ type UpdateUserRequest struct {
 	Status          string  `json:"status" validate:"omitempty,oneof=active inactive pending"`
 }
 
+// AdminActionRequest es el cuerpo opcional de las acciones de /admin/users;
+// el motivo queda en la auditoría
+type AdminActionRequest struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// ImpersonateRequest pide un token para actuar como el usuario. El motivo es
+// obligatorio; el alcance por defecto es de solo lectura.
+type ImpersonateRequest struct {
+	Reason     string `json:"reason" validate:"required,min=3,max=500"`
+	Scope      string `json:"scope" validate:"omitempty,oneof=read write"`
+	TTLMinutes int    `json:"ttl_minutes" validate:"omitempty,min=1,max=60"`
+}
+
 // User Profile models - Modelos relacionados con el perfil del usuario
 type UserProfile struct {
 	ID          uint                   `json:"id" gorm:"primaryKey;autoIncrement"`
@@ -0,0 +1,127 @@
+package principal
+
+import (
+	"context"
+	"time"
+)
+
+// Claims de los tokens de suplantación, agregados al custom token con el que
+// un administrador inicia sesión como otro usuario
+const (
+	ClaimImpersonatedBy         = "impersonated_by"
+	ClaimImpersonationID        = "impersonation_id"
+	ClaimImpersonationExpiresAt = "impersonation_expires_at"
+	ClaimScope                  = "scope"
+)
+
+// Alcances de un token de suplantación
+const (
+	// ScopeRead solo permite requests de lectura (GET y HEAD)
+	ScopeRead = "read"
+	// ScopeWrite permite además modificar los datos del usuario
+	ScopeWrite = "write"
+)
+
+// ActorClientPrefix antecede al nombre del cliente de API en el actor de sus acciones
+const ActorClientPrefix = "client:"
+
+// Principal es quien hace una request autenticada: un usuario con su ID
+// token de Firebase o un servicio con su API key
+type Principal struct {
+	// UID es el Firebase UID del usuario en cuyo nombre se actúa; vacío para servicios
+	UID   string
+	Email string
+	// ClientID es el nombre del cliente de API cuando quien llama es un servicio
+	ClientID string
+	// ClientScopes son los permisos del cliente de API
+	ClientScopes []string
+	// ImpersonatedBy es el Firebase UID del administrador que suplanta al
+	// usuario; vacío si el usuario actúa por sí mismo
+	ImpersonatedBy  string
+	ImpersonationID string
+	Scope           string
+	// ExpiresAt es el fin de la suplantación, que no se extiende al refrescar el token
+	ExpiresAt time.Time
+}
+
+// IsService indica si quien llama es un servicio con una API key
+func (p Principal) IsService() bool {
+	return p.ClientID != ""
+}
+
+// HasClientScope indica si el cliente de API tiene el scope
+func (p Principal) HasClientScope(scope string) bool {
+	for _, s := range p.ClientScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Actor identifica a quien realmente actúa: el administrador en una
+// suplantación, client:<nombre> para un servicio y si no el usuario
+func (p Principal) Actor() string {
+	switch {
+	case p.IsService():
+		return ActorClientPrefix + p.ClientID
+	case p.Impersonated():
+		return p.ImpersonatedBy
+	default:
+		return p.UID
+	}
+}
+
+// Impersonated indica si un administrador actúa en nombre del usuario
+func (p Principal) Impersonated() bool {
+	return p.ImpersonatedBy != ""
+}
+
+// Expired indica si la suplantación ya terminó
+func (p Principal) Expired(now time.Time) bool {
+	return p.Impersonated() && !now.Before(p.ExpiresAt)
+}
+
+// Allows indica si el alcance de la suplantación permite el método HTTP
+func (p Principal) Allows(method string) bool {
+	if !p.Impersonated() || p.Scope == ScopeWrite {
+		return true
+	}
+	return method == "GET" || method == "HEAD"
+}
+
+// FromClaims arma el principal de un token verificado. Un token de
+// suplantación sin vencimiento o con un alcance desconocido queda vencido y
+// de solo lectura.
+func FromClaims(uid string, claims map[string]interface{}) Principal {
+	p := Principal{UID: uid}
+	p.Email, _ = claims["email"].(string)
+	p.ImpersonatedBy, _ = claims[ClaimImpersonatedBy].(string)
+	if !p.Impersonated() {
+		return p
+	}
+
+	p.ImpersonationID, _ = claims[ClaimImpersonationID].(string)
+	p.Scope = ScopeRead
+	if scope, _ := claims[ClaimScope].(string); scope == ScopeWrite {
+		p.Scope = ScopeWrite
+	}
+	// Los números de los claims llegan como float64 al decodificar el JWT
+	if expiresAt, ok := claims[ClaimImpersonationExpiresAt].(float64); ok {
+		p.ExpiresAt = time.Unix(int64(expiresAt), 0)
+	}
+	return p
+}
+
+type contextKey struct{}
+
+// With devuelve un contexto que transporta el principal
+func With(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext obtiene el principal de la request autenticada
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(Principal)
+	return p, ok
+}
@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"it-app_user/internal/models"
+)
+
+type AccountDeletionRepository struct {
+	db *gorm.DB
+}
+
+// NewAccountDeletionRepository crea una nueva instancia del repositorio de solicitudes de eliminación de cuenta
+func NewAccountDeletionRepository(db *gorm.DB) AccountDeletionRepositoryInterface {
+	return &AccountDeletionRepository{db: db}
+}
+
+// Create crea una nueva solicitud
+func (r *AccountDeletionRepository) Create(ctx context.Context, request *models.AccountDeletionRequest) error {
+	return r.db.WithContext(ctx).Create(request).Error
+}
+
+// GetLatestByUserID obtiene la solicitud más reciente de un usuario
+func (r *AccountDeletionRepository) GetLatestByUserID(ctx context.Context, userID uint) (*models.AccountDeletionRequest, error) {
+	var request models.AccountDeletionRequest
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id DESC").First(&request).Error
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// Update actualiza una solicitud existente
+func (r *AccountDeletionRepository) Update(ctx context.Context, request *models.AccountDeletionRequest) error {
+	return r.db.WithContext(ctx).Save(request).Error
+}
+
+// ClaimAttempt consume un intento de confirmación si quedan menos de max. El
+// UPDATE condicional es atómico, así que requests concurrentes no superan el
+// límite. Devuelve false si los intentos ya se agotaron.
+func (r *AccountDeletionRepository) ClaimAttempt(ctx context.Context, id uint, max int) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.AccountDeletionRequest{}).
+		Where("id = ? AND attempts < ?", id, max).
+		UpdateColumn("attempts", gorm.Expr("attempts + 1"))
+	return result.RowsAffected == 1, result.Error
+}
+
+// ListDue obtiene las eliminaciones confirmadas cuya ventana de cancelación
+// venció antes de now y las que quedaron abandonadas en curso
+func (r *AccountDeletionRepository) ListDue(ctx context.Context, now, staleBefore time.Time, limit int) ([]models.AccountDeletionRequest, error) {
+	var requests []models.AccountDeletionRequest
+	err := r.db.WithContext(ctx).
+		Where("(status = ? AND scheduled_for <= ?) OR (status = ? AND started_at < ?)",
+			models.AccountDeletionPending, now, models.AccountDeletionInProgress, staleBefore).
+		Order("scheduled_for").
+		Limit(limit).
+		Find(&requests).Error
+	return requests, err
+}
+
+// ClaimDue marca la eliminación como en curso si sigue vencida y sin
+// cancelar (o quedó abandonada). Devuelve false si el usuario la canceló u
+// otra instancia ya la tomó.
+func (r *AccountDeletionRepository) ClaimDue(ctx context.Context, id uint, now, staleBefore time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.AccountDeletionRequest{}).
+		Where("id = ? AND ((status = ? AND scheduled_for <= ?) OR (status = ? AND started_at < ?))",
+			id, models.AccountDeletionPending, now, models.AccountDeletionInProgress, staleBefore).
+		Updates(map[string]interface{}{
+			"status":     models.AccountDeletionInProgress,
+			"started_at": now,
+			"updated_at": now,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// Release devuelve una eliminación en curso a pendiente para reintentarla
+func (r *AccountDeletionRepository) Release(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&models.AccountDeletionRequest{}).
+		Where("id = ? AND status = ?", id, models.AccountDeletionInProgress).
+		Updates(map[string]interface{}{
+			"status":     models.AccountDeletionPending,
+			"started_at": nil,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// Cancel cancela la eliminación si sigue pendiente y su ventana de
+// cancelación no venció. Devuelve false si el job ya puede tomarla.
+func (r *AccountDeletionRepository) Cancel(ctx context.Context, id uint, now time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.AccountDeletionRequest{}).
+		Where("id = ? AND status = ? AND scheduled_for > ?", id, models.AccountDeletionPending, now).
+		Updates(map[string]interface{}{
+			"status":       models.AccountDeletionCancelled,
+			"cancelled_at": now,
+			"updated_at":   now,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// DeleteByUserID elimina todas las solicitudes de un usuario
+func (r *AccountDeletionRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.AccountDeletionRequest{}).Error
+}
@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"it-app_user/internal/models"
+)
+
+type APIClientRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIClientRepository crea una nueva instancia del repositorio de clientes de API
+func NewAPIClientRepository(db *gorm.DB) APIClientRepositoryInterface {
+	return &APIClientRepository{db: db}
+}
+
+// List obtiene todos los clientes con sus keys, ordenados por nombre
+func (r *APIClientRepository) List(ctx context.Context) ([]models.APIClient, error) {
+	var clients []models.APIClient
+	err := r.db.WithContext(ctx).Preload("Keys", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Order("name").Find(&clients).Error
+	return clients, err
+}
+
+// GetByID obtiene un cliente con sus keys
+func (r *APIClientRepository) GetByID(ctx context.Context, id uint) (*models.APIClient, error) {
+	var client models.APIClient
+	err := r.db.WithContext(ctx).Preload("Keys", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).First(&client, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+// GetKeyByPrefix obtiene la key con ese prefijo y su cliente (sin sus keys)
+func (r *APIClientRepository) GetKeyByPrefix(ctx context.Context, prefix string) (*models.APIClientKey, *models.APIClient, error) {
+	db := r.db.WithContext(ctx)
+	var key models.APIClientKey
+	if err := db.Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		return nil, nil, err
+	}
+	var client models.APIClient
+	if err := db.First(&client, key.APIClientID).Error; err != nil {
+		return nil, nil, err
+	}
+	return &key, &client, nil
+}
+
+// Create crea el cliente junto con sus keys
+func (r *APIClientRepository) Create(ctx context.Context, client *models.APIClient) error {
+	return r.db.WithContext(ctx).Create(client).Error
+}
+
+// Update guarda la descripción, los scopes y la revocación del cliente
+func (r *APIClientRepository) Update(ctx context.Context, client *models.APIClient) error {
+	return r.db.WithContext(ctx).Model(client).Select("description", "scopes", "revoked_at", "updated_at").Updates(client).Error
+}
+
+// CreateKey agrega una key al cliente
+func (r *APIClientRepository) CreateKey(ctx context.Context, key *models.APIClientKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+// ExpireKeys adelanta a expiresAt el vencimiento de las keys del cliente que
+// vencen después (o nunca), salvo la key exceptID
+func (r *APIClientRepository) ExpireKeys(ctx context.Context, clientID uint, expiresAt time.Time, exceptID uint) error {
+	return r.db.WithContext(ctx).Model(&models.APIClientKey{}).
+		Where("api_client_id = ? AND id <> ?", clientID, exceptID).
+		Where("expires_at IS NULL OR expires_at > ?", expiresAt).
+		Update("expires_at", expiresAt).Error
+}
+
+// Touch registra el uso de la key y de su cliente
+func (r *APIClientRepository) Touch(ctx context.Context, keyID, clientID uint, usedAt time.Time) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Model(&models.APIClientKey{}).Where("id = ?", keyID).Update("last_used_at", usedAt).Error; err != nil {
+		return err
+	}
+	return db.Model(&models.APIClient{}).Where("id = ?", clientID).UpdateColumn("last_used_at", usedAt).Error
+}
@@ -0,0 +1,183 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"it-app_user/internal/models"
+)
+
+// auditChainLockID es la clave del advisory lock que serializa los appends
+// de todas las instancias, para que cada evento encadene el hash del último
+const auditChainLockID = 7_236_001
+
+// AuditEventFilter son los filtros de la consulta del log de auditoría.
+// Action acepta un prefijo terminado en "*" (p. ej. "user.*"). Con
+// AnyTarget basta que coincida TargetUserID o TargetFirebaseID, para incluir
+// los eventos que registran solo uno de los dos.
+type AuditEventFilter struct {
+	Action           string
+	ActorID          string
+	TargetUserID     uint
+	TargetFirebaseID string
+	AnyTarget        bool
+	RequestID        string
+	From             time.Time
+	To               time.Time
+	Limit            int
+	Offset           int
+}
+
+type AuditEventRepository struct {
+	db *gorm.DB
+}
+
+// NewAuditEventRepository crea una nueva instancia del repositorio del log de auditoría
+func NewAuditEventRepository(db *gorm.DB) AuditEventRepositoryInterface {
+	return &AuditEventRepository{db: db}
+}
+
+// Append agrega el evento al final de la cadena: completa PrevHash con el
+// hash del último evento y calcula su Hash. Usa su propia transacción, así
+// que el evento queda registrado aunque la transacción de ctx se revierta.
+func (r *AuditEventRepository) Append(ctx context.Context, event *models.AuditEvent) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockAuditChain(tx); err != nil {
+			return err
+		}
+		return appendAuditEvent(tx, event)
+	})
+}
+
+// List obtiene los eventos que cumplen el filtro, del más reciente al más antiguo
+func (r *AuditEventRepository) List(ctx context.Context, filter AuditEventFilter) ([]models.AuditEvent, error) {
+	query := r.db.WithContext(ctx).Model(&models.AuditEvent{})
+	if filter.Action != "" {
+		if prefix, ok := strings.CutSuffix(filter.Action, "*"); ok {
+			query = query.Where(`action LIKE ? ESCAPE '\'`, escapeLike(prefix)+"%")
+		} else {
+			query = query.Where("action = ?", filter.Action)
+		}
+	}
+	if filter.ActorID != "" {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.AnyTarget && filter.TargetUserID != 0 && filter.TargetFirebaseID != "" {
+		query = query.Where("target_user_id = ? OR target_firebase_id = ?", filter.TargetUserID, filter.TargetFirebaseID)
+	} else {
+		if filter.TargetUserID != 0 {
+			query = query.Where("target_user_id = ?", filter.TargetUserID)
+		}
+		if filter.TargetFirebaseID != "" {
+			query = query.Where("target_firebase_id = ?", filter.TargetFirebaseID)
+		}
+	}
+	if filter.RequestID != "" {
+		query = query.Where("request_id = ?", filter.RequestID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("occurred_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("occurred_at < ?", filter.To)
+	}
+
+	var events []models.AuditEvent
+	err := query.Order("id DESC").Limit(filter.Limit).Offset(filter.Offset).Find(&events).Error
+	return events, err
+}
+
+// ListAfter obtiene hasta limit eventos con ID mayor que afterID, en orden de la cadena
+func (r *AuditEventRepository) ListAfter(ctx context.Context, afterID uint, limit int) ([]models.AuditEvent, error) {
+	var events []models.AuditEvent
+	err := r.db.WithContext(ctx).Where("id > ?", afterID).Order("id").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+// GetLatestByAction obtiene el evento más reciente con la acción dada
+func (r *AuditEventRepository) GetLatestByAction(ctx context.Context, action string) (*models.AuditEvent, error) {
+	var event models.AuditEvent
+	err := r.db.WithContext(ctx).Where("action = ?", action).Order("id DESC").First(&event).Error
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// DeleteBefore elimina los eventos anteriores a before y, en la misma
+// transacción, agrega marker al final de la cadena con anchor_id, anchor_hash
+// y deleted en su Metadata. Borra siempre un prefijo de la cadena (todos los
+// IDs hasta el último evento vencido), así que anchor_hash es el prev_hash
+// que debe tener el primer evento que queda. Si no hay nada que borrar no
+// agrega marker y devuelve 0.
+func (r *AuditEventRepository) DeleteBefore(ctx context.Context, before time.Time, marker *models.AuditEvent) (int64, error) {
+	var deleted int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockAuditChain(tx); err != nil {
+			return err
+		}
+
+		var last models.AuditEvent
+		err := tx.Where("occurred_at < ?", before).Order("id DESC").Take(&last).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// El trigger append-only solo permite DELETE con esta variable activa en la transacción
+		if err := tx.Exec("SELECT set_config('audit.allow_prune', 'on', true)").Error; err != nil {
+			return err
+		}
+		result := tx.Where("id <= ?", last.ID).Delete(&models.AuditEvent{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected
+
+		if marker.Metadata == nil {
+			marker.Metadata = make(map[string]interface{})
+		}
+		marker.Metadata["anchor_id"] = last.ID
+		marker.Metadata["anchor_hash"] = last.Hash
+		marker.Metadata["deleted"] = deleted
+		return appendAuditEvent(tx, marker)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// lockAuditChain toma el advisory lock de la cadena hasta el final de la transacción
+func lockAuditChain(tx *gorm.DB) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(?)", auditChainLockID).Error
+}
+
+// appendAuditEvent encadena e inserta el evento; requiere el lock de la cadena
+func appendAuditEvent(tx *gorm.DB, event *models.AuditEvent) error {
+	event.OccurredAt = event.OccurredAt.Truncate(time.Microsecond)
+
+	var last models.AuditEvent
+	err := tx.Select("hash").Order("id DESC").Take(&last).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		event.PrevHash = models.AuditGenesisHash
+	case err != nil:
+		return err
+	default:
+		event.PrevHash = last.Hash
+	}
+
+	hash, err := event.ComputeHash()
+	if err != nil {
+		return err
+	}
+	event.Hash = hash
+	return tx.Create(event).Error
+}
@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"it-app_user/internal/dbtest"
+	"it-app_user/internal/models"
+)
+
+func TestAppendChainsToLastEvent(t *testing.T) {
+	lastHash := "5f2c6a0d0f1f5f3b8c2f4a1e9d7b6c5a4f3e2d1c0b9a8f7e6d5c4b3a29180706"
+	db, mock := dbtest.NewMockDB(t)
+	mock.ExpectBegin()
+	// El lock se toma antes de leer el último hash
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).
+		WithArgs(auditChainLockID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT "hash" FROM "audit_events" ORDER BY id DESC LIMIT 1`).
+		WillReturnRows(sqlmock.NewRows([]string{"hash"}).AddRow(lastHash))
+	mock.ExpectQuery(`INSERT INTO "audit_events"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(43))
+	mock.ExpectCommit()
+
+	event := &models.AuditEvent{
+		OccurredAt: time.Date(2026, 3, 1, 12, 0, 0, 123456789, time.UTC),
+		Action:     "user.updated",
+		ActorID:    "uid-admin",
+	}
+	if err := NewAuditEventRepository(db).Append(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+
+	if event.PrevHash != lastHash {
+		t.Errorf("PrevHash = %q, want the last event's hash", event.PrevHash)
+	}
+	if event.OccurredAt.Nanosecond()%1000 != 0 {
+		t.Errorf("OccurredAt = %v, want it truncated to microseconds", event.OccurredAt)
+	}
+	want, err := event.ComputeHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Hash != want {
+		t.Errorf("Hash = %q, want %q", event.Hash, want)
+	}
+}
+
+func TestAppendStartsAtGenesis(t *testing.T) {
+	db, mock := dbtest.NewMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT "hash" FROM "audit_events"`).
+		WillReturnRows(sqlmock.NewRows([]string{"hash"}))
+	mock.ExpectQuery(`INSERT INTO "audit_events"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	event := &models.AuditEvent{OccurredAt: time.Now(), Action: "user.created", ActorID: "system"}
+	if err := NewAuditEventRepository(db).Append(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+	if event.PrevHash != models.AuditGenesisHash {
+		t.Errorf("PrevHash = %q, want the genesis hash", event.PrevHash)
+	}
+}
+
+func TestAppendDoesNotInsertWithoutTheLock(t *testing.T) {
+	db, mock := dbtest.NewMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WillReturnError(sqlStateError(sqlStateDeadlockDetected))
+	mock.ExpectRollback()
+
+	event := &models.AuditEvent{OccurredAt: time.Now(), Action: "user.created", ActorID: "system"}
+	if err := NewAuditEventRepository(db).Append(context.Background(), event); err == nil {
+		t.Fatal("Append succeeded without the chain lock")
+	}
+}
+
+func TestDeleteBeforeAnchorsMarker(t *testing.T) {
+	lastHash := "a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3d4e5f60718293a4b5c6d7e8f90"
+	db, mock := dbtest.NewMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT \* FROM "audit_events" WHERE occurred_at < \$1 ORDER BY id DESC LIMIT 1`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "hash"}).AddRow(40, lastHash))
+	mock.ExpectExec(`SELECT set_config\('audit.allow_prune', 'on', true\)`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM "audit_events" WHERE id <= \$1`).
+		WithArgs(40).
+		WillReturnResult(sqlmock.NewResult(0, 40))
+	mock.ExpectQuery(`SELECT "hash" FROM "audit_events"`).
+		WillReturnRows(sqlmock.NewRows([]string{"hash"}).AddRow("f0e1d2c3b4a5968778695a4b3c2d1e0ff0e1d2c3b4a5968778695a4b3c2d1e0f"))
+	mock.ExpectQuery(`INSERT INTO "audit_events"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(61))
+	mock.ExpectCommit()
+
+	marker := &models.AuditEvent{OccurredAt: time.Now(), Action: "audit.pruned", ActorID: "system"}
+	deleted, err := NewAuditEventRepository(db).DeleteBefore(context.Background(), time.Now(), marker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 40 {
+		t.Errorf("deleted = %d, want 40", deleted)
+	}
+	if marker.Metadata["anchor_id"] != uint(40) || marker.Metadata["anchor_hash"] != lastHash {
+		t.Errorf("marker metadata = %v, want anchor 40 with the last deleted hash", marker.Metadata)
+	}
+}
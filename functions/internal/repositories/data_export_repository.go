@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"it-app_user/internal/models"
+)
+
+type DataExportRepository struct {
+	db *gorm.DB
+}
+
+// NewDataExportRepository crea una nueva instancia del repositorio de exportaciones de datos
+func NewDataExportRepository(db *gorm.DB) DataExportRepositoryInterface {
+	return &DataExportRepository{db: db}
+}
+
+// Create crea una nueva exportación
+func (r *DataExportRepository) Create(ctx context.Context, export *models.DataExport) error {
+	return r.db.WithContext(ctx).Create(export).Error
+}
+
+// GetByID obtiene una exportación por su ID
+func (r *DataExportRepository) GetByID(ctx context.Context, id uint) (*models.DataExport, error) {
+	var export models.DataExport
+	err := r.db.WithContext(ctx).First(&export, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// GetLatestByUserID obtiene la exportación más reciente de un usuario
+func (r *DataExportRepository) GetLatestByUserID(ctx context.Context, userID uint) (*models.DataExport, error) {
+	var export models.DataExport
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id DESC").First(&export).Error
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// ListByUserID obtiene todas las exportaciones de un usuario
+func (r *DataExportRepository) ListByUserID(ctx context.Context, userID uint) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id").Find(&exports).Error
+	return exports, err
+}
+
+// ListClaimable obtiene exportaciones pendientes o cuyo procesamiento empezó
+// antes de staleBefore (la instancia que las tomó probablemente murió)
+func (r *DataExportRepository) ListClaimable(ctx context.Context, staleBefore time.Time, limit int) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	err := r.db.WithContext(ctx).
+		Where("status = ? OR (status = ? AND started_at < ?)", models.DataExportPending, models.DataExportProcessing, staleBefore).
+		Order("id").
+		Limit(limit).
+		Find(&exports).Error
+	return exports, err
+}
+
+// Claim marca la exportación como en proceso si sigue disponible (pendiente o
+// abandonada). Devuelve false si otra instancia ya la tomó.
+func (r *DataExportRepository) Claim(ctx context.Context, id uint, staleBefore time.Time) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&models.DataExport{}).
+		Where("id = ? AND (status = ? OR (status = ? AND started_at < ?))", id, models.DataExportPending, models.DataExportProcessing, staleBefore).
+		Updates(map[string]interface{}{
+			"status":     models.DataExportProcessing,
+			"started_at": now,
+			"attempts":   gorm.Expr("attempts + 1"),
+			"updated_at": now,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// Update actualiza una exportación existente
+func (r *DataExportRepository) Update(ctx context.Context, export *models.DataExport) error {
+	return r.db.WithContext(ctx).Save(export).Error
+}
+
+// ListExpired obtiene exportaciones completadas cuyo archivo venció antes de now
+func (r *DataExportRepository) ListExpired(ctx context.Context, now time.Time, limit int) ([]models.DataExport, error) {
+	var exports []models.DataExport
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND expires_at < ?", models.DataExportCompleted, now).
+		Order("id").
+		Limit(limit).
+		Find(&exports).Error
+	return exports, err
+}
+
+// DeleteByUserID elimina todas las exportaciones de un usuario
+func (r *DataExportRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.DataExport{}).Error
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"time"
 	"gorm.io/gorm"
 	"it-app_user/internal/models"
@@ -16,19 +17,25 @@ func NewEmailVerificationRepository(db *gorm.DB) EmailVerificationRepositoryInte
 }
 
 // GetByUserID obtiene la verificación de email por ID de usuario
-func (r *EmailVerificationRepository) GetByUserID(userID uint) (*models.EmailVerification, error) {
+func (r *EmailVerificationRepository) GetByUserID(ctx context.Context, userID uint) (*models.EmailVerification, error) {
 	var verification models.EmailVerification
-	err := r.db.Where("user_id = ?", userID).First(&verification).Error
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&verification).Error
 	if err != nil {
 		return nil, err
 	}
 	return &verification, nil
 }
 
-// GetByEmail obtiene la verificación de email por email
-func (r *EmailVerificationRepository) GetByEmail(email string) (*models.EmailVerification, error) {
+// GetByEmail obtiene la última verificación del usuario con ese email. Se
+// busca por el email canónico del usuario, como GetByEmail de usuarios, así
+// que "Bob@x.com" encuentra la verificación de "bob@x.com".
+func (r *EmailVerificationRepository) GetByEmail(ctx context.Context, email string) (*models.EmailVerification, error) {
 	var verification models.EmailVerification
-	err := r.db.Where("email = ?", email).First(&verification).Error
+	err := r.db.WithContext(ctx).
+		Joins("JOIN users ON users.id = email_verifications.user_id AND users.deleted_at IS NULL").
+		Where("users.email_canonical = ?", models.CanonicalEmail(email)).
+		Order("email_verifications.id DESC").
+		First(&verification).Error
 	if err != nil {
 		return nil, err
 	}
@@ -36,9 +43,9 @@ func (r *EmailVerificationRepository) GetByEmail(email string) (*models.EmailVer
 }
 
 // GetByFirebaseID obtiene la verificación de email por Firebase ID
-func (r *EmailVerificationRepository) GetByFirebaseID(firebaseID string) (*models.EmailVerification, error) {
+func (r *EmailVerificationRepository) GetByFirebaseID(ctx context.Context, firebaseID string) (*models.EmailVerification, error) {
 	var verification models.EmailVerification
-	err := r.db.Where("firebase_id = ?", firebaseID).First(&verification).Error
+	err := r.db.WithContext(ctx).Where("firebase_id = ?", firebaseID).First(&verification).Error
 	if err != nil {
 		return nil, err
 	}
@@ -46,22 +53,34 @@ func (r *EmailVerificationRepository) GetByFirebaseID(firebaseID string) (*model
 }
 
 // Create crea una nueva verificación de email
-func (r *EmailVerificationRepository) Create(verification *models.EmailVerification) error {
-	return r.db.Create(verification).Error
+func (r *EmailVerificationRepository) Create(ctx context.Context, verification *models.EmailVerification) error {
+	return r.db.WithContext(ctx).Create(verification).Error
 }
 
 // Update actualiza una verificación de email existente
-func (r *EmailVerificationRepository) Update(verification *models.EmailVerification) error {
-	return r.db.Save(verification).Error
+func (r *EmailVerificationRepository) Update(ctx context.Context, verification *models.EmailVerification) error {
+	return r.db.WithContext(ctx).Save(verification).Error
 }
 
 // Delete elimina una verificación de email por su ID
-func (r *EmailVerificationRepository) Delete(id uint) error {
-	return r.db.Delete(&models.EmailVerification{}, id).Error
+func (r *EmailVerificationRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.EmailVerification{}, id).Error
+}
+
+// DeleteByUserID elimina todas las verificaciones de un usuario
+func (r *EmailVerificationRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.EmailVerification{}).Error
+}
+
+// ListByUserID obtiene el historial de verificaciones de un usuario
+func (r *EmailVerificationRepository) ListByUserID(ctx context.Context, userID uint) ([]models.EmailVerification, error) {
+	var verifications []models.EmailVerification
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id").Find(&verifications).Error
+	return verifications, err
 }
 
 // MarkAsVerified marca un email como verificado
-func (r *EmailVerificationRepository) MarkAsVerified(userID uint) error {
+func (r *EmailVerificationRepository) MarkAsVerified(ctx context.Context, userID uint) error {
 	now := time.Now()
 	updates := map[string]interface{}{
 		"is_verified":  true,
@@ -69,11 +88,11 @@ func (r *EmailVerificationRepository) MarkAsVerified(userID uint) error {
 		"updated_at":   now,
 	}
 	
-	return r.db.Model(&models.EmailVerification{}).Where("user_id = ?", userID).Updates(updates).Error
+	return r.db.WithContext(ctx).Model(&models.EmailVerification{}).Where("user_id = ?", userID).Updates(updates).Error
 }
 
 // IncrementAttempts incrementa el contador de intentos de verificación
-func (r *EmailVerificationRepository) IncrementAttempts(userID uint) error {
+func (r *EmailVerificationRepository) IncrementAttempts(ctx context.Context, userID uint) error {
 	now := time.Now()
 	updates := map[string]interface{}{
 		"attempts_count":  gorm.Expr("attempts_count + 1"),
@@ -81,12 +100,12 @@ func (r *EmailVerificationRepository) IncrementAttempts(userID uint) error {
 		"updated_at":      now,
 	}
 	
-	return r.db.Model(&models.EmailVerification{}).Where("user_id = ?", userID).Updates(updates).Error
+	return r.db.WithContext(ctx).Model(&models.EmailVerification{}).Where("user_id = ?", userID).Updates(updates).Error
 }
 
 // GetPendingVerifications obtiene todas las verificaciones pendientes
-func (r *EmailVerificationRepository) GetPendingVerifications() ([]models.EmailVerification, error) {
+func (r *EmailVerificationRepository) GetPendingVerifications(ctx context.Context) ([]models.EmailVerification, error) {
 	var verifications []models.EmailVerification
-	err := r.db.Where("is_verified = ?", false).Find(&verifications).Error
+	err := r.db.WithContext(ctx).Where("is_verified = ?", false).Find(&verifications).Error
 	return verifications, err
 }
\ No newline at end of file
@@ -0,0 +1,25 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"it-app_user/internal/dbtest"
+)
+
+func TestEmailVerificationGetByEmailUsesCanonicalEmail(t *testing.T) {
+	db, mock := dbtest.NewMockDB(t)
+	mock.ExpectQuery(`SELECT "email_verifications"\."id",.* FROM "email_verifications" JOIN users ON users.id = email_verifications.user_id AND users.deleted_at IS NULL WHERE users.email_canonical = \$1 ORDER BY email_verifications.id DESC`).
+		WithArgs("bob@x.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "email"}).AddRow(3, 7, "bob@x.com"))
+
+	verification, err := NewEmailVerificationRepository(db).GetByEmail(context.Background(), " Bob@X.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verification.UserID != 7 {
+		t.Errorf("user_id = %d, want 7", verification.UserID)
+	}
+}
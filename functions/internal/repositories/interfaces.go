@@ -1,77 +1,186 @@
 package repositories
 
-import "it-app_user/internal/models"
+import (
+	"context"
+	"time"
+
+	"it-app_user/internal/models"
+)
 
 // UserRepositoryInterface define los métodos para el repositorio de usuarios
 type UserRepositoryInterface interface {
 	// CRUD básico
-	GetByID(id uint) (*models.User, error)
-	GetByEmail(email string) (*models.User, error)
-	GetByFirebaseID(firebaseID string) (*models.User, error)
-	GetByUsername(username string) (*models.User, error)
-	GetAll(limit, offset int) ([]models.User, error)
-	Create(user *models.User) error
-	Update(user *models.User) error
-	Delete(id uint) error
+	GetByID(ctx context.Context, id uint) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	GetByFirebaseID(ctx context.Context, firebaseID string) (*models.User, error)
+	GetByUsername(ctx context.Context, username string) (*models.User, error)
+	ExistingUsernames(ctx context.Context, usernames []string) ([]string, error)
+	GetAll(ctx context.Context, limit, offset int) ([]models.User, error)
+	Create(ctx context.Context, user *models.User) error
+	CreateIfNotExists(ctx context.Context, user *models.User) (bool, error)
+	Update(ctx context.Context, user *models.User) error
+	Delete(ctx context.Context, id uint) error
+
+	// Borrado lógico
+	GetDeletedByID(ctx context.Context, id uint) (*models.User, error)
+	GetDeletedByFirebaseID(ctx context.Context, firebaseID string) (*models.User, error)
+	Restore(ctx context.Context, id uint) error
+	ListDeletedBefore(ctx context.Context, before time.Time, limit int) ([]models.User, error)
+	Purge(ctx context.Context, id uint) error
 	
 	// Métodos específicos
-	UpdateLoginInfo(id uint, loginIP, loginDevice string) error
-	GetActiveUsers() ([]models.User, error)
-	SearchUsers(query string, limit, offset int) ([]models.User, error)
-	CountUsers() (int64, error)
+	UpdateLoginInfo(ctx context.Context, id uint, loginIP, loginDevice string) error
+	GetActiveUsers(ctx context.Context) ([]models.User, error)
+	SearchUsers(ctx context.Context, query string, limit, offset int) ([]models.User, error)
+	CountUsers(ctx context.Context) (int64, error)
+
+	// Administración
+	List(ctx context.Context, filter UserFilter) ([]models.User, int64, error)
+
+	// Reconciliación con Firebase
+	ListByFirebaseIDs(ctx context.Context, firebaseIDs []string) ([]models.User, error)
+	ListAfterID(ctx context.Context, afterID uint, limit int) ([]models.User, error)
 }
 
 // EmailVerificationRepositoryInterface define los métodos para verificación de email
 type EmailVerificationRepositoryInterface interface {
-	GetByUserID(userID uint) (*models.EmailVerification, error)
-	GetByEmail(email string) (*models.EmailVerification, error)
-	GetByFirebaseID(firebaseID string) (*models.EmailVerification, error)
-	Create(verification *models.EmailVerification) error
-	Update(verification *models.EmailVerification) error
-	Delete(id uint) error
-	MarkAsVerified(userID uint) error
-	IncrementAttempts(userID uint) error
-	GetPendingVerifications() ([]models.EmailVerification, error)
+	GetByUserID(ctx context.Context, userID uint) (*models.EmailVerification, error)
+	GetByEmail(ctx context.Context, email string) (*models.EmailVerification, error)
+	GetByFirebaseID(ctx context.Context, firebaseID string) (*models.EmailVerification, error)
+	Create(ctx context.Context, verification *models.EmailVerification) error
+	Update(ctx context.Context, verification *models.EmailVerification) error
+	Delete(ctx context.Context, id uint) error
+	DeleteByUserID(ctx context.Context, userID uint) error
+	ListByUserID(ctx context.Context, userID uint) ([]models.EmailVerification, error)
+	MarkAsVerified(ctx context.Context, userID uint) error
+	IncrementAttempts(ctx context.Context, userID uint) error
+	GetPendingVerifications(ctx context.Context) ([]models.EmailVerification, error)
 }
 
 // PasswordResetRepositoryInterface define los métodos para reset de contraseña
 type PasswordResetRepositoryInterface interface {
-	GetByToken(token string) (*models.PasswordResetToken, error)
-	GetByCode(code string) (*models.PasswordResetToken, error)
-	GetByUserID(userID uint) (*models.PasswordResetToken, error)
-	Create(resetToken *models.PasswordResetToken) error
-	Update(resetToken *models.PasswordResetToken) error
-	Delete(id uint) error
-	MarkAsUsed(id uint) error
-	CleanExpiredTokens() error
+	GetByToken(ctx context.Context, token string) (*models.PasswordResetToken, error)
+	GetByCode(ctx context.Context, code string) (*models.PasswordResetToken, error)
+	GetByUserID(ctx context.Context, userID uint) (*models.PasswordResetToken, error)
+	Create(ctx context.Context, resetToken *models.PasswordResetToken) error
+	Update(ctx context.Context, resetToken *models.PasswordResetToken) error
+	Delete(ctx context.Context, id uint) error
+	DeleteByUserID(ctx context.Context, userID uint) error
+	ListByUserID(ctx context.Context, userID uint) ([]models.PasswordResetToken, error)
+	MarkAsUsed(ctx context.Context, id uint) error
+	CleanExpiredTokens(ctx context.Context) error
 }
 
 // UserProfileRepositoryInterface define los métodos para perfiles de usuario
 type UserProfileRepositoryInterface interface {
-	GetByUserID(userID uint) (*models.UserProfile, error)
-	Create(profile *models.UserProfile) error
-	Update(profile *models.UserProfile) error
-	Delete(userID uint) error
-	UpdateAvatar(userID uint, avatarURL string) error
+	GetByUserID(ctx context.Context, userID uint) (*models.UserProfile, error)
+	Create(ctx context.Context, profile *models.UserProfile) error
+	Update(ctx context.Context, profile *models.UserProfile) error
+	Delete(ctx context.Context, userID uint) error
+	UpdateAvatar(ctx context.Context, userID uint, avatarURL string) error
 }
 
 // UserSettingsRepositoryInterface define los métodos para configuraciones de usuario
 type UserSettingsRepositoryInterface interface {
-	GetByUserID(userID uint) (*models.UserSettings, error)
-	Create(settings *models.UserSettings) error
-	Update(settings *models.UserSettings) error
-	Delete(userID uint) error
-	UpdateLanguage(userID uint, language string) error
-	UpdateTheme(userID uint, theme string) error
+	GetByUserID(ctx context.Context, userID uint) (*models.UserSettings, error)
+	GetByFirebaseID(ctx context.Context, firebaseID string) (*models.UserSettings, error)
+	Create(ctx context.Context, settings *models.UserSettings) error
+	Update(ctx context.Context, settings *models.UserSettings) error
+	Delete(ctx context.Context, userID uint) error
+	UpdateLanguage(ctx context.Context, userID uint, language string) error
+	UpdateTheme(ctx context.Context, userID uint, theme string) error
 }
 
 // UserStatsRepositoryInterface define los métodos para estadísticas de usuario
 type UserStatsRepositoryInterface interface {
-	GetByUserID(userID uint) (*models.UserStats, error)
-	Create(stats *models.UserStats) error
-	Update(stats *models.UserStats) error
-	Delete(userID uint) error
-	IncrementLoginCount(userID uint) error
-	IncrementProfileViews(userID uint) error
-	UpdateLastActive(userID uint) error
-}
\ No newline at end of file
+	GetByUserID(ctx context.Context, userID uint) (*models.UserStats, error)
+	Create(ctx context.Context, stats *models.UserStats) error
+	Update(ctx context.Context, stats *models.UserStats) error
+	Delete(ctx context.Context, userID uint) error
+	IncrementLoginCount(ctx context.Context, userID uint) error
+	IncrementProfileViews(ctx context.Context, userID uint) error
+	UpdateLastActive(ctx context.Context, userID uint) error
+}
+
+// DataExportRepositoryInterface define los métodos para exportaciones de datos personales
+type DataExportRepositoryInterface interface {
+	Create(ctx context.Context, export *models.DataExport) error
+	GetByID(ctx context.Context, id uint) (*models.DataExport, error)
+	GetLatestByUserID(ctx context.Context, userID uint) (*models.DataExport, error)
+	ListByUserID(ctx context.Context, userID uint) ([]models.DataExport, error)
+	ListClaimable(ctx context.Context, staleBefore time.Time, limit int) ([]models.DataExport, error)
+	Claim(ctx context.Context, id uint, staleBefore time.Time) (bool, error)
+	Update(ctx context.Context, export *models.DataExport) error
+	ListExpired(ctx context.Context, now time.Time, limit int) ([]models.DataExport, error)
+	DeleteByUserID(ctx context.Context, userID uint) error
+}
+
+// AccountDeletionRepositoryInterface define los métodos para solicitudes de eliminación de cuenta
+type AccountDeletionRepositoryInterface interface {
+	Create(ctx context.Context, request *models.AccountDeletionRequest) error
+	GetLatestByUserID(ctx context.Context, userID uint) (*models.AccountDeletionRequest, error)
+	Update(ctx context.Context, request *models.AccountDeletionRequest) error
+	ClaimAttempt(ctx context.Context, id uint, max int) (bool, error)
+	ListDue(ctx context.Context, now, staleBefore time.Time, limit int) ([]models.AccountDeletionRequest, error)
+	ClaimDue(ctx context.Context, id uint, now, staleBefore time.Time) (bool, error)
+	Release(ctx context.Context, id uint) error
+	Cancel(ctx context.Context, id uint, now time.Time) (bool, error)
+	DeleteByUserID(ctx context.Context, userID uint) error
+}
+
+// AuditEventRepositoryInterface define los métodos del log de auditoría. Es
+// append-only: no hay métodos para modificar eventos y solo la retención borra.
+type AuditEventRepositoryInterface interface {
+	Append(ctx context.Context, event *models.AuditEvent) error
+	List(ctx context.Context, filter AuditEventFilter) ([]models.AuditEvent, error)
+	ListAfter(ctx context.Context, afterID uint, limit int) ([]models.AuditEvent, error)
+	GetLatestByAction(ctx context.Context, action string) (*models.AuditEvent, error)
+	DeleteBefore(ctx context.Context, before time.Time, marker *models.AuditEvent) (int64, error)
+}
+
+// RecordVersionRepositoryInterface define los métodos del historial de
+// versiones de usuarios y perfiles. Las versiones las escribe un trigger, así
+// que no hay métodos para crearlas.
+type RecordVersionRepositoryInterface interface {
+	List(ctx context.Context, filter RecordVersionFilter) ([]models.RecordVersion, error)
+	GetByID(ctx context.Context, id uint) (*models.RecordVersion, error)
+	GetAsOf(ctx context.Context, entity string, userID uint, at time.Time) (*models.RecordVersion, error)
+	DeleteByUserID(ctx context.Context, userID uint) error
+}
+
+// ProcessedEventRepositoryInterface define los métodos del registro de eventos
+// procesados con el que se descartan los reenvíos
+type ProcessedEventRepositoryInterface interface {
+	MarkProcessed(ctx context.Context, eventID, eventType string) (bool, error)
+	DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// RoleRepositoryInterface define los métodos de roles, permisos y asignaciones de roles a usuarios
+type RoleRepositoryInterface interface {
+	List(ctx context.Context) ([]models.Role, error)
+	GetByID(ctx context.Context, id uint) (*models.Role, error)
+	GetByName(ctx context.Context, name string) (*models.Role, error)
+	Create(ctx context.Context, role *models.Role) error
+	Update(ctx context.Context, role *models.Role) error
+	Delete(ctx context.Context, id uint) error
+	ListPermissions(ctx context.Context) ([]models.Permission, error)
+	GetPermissionsByName(ctx context.Context, names []string) ([]models.Permission, error)
+	ListByUserID(ctx context.Context, userID uint) ([]models.Role, error)
+	Assign(ctx context.Context, assignment *models.UserRole) (bool, error)
+	Unassign(ctx context.Context, userID, roleID uint) (bool, error)
+	LockUser(ctx context.Context, userID uint) error
+	ListUserIDs(ctx context.Context, roleID uint) ([]uint, error)
+	DeleteByUserID(ctx context.Context, userID uint) error
+}
+
+// APIClientRepositoryInterface define los métodos de los clientes de API y sus keys
+type APIClientRepositoryInterface interface {
+	List(ctx context.Context) ([]models.APIClient, error)
+	GetByID(ctx context.Context, id uint) (*models.APIClient, error)
+	GetKeyByPrefix(ctx context.Context, prefix string) (*models.APIClientKey, *models.APIClient, error)
+	Create(ctx context.Context, client *models.APIClient) error
+	Update(ctx context.Context, client *models.APIClient) error
+	CreateKey(ctx context.Context, key *models.APIClientKey) error
+	ExpireKeys(ctx context.Context, clientID uint, expiresAt time.Time, exceptID uint) error
+	Touch(ctx context.Context, keyID, clientID uint, usedAt time.Time) error
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"time"
 	"gorm.io/gorm"
 	"it-app_user/internal/models"
@@ -16,9 +17,9 @@ func NewPasswordResetRepository(db *gorm.DB) PasswordResetRepositoryInterface {
 }
 
 // GetByToken obtiene un token de reset por su token
-func (r *PasswordResetRepository) GetByToken(token string) (*models.PasswordResetToken, error) {
+func (r *PasswordResetRepository) GetByToken(ctx context.Context, token string) (*models.PasswordResetToken, error) {
 	var resetToken models.PasswordResetToken
-	err := r.db.Where("token = ? AND is_used = ? AND expires_at > ?", token, false, time.Now()).First(&resetToken).Error
+	err := r.db.WithContext(ctx).Where("token = ? AND is_used = ? AND expires_at > ?", token, false, time.Now()).First(&resetToken).Error
 	if err != nil {
 		return nil, err
 	}
@@ -26,9 +27,9 @@ func (r *PasswordResetRepository) GetByToken(token string) (*models.PasswordRese
 }
 
 // GetByCode obtiene un token de reset por su código
-func (r *PasswordResetRepository) GetByCode(code string) (*models.PasswordResetToken, error) {
+func (r *PasswordResetRepository) GetByCode(ctx context.Context, code string) (*models.PasswordResetToken, error) {
 	var resetToken models.PasswordResetToken
-	err := r.db.Where("code = ? AND is_used = ? AND expires_at > ?", code, false, time.Now()).First(&resetToken).Error
+	err := r.db.WithContext(ctx).Where("code = ? AND is_used = ? AND expires_at > ?", code, false, time.Now()).First(&resetToken).Error
 	if err != nil {
 		return nil, err
 	}
@@ -36,9 +37,9 @@ func (r *PasswordResetRepository) GetByCode(code string) (*models.PasswordResetT
 }
 
 // GetByUserID obtiene el token de reset más reciente de un usuario
-func (r *PasswordResetRepository) GetByUserID(userID uint) (*models.PasswordResetToken, error) {
+func (r *PasswordResetRepository) GetByUserID(ctx context.Context, userID uint) (*models.PasswordResetToken, error) {
 	var resetToken models.PasswordResetToken
-	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").First(&resetToken).Error
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").First(&resetToken).Error
 	if err != nil {
 		return nil, err
 	}
@@ -46,22 +47,34 @@ func (r *PasswordResetRepository) GetByUserID(userID uint) (*models.PasswordRese
 }
 
 // Create crea un nuevo token de reset de contraseña
-func (r *PasswordResetRepository) Create(resetToken *models.PasswordResetToken) error {
-	return r.db.Create(resetToken).Error
+func (r *PasswordResetRepository) Create(ctx context.Context, resetToken *models.PasswordResetToken) error {
+	return r.db.WithContext(ctx).Create(resetToken).Error
 }
 
 // Update actualiza un token de reset existente
-func (r *PasswordResetRepository) Update(resetToken *models.PasswordResetToken) error {
-	return r.db.Save(resetToken).Error
+func (r *PasswordResetRepository) Update(ctx context.Context, resetToken *models.PasswordResetToken) error {
+	return r.db.WithContext(ctx).Save(resetToken).Error
 }
 
 // Delete elimina un token de reset por su ID
-func (r *PasswordResetRepository) Delete(id uint) error {
-	return r.db.Delete(&models.PasswordResetToken{}, id).Error
+func (r *PasswordResetRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.PasswordResetToken{}, id).Error
+}
+
+// DeleteByUserID elimina todos los tokens de reset de un usuario
+func (r *PasswordResetRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.PasswordResetToken{}).Error
+}
+
+// ListByUserID obtiene el historial de solicitudes de reset de un usuario
+func (r *PasswordResetRepository) ListByUserID(ctx context.Context, userID uint) ([]models.PasswordResetToken, error) {
+	var tokens []models.PasswordResetToken
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id").Find(&tokens).Error
+	return tokens, err
 }
 
 // MarkAsUsed marca un token como usado
-func (r *PasswordResetRepository) MarkAsUsed(id uint) error {
+func (r *PasswordResetRepository) MarkAsUsed(ctx context.Context, id uint) error {
 	now := time.Now()
 	updates := map[string]interface{}{
 		"is_used":    true,
@@ -69,10 +82,10 @@ func (r *PasswordResetRepository) MarkAsUsed(id uint) error {
 		"updated_at": now,
 	}
 	
-	return r.db.Model(&models.PasswordResetToken{}).Where("id = ?", id).Updates(updates).Error
+	return r.db.WithContext(ctx).Model(&models.PasswordResetToken{}).Where("id = ?", id).Updates(updates).Error
 }
 
 // CleanExpiredTokens elimina todos los tokens expirados
-func (r *PasswordResetRepository) CleanExpiredTokens() error {
-	return r.db.Where("expires_at < ? OR is_used = ?", time.Now(), true).Delete(&models.PasswordResetToken{}).Error
+func (r *PasswordResetRepository) CleanExpiredTokens(ctx context.Context) error {
+	return r.db.WithContext(ctx).Where("expires_at < ? OR is_used = ?", time.Now(), true).Delete(&models.PasswordResetToken{}).Error
 }
\ No newline at end of file
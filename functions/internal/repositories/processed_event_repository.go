@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"it-app_user/internal/models"
+)
+
+type ProcessedEventRepository struct {
+	db *gorm.DB
+}
+
+// NewProcessedEventRepository crea una nueva instancia del repositorio de eventos procesados
+func NewProcessedEventRepository(db *gorm.DB) ProcessedEventRepositoryInterface {
+	return &ProcessedEventRepository{db: db}
+}
+
+// MarkProcessed registra el evento con INSERT ... ON CONFLICT DO NOTHING.
+// Devuelve false si ya estaba registrado.
+func (r *ProcessedEventRepository) MarkProcessed(ctx context.Context, eventID, eventType string) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&models.ProcessedEvent{
+		EventID:     eventID,
+		Type:        eventType,
+		ProcessedAt: time.Now(),
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// DeleteBefore elimina los eventos procesados antes de before
+func (r *ProcessedEventRepository) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("processed_at < ?", before).Delete(&models.ProcessedEvent{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+
+	"it-app_user/internal/models"
+)
+
+// RecordVersionFilter son los filtros del historial de un usuario. Entity
+// vacío incluye las versiones de users y de user_profiles.
+type RecordVersionFilter struct {
+	UserID uint
+	Entity string
+	Limit  int
+	Offset int
+}
+
+type RecordVersionRepository struct {
+	db *gorm.DB
+}
+
+// NewRecordVersionRepository crea una nueva instancia del repositorio del historial de versiones
+func NewRecordVersionRepository(db *gorm.DB) RecordVersionRepositoryInterface {
+	return &RecordVersionRepository{db: db}
+}
+
+// List obtiene las versiones que cumplen el filtro, de la más reciente a la más antigua
+func (r *RecordVersionRepository) List(ctx context.Context, filter RecordVersionFilter) ([]models.RecordVersion, error) {
+	query := r.db.WithContext(ctx).Where("user_id = ?", filter.UserID)
+	if filter.Entity != "" {
+		query = query.Where("entity = ?", filter.Entity)
+	}
+
+	var versions []models.RecordVersion
+	err := query.Order("valid_from DESC, id DESC").
+		Limit(filter.Limit).
+		Offset(filter.Offset).
+		Find(&versions).Error
+	return versions, err
+}
+
+// GetByID obtiene una versión por su ID
+func (r *RecordVersionRepository) GetByID(ctx context.Context, id uint) (*models.RecordVersion, error) {
+	var version models.RecordVersion
+	err := r.db.WithContext(ctx).First(&version, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// GetAsOf obtiene la versión de la fila del usuario vigente en at: la última
+// creada hasta ese momento
+func (r *RecordVersionRepository) GetAsOf(ctx context.Context, entity string, userID uint, at time.Time) (*models.RecordVersion, error) {
+	var version models.RecordVersion
+	err := r.db.WithContext(ctx).
+		Where("entity = ? AND user_id = ? AND valid_from <= ?", entity, userID, at).
+		Order("valid_from DESC, id DESC").
+		First(&version).Error
+	if err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// DeleteByUserID elimina todo el historial de un usuario
+func (r *RecordVersionRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.RecordVersion{}).Error
+}
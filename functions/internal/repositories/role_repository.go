@@ -0,0 +1,137 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"it-app_user/internal/models"
+)
+
+// userRolesLockClass es la primera clave del advisory lock de las
+// asignaciones de cada usuario; la segunda es el ID del usuario
+const userRolesLockClass = 7_236_002
+
+type RoleRepository struct {
+	db *gorm.DB
+}
+
+// NewRoleRepository crea una nueva instancia del repositorio de roles y permisos
+func NewRoleRepository(db *gorm.DB) RoleRepositoryInterface {
+	return &RoleRepository{db: db}
+}
+
+// List obtiene todos los roles con sus permisos, ordenados por nombre
+func (r *RoleRepository) List(ctx context.Context) ([]models.Role, error) {
+	var roles []models.Role
+	err := r.db.WithContext(ctx).Preload("Permissions", func(db *gorm.DB) *gorm.DB {
+		return db.Order("name")
+	}).Order("name").Find(&roles).Error
+	return roles, err
+}
+
+// GetByID obtiene un rol con sus permisos
+func (r *RoleRepository) GetByID(ctx context.Context, id uint) (*models.Role, error) {
+	var role models.Role
+	err := r.db.WithContext(ctx).Preload("Permissions").First(&role, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// GetByName obtiene un rol con sus permisos
+func (r *RoleRepository) GetByName(ctx context.Context, name string) (*models.Role, error) {
+	var role models.Role
+	err := r.db.WithContext(ctx).Preload("Permissions").Where("name = ?", name).First(&role).Error
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// Create crea el rol con sus permisos, que ya deben existir
+func (r *RoleRepository) Create(ctx context.Context, role *models.Role) error {
+	return r.db.WithContext(ctx).Omit("Permissions.*").Create(role).Error
+}
+
+// Update guarda la descripción del rol y reemplaza sus permisos por role.Permissions
+func (r *RoleRepository) Update(ctx context.Context, role *models.Role) error {
+	db := r.db.WithContext(ctx)
+	if err := db.Model(role).Omit("Permissions").Updates(map[string]interface{}{
+		"description": role.Description,
+	}).Error; err != nil {
+		return err
+	}
+	return db.Model(role).Omit("Permissions.*").Association("Permissions").Replace(role.Permissions)
+}
+
+// Delete elimina el rol; sus permisos y asignaciones se eliminan en cascada
+func (r *RoleRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.Role{}, id).Error
+}
+
+// ListPermissions obtiene todos los permisos ordenados por nombre
+func (r *RoleRepository) ListPermissions(ctx context.Context) ([]models.Permission, error) {
+	var permissions []models.Permission
+	err := r.db.WithContext(ctx).Order("name").Find(&permissions).Error
+	return permissions, err
+}
+
+// GetPermissionsByName obtiene los permisos con esos nombres; los que no existen se omiten
+func (r *RoleRepository) GetPermissionsByName(ctx context.Context, names []string) ([]models.Permission, error) {
+	var permissions []models.Permission
+	if len(names) == 0 {
+		return permissions, nil
+	}
+	err := r.db.WithContext(ctx).Where("name IN ?", names).Order("name").Find(&permissions).Error
+	return permissions, err
+}
+
+// ListByUserID obtiene los roles asignados al usuario con sus permisos, ordenados por ID
+func (r *RoleRepository) ListByUserID(ctx context.Context, userID uint) ([]models.Role, error) {
+	var roles []models.Role
+	err := r.db.WithContext(ctx).Preload("Permissions").
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Order("roles.id").Find(&roles).Error
+	return roles, err
+}
+
+// Assign asigna el rol al usuario con INSERT ... ON CONFLICT DO NOTHING.
+// Devuelve false si ya lo tenía.
+func (r *RoleRepository) Assign(ctx context.Context, assignment *models.UserRole) (bool, error) {
+	result := r.db.WithContext(ctx).Omit("Role").Clauses(clause.OnConflict{DoNothing: true}).Create(assignment)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Unassign quita el rol al usuario. Devuelve false si no lo tenía.
+func (r *RoleRepository) Unassign(ctx context.Context, userID, roleID uint) (bool, error) {
+	result := r.db.WithContext(ctx).Where("user_id = ? AND role_id = ?", userID, roleID).Delete(&models.UserRole{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// LockUser toma el advisory lock de las asignaciones del usuario hasta el
+// final de la transacción, para serializarlas entre instancias
+func (r *RoleRepository) LockUser(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Exec("SELECT pg_advisory_xact_lock(?, ?)", userRolesLockClass, userID).Error
+}
+
+// ListUserIDs obtiene los IDs de los usuarios que tienen el rol
+func (r *RoleRepository) ListUserIDs(ctx context.Context, roleID uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).Model(&models.UserRole{}).Where("role_id = ?", roleID).Order("user_id").Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+// DeleteByUserID quita todos los roles del usuario
+func (r *RoleRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.UserRole{}).Error
+}
@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Repositories agrupa los repositorios ligados a una misma conexión o transacción
+type Repositories struct {
+	Users              UserRepositoryInterface
+	Profiles           UserProfileRepositoryInterface
+	Settings           UserSettingsRepositoryInterface
+	Stats              UserStatsRepositoryInterface
+	EmailVerifications EmailVerificationRepositoryInterface
+	PasswordResets     PasswordResetRepositoryInterface
+	DataExports        DataExportRepositoryInterface
+	AccountDeletions   AccountDeletionRepositoryInterface
+	Versions           RecordVersionRepositoryInterface
+	ProcessedEvents    ProcessedEventRepositoryInterface
+	Roles              RoleRepositoryInterface
+	APIClients         APIClientRepositoryInterface
+}
+
+// NewRepositories crea todos los repositorios sobre db
+func NewRepositories(db *gorm.DB) *Repositories {
+	return &Repositories{
+		Users:              NewUserRepository(db),
+		Profiles:           NewUserProfileRepository(db),
+		Settings:           NewUserSettingsRepository(db),
+		Stats:              NewUserStatsRepository(db),
+		EmailVerifications: NewEmailVerificationRepository(db),
+		PasswordResets:     NewPasswordResetRepository(db),
+		DataExports:        NewDataExportRepository(db),
+		AccountDeletions:   NewAccountDeletionRepository(db),
+		Versions:           NewRecordVersionRepository(db),
+		ProcessedEvents:    NewProcessedEventRepository(db),
+		Roles:              NewRoleRepository(db),
+		APIClients:         NewAPIClientRepository(db),
+	}
+}
+
+// TxFunc es el trabajo que se ejecuta dentro de una transacción. Debe usar
+// solo los repositorios recibidos y el ctx recibido, y puede ejecutarse más
+// de una vez si la transacción se reintenta.
+type TxFunc func(ctx context.Context, repos *Repositories) error
+
+type txKey struct{}
+
+// TxManager ejecuta unidades de trabajo en una transacción
+type TxManager struct {
+	db         *gorm.DB
+	maxRetries int
+}
+
+// NewTxManager crea un TxManager que reintenta hasta 3 veces ante conflictos de serialización
+func NewTxManager(db *gorm.DB) *TxManager {
+	return &TxManager{
+		db:         db,
+		maxRetries: 3,
+	}
+}
+
+// WithinTransaction ejecuta fn con repositorios ligados a una transacción: se
+// confirma si fn no devuelve error y se revierte en caso contrario (o de panic).
+//
+// Si ctx ya tiene una transacción abierta (llamada anidada), fn se ejecuta en
+// un savepoint de esa transacción: un error solo revierte el trabajo de fn y
+// la transacción externa decide si continuar.
+//
+// Las transacciones externas que fallan por un conflicto de serialización o un
+// deadlock se reintentan desde el principio.
+func (m *TxManager) WithinTransaction(ctx context.Context, fn TxFunc, opts ...*sql.TxOptions) error {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		// GORM usa SAVEPOINT/ROLLBACK TO SAVEPOINT para las transacciones anidadas
+		return tx.Transaction(func(inner *gorm.DB) error {
+			return fn(context.WithValue(ctx, txKey{}, inner), NewRepositories(inner))
+		})
+	}
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return fn(context.WithValue(ctx, txKey{}, tx), NewRepositories(tx))
+		}, opts...)
+
+		if err == nil || !IsRetryableTxError(err) || attempt >= m.maxRetries {
+			return err
+		}
+
+		// Backoff breve y creciente antes de reintentar
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(time.Duration(attempt+1) * 20 * time.Millisecond):
+		}
+	}
+}
+
+// Códigos SQLSTATE de PostgreSQL
+const (
+	sqlStateUniqueViolation      = "23505"
+	sqlStateSerializationFailure = "40001"
+	sqlStateDeadlockDetected     = "40P01"
+)
+
+// sqlState devuelve el código SQLSTATE de un error del driver de PostgreSQL, o ""
+func sqlState(err error) string {
+	var pgErr interface{ SQLState() string }
+	if errors.As(err, &pgErr) {
+		return pgErr.SQLState()
+	}
+	return ""
+}
+
+// IsRetryableTxError indica si una transacción falló por un conflicto de
+// concurrencia y puede reintentarse
+func IsRetryableTxError(err error) bool {
+	switch sqlState(err) {
+	case sqlStateSerializationFailure, sqlStateDeadlockDetected:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsUniqueViolation indica si el error es una violación de un índice único
+func IsUniqueViolation(err error) bool {
+	return sqlState(err) == sqlStateUniqueViolation || errors.Is(err, gorm.ErrDuplicatedKey)
+}
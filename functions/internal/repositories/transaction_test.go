@@ -0,0 +1,140 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/gorm"
+
+	"it-app_user/internal/dbtest"
+)
+
+// sqlStateError imita los errores del driver de PostgreSQL, que exponen su SQLSTATE
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "sqlstate " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+// execIn ejecuta sentencias en la transacción que WithinTransaction puso en ctx
+func execIn(ctx context.Context, sql string) error {
+	return ctx.Value(txKey{}).(*gorm.DB).Exec(sql).Error
+}
+
+func TestWithinTransactionRetriesSerializationFailures(t *testing.T) {
+	for _, code := range []string{sqlStateSerializationFailure, sqlStateDeadlockDetected} {
+		t.Run(code, func(t *testing.T) {
+			db, mock := dbtest.NewMockDB(t)
+			mock.ExpectBegin()
+			mock.ExpectExec("UPDATE users").WillReturnError(sqlStateError(code))
+			mock.ExpectRollback()
+			mock.ExpectBegin()
+			mock.ExpectExec("UPDATE users").WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			calls := 0
+			err := NewTxManager(db).WithinTransaction(context.Background(), func(ctx context.Context, repos *Repositories) error {
+				calls++
+				return execIn(ctx, "UPDATE users SET login_count = 1")
+			})
+			if err != nil {
+				t.Fatalf("WithinTransaction: %v", err)
+			}
+			if calls != 2 {
+				t.Fatalf("fn ran %d times, want exactly one retry", calls)
+			}
+		})
+	}
+}
+
+func TestWithinTransactionStopsRetrying(t *testing.T) {
+	db, mock := dbtest.NewMockDB(t)
+	manager := NewTxManager(db)
+	for i := 0; i <= manager.maxRetries; i++ {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE users").WillReturnError(sqlStateError(sqlStateSerializationFailure))
+		mock.ExpectRollback()
+	}
+
+	calls := 0
+	err := manager.WithinTransaction(context.Background(), func(ctx context.Context, repos *Repositories) error {
+		calls++
+		return execIn(ctx, "UPDATE users SET login_count = 1")
+	})
+	if !IsRetryableTxError(err) {
+		t.Fatalf("err = %v, want the serialization failure", err)
+	}
+	if calls != manager.maxRetries+1 {
+		t.Fatalf("fn ran %d times, want %d", calls, manager.maxRetries+1)
+	}
+}
+
+func TestWithinTransactionDoesNotRetryOtherErrors(t *testing.T) {
+	db, mock := dbtest.NewMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO users").WillReturnError(sqlStateError(sqlStateUniqueViolation))
+	mock.ExpectRollback()
+
+	calls := 0
+	err := NewTxManager(db).WithinTransaction(context.Background(), func(ctx context.Context, repos *Repositories) error {
+		calls++
+		return execIn(ctx, "INSERT INTO users DEFAULT VALUES")
+	})
+	if !IsUniqueViolation(err) || calls != 1 {
+		t.Fatalf("err = %v after %d calls, want the unique violation after 1", err, calls)
+	}
+}
+
+func TestWithinTransactionNestedRollbackKeepsOuter(t *testing.T) {
+	db, mock := dbtest.NewMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO users").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("SAVEPOINT sp").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT INTO audit_events").WillReturnError(errors.New("check constraint"))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT sp").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE users").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	manager := NewTxManager(db)
+	innerErr := errors.New("inner failed")
+	err := manager.WithinTransaction(context.Background(), func(ctx context.Context, repos *Repositories) error {
+		if err := execIn(ctx, "INSERT INTO users DEFAULT VALUES"); err != nil {
+			return err
+		}
+		err := manager.WithinTransaction(ctx, func(ctx context.Context, repos *Repositories) error {
+			if err := execIn(ctx, "INSERT INTO audit_events DEFAULT VALUES"); err != nil {
+				return innerErr
+			}
+			return nil
+		})
+		if !errors.Is(err, innerErr) {
+			t.Errorf("nested err = %v, want %v", err, innerErr)
+		}
+		// La transacción externa sigue usable después del savepoint revertido
+		return execIn(ctx, "UPDATE users SET login_count = 1")
+	})
+	if err != nil {
+		t.Fatalf("WithinTransaction: %v", err)
+	}
+}
+
+func TestWithinTransactionNestedErrorRollsBackOuter(t *testing.T) {
+	db, mock := dbtest.NewMockDB(t)
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT sp").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT sp").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	manager := NewTxManager(db)
+	innerErr := errors.New("inner failed")
+	err := manager.WithinTransaction(context.Background(), func(ctx context.Context, repos *Repositories) error {
+		// La transacción externa decide: aquí propaga el error del savepoint
+		return manager.WithinTransaction(ctx, func(ctx context.Context, repos *Repositories) error {
+			return innerErr
+		})
+	})
+	if !errors.Is(err, innerErr) {
+		t.Fatalf("err = %v, want %v", err, innerErr)
+	}
+}
@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"gorm.io/gorm"
+	"it-app_user/internal/models"
+)
+
+type UserProfileRepository struct {
+	db *gorm.DB
+}
+
+// NewUserProfileRepository crea una nueva instancia del repositorio de perfiles de usuario
+func NewUserProfileRepository(db *gorm.DB) UserProfileRepositoryInterface {
+	return &UserProfileRepository{db: db}
+}
+
+// GetByUserID obtiene el perfil de un usuario
+func (r *UserProfileRepository) GetByUserID(ctx context.Context, userID uint) (*models.UserProfile, error) {
+	var profile models.UserProfile
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&profile).Error
+	if err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// Create crea el perfil de un usuario
+func (r *UserProfileRepository) Create(ctx context.Context, profile *models.UserProfile) error {
+	return r.db.WithContext(ctx).Create(profile).Error
+}
+
+// Update actualiza el perfil de un usuario
+func (r *UserProfileRepository) Update(ctx context.Context, profile *models.UserProfile) error {
+	return r.db.WithContext(ctx).Save(profile).Error
+}
+
+// Delete elimina el perfil de un usuario
+func (r *UserProfileRepository) Delete(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.UserProfile{}).Error
+}
+
+// UpdateAvatar actualiza el avatar del usuario
+func (r *UserProfileRepository) UpdateAvatar(ctx context.Context, userID uint, avatarURL string) error {
+	updates := map[string]interface{}{
+		"avatar":     avatarURL,
+		"updated_at": time.Now(),
+	}
+
+	return r.db.WithContext(ctx).Model(&models.UserProfile{}).Where("user_id = ?", userID).Updates(updates).Error
+}
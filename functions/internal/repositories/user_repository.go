@@ -1,11 +1,45 @@
 package repositories
 
 import (
+	"context"
+	"strings"
 	"time"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"it-app_user/internal/models"
 )
 
+// Campos por los que puede ordenarse UserFilter
+const (
+	UserSortCreatedAt   = "created_at"
+	UserSortLastLoginAt = "last_login_at"
+	UserSortEmail       = "email"
+	UserSortUsername    = "username"
+)
+
+// UserFilter son los filtros del listado de usuarios de administración. Los
+// punteros nil no filtran; Query busca igual que SearchUsers y Role es el
+// nombre de un rol asignado. Con Deleted solo devuelve usuarios con borrado
+// lógico pendiente de purga.
+type UserFilter struct {
+	Query         string
+	Status        string
+	Provider      string
+	Role          string
+	Disabled      *bool
+	EmailVerified *bool
+	Deleted       bool
+	CreatedFrom   time.Time
+	CreatedTo     time.Time
+	LastLoginFrom time.Time
+	LastLoginTo   time.Time
+	NeverLoggedIn bool
+	Sort          string
+	Desc          bool
+	Limit         int
+	Offset        int
+}
+
 type UserRepository struct {
 	db *gorm.DB
 }
@@ -16,19 +50,20 @@ func NewUserRepository(db *gorm.DB) UserRepositoryInterface {
 }
 
 // GetByID obtiene un usuario por su ID
-func (r *UserRepository) GetByID(id uint) (*models.User, error) {
+func (r *UserRepository) GetByID(ctx context.Context, id uint) (*models.User, error) {
 	var user models.User
-	err := r.db.First(&user, id).Error
+	err := r.db.WithContext(ctx).First(&user, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-// GetByEmail obtiene un usuario por su email
-func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
+// GetByEmail obtiene un usuario por su email canónico (sin distinguir
+// mayúsculas y, si están activas, con las reglas por proveedor)
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := r.db.WithContext(ctx).Where("email_canonical = ?", models.CanonicalEmail(email)).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
@@ -36,49 +71,146 @@ func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
 }
 
 // GetByFirebaseID obtiene un usuario por su Firebase ID
-func (r *UserRepository) GetByFirebaseID(firebaseID string) (*models.User, error) {
+func (r *UserRepository) GetByFirebaseID(ctx context.Context, firebaseID string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("firebase_id = ?", firebaseID).First(&user).Error
+	err := r.db.WithContext(ctx).Where("firebase_id = ?", firebaseID).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-// GetByUsername obtiene un usuario por su username
-func (r *UserRepository) GetByUsername(username string) (*models.User, error) {
+// GetByUsername obtiene un usuario por su username, sin distinguir mayúsculas
+func (r *UserRepository) GetByUsername(ctx context.Context, username string) (*models.User, error) {
 	var user models.User
-	err := r.db.Where("username = ?", username).First(&user).Error
+	err := r.db.WithContext(ctx).Where("username_canonical = ?", models.CanonicalUsername(username)).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
+// ExistingUsernames devuelve, en forma canónica, cuáles de los usernames ya
+// están registrados. Incluye los de usuarios con borrado lógico: los
+// conservan hasta la purga.
+func (r *UserRepository) ExistingUsernames(ctx context.Context, usernames []string) ([]string, error) {
+	canonical := make([]string, len(usernames))
+	for i, u := range usernames {
+		canonical[i] = models.CanonicalUsername(u)
+	}
+
+	var existing []string
+	err := r.db.WithContext(ctx).Unscoped().Model(&models.User{}).
+		Where("username_canonical IN ?", canonical).
+		Pluck("username_canonical", &existing).Error
+	return existing, err
+}
+
+// ListByFirebaseIDs obtiene los usuarios con esos Firebase IDs, incluidos los
+// que tienen borrado lógico
+func (r *UserRepository) ListByFirebaseIDs(ctx context.Context, firebaseIDs []string) ([]models.User, error) {
+	var users []models.User
+	err := r.db.WithContext(ctx).Unscoped().Where("firebase_id IN ?", firebaseIDs).Find(&users).Error
+	return users, err
+}
+
+// ListAfterID obtiene hasta limit usuarios con ID mayor a afterID, ordenados
+// por ID, para recorrer la tabla completa por lotes
+func (r *UserRepository) ListAfterID(ctx context.Context, afterID uint, limit int) ([]models.User, error) {
+	var users []models.User
+	err := r.db.WithContext(ctx).Where("id > ?", afterID).Order("id").Limit(limit).Find(&users).Error
+	return users, err
+}
+
 // GetAll obtiene todos los usuarios con paginación
-func (r *UserRepository) GetAll(limit, offset int) ([]models.User, error) {
+func (r *UserRepository) GetAll(ctx context.Context, limit, offset int) ([]models.User, error) {
 	var users []models.User
-	err := r.db.Limit(limit).Offset(offset).Find(&users).Error
+	err := r.db.WithContext(ctx).Limit(limit).Offset(offset).Find(&users).Error
 	return users, err
 }
 
 // Create crea un nuevo usuario
-func (r *UserRepository) Create(user *models.User) error {
-	return r.db.Create(user).Error
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
+}
+
+// CreateIfNotExists inserta el usuario con INSERT ... ON CONFLICT DO NOTHING.
+// Devuelve false si ya existía un usuario con el mismo Firebase ID, email o username.
+func (r *UserRepository) CreateIfNotExists(ctx context.Context, user *models.User) (bool, error) {
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(user)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
 }
 
 // Update actualiza un usuario existente
-func (r *UserRepository) Update(user *models.User) error {
-	return r.db.Save(user).Error
+func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Save(user).Error
+}
+
+// Delete marca el usuario como eliminado (borrado lógico). El resto de
+// consultas deja de verlo; Purge lo elimina definitivamente.
+func (r *UserRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.User{}, id).Error
+}
+
+// GetDeletedByID obtiene un usuario con borrado lógico por su ID
+func (r *UserRepository) GetDeletedByID(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL").First(&user, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetDeletedByFirebaseID obtiene un usuario con borrado lógico por su Firebase ID
+func (r *UserRepository) GetDeletedByFirebaseID(ctx context.Context, firebaseID string) (*models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("firebase_id = ? AND deleted_at IS NOT NULL", firebaseID).
+		First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
 }
 
-// Delete elimina un usuario por su ID
-func (r *UserRepository) Delete(id uint) error {
-	return r.db.Delete(&models.User{}, id).Error
+// Restore deshace el borrado lógico de un usuario
+func (r *UserRepository) Restore(ctx context.Context, id uint) error {
+	result := r.db.WithContext(ctx).Unscoped().Model(&models.User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]interface{}{"deleted_at": nil, "updated_at": time.Now()})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ListDeletedBefore devuelve hasta limit usuarios eliminados antes de before, los más antiguos primero
+func (r *UserRepository) ListDeletedBefore(ctx context.Context, before time.Time, limit int) ([]models.User, error) {
+	var users []models.User
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+		Order("deleted_at, id").
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
+
+// Purge elimina definitivamente la fila del usuario (debe tener borrado lógico)
+func (r *UserRepository) Purge(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL").
+		Delete(&models.User{}, id).Error
 }
 
 // UpdateLoginInfo actualiza la información de login del usuario
-func (r *UserRepository) UpdateLoginInfo(id uint, loginIP, loginDevice string) error {
+func (r *UserRepository) UpdateLoginInfo(ctx context.Context, id uint, loginIP, loginDevice string) error {
 	updates := map[string]interface{}{
 		"login_count":        gorm.Expr("login_count + 1"),
 		"last_login_at":      time.Now(),
@@ -87,32 +219,123 @@ func (r *UserRepository) UpdateLoginInfo(id uint, loginIP, loginDevice string) e
 		"updated_at":         time.Now(),
 	}
 	
-	return r.db.Model(&models.User{}).Where("id = ?", id).Updates(updates).Error
+	return r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).Updates(updates).Error
 }
 
 // GetActiveUsers obtiene todos los usuarios activos
-func (r *UserRepository) GetActiveUsers() ([]models.User, error) {
+func (r *UserRepository) GetActiveUsers(ctx context.Context) ([]models.User, error) {
 	var users []models.User
-	err := r.db.Where("status = ? AND disabled = ?", "active", false).Find(&users).Error
+	err := r.db.WithContext(ctx).Where("status = ? AND disabled = ?", "active", false).Find(&users).Error
 	return users, err
 }
 
-// SearchUsers busca usuarios por nombre, email o username
-func (r *UserRepository) SearchUsers(query string, limit, offset int) ([]models.User, error) {
+// userSearchCondition busca por nombre, apellido, email canónico y username
+// canónico; los patrones se escapan con escapeLike
+const userSearchCondition = `first_name ILIKE ? ESCAPE '\' OR last_name ILIKE ? ESCAPE '\' OR email_canonical LIKE ? ESCAPE '\' OR username_canonical LIKE ? ESCAPE '\'`
+
+// SearchUsers busca usuarios por nombre, email o username. Email y username se
+// buscan por su forma canónica, así que "J.Doe@Gmail.com" encuentra a
+// "jdoe@gmail.com" si las reglas por proveedor están activas.
+func (r *UserRepository) SearchUsers(ctx context.Context, query string, limit, offset int) ([]models.User, error) {
 	var users []models.User
-	searchPattern := "%" + query + "%"
+	searchPattern := "%" + escapeLike(query) + "%"
+	emailPattern := "%" + escapeLike(models.CanonicalEmail(query)) + "%"
+	usernamePattern := "%" + escapeLike(models.CanonicalUsername(query)) + "%"
 	
-	err := r.db.Where(
-		"first_name ILIKE ? OR last_name ILIKE ? OR email ILIKE ? OR username ILIKE ?",
-		searchPattern, searchPattern, searchPattern, searchPattern,
+	err := r.db.WithContext(ctx).Where(
+		userSearchCondition,
+		searchPattern, searchPattern, emailPattern, usernamePattern,
 	).Limit(limit).Offset(offset).Find(&users).Error
 	
 	return users, err
 }
 
 // CountUsers cuenta el total de usuarios
-func (r *UserRepository) CountUsers() (int64, error) {
+func (r *UserRepository) CountUsers(ctx context.Context) (int64, error) {
 	var count int64
-	err := r.db.Model(&models.User{}).Count(&count).Error
+	err := r.db.WithContext(ctx).Model(&models.User{}).Count(&count).Error
 	return count, err
-}
\ No newline at end of file
+}
+
+// List obtiene una página de usuarios que cumplen el filtro y el total de
+// usuarios que lo cumplen
+func (r *UserRepository) List(ctx context.Context, filter UserFilter) ([]models.User, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.User{})
+	if filter.Deleted {
+		query = query.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	if filter.Query != "" {
+		searchPattern := "%" + escapeLike(filter.Query) + "%"
+		query = query.Where(
+			userSearchCondition,
+			searchPattern, searchPattern,
+			"%"+escapeLike(models.CanonicalEmail(filter.Query))+"%",
+			"%"+escapeLike(models.CanonicalUsername(filter.Query))+"%",
+		)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Provider != "" {
+		query = query.Where("provider = ?", filter.Provider)
+	}
+	if filter.Role != "" {
+		query = query.Where("EXISTS (SELECT 1 FROM user_roles JOIN roles ON roles.id = user_roles.role_id WHERE user_roles.user_id = users.id AND roles.name = ?)", filter.Role)
+	}
+	if filter.Disabled != nil {
+		query = query.Where("disabled = ?", *filter.Disabled)
+	}
+	if filter.EmailVerified != nil {
+		query = query.Where("email_verified = ?", *filter.EmailVerified)
+	}
+	if !filter.CreatedFrom.IsZero() {
+		query = query.Where("created_at >= ?", filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		query = query.Where("created_at < ?", filter.CreatedTo)
+	}
+	if filter.NeverLoggedIn {
+		query = query.Where("last_login_at IS NULL")
+	}
+	if !filter.LastLoginFrom.IsZero() {
+		query = query.Where("last_login_at >= ?", filter.LastLoginFrom)
+	}
+	if !filter.LastLoginTo.IsZero() {
+		query = query.Where("last_login_at < ?", filter.LastLoginTo)
+	}
+
+	// Con una sesión el conteo y la página parten de los mismos filtros sin
+	// compartir la sentencia (Count la modifica)
+	query = query.Session(&gorm.Session{})
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// El ID desempata para que las páginas no repitan ni salteen usuarios
+	sort := UserSortCreatedAt
+	switch filter.Sort {
+	case UserSortLastLoginAt, UserSortEmail, UserSortUsername:
+		sort = filter.Sort
+	}
+	direction := "ASC"
+	if filter.Desc {
+		direction = "DESC"
+	}
+	order := sort + " " + direction
+	if sort == UserSortLastLoginAt {
+		order += " NULLS LAST"
+	}
+
+	var users []models.User
+	err := query.Order(order + ", id " + direction).Limit(filter.Limit).Offset(filter.Offset).Find(&users).Error
+	return users, total, err
+}
+
+// likeEscaper escapa los comodines de LIKE; las consultas declaran ESCAPE '\'
+// para no depender del escape por defecto de Postgres
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
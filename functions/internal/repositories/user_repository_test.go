@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"it-app_user/internal/dbtest"
+)
+
+func TestEscapeLike(t *testing.T) {
+	tests := map[string]string{
+		"ana":       "ana",
+		"100%":      `100\%`,
+		"a_b":       `a\_b`,
+		`c:\temp`:   `c:\\temp`,
+		`%_\`:       `\%\_\\`,
+		"josé@x.io": "josé@x.io",
+	}
+	for in, want := range tests {
+		if got := escapeLike(in); got != want {
+			t.Errorf("escapeLike(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSearchUsersEscapesWildcards(t *testing.T) {
+	db, mock := dbtest.NewMockDB(t)
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE \(first_name ILIKE \$1 ESCAPE '\\' OR last_name ILIKE \$2 ESCAPE '\\' OR email_canonical LIKE \$3 ESCAPE '\\' OR username_canonical LIKE \$4 ESCAPE '\\'\)`).
+		WithArgs(`%50\%\_Off%`, `%50\%\_Off%`, `%50\%\_off%`, `%50\%\_off%`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	if _, err := NewUserRepository(db).SearchUsers(context.Background(), "50%_Off", 10, 0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestListEscapesWildcards(t *testing.T) {
+	db, mock := dbtest.NewMockDB(t)
+	condition := `\(first_name ILIKE \$1 ESCAPE '\\' OR last_name ILIKE \$2 ESCAPE '\\' OR email_canonical LIKE \$3 ESCAPE '\\' OR username_canonical LIKE \$4 ESCAPE '\\'\)`
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "users" WHERE `+condition).
+		WithArgs(`%a\_b%`, `%a\_b%`, `%a\_b%`, `%a\_b%`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE `+condition).
+		WithArgs(`%a\_b%`, `%a\_b%`, `%a\_b%`, `%a\_b%`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+	if _, _, err := NewUserRepository(db).List(context.Background(), UserFilter{Query: "a_b", Limit: 10}); err != nil {
+		t.Fatal(err)
+	}
+}
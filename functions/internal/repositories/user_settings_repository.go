@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"gorm.io/gorm"
+	"it-app_user/internal/models"
+)
+
+type UserSettingsRepository struct {
+	db *gorm.DB
+}
+
+// NewUserSettingsRepository crea una nueva instancia del repositorio de configuraciones de usuario
+func NewUserSettingsRepository(db *gorm.DB) UserSettingsRepositoryInterface {
+	return &UserSettingsRepository{db: db}
+}
+
+// GetByUserID obtiene las configuraciones de un usuario
+func (r *UserSettingsRepository) GetByUserID(ctx context.Context, userID uint) (*models.UserSettings, error) {
+	var settings models.UserSettings
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&settings).Error
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// GetByFirebaseID obtiene las configuraciones de un usuario por su Firebase ID
+func (r *UserSettingsRepository) GetByFirebaseID(ctx context.Context, firebaseID string) (*models.UserSettings, error) {
+	var settings models.UserSettings
+	err := r.db.WithContext(ctx).Joins("JOIN users ON users.id = user_settings.user_id").
+		Where("users.firebase_id = ?", firebaseID).
+		First(&settings).Error
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// Create crea las configuraciones de un usuario
+func (r *UserSettingsRepository) Create(ctx context.Context, settings *models.UserSettings) error {
+	return r.db.WithContext(ctx).Create(settings).Error
+}
+
+// Update actualiza las configuraciones de un usuario
+func (r *UserSettingsRepository) Update(ctx context.Context, settings *models.UserSettings) error {
+	return r.db.WithContext(ctx).Save(settings).Error
+}
+
+// Delete elimina las configuraciones de un usuario
+func (r *UserSettingsRepository) Delete(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.UserSettings{}).Error
+}
+
+// UpdateLanguage actualiza el idioma preferido del usuario
+func (r *UserSettingsRepository) UpdateLanguage(ctx context.Context, userID uint, language string) error {
+	updates := map[string]interface{}{
+		"language":   language,
+		"updated_at": time.Now(),
+	}
+
+	return r.db.WithContext(ctx).Model(&models.UserSettings{}).Where("user_id = ?", userID).Updates(updates).Error
+}
+
+// UpdateTheme actualiza el tema del usuario
+func (r *UserSettingsRepository) UpdateTheme(ctx context.Context, userID uint, theme string) error {
+	updates := map[string]interface{}{
+		"theme":      theme,
+		"updated_at": time.Now(),
+	}
+
+	return r.db.WithContext(ctx).Model(&models.UserSettings{}).Where("user_id = ?", userID).Updates(updates).Error
+}
@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"context"
+	"time"
+	"gorm.io/gorm"
+	"it-app_user/internal/models"
+)
+
+type UserStatsRepository struct {
+	db *gorm.DB
+}
+
+// NewUserStatsRepository crea una nueva instancia del repositorio de estadísticas de usuario
+func NewUserStatsRepository(db *gorm.DB) UserStatsRepositoryInterface {
+	return &UserStatsRepository{db: db}
+}
+
+// GetByUserID obtiene las estadísticas de un usuario
+func (r *UserStatsRepository) GetByUserID(ctx context.Context, userID uint) (*models.UserStats, error) {
+	var stats models.UserStats
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// Create crea las estadísticas de un usuario
+func (r *UserStatsRepository) Create(ctx context.Context, stats *models.UserStats) error {
+	return r.db.WithContext(ctx).Create(stats).Error
+}
+
+// Update actualiza las estadísticas de un usuario
+func (r *UserStatsRepository) Update(ctx context.Context, stats *models.UserStats) error {
+	return r.db.WithContext(ctx).Save(stats).Error
+}
+
+// Delete elimina las estadísticas de un usuario
+func (r *UserStatsRepository) Delete(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.UserStats{}).Error
+}
+
+// IncrementLoginCount incrementa el contador de logins del usuario
+func (r *UserStatsRepository) IncrementLoginCount(ctx context.Context, userID uint) error {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"login_count":    gorm.Expr("login_count + 1"),
+		"last_login_at":  &now,
+		"last_active_at": &now,
+		"updated_at":     now,
+	}
+
+	return r.db.WithContext(ctx).Model(&models.UserStats{}).Where("user_id = ?", userID).Updates(updates).Error
+}
+
+// IncrementProfileViews incrementa las visitas al perfil del usuario
+func (r *UserStatsRepository) IncrementProfileViews(ctx context.Context, userID uint) error {
+	updates := map[string]interface{}{
+		"profile_views": gorm.Expr("profile_views + 1"),
+		"updated_at":    time.Now(),
+	}
+
+	return r.db.WithContext(ctx).Model(&models.UserStats{}).Where("user_id = ?", userID).Updates(updates).Error
+}
+
+// UpdateLastActive actualiza la última actividad del usuario
+func (r *UserStatsRepository) UpdateLastActive(ctx context.Context, userID uint) error {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"last_active_at": &now,
+		"updated_at":     now,
+	}
+
+	return r.db.WithContext(ctx).Model(&models.UserStats{}).Where("user_id = ?", userID).Updates(updates).Error
+}
@@ -0,0 +1,68 @@
+package requestinfo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// HeaderRequestID es el header con el que se recibe y se devuelve el ID de la request
+const HeaderRequestID = "X-Request-ID"
+
+// maxIDLength limita el largo de un ID recibido del cliente
+const maxIDLength = 128
+
+// Info son los datos de la request que se conservan en el contexto para
+// logs y auditoría
+type Info struct {
+	ID        string
+	UserAgent string
+}
+
+type contextKey struct{}
+
+// With devuelve un contexto que transporta los datos de la request
+func With(ctx context.Context, info Info) context.Context {
+	return context.WithValue(ctx, contextKey{}, info)
+}
+
+// FromContext obtiene los datos de la request guardados en el contexto
+func FromContext(ctx context.Context) (Info, bool) {
+	info, ok := ctx.Value(contextKey{}).(Info)
+	return info, ok
+}
+
+// FromRequest arma los datos de la request: reutiliza el X-Request-ID
+// recibido si es válido (lo propaga un gateway o el servicio que llama) y si
+// no genera uno nuevo
+func FromRequest(r *http.Request) Info {
+	id := r.Header.Get(HeaderRequestID)
+	if !validID(id) {
+		id = NewID()
+	}
+	return Info{ID: id, UserAgent: r.UserAgent()}
+}
+
+// NewID genera un ID de request aleatorio
+func NewID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// validID acepta IDs cortos de caracteres ASCII imprimibles, para que no
+// puedan inyectar contenido en logs ni headers
+func validID(id string) bool {
+	if id == "" || len(id) > maxIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,70 @@
+package routes
+
+import (
+	"github.com/gorilla/mux"
+	"it-app_user/internal/handlers"
+	"it-app_user/internal/middleware"
+	"it-app_user/internal/models"
+)
+
+// SetupAdminRoutes configura las rutas de administración. Cada grupo exige
+// su permiso (el rol admin y el custom claim admin=true los otorgan todos),
+// así que sin Firebase Auth no existen.
+func SetupAdminRoutes(router *mux.Router, auditHandler *handlers.AuditHandler, historyHandler *handlers.UserHistoryHandler, roleHandler *handlers.RoleHandler, adminUserHandler *handlers.AdminUserHandler, apiClientHandler *handlers.APIClientHandler, authMiddleware *middleware.AuthMiddleware) {
+	if authMiddleware == nil {
+		return
+	}
+
+	adminRouter := router.PathPrefix("/admin").Subrouter()
+	// Un subrouter por permiso: si el método no coincide mux sigue con el siguiente
+	permissionRouter := func(permission string) *mux.Router {
+		subrouter := adminRouter.PathPrefix("").Subrouter()
+		subrouter.Use(authMiddleware.RequirePermission(permission))
+		return subrouter
+	}
+
+	// Log de auditoría
+	auditRouter := permissionRouter(models.PermissionAuditRead)
+	auditRouter.HandleFunc("/audit", auditHandler.ListAuditEvents).Methods("GET")
+	auditRouter.HandleFunc("/audit/verify", auditHandler.VerifyAuditChain).Methods("GET")
+
+	// Gestión de usuarios e historial de versiones de usuarios y perfiles
+	readUserRouter := permissionRouter(models.PermissionUsersRead)
+	readUserRouter.HandleFunc("/users", adminUserHandler.ListUsers).Methods("GET")
+	readUserRouter.HandleFunc("/users/{id:[0-9]+}/timeline", adminUserHandler.GetSecurityTimeline).Methods("GET")
+	readUserRouter.HandleFunc("/users/{id:[0-9]+}/history", historyHandler.ListUserHistory).Methods("GET")
+	readUserRouter.HandleFunc("/users/{id:[0-9]+}/as-of", historyHandler.GetUserAsOf).Methods("GET")
+	writeUserRouter := permissionRouter(models.PermissionUsersWrite)
+	writeUserRouter.HandleFunc("/users/{id:[0-9]+}/disable", adminUserHandler.DisableUser).Methods("POST")
+	writeUserRouter.HandleFunc("/users/{id:[0-9]+}/enable", adminUserHandler.EnableUser).Methods("POST")
+	writeUserRouter.HandleFunc("/users/{id:[0-9]+}/password-reset", adminUserHandler.ForcePasswordReset).Methods("POST")
+	writeUserRouter.HandleFunc("/users/{id:[0-9]+}/revoke-sessions", adminUserHandler.RevokeSessions).Methods("POST")
+	writeUserRouter.HandleFunc("/users/{id:[0-9]+}/verify-email", adminUserHandler.VerifyEmail).Methods("POST")
+	writeUserRouter.HandleFunc("/users/{id:[0-9]+}/invite", adminUserHandler.ResendInvite).Methods("POST")
+	writeUserRouter.HandleFunc("/users/{id:[0-9]+}/revert", historyHandler.RevertUser).Methods("POST")
+	impersonateRouter := permissionRouter(models.PermissionUsersImpersonate)
+	impersonateRouter.HandleFunc("/impersonate/{id:[0-9]+}", adminUserHandler.Impersonate).Methods("POST")
+
+	// Roles, permisos y asignaciones
+	readRoleRouter := permissionRouter(models.PermissionRolesRead)
+	readRoleRouter.HandleFunc("/roles", roleHandler.ListRoles).Methods("GET")
+	readRoleRouter.HandleFunc("/permissions", roleHandler.ListPermissions).Methods("GET")
+	readRoleRouter.HandleFunc("/users/{id:[0-9]+}/roles", roleHandler.ListUserRoles).Methods("GET")
+	writeRoleRouter := permissionRouter(models.PermissionRolesWrite)
+	writeRoleRouter.HandleFunc("/roles", roleHandler.CreateRole).Methods("POST")
+	writeRoleRouter.HandleFunc("/roles/{id:[0-9]+}", roleHandler.UpdateRole).Methods("PUT")
+	writeRoleRouter.HandleFunc("/roles/{id:[0-9]+}", roleHandler.DeleteRole).Methods("DELETE")
+	writeRoleRouter.HandleFunc("/users/{id:[0-9]+}/roles", roleHandler.AssignRole).Methods("POST")
+	writeRoleRouter.HandleFunc("/users/{id:[0-9]+}/roles/sync", roleHandler.SyncRoleClaims).Methods("POST")
+	writeRoleRouter.HandleFunc("/users/{id:[0-9]+}/roles/{role_id:[0-9]+}", roleHandler.UnassignRole).Methods("DELETE")
+
+	// Clientes de API de otros servicios y sus keys
+	readClientRouter := permissionRouter(models.PermissionAPIClientsRead)
+	readClientRouter.HandleFunc("/api-clients", apiClientHandler.ListAPIClients).Methods("GET")
+	readClientRouter.HandleFunc("/api-clients/{id:[0-9]+}", apiClientHandler.GetAPIClient).Methods("GET")
+	writeClientRouter := permissionRouter(models.PermissionAPIClientsWrite)
+	writeClientRouter.HandleFunc("/api-clients", apiClientHandler.CreateAPIClient).Methods("POST")
+	writeClientRouter.HandleFunc("/api-clients/{id:[0-9]+}", apiClientHandler.UpdateAPIClient).Methods("PUT")
+	writeClientRouter.HandleFunc("/api-clients/{id:[0-9]+}", apiClientHandler.RevokeAPIClient).Methods("DELETE")
+	writeClientRouter.HandleFunc("/api-clients/{id:[0-9]+}/rotate", apiClientHandler.RotateAPIClientKey).Methods("POST")
+}
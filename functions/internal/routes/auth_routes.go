@@ -27,9 +27,13 @@ func SetupAuthRoutes(router *mux.Router, authHandler *handlers.AuthHandler, auth
 		
 		protectedAuthRouter.HandleFunc("/profile", authHandler.GetProfile).Methods("GET")
 		protectedAuthRouter.HandleFunc("/profile", authHandler.UpdateProfile).Methods("PUT")
-		protectedAuthRouter.HandleFunc("/change-password", authHandler.ChangePassword).Methods("POST")
-		protectedAuthRouter.HandleFunc("/revoke-tokens", authHandler.RevokeAllTokens).Methods("POST")
 		protectedAuthRouter.HandleFunc("/sessions", authHandler.GetActiveSessions).Methods("GET")
-		protectedAuthRouter.HandleFunc("/sessions/{session_id}", authHandler.RevokeSession).Methods("DELETE")
+
+		// Acciones sensibles: no se aceptan tokens de suplantación
+		sensitiveAuthRouter := authRouter.PathPrefix("").Subrouter()
+		sensitiveAuthRouter.Use(authMiddleware.RequireNoImpersonation)
+		sensitiveAuthRouter.HandleFunc("/change-password", authHandler.ChangePassword).Methods("POST")
+		sensitiveAuthRouter.HandleFunc("/revoke-tokens", authHandler.RevokeAllTokens).Methods("POST")
+		sensitiveAuthRouter.HandleFunc("/sessions/{session_id}", authHandler.RevokeSession).Methods("DELETE")
 	}
 }
\ No newline at end of file
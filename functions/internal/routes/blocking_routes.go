@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gorilla/mux"
+	"it-app_user/internal/handlers"
+)
+
+// SetupBlockingRoutes configura las blocking functions de Identity Platform.
+// Las llama Google con un JWT firmado en el body, así que no usan el
+// middleware de autenticación; sin Project ID de Firebase no existen.
+func SetupBlockingRoutes(router *mux.Router, blockingHandler *handlers.BlockingHandler) {
+	if blockingHandler == nil {
+		return
+	}
+
+	blockingRouter := router.PathPrefix("/auth/blocking").Subrouter()
+	blockingRouter.HandleFunc("/before-create", blockingHandler.BeforeCreate).Methods("POST")
+	blockingRouter.HandleFunc("/before-sign-in", blockingHandler.BeforeSignIn).Methods("POST")
+}
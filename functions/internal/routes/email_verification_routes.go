@@ -24,9 +24,13 @@ func SetupEmailVerificationRoutes(router *mux.Router, emailHandler *handlers.Ver
 		protectedEmailRouter.Use(authMiddleware.RequireAuth)
 		
 		protectedEmailRouter.HandleFunc("/my-status", emailHandler.GetMyVerificationStatus).Methods("GET")
-		protectedEmailRouter.HandleFunc("/update-email", emailHandler.UpdateEmail).Methods("POST")
 		protectedEmailRouter.HandleFunc("/verification-history", emailHandler.GetVerificationHistory).Methods("GET")
 		protectedEmailRouter.HandleFunc("/settings", emailHandler.GetEmailSettings).Methods("GET")
 		protectedEmailRouter.HandleFunc("/settings", emailHandler.UpdateEmailSettings).Methods("PUT")
+
+		// Cambiar el email no se acepta con un token de suplantación
+		sensitiveEmailRouter := emailRouter.PathPrefix("").Subrouter()
+		sensitiveEmailRouter.Use(authMiddleware.RequireNoImpersonation)
+		sensitiveEmailRouter.HandleFunc("/update-email", emailHandler.UpdateEmail).Methods("POST")
 	}
 }
\ No newline at end of file
@@ -25,8 +25,12 @@ func SetupLoginRoutes(router *mux.Router, loginHandler *handlers.LoginHandler, a
 		protectedLoginRouter.HandleFunc("/update-info/{id:[0-9]+}", loginHandler.UpdateLoginInfo).Methods("POST")
 		protectedLoginRouter.HandleFunc("/my-history", loginHandler.GetMyLoginHistory).Methods("GET")
 		protectedLoginRouter.HandleFunc("/active-sessions", loginHandler.GetActiveSessions).Methods("GET")
-		protectedLoginRouter.HandleFunc("/terminate-session/{session_id}", loginHandler.TerminateSession).Methods("DELETE")
-		protectedLoginRouter.HandleFunc("/terminate-all-sessions", loginHandler.TerminateAllSessions).Methods("DELETE")
 		protectedLoginRouter.HandleFunc("/suspicious-activity", loginHandler.GetSuspiciousActivity).Methods("GET")
+
+		// Cerrar sesiones no se acepta con un token de suplantación
+		sensitiveLoginRouter := loginRouter.PathPrefix("").Subrouter()
+		sensitiveLoginRouter.Use(authMiddleware.RequireNoImpersonation)
+		sensitiveLoginRouter.HandleFunc("/terminate-session/{session_id}", loginHandler.TerminateSession).Methods("DELETE")
+		sensitiveLoginRouter.HandleFunc("/terminate-all-sessions", loginHandler.TerminateAllSessions).Methods("DELETE")
 	}
 }
\ No newline at end of file
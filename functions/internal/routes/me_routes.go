@@ -0,0 +1,31 @@
+package routes
+
+import (
+	"github.com/gorilla/mux"
+	"it-app_user/internal/handlers"
+	"it-app_user/internal/middleware"
+)
+
+// SetupMeRoutes configura las rutas del usuario autenticado sobre sus propios datos.
+// Solo existen con autenticación: sin token no hay usuario al que referirse.
+func SetupMeRoutes(router *mux.Router, exportHandler *handlers.ExportHandler, accountDeletionHandler *handlers.AccountDeletionHandler, authMiddleware *middleware.AuthMiddleware) {
+	if authMiddleware == nil {
+		return
+	}
+
+	meRouter := router.PathPrefix("/me").Subrouter()
+	protectedMeRouter := meRouter.PathPrefix("").Subrouter()
+	protectedMeRouter.Use(authMiddleware.RequireAuth)
+	// Exportar y eliminar la cuenta no se aceptan con un token de suplantación
+	sensitiveMeRouter := meRouter.PathPrefix("").Subrouter()
+	sensitiveMeRouter.Use(authMiddleware.RequireNoImpersonation)
+
+	// Exportación de datos personales
+	sensitiveMeRouter.HandleFunc("/export", exportHandler.RequestExport).Methods("POST")
+	protectedMeRouter.HandleFunc("/export/{id:[0-9]+}", exportHandler.GetExport).Methods("GET")
+
+	// Eliminación de la propia cuenta
+	sensitiveMeRouter.HandleFunc("", accountDeletionHandler.DeleteMe).Methods("DELETE")
+	protectedMeRouter.HandleFunc("/deletion", accountDeletionHandler.GetDeletionStatus).Methods("GET")
+	sensitiveMeRouter.HandleFunc("/deletion/cancel", accountDeletionHandler.CancelDeletion).Methods("POST")
+}
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/gorilla/mux"
+	"it-app_user/internal/metrics"
+	"it-app_user/internal/middleware"
+	"it-app_user/internal/models"
+)
+
+// SetupMetricsRoutes configura /metrics. Las métricas exponen las rutas, las
+// políticas de rate limiting y el volumen de tráfico, así que solo las leen
+// usuarios con metrics:read o el scraper con la API key de un cliente con ese
+// scope; sin Firebase no hay quién lo autorice y la ruta no existe.
+func SetupMetricsRoutes(router *mux.Router, authMiddleware *middleware.AuthMiddleware) {
+	if authMiddleware == nil {
+		return
+	}
+
+	metricsRouter := router.PathPrefix("/metrics").Subrouter()
+	metricsRouter.Use(authMiddleware.RequirePermissionOrService(models.PermissionMetricsRead))
+	metricsRouter.Handle("", metrics.Handler()).Methods("GET")
+}
@@ -0,0 +1,81 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"it-app_user/internal/firebasetest"
+	"it-app_user/internal/middleware"
+	"it-app_user/internal/models"
+	"it-app_user/internal/principal"
+)
+
+func TestMetricsRoutesWithoutAuth(t *testing.T) {
+	router := mux.NewRouter()
+	SetupMetricsRoutes(router, nil)
+
+	var match mux.RouteMatch
+	if router.Match(httptest.NewRequest(http.MethodGet, "/metrics", nil), &match) && match.MatchErr == nil {
+		t.Error("GET /metrics registered without authentication")
+	}
+}
+
+func TestMetricsRequiresReadPermission(t *testing.T) {
+	firebaseAuth, _ := firebasetest.NewAuth(t, map[string]*firebasetest.Account{"uid-ana": {LocalID: "uid-ana"}})
+	authMiddleware := middleware.NewAuthMiddleware(firebaseAuth)
+	// El rol 2 solo puede leer usuarios y el 3 también las métricas
+	authMiddleware.SetPermissionLookup(func(ctx context.Context, roleIDs []uint) (map[string]bool, error) {
+		granted := map[string]bool{}
+		for _, id := range roleIDs {
+			granted[models.PermissionUsersRead] = true
+			if id == 3 {
+				granted[models.PermissionMetricsRead] = true
+			}
+		}
+		return granted, nil
+	})
+	clients := map[string]principal.Principal{
+		models.APIKeyPrefix + "prometheus": {ClientID: "prometheus", ClientScopes: []string{models.PermissionMetricsRead}},
+		models.APIKeyPrefix + "billing":    {ClientID: "billing", ClientScopes: []string{models.PermissionTokensIntrospect}},
+	}
+	authMiddleware.SetClientLookup(func(ctx context.Context, key string) (principal.Principal, bool, error) {
+		p, ok := clients[key]
+		return p, ok, nil
+	})
+
+	router := mux.NewRouter()
+	SetupMetricsRoutes(router, authMiddleware)
+
+	tests := []struct {
+		name   string
+		token  string
+		apiKey string
+		status int
+	}{
+		{name: "anonymous", status: http.StatusUnauthorized},
+		{name: "read-only role", token: firebasetest.IDToken(t, "uid-ana", map[string]interface{}{"roles": models.EncodeRoleSet([]uint{2})}), status: http.StatusForbidden},
+		{name: "role with metrics:read", token: firebasetest.IDToken(t, "uid-ana", map[string]interface{}{"roles": models.EncodeRoleSet([]uint{3})}), status: http.StatusOK},
+		{name: "admin", token: firebasetest.IDToken(t, "uid-ana", map[string]interface{}{"admin": true}), status: http.StatusOK},
+		{name: "client with metrics:read", apiKey: models.APIKeyPrefix + "prometheus", status: http.StatusOK},
+		{name: "client without the scope", apiKey: models.APIKeyPrefix + "billing", status: http.StatusForbidden},
+		{name: "unknown API key", apiKey: models.APIKeyPrefix + "unknown", status: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if tt.token != "" {
+			r.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		if tt.apiKey != "" {
+			r.Header.Set(middleware.HeaderAPIKey, tt.apiKey)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d: %s", tt.name, w.Code, tt.status, w.Body)
+		}
+	}
+}
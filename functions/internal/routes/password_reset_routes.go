@@ -22,9 +22,13 @@ func SetupPasswordResetRoutes(router *mux.Router, passwordResetHandler *handlers
 		protectedPasswordRouter := passwordRouter.PathPrefix("").Subrouter()
 		protectedPasswordRouter.Use(authMiddleware.RequireAuth)
 		
-		protectedPasswordRouter.HandleFunc("/change", passwordResetHandler.ChangePassword).Methods("POST")
 		protectedPasswordRouter.HandleFunc("/strength-check", passwordResetHandler.CheckPasswordStrength).Methods("POST")
 		protectedPasswordRouter.HandleFunc("/history", passwordResetHandler.GetPasswordHistory).Methods("GET")
 		protectedPasswordRouter.HandleFunc("/policy", passwordResetHandler.GetPasswordPolicy).Methods("GET")
+
+		// Cambiar la contraseña no se acepta con un token de suplantación
+		sensitivePasswordRouter := passwordRouter.PathPrefix("").Subrouter()
+		sensitivePasswordRouter.Use(authMiddleware.RequireNoImpersonation)
+		sensitivePasswordRouter.HandleFunc("/change", passwordResetHandler.ChangePassword).Methods("POST")
 	}
 }
\ No newline at end of file
@@ -1,20 +1,47 @@
 package routes
 
 import (
+	"context"
 	"net/http"
 	"time"
 
 	"github.com/gorilla/mux"
-	"golang.org/x/time/rate"
 	
+	"it-app_user/internal/clientip"
 	"it-app_user/internal/handlers"
+	"it-app_user/internal/health"
 	"it-app_user/internal/middleware"
 	"it-app_user/internal/models"
 	"it-app_user/internal/repositories"
+	"it-app_user/internal/services"
+	"it-app_user/internal/storage"
 	"it-app_user/pkg/firebase"
 )
 
-func SetupRoutes(firebaseAuth *firebase.Auth, rateLimitRPS int, rateLimitBurst int) *mux.Router {
+// Deps son las dependencias que SetupRoutes no crea: los subsistemas que
+// NewServer inicializa a partir de la configuración
+type Deps struct {
+	FirebaseAuth     *firebase.Auth
+	RateLimiter      *middleware.RateLimiter
+	IPResolver       *clientip.Resolver
+	CORSPolicy       *middleware.CORSPolicy
+	Timeouts         *middleware.TimeoutMiddleware
+	Health           *health.Registry
+	Deletion         *services.UserDeletionService
+	Exports          *services.DataExportService
+	ExportDownloads  http.Handler
+	AccountDeletion  *services.AccountDeletionService
+	Audit            *services.AuditService
+	AdminUsers       *services.AdminUserService
+	EmailSettings    models.EmailVerificationSettings
+	Risk             *services.RiskService
+	BlockingVerifier *firebase.BlockingTokenVerifier
+}
+
+// languageCacheTTL es cuánto se reutiliza el idioma guardado de cada usuario
+const languageCacheTTL = 30 * time.Second
+
+func SetupRoutes(deps Deps) *mux.Router {
 	router := mux.NewRouter()
 	
 	// Crear repositorios
@@ -22,44 +49,102 @@ func SetupRoutes(firebaseAuth *firebase.Auth, rateLimitRPS int, rateLimitBurst i
 	userRepo := repositories.NewUserRepository(db)
 	emailRepo := repositories.NewEmailVerificationRepository(db)
 	passwordRepo := repositories.NewPasswordResetRepository(db)
+	settingsRepo := repositories.NewUserSettingsRepository(db)
+	txManager := repositories.NewTxManager(db)
+	
+	// Crear servicios
+	usernameService := services.NewUsernameService(userRepo)
+	userService := services.NewUserService(txManager, usernameService)
+	historyService := services.NewUserHistoryService(repositories.NewRecordVersionRepository(db), txManager)
+	roleService := services.NewRoleService(txManager, repositories.NewRoleRepository(db), userRepo, deps.FirebaseAuth)
+	apiClientService := services.NewAPIClientService(repositories.NewRepositories(db), txManager)
+	introspectionService := services.NewTokenIntrospectionService(deps.FirebaseAuth, userRepo, repositories.NewRoleRepository(db))
 	
 	// Crear handlers
-	userHandler := handlers.NewUserHandler(userRepo)
-	authHandler := handlers.NewAuthHandler(firebaseAuth)
-	tokenHandler := handlers.NewTokenHandler(firebaseAuth)
-	passwordResetHandler := handlers.NewPasswordResetHandler(firebaseAuth, passwordRepo)
-	emailHandler := handlers.NewVerifyEmailHandler(firebaseAuth, emailRepo)
-	loginHandler := handlers.NewLoginHandler(firebaseAuth)
+	userHandler := handlers.NewUserHandler(userRepo, userService, usernameService, deps.Deletion)
+	authHandler := handlers.NewAuthHandler(deps.FirebaseAuth, userService)
+	tokenHandler := handlers.NewTokenHandler(deps.FirebaseAuth)
+	introspectionHandler := handlers.NewTokenIntrospectionHandler(introspectionService)
+	passwordResetHandler := handlers.NewPasswordResetHandler(deps.FirebaseAuth, passwordRepo)
+	emailHandler := handlers.NewVerifyEmailHandler(deps.FirebaseAuth, emailRepo, deps.EmailSettings)
+	loginHandler := handlers.NewLoginHandler(deps.FirebaseAuth, deps.Risk)
+	exportHandler := handlers.NewExportHandler(deps.Exports)
+	accountDeletionHandler := handlers.NewAccountDeletionHandler(deps.AccountDeletion)
+	auditHandler := handlers.NewAuditHandler(deps.Audit)
+	historyHandler := handlers.NewUserHistoryHandler(historyService)
+	roleHandler := handlers.NewRoleHandler(roleService)
+	adminUserHandler := handlers.NewAdminUserHandler(deps.AdminUsers)
+	apiClientHandler := handlers.NewAPIClientHandler(apiClientService)
+	var blockingHandler *handlers.BlockingHandler
+	if deps.BlockingVerifier != nil {
+		blockingHandler = handlers.NewBlockingHandler(deps.BlockingVerifier, services.NewSignInPolicyService(userRepo, deps.Risk))
+	}
 	
-	// Middleware global
-	rateLimiter := middleware.NewRateLimiter(
-		rate.Every(time.Second/time.Duration(rateLimitRPS)), 
-		rateLimitBurst,
-	)
+	// Middleware global (la IP del cliente se resuelve antes que todo lo demás)
+	router.Use(middleware.ClientIPMiddleware(deps.IPResolver))
+	router.Use(middleware.RequestInfoMiddleware)
 	router.Use(middleware.LoggingMiddleware)
-	router.Use(rateLimiter.Middleware)
-	router.Use(middleware.CORSMiddleware)
+	router.Use(middleware.LanguageMiddleware)
+	// CORS va antes de los timeouts y del rate limiting para que los 429 y los
+	// 503 también lleven sus headers y el navegador deje leerlos
+	router.Use(deps.CORSPolicy.Middleware)
+	router.Use(deps.Timeouts.Middleware)
+	router.Use(deps.RateLimiter.Middleware)
+
+	// mux solo ejecuta los middlewares si una ruta acepta el método, así que
+	// los preflight OPTIONS necesitan su propia ruta para pasar por CORS
+	router.Methods(http.MethodOptions).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
 	
 	// Middleware de autenticación (opcional)
 	var authMiddleware *middleware.AuthMiddleware
-	if firebaseAuth != nil {
-		authMiddleware = middleware.NewAuthMiddleware(firebaseAuth)
+	if deps.FirebaseAuth != nil {
+		authMiddleware = middleware.NewAuthMiddleware(deps.FirebaseAuth)
+		// El idioma guardado en las configuraciones tiene prioridad sobre
+		// Accept-Language; se cachea para no consultarlo en cada request
+		authMiddleware.SetLanguageLookup(middleware.CachedLanguageLookup(func(ctx context.Context, firebaseID string) (string, bool) {
+			settings, err := settingsRepo.GetByFirebaseID(ctx, firebaseID)
+			if err != nil {
+				return "", false
+			}
+			return settings.Language, true
+		}, languageCacheTTL))
+		// Los permisos de cada rol del token se resuelven con la base de datos
+		authMiddleware.SetPermissionLookup(roleService.Permissions)
+		// Los servicios internos se autentican con las API keys de sus clientes
+		authMiddleware.SetClientLookup(apiClientService.Principal)
+		// Las políticas por usuario necesitan el UID del token
+		authMiddleware.Use(deps.RateLimiter.UserMiddleware)
 	}
 	
-	// Rutas de salud
-	router.HandleFunc("/health", userHandler.HealthCheck).Methods("GET")
+	// Rutas de salud: /healthz (liveness) no consulta dependencias, /readyz (readiness) sí.
+	// /health se mantiene por compatibilidad y equivale a /readyz.
+	router.HandleFunc("/healthz", health.LivenessHandler).Methods("GET")
+	router.HandleFunc("/readyz", deps.Health.ReadinessHandler).Methods("GET")
+	router.HandleFunc("/health", deps.Health.ReadinessHandler).Methods("GET")
 	router.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("pong"))
 	}).Methods("GET")
+
+	// Descargas firmadas del almacenamiento local de exportaciones (con Cloud
+	// Storage los links apuntan directamente al bucket)
+	if deps.ExportDownloads != nil {
+		router.Handle(storage.LocalDownloadPath, deps.ExportDownloads).Methods("GET")
+	}
 	
 	// Configurar todas las rutas por módulos
 	SetupUserRoutes(router, userHandler, authMiddleware)
 	SetupAuthRoutes(router, authHandler, authMiddleware)
-	SetupTokenRoutes(router, tokenHandler, authMiddleware)
+	SetupTokenRoutes(router, tokenHandler, introspectionHandler, authMiddleware)
 	SetupPasswordResetRoutes(router, passwordResetHandler, authMiddleware)
 	SetupEmailVerificationRoutes(router, emailHandler, authMiddleware)
 	SetupLoginRoutes(router, loginHandler, authMiddleware)
+	SetupMeRoutes(router, exportHandler, accountDeletionHandler, authMiddleware)
+	SetupAdminRoutes(router, auditHandler, historyHandler, roleHandler, adminUserHandler, apiClientHandler, authMiddleware)
+	SetupBlockingRoutes(router, blockingHandler)
+	SetupMetricsRoutes(router, authMiddleware)
 	
 	return router
 }
\ No newline at end of file
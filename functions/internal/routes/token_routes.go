@@ -4,10 +4,11 @@ import (
 	"github.com/gorilla/mux"
 	"it-app_user/internal/handlers"
 	"it-app_user/internal/middleware"
+	"it-app_user/internal/models"
 )
 
 // SetupTokenRoutes configura todas las rutas relacionadas con tokens
-func SetupTokenRoutes(router *mux.Router, tokenHandler *handlers.TokenHandler, authMiddleware *middleware.AuthMiddleware) {
+func SetupTokenRoutes(router *mux.Router, tokenHandler *handlers.TokenHandler, introspectionHandler *handlers.TokenIntrospectionHandler, authMiddleware *middleware.AuthMiddleware) {
 	// Subrouter para tokens
 	tokenRouter := router.PathPrefix("/tokens").Subrouter()
 	
@@ -21,9 +22,19 @@ func SetupTokenRoutes(router *mux.Router, tokenHandler *handlers.TokenHandler, a
 		protectedTokenRouter := tokenRouter.PathPrefix("").Subrouter()
 		protectedTokenRouter.Use(authMiddleware.RequireAuth)
 		
-		protectedTokenRouter.HandleFunc("/revoke", tokenHandler.RevokeToken).Methods("POST")
-		protectedTokenRouter.HandleFunc("/revoke-all", tokenHandler.RevokeAllTokens).Methods("POST")
 		protectedTokenRouter.HandleFunc("/info", tokenHandler.GetTokenInfo).Methods("GET")
 		protectedTokenRouter.HandleFunc("/validate", tokenHandler.ValidateToken).Methods("POST")
+
+		// Revocar tokens no se acepta con un token de suplantación
+		sensitiveTokenRouter := tokenRouter.PathPrefix("").Subrouter()
+		sensitiveTokenRouter.Use(authMiddleware.RequireNoImpersonation)
+		sensitiveTokenRouter.HandleFunc("/revoke", tokenHandler.RevokeToken).Methods("POST")
+		sensitiveTokenRouter.HandleFunc("/revoke-all", tokenHandler.RevokeAllTokens).Methods("POST")
+
+		// Introspección (RFC 7662) para gateways y servicios: el token a
+		// consultar va en el formulario, no en Authorization
+		introspectionRouter := tokenRouter.PathPrefix("").Subrouter()
+		introspectionRouter.Use(authMiddleware.RequirePermissionOrService(models.PermissionTokensIntrospect))
+		introspectionRouter.HandleFunc("/introspect", introspectionHandler.IntrospectToken).Methods("POST")
 	}
 }
\ No newline at end of file
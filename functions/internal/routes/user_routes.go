@@ -4,6 +4,7 @@ import (
 	"github.com/gorilla/mux"
 	"it-app_user/internal/handlers"
 	"it-app_user/internal/middleware"
+	"it-app_user/internal/models"
 )
 
 // SetupUserRoutes configura todas las rutas relacionadas con usuarios
@@ -16,8 +17,8 @@ func SetupUserRoutes(router *mux.Router, userHandler *handlers.UserHandler, auth
 	userRouter.HandleFunc("/create", userHandler.CreateUser).Methods("POST") // Registro público
 	userRouter.HandleFunc("/test", userHandler.TestConnection).Methods("POST") // Test para Flutter
 	userRouter.HandleFunc("/{id:[0-9]+}", userHandler.GetUserByID).Methods("GET")
-	userRouter.HandleFunc("/firebase/{firebase_id}", userHandler.GetUserByFirebaseID).Methods("GET")
 	userRouter.HandleFunc("/username/{username}", userHandler.GetUserByUsername).Methods("GET")
+	userRouter.HandleFunc("/username-availability", userHandler.CheckUsernameAvailability).Methods("GET")
 	userRouter.HandleFunc("/email/{email}", userHandler.GetUserByEmail).Methods("GET")
 	userRouter.HandleFunc("/search", userHandler.SearchUsers).Methods("GET")
 	userRouter.HandleFunc("/count", userHandler.CountUsers).Methods("GET")
@@ -27,22 +28,37 @@ func SetupUserRoutes(router *mux.Router, userHandler *handlers.UserHandler, auth
 		protectedUserRouter := userRouter.PathPrefix("").Subrouter()
 		protectedUserRouter.Use(authMiddleware.RequireAuth)
 		
-		// CRUD protegido (create está en rutas públicas para registro)
-		protectedUserRouter.HandleFunc("/{id:[0-9]+}", userHandler.UpdateUser).Methods("PUT")
-		protectedUserRouter.HandleFunc("/{id:[0-9]+}", userHandler.DeleteUser).Methods("DELETE")
-		
 		// Operaciones específicas
 		protectedUserRouter.HandleFunc("/{id:[0-9]+}/login", userHandler.UpdateLoginInfo).Methods("POST")
 		protectedUserRouter.HandleFunc("/active", userHandler.GetActiveUsers).Methods("GET")
 		protectedUserRouter.HandleFunc("/{id:[0-9]+}/profile", userHandler.GetUserProfile).Methods("GET")
 		protectedUserRouter.HandleFunc("/{id:[0-9]+}/settings", userHandler.GetUserSettings).Methods("GET")
 		protectedUserRouter.HandleFunc("/{id:[0-9]+}/stats", userHandler.GetUserStats).Methods("GET")
+
+		// Consultas de otros servicios: aceptan un usuario autenticado o una API
+		// key de un cliente con el scope users:read
+		serviceUserRouter := userRouter.PathPrefix("").Subrouter()
+		serviceUserRouter.Use(authMiddleware.RequireUserOrService(models.PermissionUsersRead))
+		serviceUserRouter.HandleFunc("/firebase/{firebase_id}", userHandler.GetUserByFirebaseID).Methods("GET")
+
+		// CRUD protegido (create está en rutas públicas para registro). Modificar
+		// a cualquier usuario requiere permisos; el propio perfil se edita en /auth/profile
+		writeUserRouter := userRouter.PathPrefix("").Subrouter()
+		writeUserRouter.Use(authMiddleware.RequirePermission(models.PermissionUsersWrite))
+		writeUserRouter.HandleFunc("/{id:[0-9]+}", userHandler.UpdateUser).Methods("PUT")
+		writeUserRouter.HandleFunc("/{id:[0-9]+}/restore", userHandler.RestoreUser).Methods("POST")
+
+		deleteUserRouter := userRouter.PathPrefix("").Subrouter()
+		deleteUserRouter.Use(authMiddleware.RequirePermission(models.PermissionUsersDelete))
+		deleteUserRouter.HandleFunc("/{id:[0-9]+}", userHandler.DeleteUser).Methods("DELETE")
 	} else {
 		// Si no hay autenticación, todas las rutas son públicas (desarrollo)
-		// create ya está en rutas públicas arriba
+		// create ya está en rutas públicas arriba. Restore no se registra: sin
+		// Firebase cualquiera podría restaurar cuentas eliminadas.
 		userRouter.HandleFunc("/{id:[0-9]+}", userHandler.UpdateUser).Methods("PUT")
 		userRouter.HandleFunc("/{id:[0-9]+}", userHandler.DeleteUser).Methods("DELETE")
 		userRouter.HandleFunc("/{id:[0-9]+}/login", userHandler.UpdateLoginInfo).Methods("POST")
 		userRouter.HandleFunc("/active", userHandler.GetActiveUsers).Methods("GET")
+		userRouter.HandleFunc("/firebase/{firebase_id}", userHandler.GetUserByFirebaseID).Methods("GET")
 	}
 }
\ No newline at end of file
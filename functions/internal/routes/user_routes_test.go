@@ -0,0 +1,79 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"it-app_user/internal/firebasetest"
+	"it-app_user/internal/handlers"
+	"it-app_user/internal/middleware"
+	"it-app_user/internal/models"
+)
+
+func TestUserRoutesWithoutAuth(t *testing.T) {
+	router := mux.NewRouter()
+	SetupUserRoutes(router, &handlers.UserHandler{}, nil)
+
+	tests := []struct {
+		method     string
+		path       string
+		registered bool
+	}{
+		{method: http.MethodGet, path: "/users/5", registered: true},
+		{method: http.MethodDelete, path: "/users/5", registered: true},
+		// Sin Firebase no hay quién autorice la restauración de cuentas eliminadas
+		{method: http.MethodPost, path: "/users/5/restore", registered: false},
+	}
+	for _, tt := range tests {
+		var match mux.RouteMatch
+		matched := router.Match(httptest.NewRequest(tt.method, tt.path, nil), &match) && match.MatchErr == nil
+		if matched != tt.registered {
+			t.Errorf("%s %s registered = %v, want %v", tt.method, tt.path, matched, tt.registered)
+		}
+	}
+}
+
+func TestUpdateUserRequiresWritePermission(t *testing.T) {
+	firebaseAuth, _ := firebasetest.NewAuth(t, map[string]*firebasetest.Account{"uid-ana": {LocalID: "uid-ana"}})
+	authMiddleware := middleware.NewAuthMiddleware(firebaseAuth)
+	// El rol 2 solo puede leer usuarios y el 3 también modificarlos
+	authMiddleware.SetPermissionLookup(func(ctx context.Context, roleIDs []uint) (map[string]bool, error) {
+		granted := map[string]bool{}
+		for _, id := range roleIDs {
+			granted[models.PermissionUsersRead] = true
+			if id == 3 {
+				granted[models.PermissionUsersWrite] = true
+			}
+		}
+		return granted, nil
+	})
+
+	router := mux.NewRouter()
+	SetupUserRoutes(router, &handlers.UserHandler{}, authMiddleware)
+
+	tests := []struct {
+		name   string
+		claims map[string]interface{}
+		status int
+	}{
+		{name: "without roles", status: http.StatusForbidden},
+		{name: "read-only role", claims: map[string]interface{}{"roles": models.EncodeRoleSet([]uint{2})}, status: http.StatusForbidden},
+		// El handler rechaza el body inválido: la request pasó la autorización
+		{name: "role with users:write", claims: map[string]interface{}{"roles": models.EncodeRoleSet([]uint{3})}, status: http.StatusBadRequest},
+		{name: "admin", claims: map[string]interface{}{"admin": true}, status: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodPut, "/users/5", strings.NewReader("{"))
+		r.Header.Set("Authorization", "Bearer "+firebasetest.IDToken(t, "uid-ana", tt.claims))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, r)
+		if w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d: %s", tt.name, w.Code, tt.status, w.Body)
+		}
+	}
+}
@@ -1,73 +1,487 @@
 package server
 
 import (
+	"context"
+	"crypto/rand"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/redis/go-redis/v9"
 
+	"it-app_user/internal/audit"
+	"it-app_user/internal/clientip"
 	"it-app_user/internal/config"
+	"it-app_user/internal/health"
+	"it-app_user/internal/jobs"
 	"it-app_user/internal/logger"
+	"it-app_user/internal/mail"
+	"it-app_user/internal/middleware"
 	"it-app_user/internal/models"
+	"it-app_user/internal/repositories"
 	"it-app_user/internal/routes"
+	"it-app_user/internal/services"
+	"it-app_user/internal/storage"
 	"it-app_user/pkg/firebase"
 )
 
+// PurgeUsersJob es el nombre del job que purga los usuarios eliminados cuyo período de gracia venció
+const PurgeUsersJob = "purge_deleted_users"
+
+// ProcessExportsJob es el nombre del job que genera las exportaciones de datos
+// pendientes o abandonadas y elimina los archivos vencidos
+const ProcessExportsJob = "process_data_exports"
+
+// CompleteAccountDeletionsJob es el nombre del job que elimina las cuentas
+// cuya ventana de cancelación venció
+const CompleteAccountDeletionsJob = "complete_account_deletions"
+
+// PruneAuditEventsJob es el nombre del job que aplica la retención del log de auditoría
+const PruneAuditEventsJob = "prune_audit_events"
+
+// PruneProcessedEventsJob es el nombre del job que elimina los IDs de eventos
+// de Firebase Auth ya procesados que no pueden reenviarse
+const PruneProcessedEventsJob = "prune_processed_events"
+
+// shutdownTimeout es cuánto espera Start a las requests en curso al apagarse
+const shutdownTimeout = 30 * time.Second
+
+// ReconcileUsersJob es el nombre del job que compara los usuarios de Firebase
+// Auth con los de la base de datos
+const ReconcileUsersJob = "reconcile_users"
+
 type Server struct {
-	config       config.Config
+	config       *config.Config
 	router       *mux.Router
 	firebaseAuth *firebase.Auth
+	rateLimiter  *middleware.RateLimiter
+	reconciler   *services.UserReconciliationService
+	authEvents   *services.AuthEventService
+	jobs         *jobs.Scheduler
 }
 
-func NewServer(cfg config.Config) (*Server, error) {
+// NewServer inicializa todos los subsistemas a partir de la configuración ya validada
+func NewServer(cfg *config.Config) (*Server, error) {
 	// Inicializar logger
-	logger.Init()
+	logger.Init(cfg.Log.Level)
 	log := logger.GetLogger()
 
 	// Conectar a la base de datos
-	models.ConnectDB()
+	if err := models.ConnectDB(cfg.Database); err != nil {
+		return nil, err
+	}
 	
-	// Ejecutar migraciones
+	// Ejecutar migraciones (las columnas canónicas dependen de las reglas de email)
+	models.SetEmailProviderRules(cfg.Users.EmailProviderRules)
 	models.MigrateDB()
 
 	// Inicializar Firebase Auth (opcional)
 	var firebaseAuth *firebase.Auth
 	var err error
-	if cfg.FirebaseProjectID != "" {
-		firebaseAuth, err = firebase.NewAuth("firebase-service-account.json")
+	if cfg.Firebase.ProjectID != "" {
+		firebaseAuth, err = firebase.NewAuth(cfg.Firebase.CredentialsFile, cfg.Firebase.ProjectID)
 		if err != nil {
 			log.WithError(err).Warn("Failed to initialize Firebase Auth, continuing without it")
 		}
 	}
 
-	// Crear servidor
+	// Resolución de la IP del cliente detrás de proxies de confianza
+	ipResolver, err := clientip.NewResolver(cfg.TrustedProxies, cfg.ClientIPHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	// Política CORS única para todo el servicio
+	corsPolicy, err := middleware.NewCORSPolicy(middleware.CORSConfig{
+		AllowedOrigins:   cfg.CORS.AllowedOrigins,
+		AllowedMethods:   cfg.CORS.AllowedMethods,
+		AllowedHeaders:   cfg.CORS.AllowedHeaders,
+		ExposedHeaders:   cfg.CORS.ExposedHeaders,
+		AllowCredentials: cfg.CORS.AllowCredentials,
+		MaxAge:           cfg.CORS.MaxAge,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Deadline por request, con timeouts específicos por ruta
+	routeTimeouts := make([]middleware.RouteTimeout, 0, len(cfg.Server.RouteTimeouts))
+	for _, rt := range cfg.Server.RouteTimeouts {
+		methods := make([]string, len(rt.Methods))
+		for i, m := range rt.Methods {
+			methods[i] = strings.ToUpper(m)
+		}
+		routeTimeouts = append(routeTimeouts, middleware.RouteTimeout{
+			Routes:  rt.Routes,
+			Methods: methods,
+			Timeout: time.Duration(rt.Timeout),
+		})
+	}
+	timeouts := middleware.NewTimeoutMiddleware(time.Duration(cfg.Server.RequestTimeout), routeTimeouts)
+
+	// Checkers de /readyz
+	healthRegistry := health.NewRegistry(time.Duration(cfg.Health.CheckTimeout), time.Duration(cfg.Health.CacheTTL))
+	healthRegistry.Register(health.DatabaseChecker(models.GetDB()))
+	healthRegistry.Register(health.MigrationChecker(models.GetDB()))
+	healthRegistry.Register(health.CanonicalIndexChecker(models.GetDB()))
+	if cfg.Firebase.ProjectID != "" && cfg.Health.CheckFirebase {
+		healthRegistry.Register(health.FirebaseChecker(firebaseAuth))
+	}
+
+	// Log de auditoría: desde acá los eventos también se guardan en audit_events
+	db := models.GetDB()
+	auditRepo := repositories.NewAuditEventRepository(db)
+	audit.SetStore(auditRepo)
+	auditService := services.NewAuditService(auditRepo, time.Duration(cfg.Audit.Retention))
+
+	// Borrado lógico de usuarios y job de purga al vencer el período de gracia
+	deletionService := services.NewUserDeletionService(
+		repositories.NewUserRepository(db),
+		repositories.NewTxManager(db),
+		firebaseAuth,
+		time.Duration(cfg.Users.DeletionGracePeriod),
+		cfg.Users.PurgeBatchSize,
+	)
+
+	// Exportación de datos personales: archivos en el almacenamiento
+	// configurado y job que genera las pendientes y elimina las vencidas
+	exportStore, downloads, err := newExportStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	exportService := services.NewDataExportService(
+		repositories.NewRepositories(db),
+		firebaseAuth,
+		exportStore,
+		time.Duration(cfg.Export.URLTTL),
+		time.Duration(cfg.Export.Retention),
+	)
+	if local, ok := exportStore.(*storage.LocalStore); ok {
+		local.OnDownload = exportService.RecordDownload
+	}
+	deletionService.SetExports(repositories.NewDataExportRepository(db), exportStore)
+
+	// Eliminación de la cuenta por el propio usuario: confirmación por email
+	// y job que elimina las cuentas al vencer la ventana de cancelación
+	mailer, err := newMailer(cfg)
+	if err != nil {
+		return nil, err
+	}
+	accountDeletionService := services.NewAccountDeletionService(
+		repositories.NewRepositories(db),
+		deletionService,
+		mailer,
+		time.Duration(cfg.Users.DeletionReauthWindow),
+		time.Duration(cfg.Users.DeletionCodeTTL),
+		time.Duration(cfg.Users.DeletionCancelWindow),
+	)
+
+	// Administración de cuentas (/admin/users): los links de contraseña e
+	// invitación se envían con el mismo mailer
+	adminUserService := services.NewAdminUserService(
+		repositories.NewRepositories(db),
+		repositories.NewTxManager(db),
+		firebaseAuth,
+		mailer,
+		auditService,
+	)
+
+	// Reconciliación con Firebase Auth: a demanda (CLI o Cloud Scheduler) o
+	// cada USERS_RECONCILE_INTERVAL
+	reconciler := services.NewUserReconciliationService(
+		repositories.NewUserRepository(db),
+		firebaseAuth,
+		deletionService,
+		cfg.Users.ReconcileMaxDeletions,
+	)
+
+	// Eventos de creación y eliminación de usuarios de Firebase Auth (Cloud
+	// Function AuthEvents) y job que olvida los IDs de eventos viejos
+	userRepo := repositories.NewUserRepository(db)
+	authEventService := services.NewAuthEventService(
+		repositories.NewTxManager(db),
+		repositories.NewProcessedEventRepository(db),
+		services.NewUserService(repositories.NewTxManager(db), services.NewUsernameService(userRepo)),
+		deletionService,
+	)
+
+	// Políticas de registro e inicio de sesión: las aplican las blocking
+	// functions de Identity Platform y las usa /login/security-check
+	emailSettings := models.DefaultEmailVerificationSettings(cfg.Security.BlockedEmailDomains)
+	riskService, err := services.NewRiskService(emailSettings, cfg.Security.BlockedCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	var blockingVerifier *firebase.BlockingTokenVerifier
+	switch {
+	case cfg.Firebase.ProjectID == "":
+	case len(cfg.Firebase.BlockingAudiences) == 0:
+		// Sin las URLs registradas no hay audiencia con la que validar los tokens
+		logger.GetLogger().Warn("FIREBASE_BLOCKING_AUDIENCES not set, blocking functions disabled")
+	default:
+		blockingVerifier = firebase.NewBlockingTokenVerifier(cfg.Firebase.ProjectID, cfg.Firebase.BlockingAudiences)
+	}
+
+	scheduler := jobs.NewScheduler()
+	scheduler.Register(jobs.Job{
+		Name:     PurgeUsersJob,
+		Interval: time.Duration(cfg.Users.PurgeInterval),
+		Timeout:  10 * time.Minute,
+		Run: func(ctx context.Context) error {
+			_, err := deletionService.PurgeExpired(ctx)
+			return err
+		},
+	})
+	scheduler.Register(jobs.Job{
+		Name:     ProcessExportsJob,
+		Interval: time.Duration(cfg.Export.ProcessInterval),
+		Timeout:  10 * time.Minute,
+		Run:      exportService.ProcessPending,
+	})
+	scheduler.Register(jobs.Job{
+		Name:     CompleteAccountDeletionsJob,
+		Interval: time.Duration(cfg.Users.PurgeInterval),
+		Timeout:  10 * time.Minute,
+		Run: func(ctx context.Context) error {
+			_, err := accountDeletionService.CompleteDue(ctx)
+			return err
+		},
+	})
+	scheduler.Register(jobs.Job{
+		Name:     PruneAuditEventsJob,
+		Interval: time.Duration(cfg.Audit.PruneInterval),
+		Timeout:  10 * time.Minute,
+		Run: func(ctx context.Context) error {
+			_, err := auditService.Prune(ctx)
+			return err
+		},
+	})
+	scheduler.Register(jobs.Job{
+		Name:     ReconcileUsersJob,
+		Interval: time.Duration(cfg.Users.ReconcileInterval),
+		Timeout:  30 * time.Minute,
+		Run: func(ctx context.Context) error {
+			_, err := reconciler.Run(ctx, cfg.Users.ReconcileDryRun)
+			return err
+		},
+	})
+	scheduler.Register(jobs.Job{
+		Name:     PruneProcessedEventsJob,
+		Interval: time.Duration(cfg.Users.PurgeInterval),
+		Timeout:  10 * time.Minute,
+		Run: func(ctx context.Context) error {
+			_, err := authEventService.Prune(ctx)
+			return err
+		},
+	})
+
+	// Rate limiting con store compartido y políticas por ruta. Va al final:
+	// el store arranca una limpieza en segundo plano que quedaría huérfana si
+	// algo de arriba falla y getServer (function.go) vuelve a intentarlo
+	rateLimiter, err := newRateLimiter(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Crear servidor con las rutas de routes.go
 	server := &Server{
 		config:       cfg,
 		firebaseAuth: firebaseAuth,
+		rateLimiter:  rateLimiter,
+		reconciler:   reconciler,
+		authEvents:   authEventService,
+		jobs:         scheduler,
+		router: routes.SetupRoutes(routes.Deps{
+			FirebaseAuth:     firebaseAuth,
+			RateLimiter:      rateLimiter,
+			IPResolver:       ipResolver,
+			CORSPolicy:       corsPolicy,
+			Timeouts:         timeouts,
+			Health:           healthRegistry,
+			Deletion:         deletionService,
+			Exports:          exportService,
+			ExportDownloads:  downloads,
+			AccountDeletion:  accountDeletionService,
+			Audit:            auditService,
+			AdminUsers:       adminUserService,
+			EmailSettings:    emailSettings,
+			Risk:             riskService,
+			BlockingVerifier: blockingVerifier,
+		}),
 	}
 
-	// Configurar rutas
-	server.setupRoutes()
-
 	return server, nil
 }
 
-func (s *Server) setupRoutes() {
-	// Usar el router de routes.go
-	s.router = routes.SetupRoutes(s.firebaseAuth, s.config.RateLimitRPS, s.config.RateLimitBurst)
+// Handler devuelve el handler HTTP del servicio (usado también por la Cloud Function)
+func (s *Server) Handler() http.Handler {
+	return s.router
+}
+
+// Jobs devuelve el scheduler de jobs de mantenimiento (usado también por la Cloud Function de jobs)
+func (s *Server) Jobs() *jobs.Scheduler {
+	return s.jobs
+}
+
+// Reconciler devuelve el servicio de reconciliación con Firebase (usado por el comando reconcile-users)
+func (s *Server) Reconciler() *services.UserReconciliationService {
+	return s.reconciler
 }
 
-func (s *Server) Start() error {
+// AuthEvents devuelve el servicio de eventos de Firebase Auth (usado por la Cloud Function AuthEvents)
+func (s *Server) AuthEvents() *services.AuthEventService {
+	return s.authEvents
+}
+
+// newExportStore crea el almacenamiento de exportaciones configurado en
+// EXPORT_STORAGE. Con almacenamiento local también devuelve el handler que
+// sirve sus descargas firmadas.
+func newExportStore(cfg *config.Config) (storage.Store, http.Handler, error) {
+	switch cfg.Export.Storage {
+	case "local":
+		secret := []byte(cfg.Export.SigningSecret)
+		if len(secret) == 0 {
+			// Los links emitidos dejan de ser válidos al reiniciar
+			secret = make([]byte, 32)
+			if _, err := rand.Read(secret); err != nil {
+				return nil, nil, err
+			}
+			logger.GetLogger().Warn("EXPORT_SIGNING_SECRET not set, using a random key for download links")
+		}
+		store, err := storage.NewLocalStore(cfg.Export.LocalDir, cfg.Export.PublicURL, secret)
+		if err != nil {
+			return nil, nil, err
+		}
+		return store, store, nil
+	case "gcs":
+		store, err := storage.NewGCSStore(context.Background(), cfg.Export.GCSBucket)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create Cloud Storage client: %w", err)
+		}
+		return store, nil, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown EXPORT_STORAGE %q (expected local or gcs)", cfg.Export.Storage)
+	}
+}
+
+// newMailer crea el envío de emails configurado en MAIL_DRIVER
+func newMailer(cfg *config.Config) (mail.Sender, error) {
+	switch cfg.Mail.Driver {
+	case "log":
+		return mail.NewLogSender(), nil
+	case "smtp":
+		return mail.NewSMTPSender(cfg.Mail.SMTPHost, cfg.Mail.SMTPPort, cfg.Mail.SMTPUsername, cfg.Mail.SMTPPassword, cfg.Mail.From), nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_DRIVER %q (expected log or smtp)", cfg.Mail.Driver)
+	}
+}
+
+// newRateLimiter crea el rate limiter con las políticas configuradas
+// o, si no hay ninguna, con las políticas por defecto
+func newRateLimiter(cfg *config.Config) (*middleware.RateLimiter, error) {
+	var exempt middleware.ExemptFunc
+	if len(cfg.RateLimit.ExemptCIDRs) > 0 {
+		var err error
+		exempt, err = middleware.NewCIDRExemption(cfg.RateLimit.ExemptCIDRs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	policies := middleware.DefaultRateLimitPolicies(cfg.RateLimit.RPS, cfg.RateLimit.Burst)
+	if len(cfg.RateLimit.Policies) > 0 {
+		policies = make([]middleware.RateLimitPolicy, 0, len(cfg.RateLimit.Policies))
+		for _, p := range cfg.RateLimit.Policies {
+			burst := p.Burst
+			if burst == 0 {
+				burst = p.Rate
+			}
+			methods := make([]string, len(p.Methods))
+			for i, m := range p.Methods {
+				methods[i] = strings.ToUpper(m)
+			}
+			policies = append(policies, middleware.RateLimitPolicy{
+				Name:    p.Name,
+				Routes:  p.Routes,
+				Methods: methods,
+				Key:     p.Key,
+				Limit:   middleware.RateLimit{Rate: p.Rate, Period: time.Duration(p.Period), Burst: burst},
+			})
+		}
+	}
+
+	// El store se crea después de todo lo que puede fallar
+	store, err := newRateLimitStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	rateLimiter := middleware.NewRateLimiter(store, policies)
+	if exempt != nil {
+		rateLimiter.SetExemption(exempt)
+	}
+
+	return rateLimiter, nil
+}
+
+// newRateLimitStore crea el store de rate limiting configurado en RATE_LIMIT_STORE
+func newRateLimitStore(cfg *config.Config) (middleware.RateLimitStore, error) {
+	switch cfg.RateLimit.Store {
+	case "memory":
+		return middleware.NewMemoryRateLimitStore(), nil
+	case "postgres":
+		return middleware.NewPostgresRateLimitStore(models.GetDB()), nil
+	case "redis":
+		opts, err := redis.ParseURL(cfg.Redis.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid REDIS_URL: %w", err)
+		}
+		return middleware.NewRedisRateLimitStore(redis.NewClient(opts)), nil
+	default:
+		return nil, fmt.Errorf("unknown RATE_LIMIT_STORE %q (expected memory, postgres or redis)", cfg.RateLimit.Store)
+	}
+}
+
+// Start sirve hasta que ctx se cancela; entonces deja de aceptar conexiones,
+// espera a las requests en curso (hasta shutdownTimeout) y cierra el servidor
+func (s *Server) Start(ctx context.Context) error {
 	log := logger.GetLogger()
 	
 	server := &http.Server{
-		Addr:         ":" + s.config.Port,
+		Addr:         fmt.Sprintf(":%d", s.config.Server.Port),
 		Handler:      s.router,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		ReadTimeout:  time.Duration(s.config.Server.ReadTimeout),
+		WriteTimeout: time.Duration(s.config.Server.WriteTimeout),
+		IdleTimeout:  time.Duration(s.config.Server.IdleTimeout),
+	}
+
+	// Jobs periódicos en proceso (los que tienen intervalo configurado)
+	s.jobs.Start(ctx)
+
+	log.WithField("port", s.config.Server.Port).Info("Server starting")
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- server.ListenAndServe() }()
+
+	select {
+	case err := <-serveErr:
+		s.Close()
+		return err
+	case <-ctx.Done():
 	}
 
-	log.WithField("port", s.config.Port).Info("Server starting")
-	return server.ListenAndServe()
-}
\ No newline at end of file
+	log.Info("Server shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	err := server.Shutdown(shutdownCtx)
+	if closeErr := s.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Close libera los recursos en segundo plano del servidor (la limpieza y las
+// conexiones del store de rate limiting). Start lo llama al terminar.
+func (s *Server) Close() error {
+	return s.rateLimiter.Close()
+}
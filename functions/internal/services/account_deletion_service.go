@@ -0,0 +1,389 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"gorm.io/gorm"
+
+	"it-app_user/internal/audit"
+	"it-app_user/internal/i18n"
+	"it-app_user/internal/logger"
+	"it-app_user/internal/mail"
+	"it-app_user/internal/models"
+	"it-app_user/internal/repositories"
+)
+
+const (
+	// maxDeletionCodeAttempts es la cantidad de códigos incorrectos tras la cual hay que pedir uno nuevo
+	maxDeletionCodeAttempts = 5
+	// accountDeletionBatchSize es la cantidad de cuentas eliminadas por ejecución del job
+	accountDeletionBatchSize = 50
+	// accountDeletionStaleAfter es el tiempo tras el cual una eliminación en
+	// curso se considera abandonada (p. ej. si la instancia murió) y se reintenta
+	accountDeletionStaleAfter = 15 * time.Minute
+)
+
+var (
+	// ErrReauthenticationRequired indica que el último inicio de sesión es demasiado antiguo
+	ErrReauthenticationRequired = errors.New("recent authentication required")
+	// ErrDeletionNotRequested indica que no hay un código de confirmación pendiente
+	ErrDeletionNotRequested = errors.New("account deletion not requested")
+	// ErrDeletionAlreadyScheduled indica que la eliminación ya está confirmada
+	ErrDeletionAlreadyScheduled = errors.New("account deletion already scheduled")
+	// ErrDeletionNotScheduled indica que no hay una eliminación confirmada que cancelar
+	ErrDeletionNotScheduled = errors.New("account deletion not scheduled")
+	// ErrDeletionInProgress indica que la ventana de cancelación venció y la eliminación ya no puede cancelarse
+	ErrDeletionInProgress = errors.New("account deletion already in progress")
+	// ErrDeletionCodeInvalid indica que el código de confirmación no coincide
+	ErrDeletionCodeInvalid = errors.New("invalid confirmation code")
+	// ErrDeletionCodeExpired indica que el código de confirmación venció
+	ErrDeletionCodeExpired = errors.New("confirmation code expired")
+	// ErrDeletionTooManyAttempts indica que se agotaron los intentos del código actual
+	ErrDeletionTooManyAttempts = errors.New("too many confirmation attempts")
+)
+
+// AccountDeletionService gestiona la eliminación de la cuenta solicitada por
+// el propio usuario: pide un inicio de sesión reciente y un código enviado por
+// email, deja la eliminación pendiente durante la ventana de cancelación y al
+// vencer elimina la cuenta de Firebase y de Postgres (ver UserDeletionService.Erase).
+// Cada paso se notifica por email y queda en el log de auditoría.
+type AccountDeletionService struct {
+	repos        *repositories.Repositories
+	deletion     *UserDeletionService
+	mailer       mail.Sender
+	reauthWindow time.Duration
+	codeTTL      time.Duration
+	cancelWindow time.Duration
+}
+
+func NewAccountDeletionService(repos *repositories.Repositories, deletion *UserDeletionService, mailer mail.Sender, reauthWindow, codeTTL, cancelWindow time.Duration) *AccountDeletionService {
+	return &AccountDeletionService{
+		repos:        repos,
+		deletion:     deletion,
+		mailer:       mailer,
+		reauthWindow: reauthWindow,
+		codeTTL:      codeTTL,
+		cancelWindow: cancelWindow,
+	}
+}
+
+// Request envía un código de confirmación al email del usuario. Si ya había
+// uno pendiente lo reemplaza. Devuelve ErrDeletionAlreadyScheduled (junto con
+// la solicitud) si la eliminación ya está confirmada.
+func (s *AccountDeletionService) Request(ctx context.Context, firebaseID string, authTime time.Time, ip string) (*models.AccountDeletionRequest, error) {
+	if err := s.requireRecentLogin(authTime); err != nil {
+		return nil, err
+	}
+	user, err := s.repos.Users.GetByFirebaseID(ctx, firebaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := s.latest(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && (latest.Status == models.AccountDeletionPending || latest.Status == models.AccountDeletionInProgress) {
+		return latest, ErrDeletionAlreadyScheduled
+	}
+
+	code, err := generateDeletionCode()
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(s.codeTTL)
+
+	request := latest
+	if request == nil || request.Status != models.AccountDeletionAwaitingConfirmation {
+		request = &models.AccountDeletionRequest{
+			UserID:     user.ID,
+			FirebaseID: user.FirebaseID,
+			Status:     models.AccountDeletionAwaitingConfirmation,
+		}
+	}
+	request.Email = user.Email
+	request.Language = i18n.FromContext(ctx)
+	request.CodeHash = hashDeletionCode(user.FirebaseID, code)
+	request.CodeExpiresAt = &expiresAt
+	request.Attempts = 0
+	request.RequestedIP = ip
+
+	if request.ID == 0 {
+		err = s.repos.AccountDeletions.Create(ctx, request)
+	} else {
+		err = s.repos.AccountDeletions.Update(ctx, request)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Sin el código el usuario no puede continuar, así que un fallo del envío es un error
+	err = s.mailer.Send(ctx, mail.Message{
+		To:      request.Email,
+		Subject: i18n.Translate(request.Language, "Confirm your account deletion"),
+		Body: fmt.Sprintf(i18n.Translate(request.Language, "Your code to confirm the deletion of your account is %s. It expires in %d minutes.\n\nIf you did not request it, change your password: someone may have access to your account."),
+			code, int(s.codeTTL.Minutes())),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("send confirmation code: %w", err)
+	}
+
+	audit.Record(ctx, audit.Event{
+		Action:       audit.ActionAccountDeletionRequested,
+		ActorID:      firebaseID,
+		TargetUserID: user.ID,
+		IP:           ip,
+		Metadata:     map[string]interface{}{"request_id": request.ID, "code_expires_at": expiresAt.UTC()},
+	})
+	return request, nil
+}
+
+// Confirm valida el código y programa la eliminación para dentro de la
+// ventana de cancelación
+func (s *AccountDeletionService) Confirm(ctx context.Context, firebaseID string, authTime time.Time, code, ip string) (*models.AccountDeletionRequest, error) {
+	if err := s.requireRecentLogin(authTime); err != nil {
+		return nil, err
+	}
+	user, err := s.repos.Users.GetByFirebaseID(ctx, firebaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := s.latest(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case request == nil:
+		return nil, ErrDeletionNotRequested
+	case request.Status == models.AccountDeletionPending, request.Status == models.AccountDeletionInProgress:
+		return request, ErrDeletionAlreadyScheduled
+	case request.Status != models.AccountDeletionAwaitingConfirmation:
+		return nil, ErrDeletionNotRequested
+	case request.Attempts >= maxDeletionCodeAttempts:
+		return nil, ErrDeletionTooManyAttempts
+	case request.CodeExpiresAt == nil || time.Now().After(*request.CodeExpiresAt):
+		return nil, ErrDeletionCodeExpired
+	}
+
+	// Cada intento se consume antes de comparar el código: con requests
+	// concurrentes la lectura anterior puede estar desactualizada
+	claimed, err := s.repos.AccountDeletions.ClaimAttempt(ctx, request.ID, maxDeletionCodeAttempts)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, ErrDeletionTooManyAttempts
+	}
+
+	if !hmac.Equal([]byte(hashDeletionCode(user.FirebaseID, code)), []byte(request.CodeHash)) {
+		audit.Record(ctx, audit.Event{
+			Action:       audit.ActionAccountDeletionCodeRejected,
+			ActorID:      firebaseID,
+			TargetUserID: user.ID,
+			IP:           ip,
+			Metadata:     map[string]interface{}{"request_id": request.ID, "attempts": request.Attempts + 1},
+		})
+		return nil, ErrDeletionCodeInvalid
+	}
+
+	now := time.Now()
+	scheduledFor := now.Add(s.cancelWindow)
+	request.Attempts++
+	request.Status = models.AccountDeletionPending
+	request.ConfirmedAt = &now
+	request.ScheduledFor = &scheduledFor
+	request.CodeHash = ""
+	request.CodeExpiresAt = nil
+	if err := s.repos.AccountDeletions.Update(ctx, request); err != nil {
+		return nil, err
+	}
+
+	s.notify(ctx, request, "Your account deletion is scheduled",
+		fmt.Sprintf(i18n.Translate(request.Language, "Your account and all your data will be permanently deleted on %s.\n\nIf you change your mind, sign in and cancel the deletion before that date."),
+			scheduledFor.UTC().Format("2006-01-02 15:04 UTC")))
+	audit.Record(ctx, audit.Event{
+		Action:       audit.ActionAccountDeletionScheduled,
+		ActorID:      firebaseID,
+		TargetUserID: user.ID,
+		IP:           ip,
+		Metadata:     map[string]interface{}{"request_id": request.ID, "scheduled_for": scheduledFor.UTC()},
+	})
+	return request, nil
+}
+
+// Cancel cancela una eliminación confirmada mientras no venza su ventana de
+// cancelación. Después devuelve ErrDeletionInProgress: el job puede estar
+// eliminando la cuenta.
+func (s *AccountDeletionService) Cancel(ctx context.Context, firebaseID, ip string) (*models.AccountDeletionRequest, error) {
+	user, err := s.repos.Users.GetByFirebaseID(ctx, firebaseID)
+	if err != nil {
+		return nil, err
+	}
+	request, err := s.latest(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	switch {
+	case request == nil:
+		return nil, ErrDeletionNotScheduled
+	case request.Status == models.AccountDeletionInProgress:
+		return nil, ErrDeletionInProgress
+	case request.Status != models.AccountDeletionPending:
+		return nil, ErrDeletionNotScheduled
+	case request.ScheduledFor == nil || !now.Before(*request.ScheduledFor):
+		return nil, ErrDeletionInProgress
+	}
+
+	// El UPDATE condicional compite con el job: si el job ya la tomó no se cancela
+	cancelled, err := s.repos.AccountDeletions.Cancel(ctx, request.ID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !cancelled {
+		return nil, ErrDeletionInProgress
+	}
+	request.Status = models.AccountDeletionCancelled
+	request.CancelledAt = &now
+
+	s.notify(ctx, request, "Your account deletion was cancelled",
+		i18n.Translate(request.Language, "The deletion of your account has been cancelled. Your account remains active."))
+	audit.Record(ctx, audit.Event{
+		Action:       audit.ActionAccountDeletionCancelled,
+		ActorID:      firebaseID,
+		TargetUserID: user.ID,
+		IP:           ip,
+		Metadata:     map[string]interface{}{"request_id": request.ID},
+	})
+	return request, nil
+}
+
+// Status devuelve la solicitud más reciente del usuario. Devuelve
+// gorm.ErrRecordNotFound si el usuario no existe o nunca pidió eliminar su cuenta.
+func (s *AccountDeletionService) Status(ctx context.Context, firebaseID string) (*models.AccountDeletionRequest, error) {
+	user, err := s.repos.Users.GetByFirebaseID(ctx, firebaseID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repos.AccountDeletions.GetLatestByUserID(ctx, user.ID)
+}
+
+// CompleteDue elimina las cuentas cuya ventana de cancelación venció. Cada
+// solicitud se marca como en curso antes de eliminar la cuenta, así que ni
+// Cancel ni otra instancia del job pueden tomarla a la vez. Una cuenta que
+// falla queda para la próxima ejecución; devuelve cuántas se eliminaron.
+func (s *AccountDeletionService) CompleteDue(ctx context.Context) (int, error) {
+	log := logger.GetLogger()
+
+	now := time.Now()
+	staleBefore := now.Add(-accountDeletionStaleAfter)
+	requests, err := s.repos.AccountDeletions.ListDue(ctx, now, staleBefore, accountDeletionBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	completed := 0
+	var errs []error
+	for i := range requests {
+		request := &requests[i]
+		claimed, err := s.repos.AccountDeletions.ClaimDue(ctx, request.ID, now, staleBefore)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("user %d: %w", request.UserID, err))
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		// Erase solo necesita los identificadores y es idempotente, así que
+		// también sirve si el usuario ya fue eliminado por otra vía
+		user := &models.User{ID: request.UserID, FirebaseID: request.FirebaseID}
+		if err := s.deletion.Erase(ctx, user); err != nil {
+			log.WithError(err).WithField("user_id", request.UserID).Error("Failed to delete account")
+			audit.Record(ctx, audit.Event{
+				Action:       audit.ActionAccountDeletionFailed,
+				ActorID:      audit.ActorSystem,
+				TargetUserID: request.UserID,
+				Metadata:     map[string]interface{}{"request_id": request.ID, "error": err.Error()},
+			})
+			// Vuelve a pendiente para que la próxima ejecución la reintente sin
+			// esperar a que se considere abandonada
+			if err := s.repos.AccountDeletions.Release(ctx, request.ID); err != nil {
+				log.WithError(err).WithField("user_id", request.UserID).Warn("Failed to release account deletion")
+			}
+			errs = append(errs, fmt.Errorf("user %d: %w", request.UserID, err))
+			continue
+		}
+
+		completed++
+		// La solicitud ya no existe, pero conserva el email y el idioma para el último aviso
+		s.notify(ctx, request, "Your account has been deleted",
+			i18n.Translate(request.Language, "Your account and all your data have been permanently deleted."))
+		audit.Record(ctx, audit.Event{
+			Action:       audit.ActionAccountDeletionCompleted,
+			ActorID:      audit.ActorSystem,
+			TargetUserID: request.UserID,
+			Metadata:     map[string]interface{}{"request_id": request.ID, "requested_by": request.FirebaseID},
+		})
+		log.WithField("user_id", request.UserID).Info("Account deleted")
+	}
+
+	return completed, errors.Join(errs...)
+}
+
+// ReauthWindow devuelve la antigüedad máxima del último inicio de sesión para eliminar la cuenta
+func (s *AccountDeletionService) ReauthWindow() time.Duration {
+	return s.reauthWindow
+}
+
+func (s *AccountDeletionService) requireRecentLogin(authTime time.Time) error {
+	if authTime.IsZero() || time.Since(authTime) > s.reauthWindow {
+		return ErrReauthenticationRequired
+	}
+	return nil
+}
+
+// latest devuelve la solicitud más reciente del usuario o nil si no tiene ninguna
+func (s *AccountDeletionService) latest(ctx context.Context, userID uint) (*models.AccountDeletionRequest, error) {
+	request, err := s.repos.AccountDeletions.GetLatestByUserID(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return request, err
+}
+
+// notify envía un aviso al usuario; los errores solo se registran
+func (s *AccountDeletionService) notify(ctx context.Context, request *models.AccountDeletionRequest, subject, body string) {
+	err := s.mailer.Send(ctx, mail.Message{
+		To:      request.Email,
+		Subject: i18n.Translate(request.Language, subject),
+		Body:    body,
+	})
+	if err != nil {
+		logger.GetLogger().WithError(err).WithField("user_id", request.UserID).Warn("Failed to send account deletion notification")
+	}
+}
+
+// generateDeletionCode genera un código numérico de 6 dígitos
+func generateDeletionCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// hashDeletionCode evita guardar el código en claro; el UID impide reutilizar hashes entre usuarios
+func hashDeletionCode(firebaseID, code string) string {
+	sum := sha256.Sum256([]byte(firebaseID + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
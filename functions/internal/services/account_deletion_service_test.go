@@ -0,0 +1,391 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"it-app_user/internal/mail"
+	"it-app_user/internal/models"
+	"it-app_user/internal/repositories"
+)
+
+// fakeUserLookup resuelve un único usuario por su Firebase UID
+type fakeUserLookup struct {
+	repositories.UserRepositoryInterface
+	user *models.User
+}
+
+func (r *fakeUserLookup) GetByFirebaseID(ctx context.Context, firebaseID string) (*models.User, error) {
+	if r.user == nil || r.user.FirebaseID != firebaseID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *r.user
+	return &found, nil
+}
+
+// fakeAccountDeletionRepo guarda las solicitudes en memoria
+type fakeAccountDeletionRepo struct {
+	repositories.AccountDeletionRepositoryInterface
+	requests []models.AccountDeletionRequest
+}
+
+func (r *fakeAccountDeletionRepo) Create(ctx context.Context, request *models.AccountDeletionRequest) error {
+	request.ID = uint(len(r.requests) + 1)
+	r.requests = append(r.requests, *request)
+	return nil
+}
+
+func (r *fakeAccountDeletionRepo) GetLatestByUserID(ctx context.Context, userID uint) (*models.AccountDeletionRequest, error) {
+	for i := len(r.requests) - 1; i >= 0; i-- {
+		if r.requests[i].UserID == userID {
+			found := r.requests[i]
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeAccountDeletionRepo) Update(ctx context.Context, request *models.AccountDeletionRequest) error {
+	r.requests[request.ID-1] = *request
+	return nil
+}
+
+func (r *fakeAccountDeletionRepo) ClaimAttempt(ctx context.Context, id uint, max int) (bool, error) {
+	if r.requests[id-1].Attempts >= max {
+		return false, nil
+	}
+	r.requests[id-1].Attempts++
+	return true, nil
+}
+
+// staleAccountDeletionRepo devuelve las solicitudes como las leyó una request
+// concurrente, antes de que otras consumieran sus intentos
+type staleAccountDeletionRepo struct {
+	*fakeAccountDeletionRepo
+}
+
+func (r staleAccountDeletionRepo) GetLatestByUserID(ctx context.Context, userID uint) (*models.AccountDeletionRequest, error) {
+	request, err := r.fakeAccountDeletionRepo.GetLatestByUserID(ctx, userID)
+	if err == nil {
+		request.Attempts = 0
+	}
+	return request, err
+}
+
+func (r *fakeAccountDeletionRepo) ListDue(ctx context.Context, now, staleBefore time.Time, limit int) ([]models.AccountDeletionRequest, error) {
+	var due []models.AccountDeletionRequest
+	for _, request := range r.requests {
+		if deletionDue(request, now, staleBefore) {
+			due = append(due, request)
+		}
+	}
+	return due, nil
+}
+
+func (r *fakeAccountDeletionRepo) ClaimDue(ctx context.Context, id uint, now, staleBefore time.Time) (bool, error) {
+	request := &r.requests[id-1]
+	if !deletionDue(*request, now, staleBefore) {
+		return false, nil
+	}
+	request.Status = models.AccountDeletionInProgress
+	request.StartedAt = &now
+	return true, nil
+}
+
+func (r *fakeAccountDeletionRepo) Release(ctx context.Context, id uint) error {
+	if r.requests[id-1].Status == models.AccountDeletionInProgress {
+		r.requests[id-1].Status = models.AccountDeletionPending
+		r.requests[id-1].StartedAt = nil
+	}
+	return nil
+}
+
+func (r *fakeAccountDeletionRepo) Cancel(ctx context.Context, id uint, now time.Time) (bool, error) {
+	request := &r.requests[id-1]
+	if request.Status != models.AccountDeletionPending || !request.ScheduledFor.After(now) {
+		return false, nil
+	}
+	request.Status = models.AccountDeletionCancelled
+	request.CancelledAt = &now
+	return true, nil
+}
+
+// deletionDue reproduce la condición de ListDue y ClaimDue
+func deletionDue(request models.AccountDeletionRequest, now, staleBefore time.Time) bool {
+	switch request.Status {
+	case models.AccountDeletionPending:
+		return !request.ScheduledFor.After(now)
+	case models.AccountDeletionInProgress:
+		return request.StartedAt.Before(staleBefore)
+	}
+	return false
+}
+
+// claimedAccountDeletionRepo simula que el job toma cada solicitud justo
+// después de que Cancel la leyó
+type claimedAccountDeletionRepo struct {
+	*fakeAccountDeletionRepo
+}
+
+func (r claimedAccountDeletionRepo) Cancel(ctx context.Context, id uint, now time.Time) (bool, error) {
+	r.requests[id-1].Status = models.AccountDeletionInProgress
+	return r.fakeAccountDeletionRepo.Cancel(ctx, id, now)
+}
+
+// recordingMailer guarda los emails enviados
+type recordingMailer struct {
+	sent []mail.Message
+	err  error
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg mail.Message) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+var deletionCodePattern = regexp.MustCompile(`\b\d{6}\b`)
+
+// newTestAccountDeletionService crea un AccountDeletionService sin Firebase
+// para el usuario 5 (uid-ana), con una ventana de cancelación de 7 días
+func newTestAccountDeletionService(t *testing.T) (*AccountDeletionService, *fakeAccountDeletionRepo, *recordingMailer) {
+	t.Helper()
+	users := &fakeUserLookup{user: &models.User{ID: 5, FirebaseID: "uid-ana", Email: "ana@example.com"}}
+	deletions := &fakeAccountDeletionRepo{}
+	mailer := &recordingMailer{}
+	repos := &repositories.Repositories{Users: users, AccountDeletions: deletions}
+	deletion := NewUserDeletionService(users, nil, nil, testGracePeriod, 100)
+	return NewAccountDeletionService(repos, deletion, mailer, 5*time.Minute, 15*time.Minute, 7*24*time.Hour), deletions, mailer
+}
+
+// lastCode extrae el código de confirmación del último email enviado
+func lastCode(t *testing.T, mailer *recordingMailer) string {
+	t.Helper()
+	if len(mailer.sent) == 0 {
+		t.Fatal("no email was sent")
+	}
+	code := deletionCodePattern.FindString(mailer.sent[len(mailer.sent)-1].Body)
+	if code == "" {
+		t.Fatalf("email %q has no confirmation code", mailer.sent[len(mailer.sent)-1].Body)
+	}
+	return code
+}
+
+func TestAccountDeletionRequiresRecentLogin(t *testing.T) {
+	service, deletions, mailer := newTestAccountDeletionService(t)
+	ctx := context.Background()
+
+	for _, authTime := range []time.Time{{}, time.Now().Add(-6 * time.Minute)} {
+		if _, err := service.Request(ctx, "uid-ana", authTime, "203.0.113.7"); !errors.Is(err, ErrReauthenticationRequired) {
+			t.Errorf("Request(auth %v) = %v, want ErrReauthenticationRequired", authTime, err)
+		}
+		if _, err := service.Confirm(ctx, "uid-ana", authTime, "123456", "203.0.113.7"); !errors.Is(err, ErrReauthenticationRequired) {
+			t.Errorf("Confirm(auth %v) = %v, want ErrReauthenticationRequired", authTime, err)
+		}
+	}
+	if len(deletions.requests) != 0 || len(mailer.sent) != 0 {
+		t.Errorf("requests = %v, emails = %v, want none", deletions.requests, mailer.sent)
+	}
+}
+
+func TestAccountDeletionFlow(t *testing.T) {
+	service, deletions, mailer := newTestAccountDeletionService(t)
+	ctx := context.Background()
+	authTime := time.Now()
+
+	if _, err := service.Request(ctx, "uid-ana", authTime, "203.0.113.7"); err != nil {
+		t.Fatal(err)
+	}
+	first := lastCode(t, mailer)
+	if mailer.sent[0].To != "ana@example.com" {
+		t.Errorf("code sent to %q", mailer.sent[0].To)
+	}
+
+	// Pedir otro código reemplaza el anterior en la misma solicitud
+	if _, err := service.Request(ctx, "uid-ana", authTime, "203.0.113.7"); err != nil {
+		t.Fatal(err)
+	}
+	code := lastCode(t, mailer)
+	if len(deletions.requests) != 1 {
+		t.Fatalf("%d requests, want the first one reused", len(deletions.requests))
+	}
+	if first != code {
+		if _, err := service.Confirm(ctx, "uid-ana", authTime, first, "203.0.113.7"); !errors.Is(err, ErrDeletionCodeInvalid) {
+			t.Errorf("Confirm(replaced code) = %v, want ErrDeletionCodeInvalid", err)
+		}
+		if deletions.requests[0].Attempts != 1 {
+			t.Errorf("attempts = %d, want 1", deletions.requests[0].Attempts)
+		}
+	}
+
+	request, err := service.Confirm(ctx, "uid-ana", authTime, code, "203.0.113.7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if request.Status != models.AccountDeletionPending || request.CodeHash != "" || request.ScheduledFor == nil {
+		t.Fatalf("request = %+v, want a scheduled deletion", request)
+	}
+	if wait := time.Until(*request.ScheduledFor); wait < 7*24*time.Hour-time.Minute || wait > 7*24*time.Hour {
+		t.Errorf("deletion scheduled in %v, want the 7 day cancellation window", wait)
+	}
+
+	// Con la eliminación programada no se emiten códigos nuevos
+	sent := len(mailer.sent)
+	if _, err := service.Request(ctx, "uid-ana", authTime, "203.0.113.7"); !errors.Is(err, ErrDeletionAlreadyScheduled) {
+		t.Errorf("Request() = %v, want ErrDeletionAlreadyScheduled", err)
+	}
+	if len(mailer.sent) != sent {
+		t.Error("a new code was sent for a scheduled deletion")
+	}
+
+	if request, err = service.Cancel(ctx, "uid-ana", "203.0.113.7"); err != nil || request.Status != models.AccountDeletionCancelled {
+		t.Fatalf("Cancel() = %+v, %v, want a cancelled request", request, err)
+	}
+	if _, err := service.Cancel(ctx, "uid-ana", "203.0.113.7"); !errors.Is(err, ErrDeletionNotScheduled) {
+		t.Errorf("second Cancel() = %v, want ErrDeletionNotScheduled", err)
+	}
+}
+
+func TestAccountDeletionConfirmErrors(t *testing.T) {
+	expired := time.Now().Add(-time.Minute)
+	valid := time.Now().Add(time.Minute)
+
+	tests := []struct {
+		name    string
+		request *models.AccountDeletionRequest
+		err     error
+	}{
+		{name: "not requested", err: ErrDeletionNotRequested},
+		{name: "cancelled", request: &models.AccountDeletionRequest{Status: models.AccountDeletionCancelled}, err: ErrDeletionNotRequested},
+		{name: "code expired", request: &models.AccountDeletionRequest{
+			Status: models.AccountDeletionAwaitingConfirmation, CodeHash: hashDeletionCode("uid-ana", "123456"), CodeExpiresAt: &expired,
+		}, err: ErrDeletionCodeExpired},
+		{name: "too many attempts", request: &models.AccountDeletionRequest{
+			Status: models.AccountDeletionAwaitingConfirmation, CodeHash: hashDeletionCode("uid-ana", "123456"), CodeExpiresAt: &valid, Attempts: maxDeletionCodeAttempts,
+		}, err: ErrDeletionTooManyAttempts},
+		{name: "code of another user", request: &models.AccountDeletionRequest{
+			Status: models.AccountDeletionAwaitingConfirmation, CodeHash: hashDeletionCode("uid-other", "123456"), CodeExpiresAt: &valid,
+		}, err: ErrDeletionCodeInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, deletions, _ := newTestAccountDeletionService(t)
+			if tt.request != nil {
+				tt.request.UserID = 5
+				deletions.Create(context.Background(), tt.request)
+			}
+
+			if _, err := service.Confirm(context.Background(), "uid-ana", time.Now(), "123456", "203.0.113.7"); !errors.Is(err, tt.err) {
+				t.Errorf("Confirm() = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestAccountDeletionConfirmClaimsAttemptAtomically(t *testing.T) {
+	service, deletions, _ := newTestAccountDeletionService(t)
+	valid := time.Now().Add(time.Minute)
+	deletions.Create(context.Background(), &models.AccountDeletionRequest{
+		UserID: 5, Status: models.AccountDeletionAwaitingConfirmation, CodeHash: hashDeletionCode("uid-ana", "123456"),
+		CodeExpiresAt: &valid, Attempts: maxDeletionCodeAttempts,
+	})
+	service.repos.AccountDeletions = staleAccountDeletionRepo{deletions}
+
+	// La lectura no ve los intentos ya consumidos, pero el claim sí: ni el código correcto pasa
+	if _, err := service.Confirm(context.Background(), "uid-ana", time.Now(), "123456", "203.0.113.7"); !errors.Is(err, ErrDeletionTooManyAttempts) {
+		t.Errorf("Confirm() = %v, want ErrDeletionTooManyAttempts", err)
+	}
+	if deletions.requests[0].Status != models.AccountDeletionAwaitingConfirmation || deletions.requests[0].Attempts != maxDeletionCodeAttempts {
+		t.Errorf("request = %+v, want it unchanged", deletions.requests[0])
+	}
+}
+
+func TestAccountDeletionRequestMailFailure(t *testing.T) {
+	service, _, mailer := newTestAccountDeletionService(t)
+	mailer.err = errors.New("smtp unavailable")
+
+	// Sin el email el usuario no recibe el código: es un error de la solicitud
+	if _, err := service.Request(context.Background(), "uid-ana", time.Now(), "203.0.113.7"); !errors.Is(err, mailer.err) {
+		t.Errorf("Request() = %v, want the mail error", err)
+	}
+}
+
+func TestAccountDeletionCompleteDueWithoutFirebase(t *testing.T) {
+	service, deletions, mailer := newTestAccountDeletionService(t)
+	past := time.Now().Add(-time.Hour)
+	deletions.Create(context.Background(), &models.AccountDeletionRequest{
+		UserID: 5, FirebaseID: "uid-ana", Email: "ana@example.com", Status: models.AccountDeletionPending, ScheduledFor: &past,
+	})
+
+	// Sin Firebase no se borra nada: la cuenta de Firebase podría volver a entrar
+	completed, err := service.CompleteDue(context.Background())
+	if completed != 0 || !errors.Is(err, ErrFirebaseNotConfigured) {
+		t.Errorf("CompleteDue() = %d, %v, want 0, ErrFirebaseNotConfigured", completed, err)
+	}
+	if len(mailer.sent) != 0 {
+		t.Errorf("sent %v for an account that was not deleted", mailer.sent)
+	}
+	if deletions.requests[0].Status != models.AccountDeletionPending {
+		t.Errorf("status = %q, want the deletion still pending", deletions.requests[0].Status)
+	}
+}
+
+func TestAccountDeletionCancelAfterScheduledFor(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		request models.AccountDeletionRequest
+	}{
+		{name: "window expired", request: models.AccountDeletionRequest{Status: models.AccountDeletionPending, ScheduledFor: &past}},
+		{name: "deleting", request: models.AccountDeletionRequest{Status: models.AccountDeletionInProgress, ScheduledFor: &past, StartedAt: &past}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, deletions, mailer := newTestAccountDeletionService(t)
+			tt.request.UserID = 5
+			deletions.Create(context.Background(), &tt.request)
+
+			if _, err := service.Cancel(context.Background(), "uid-ana", "203.0.113.7"); !errors.Is(err, ErrDeletionInProgress) {
+				t.Errorf("Cancel() = %v, want ErrDeletionInProgress", err)
+			}
+			if deletions.requests[0].Status != tt.request.Status || len(mailer.sent) != 0 {
+				t.Errorf("status = %q, emails = %v, want the deletion untouched", deletions.requests[0].Status, mailer.sent)
+			}
+		})
+	}
+
+	// Si el job la toma entre la lectura y el UPDATE, Cancel pierde
+	service, deletions, _ := newTestAccountDeletionService(t)
+	deletions.Create(context.Background(), &models.AccountDeletionRequest{UserID: 5, Status: models.AccountDeletionPending, ScheduledFor: &future})
+	service.repos.AccountDeletions = claimedAccountDeletionRepo{deletions}
+	if _, err := service.Cancel(context.Background(), "uid-ana", "203.0.113.7"); !errors.Is(err, ErrDeletionInProgress) {
+		t.Errorf("Cancel() after the job claimed it = %v, want ErrDeletionInProgress", err)
+	}
+}
+
+func TestAccountDeletionCompleteDueSkipsClaimedRequests(t *testing.T) {
+	service, deletions, mailer := newTestAccountDeletionService(t)
+	past := time.Now().Add(-time.Hour)
+	started := time.Now().Add(-time.Minute)
+	deletions.Create(context.Background(), &models.AccountDeletionRequest{
+		UserID: 5, FirebaseID: "uid-ana", Status: models.AccountDeletionInProgress, ScheduledFor: &past, StartedAt: &started,
+	})
+
+	// Otra instancia la está eliminando: no se toma hasta que se considere abandonada
+	completed, err := service.CompleteDue(context.Background())
+	if completed != 0 || err != nil {
+		t.Errorf("CompleteDue() = %d, %v, want 0, nil", completed, err)
+	}
+	if request := deletions.requests[0]; request.Status != models.AccountDeletionInProgress || !request.StartedAt.Equal(started) || len(mailer.sent) != 0 {
+		t.Errorf("request = %+v, emails = %v, want it untouched", request, mailer.sent)
+	}
+}
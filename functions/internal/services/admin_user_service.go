@@ -0,0 +1,479 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+	"gorm.io/gorm"
+
+	"it-app_user/internal/i18n"
+	"it-app_user/internal/logger"
+	"it-app_user/internal/mail"
+	"it-app_user/internal/models"
+	"it-app_user/internal/principal"
+	"it-app_user/internal/repositories"
+	"it-app_user/pkg/firebase"
+)
+
+// Errores de la administración de usuarios
+var (
+	// ErrFirebaseUpdateFailed indica que Firebase rechazó o no respondió el cambio
+	ErrFirebaseUpdateFailed = errors.New("firebase account update failed")
+	// ErrFirebaseAccountMissing indica que el usuario no tiene cuenta en Firebase
+	ErrFirebaseAccountMissing = errors.New("firebase account not found")
+	// ErrNoPasswordProvider indica que el usuario no inicia sesión con contraseña
+	ErrNoPasswordProvider = errors.New("user does not sign in with a password")
+	// ErrInviteAccepted indica que el usuario ya inició sesión alguna vez
+	ErrInviteAccepted = errors.New("user already signed in")
+	// ErrEmailNotSent indica que el cambio se aplicó pero el email no pudo enviarse
+	ErrEmailNotSent = errors.New("email could not be sent")
+	// ErrImpersonationNotAllowed indica que el usuario no puede suplantarse:
+	// es el propio administrador, está deshabilitado o tiene roles
+	ErrImpersonationNotAllowed = errors.New("user cannot be impersonated")
+)
+
+// Duración de los tokens de suplantación
+const (
+	ImpersonationDefaultTTL = 15 * time.Minute
+	ImpersonationMaxTTL     = time.Hour
+)
+
+// Orígenes de las entradas del historial de seguridad
+const (
+	TimelineSourceAudit    = "audit"
+	TimelineSourceFirebase = "firebase"
+	TimelineSourceDatabase = "database"
+)
+
+// AdminUserService agrupa las operaciones de administración sobre cuentas de
+// usuarios. Los cambios se aplican primero en Firebase y después en Postgres,
+// así que si Firebase falla la base de datos no cambia. La auditoría la
+// registran los handlers, que conocen al administrador.
+type AdminUserService struct {
+	repos        *repositories.Repositories
+	txManager    *repositories.TxManager
+	firebaseAuth *firebase.Auth
+	mailer       mail.Sender
+	auditService *AuditService
+}
+
+func NewAdminUserService(repos *repositories.Repositories, txManager *repositories.TxManager, firebaseAuth *firebase.Auth, mailer mail.Sender, auditService *AuditService) *AdminUserService {
+	return &AdminUserService{
+		repos:        repos,
+		txManager:    txManager,
+		firebaseAuth: firebaseAuth,
+		mailer:       mailer,
+		auditService: auditService,
+	}
+}
+
+// SecurityTimeline es el historial de seguridad de un usuario: el estado de
+// su cuenta de Firebase (nil si no se pudo consultar) y los eventos de la
+// auditoría, de Firebase y de la base de datos, del más reciente al más antiguo
+type SecurityTimeline struct {
+	User     *models.User            `json:"user"`
+	Firebase *FirebaseAccountState   `json:"firebase"`
+	Entries  []SecurityTimelineEntry `json:"entries"`
+}
+
+// FirebaseAccountState es el estado actual de la cuenta en Firebase Auth
+type FirebaseAccountState struct {
+	Disabled      bool                   `json:"disabled"`
+	EmailVerified bool                   `json:"email_verified"`
+	Providers     []string               `json:"providers"`
+	CustomClaims  map[string]interface{} `json:"custom_claims,omitempty"`
+}
+
+// SecurityTimelineEntry es un evento del historial de seguridad. Las de la
+// auditoría llevan el ID del evento y sus cambios y metadata en Details.
+type SecurityTimelineEntry struct {
+	OccurredAt   time.Time              `json:"occurred_at"`
+	Source       string                 `json:"source"`
+	Event        string                 `json:"event"`
+	ActorID      string                 `json:"actor_id,omitempty"`
+	IP           string                 `json:"ip,omitempty"`
+	UserAgent    string                 `json:"user_agent,omitempty"`
+	AuditEventID uint                   `json:"audit_event_id,omitempty"`
+	Details      map[string]interface{} `json:"details,omitempty"`
+}
+
+// Impersonation es un custom token de Firebase para actuar como un usuario.
+// El cliente lo canjea con signInWithCustomToken; los ID tokens resultantes
+// llevan los claims de la suplantación y dejan de aceptarse en ExpiresAt
+// aunque Firebase los siga refrescando.
+type Impersonation struct {
+	ID          string       `json:"impersonation_id"`
+	CustomToken string       `json:"custom_token"`
+	Scope       string       `json:"scope"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+	User        *models.User `json:"user"`
+}
+
+// List obtiene una página de usuarios que cumplen el filtro y el total
+func (s *AdminUserService) List(ctx context.Context, filter repositories.UserFilter) ([]models.User, int64, error) {
+	return s.repos.Users.List(ctx, filter)
+}
+
+// SetDisabled deshabilita o habilita la cuenta en Firebase y en la base de
+// datos; al deshabilitarla también revoca sus refresh tokens. Si la cuenta no
+// existe en Firebase solo cambia la base de datos. Devuelve el usuario antes
+// y después del cambio.
+func (s *AdminUserService) SetDisabled(ctx context.Context, id uint, disabled bool) (*models.User, *models.User, error) {
+	if s.firebaseAuth == nil {
+		return nil, nil, ErrFirebaseNotConfigured
+	}
+	user, err := s.repos.Users.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	log := logger.GetLogger().WithField("user_id", user.ID)
+
+	_, err = s.firebaseAuth.UpdateUser(ctx, user.FirebaseID, (&auth.UserToUpdate{}).Disabled(disabled))
+	switch {
+	case firebase.IsUserNotFound(err):
+		log.Warn("Firebase account not found, updating local user only")
+	case err != nil:
+		return nil, nil, fmt.Errorf("%w: %v", ErrFirebaseUpdateFailed, err)
+	case disabled:
+		// Con la cuenta deshabilitada Firebase ya no renueva los tokens; revocarlos
+		// también invalida los ID tokens vigentes en las rutas que lo comprueban
+		if err := s.firebaseAuth.RevokeRefreshTokens(ctx, user.FirebaseID); err != nil {
+			log.WithError(err).Warn("Failed to revoke Firebase refresh tokens")
+		}
+	}
+
+	before := *user
+	user.Disabled = disabled
+	if err := s.repos.Users.Update(ctx, user); err != nil {
+		return nil, nil, err
+	}
+	return &before, user, nil
+}
+
+// ForcePasswordReset reemplaza la contraseña por una aleatoria, revoca las
+// sesiones y envía al usuario un link para elegir una nueva. Si el envío
+// falla devuelve el usuario junto con ErrEmailNotSent: la contraseña ya
+// cambió y el usuario puede pedir el link desde la app.
+func (s *AdminUserService) ForcePasswordReset(ctx context.Context, id uint) (*models.User, error) {
+	user, record, err := s.firebaseUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !hasProvider(record, "password") {
+		return nil, ErrNoPasswordProvider
+	}
+
+	password, err := randomPassword()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.firebaseAuth.UpdateUser(ctx, user.FirebaseID, (&auth.UserToUpdate{}).Password(password)); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFirebaseUpdateFailed, err)
+	}
+	if err := s.firebaseAuth.RevokeRefreshTokens(ctx, user.FirebaseID); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFirebaseUpdateFailed, err)
+	}
+
+	lang := s.userLanguage(ctx, user)
+	if err := s.sendLink(ctx, user, lang,
+		"Reset your password",
+		"An administrator has reset your password and closed your sessions. Choose a new password with this link:\n\n%s\n\nIf you did not expect this, contact support."); err != nil {
+		return user, err
+	}
+	return user, nil
+}
+
+// RevokeSessions revoca los refresh tokens del usuario, cerrando todas sus sesiones
+func (s *AdminUserService) RevokeSessions(ctx context.Context, id uint) (*models.User, error) {
+	if s.firebaseAuth == nil {
+		return nil, ErrFirebaseNotConfigured
+	}
+	user, err := s.repos.Users.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.firebaseAuth.RevokeRefreshTokens(ctx, user.FirebaseID); err != nil {
+		if firebase.IsUserNotFound(err) {
+			return nil, ErrFirebaseAccountMissing
+		}
+		return nil, fmt.Errorf("%w: %v", ErrFirebaseUpdateFailed, err)
+	}
+	return user, nil
+}
+
+// VerifyEmail marca el email del usuario como verificado en Firebase, en
+// users y en su estado de verificación. Devuelve el usuario antes y después.
+func (s *AdminUserService) VerifyEmail(ctx context.Context, id uint) (*models.User, *models.User, error) {
+	user, _, err := s.firebaseUser(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := s.firebaseAuth.UpdateUser(ctx, user.FirebaseID, (&auth.UserToUpdate{}).EmailVerified(true)); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrFirebaseUpdateFailed, err)
+	}
+
+	before := *user
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context, repos *repositories.Repositories) error {
+		user.EmailVerified = true
+		if err := repos.Users.Update(ctx, user); err != nil {
+			return err
+		}
+		verification, err := repos.EmailVerifications.GetByUserID(ctx, user.ID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if verification.IsVerified {
+			return nil
+		}
+		now := time.Now()
+		verification.IsVerified = true
+		verification.VerifiedAt = &now
+		return repos.EmailVerifications.Update(ctx, verification)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &before, user, nil
+}
+
+// ResendInvite envía al usuario un link para definir su contraseña y entrar
+// por primera vez. Devuelve ErrInviteAccepted si ya inició sesión.
+func (s *AdminUserService) ResendInvite(ctx context.Context, id uint) (*models.User, error) {
+	user, record, err := s.firebaseUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.LastLoginAt != nil || (record.UserMetadata != nil && record.UserMetadata.LastLogInTimestamp > 0) {
+		return nil, ErrInviteAccepted
+	}
+
+	lang := s.userLanguage(ctx, user)
+	if err := s.sendLink(ctx, user, lang,
+		"You have been invited",
+		"An account has been created for you. Choose your password and sign in with this link:\n\n%s"); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Timeline arma el historial de seguridad del usuario, también si tiene un
+// borrado lógico pendiente. limit es la cantidad máxima de eventos de
+// auditoría; los de Firebase y la base de datos se agregan siempre.
+func (s *AdminUserService) Timeline(ctx context.Context, id uint, limit int) (*SecurityTimeline, error) {
+	user, err := s.repos.Users.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		user, err = s.repos.Users.GetDeletedByID(ctx, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := s.auditService.List(ctx, repositories.AuditEventFilter{
+		TargetUserID:     user.ID,
+		TargetFirebaseID: user.FirebaseID,
+		AnyTarget:        true,
+		Limit:            limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	timeline := &SecurityTimeline{User: user, Entries: make([]SecurityTimelineEntry, 0, len(events)+6)}
+	for _, event := range events {
+		details := make(map[string]interface{}, len(event.Metadata)+1)
+		for k, v := range event.Metadata {
+			details[k] = v
+		}
+		if len(event.Changes) > 0 {
+			details["changes"] = event.Changes
+		}
+		timeline.Entries = append(timeline.Entries, SecurityTimelineEntry{
+			OccurredAt:   event.OccurredAt,
+			Source:       TimelineSourceAudit,
+			Event:        event.Action,
+			ActorID:      event.ActorID,
+			IP:           event.IP,
+			UserAgent:    event.UserAgent,
+			AuditEventID: event.ID,
+			Details:      details,
+		})
+	}
+
+	if user.LastLoginAt != nil {
+		entry := SecurityTimelineEntry{OccurredAt: *user.LastLoginAt, Source: TimelineSourceDatabase, Event: "login.last", Details: map[string]interface{}{"login_count": user.LoginCount}}
+		if user.LastLoginIP != nil {
+			entry.IP = *user.LastLoginIP
+		}
+		if user.LastLoginDevice != nil {
+			entry.UserAgent = *user.LastLoginDevice
+		}
+		timeline.Entries = append(timeline.Entries, entry)
+	}
+	if verification, err := s.repos.EmailVerifications.GetByUserID(ctx, user.ID); err == nil && verification.VerifiedAt != nil {
+		timeline.Entries = append(timeline.Entries, SecurityTimelineEntry{OccurredAt: *verification.VerifiedAt, Source: TimelineSourceDatabase, Event: "email.verified", Details: map[string]interface{}{"email": verification.Email}})
+	}
+
+	if s.firebaseAuth != nil {
+		record, err := s.firebaseAuth.GetUser(ctx, user.FirebaseID)
+		if err != nil {
+			// El historial sigue siendo útil sin Firebase: la cuenta puede estar purgada
+			logger.GetLogger().WithError(err).WithField("user_id", user.ID).Warn("Failed to get Firebase account for timeline")
+		} else {
+			timeline.Firebase, timeline.Entries = firebaseTimeline(record, timeline.Entries)
+		}
+	}
+
+	sort.SliceStable(timeline.Entries, func(i, j int) bool {
+		return timeline.Entries[i].OccurredAt.After(timeline.Entries[j].OccurredAt)
+	})
+	return timeline, nil
+}
+
+// Impersonate emite un token con el que el administrador adminUID actúa como
+// el usuario, con el alcance indicado (principal.ScopeRead o ScopeWrite)
+// durante ttl. No se pueden suplantar administradores ni usuarios con roles:
+// la suplantación no debe servir para obtener los permisos de otro.
+func (s *AdminUserService) Impersonate(ctx context.Context, id uint, adminUID, scope string, ttl time.Duration) (*Impersonation, error) {
+	user, record, err := s.firebaseUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.FirebaseID == adminUID || record.Disabled {
+		return nil, ErrImpersonationNotAllowed
+	}
+	if isAdmin, _ := record.CustomClaims["admin"].(bool); isAdmin {
+		return nil, ErrImpersonationNotAllowed
+	}
+	roles, err := s.repos.Roles.ListByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) > 0 {
+		return nil, ErrImpersonationNotAllowed
+	}
+
+	if scope != principal.ScopeWrite {
+		scope = principal.ScopeRead
+	}
+	if ttl <= 0 {
+		ttl = ImpersonationDefaultTTL
+	}
+	if ttl > ImpersonationMaxTTL {
+		ttl = ImpersonationMaxTTL
+	}
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	impersonation := &Impersonation{
+		ID:        hex.EncodeToString(b),
+		Scope:     scope,
+		ExpiresAt: time.Now().Add(ttl).Truncate(time.Second).UTC(),
+		User:      user,
+	}
+	impersonation.CustomToken, err = s.firebaseAuth.CreateCustomTokenWithClaims(ctx, user.FirebaseID, map[string]interface{}{
+		principal.ClaimImpersonatedBy:         adminUID,
+		principal.ClaimImpersonationID:        impersonation.ID,
+		principal.ClaimImpersonationExpiresAt: impersonation.ExpiresAt.Unix(),
+		principal.ClaimScope:                  scope,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFirebaseUpdateFailed, err)
+	}
+	return impersonation, nil
+}
+
+// firebaseTimeline agrega a entries los momentos que registra Firebase y
+// devuelve el estado de la cuenta
+func firebaseTimeline(record *auth.UserRecord, entries []SecurityTimelineEntry) (*FirebaseAccountState, []SecurityTimelineEntry) {
+	state := &FirebaseAccountState{
+		Disabled:      record.Disabled,
+		EmailVerified: record.EmailVerified,
+		Providers:     make([]string, 0, len(record.ProviderUserInfo)),
+		CustomClaims:  record.CustomClaims,
+	}
+	for _, info := range record.ProviderUserInfo {
+		state.Providers = append(state.Providers, info.ProviderID)
+	}
+
+	add := func(millis int64, event string) {
+		if millis > 0 {
+			entries = append(entries, SecurityTimelineEntry{OccurredAt: time.UnixMilli(millis).UTC(), Source: TimelineSourceFirebase, Event: event})
+		}
+	}
+	if record.UserMetadata != nil {
+		add(record.UserMetadata.CreationTimestamp, "firebase.account_created")
+		add(record.UserMetadata.LastLogInTimestamp, "firebase.last_sign_in")
+		add(record.UserMetadata.LastRefreshTimestamp, "firebase.last_token_refresh")
+	}
+	// Los tokens emitidos antes de este momento están revocados
+	add(record.TokensValidAfterMillis, "firebase.tokens_valid_after")
+	return state, entries
+}
+
+// firebaseUser obtiene el usuario y su cuenta de Firebase
+func (s *AdminUserService) firebaseUser(ctx context.Context, id uint) (*models.User, *auth.UserRecord, error) {
+	if s.firebaseAuth == nil {
+		return nil, nil, ErrFirebaseNotConfigured
+	}
+	user, err := s.repos.Users.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	record, err := s.firebaseAuth.GetUser(ctx, user.FirebaseID)
+	if firebase.IsUserNotFound(err) {
+		return nil, nil, ErrFirebaseAccountMissing
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrFirebaseUpdateFailed, err)
+	}
+	return user, record, nil
+}
+
+// sendLink envía al usuario un email con un link de Firebase para elegir su
+// contraseña; body es el texto a traducir con %s en lugar del link
+func (s *AdminUserService) sendLink(ctx context.Context, user *models.User, lang, subject, body string) error {
+	link, err := s.firebaseAuth.PasswordResetLink(ctx, user.Email)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrFirebaseUpdateFailed, err)
+	}
+	err = s.mailer.Send(ctx, mail.Message{
+		To:      user.Email,
+		Subject: i18n.Translate(lang, subject),
+		Body:    fmt.Sprintf(i18n.Translate(lang, body), link),
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrEmailNotSent, err)
+	}
+	return nil
+}
+
+// userLanguage es el idioma de las configuraciones del usuario, para
+// escribirle en su idioma y no en el del administrador
+func (s *AdminUserService) userLanguage(ctx context.Context, user *models.User) string {
+	settings, err := s.repos.Settings.GetByUserID(ctx, user.ID)
+	if err != nil {
+		return i18n.DefaultLanguage
+	}
+	return i18n.Normalize(settings.Language)
+}
+
+// randomPassword genera una contraseña que nadie conoce, para invalidar la actual
+func randomPassword() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
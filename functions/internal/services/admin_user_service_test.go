@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"it-app_user/internal/firebasetest"
+	"it-app_user/internal/models"
+	"it-app_user/internal/repositories"
+)
+
+func (r *fakeUserLookup) Update(ctx context.Context, user *models.User) error {
+	if r.user == nil || r.user.ID != user.ID {
+		return gorm.ErrRecordNotFound
+	}
+	updated := *user
+	r.user = &updated
+	return nil
+}
+
+// fakeSettingsRepo devuelve las configuraciones de un único usuario
+type fakeSettingsRepo struct {
+	repositories.UserSettingsRepositoryInterface
+	settings *models.UserSettings
+}
+
+func (r *fakeSettingsRepo) GetByUserID(ctx context.Context, userID uint) (*models.UserSettings, error) {
+	if r.settings == nil || r.settings.UserID != userID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.settings, nil
+}
+
+// newTestAdminUserService crea un AdminUserService para el usuario 5
+// (uid-ana), que tiene configurado el español, con las cuentas de Firebase dadas
+func newTestAdminUserService(t *testing.T, accounts map[string]*firebasetest.Account) (*AdminUserService, *fakeUserLookup, *firebasetest.Emulator, *recordingMailer) {
+	t.Helper()
+	firebaseAuth, emulator := firebasetest.NewAuth(t, accounts)
+	users := &fakeUserLookup{user: &models.User{ID: 5, FirebaseID: "uid-ana", Email: "ana@example.com"}}
+	repos := &repositories.Repositories{
+		Users:    users,
+		Settings: &fakeSettingsRepo{settings: &models.UserSettings{UserID: 5, Language: "es"}},
+	}
+	mailer := &recordingMailer{}
+	return NewAdminUserService(repos, nil, firebaseAuth, mailer, nil), users, emulator, mailer
+}
+
+func TestAdminSetDisabled(t *testing.T) {
+	service, users, emulator, _ := newTestAdminUserService(t, map[string]*firebasetest.Account{
+		"uid-ana": {LocalID: "uid-ana", Email: "ana@example.com"},
+	})
+
+	before, user, err := service.SetDisabled(context.Background(), 5, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before.Disabled || !user.Disabled || !users.user.Disabled {
+		t.Errorf("before = %+v, after = %+v, want the user disabled", before, user)
+	}
+	// Deshabilitar la cuenta también revoca sus sesiones
+	if account := emulator.Account("uid-ana"); !account.Disabled || account.ValidSince == "" {
+		t.Errorf("firebase account = %+v, want it disabled with revoked tokens", account)
+	}
+}
+
+func TestAdminSetDisabledWithoutFirebaseAccount(t *testing.T) {
+	service, users, _, _ := newTestAdminUserService(t, nil)
+
+	// La cuenta ya no existe en Firebase: solo cambia la base de datos
+	_, user, err := service.SetDisabled(context.Background(), 5, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !user.Disabled || !users.user.Disabled {
+		t.Errorf("user = %+v, want it disabled locally", user)
+	}
+}
+
+func TestAdminSetDisabledFirebaseFailure(t *testing.T) {
+	service, users, emulator, _ := newTestAdminUserService(t, map[string]*firebasetest.Account{
+		"uid-ana": {LocalID: "uid-ana", Email: "ana@example.com"},
+	})
+	emulator.FailUpdates = true
+
+	if _, _, err := service.SetDisabled(context.Background(), 5, true); !errors.Is(err, ErrFirebaseUpdateFailed) {
+		t.Fatalf("err = %v, want ErrFirebaseUpdateFailed", err)
+	}
+	if users.user.Disabled {
+		t.Error("user disabled locally although Firebase failed")
+	}
+}
+
+func TestAdminForcePasswordReset(t *testing.T) {
+	service, _, emulator, mailer := newTestAdminUserService(t, map[string]*firebasetest.Account{
+		"uid-ana": {LocalID: "uid-ana", Email: "ana@example.com", Providers: []firebasetest.Provider{{ProviderID: "password"}}},
+	})
+
+	user, err := service.ForcePasswordReset(context.Background(), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.ID != 5 {
+		t.Errorf("user = %+v, want user 5", user)
+	}
+	if account := emulator.Account("uid-ana"); account.Password == "" || account.ValidSince == "" {
+		t.Errorf("firebase account = %+v, want a new password and revoked tokens", account)
+	}
+	// El email va en el idioma del usuario, no en el del administrador
+	if len(mailer.sent) != 1 || mailer.sent[0].Subject != "Restablece tu contraseña" ||
+		!strings.Contains(mailer.sent[0].Body, firebasetest.ResetLink("ana@example.com")) {
+		t.Errorf("sent = %+v, want the Spanish reset email with the link", mailer.sent)
+	}
+}
+
+func TestAdminForcePasswordResetEmailNotSent(t *testing.T) {
+	service, _, emulator, mailer := newTestAdminUserService(t, map[string]*firebasetest.Account{
+		"uid-ana": {LocalID: "uid-ana", Email: "ana@example.com", Providers: []firebasetest.Provider{{ProviderID: "password"}}},
+	})
+	mailer.err = errors.New("smtp unavailable")
+
+	// La contraseña ya cambió: se devuelve el usuario para auditarlo
+	user, err := service.ForcePasswordReset(context.Background(), 5)
+	if !errors.Is(err, ErrEmailNotSent) {
+		t.Fatalf("err = %v, want ErrEmailNotSent", err)
+	}
+	if user == nil || user.ID != 5 {
+		t.Errorf("user = %+v, want user 5", user)
+	}
+	if account := emulator.Account("uid-ana"); account.Password == "" {
+		t.Error("password was not replaced")
+	}
+}
+
+func TestAdminForcePasswordResetErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		accounts map[string]*firebasetest.Account
+		err      error
+	}{
+		{name: "no firebase account", err: ErrFirebaseAccountMissing},
+		{name: "no password provider", accounts: map[string]*firebasetest.Account{
+			"uid-ana": {LocalID: "uid-ana", Email: "ana@example.com", Providers: []firebasetest.Provider{{ProviderID: "google.com"}}},
+		}, err: ErrNoPasswordProvider},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, _, _, mailer := newTestAdminUserService(t, tt.accounts)
+
+			if _, err := service.ForcePasswordReset(context.Background(), 5); !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if len(mailer.sent) != 0 {
+				t.Errorf("sent %d emails, want none", len(mailer.sent))
+			}
+		})
+	}
+}
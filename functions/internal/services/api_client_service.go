@@ -0,0 +1,291 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"it-app_user/internal/logger"
+	"it-app_user/internal/models"
+	"it-app_user/internal/principal"
+	"it-app_user/internal/repositories"
+)
+
+var (
+	// ErrAPIClientNotFound indica que no existe un cliente de API con ese ID
+	ErrAPIClientNotFound = errors.New("api client not found")
+	// ErrAPIClientExists indica que ya existe un cliente de API con ese nombre
+	ErrAPIClientExists = errors.New("api client already exists")
+	// ErrAPIClientRevoked indica que el cliente de API fue revocado
+	ErrAPIClientRevoked = errors.New("api client revoked")
+	// ErrUnknownScope indica que un scope pedido no es un permiso del catálogo
+	ErrUnknownScope = errors.New("unknown scope")
+	// ErrInvalidAPIKey indica que la API key no existe, venció o es de un cliente revocado
+	ErrInvalidAPIKey = errors.New("invalid api key")
+)
+
+const (
+	// apiKeyGrace es cuánto siguen valiendo las keys anteriores al rotar, si no se indica
+	apiKeyGrace = time.Hour
+	// apiKeyTouchInterval evita escribir last_used_at en cada request de un cliente
+	apiKeyTouchInterval = time.Minute
+)
+
+// APIClientService gestiona los clientes de API con los que otros servicios
+// llaman a la API, y autentica sus API keys. Las keys se muestran una sola
+// vez al crearlas; la base de datos guarda su hash SHA-256, suficiente para
+// secretos aleatorios de 256 bits.
+type APIClientService struct {
+	repos     *repositories.Repositories
+	txManager *repositories.TxManager
+	now       func() time.Time
+}
+
+func NewAPIClientService(repos *repositories.Repositories, txManager *repositories.TxManager) *APIClientService {
+	return &APIClientService{
+		repos:     repos,
+		txManager: txManager,
+		now:       time.Now,
+	}
+}
+
+// List devuelve todos los clientes con sus keys
+func (s *APIClientService) List(ctx context.Context) ([]models.APIClient, error) {
+	return s.repos.APIClients.List(ctx)
+}
+
+// Get devuelve el cliente con sus keys
+func (s *APIClientService) Get(ctx context.Context, id uint) (*models.APIClient, error) {
+	client, err := s.repos.APIClients.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAPIClientNotFound
+	}
+	return client, err
+}
+
+// Create registra un cliente con una primera key y devuelve la key en claro
+func (s *APIClientService) Create(ctx context.Context, req models.CreateAPIClientRequest, createdBy string) (*models.APIClient, string, error) {
+	if err := validateScopes(req.Scopes); err != nil {
+		return nil, "", err
+	}
+	key, plain, err := newAPIKey(s.now(), req.ExpiresInDays)
+	if err != nil {
+		return nil, "", err
+	}
+
+	client := &models.APIClient{
+		Name:        req.Name,
+		Description: req.Description,
+		Scopes:      req.Scopes,
+		CreatedBy:   createdBy,
+		Keys:        []models.APIClientKey{*key},
+	}
+	if err := s.repos.APIClients.Create(ctx, client); err != nil {
+		if repositories.IsUniqueViolation(err) {
+			return nil, "", ErrAPIClientExists
+		}
+		return nil, "", err
+	}
+	return client, plain, nil
+}
+
+// Update cambia la descripción o reemplaza los scopes del cliente; los
+// scopes nuevos se aplican desde la siguiente request. Devuelve el cliente
+// antes y después.
+func (s *APIClientService) Update(ctx context.Context, id uint, req models.UpdateAPIClientRequest) (*models.APIClient, *models.APIClient, error) {
+	client, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if client.RevokedAt != nil {
+		return nil, nil, ErrAPIClientRevoked
+	}
+	before := *client
+
+	if req.Description != nil {
+		client.Description = *req.Description
+	}
+	if req.Scopes != nil {
+		if err := validateScopes(req.Scopes); err != nil {
+			return nil, nil, err
+		}
+		client.Scopes = req.Scopes
+	}
+	if err := s.repos.APIClients.Update(ctx, client); err != nil {
+		return nil, nil, err
+	}
+	return &before, client, nil
+}
+
+// Rotate crea una key nueva y adelanta el vencimiento de las anteriores al
+// fin del período de gracia. Devuelve el cliente con sus keys y la key nueva en claro.
+func (s *APIClientService) Rotate(ctx context.Context, id uint, req models.RotateAPIClientKeyRequest) (*models.APIClient, string, error) {
+	grace := apiKeyGrace
+	if req.GraceMinutes != nil {
+		grace = time.Duration(*req.GraceMinutes) * time.Minute
+	}
+	key, plain, err := newAPIKey(s.now(), req.ExpiresInDays)
+	if err != nil {
+		return nil, "", err
+	}
+
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context, repos *repositories.Repositories) error {
+		client, err := repos.APIClients.GetByID(ctx, id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAPIClientNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if client.RevokedAt != nil {
+			return ErrAPIClientRevoked
+		}
+		key.APIClientID = client.ID
+		if err := repos.APIClients.CreateKey(ctx, key); err != nil {
+			return err
+		}
+		return repos.APIClients.ExpireKeys(ctx, client.ID, s.now().Add(grace), key.ID)
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	client, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+	return client, plain, nil
+}
+
+// Revoke revoca el cliente y vence todas sus keys en el momento
+func (s *APIClientService) Revoke(ctx context.Context, id uint) (*models.APIClient, error) {
+	var client *models.APIClient
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context, repos *repositories.Repositories) error {
+		var err error
+		client, err = repos.APIClients.GetByID(ctx, id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrAPIClientNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if client.RevokedAt != nil {
+			return ErrAPIClientRevoked
+		}
+		now := s.now()
+		client.RevokedAt = &now
+		if err := repos.APIClients.Update(ctx, client); err != nil {
+			return err
+		}
+		return repos.APIClients.ExpireKeys(ctx, client.ID, now, 0)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// Authenticate valida la API key y devuelve su cliente, registrando el uso.
+// Devuelve ErrInvalidAPIKey si la key no existe, no coincide, venció o su
+// cliente fue revocado.
+func (s *APIClientService) Authenticate(ctx context.Context, plain string) (*models.APIClient, error) {
+	prefix, ok := apiKeyPrefix(plain)
+	if !ok {
+		return nil, ErrInvalidAPIKey
+	}
+	key, client, err := s.repos.APIClients.GetKeyByPrefix(ctx, prefix)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	if subtle.ConstantTimeCompare([]byte(hashAPIKey(plain)), []byte(key.Hash)) != 1 || key.Expired(now) || client.RevokedAt != nil {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		// No registrar el uso no impide la request
+		if err := s.repos.APIClients.Touch(ctx, key.ID, client.ID, now); err != nil {
+			logger.GetLogger().WithError(err).WithField("client", client.Name).Warn("Failed to record API key usage")
+		}
+	}
+	return client, nil
+}
+
+// Principal autentica la API key y devuelve el principal del servicio para
+// AuthMiddleware. ok es false si la key no es válida.
+func (s *APIClientService) Principal(ctx context.Context, plain string) (principal.Principal, bool, error) {
+	client, err := s.Authenticate(ctx, plain)
+	if errors.Is(err, ErrInvalidAPIKey) {
+		return principal.Principal{}, false, nil
+	}
+	if err != nil {
+		return principal.Principal{}, false, err
+	}
+	return principal.Principal{ClientID: client.Name, ClientScopes: client.Scopes}, true, nil
+}
+
+// validateScopes comprueba que los scopes sean permisos del catálogo
+func validateScopes(scopes []string) error {
+	known := make(map[string]bool, len(models.PermissionCatalog))
+	for _, permission := range models.PermissionCatalog {
+		known[permission.Name] = true
+	}
+	for _, scope := range scopes {
+		if !known[scope] {
+			return ErrUnknownScope
+		}
+	}
+	return nil
+}
+
+// newAPIKey genera una key con el formato svc_<prefijo>_<secreto> y devuelve
+// el registro a guardar y la key en claro
+func newAPIKey(now time.Time, expiresInDays int) (*models.APIClientKey, string, error) {
+	id := make([]byte, 6)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(id); err != nil {
+		return nil, "", err
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+
+	prefix := models.APIKeyPrefix + hex.EncodeToString(id)
+	plain := prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+	key := &models.APIClientKey{Prefix: prefix, Hash: hashAPIKey(plain)}
+	if expiresInDays > 0 {
+		expiresAt := now.AddDate(0, 0, expiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+	return key, plain, nil
+}
+
+// apiKeyPrefix extrae la parte pública de una key svc_<prefijo>_<secreto>
+func apiKeyPrefix(plain string) (string, bool) {
+	if !strings.HasPrefix(plain, models.APIKeyPrefix) {
+		return "", false
+	}
+	i := strings.Index(plain[len(models.APIKeyPrefix):], "_")
+	if i <= 0 {
+		return "", false
+	}
+	return plain[:len(models.APIKeyPrefix)+i], true
+}
+
+// hashAPIKey es el hash SHA-256 en hexadecimal de la key completa
+func hashAPIKey(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
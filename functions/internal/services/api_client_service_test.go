@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/gorm"
+
+	"it-app_user/internal/dbtest"
+	"it-app_user/internal/models"
+	"it-app_user/internal/repositories"
+)
+
+// fakeAPIClientRepo resuelve las keys de Authenticate en memoria
+type fakeAPIClientRepo struct {
+	repositories.APIClientRepositoryInterface
+	keys    map[string]models.APIClientKey
+	clients map[uint]models.APIClient
+	touched int
+}
+
+func (r *fakeAPIClientRepo) GetKeyByPrefix(ctx context.Context, prefix string) (*models.APIClientKey, *models.APIClient, error) {
+	key, ok := r.keys[prefix]
+	if !ok {
+		return nil, nil, gorm.ErrRecordNotFound
+	}
+	client := r.clients[key.APIClientID]
+	return &key, &client, nil
+}
+
+func (r *fakeAPIClientRepo) Touch(ctx context.Context, keyID, clientID uint, usedAt time.Time) error {
+	r.touched++
+	return nil
+}
+
+// newTestAPIKey genera una key del cliente y la registra en el repositorio
+func (r *fakeAPIClientRepo) newTestAPIKey(t *testing.T, clientID uint, expiresAt *time.Time) string {
+	t.Helper()
+	key, plain, err := newAPIKey(time.Now(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key.ID = uint(len(r.keys) + 1)
+	key.APIClientID = clientID
+	key.ExpiresAt = expiresAt
+	r.keys[key.Prefix] = *key
+	return plain
+}
+
+func TestAPIKeyPrefix(t *testing.T) {
+	tests := []struct {
+		key    string
+		prefix string
+		ok     bool
+	}{
+		{key: "svc_0a1b2c3d4e5f_c2VjcmV0", prefix: "svc_0a1b2c3d4e5f", ok: true},
+		{key: "svc_0a1b2c3d4e5f_", prefix: "svc_0a1b2c3d4e5f", ok: true},
+		{key: "svc_0a1b2c3d4e5f", ok: false},
+		{key: "svc__c2VjcmV0", ok: false},
+		{key: "svc_", ok: false},
+		{key: "tok_0a1b2c3d4e5f_c2VjcmV0", ok: false},
+		{key: "eyJhbGciOiJSUzI1NiJ9.e30.sig", ok: false},
+		{key: "", ok: false},
+	}
+	for _, tt := range tests {
+		prefix, ok := apiKeyPrefix(tt.key)
+		if ok != tt.ok || prefix != tt.prefix {
+			t.Errorf("apiKeyPrefix(%q) = %q, %v, want %q, %v", tt.key, prefix, ok, tt.prefix, tt.ok)
+		}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	revokedAt := now.Add(-time.Hour)
+	expiredAt := now.Add(-time.Second)
+	expiresLater := now.Add(time.Minute)
+
+	repo := &fakeAPIClientRepo{
+		keys: map[string]models.APIClientKey{},
+		clients: map[uint]models.APIClient{
+			1: {ID: 1, Name: "billing", Scopes: []string{models.PermissionTokensIntrospect}},
+			2: {ID: 2, Name: "legacy", RevokedAt: &revokedAt},
+		},
+	}
+	valid := repo.newTestAPIKey(t, 1, &expiresLater)
+	expired := repo.newTestAPIKey(t, 1, &expiredAt)
+	revoked := repo.newTestAPIKey(t, 2, nil)
+	prefix, _ := apiKeyPrefix(valid)
+
+	service := NewAPIClientService(&repositories.Repositories{APIClients: repo}, nil)
+	service.now = func() time.Time { return now }
+
+	client, err := service.Authenticate(context.Background(), valid)
+	if err != nil || client.Name != "billing" {
+		t.Fatalf("valid key: client %+v, err %v", client, err)
+	}
+	if repo.touched != 1 {
+		t.Errorf("usage recorded %d times, want 1", repo.touched)
+	}
+
+	for name, key := range map[string]string{
+		"empty secret":   prefix + "_",
+		"wrong secret":   prefix + "_c2VjcmV0",
+		"without secret": prefix,
+		"unknown prefix": "svc_ffffffffffff_c2VjcmV0",
+		"expired key":    expired,
+		"revoked client": revoked,
+	} {
+		if _, err := service.Authenticate(context.Background(), key); !errors.Is(err, ErrInvalidAPIKey) {
+			t.Errorf("%s: err = %v, want ErrInvalidAPIKey", name, err)
+		}
+	}
+
+	// El principal de una key inválida no es un error: RequireUserOrService responde 401
+	if _, ok, err := service.Principal(context.Background(), revoked); ok || err != nil {
+		t.Errorf("Principal(revoked) = %v, %v, want not ok without error", ok, err)
+	}
+}
+
+// timeArg captura el valor de un parámetro de tipo time.Time de la consulta
+type timeArg struct {
+	value *time.Time
+}
+
+func (a timeArg) Match(v driver.Value) bool {
+	t, ok := v.(time.Time)
+	if ok {
+		*a.value = t
+	}
+	return ok
+}
+
+func TestRotateKeepsOldKeyDuringGrace(t *testing.T) {
+	db, mock := dbtest.NewMockDB(t)
+	clock := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	service := NewAPIClientService(repositories.NewRepositories(db), repositories.NewTxManager(db))
+	service.now = func() time.Time { return clock }
+
+	oldKey, oldPlain, err := newAPIKey(clock, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "name", "scopes", "revoked_at"}).
+			AddRow(3, "billing", `["tokens:introspect"]`, nil)
+	}
+	keyColumns := []string{"id", "api_client_id", "prefix", "hash", "expires_at", "last_used_at"}
+
+	var graceEnd, unchangedAfter time.Time
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "api_clients" WHERE "api_clients"."id" = \$1`).
+		WithArgs(3).
+		WillReturnRows(clientRows())
+	mock.ExpectQuery(`SELECT \* FROM "api_client_keys" WHERE "api_client_keys"."api_client_id" = \$1 ORDER BY id`).
+		WillReturnRows(sqlmock.NewRows(keyColumns).AddRow(1, 3, oldKey.Prefix, oldKey.Hash, nil, nil))
+	mock.ExpectQuery(`INSERT INTO "api_client_keys"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(2, clock))
+	mock.ExpectExec(`UPDATE "api_client_keys" SET "expires_at"=\$1 WHERE \(api_client_id = \$2 AND id <> \$3\) AND \(expires_at IS NULL OR expires_at > \$4\)`).
+		WithArgs(timeArg{&graceEnd}, 3, 2, timeArg{&unchangedAfter}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`SELECT \* FROM "api_clients"`).WillReturnRows(clientRows())
+	mock.ExpectQuery(`SELECT \* FROM "api_client_keys"`).WillReturnRows(sqlmock.NewRows(keyColumns))
+
+	grace := 30
+	_, newPlain, err := service.Rotate(context.Background(), 3, models.RotateAPIClientKeyRequest{GraceMinutes: &grace})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := clock.Add(30 * time.Minute); !graceEnd.Equal(want) || !unchangedAfter.Equal(want) {
+		t.Fatalf("old keys expire at %v (only if later than %v), want %v", graceEnd, unchangedAfter, want)
+	}
+	if newPlain == oldPlain {
+		t.Fatal("Rotate returned the old key")
+	}
+
+	// Authenticate con la key anterior, que ahora vence al terminar la gracia
+	authenticate := func(at time.Time) error {
+		t.Helper()
+		clock = at
+		mock.ExpectQuery(`SELECT \* FROM "api_client_keys" WHERE prefix = \$1`).
+			WithArgs(oldKey.Prefix).
+			WillReturnRows(sqlmock.NewRows(keyColumns).AddRow(1, 3, oldKey.Prefix, oldKey.Hash, graceEnd, at))
+		mock.ExpectQuery(`SELECT \* FROM "api_clients" WHERE "api_clients"."id" = \$1`).
+			WithArgs(3).
+			WillReturnRows(clientRows())
+		_, err := service.Authenticate(context.Background(), oldPlain)
+		return err
+	}
+	if err := authenticate(graceEnd.Add(-time.Second)); err != nil {
+		t.Fatalf("old key during the grace period: %v", err)
+	}
+	if err := authenticate(graceEnd); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Fatalf("old key after the grace period: err = %v, want ErrInvalidAPIKey", err)
+	}
+}
+
+func TestRotateRevokedClient(t *testing.T) {
+	db, mock := dbtest.NewMockDB(t)
+	service := NewAPIClientService(repositories.NewRepositories(db), repositories.NewTxManager(db))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "api_clients"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "scopes", "revoked_at"}).
+			AddRow(3, "billing", `[]`, time.Now().Add(-time.Hour)))
+	mock.ExpectQuery(`SELECT \* FROM "api_client_keys"`).WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	if _, _, err := service.Rotate(context.Background(), 3, models.RotateAPIClientKeyRequest{}); !errors.Is(err, ErrAPIClientRevoked) {
+		t.Fatalf("err = %v, want ErrAPIClientRevoked", err)
+	}
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"it-app_user/internal/audit"
+	"it-app_user/internal/logger"
+	"it-app_user/internal/models"
+	"it-app_user/internal/repositories"
+)
+
+// auditVerifyBatchSize es la cantidad de eventos leídos por consulta al verificar la cadena
+const auditVerifyBatchSize = 1000
+
+// AuditService consulta el log de auditoría, verifica su cadena de hashes y
+// aplica la política de retención
+type AuditService struct {
+	repo      repositories.AuditEventRepositoryInterface
+	retention time.Duration
+}
+
+func NewAuditService(repo repositories.AuditEventRepositoryInterface, retention time.Duration) *AuditService {
+	return &AuditService{
+		repo:      repo,
+		retention: retention,
+	}
+}
+
+// AuditVerification es el resultado de verificar un tramo de la cadena
+type AuditVerification struct {
+	Valid bool `json:"valid"`
+	// Complete indica si se llegó al último evento de la cadena
+	Complete bool   `json:"complete"`
+	Checked  int    `json:"checked"`
+	FirstID  uint   `json:"first_id,omitempty"`
+	LastID   uint   `json:"last_id,omitempty"`
+	LastHash string `json:"last_hash,omitempty"`
+	// BrokenAtID es el primer evento que no coincide con su hash o no enlaza con el anterior
+	BrokenAtID uint   `json:"broken_at_id,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// List obtiene los eventos que cumplen el filtro, del más reciente al más antiguo
+func (s *AuditService) List(ctx context.Context, filter repositories.AuditEventFilter) ([]models.AuditEvent, error) {
+	return s.repo.List(ctx, filter)
+}
+
+// Verify recalcula el hash de hasta limit eventos posteriores a afterID y
+// comprueba que cada uno enlace con el anterior. Con afterID 0 empieza por el
+// primer evento, que debe enlazar con el génesis o con el ancla de la última
+// purga por retención. Devuelve gorm.ErrRecordNotFound si afterID no existe.
+func (s *AuditService) Verify(ctx context.Context, afterID uint, limit int) (*AuditVerification, error) {
+	result := &AuditVerification{Valid: true}
+
+	prevHash := ""
+	if afterID > 0 {
+		start, err := s.repo.ListAfter(ctx, afterID-1, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(start) == 0 || start[0].ID != afterID {
+			return nil, gorm.ErrRecordNotFound
+		}
+		prevHash = start[0].Hash
+	}
+
+	cursor := afterID
+	for result.Checked < limit {
+		events, err := s.repo.ListAfter(ctx, cursor, min(auditVerifyBatchSize, limit-result.Checked))
+		if err != nil {
+			return nil, err
+		}
+		if len(events) == 0 {
+			result.Complete = true
+			break
+		}
+
+		for i := range events {
+			event := &events[i]
+			if result.FirstID == 0 {
+				result.FirstID = event.ID
+				if afterID == 0 {
+					anchor, err := s.chainAnchor(ctx, event)
+					if err != nil {
+						return nil, err
+					}
+					prevHash = anchor
+				}
+			}
+
+			if event.PrevHash != prevHash {
+				return result.broken(event.ID, "prev_hash does not match the previous event"), nil
+			}
+			hash, err := event.ComputeHash()
+			if err != nil {
+				return nil, err
+			}
+			if hash != event.Hash {
+				return result.broken(event.ID, "hash does not match the event contents"), nil
+			}
+
+			prevHash = event.Hash
+			result.Checked++
+			result.LastID = event.ID
+			result.LastHash = event.Hash
+		}
+		cursor = events[len(events)-1].ID
+	}
+
+	return result, nil
+}
+
+// chainAnchor devuelve el prev_hash que debe tener el primer evento que
+// queda: el génesis o, si la retención ya borró eventos, el hash del último
+// evento borrado, que la purga registra en su evento audit.pruned
+func (s *AuditService) chainAnchor(ctx context.Context, first *models.AuditEvent) (string, error) {
+	if first.PrevHash == models.AuditGenesisHash {
+		return models.AuditGenesisHash, nil
+	}
+	pruned, err := s.repo.GetLatestByAction(ctx, audit.ActionAuditPruned)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.AuditGenesisHash, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	anchor, _ := pruned.Metadata["anchor_hash"].(string)
+	return anchor, nil
+}
+
+func (v *AuditVerification) broken(id uint, reason string) *AuditVerification {
+	v.Valid = false
+	v.BrokenAtID = id
+	v.Reason = reason
+	return v
+}
+
+// Prune elimina los eventos más antiguos que la retención configurada y, en
+// la misma transacción, registra un evento audit.pruned con el hash del
+// último evento eliminado, que pasa a ser el ancla de la cadena. Devuelve
+// cuántos eliminó.
+func (s *AuditService) Prune(ctx context.Context) (int64, error) {
+	if s.retention == 0 {
+		return 0, nil
+	}
+
+	before := time.Now().Add(-s.retention)
+	deleted, err := s.repo.DeleteBefore(ctx, before, &models.AuditEvent{
+		OccurredAt: time.Now(),
+		Action:     audit.ActionAuditPruned,
+		ActorID:    audit.ActorSystem,
+		Metadata:   map[string]interface{}{"before": before.UTC()},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if deleted > 0 {
+		logger.GetLogger().WithField("deleted", deleted).Info("Audit events pruned")
+	}
+	return deleted, nil
+}
@@ -0,0 +1,237 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"it-app_user/internal/audit"
+	"it-app_user/internal/models"
+	"it-app_user/internal/repositories"
+)
+
+// fakeAuditRepo guarda la cadena en memoria, en orden de ID
+type fakeAuditRepo struct {
+	events []models.AuditEvent
+}
+
+func (r *fakeAuditRepo) Append(ctx context.Context, event *models.AuditEvent) error {
+	event.OccurredAt = event.OccurredAt.Truncate(time.Microsecond)
+	event.PrevHash = models.AuditGenesisHash
+	if n := len(r.events); n > 0 {
+		event.PrevHash = r.events[n-1].Hash
+		event.ID = r.events[n-1].ID + 1
+	} else {
+		event.ID = 1
+	}
+	hash, err := event.ComputeHash()
+	if err != nil {
+		return err
+	}
+	event.Hash = hash
+	r.events = append(r.events, *event)
+	return nil
+}
+
+func (r *fakeAuditRepo) List(ctx context.Context, filter repositories.AuditEventFilter) ([]models.AuditEvent, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r *fakeAuditRepo) ListAfter(ctx context.Context, afterID uint, limit int) ([]models.AuditEvent, error) {
+	var events []models.AuditEvent
+	for _, e := range r.events {
+		if e.ID > afterID && len(events) < limit {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
+
+func (r *fakeAuditRepo) GetLatestByAction(ctx context.Context, action string) (*models.AuditEvent, error) {
+	for i := len(r.events) - 1; i >= 0; i-- {
+		if r.events[i].Action == action {
+			event := r.events[i]
+			return &event, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeAuditRepo) DeleteBefore(ctx context.Context, before time.Time, marker *models.AuditEvent) (int64, error) {
+	last := -1
+	for i, e := range r.events {
+		if e.OccurredAt.Before(before) {
+			last = i
+		}
+	}
+	if last < 0 {
+		return 0, nil
+	}
+	marker.Metadata = map[string]interface{}{
+		"anchor_id":   r.events[last].ID,
+		"anchor_hash": r.events[last].Hash,
+		"deleted":     last + 1,
+	}
+	r.events = r.events[last+1:]
+	return int64(last + 1), r.Append(ctx, marker)
+}
+
+// newAuditChain devuelve un repositorio con n eventos encadenados, uno por minuto
+func newAuditChain(t *testing.T, n int) *fakeAuditRepo {
+	t.Helper()
+	repo := &fakeAuditRepo{}
+	appendAuditEvents(t, repo, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), n)
+	return repo
+}
+
+// appendAuditEvents agrega n eventos de prueba desde start, uno por minuto
+func appendAuditEvents(t *testing.T, repo *fakeAuditRepo, start time.Time, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		err := repo.Append(context.Background(), &models.AuditEvent{
+			OccurredAt:   start.Add(time.Duration(i) * time.Minute),
+			Action:       "user.updated",
+			ActorID:      "uid-admin",
+			TargetUserID: uint(100 + i),
+			Changes:      map[string]models.AuditChange{"first_name": {From: "Ana", To: fmt.Sprintf("Ana %d", i)}},
+			Metadata:     map[string]interface{}{"attempt": i},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestAuditVerifyValidChain(t *testing.T) {
+	repo := newAuditChain(t, 5)
+	result, err := NewAuditService(repo, 0).Verify(context.Background(), 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid || !result.Complete || result.Checked != 5 || result.FirstID != 1 || result.LastID != 5 {
+		t.Fatalf("got %+v, want a valid complete chain of 5 events", result)
+	}
+	if result.LastHash != repo.events[4].Hash {
+		t.Errorf("LastHash = %q, want the hash of the last event", result.LastHash)
+	}
+}
+
+func TestAuditVerifyReportsTamperedRow(t *testing.T) {
+	tests := []struct {
+		name     string
+		tamper   func(e *models.AuditEvent)
+		brokenAt uint
+		reason   string
+	}{
+		{
+			name:     "metadata",
+			tamper:   func(e *models.AuditEvent) { e.Metadata["attempt"] = 99 },
+			brokenAt: 3,
+			reason:   "hash does not match the event contents",
+		},
+		{
+			name:     "changes",
+			tamper:   func(e *models.AuditEvent) { e.Changes["first_name"] = models.AuditChange{From: "Ana", To: "Eva"} },
+			brokenAt: 3,
+			reason:   "hash does not match the event contents",
+		},
+		{
+			name:     "target",
+			tamper:   func(e *models.AuditEvent) { e.TargetUserID = 1 },
+			brokenAt: 3,
+			reason:   "hash does not match the event contents",
+		},
+		{
+			name: "rehashed row",
+			// Recalcular el hash de la fila alterada no alcanza: el siguiente deja de enlazar
+			tamper: func(e *models.AuditEvent) {
+				e.ActorID = "uid-other"
+				e.Hash, _ = e.ComputeHash()
+			},
+			brokenAt: 4,
+			reason:   "prev_hash does not match the previous event",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newAuditChain(t, 5)
+			tt.tamper(&repo.events[2])
+
+			result, err := NewAuditService(repo, 0).Verify(context.Background(), 0, 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Valid || result.BrokenAtID != tt.brokenAt || result.Reason != tt.reason {
+				t.Fatalf("got %+v, want broken at %d: %s", result, tt.brokenAt, tt.reason)
+			}
+			if result.Checked != int(tt.brokenAt)-1 || result.LastID != tt.brokenAt-1 {
+				t.Errorf("checked %d up to %d, want the events before the break", result.Checked, result.LastID)
+			}
+		})
+	}
+}
+
+func TestAuditVerifyPagesFromAfterID(t *testing.T) {
+	repo := newAuditChain(t, 6)
+	service := NewAuditService(repo, 0)
+
+	first, err := service.Verify(context.Background(), 0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first.Valid || first.Complete || first.LastID != 3 {
+		t.Fatalf("first page = %+v, want valid and incomplete up to 3", first)
+	}
+
+	repo.events[4].IP = "203.0.113.9"
+	second, err := service.Verify(context.Background(), first.LastID, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Valid || second.BrokenAtID != 5 {
+		t.Fatalf("second page = %+v, want broken at 5", second)
+	}
+
+	if _, err := service.Verify(context.Background(), 42, 3); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("err = %v, want gorm.ErrRecordNotFound for an unknown afterID", err)
+	}
+}
+
+func TestAuditVerifyAfterPrune(t *testing.T) {
+	// Tres eventos vencidos y dos dentro de la retención de un día
+	repo := &fakeAuditRepo{}
+	appendAuditEvents(t, repo, time.Now().Add(-48*time.Hour), 3)
+	appendAuditEvents(t, repo, time.Now().Add(-time.Hour), 2)
+	prunedHash := repo.events[2].Hash
+
+	service := NewAuditService(repo, 24*time.Hour)
+	if deleted, err := service.Prune(context.Background()); err != nil || deleted != 3 {
+		t.Fatalf("Prune() = %d, %v, want 3 events deleted", deleted, err)
+	}
+	marker := &repo.events[len(repo.events)-1]
+	if marker.Action != audit.ActionAuditPruned || marker.Metadata["anchor_hash"] != prunedHash {
+		t.Fatalf("last event = %+v, want the audit.pruned marker anchored to event 3", marker)
+	}
+
+	result, err := service.Verify(context.Background(), 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Valid || result.FirstID != 4 || result.Checked != 3 {
+		t.Fatalf("got %+v, want the remaining chain linked to the anchor", result)
+	}
+
+	// Sin el ancla correcta, el primer evento que queda deja de enlazar
+	marker.Metadata["anchor_hash"] = models.AuditGenesisHash
+	result, err = service.Verify(context.Background(), 0, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Valid || result.BrokenAtID != 4 {
+		t.Fatalf("got %+v, want the first remaining event reported", result)
+	}
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"it-app_user/internal/audit"
+	"it-app_user/internal/logger"
+	"it-app_user/internal/metrics"
+	"it-app_user/internal/models"
+	"it-app_user/internal/repositories"
+	"it-app_user/pkg/firebase"
+)
+
+// Resultados del procesamiento de un evento de Firebase Auth
+const (
+	AuthEventResultCreated   = "created"
+	AuthEventResultExisting  = "existing"
+	AuthEventResultDeleted   = "deleted"
+	AuthEventResultNotFound  = "not_found"
+	AuthEventResultDuplicate = "duplicate"
+	// AuthEventResultSkipped: la cuenta tiene un borrado lógico pendiente de
+	// purga o su email pertenece a otro usuario; no se crea nada
+	AuthEventResultSkipped = "skipped"
+	AuthEventResultError   = "error"
+)
+
+// processedEventRetention es cuánto se guardan los IDs de eventos procesados:
+// los reintentos de Cloud Functions duran como mucho 7 días
+const processedEventRetention = 30 * 24 * time.Hour
+
+// AuthEventService mantiene la base de datos al día con los usuarios creados
+// o eliminados directamente en Firebase (consola, Admin SDK, otras apps), a
+// partir de los eventos de Firebase Auth. Los eventos pueden llegar más de una
+// vez: cada ID se registra en processed_events en la misma transacción que
+// sus cambios, así que un reenvío no hace nada.
+type AuthEventService struct {
+	txManager *repositories.TxManager
+	eventRepo repositories.ProcessedEventRepositoryInterface
+	users     *UserService
+	deletion  *UserDeletionService
+}
+
+func NewAuthEventService(txManager *repositories.TxManager, eventRepo repositories.ProcessedEventRepositoryInterface, users *UserService, deletion *UserDeletionService) *AuthEventService {
+	return &AuthEventService{
+		txManager: txManager,
+		eventRepo: eventRepo,
+		users:     users,
+		deletion:  deletion,
+	}
+}
+
+// UserCreated crea el usuario local con su perfil, configuraciones,
+// estadísticas y verificación de email, igual que en su primer login. Si ya
+// existe (p. ej. el login llegó antes que el evento) no lo modifica.
+func (s *AuthEventService) UserCreated(ctx context.Context, eventID string, record *firebase.AuthEventUser) (string, error) {
+	firstName, lastName := splitDisplayName(record.DisplayName)
+	candidate := &models.User{
+		FirebaseID:    record.UID,
+		Email:         record.Email,
+		EmailVerified: record.EmailVerified,
+		Username:      usernameSuggestion(record.DisplayName, record.Email),
+		FirstName:     firstName,
+		LastName:      lastName,
+		PhotoURL:      record.PhotoURL,
+		Provider:      eventProvider(record),
+		Status:        "active",
+		Disabled:      record.Disabled,
+	}
+
+	var user *models.User
+	result, err := s.process(ctx, eventID, firebase.AuthEventUserCreated, record.UID, func(ctx context.Context, repos *repositories.Repositories) (string, error) {
+		provisioned, created, err := s.users.Provision(ctx, candidate)
+		switch {
+		case errors.Is(err, ErrUserDeleted), errors.Is(err, ErrUserAlreadyExists):
+			// Reintentar no cambia el resultado: el evento queda procesado
+			logger.GetLogger().WithError(err).WithField("firebase_id", record.UID).Warn("Skipping Firebase user created event")
+			return AuthEventResultSkipped, nil
+		case err != nil:
+			return "", err
+		case !created:
+			return AuthEventResultExisting, nil
+		}
+		user = provisioned
+		return AuthEventResultCreated, nil
+	})
+
+	if result == AuthEventResultCreated {
+		audit.Record(ctx, audit.Event{
+			Action:           audit.ActionUserCreated,
+			ActorID:          audit.ActorSystem,
+			TargetUserID:     user.ID,
+			TargetFirebaseID: user.FirebaseID,
+			Metadata:         map[string]interface{}{"source": "firebase_auth_event", "event_id": eventID},
+		})
+	}
+	return result, err
+}
+
+// UserDeleted aplica el borrado lógico al usuario local de una cuenta
+// eliminada en Firebase. La purga lo elimina al vencer el período de gracia;
+// si se restaura antes, tendrá que volver a registrarse en Firebase.
+func (s *AuthEventService) UserDeleted(ctx context.Context, eventID string, record *firebase.AuthEventUser) (string, error) {
+	var user *models.User
+	result, err := s.process(ctx, eventID, firebase.AuthEventUserDeleted, record.UID, func(ctx context.Context, repos *repositories.Repositories) (string, error) {
+		existing, err := repos.Users.GetByFirebaseID(ctx, record.UID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Nunca se creó o ya tiene un borrado lógico
+			return AuthEventResultNotFound, nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		// La cuenta de Firebase ya no existe: no hay nada que deshabilitar
+		deleted, err := s.deletion.SoftDeleteLocal(ctx, existing.ID)
+		if err != nil {
+			return "", err
+		}
+		user = deleted
+		return AuthEventResultDeleted, nil
+	})
+
+	if result == AuthEventResultDeleted {
+		audit.Record(ctx, audit.Event{
+			Action:           audit.ActionUserDeleted,
+			ActorID:          audit.ActorSystem,
+			TargetUserID:     user.ID,
+			TargetFirebaseID: user.FirebaseID,
+			Metadata: map[string]interface{}{
+				"source":      "firebase_auth_event",
+				"event_id":    eventID,
+				"purge_after": s.deletion.PurgeAfter(user.DeletedAt.Time).UTC(),
+			},
+		})
+	}
+	return result, err
+}
+
+// Prune elimina los IDs de eventos procesados que ya no pueden reenviarse
+func (s *AuthEventService) Prune(ctx context.Context) (int64, error) {
+	deleted, err := s.eventRepo.DeleteBefore(ctx, time.Now().Add(-processedEventRetention))
+	if err != nil {
+		return 0, err
+	}
+	if deleted > 0 {
+		logger.GetLogger().WithField("deleted", deleted).Info("Pruned processed events")
+	}
+	return deleted, nil
+}
+
+// process registra el evento y ejecuta fn en la misma transacción; si el
+// evento ya estaba registrado no ejecuta fn. Si fn falla el registro también
+// se revierte, para que el reintento vuelva a procesarlo.
+func (s *AuthEventService) process(ctx context.Context, eventID, eventType, firebaseID string, fn func(ctx context.Context, repos *repositories.Repositories) (string, error)) (string, error) {
+	var result string
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context, repos *repositories.Repositories) error {
+		result = ""
+		isNew, err := repos.ProcessedEvents.MarkProcessed(ctx, eventID, eventType)
+		if err != nil {
+			return err
+		}
+		if !isNew {
+			result = AuthEventResultDuplicate
+			return nil
+		}
+		result, err = fn(ctx, repos)
+		return err
+	})
+
+	log := logger.GetLogger().WithFields(map[string]interface{}{
+		"event_id":    eventID,
+		"event_type":  eventType,
+		"firebase_id": firebaseID,
+	})
+	if err != nil {
+		result = AuthEventResultError
+		log.WithError(err).Error("Failed to process Firebase auth event")
+	} else {
+		log.WithField("result", result).Info("Firebase auth event processed")
+	}
+	metrics.AuthEvents.WithLabelValues(eventType, result).Inc()
+	return result, err
+}
+
+// splitDisplayName separa el nombre visible en nombre y apellido como el login
+func splitDisplayName(displayName string) (string, string) {
+	parts := strings.Fields(displayName)
+	switch len(parts) {
+	case 0:
+		return "", ""
+	case 1:
+		return parts[0], ""
+	default:
+		return parts[0], strings.Join(parts[1:], " ")
+	}
+}
+
+// usernameSuggestion sugiere el username como el login: el nombre visible o,
+// si no queda nada tras normalizarlo, la parte local del email
+func usernameSuggestion(displayName, email string) string {
+	if NormalizeUsername(displayName) != "" {
+		return displayName
+	}
+	if local, _, found := strings.Cut(email, "@"); found {
+		return local
+	}
+	return ""
+}
+
+// eventProvider elige el proveedor de la cuenta con la misma prioridad que
+// el login: Google, Facebook, contraseña y si no el primero vinculado
+func eventProvider(record *firebase.AuthEventUser) string {
+	for _, provider := range []string{"google.com", "facebook.com", "password"} {
+		for _, info := range record.ProviderData {
+			if info.ProviderID == provider {
+				return provider
+			}
+		}
+	}
+	if len(record.ProviderData) > 0 {
+		return record.ProviderData[0].ProviderID
+	}
+	return "password"
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"it-app_user/internal/dbtest"
+	"it-app_user/internal/repositories"
+	"it-app_user/pkg/firebase"
+)
+
+// newTestAuthEventService crea un AuthEventService sin Firebase sobre una base de datos simulada
+func newTestAuthEventService(t *testing.T) (*AuthEventService, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock := dbtest.NewMockDB(t)
+	repos := repositories.NewRepositories(db)
+	txManager := repositories.NewTxManager(db)
+	users := NewUserService(txManager, NewUsernameService(repos.Users))
+	deletion := NewUserDeletionService(repos.Users, txManager, nil, testGracePeriod, 100)
+	return NewAuthEventService(txManager, repos.ProcessedEvents, users, deletion), mock
+}
+
+// expectMarkProcessed simula el registro del evento; isNew false indica que ya estaba registrado
+func expectMarkProcessed(mock sqlmock.Sqlmock, eventID string, isNew bool) {
+	var rows int64
+	if isNew {
+		rows = 1
+	}
+	mock.ExpectExec(`INSERT INTO "processed_events" .* ON CONFLICT DO NOTHING`).
+		WithArgs(eventID, firebase.AuthEventUserDeleted, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, rows))
+}
+
+func TestAuthEventDuplicate(t *testing.T) {
+	service, mock := newTestAuthEventService(t)
+
+	// El evento ya se procesó: no se consulta ni se modifica el usuario
+	mock.ExpectBegin()
+	expectMarkProcessed(mock, "event-1", false)
+	mock.ExpectCommit()
+
+	result, err := service.UserDeleted(context.Background(), "event-1", &firebase.AuthEventUser{UID: "uid-ana"})
+	if err != nil || result != AuthEventResultDuplicate {
+		t.Fatalf("UserDeleted() = %q, %v, want %q", result, err, AuthEventResultDuplicate)
+	}
+}
+
+func TestAuthEventUserDeletedNotFound(t *testing.T) {
+	service, mock := newTestAuthEventService(t)
+
+	mock.ExpectBegin()
+	expectMarkProcessed(mock, "event-1", true)
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE firebase_id = \$1`).
+		WithArgs("uid-ana").
+		WillReturnRows(noRows())
+	mock.ExpectCommit()
+
+	result, err := service.UserDeleted(context.Background(), "event-1", &firebase.AuthEventUser{UID: "uid-ana"})
+	if err != nil || result != AuthEventResultNotFound {
+		t.Fatalf("UserDeleted() = %q, %v, want %q", result, err, AuthEventResultNotFound)
+	}
+}
+
+func TestAuthEventRetryAfterRollback(t *testing.T) {
+	service, mock := newTestAuthEventService(t)
+	record := &firebase.AuthEventUser{UID: "uid-ana"}
+
+	expectUser := func() {
+		mock.ExpectQuery(`SELECT \* FROM "users" WHERE firebase_id = \$1`).
+			WithArgs("uid-ana").
+			WillReturnRows(userRows(7, "uid-ana", "ana"))
+		mock.ExpectExec(`SAVEPOINT`).WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+
+	// El borrado lógico falla: se revierte también el registro del evento
+	mock.ExpectBegin()
+	expectMarkProcessed(mock, "event-1", true)
+	expectUser()
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1`).
+		WithArgs(7).
+		WillReturnError(errors.New("connection reset"))
+	mock.ExpectExec(`ROLLBACK TO SAVEPOINT`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	result, err := service.UserDeleted(context.Background(), "event-1", record)
+	if err == nil || result != AuthEventResultError {
+		t.Fatalf("UserDeleted() = %q, %v, want an error", result, err)
+	}
+
+	// El reintento del mismo evento vuelve a procesarlo
+	mock.ExpectBegin()
+	expectMarkProcessed(mock, "event-1", true)
+	expectUser()
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1`).
+		WithArgs(7).
+		WillReturnRows(userRows(7, "uid-ana", "ana"))
+	mock.ExpectExec(`UPDATE "users" SET "deleted_at"=\$1 WHERE "users"."id" = \$2 AND "users"."deleted_at" IS NULL`).
+		WithArgs(sqlmock.AnyArg(), 7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE deleted_at IS NOT NULL AND "users"."id" = \$1`).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "firebase_id", "deleted_at"}).AddRow(7, "uid-ana", time.Now()))
+	mock.ExpectCommit()
+
+	result, err = service.UserDeleted(context.Background(), "event-1", record)
+	if err != nil || result != AuthEventResultDeleted {
+		t.Fatalf("retried UserDeleted() = %q, %v, want %q", result, err, AuthEventResultDeleted)
+	}
+}
+
+func TestAuthEventUserCreatedSkipped(t *testing.T) {
+	tests := []struct {
+		name   string
+		expect func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name: "account pending purge",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT \* FROM "users" WHERE firebase_id = \$1 AND deleted_at IS NOT NULL`).
+					WillReturnRows(userRows(7, "uid-ana", "ana"))
+			},
+		},
+		{
+			name: "email of another account",
+			expect: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`SELECT \* FROM "users" WHERE firebase_id = \$1 AND deleted_at IS NOT NULL`).
+					WillReturnRows(noRows())
+				mock.ExpectQuery(`SELECT \* FROM "users" WHERE email_canonical = \$1`).
+					WillReturnRows(userRows(3, "uid-other", "ana"))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock := newTestAuthEventService(t)
+
+			mock.ExpectBegin()
+			mock.ExpectExec(`INSERT INTO "processed_events" .* ON CONFLICT DO NOTHING`).
+				WithArgs("event-1", firebase.AuthEventUserCreated, sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			expectUsernamesAvailable(mock)
+			mock.ExpectExec(`SAVEPOINT`).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectQuery(`INSERT INTO "users" .* ON CONFLICT DO NOTHING RETURNING`).WillReturnRows(noRows())
+			mock.ExpectQuery(`SELECT \* FROM "users" WHERE firebase_id = \$1`).WillReturnRows(noRows())
+			tt.expect(mock)
+			mock.ExpectExec(`ROLLBACK TO SAVEPOINT`).WillReturnResult(sqlmock.NewResult(0, 0))
+			// El evento queda registrado: reintentarlo no cambiaría el resultado
+			mock.ExpectCommit()
+
+			result, err := service.UserCreated(context.Background(), "event-1", &firebase.AuthEventUser{
+				UID: "uid-ana", Email: "ana@example.com", DisplayName: "Ana",
+			})
+			if err != nil || result != AuthEventResultSkipped {
+				t.Fatalf("UserCreated() = %q, %v, want %q", result, err, AuthEventResultSkipped)
+			}
+		})
+	}
+}
@@ -0,0 +1,452 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+	"gorm.io/gorm"
+
+	"it-app_user/internal/audit"
+	"it-app_user/internal/models"
+	"it-app_user/internal/repositories"
+	"it-app_user/internal/storage"
+	"it-app_user/pkg/firebase"
+)
+
+const (
+	// exportTimeout limita la generación de un archivo de exportación
+	exportTimeout = 5 * time.Minute
+	// exportStaleAfter es el tiempo tras el cual una exportación en proceso se
+	// considera abandonada (la instancia murió) y el job puede retomarla
+	exportStaleAfter = 15 * time.Minute
+	// exportBatchSize es la cantidad de exportaciones procesadas o vencidas por ejecución del job
+	exportBatchSize = 20
+	// exportFormatVersion versiona la estructura del ZIP (ver manifest.json)
+	exportFormatVersion = 1
+	// exportKeyFormat es la clave de un archivo de exportación en el almacenamiento (user_id, export_id)
+	exportKeyFormat = "data-exports/%d/data-export-%d.zip"
+)
+
+// ErrUnknownExportKey indica que la clave no es la de un archivo de exportación
+var ErrUnknownExportKey = errors.New("unknown data export key")
+
+// DataExportResult es el estado de una exportación y, si está lista, su link de descarga
+type DataExportResult struct {
+	Export       *models.DataExport
+	DownloadURL  string
+	URLExpiresAt *time.Time
+}
+
+// DataExportService genera de forma asíncrona un ZIP con los datos personales
+// de un usuario (registro local, perfil, configuraciones, estadísticas,
+// historial de verificaciones y resets y la cuenta de Firebase), lo guarda en
+// el almacenamiento configurado y emite links de descarga firmados. Cada
+// paso queda registrado en el log de auditoría.
+type DataExportService struct {
+	repos        *repositories.Repositories
+	firebaseAuth *firebase.Auth
+	store        storage.Store
+	urlTTL       time.Duration
+	retention    time.Duration
+}
+
+func NewDataExportService(repos *repositories.Repositories, firebaseAuth *firebase.Auth, store storage.Store, urlTTL, retention time.Duration) *DataExportService {
+	return &DataExportService{
+		repos:        repos,
+		firebaseAuth: firebaseAuth,
+		store:        store,
+		urlTTL:       urlTTL,
+		retention:    retention,
+	}
+}
+
+// Request crea una exportación pendiente para el usuario; la genera el job de
+// exportaciones (ver ProcessPending), no la request, así que no depende de que
+// la instancia siga viva al responder. Si ya tiene una en curso o descargable
+// la devuelve en lugar de crear otra (created=false). Devuelve
+// gorm.ErrRecordNotFound si el usuario no existe.
+func (s *DataExportService) Request(ctx context.Context, firebaseID, ip string) (export *models.DataExport, created bool, err error) {
+	user, err := s.repos.Users.GetByFirebaseID(ctx, firebaseID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	latest, err := s.repos.DataExports.GetLatestByUserID(ctx, user.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+	if latest != nil && latest.IsActive(time.Now()) {
+		return latest, false, nil
+	}
+
+	export = &models.DataExport{
+		UserID:      user.ID,
+		FirebaseID:  user.FirebaseID,
+		Status:      models.DataExportPending,
+		RequestedIP: ip,
+	}
+	if err := s.repos.DataExports.Create(ctx, export); err != nil {
+		return nil, false, err
+	}
+
+	audit.Record(ctx, audit.Event{
+		Action:       audit.ActionDataExportRequested,
+		ActorID:      firebaseID,
+		TargetUserID: user.ID,
+		IP:           ip,
+		Metadata:     map[string]interface{}{"export_id": export.ID},
+	})
+
+	return export, true, nil
+}
+
+// Get devuelve el estado de una exportación del usuario y, si está completa,
+// un link de descarga firmado. Devuelve gorm.ErrRecordNotFound si la
+// exportación no existe o pertenece a otro usuario.
+func (s *DataExportService) Get(ctx context.Context, firebaseID string, id uint, ip string) (*DataExportResult, error) {
+	export, err := s.repos.DataExports.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if export.FirebaseID != firebaseID {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	result := &DataExportResult{Export: export}
+	now := time.Now()
+	if export.Status != models.DataExportCompleted || !export.IsActive(now) {
+		return result, nil
+	}
+
+	// El link nunca dura más que el archivo
+	expires := now.Add(s.urlTTL)
+	if export.ExpiresAt.Before(expires) {
+		expires = *export.ExpiresAt
+	}
+	url, err := s.store.SignedURL(ctx, export.StorageKey, expires)
+	if err != nil {
+		return nil, err
+	}
+	result.DownloadURL = url
+	result.URLExpiresAt = &expires
+
+	audit.Record(ctx, audit.Event{
+		Action:       audit.ActionDataExportURLIssued,
+		ActorID:      firebaseID,
+		TargetUserID: export.UserID,
+		IP:           ip,
+		Metadata:     map[string]interface{}{"export_id": export.ID, "url_expires_at": expires.UTC()},
+	})
+	return result, nil
+}
+
+// ProcessPending genera las exportaciones pendientes o abandonadas y elimina
+// los archivos vencidos. Es el job de exportaciones.
+func (s *DataExportService) ProcessPending(ctx context.Context) error {
+	var errs []error
+
+	exports, err := s.repos.DataExports.ListClaimable(ctx, time.Now().Add(-exportStaleAfter), exportBatchSize)
+	if err != nil {
+		return err
+	}
+	for _, export := range exports {
+		exportCtx, cancel := context.WithTimeout(ctx, exportTimeout)
+		err := s.process(exportCtx, export.ID)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("export %d: %w", export.ID, err))
+		}
+	}
+
+	if err := s.expire(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// RecordDownload audita una descarga servida por el almacenamiento local.
+// Devuelve ErrUnknownExportKey si key no es la de una exportación, para no
+// servir un archivo que no se puede atribuir a un usuario.
+func (s *DataExportService) RecordDownload(r *http.Request, key string) error {
+	var userID, exportID uint
+	// Sscanf no exige consumir toda la entrada ni rechaza ceros a la izquierda:
+	// la clave tiene que reconstruirse exactamente
+	n, err := fmt.Sscanf(key, exportKeyFormat, &userID, &exportID)
+	if err != nil || n != 2 || fmt.Sprintf(exportKeyFormat, userID, exportID) != key {
+		return fmt.Errorf("%w: %q", ErrUnknownExportKey, key)
+	}
+
+	audit.Record(r.Context(), audit.FromRequest(r, audit.Event{
+		Action:       audit.ActionDataExportDownloaded,
+		ActorID:      "signed_url",
+		TargetUserID: userID,
+		Metadata:     map[string]interface{}{"export_id": exportID},
+	}))
+	return nil
+}
+
+// process toma la exportación (si otra instancia no lo hizo), genera el ZIP y
+// lo guarda. Un error al generarla la marca como fallida; el usuario puede
+// solicitar una nueva.
+func (s *DataExportService) process(ctx context.Context, id uint) error {
+	claimed, err := s.repos.DataExports.Claim(ctx, id, time.Now().Add(-exportStaleAfter))
+	if err != nil || !claimed {
+		return err
+	}
+	export, err := s.repos.DataExports.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf(exportKeyFormat, export.UserID, export.ID)
+	size, buildErr := s.build(ctx, export, key)
+
+	now := time.Now()
+	if buildErr != nil {
+		export.Status = models.DataExportFailed
+		export.Error = truncate(buildErr.Error(), 500)
+		audit.Record(ctx, audit.Event{
+			Action:       audit.ActionDataExportFailed,
+			ActorID:      audit.ActorSystem,
+			TargetUserID: export.UserID,
+			Metadata:     map[string]interface{}{"export_id": export.ID, "error": export.Error},
+		})
+	} else {
+		expiresAt := now.Add(s.retention)
+		export.Status = models.DataExportCompleted
+		export.Error = ""
+		export.StorageKey = key
+		export.SizeBytes = size
+		export.CompletedAt = &now
+		export.ExpiresAt = &expiresAt
+		audit.Record(ctx, audit.Event{
+			Action:       audit.ActionDataExportCompleted,
+			ActorID:      audit.ActorSystem,
+			TargetUserID: export.UserID,
+			Metadata:     map[string]interface{}{"export_id": export.ID, "size_bytes": size},
+		})
+	}
+
+	if err := s.repos.DataExports.Update(ctx, export); err != nil {
+		return err
+	}
+	return buildErr
+}
+
+// build reúne los datos del usuario en un ZIP de archivos JSON y lo guarda en key
+func (s *DataExportService) build(ctx context.Context, export *models.DataExport, key string) (int64, error) {
+	user, err := s.repos.Users.GetByID(ctx, export.UserID)
+	if err != nil {
+		return 0, fmt.Errorf("load user: %w", err)
+	}
+
+	files := []string{}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	add := func(name string, v interface{}) error {
+		files = append(files, name)
+		return writeZipJSON(zw, name, v)
+	}
+
+	if err := add("user.json", user); err != nil {
+		return 0, err
+	}
+
+	// Las relaciones con User se omiten: el usuario ya está en user.json
+	profile, err := s.repos.Profiles.GetByUserID(ctx, user.ID)
+	if err := optional(err); err != nil {
+		return 0, fmt.Errorf("load profile: %w", err)
+	}
+	var profileData interface{}
+	if profile != nil {
+		profileData = struct {
+			*models.UserProfile
+			User *struct{} `json:"user,omitempty"`
+		}{UserProfile: profile}
+	}
+	if err := add("profile.json", profileData); err != nil {
+		return 0, err
+	}
+
+	settings, err := s.repos.Settings.GetByUserID(ctx, user.ID)
+	if err := optional(err); err != nil {
+		return 0, fmt.Errorf("load settings: %w", err)
+	}
+	var settingsData interface{}
+	if settings != nil {
+		settingsData = struct {
+			*models.UserSettings
+			User *struct{} `json:"user,omitempty"`
+		}{UserSettings: settings}
+	}
+	if err := add("settings.json", settingsData); err != nil {
+		return 0, err
+	}
+
+	stats, err := s.repos.Stats.GetByUserID(ctx, user.ID)
+	if err := optional(err); err != nil {
+		return 0, fmt.Errorf("load stats: %w", err)
+	}
+	var statsData interface{}
+	if stats != nil {
+		statsData = struct {
+			*models.UserStats
+			User *struct{} `json:"user,omitempty"`
+		}{UserStats: stats}
+	}
+	if err := add("stats.json", statsData); err != nil {
+		return 0, err
+	}
+
+	// Códigos y tokens no se exportan (json:"-" en los modelos)
+	verifications, err := s.repos.EmailVerifications.ListByUserID(ctx, user.ID)
+	if err != nil {
+		return 0, fmt.Errorf("load email verifications: %w", err)
+	}
+	verificationData := make([]interface{}, 0, len(verifications))
+	for i := range verifications {
+		verificationData = append(verificationData, struct {
+			*models.EmailVerification
+			User *struct{} `json:"user,omitempty"`
+		}{EmailVerification: &verifications[i]})
+	}
+	if err := add("email_verifications.json", verificationData); err != nil {
+		return 0, err
+	}
+
+	resets, err := s.repos.PasswordResets.ListByUserID(ctx, user.ID)
+	if err != nil {
+		return 0, fmt.Errorf("load password resets: %w", err)
+	}
+	if err := add("password_resets.json", resets); err != nil {
+		return 0, err
+	}
+
+	var fbUser *auth.UserRecord
+	if s.firebaseAuth != nil {
+		fbUser, err = s.firebaseAuth.GetUser(ctx, user.FirebaseID)
+		if err != nil && !firebase.IsUserNotFound(err) {
+			return 0, fmt.Errorf("load firebase user: %w", err)
+		}
+	}
+	if err := add("firebase_user.json", fbUser); err != nil {
+		return 0, err
+	}
+
+	if err := add("login_events.json", loginEvents(user, stats, fbUser)); err != nil {
+		return 0, err
+	}
+	if err := add("sessions.json", sessions(fbUser)); err != nil {
+		return 0, err
+	}
+
+	manifest := map[string]interface{}{
+		"format_version": exportFormatVersion,
+		"export_id":      export.ID,
+		"user_id":        user.ID,
+		"generated_at":   time.Now().UTC(),
+		"files":          files,
+	}
+	if err := writeZipJSON(zw, "manifest.json", manifest); err != nil {
+		return 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return 0, err
+	}
+
+	size, err := s.store.Put(ctx, key, &buf, "application/zip")
+	if err != nil {
+		return 0, fmt.Errorf("store export: %w", err)
+	}
+	return size, nil
+}
+
+// expire elimina los archivos vencidos y marca sus exportaciones como expiradas
+func (s *DataExportService) expire(ctx context.Context) error {
+	exports, err := s.repos.DataExports.ListExpired(ctx, time.Now(), exportBatchSize)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i := range exports {
+		export := &exports[i]
+		if err := s.store.Delete(ctx, export.StorageKey); err != nil {
+			errs = append(errs, fmt.Errorf("delete export %d: %w", export.ID, err))
+			continue
+		}
+		export.Status = models.DataExportExpired
+		export.StorageKey = ""
+		if err := s.repos.DataExports.Update(ctx, export); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// loginEvents resume los inicios de sesión registrados. El servicio solo
+// guarda el último inicio de sesión y contadores, no un historial por evento.
+func loginEvents(user *models.User, stats *models.UserStats, fbUser *auth.UserRecord) map[string]interface{} {
+	events := map[string]interface{}{
+		"login_count":       user.LoginCount,
+		"last_login_at":     user.LastLoginAt,
+		"last_login_ip":     user.LastLoginIP,
+		"last_login_device": user.LastLoginDevice,
+	}
+	if stats != nil {
+		events["last_active_at"] = stats.LastActiveAt
+	}
+	if fbUser != nil && fbUser.UserMetadata != nil {
+		events["firebase_created_at"] = millisToTime(fbUser.UserMetadata.CreationTimestamp)
+		events["firebase_last_login_at"] = millisToTime(fbUser.UserMetadata.LastLogInTimestamp)
+	}
+	return events
+}
+
+// sessions describe las sesiones según Firebase: los refresh tokens emitidos
+// antes de tokens_valid_after están revocados
+func sessions(fbUser *auth.UserRecord) map[string]interface{} {
+	if fbUser == nil {
+		return map[string]interface{}{}
+	}
+	result := map[string]interface{}{
+		"tokens_valid_after": millisToTime(fbUser.TokensValidAfterMillis),
+	}
+	if fbUser.UserMetadata != nil {
+		result["last_refresh_at"] = millisToTime(fbUser.UserMetadata.LastRefreshTimestamp)
+	}
+	return result
+}
+
+func writeZipJSON(zw *zip.Writer, name string, v interface{}) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// optional ignora gorm.ErrRecordNotFound para los datos que el usuario puede no tener
+func optional(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	return err
+}
+
+func millisToTime(ms int64) *time.Time {
+	if ms == 0 {
+		return nil
+	}
+	t := time.UnixMilli(ms).UTC()
+	return &t
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/gorm"
+
+	"it-app_user/internal/audit"
+	"it-app_user/internal/dbtest"
+	"it-app_user/internal/repositories"
+)
+
+// fakeStore registra las operaciones sobre el almacenamiento
+type fakeStore struct {
+	signed  []string
+	deleted []string
+}
+
+func (s *fakeStore) Put(ctx context.Context, key string, r io.Reader, contentType string) (int64, error) {
+	return io.Copy(io.Discard, r)
+}
+
+func (s *fakeStore) Delete(ctx context.Context, key string) error {
+	s.deleted = append(s.deleted, key)
+	return nil
+}
+
+func (s *fakeStore) SignedURL(ctx context.Context, key string, expires time.Time) (string, error) {
+	s.signed = append(s.signed, key)
+	return "https://storage.example.com/" + key, nil
+}
+
+// newTestDataExportService crea un DataExportService sin Firebase con links de 15 minutos
+func newTestDataExportService(t *testing.T) (*DataExportService, *fakeStore, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock := dbtest.NewMockDB(t)
+	store := &fakeStore{}
+	return NewDataExportService(repositories.NewRepositories(db), nil, store, 15*time.Minute, 72*time.Hour), store, mock
+}
+
+func exportRows(id int, firebaseID, status, key string, expiresAt *time.Time) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "user_id", "firebase_id", "status", "storage_key", "expires_at"}).
+		AddRow(id, 5, firebaseID, status, key, expiresAt)
+}
+
+func TestDataExportGet(t *testing.T) {
+	soon := time.Now().Add(5 * time.Minute)
+	later := time.Now().Add(48 * time.Hour)
+	past := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name       string
+		firebaseID string
+		status     string
+		expiresAt  *time.Time
+		err        error
+		url        bool
+		urlExpires *time.Time
+	}{
+		{name: "another user's export", firebaseID: "uid-other", status: "completed", expiresAt: &later, err: gorm.ErrRecordNotFound},
+		{name: "pending", firebaseID: "uid-ana", status: "pending"},
+		{name: "completed", firebaseID: "uid-ana", status: "completed", expiresAt: &later, url: true},
+		{name: "file about to expire", firebaseID: "uid-ana", status: "completed", expiresAt: &soon, url: true, urlExpires: &soon},
+		{name: "file expired", firebaseID: "uid-ana", status: "completed", expiresAt: &past},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, store, mock := newTestDataExportService(t)
+			mock.ExpectQuery(`SELECT \* FROM "data_exports" WHERE "data_exports"."id" = \$1`).
+				WithArgs(9).
+				WillReturnRows(exportRows(9, tt.firebaseID, tt.status, "data-exports/5/data-export-9.zip", tt.expiresAt))
+
+			result, err := service.Get(context.Background(), "uid-ana", 9, "203.0.113.7")
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if (result.DownloadURL != "") != tt.url {
+				t.Fatalf("download URL = %q, want one: %v", result.DownloadURL, tt.url)
+			}
+			if !tt.url {
+				if len(store.signed) != 0 || result.URLExpiresAt != nil {
+					t.Errorf("signed %v for an export that cannot be downloaded", store.signed)
+				}
+				return
+			}
+			// El link dura urlTTL, pero nunca más que el archivo
+			want := time.Now().Add(15 * time.Minute)
+			if tt.urlExpires != nil {
+				want = *tt.urlExpires
+			}
+			if result.URLExpiresAt == nil || result.URLExpiresAt.Sub(want).Abs() > time.Second {
+				t.Errorf("URL expires at %v, want %v", result.URLExpiresAt, want)
+			}
+		})
+	}
+}
+
+func TestDataExportRequestReturnsActiveExport(t *testing.T) {
+	service, _, mock := newTestDataExportService(t)
+
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE firebase_id = \$1`).
+		WithArgs("uid-ana").
+		WillReturnRows(userRows(5, "uid-ana", "ana"))
+	mock.ExpectQuery(`SELECT \* FROM "data_exports" WHERE user_id = \$1 ORDER BY id DESC`).
+		WithArgs(5).
+		WillReturnRows(exportRows(9, "uid-ana", "processing", "", nil))
+
+	// Sin INSERT: se devuelve la exportación en curso en lugar de crear otra
+	export, created, err := service.Request(context.Background(), "uid-ana", "203.0.113.7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created || export.ID != 9 {
+		t.Errorf("export = %+v, created = %v, want the active export 9", export, created)
+	}
+}
+
+func TestDataExportRequestLeavesBuildToJob(t *testing.T) {
+	service, _, mock := newTestDataExportService(t)
+
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE firebase_id = \$1`).
+		WithArgs("uid-ana").
+		WillReturnRows(userRows(5, "uid-ana", "ana"))
+	mock.ExpectQuery(`SELECT \* FROM "data_exports" WHERE user_id = \$1 ORDER BY id DESC`).
+		WithArgs(5).
+		WillReturnError(gorm.ErrRecordNotFound)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "data_exports"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at"}).AddRow(12, time.Now(), time.Now()))
+	mock.ExpectCommit()
+
+	// Solo el INSERT: sin el UPDATE del claim, la request no genera el archivo
+	export, created, err := service.Request(context.Background(), "uid-ana", "203.0.113.7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created || export.ID != 12 || export.Status != "pending" {
+		t.Errorf("export = %+v, created = %v, want a new pending export", export, created)
+	}
+}
+
+func TestDataExportProcessPendingExpiresFiles(t *testing.T) {
+	service, store, mock := newTestDataExportService(t)
+	past := time.Now().Add(-time.Hour)
+
+	mock.ExpectQuery(`SELECT \* FROM "data_exports" WHERE status = \$1 OR`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT \* FROM "data_exports" WHERE status = \$1 AND expires_at < \$2`).
+		WillReturnRows(exportRows(9, "uid-ana", "completed", "data-exports/5/data-export-9.zip", &past))
+	mock.ExpectBegin()
+	// Se marca como expirada y se olvida la clave del archivo
+	mock.ExpectExec(`UPDATE "data_exports" SET .*"status"=\$\d.*"storage_key"=\$\d`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := service.ProcessPending(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.deleted) != 1 || store.deleted[0] != "data-exports/5/data-export-9.zip" {
+		t.Errorf("deleted = %v, want the expired file", store.deleted)
+	}
+}
+
+func TestDataExportRecordDownloadRejectsUnknownKeys(t *testing.T) {
+	service, _, _ := newTestDataExportService(t)
+	recorder := &fakeAuditRepo{}
+	audit.SetStore(recorder)
+	t.Cleanup(func() { audit.SetStore(nil) })
+
+	for _, key := range []string{
+		"data-exports/5/other.zip",
+		"data-exports/5/data-export-9.zip.bak",
+		"data-exports/05/data-export-9.zip",
+		"profiles/5/avatar.png",
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/files/download", nil)
+		if err := service.RecordDownload(r, key); !errors.Is(err, ErrUnknownExportKey) {
+			t.Errorf("RecordDownload(%q) = %v, want ErrUnknownExportKey", key, err)
+		}
+	}
+	if len(recorder.events) != 0 {
+		t.Errorf("audited %d downloads of unknown keys", len(recorder.events))
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/files/download", nil)
+	if err := service.RecordDownload(r, "data-exports/5/data-export-9.zip"); err != nil {
+		t.Fatal(err)
+	}
+	if len(recorder.events) != 1 || recorder.events[0].TargetUserID != 5 {
+		t.Errorf("audit events = %+v, want the download of user 5", recorder.events)
+	}
+}
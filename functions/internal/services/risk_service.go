@@ -0,0 +1,105 @@
+package services
+
+import (
+	"fmt"
+	"net"
+
+	"it-app_user/internal/models"
+)
+
+// Niveles de riesgo de un registro o inicio de sesión
+const (
+	RiskLevelLow    = "low"
+	RiskLevelMedium = "medium"
+	RiskLevelHigh   = "high"
+)
+
+// Motivos de la evaluación de riesgo
+const (
+	RiskReasonBlockedIP          = "blocked_ip"
+	RiskReasonBlockedEmailDomain = "blocked_email_domain"
+	RiskReasonNewIP              = "new_ip"
+	RiskReasonMissingUserAgent   = "missing_user_agent"
+)
+
+// RiskSignals son los datos con los que se evalúa un registro o inicio de sesión
+type RiskSignals struct {
+	Email     string
+	IPAddress string
+	UserAgent string
+	// User es el usuario local; nil si no existe o no se consultó
+	User *models.User
+}
+
+// RiskAssessment es el resultado de la evaluación. Blocked es true solo con
+// riesgo alto; el riesgo medio se informa pero no bloquea.
+type RiskAssessment struct {
+	Level   string   `json:"risk_level"`
+	Blocked bool     `json:"blocked"`
+	Reasons []string `json:"reasons"`
+}
+
+// HasReason indica si la evaluación incluye el motivo
+func (a RiskAssessment) HasReason(reason string) bool {
+	for _, r := range a.Reasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// RiskService evalúa el riesgo de registros e inicios de sesión con reglas
+// fijas: IPs y dominios de email bloqueados (riesgo alto), y una IP distinta
+// de la del último inicio de sesión o un User-Agent vacío (riesgo medio).
+type RiskService struct {
+	emailSettings models.EmailVerificationSettings
+	blockedNets   []*net.IPNet
+}
+
+func NewRiskService(emailSettings models.EmailVerificationSettings, blockedCIDRs []string) (*RiskService, error) {
+	blockedNets := make([]*net.IPNet, 0, len(blockedCIDRs))
+	for _, cidr := range blockedCIDRs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid blocked CIDR %q: %w", cidr, err)
+		}
+		blockedNets = append(blockedNets, ipNet)
+	}
+	return &RiskService{
+		emailSettings: emailSettings,
+		blockedNets:   blockedNets,
+	}, nil
+}
+
+// Evaluate aplica las reglas a las señales y devuelve el nivel de riesgo
+func (s *RiskService) Evaluate(signals RiskSignals) RiskAssessment {
+	assessment := RiskAssessment{Level: RiskLevelLow, Reasons: []string{}}
+	raise := func(level, reason string) {
+		assessment.Reasons = append(assessment.Reasons, reason)
+		if level == RiskLevelHigh || assessment.Level == RiskLevelLow {
+			assessment.Level = level
+		}
+	}
+
+	if ip := net.ParseIP(signals.IPAddress); ip != nil {
+		for _, ipNet := range s.blockedNets {
+			if ipNet.Contains(ip) {
+				raise(RiskLevelHigh, RiskReasonBlockedIP)
+				break
+			}
+		}
+	}
+	if signals.Email != "" && s.emailSettings.IsBlockedDomain(signals.Email) {
+		raise(RiskLevelHigh, RiskReasonBlockedEmailDomain)
+	}
+	if user := signals.User; user != nil && user.LastLoginIP != nil && signals.IPAddress != "" && *user.LastLoginIP != signals.IPAddress {
+		raise(RiskLevelMedium, RiskReasonNewIP)
+	}
+	if signals.UserAgent == "" {
+		raise(RiskLevelMedium, RiskReasonMissingUserAgent)
+	}
+
+	assessment.Blocked = assessment.Level == RiskLevelHigh
+	return assessment
+}
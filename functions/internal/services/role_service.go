@@ -0,0 +1,444 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"it-app_user/internal/logger"
+	"it-app_user/internal/models"
+	"it-app_user/internal/repositories"
+	"it-app_user/pkg/firebase"
+)
+
+var (
+	// ErrRoleNotFound indica que no existe un rol con ese ID o nombre
+	ErrRoleNotFound = errors.New("role not found")
+	// ErrRoleExists indica que ya existe un rol con ese nombre
+	ErrRoleExists = errors.New("role already exists")
+	// ErrRoleNotAssigned indica que el usuario no tiene el rol
+	ErrRoleNotAssigned = errors.New("role not assigned to user")
+	// ErrProtectedRole indica que el rol admin no puede eliminarse ni cambiar de permisos
+	ErrProtectedRole = errors.New("role is protected")
+	// ErrUnknownPermission indica que un permiso pedido no existe
+	ErrUnknownPermission = errors.New("unknown permission")
+	// ErrClaimsTooLarge indica que los custom claims superarían el límite de Firebase
+	ErrClaimsTooLarge = errors.New("custom claims exceed the Firebase size limit")
+	// ErrClaimsSyncFailed indica que los roles se guardaron pero no se pudieron
+	// copiar a los custom claims; puede reintentarse con SyncClaims
+	ErrClaimsSyncFailed = errors.New("failed to sync role claims")
+)
+
+const (
+	// maxCustomClaimsSize es el límite de Firebase para el JSON de los custom claims
+	maxCustomClaimsSize = 1000
+	// permissionCacheTTL es cuánto se reutilizan los permisos de cada rol: un
+	// cambio de permisos tarda como mucho esto en aplicarse en cada instancia
+	permissionCacheTTL = time.Minute
+)
+
+// RoleService gestiona los roles, sus permisos y su asignación a usuarios.
+// La base de datos es la fuente de verdad; cada asignación se copia a los
+// custom claims de Firebase (claims roles y role) para que los permisos se
+// resuelvan desde el token sin consultar las asignaciones. Los tokens ya
+// emitidos no cambian hasta que el cliente los refresca (como mucho una hora).
+type RoleService struct {
+	txManager    *repositories.TxManager
+	roleRepo     repositories.RoleRepositoryInterface
+	userRepo     repositories.UserRepositoryInterface
+	firebaseAuth *firebase.Auth
+
+	mu          sync.Mutex
+	permissions map[uint][]string
+	loadedAt    time.Time
+
+	// userLocks serializan las asignaciones de cada usuario en la instancia
+	// (por ID módulo 64), para no ocupar conexiones esperando el advisory lock
+	userLocks [64]sync.Mutex
+}
+
+func NewRoleService(txManager *repositories.TxManager, roleRepo repositories.RoleRepositoryInterface, userRepo repositories.UserRepositoryInterface, firebaseAuth *firebase.Auth) *RoleService {
+	return &RoleService{
+		txManager:    txManager,
+		roleRepo:     roleRepo,
+		userRepo:     userRepo,
+		firebaseAuth: firebaseAuth,
+	}
+}
+
+// List devuelve todos los roles con sus permisos
+func (s *RoleService) List(ctx context.Context) ([]models.Role, error) {
+	return s.roleRepo.List(ctx)
+}
+
+// ListPermissions devuelve todos los permisos que pueden incluirse en un rol
+func (s *RoleService) ListPermissions(ctx context.Context) ([]models.Permission, error) {
+	return s.roleRepo.ListPermissions(ctx)
+}
+
+// Create crea un rol con los permisos indicados, que deben existir
+func (s *RoleService) Create(ctx context.Context, req models.CreateRoleRequest) (*models.Role, error) {
+	permissions, err := s.resolvePermissions(ctx, req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role := &models.Role{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: permissions,
+	}
+	if err := s.roleRepo.Create(ctx, role); err != nil {
+		if repositories.IsUniqueViolation(err) {
+			return nil, ErrRoleExists
+		}
+		return nil, err
+	}
+
+	s.invalidatePermissions()
+	return role, nil
+}
+
+// Update cambia la descripción o reemplaza los permisos del rol. Como los
+// tokens llevan los IDs de los roles y no sus permisos, no hace falta
+// actualizar los claims de sus usuarios. Devuelve el rol antes y después.
+func (s *RoleService) Update(ctx context.Context, id uint, req models.UpdateRoleRequest) (*models.Role, *models.Role, error) {
+	role, err := s.getRole(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	before := *role
+
+	if req.Description != nil {
+		role.Description = *req.Description
+	}
+	if req.Permissions != nil {
+		// Las migraciones le devuelven todos los permisos al reiniciar
+		if role.Name == models.RoleAdmin {
+			return nil, nil, ErrProtectedRole
+		}
+		if role.Permissions, err = s.resolvePermissions(ctx, req.Permissions); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := s.roleRepo.Update(ctx, role); err != nil {
+		return nil, nil, err
+	}
+
+	s.invalidatePermissions()
+	return &before, role, nil
+}
+
+// Delete elimina el rol y se lo quita a sus usuarios, actualizando sus
+// claims. Si la actualización falla para algún usuario solo se registra: su
+// token conserva el ID de un rol que ya no existe, que no otorga permisos.
+// Devuelve el rol eliminado y los usuarios que lo tenían.
+func (s *RoleService) Delete(ctx context.Context, id uint) (*models.Role, []uint, error) {
+	var role *models.Role
+	var userIDs []uint
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context, repos *repositories.Repositories) error {
+		existing, err := repos.Roles.GetByID(ctx, id)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRoleNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if existing.Name == models.RoleAdmin {
+			return ErrProtectedRole
+		}
+		role = existing
+
+		if userIDs, err = repos.Roles.ListUserIDs(ctx, id); err != nil {
+			return err
+		}
+		return repos.Roles.Delete(ctx, id)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.invalidatePermissions()
+	for _, userID := range userIDs {
+		if err := s.SyncClaims(ctx, userID); err != nil {
+			logger.GetLogger().WithError(err).WithFields(map[string]interface{}{
+				"user_id": userID,
+				"role_id": id,
+			}).Warn("Failed to sync role claims after deleting role")
+		}
+	}
+	return role, userIDs, nil
+}
+
+// UserRoles devuelve los roles asignados al usuario. Devuelve
+// gorm.ErrRecordNotFound si el usuario no existe.
+func (s *RoleService) UserRoles(ctx context.Context, userID uint) ([]models.Role, error) {
+	if _, err := s.userRepo.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.roleRepo.ListByUserID(ctx, userID)
+}
+
+// Assign asigna el rol al usuario y actualiza sus custom claims. Si ya lo
+// tenía solo vuelve a sincronizar los claims. Se rechaza con
+// ErrClaimsTooLarge sin guardar nada si los claims no entran en el límite
+// de Firebase. Devuelve el usuario, el rol y si se asignó ahora.
+func (s *RoleService) Assign(ctx context.Context, userID uint, roleName, assignedBy string) (*models.User, *models.Role, bool, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	role, err := s.roleRepo.GetByName(ctx, roleName)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, false, ErrRoleNotFound
+	}
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	var assigned bool
+	err = s.syncRoles(ctx, user, func(ctx context.Context, roles repositories.RoleRepositoryInterface) error {
+		var err error
+		assigned, err = roles.Assign(ctx, &models.UserRole{
+			UserID:     user.ID,
+			RoleID:     role.ID,
+			AssignedBy: assignedBy,
+		})
+		return err
+	})
+	if err != nil && !errors.Is(err, ErrClaimsSyncFailed) {
+		return nil, nil, false, err
+	}
+	return user, role, assigned, err
+}
+
+// Unassign quita el rol al usuario y actualiza sus custom claims. Devuelve
+// el usuario y el rol quitado.
+func (s *RoleService) Unassign(ctx context.Context, userID, roleID uint) (*models.User, *models.Role, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	role, err := s.getRole(ctx, roleID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = s.syncRoles(ctx, user, func(ctx context.Context, roles repositories.RoleRepositoryInterface) error {
+		removed, err := roles.Unassign(ctx, user.ID, role.ID)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			return ErrRoleNotAssigned
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, ErrClaimsSyncFailed) {
+		return nil, nil, err
+	}
+	return user, role, err
+}
+
+// SyncClaims vuelve a copiar los roles del usuario a sus custom claims (p. ej.
+// si falló la sincronización de una asignación). Devuelve
+// gorm.ErrRecordNotFound si el usuario no existe.
+func (s *RoleService) SyncClaims(ctx context.Context, userID uint) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.syncRoles(ctx, user, nil)
+}
+
+// syncRoles aplica change a las asignaciones del usuario y copia el
+// resultado a sus custom claims. Las llamadas de un mismo usuario se
+// serializan (en la instancia con un mutex y entre instancias con un
+// advisory lock) y los claims se escriben antes del commit: así dos
+// asignaciones simultáneas no pueden dejar en Firebase los roles de la que
+// terminó primero. Si los claims no entran en el límite, change se revierte;
+// si no se pudieron escribir, change queda guardado y se devuelve
+// ErrClaimsSyncFailed. Si el commit falla después de escribir los claims,
+// se vuelven a copiar los roles guardados antes de soltar el mutex.
+func (s *RoleService) syncRoles(ctx context.Context, user *models.User, change func(ctx context.Context, roles repositories.RoleRepositoryInterface) error) error {
+	mu := &s.userLocks[user.ID%uint(len(s.userLocks))]
+	mu.Lock()
+	defer mu.Unlock()
+
+	written, syncErr, err := s.writeRoleClaims(ctx, user, change)
+	if err != nil {
+		if written {
+			// Firebase tiene los roles de un cambio que no llegó a guardarse
+			if _, resyncErr, txErr := s.writeRoleClaims(ctx, user, nil); txErr != nil || resyncErr != nil {
+				if txErr == nil {
+					txErr = resyncErr
+				}
+				logger.GetLogger().WithError(txErr).WithField("user_id", user.ID).
+					Warn("Failed to restore role claims after a failed commit")
+			}
+		}
+		return err
+	}
+	return syncErr
+}
+
+// writeRoleClaims ejecuta una transacción de syncRoles. written indica si se
+// intentó escribir los claims, syncErr el error al escribirlos y err el de la
+// transacción (incluido el commit).
+func (s *RoleService) writeRoleClaims(ctx context.Context, user *models.User, change func(ctx context.Context, roles repositories.RoleRepositoryInterface) error) (written bool, syncErr error, err error) {
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context, repos *repositories.Repositories) error {
+		written, syncErr = false, nil
+		if err := repos.Roles.LockUser(ctx, user.ID); err != nil {
+			return err
+		}
+		if change != nil {
+			if err := change(ctx, repos.Roles); err != nil {
+				return err
+			}
+		}
+		roles, err := repos.Roles.ListByUserID(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		// Los claims actuales se leen con el lock tomado para no pisar otra escritura
+		current, err := s.currentClaims(ctx, user)
+		if err != nil {
+			return err
+		}
+		claims, err := roleClaims(current, roles)
+		if err != nil {
+			return err
+		}
+		written = true
+		syncErr = s.setClaims(ctx, user, claims)
+		return nil
+	})
+	return written, syncErr, err
+}
+
+// Permissions devuelve los permisos que otorgan los roles. Los IDs de roles
+// que ya no existen se ignoran. Es el PermissionLookup del AuthMiddleware.
+func (s *RoleService) Permissions(ctx context.Context, roleIDs []uint) (map[string]bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.permissions == nil || time.Since(s.loadedAt) > permissionCacheTTL {
+		roles, err := s.roleRepo.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.permissions = make(map[uint][]string, len(roles))
+		for _, role := range roles {
+			s.permissions[role.ID] = role.PermissionNames()
+		}
+		s.loadedAt = time.Now()
+	}
+
+	granted := make(map[string]bool)
+	for _, id := range roleIDs {
+		for _, permission := range s.permissions[id] {
+			granted[permission] = true
+		}
+	}
+	return granted, nil
+}
+
+// invalidatePermissions descarta los permisos en caché de esta instancia
+func (s *RoleService) invalidatePermissions() {
+	s.mu.Lock()
+	s.permissions = nil
+	s.mu.Unlock()
+}
+
+// getRole obtiene el rol por ID o devuelve ErrRoleNotFound
+func (s *RoleService) getRole(ctx context.Context, id uint) (*models.Role, error) {
+	role, err := s.roleRepo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRoleNotFound
+	}
+	return role, err
+}
+
+// resolvePermissions obtiene los permisos por nombre y falla si alguno no existe
+func (s *RoleService) resolvePermissions(ctx context.Context, names []string) ([]models.Permission, error) {
+	permissions, err := s.roleRepo.GetPermissionsByName(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]bool, len(permissions))
+	for _, permission := range permissions {
+		found[permission.Name] = true
+	}
+	for _, name := range names {
+		if !found[name] {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownPermission, name)
+		}
+	}
+	return permissions, nil
+}
+
+// currentClaims obtiene los custom claims actuales del usuario en Firebase,
+// para conservar los que no maneja este servicio
+func (s *RoleService) currentClaims(ctx context.Context, user *models.User) (map[string]interface{}, error) {
+	if s.firebaseAuth == nil {
+		return nil, ErrFirebaseNotConfigured
+	}
+	record, err := s.firebaseAuth.GetUser(ctx, user.FirebaseID)
+	if err != nil {
+		return nil, err
+	}
+	return record.CustomClaims, nil
+}
+
+// roleClaims arma los custom claims con los roles del usuario: roles con el
+// conjunto comprimido y role con admin o user, el rol principal que usan las
+// blocking functions. El resto de los claims actuales se conserva. Falla con
+// ErrClaimsTooLarge si el JSON resultante supera el límite de Firebase.
+func roleClaims(current map[string]interface{}, roles []models.Role) (map[string]interface{}, error) {
+	claims := make(map[string]interface{}, len(current)+2)
+	for key, value := range current {
+		claims[key] = value
+	}
+
+	ids := make([]uint, len(roles))
+	primary := RoleUser
+	if isAdmin, _ := current["admin"].(bool); isAdmin {
+		primary = RoleAdmin
+	}
+	for i, role := range roles {
+		ids[i] = role.ID
+		if role.Name == models.RoleAdmin {
+			primary = RoleAdmin
+		}
+	}
+	if len(ids) > 0 {
+		claims[models.ClaimRoles] = models.EncodeRoleSet(ids)
+	} else {
+		delete(claims, models.ClaimRoles)
+	}
+	claims[ClaimRole] = primary
+
+	encoded, err := json.Marshal(claims)
+	if err != nil {
+		return nil, err
+	}
+	if len(encoded) > maxCustomClaimsSize {
+		return nil, ErrClaimsTooLarge
+	}
+	return claims, nil
+}
+
+// setClaims guarda los custom claims en Firebase; los errores se devuelven
+// envueltos en ErrClaimsSyncFailed
+func (s *RoleService) setClaims(ctx context.Context, user *models.User, claims map[string]interface{}) error {
+	if err := s.firebaseAuth.SetCustomUserClaims(ctx, user.FirebaseID, claims); err != nil {
+		return fmt.Errorf("%w: %v", ErrClaimsSyncFailed, err)
+	}
+	return nil
+}
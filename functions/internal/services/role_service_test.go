@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/gorm"
+
+	"it-app_user/internal/dbtest"
+	"it-app_user/internal/firebasetest"
+	"it-app_user/internal/models"
+	"it-app_user/internal/repositories"
+)
+
+// fakeRoleRepo resuelve los roles por ID y por nombre
+type fakeRoleRepo struct {
+	repositories.RoleRepositoryInterface
+	roles []models.Role
+}
+
+func (r *fakeRoleRepo) GetByID(ctx context.Context, id uint) (*models.Role, error) {
+	for _, role := range r.roles {
+		if role.ID == id {
+			return &role, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeRoleRepo) GetByName(ctx context.Context, name string) (*models.Role, error) {
+	for _, role := range r.roles {
+		if role.Name == name {
+			return &role, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+var testRoles = []models.Role{
+	{ID: 1, Name: models.RoleAdmin},
+	{ID: 2, Name: "support"},
+}
+
+// newTestRoleService crea un RoleService para el usuario 5 (uid-ana), con su
+// cuenta de Firebase en un emulador falso
+func newTestRoleService(t *testing.T, customClaims string) (*RoleService, sqlmock.Sqlmock, *firebasetest.Emulator) {
+	t.Helper()
+	db, mock := dbtest.NewMockDB(t)
+	firebaseAuth, emulator := firebasetest.NewAuth(t, map[string]*firebasetest.Account{
+		"uid-ana": {LocalID: "uid-ana", CustomAttributes: customClaims},
+	})
+	users := &fakeUserLookup{user: &models.User{ID: 5, FirebaseID: "uid-ana"}}
+	return NewRoleService(repositories.NewTxManager(db), &fakeRoleRepo{roles: testRoles}, users, firebaseAuth), mock, emulator
+}
+
+func (r *fakeUserLookup) GetByID(ctx context.Context, id uint) (*models.User, error) {
+	if r.user == nil || r.user.ID != id {
+		return nil, gorm.ErrRecordNotFound
+	}
+	found := *r.user
+	return &found, nil
+}
+
+// expectAssign simula el lock del usuario, la asignación y la relectura de sus roles
+func expectAssign(mock sqlmock.Sqlmock, roleIDs ...uint) {
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1, \$2\)`).WithArgs(7_236_002, 5).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO "user_roles" .* ON CONFLICT DO NOTHING`).WillReturnResult(sqlmock.NewResult(0, 1))
+	rows := sqlmock.NewRows([]string{"id", "name"})
+	for _, id := range roleIDs {
+		rows.AddRow(id, testRoles[id-1].Name)
+	}
+	mock.ExpectQuery(`SELECT "roles"\."id".* FROM "roles" JOIN user_roles ON user_roles.role_id = roles.id WHERE user_roles.user_id = \$1`).
+		WithArgs(5).
+		WillReturnRows(rows)
+	mock.ExpectQuery(`SELECT \* FROM "role_permissions"`).WillReturnRows(sqlmock.NewRows([]string{"role_id", "permission_id"}))
+}
+
+func TestRoleClaims(t *testing.T) {
+	tests := []struct {
+		name    string
+		current map[string]interface{}
+		roles   []models.Role
+		want    map[string]interface{}
+	}{
+		{
+			name:    "other claims are preserved",
+			current: map[string]interface{}{"tenant": "acme", "roles": "old"},
+			roles:   []models.Role{testRoles[1]},
+			want:    map[string]interface{}{"tenant": "acme", "roles": "BA", "role": "user"},
+		},
+		{
+			name:  "admin role",
+			roles: testRoles,
+			want:  map[string]interface{}{"roles": "Bg", "role": "admin"},
+		},
+		{
+			name:    "admin claim without the role",
+			current: map[string]interface{}{"admin": true},
+			want:    map[string]interface{}{"admin": true, "role": "admin"},
+		},
+		{
+			name:    "last role removed",
+			current: map[string]interface{}{"roles": "BA", "role": "user"},
+			want:    map[string]interface{}{"role": "user"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := roleClaims(tt.current, tt.roles)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, _ := json.Marshal(claims)
+			want, _ := json.Marshal(tt.want)
+			if string(got) != string(want) {
+				t.Errorf("claims = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestRoleClaimsTooLarge(t *testing.T) {
+	// 1000 bytes de JSON en total: {"profile":"...","role":"user"} con el relleno justo
+	padding := maxCustomClaimsSize - len(`{"profile":"","role":"user"}`)
+	claims, err := roleClaims(map[string]interface{}{"profile": strings.Repeat("x", padding)}, nil)
+	if err != nil {
+		t.Fatalf("claims of exactly %d bytes rejected: %v", maxCustomClaimsSize, err)
+	}
+	if encoded, _ := json.Marshal(claims); len(encoded) != maxCustomClaimsSize {
+		t.Fatalf("claims have %d bytes, want %d", len(encoded), maxCustomClaimsSize)
+	}
+
+	if _, err := roleClaims(map[string]interface{}{"profile": strings.Repeat("x", padding)}, testRoles[1:]); !errors.Is(err, ErrClaimsTooLarge) {
+		t.Errorf("err = %v, want ErrClaimsTooLarge", err)
+	}
+}
+
+func TestRoleAssignPreservesClaims(t *testing.T) {
+	service, mock, emulator := newTestRoleService(t, `{"tenant":"acme"}`)
+	expectAssign(mock, 2)
+	mock.ExpectCommit()
+
+	_, role, assigned, err := service.Assign(context.Background(), 5, "support", "uid-admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !assigned || role.ID != 2 {
+		t.Errorf("role = %+v, assigned = %v, want support assigned", role, assigned)
+	}
+	if claims := emulator.Account("uid-ana").CustomAttributes; claims != `{"role":"user","roles":"BA","tenant":"acme"}` {
+		t.Errorf("Firebase claims = %s, want the tenant claim preserved", claims)
+	}
+}
+
+func TestRoleAssignClaimsTooLarge(t *testing.T) {
+	current := `{"profile":"` + strings.Repeat("x", maxCustomClaimsSize-len(`{"profile":"","role":"user"}`)) + `"}`
+	service, mock, emulator := newTestRoleService(t, current)
+	// La asignación se revierte si los claims no entran en el límite de Firebase
+	expectAssign(mock, 2)
+	mock.ExpectRollback()
+
+	if _, _, _, err := service.Assign(context.Background(), 5, "support", "uid-admin"); !errors.Is(err, ErrClaimsTooLarge) {
+		t.Fatalf("err = %v, want ErrClaimsTooLarge", err)
+	}
+	if claims := emulator.Account("uid-ana").CustomAttributes; claims != current {
+		t.Errorf("Firebase claims changed to %s", claims)
+	}
+}
+
+func TestRoleAssignClaimsSyncFailed(t *testing.T) {
+	service, mock, emulator := newTestRoleService(t, "")
+	emulator.FailUpdates = true
+	expectAssign(mock, 2)
+	mock.ExpectCommit()
+
+	// El rol queda asignado y la sincronización puede reintentarse
+	_, _, assigned, err := service.Assign(context.Background(), 5, "support", "uid-admin")
+	if !errors.Is(err, ErrClaimsSyncFailed) || !assigned {
+		t.Fatalf("Assign() = assigned %v, %v, want assigned with ErrClaimsSyncFailed", assigned, err)
+	}
+}
+
+func TestRoleAssignCommitFailedRestoresClaims(t *testing.T) {
+	service, mock, emulator := newTestRoleService(t, `{"tenant":"acme"}`)
+	expectAssign(mock, 2)
+	mock.ExpectCommit().WillReturnError(errors.New("connection reset"))
+	// Los claims ya escritos se vuelven a copiar de los roles guardados
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1, \$2\)`).WithArgs(7_236_002, 5).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT "roles"\."id".* FROM "roles" JOIN user_roles`).WithArgs(5).WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	mock.ExpectCommit()
+
+	if _, _, _, err := service.Assign(context.Background(), 5, "support", "uid-admin"); err == nil || errors.Is(err, ErrClaimsSyncFailed) {
+		t.Fatalf("err = %v, want the commit error", err)
+	}
+	if claims := emulator.Account("uid-ana").CustomAttributes; claims != `{"role":"user","tenant":"acme"}` {
+		t.Errorf("Firebase claims = %s, want the roles of the failed assignment removed", claims)
+	}
+}
+
+func TestRoleAssignConcurrentSameUser(t *testing.T) {
+	service, mock, emulator := newTestRoleService(t, "")
+	// sqlmock exige las sentencias en orden: las dos asignaciones solo pasan
+	// si la segunda empieza después del commit de la primera, y con los
+	// claims de la primera ya escritos
+	expectAssign(mock, 2)
+	mock.ExpectCommit()
+	expectAssign(mock, 1, 2)
+	mock.ExpectCommit()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	for _, role := range []string{"support", models.RoleAdmin} {
+		wg.Add(1)
+		go func(role string) {
+			defer wg.Done()
+			_, _, _, err := service.Assign(context.Background(), 5, role, "uid-admin")
+			errs <- err
+		}(role)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Firebase queda con los roles de la última asignación, que ve las dos
+	if claims := emulator.Account("uid-ana").CustomAttributes; claims != `{"role":"admin","roles":"Bg"}` {
+		t.Errorf("Firebase claims = %s, want both roles", claims)
+	}
+}
+
+func TestAdminRoleIsProtected(t *testing.T) {
+	service, mock, _ := newTestRoleService(t, "")
+
+	if _, _, err := service.Update(context.Background(), 1, models.UpdateRoleRequest{Permissions: []string{models.PermissionUsersRead}}); !errors.Is(err, ErrProtectedRole) {
+		t.Errorf("Update(admin permissions) = %v, want ErrProtectedRole", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "roles" WHERE "roles"."id" = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, models.RoleAdmin))
+	mock.ExpectQuery(`SELECT \* FROM "role_permissions"`).WillReturnRows(sqlmock.NewRows([]string{"role_id", "permission_id"}))
+	mock.ExpectRollback()
+	if _, _, err := service.Delete(context.Background(), 1); !errors.Is(err, ErrProtectedRole) {
+		t.Errorf("Delete(admin) = %v, want ErrProtectedRole", err)
+	}
+}
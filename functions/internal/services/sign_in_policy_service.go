@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+
+	"it-app_user/internal/models"
+	"it-app_user/internal/repositories"
+	"it-app_user/pkg/firebase"
+)
+
+// Motivos por los que las blocking functions rechazan un registro o inicio de sesión
+var (
+	ErrEmailDomainBlocked = errors.New("email domain is not allowed")
+	ErrAccountDisabled    = errors.New("account is disabled")
+	ErrSignInRiskBlocked  = errors.New("sign-in blocked by risk policy")
+)
+
+// Claims que las blocking functions agregan a los tokens de Firebase
+const (
+	ClaimRole        = "role"
+	ClaimLocalUserID = "local_user_id"
+)
+
+// Roles por defecto mientras el usuario no tenga uno asignado en sus custom claims
+const (
+	RoleUser  = "user"
+	RoleAdmin = models.RoleAdmin
+)
+
+// SignInDecision es el resultado de aplicar las políticas a un evento de
+// Identity Platform. Si el evento se rechaza, Update es nil y el error indica el motivo.
+type SignInDecision struct {
+	Update *firebase.BlockingUserUpdate
+	Risk   RiskAssessment
+	// LocalUser es el usuario local del evento; nil si todavía no existe
+	LocalUser *models.User
+}
+
+// SignInPolicyService aplica en las blocking functions de Identity Platform
+// las políticas del servicio: dominios de email bloqueados, usuarios locales
+// deshabilitados y decisiones del motor de riesgo. Si el evento pasa, agrega
+// el rol y el ID local a los claims del usuario.
+type SignInPolicyService struct {
+	userRepo repositories.UserRepositoryInterface
+	risk     *RiskService
+}
+
+func NewSignInPolicyService(userRepo repositories.UserRepositoryInterface, risk *RiskService) *SignInPolicyService {
+	return &SignInPolicyService{
+		userRepo: userRepo,
+		risk:     risk,
+	}
+}
+
+// BeforeCreate decide si se permite crear la cuenta de Firebase. También se
+// rechaza si el email pertenece a un usuario local deshabilitado, para que no
+// pueda volver a registrarse con otra cuenta. El rol queda en los custom claims.
+func (s *SignInPolicyService) BeforeCreate(ctx context.Context, event *firebase.BlockingEvent) (*SignInDecision, error) {
+	record := event.UserRecord
+	decision := &SignInDecision{}
+
+	if record.Email != "" {
+		user, err := s.userRepo.GetByEmail(ctx, record.Email)
+		switch {
+		case err == nil:
+			decision.LocalUser = user
+			if user.Disabled {
+				return decision, ErrAccountDisabled
+			}
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return decision, err
+		}
+	}
+
+	// El usuario local que comparte el email es otra cuenta: su última IP no aplica
+	decision.Risk = s.risk.Evaluate(RiskSignals{
+		Email:     record.Email,
+		IPAddress: event.IPAddress,
+		UserAgent: event.UserAgent,
+	})
+	if err := riskError(decision.Risk); err != nil {
+		return decision, err
+	}
+
+	claims := make(map[string]interface{}, len(record.CustomClaims)+1)
+	for key, value := range record.CustomClaims {
+		claims[key] = value
+	}
+	claims[ClaimRole] = roleFromClaims(record.CustomClaims)
+	decision.Update = &firebase.BlockingUserUpdate{
+		UpdateMask:   "customClaims",
+		CustomClaims: claims,
+	}
+	return decision, nil
+}
+
+// BeforeSignIn decide si se permite el inicio de sesión. Rechaza a los
+// usuarios locales deshabilitados o con borrado lógico. El rol y el ID local
+// van como session claims: solo están en los tokens de esta sesión.
+func (s *SignInPolicyService) BeforeSignIn(ctx context.Context, event *firebase.BlockingEvent) (*SignInDecision, error) {
+	record := event.UserRecord
+	decision := &SignInDecision{}
+
+	user, err := s.userRepo.GetByFirebaseID(ctx, record.UID)
+	switch {
+	case err == nil:
+		decision.LocalUser = user
+		if user.Disabled {
+			return decision, ErrAccountDisabled
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		// Puede no estar registrado todavía; si tiene borrado lógico no entra
+		deleted, err := s.userRepo.GetDeletedByFirebaseID(ctx, record.UID)
+		if err == nil {
+			decision.LocalUser = deleted
+			return decision, ErrAccountDisabled
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return decision, err
+		}
+	default:
+		return decision, err
+	}
+
+	decision.Risk = s.risk.Evaluate(RiskSignals{
+		Email:     record.Email,
+		IPAddress: event.IPAddress,
+		UserAgent: event.UserAgent,
+		User:      decision.LocalUser,
+	})
+	if err := riskError(decision.Risk); err != nil {
+		return decision, err
+	}
+
+	claims := map[string]interface{}{
+		ClaimRole: roleFromClaims(record.CustomClaims),
+	}
+	if decision.LocalUser != nil {
+		claims[ClaimLocalUserID] = decision.LocalUser.ID
+	}
+	decision.Update = &firebase.BlockingUserUpdate{
+		UpdateMask:    "sessionClaims",
+		SessionClaims: claims,
+	}
+	return decision, nil
+}
+
+// riskError traduce una evaluación bloqueante al error que explica el rechazo
+func riskError(assessment RiskAssessment) error {
+	switch {
+	case !assessment.Blocked:
+		return nil
+	case assessment.HasReason(RiskReasonBlockedEmailDomain):
+		return ErrEmailDomainBlocked
+	default:
+		return ErrSignInRiskBlocked
+	}
+}
+
+// roleFromClaims devuelve el rol de los custom claims o, si no tiene, admin o
+// user según el claim admin del primer administrador
+func roleFromClaims(claims map[string]interface{}) string {
+	if role, ok := claims[ClaimRole].(string); ok && role != "" {
+		return role
+	}
+	if isAdmin, _ := claims["admin"].(bool); isAdmin {
+		return RoleAdmin
+	}
+	return RoleUser
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+	"gorm.io/gorm"
+
+	"it-app_user/internal/models"
+	"it-app_user/internal/principal"
+	"it-app_user/internal/repositories"
+	"it-app_user/pkg/firebase"
+)
+
+// Estados de la sesión de un token activo
+const (
+	SessionStatusActive       = "active"
+	SessionStatusImpersonated = "impersonated"
+)
+
+// TokenIntrospection es la respuesta de introspección de RFC 7662. Si el
+// token no está activo solo se informa Active; el resto de los campos queda
+// vacío para no revelar nada de un token inválido.
+type TokenIntrospection struct {
+	Active    bool   `json:"active"`
+	TokenType string `json:"token_type,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	Issuer    string `json:"iss,omitempty"`
+	Audience  string `json:"aud,omitempty"`
+	// ClientID es el cliente para el que se emitió el token: el proyecto de
+	// Firebase (aud). Quien consulta y quien suplanta van en Session.
+	ClientID  string `json:"client_id,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	// Scope son los permisos de los roles del usuario, separados por espacios
+	Scope    string `json:"scope,omitempty"`
+	Username string `json:"username,omitempty"`
+	// UserID es el ID local del usuario; nil si todavía no está registrado
+	UserID  *uint                `json:"user_id,omitempty"`
+	Roles   []string             `json:"roles,omitempty"`
+	Session *IntrospectedSession `json:"session,omitempty"`
+}
+
+// IntrospectedSession describe la sesión del token introspeccionado. RequestedBy
+// es quien consulta (su Firebase UID o client:<nombre> si es un servicio) e
+// ImpersonatedBy el administrador que suplanta al titular.
+type IntrospectedSession struct {
+	Status                 string `json:"status"`
+	AuthTime               int64  `json:"auth_time"`
+	SignInProvider         string `json:"sign_in_provider,omitempty"`
+	RequestedBy            string `json:"requested_by,omitempty"`
+	ImpersonatedBy         string `json:"impersonated_by,omitempty"`
+	ImpersonationExpiresAt int64  `json:"impersonation_expires_at,omitempty"`
+}
+
+// TokenIntrospectionService informa a los gateways y a otros servicios si un
+// ID token de Firebase sigue activo y a quién pertenece, sin que tengan que
+// verificarlo ni conocer los roles locales
+type TokenIntrospectionService struct {
+	firebaseAuth *firebase.Auth
+	userRepo     repositories.UserRepositoryInterface
+	roleRepo     repositories.RoleRepositoryInterface
+}
+
+func NewTokenIntrospectionService(firebaseAuth *firebase.Auth, userRepo repositories.UserRepositoryInterface, roleRepo repositories.RoleRepositoryInterface) *TokenIntrospectionService {
+	return &TokenIntrospectionService{
+		firebaseAuth: firebaseAuth,
+		userRepo:     userRepo,
+		roleRepo:     roleRepo,
+	}
+}
+
+// Introspect verifica el token contra Firebase, incluida la revocación, y lo
+// completa con el usuario local. Un token inválido, vencido o revocado, de
+// una cuenta deshabilitada o eliminada, o de una suplantación terminada no
+// está activo. Solo devuelve error si no se pudo decidir. caller es quien
+// consulta, para informar session.requested_by.
+func (s *TokenIntrospectionService) Introspect(ctx context.Context, rawToken string, caller principal.Principal) (*TokenIntrospection, error) {
+	inactive := &TokenIntrospection{Active: false}
+
+	token, err := s.firebaseAuth.VerifyIDTokenAndCheckRevoked(ctx, rawToken)
+	if err != nil {
+		if firebase.IsIDTokenInvalid(err) || firebase.IsUserNotFound(err) {
+			return inactive, nil
+		}
+		return nil, err
+	}
+
+	p := principal.FromClaims(token.UID, token.Claims)
+	if p.Expired(time.Now()) {
+		return inactive, nil
+	}
+
+	user, err := s.localUser(ctx, token.UID)
+	if err != nil {
+		return nil, err
+	}
+	if user != nil && (user.Disabled || user.DeletedAt.Valid) {
+		return inactive, nil
+	}
+
+	result := &TokenIntrospection{
+		Active:    true,
+		TokenType: "Bearer",
+		Subject:   token.Subject,
+		Issuer:    token.Issuer,
+		Audience:  token.Audience,
+		ClientID:  token.Audience,
+		ExpiresAt: token.Expires,
+		IssuedAt:  token.IssuedAt,
+		Session:   introspectedSession(token, p, caller),
+	}
+	if user == nil {
+		return result, nil
+	}
+
+	result.UserID = &user.ID
+	result.Username = user.Username
+	roles, err := s.roleRepo.ListByUserID(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	result.Roles = make([]string, len(roles))
+	for i, role := range roles {
+		result.Roles[i] = role.Name
+	}
+	// Un token de suplantación no tiene los permisos del usuario
+	if !p.Impersonated() {
+		result.Scope = tokenScope(token.Claims, roles)
+	}
+	return result, nil
+}
+
+// localUser obtiene el usuario local del Firebase UID, incluso con borrado
+// lógico; nil si no está registrado
+func (s *TokenIntrospectionService) localUser(ctx context.Context, firebaseID string) (*models.User, error) {
+	user, err := s.userRepo.GetByFirebaseID(ctx, firebaseID)
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, err
+	}
+	user, err = s.userRepo.GetDeletedByFirebaseID(ctx, firebaseID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return user, err
+}
+
+// introspectedSession arma el estado de la sesión a partir de los claims y
+// de quien consulta
+func introspectedSession(token *auth.Token, p principal.Principal, caller principal.Principal) *IntrospectedSession {
+	session := &IntrospectedSession{
+		Status:         SessionStatusActive,
+		SignInProvider: token.Firebase.SignInProvider,
+		RequestedBy:    caller.Actor(),
+	}
+	// Los números de los claims llegan como float64 al decodificar el JWT
+	if authTime, ok := token.Claims["auth_time"].(float64); ok {
+		session.AuthTime = int64(authTime)
+	}
+	if p.Impersonated() {
+		session.Status = SessionStatusImpersonated
+		session.ImpersonatedBy = p.ImpersonatedBy
+		session.ImpersonationExpiresAt = p.ExpiresAt.Unix()
+	}
+	return session
+}
+
+// tokenScope son los permisos que otorgan los roles, ordenados y separados
+// por espacios. El claim admin=true otorga todo el catálogo, como en AuthMiddleware.
+func tokenScope(claims map[string]interface{}, roles []models.Role) string {
+	granted := make(map[string]bool)
+	if isAdmin, _ := claims["admin"].(bool); isAdmin {
+		for _, permission := range models.PermissionCatalog {
+			granted[permission.Name] = true
+		}
+	}
+	for _, role := range roles {
+		for _, permission := range role.PermissionNames() {
+			granted[permission] = true
+		}
+	}
+
+	scopes := make([]string, 0, len(granted))
+	for permission := range granted {
+		scopes = append(scopes, permission)
+	}
+	sort.Strings(scopes)
+	return strings.Join(scopes, " ")
+}
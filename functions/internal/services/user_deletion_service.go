@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+
+	"it-app_user/internal/audit"
+	"it-app_user/internal/logger"
+	"it-app_user/internal/metrics"
+	"it-app_user/internal/models"
+	"it-app_user/internal/repositories"
+	"it-app_user/internal/storage"
+	"it-app_user/pkg/firebase"
+)
+
+var (
+	// ErrUserDeleted indica que el usuario tiene un borrado lógico pendiente de purga
+	ErrUserDeleted = errors.New("user is scheduled for deletion")
+	// ErrRestoreWindowExpired indica que venció el período de gracia para restaurar al usuario
+	ErrRestoreWindowExpired = errors.New("restore window expired")
+	// ErrFirebaseNotConfigured indica que la operación no puede ejecutarse sin Firebase Auth
+	ErrFirebaseNotConfigured = errors.New("firebase auth not configured")
+)
+
+// UserDeletionService gestiona el ciclo de vida de la eliminación de usuarios:
+// borrado lógico, restauración durante el período de gracia y purga definitiva
+type UserDeletionService struct {
+	userRepo     repositories.UserRepositoryInterface
+	txManager    *repositories.TxManager
+	firebaseAuth *firebase.Auth
+	gracePeriod  time.Duration
+	batchSize    int
+	exportRepo   repositories.DataExportRepositoryInterface
+	exportStore  storage.Store
+}
+
+func NewUserDeletionService(userRepo repositories.UserRepositoryInterface, txManager *repositories.TxManager, firebaseAuth *firebase.Auth, gracePeriod time.Duration, batchSize int) *UserDeletionService {
+	return &UserDeletionService{
+		userRepo:     userRepo,
+		txManager:    txManager,
+		firebaseAuth: firebaseAuth,
+		gracePeriod:  gracePeriod,
+		batchSize:    batchSize,
+	}
+}
+
+// SetExports configura el repositorio y el almacenamiento de exportaciones de
+// datos, para eliminar los archivos del usuario al purgarlo
+func (s *UserDeletionService) SetExports(repo repositories.DataExportRepositoryInterface, store storage.Store) {
+	s.exportRepo = repo
+	s.exportStore = store
+}
+
+// PurgeAfter devuelve desde cuándo puede purgarse un usuario eliminado en deletedAt
+func (s *UserDeletionService) PurgeAfter(deletedAt time.Time) time.Time {
+	return deletedAt.Add(s.gracePeriod)
+}
+
+// SoftDelete marca el usuario como eliminado y deshabilita su cuenta de
+// Firebase (revocando sus refresh tokens) para que no pueda volver a entrar
+// mientras dura el período de gracia. Devuelve gorm.ErrRecordNotFound si no existe.
+func (s *UserDeletionService) SoftDelete(ctx context.Context, id uint) (*models.User, error) {
+	user, err := s.SoftDeleteLocal(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// El usuario ya está eliminado localmente: si Firebase falla el login igual
+	// lo rechaza (ErrUserDeleted) y la purga borrará la cuenta al final
+	s.setFirebaseDisabled(ctx, user, true)
+	return user, nil
+}
+
+// SoftDeleteLocal marca el usuario como eliminado sin tocar su cuenta de
+// Firebase, para cuando la cuenta ya no existe. Puede ejecutarse dentro de
+// otra transacción. Devuelve gorm.ErrRecordNotFound si no existe.
+func (s *UserDeletionService) SoftDeleteLocal(ctx context.Context, id uint) (*models.User, error) {
+	var user *models.User
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context, repos *repositories.Repositories) error {
+		if _, err := repos.Users.GetByID(ctx, id); err != nil {
+			return err
+		}
+		if err := repos.Users.Delete(ctx, id); err != nil {
+			return err
+		}
+		deleted, err := repos.Users.GetDeletedByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		user = deleted
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// Restore deshace el borrado lógico si no venció el período de gracia y
+// vuelve a habilitar la cuenta de Firebase (salvo que el usuario esté
+// deshabilitado localmente). Devuelve gorm.ErrRecordNotFound si no hay un
+// usuario eliminado con ese ID.
+func (s *UserDeletionService) Restore(ctx context.Context, id uint) (*models.User, error) {
+	var user *models.User
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context, repos *repositories.Repositories) error {
+		deleted, err := repos.Users.GetDeletedByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if time.Now().After(s.PurgeAfter(deleted.DeletedAt.Time)) {
+			return ErrRestoreWindowExpired
+		}
+		if err := repos.Users.Restore(ctx, id); err != nil {
+			return err
+		}
+		restored, err := repos.Users.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		user = restored
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.Disabled {
+		s.setFirebaseDisabled(ctx, user, false)
+	}
+	return user, nil
+}
+
+// PurgeExpired elimina definitivamente hasta batchSize usuarios cuyo período
+// de gracia venció: primero su cuenta de Firebase y sus archivos de
+// exportación y después, en una transacción, sus solicitudes de eliminación,
+// exportaciones, tokens de reset, verificaciones de email, roles, perfil,
+// configuraciones, estadísticas y la fila de users (ver Erase). Un usuario
+// que falla queda para la próxima ejecución; devuelve cuántos se purgaron.
+// Sin Firebase Auth no purga a nadie (ver Erase).
+func (s *UserDeletionService) PurgeExpired(ctx context.Context) (int, error) {
+	log := logger.GetLogger()
+	if s.firebaseAuth == nil {
+		return 0, ErrFirebaseNotConfigured
+	}
+
+	users, err := s.userRepo.ListDeletedBefore(ctx, time.Now().Add(-s.gracePeriod), s.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	var errs []error
+	for i := range users {
+		if err := s.Erase(ctx, &users[i]); err != nil {
+			log.WithError(err).WithField("user_id", users[i].ID).Error("Failed to purge user")
+			errs = append(errs, fmt.Errorf("user %d: %w", users[i].ID, err))
+			continue
+		}
+		purged++
+		metrics.UsersPurged.Inc()
+		audit.Record(ctx, audit.Event{
+			Action:           audit.ActionUserPurged,
+			ActorID:          audit.ActorSystem,
+			TargetUserID:     users[i].ID,
+			TargetFirebaseID: users[i].FirebaseID,
+		})
+		log.WithField("user_id", users[i].ID).Info("User purged")
+	}
+
+	return purged, errors.Join(errs...)
+}
+
+// Erase elimina definitivamente al usuario, esté o no borrado lógicamente:
+// su cuenta de Firebase, sus archivos de exportación y todas sus filas.
+// Es idempotente, así que puede reintentarse si falla a mitad de camino.
+// Sin Firebase Auth devuelve ErrFirebaseNotConfigured: borrar solo las filas
+// dejaría una cuenta de Firebase que puede volver a entrar sin usuario local.
+func (s *UserDeletionService) Erase(ctx context.Context, user *models.User) error {
+	if s.firebaseAuth == nil {
+		return ErrFirebaseNotConfigured
+	}
+	// Firebase primero: si falla la base de datos, el próximo intento encuentra
+	// la cuenta ya eliminada y continúa
+	if err := s.firebaseAuth.DeleteUser(ctx, user.FirebaseID); err != nil && !firebase.IsUserNotFound(err) {
+		return err
+	}
+	if err := s.deleteExportFiles(ctx, user.ID); err != nil {
+		return err
+	}
+
+	return s.txManager.WithinTransaction(ctx, func(ctx context.Context, repos *repositories.Repositories) error {
+		if err := repos.AccountDeletions.DeleteByUserID(ctx, user.ID); err != nil {
+			return err
+		}
+		if err := repos.DataExports.DeleteByUserID(ctx, user.ID); err != nil {
+			return err
+		}
+		if err := repos.PasswordResets.DeleteByUserID(ctx, user.ID); err != nil {
+			return err
+		}
+		if err := repos.EmailVerifications.DeleteByUserID(ctx, user.ID); err != nil {
+			return err
+		}
+		if err := repos.Roles.DeleteByUserID(ctx, user.ID); err != nil {
+			return err
+		}
+		if err := repos.Profiles.Delete(ctx, user.ID); err != nil {
+			return err
+		}
+		if err := repos.Settings.Delete(ctx, user.ID); err != nil {
+			return err
+		}
+		if err := repos.Stats.Delete(ctx, user.ID); err != nil {
+			return err
+		}
+		// Purge solo borra filas con borrado lógico
+		if err := repos.Users.Delete(ctx, user.ID); err != nil {
+			return err
+		}
+		if err := repos.Users.Purge(ctx, user.ID); err != nil {
+			return err
+		}
+		// El historial va al final: el borrado lógico de arriba también genera una versión
+		return repos.Versions.DeleteByUserID(ctx, user.ID)
+	})
+}
+
+// deleteExportFiles elimina los archivos de exportación del usuario que todavía no vencieron
+func (s *UserDeletionService) deleteExportFiles(ctx context.Context, userID uint) error {
+	if s.exportRepo == nil || s.exportStore == nil {
+		return nil
+	}
+	exports, err := s.exportRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, export := range exports {
+		if export.StorageKey == "" {
+			continue
+		}
+		if err := s.exportStore.Delete(ctx, export.StorageKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setFirebaseDisabled habilita o deshabilita la cuenta de Firebase del usuario;
+// al deshabilitarla también revoca sus refresh tokens. Los errores solo se registran.
+func (s *UserDeletionService) setFirebaseDisabled(ctx context.Context, user *models.User, disabled bool) {
+	if s.firebaseAuth == nil {
+		return
+	}
+	log := logger.GetLogger().WithField("user_id", user.ID)
+
+	_, err := s.firebaseAuth.UpdateUser(ctx, user.FirebaseID, (&auth.UserToUpdate{}).Disabled(disabled))
+	if err != nil && !firebase.IsUserNotFound(err) {
+		log.WithError(err).WithField("disabled", disabled).Warn("Failed to update Firebase account status")
+		return
+	}
+	if disabled && err == nil {
+		if err := s.firebaseAuth.RevokeRefreshTokens(ctx, user.FirebaseID); err != nil {
+			log.WithError(err).Warn("Failed to revoke Firebase refresh tokens")
+		}
+	}
+}
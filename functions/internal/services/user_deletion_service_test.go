@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/gorm"
+
+	"it-app_user/internal/dbtest"
+	"it-app_user/internal/repositories"
+)
+
+const testGracePeriod = 30 * 24 * time.Hour
+
+// newTestDeletionService crea un UserDeletionService sin Firebase sobre una base de datos simulada
+func newTestDeletionService(t *testing.T) (*UserDeletionService, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock := dbtest.NewMockDB(t)
+	return NewUserDeletionService(repositories.NewUserRepository(db), repositories.NewTxManager(db), nil, testGracePeriod, 100), mock
+}
+
+func deletedUserRows(id int, deletedAt time.Time) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "firebase_id", "username", "deleted_at"}).
+		AddRow(id, "uid-ana", "ana", deletedAt)
+}
+
+func TestPurgeExpiredWithoutFirebase(t *testing.T) {
+	// Sin expectativas en la base de datos: no se consulta ni se borra nada
+	service, _ := newTestDeletionService(t)
+
+	purged, err := service.PurgeExpired(context.Background())
+	if !errors.Is(err, ErrFirebaseNotConfigured) || purged != 0 {
+		t.Fatalf("PurgeExpired() = %d, %v, want 0, ErrFirebaseNotConfigured", purged, err)
+	}
+}
+
+func TestSoftDeleteNotFound(t *testing.T) {
+	service, mock := newTestDeletionService(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1 AND "users"."deleted_at" IS NULL`).
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	if _, err := service.SoftDelete(context.Background(), 5); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("err = %v, want gorm.ErrRecordNotFound", err)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	tests := []struct {
+		name      string
+		deletedAt time.Time
+		err       error
+	}{
+		{name: "within the grace period", deletedAt: time.Now().Add(-testGracePeriod + time.Hour)},
+		{name: "grace period expired", deletedAt: time.Now().Add(-testGracePeriod - time.Hour), err: ErrRestoreWindowExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock := newTestDeletionService(t)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT \* FROM "users" WHERE deleted_at IS NOT NULL AND "users"."id" = \$1`).
+				WithArgs(5).
+				WillReturnRows(deletedUserRows(5, tt.deletedAt))
+			if tt.err != nil {
+				mock.ExpectRollback()
+			} else {
+				mock.ExpectExec(`UPDATE "users" SET "deleted_at"=\$1,"updated_at"=\$2 WHERE id = \$3 AND deleted_at IS NOT NULL`).
+					WithArgs(nil, sqlmock.AnyArg(), 5).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1 AND "users"."deleted_at" IS NULL`).
+					WithArgs(5).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "firebase_id", "username"}).AddRow(5, "uid-ana", "ana"))
+				mock.ExpectCommit()
+			}
+
+			user, err := service.Restore(context.Background(), 5)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if tt.err == nil && (user == nil || user.ID != 5 || user.DeletedAt.Valid) {
+				t.Errorf("user = %+v, want the restored user 5", user)
+			}
+		})
+	}
+}
+
+func TestRestoreNotDeleted(t *testing.T) {
+	service, mock := newTestDeletionService(t)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE deleted_at IS NOT NULL AND "users"."id" = \$1`).
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectRollback()
+
+	if _, err := service.Restore(context.Background(), 5); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("err = %v, want gorm.ErrRecordNotFound", err)
+	}
+}
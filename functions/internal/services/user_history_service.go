@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"it-app_user/internal/models"
+	"it-app_user/internal/repositories"
+)
+
+// Errores del historial de usuarios
+var (
+	ErrVersionNotFound    = errors.New("version not found")
+	ErrFieldNotRevertible = errors.New("field cannot be reverted")
+	ErrRevertConflict     = errors.New("reverted value is already in use")
+)
+
+// RevertibleFields son, por tabla, los campos que un admin puede revertir.
+// Email, email_verified, disabled y Firebase ID no están: los administra
+// Firebase Auth y revertirlos solo en Postgres desincronizaría las cuentas.
+var RevertibleFields = map[string][]string{
+	models.VersionEntityUser:    {"username", "first_name", "last_name", "photo_url", "status"},
+	models.VersionEntityProfile: {"avatar", "bio", "website", "location", "birthday", "gender", "phone"},
+}
+
+// UserHistoryService consulta el historial de versiones de usuarios y
+// perfiles y revierte campos a una versión anterior
+type UserHistoryService struct {
+	versions  repositories.RecordVersionRepositoryInterface
+	txManager *repositories.TxManager
+}
+
+func NewUserHistoryService(versions repositories.RecordVersionRepositoryInterface, txManager *repositories.TxManager) *UserHistoryService {
+	return &UserHistoryService{
+		versions:  versions,
+		txManager: txManager,
+	}
+}
+
+// UserAsOf es el estado de un usuario y de su perfil en un momento dado.
+// Profile es nil si el usuario todavía no tenía perfil.
+type UserAsOf struct {
+	At      time.Time             `json:"at"`
+	User    *models.RecordVersion `json:"user"`
+	Profile *models.RecordVersion `json:"profile"`
+}
+
+// RevertResult es el resultado de revertir campos a una versión anterior
+type RevertResult struct {
+	Version *models.RecordVersion
+	// Before y After son la fila (User o UserProfile) antes y después de revertir
+	Before interface{}
+	After  interface{}
+}
+
+// List obtiene las versiones del usuario, de la más reciente a la más antigua
+func (s *UserHistoryService) List(ctx context.Context, filter repositories.RecordVersionFilter) ([]models.RecordVersion, error) {
+	return s.versions.List(ctx, filter)
+}
+
+// AsOf obtiene el usuario y su perfil tal como estaban en at. Devuelve
+// gorm.ErrRecordNotFound si el usuario no tenía ninguna versión en ese momento.
+func (s *UserHistoryService) AsOf(ctx context.Context, userID uint, at time.Time) (*UserAsOf, error) {
+	user, err := s.versions.GetAsOf(ctx, models.VersionEntityUser, userID, at)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &UserAsOf{At: at, User: user}
+	profile, err := s.versions.GetAsOf(ctx, models.VersionEntityProfile, userID, at)
+	switch {
+	case err == nil:
+		result.Profile = profile
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+	return result, nil
+}
+
+// Revert vuelve los campos indicados de la fila de la versión (usuario o
+// perfil) a los valores que tenían en esa versión. El cambio genera una
+// versión nueva, así que también puede revertirse. Devuelve ErrVersionNotFound
+// si la versión no existe o es de otro usuario, y gorm.ErrRecordNotFound si
+// el usuario ya no existe o tiene borrado lógico.
+func (s *UserHistoryService) Revert(ctx context.Context, userID, versionID uint, fields []string) (*RevertResult, error) {
+	version, err := s.versions.GetByID(ctx, versionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && version.UserID != userID) {
+		return nil, ErrVersionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		if !isRevertible(version.Entity, field) {
+			return nil, ErrFieldNotRevertible
+		}
+		if value, ok := version.Data[field]; ok {
+			values[field] = value
+		}
+	}
+	// Los nombres de las columnas coinciden con los tags json de los modelos
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RevertResult{Version: version}
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context, repos *repositories.Repositories) error {
+		switch version.Entity {
+		case models.VersionEntityUser:
+			user, err := repos.Users.GetByID(ctx, userID)
+			if err != nil {
+				return err
+			}
+			before := *user
+			if err := json.Unmarshal(encoded, user); err != nil {
+				return err
+			}
+			if err := repos.Users.Update(ctx, user); err != nil {
+				if repositories.IsUniqueViolation(err) {
+					return ErrRevertConflict
+				}
+				return err
+			}
+			result.Before, result.After = before, user
+
+		case models.VersionEntityProfile:
+			profile, err := repos.Profiles.GetByUserID(ctx, userID)
+			if err != nil {
+				return err
+			}
+			before := *profile
+			// json.Unmarshal escribe a través de los punteros que ya existen:
+			// sin copiarlo, before también tendría la fecha revertida
+			if profile.Birthday != nil {
+				birthday := *profile.Birthday
+				before.Birthday = &birthday
+			}
+			if err := json.Unmarshal(encoded, profile); err != nil {
+				return err
+			}
+			if err := repos.Profiles.Update(ctx, profile); err != nil {
+				return err
+			}
+			result.Before, result.After = before, profile
+
+		default:
+			return ErrFieldNotRevertible
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// isRevertible indica si el campo de la tabla está en RevertibleFields
+func isRevertible(entity, field string) bool {
+	for _, f := range RevertibleFields[entity] {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/gorm"
+
+	"it-app_user/internal/dbtest"
+	"it-app_user/internal/models"
+	"it-app_user/internal/repositories"
+)
+
+func newTestHistoryService(t *testing.T) (*UserHistoryService, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock := dbtest.NewMockDB(t)
+	return NewUserHistoryService(repositories.NewRecordVersionRepository(db), repositories.NewTxManager(db)), mock
+}
+
+// expectVersion simula la lectura de la versión 9 del usuario 5
+func expectVersion(mock sqlmock.Sqlmock, entity, data string) {
+	mock.ExpectQuery(`SELECT \* FROM "record_versions" WHERE "record_versions"."id" = \$1`).
+		WithArgs(9).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "entity", "record_id", "user_id", "version", "operation", "data"}).
+			AddRow(9, entity, 5, 5, 2, models.VersionOperationUpdate, data))
+}
+
+func TestRevertRejectsFieldNotRevertible(t *testing.T) {
+	tests := []struct {
+		name   string
+		entity string
+		fields []string
+	}{
+		// Los administra Firebase Auth
+		{name: "email", entity: models.VersionEntityUser, fields: []string{"username", "email"}},
+		{name: "disabled", entity: models.VersionEntityUser, fields: []string{"disabled"}},
+		{name: "firebase id", entity: models.VersionEntityUser, fields: []string{"firebase_id"}},
+		// Cada tabla tiene su propia lista
+		{name: "user field on a profile version", entity: models.VersionEntityProfile, fields: []string{"username"}},
+		{name: "unknown column", entity: models.VersionEntityProfile, fields: []string{"preferences"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, mock := newTestHistoryService(t)
+			expectVersion(mock, tt.entity, `{"username":"ana","email":"old@example.com","disabled":true,"firebase_id":"uid-old"}`)
+
+			// Se rechaza antes de abrir la transacción: sqlmock falla si se escribe algo
+			if _, err := service.Revert(context.Background(), 5, 9, tt.fields); !errors.Is(err, ErrFieldNotRevertible) {
+				t.Errorf("Revert(%v) = %v, want ErrFieldNotRevertible", tt.fields, err)
+			}
+		})
+	}
+}
+
+func TestRevertVersionOfAnotherUser(t *testing.T) {
+	service, mock := newTestHistoryService(t)
+	expectVersion(mock, models.VersionEntityUser, `{"username":"ana"}`)
+
+	if _, err := service.Revert(context.Background(), 6, 9, []string{"username"}); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("Revert() = %v, want ErrVersionNotFound", err)
+	}
+}
+
+func TestAsOfBeforeFirstVersion(t *testing.T) {
+	service, mock := newTestHistoryService(t)
+	at := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT \* FROM "record_versions" WHERE entity = \$1 AND user_id = \$2 AND valid_from <= \$3 ORDER BY valid_from DESC, id DESC`).
+		WithArgs(models.VersionEntityUser, 5, at).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	// Sin versión del usuario no se consulta el perfil
+	if _, err := service.AsOf(context.Background(), 5, at); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("AsOf() = %v, want gorm.ErrRecordNotFound", err)
+	}
+}
+
+func TestAsOfWithoutProfile(t *testing.T) {
+	service, mock := newTestHistoryService(t)
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT \* FROM "record_versions" WHERE entity = \$1 AND user_id = \$2 AND valid_from <= \$3`).
+		WithArgs(models.VersionEntityUser, 5, at).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "entity", "user_id", "version", "data"}).
+			AddRow(3, models.VersionEntityUser, 5, 1, `{"username":"ana"}`))
+	mock.ExpectQuery(`SELECT \* FROM "record_versions" WHERE entity = \$1 AND user_id = \$2 AND valid_from <= \$3`).
+		WithArgs(models.VersionEntityProfile, 5, at).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	result, err := service.AsOf(context.Background(), 5, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.User == nil || result.User.Data["username"] != "ana" || result.Profile != nil {
+		t.Errorf("AsOf() = %+v, want the user version without a profile", result)
+	}
+}
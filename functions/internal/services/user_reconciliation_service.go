@@ -0,0 +1,409 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+	"google.golang.org/api/iterator"
+
+	"it-app_user/internal/audit"
+	"it-app_user/internal/logger"
+	"it-app_user/internal/metrics"
+	"it-app_user/internal/models"
+	"it-app_user/internal/repositories"
+	"it-app_user/pkg/firebase"
+)
+
+// Tipos de diferencia que encuentra la reconciliación
+const (
+	// ReconcileFieldMismatch: email, email_verified, disabled, foto o proveedor
+	// distintos; se corrigen con los valores de Firebase
+	ReconcileFieldMismatch = "field_mismatch"
+	// ReconcileDisabledLocally: deshabilitado solo en la base de datos. Solo se
+	// informa: no se sabe si lo habilitaron en la consola o lo deshabilitó un admin.
+	ReconcileDisabledLocally = "disabled_locally"
+	// ReconcileMissingLocal: existe en Firebase pero no localmente. Solo se
+	// informa: el usuario local lo crea el evento de Firebase Auth o su próximo login.
+	ReconcileMissingLocal = "missing_local"
+	// ReconcileMissingInFirebase: existe localmente pero se eliminó de
+	// Firebase; se corrige con un borrado lógico
+	ReconcileMissingInFirebase = "missing_in_firebase"
+)
+
+const (
+	// reconcilePageSize es el máximo de usuarios por página de ListUsers
+	reconcilePageSize = 1000
+	// reconcileLookupSize es el máximo de UIDs por llamada a GetUsers
+	reconcileLookupSize = 100
+	// maxReportedDifferences limita las diferencias incluidas en el reporte;
+	// todas quedan en los logs y en las métricas
+	maxReportedDifferences = 1000
+)
+
+// ReconcileDifference es una diferencia entre Firebase y la base de datos
+type ReconcileDifference struct {
+	Kind       string                        `json:"kind"`
+	FirebaseID string                        `json:"firebase_id"`
+	UserID     uint                          `json:"user_id,omitempty"`
+	Changes    map[string]models.AuditChange `json:"changes,omitempty"`
+	Fixed      bool                          `json:"fixed"`
+	Error      string                        `json:"error,omitempty"`
+}
+
+// ReconcileReport es el resultado de una ejecución de la reconciliación
+type ReconcileReport struct {
+	DryRun            bool      `json:"dry_run"`
+	StartedAt         time.Time `json:"started_at"`
+	FinishedAt        time.Time `json:"finished_at"`
+	FirebaseUsers     int       `json:"firebase_users"`
+	LocalUsers        int       `json:"local_users"`
+	Mismatched        int       `json:"mismatched"`
+	DisabledLocally   int       `json:"disabled_locally"`
+	MissingLocal      int       `json:"missing_local"`
+	MissingInFirebase int       `json:"missing_in_firebase"`
+	Fixed             int       `json:"fixed"`
+	Errors            int       `json:"errors"`
+	// Differences incluye hasta 1000 diferencias; Truncated indica si hubo más
+	Differences []ReconcileDifference `json:"differences"`
+	Truncated   bool                  `json:"truncated,omitempty"`
+}
+
+// UserPager recorre los usuarios de Firebase por páginas (ver iterator.Pager)
+type UserPager interface {
+	NextPage(slicep interface{}) (nextPageToken string, err error)
+}
+
+// FirebaseUserSource es la parte de Firebase Auth que lee la reconciliación
+type FirebaseUserSource interface {
+	// Pager devuelve un paginador sobre todos los usuarios del proyecto
+	Pager(ctx context.Context, pageSize int) UserPager
+	// GetUsers obtiene hasta 100 usuarios por UID; los que no existen vuelven en NotFound
+	GetUsers(ctx context.Context, uids []string) (*auth.GetUsersResult, error)
+}
+
+// firebaseUserSource lee los usuarios del proyecto de Firebase configurado
+type firebaseUserSource struct {
+	firebaseAuth *firebase.Auth
+}
+
+func (s firebaseUserSource) Pager(ctx context.Context, pageSize int) UserPager {
+	return iterator.NewPager(s.firebaseAuth.Users(ctx), pageSize, "")
+}
+
+func (s firebaseUserSource) GetUsers(ctx context.Context, uids []string) (*auth.GetUsersResult, error) {
+	return s.firebaseAuth.GetUsers(ctx, uids)
+}
+
+// UserReconciliationService compara los usuarios de Firebase Auth con los de
+// la base de datos y corrige o informa las diferencias. Firebase es la fuente
+// de verdad de los datos de autenticación.
+type UserReconciliationService struct {
+	userRepo     repositories.UserRepositoryInterface
+	users        FirebaseUserSource
+	deletion     *UserDeletionService
+	maxDeletions int
+}
+
+// NewUserReconciliationService crea el servicio. maxDeletions limita los
+// borrados lógicos por ejecución, para que un proyecto de Firebase mal
+// configurado no elimine a todos los usuarios.
+func NewUserReconciliationService(userRepo repositories.UserRepositoryInterface, firebaseAuth *firebase.Auth, deletion *UserDeletionService, maxDeletions int) *UserReconciliationService {
+	service := &UserReconciliationService{
+		userRepo:     userRepo,
+		deletion:     deletion,
+		maxDeletions: maxDeletions,
+	}
+	if firebaseAuth != nil {
+		service.users = firebaseUserSource{firebaseAuth: firebaseAuth}
+	}
+	return service
+}
+
+// SetUserSource reemplaza la fuente de usuarios de Firebase (p. ej. en pruebas)
+func (s *UserReconciliationService) SetUserSource(users FirebaseUserSource) {
+	s.users = users
+}
+
+// Run recorre primero los usuarios de Firebase (diferencias de campos y
+// usuarios sin fila local) y después los locales (usuarios eliminados de
+// Firebase). Con dryRun solo informa. Los usuarios con borrado lógico se
+// ignoran: su cuenta de Firebase está deshabilitada a propósito. Si falla a
+// mitad de camino devuelve el reporte parcial junto con el error.
+func (s *UserReconciliationService) Run(ctx context.Context, dryRun bool) (*ReconcileReport, error) {
+	if s.users == nil {
+		return nil, ErrFirebaseNotConfigured
+	}
+
+	report := &ReconcileReport{DryRun: dryRun, StartedAt: time.Now().UTC()}
+	err := s.reconcileFirebaseUsers(ctx, report)
+	if err == nil {
+		err = s.reconcileLocalUsers(ctx, report)
+	}
+	report.FinishedAt = time.Now().UTC()
+
+	logger.GetLogger().WithFields(map[string]interface{}{
+		"dry_run":             report.DryRun,
+		"firebase_users":      report.FirebaseUsers,
+		"local_users":         report.LocalUsers,
+		"mismatched":          report.Mismatched,
+		"disabled_locally":    report.DisabledLocally,
+		"missing_local":       report.MissingLocal,
+		"missing_in_firebase": report.MissingInFirebase,
+		"fixed":               report.Fixed,
+		"errors":              report.Errors,
+	}).Info("User reconciliation finished")
+
+	return report, err
+}
+
+// reconcileFirebaseUsers recorre Firebase por páginas y compara cada página
+// con las filas locales de esos UIDs
+func (s *UserReconciliationService) reconcileFirebaseUsers(ctx context.Context, report *ReconcileReport) error {
+	pager := s.users.Pager(ctx, reconcilePageSize)
+	for {
+		var page []*auth.ExportedUserRecord
+		token, err := pager.NextPage(&page)
+		if err != nil {
+			return err
+		}
+		if err := s.reconcilePage(ctx, page, report); err != nil {
+			return err
+		}
+		if token == "" {
+			return nil
+		}
+	}
+}
+
+func (s *UserReconciliationService) reconcilePage(ctx context.Context, page []*auth.ExportedUserRecord, report *ReconcileReport) error {
+	report.FirebaseUsers += len(page)
+	if len(page) == 0 {
+		return nil
+	}
+
+	uids := make([]string, len(page))
+	for i, record := range page {
+		uids[i] = record.UID
+	}
+	users, err := s.userRepo.ListByFirebaseIDs(ctx, uids)
+	if err != nil {
+		return err
+	}
+	byUID := make(map[string]*models.User, len(users))
+	for i := range users {
+		byUID[users[i].FirebaseID] = &users[i]
+	}
+
+	for _, record := range page {
+		user, ok := byUID[record.UID]
+		switch {
+		case !ok:
+			s.addDifference(report, ReconcileDifference{Kind: ReconcileMissingLocal, FirebaseID: record.UID})
+		case user.DeletedAt.Valid:
+			continue
+		default:
+			s.reconcileUser(ctx, user, record.UserRecord, report)
+		}
+	}
+	return nil
+}
+
+// reconcileUser copia al usuario local los datos de autenticación de Firebase
+func (s *UserReconciliationService) reconcileUser(ctx context.Context, user *models.User, record *auth.UserRecord, report *ReconcileReport) {
+	if user.Disabled && !record.Disabled {
+		s.addDifference(report, ReconcileDifference{
+			Kind:       ReconcileDisabledLocally,
+			FirebaseID: user.FirebaseID,
+			UserID:     user.ID,
+		})
+	}
+
+	before := *user
+	user.EmailVerified = record.EmailVerified
+	user.PhotoURL = record.PhotoURL
+	if record.Disabled {
+		user.Disabled = true
+	}
+	// Firebase guarda los emails en minúsculas; los usuarios sin email (p. ej.
+	// por teléfono) conservan el local
+	if record.Email != "" && !strings.EqualFold(record.Email, user.Email) {
+		user.Email = record.Email
+	}
+	// El proveedor local es el del registro: solo cambia si ya no está vinculado
+	if !hasProvider(record, user.Provider) {
+		if provider := primaryProvider(record); provider != "" {
+			user.Provider = provider
+		}
+	}
+
+	changes := audit.Diff(before, user)
+	if len(changes) == 0 {
+		return
+	}
+
+	diff := ReconcileDifference{
+		Kind:       ReconcileFieldMismatch,
+		FirebaseID: user.FirebaseID,
+		UserID:     user.ID,
+		Changes:    changes,
+	}
+	if !report.DryRun {
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			diff.Error = err.Error()
+		} else {
+			diff.Fixed = true
+			audit.Record(ctx, audit.Event{
+				Action:           audit.ActionUserUpdated,
+				ActorID:          audit.ActorSystem,
+				TargetUserID:     user.ID,
+				TargetFirebaseID: user.FirebaseID,
+				Changes:          changes,
+				Metadata:         map[string]interface{}{"source": "firebase_reconciliation"},
+			})
+		}
+	}
+	s.addDifference(report, diff)
+}
+
+// reconcileLocalUsers recorre los usuarios locales por lotes y busca los que
+// ya no existen en Firebase
+func (s *UserReconciliationService) reconcileLocalUsers(ctx context.Context, report *ReconcileReport) error {
+	deleted := 0
+	var afterID uint
+	for {
+		users, err := s.userRepo.ListAfterID(ctx, afterID, reconcileLookupSize)
+		if err != nil {
+			return err
+		}
+		if len(users) == 0 {
+			return nil
+		}
+		afterID = users[len(users)-1].ID
+		report.LocalUsers += len(users)
+
+		uids := make([]string, len(users))
+		for i, user := range users {
+			uids[i] = user.FirebaseID
+		}
+		result, err := s.users.GetUsers(ctx, uids)
+		if err != nil {
+			return err
+		}
+		missing := make(map[string]bool, len(result.NotFound))
+		for _, identifier := range result.NotFound {
+			if id, ok := identifier.(auth.UIDIdentifier); ok {
+				missing[id.UID] = true
+			}
+		}
+
+		for i := range users {
+			user := &users[i]
+			if !missing[user.FirebaseID] {
+				continue
+			}
+			diff := ReconcileDifference{
+				Kind:       ReconcileMissingInFirebase,
+				FirebaseID: user.FirebaseID,
+				UserID:     user.ID,
+			}
+			if !report.DryRun {
+				if deleted >= s.maxDeletions {
+					diff.Error = "deletion limit per run reached"
+				} else if deletedUser, err := s.deletion.SoftDelete(ctx, user.ID); err != nil {
+					diff.Error = err.Error()
+				} else {
+					deleted++
+					diff.Fixed = true
+					audit.Record(ctx, audit.Event{
+						Action:           audit.ActionUserDeleted,
+						ActorID:          audit.ActorSystem,
+						TargetUserID:     user.ID,
+						TargetFirebaseID: user.FirebaseID,
+						Metadata: map[string]interface{}{
+							"source":      "firebase_reconciliation",
+							"purge_after": s.deletion.PurgeAfter(deletedUser.DeletedAt.Time).UTC(),
+						},
+					})
+				}
+			}
+			s.addDifference(report, diff)
+		}
+	}
+}
+
+// addDifference cuenta la diferencia, la registra en el log y en las métricas
+// y la agrega al reporte. El log solo incluye los nombres de los campos: los
+// valores (emails) quedan en el reporte y en la auditoría.
+func (s *UserReconciliationService) addDifference(report *ReconcileReport, diff ReconcileDifference) {
+	switch diff.Kind {
+	case ReconcileFieldMismatch:
+		report.Mismatched++
+	case ReconcileDisabledLocally:
+		report.DisabledLocally++
+	case ReconcileMissingLocal:
+		report.MissingLocal++
+	case ReconcileMissingInFirebase:
+		report.MissingInFirebase++
+	}
+
+	result := "reported"
+	switch {
+	case diff.Fixed:
+		report.Fixed++
+		result = "fixed"
+	case diff.Error != "":
+		report.Errors++
+		result = "error"
+	}
+	metrics.UsersReconciled.WithLabelValues(diff.Kind, result).Inc()
+
+	fields := make([]string, 0, len(diff.Changes))
+	for field := range diff.Changes {
+		fields = append(fields, field)
+	}
+	entry := logger.GetLogger().WithFields(map[string]interface{}{
+		"kind":        diff.Kind,
+		"firebase_id": diff.FirebaseID,
+		"user_id":     diff.UserID,
+		"fields":      fields,
+		"fixed":       diff.Fixed,
+		"dry_run":     report.DryRun,
+	})
+	if diff.Error != "" {
+		entry.WithField("error", diff.Error).Error("Failed to fix user difference with Firebase")
+	} else {
+		entry.Warn("User differs from Firebase")
+	}
+
+	if len(report.Differences) < maxReportedDifferences {
+		report.Differences = append(report.Differences, diff)
+	} else {
+		report.Truncated = true
+	}
+}
+
+// hasProvider indica si el proveedor está vinculado a la cuenta de Firebase
+func hasProvider(record *auth.UserRecord, provider string) bool {
+	for _, info := range record.ProviderUserInfo {
+		if info.ProviderID == provider {
+			return true
+		}
+	}
+	return false
+}
+
+// primaryProvider elige el proveedor de la cuenta con la misma prioridad que
+// el login: Google, Facebook, contraseña y si no el primero vinculado
+func primaryProvider(record *auth.UserRecord) string {
+	for _, provider := range []string{"google.com", "facebook.com", "password"} {
+		if hasProvider(record, provider) {
+			return provider
+		}
+	}
+	if len(record.ProviderUserInfo) > 0 {
+		return record.ProviderUserInfo[0].ProviderID
+	}
+	return ""
+}
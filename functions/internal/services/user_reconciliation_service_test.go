@@ -0,0 +1,246 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/gorm"
+
+	"it-app_user/internal/dbtest"
+	"it-app_user/internal/models"
+	"it-app_user/internal/repositories"
+)
+
+// fakeUserPager devuelve las páginas en orden
+type fakeUserPager struct {
+	pages [][]*auth.ExportedUserRecord
+}
+
+func (p *fakeUserPager) NextPage(slicep interface{}) (string, error) {
+	page := slicep.(*[]*auth.ExportedUserRecord)
+	if len(p.pages) == 0 {
+		*page = nil
+		return "", nil
+	}
+	*page, p.pages = p.pages[0], p.pages[1:]
+	if len(p.pages) == 0 {
+		return "", nil
+	}
+	return "next", nil
+}
+
+// fakeUserSource simula los usuarios de un proyecto de Firebase
+type fakeUserSource struct {
+	pages [][]*auth.ExportedUserRecord
+}
+
+func (s *fakeUserSource) Pager(ctx context.Context, pageSize int) UserPager {
+	return &fakeUserPager{pages: s.pages}
+}
+
+func (s *fakeUserSource) GetUsers(ctx context.Context, uids []string) (*auth.GetUsersResult, error) {
+	exists := make(map[string]bool)
+	for _, page := range s.pages {
+		for _, record := range page {
+			exists[record.UID] = true
+		}
+	}
+	result := &auth.GetUsersResult{}
+	for _, uid := range uids {
+		if !exists[uid] {
+			result.NotFound = append(result.NotFound, auth.UIDIdentifier{UID: uid})
+		}
+	}
+	return result, nil
+}
+
+// fakeReconcileRepo guarda los usuarios locales en memoria y registra las actualizaciones
+type fakeReconcileRepo struct {
+	repositories.UserRepositoryInterface
+	users   []models.User
+	updated []models.User
+}
+
+func (r *fakeReconcileRepo) ListByFirebaseIDs(ctx context.Context, firebaseIDs []string) ([]models.User, error) {
+	var users []models.User
+	for _, user := range r.users {
+		for _, uid := range firebaseIDs {
+			if user.FirebaseID == uid {
+				users = append(users, user)
+			}
+		}
+	}
+	return users, nil
+}
+
+func (r *fakeReconcileRepo) ListAfterID(ctx context.Context, afterID uint, limit int) ([]models.User, error) {
+	var users []models.User
+	for _, user := range r.users {
+		if user.ID > afterID && !user.DeletedAt.Valid && len(users) < limit {
+			users = append(users, user)
+		}
+	}
+	return users, nil
+}
+
+func (r *fakeReconcileRepo) Update(ctx context.Context, user *models.User) error {
+	r.updated = append(r.updated, *user)
+	return nil
+}
+
+func firebaseRecord(uid, email string, verified, disabled bool, providers ...string) *auth.ExportedUserRecord {
+	record := &auth.UserRecord{
+		UserInfo:      &auth.UserInfo{UID: uid, Email: email},
+		EmailVerified: verified,
+		Disabled:      disabled,
+	}
+	for _, provider := range providers {
+		record.ProviderUserInfo = append(record.ProviderUserInfo, &auth.UserInfo{ProviderID: provider})
+	}
+	return &auth.ExportedUserRecord{UserRecord: record}
+}
+
+func TestReconcileWithoutFirebase(t *testing.T) {
+	service := NewUserReconciliationService(&fakeReconcileRepo{}, nil, nil, 10)
+	if _, err := service.Run(context.Background(), true); !errors.Is(err, ErrFirebaseNotConfigured) {
+		t.Fatalf("Run() = %v, want ErrFirebaseNotConfigured", err)
+	}
+}
+
+func TestReconcileFieldDifferences(t *testing.T) {
+	deletedAt := gorm.DeletedAt{Time: time.Now(), Valid: true}
+	newRepo := func() *fakeReconcileRepo {
+		return &fakeReconcileRepo{users: []models.User{
+			{ID: 1, FirebaseID: "uid-changed", Email: "ana@old.example.com", Provider: "password"},
+			{ID: 2, FirebaseID: "uid-disabled", Email: "bob@example.com", EmailVerified: true, Provider: "password", Disabled: true},
+			{ID: 3, FirebaseID: "uid-same", Email: "eve@example.com", EmailVerified: true, Provider: "google.com"},
+			{ID: 4, FirebaseID: "uid-deleted", Email: "dan@example.com", Provider: "password", DeletedAt: deletedAt},
+			{ID: 5, FirebaseID: "uid-unlinked", Email: "joe@example.com", EmailVerified: true, Provider: "facebook.com"},
+		}}
+	}
+	// Dos páginas para recorrer el paginador completo
+	source := &fakeUserSource{pages: [][]*auth.ExportedUserRecord{
+		{
+			firebaseRecord("uid-changed", "ana@example.com", true, false, "password"),
+			firebaseRecord("uid-disabled", "bob@example.com", true, false, "password"),
+			// Solo cambian las mayúsculas del email: no es una diferencia
+			firebaseRecord("uid-same", "Eve@Example.com", true, false, "google.com", "password"),
+		},
+		{
+			// El borrado lógico deshabilita la cuenta de Firebase a propósito
+			firebaseRecord("uid-deleted", "dan@example.com", false, true, "password"),
+			firebaseRecord("uid-unlinked", "joe@example.com", true, false, "password", "google.com"),
+			firebaseRecord("uid-new", "new@example.com", false, false, "password"),
+		},
+	}}
+
+	wantChanges := map[uint][]string{
+		1: {"email", "email_verified"},
+		5: {"provider"},
+	}
+
+	for _, dryRun := range []bool{true, false} {
+		repo := newRepo()
+		service := NewUserReconciliationService(repo, nil, nil, 10)
+		service.SetUserSource(source)
+
+		report, err := service.Run(context.Background(), dryRun)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if report.FirebaseUsers != 6 || report.LocalUsers != 4 {
+			t.Errorf("dry run %v: %d Firebase and %d local users, want 6 and 4", dryRun, report.FirebaseUsers, report.LocalUsers)
+		}
+		if report.Mismatched != 2 || report.DisabledLocally != 1 || report.MissingLocal != 1 || report.MissingInFirebase != 0 || report.Errors != 0 {
+			t.Errorf("dry run %v: report = %+v", dryRun, report)
+		}
+
+		for _, diff := range report.Differences {
+			if diff.Kind != ReconcileFieldMismatch {
+				continue
+			}
+			var fields []string
+			for field := range diff.Changes {
+				fields = append(fields, field)
+			}
+			sort.Strings(fields)
+			if !reflect.DeepEqual(fields, wantChanges[diff.UserID]) {
+				t.Errorf("dry run %v: user %d changed %v, want %v", dryRun, diff.UserID, fields, wantChanges[diff.UserID])
+			}
+			if diff.Fixed == dryRun {
+				t.Errorf("dry run %v: user %d fixed = %v", dryRun, diff.UserID, diff.Fixed)
+			}
+		}
+
+		// El modo de prueba solo informa; si no, se copian los datos de Firebase
+		if dryRun {
+			if len(repo.updated) != 0 || report.Fixed != 0 {
+				t.Errorf("dry run updated %d users", len(repo.updated))
+			}
+			continue
+		}
+		if len(repo.updated) != 2 || report.Fixed != 2 {
+			t.Fatalf("updated %d users, fixed %d, want 2", len(repo.updated), report.Fixed)
+		}
+		if user := repo.updated[0]; user.Email != "ana@example.com" || !user.EmailVerified {
+			t.Errorf("updated user = %+v, want Firebase's email and verification", user)
+		}
+		if user := repo.updated[1]; user.Provider != "google.com" {
+			t.Errorf("provider = %q, want google.com", user.Provider)
+		}
+	}
+}
+
+func TestReconcileMaxDeletions(t *testing.T) {
+	repo := &fakeReconcileRepo{users: []models.User{
+		{ID: 1, FirebaseID: "uid-1"},
+		{ID: 2, FirebaseID: "uid-2"},
+		{ID: 3, FirebaseID: "uid-3"},
+	}}
+
+	// En modo de prueba no se borra nada ni se consume el límite
+	db, mock := dbtest.NewMockDB(t)
+	deletion := NewUserDeletionService(repositories.NewUserRepository(db), repositories.NewTxManager(db), nil, testGracePeriod, 100)
+	service := NewUserReconciliationService(repo, nil, deletion, 1)
+	service.SetUserSource(&fakeUserSource{})
+
+	report, err := service.Run(context.Background(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.MissingInFirebase != 3 || report.Fixed != 0 || report.Errors != 0 {
+		t.Errorf("dry run report = %+v, want 3 missing users reported", report)
+	}
+
+	// Solo el primero se borra: el resto supera el límite por ejecución
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1 AND "users"."deleted_at" IS NULL`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "firebase_id"}).AddRow(1, "uid-1"))
+	mock.ExpectExec(`UPDATE "users" SET "deleted_at"=\$1 WHERE "users"."id" = \$2 AND "users"."deleted_at" IS NULL`).
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE deleted_at IS NOT NULL AND "users"."id" = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "firebase_id", "deleted_at"}).AddRow(1, "uid-1", time.Now()))
+	mock.ExpectCommit()
+
+	report, err = service.Run(context.Background(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.MissingInFirebase != 3 || report.Fixed != 1 || report.Errors != 2 {
+		t.Fatalf("report = %+v, want 1 deletion and 2 over the limit", report)
+	}
+	for _, diff := range report.Differences[1:] {
+		if diff.Error != "deletion limit per run reached" {
+			t.Errorf("user %d: error = %q, want the deletion limit", diff.UserID, diff.Error)
+		}
+	}
+}
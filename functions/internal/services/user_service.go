@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	"it-app_user/internal/models"
+	"it-app_user/internal/repositories"
+)
+
+var (
+	// ErrUserAlreadyExists indica que el email, username o Firebase ID ya están registrados
+	ErrUserAlreadyExists = errors.New("user already exists")
+	// ErrUsernameUnavailable indica que no se encontró un username libre para el usuario
+	ErrUsernameUnavailable = errors.New("no username available")
+)
+
+// UserService coordina las operaciones sobre usuarios que abarcan varias tablas
+type UserService struct {
+	txManager *repositories.TxManager
+	usernames *UsernameService
+}
+
+func NewUserService(txManager *repositories.TxManager, usernames *UsernameService) *UserService {
+	return &UserService{
+		txManager: txManager,
+		usernames: usernames,
+	}
+}
+
+// Signup crea el usuario junto con su perfil, configuraciones, estadísticas y
+// estado de verificación de email en una sola transacción: si algún paso
+// falla no queda ningún registro a medio crear.
+func (s *UserService) Signup(ctx context.Context, user *models.User) error {
+	var created models.User
+	err := s.txManager.WithinTransaction(ctx, func(ctx context.Context, repos *repositories.Repositories) error {
+		// Cada intento parte de una copia: un intento revertido no debe dejar el ID asignado
+		created = *user
+		if err := repos.Users.Create(ctx, &created); err != nil {
+			return err
+		}
+		return createDefaults(ctx, repos, &created)
+	})
+
+	if repositories.IsUniqueViolation(err) {
+		return ErrUserAlreadyExists
+	}
+	if err != nil {
+		return err
+	}
+
+	*user = created
+	return nil
+}
+
+// ProvisionOnLogin obtiene el usuario local de un login de Firebase, creándolo
+// (con sus registros por defecto) si es su primer login, y registra el login.
+// Es idempotente: los logins concurrentes del mismo usuario no crean
+// duplicados gracias a INSERT ... ON CONFLICT DO NOTHING. candidate.Username
+// es solo una sugerencia (p. ej. el nombre visible): se normaliza y, si está
+// reservado u ocupado, se usa una alternativa libre del UsernameService.
+// Devuelve el usuario y si fue creado en esta llamada.
+func (s *UserService) ProvisionOnLogin(ctx context.Context, candidate *models.User, loginIP, loginDevice string) (*models.User, bool, error) {
+	usernames, err := s.usernames.Candidates(ctx, candidate.Username, candidate.FirebaseID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var user *models.User
+	var created bool
+
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context, repos *repositories.Repositories) error {
+		user, created = nil, false
+
+		provisioned, inserted, err := provision(ctx, repos, candidate, usernames)
+		if err != nil {
+			return err
+		}
+
+		if err := repos.Users.UpdateLoginInfo(ctx, provisioned.ID, loginIP, loginDevice); err != nil {
+			return err
+		}
+		if err := repos.Stats.IncrementLoginCount(ctx, provisioned.ID); err != nil {
+			return err
+		}
+
+		// Releer para devolver el contador y la fecha de login actualizados
+		refreshed, err := repos.Users.GetByID(ctx, provisioned.ID)
+		if err != nil {
+			return err
+		}
+		user, created = refreshed, inserted
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return user, created, nil
+}
+
+// Provision obtiene el usuario local de una cuenta de Firebase, creándolo con
+// sus registros por defecto si no existe, sin registrar un login (p. ej. al
+// recibir el evento de creación de Firebase Auth). Es idempotente como
+// ProvisionOnLogin y devuelve los mismos errores.
+func (s *UserService) Provision(ctx context.Context, candidate *models.User) (*models.User, bool, error) {
+	usernames, err := s.usernames.Candidates(ctx, candidate.Username, candidate.FirebaseID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var user *models.User
+	var created bool
+
+	err = s.txManager.WithinTransaction(ctx, func(ctx context.Context, repos *repositories.Repositories) error {
+		var err error
+		user, created, err = provision(ctx, repos, candidate, usernames)
+		return err
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	return user, created, nil
+}
+
+// provision inserta el usuario con el primer username libre de usernames o,
+// si ya existe su cuenta de Firebase, devuelve el existente. Devuelve
+// ErrUserDeleted si la cuenta tiene un borrado lógico y ErrUserAlreadyExists
+// si el email pertenece a otra cuenta.
+func provision(ctx context.Context, repos *repositories.Repositories, candidate *models.User, usernames []string) (*models.User, bool, error) {
+	for _, username := range usernames {
+		u := *candidate
+		u.ID = 0
+		u.Username = username
+
+		inserted, err := repos.Users.CreateIfNotExists(ctx, &u)
+		if err != nil {
+			return nil, false, err
+		}
+		if inserted {
+			if err := createDefaults(ctx, repos, &u); err != nil {
+				return nil, false, err
+			}
+			return &u, true, nil
+		}
+
+		// No se insertó: averiguar qué índice único chocó
+		existing, err := repos.Users.GetByFirebaseID(ctx, candidate.FirebaseID)
+		if err == nil {
+			return existing, false, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, err
+		}
+		// La cuenta está eliminada y pendiente de purga: no se recrea ni se reactiva
+		if _, err := repos.Users.GetDeletedByFirebaseID(ctx, candidate.FirebaseID); err == nil {
+			return nil, false, ErrUserDeleted
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, err
+		}
+		if _, err := repos.Users.GetByEmail(ctx, candidate.Email); err == nil {
+			// El email pertenece a otra cuenta de Firebase
+			return nil, false, ErrUserAlreadyExists
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, err
+		}
+		// Otro usuario ocupó el username desde la consulta: probar el siguiente
+	}
+
+	return nil, false, ErrUsernameUnavailable
+}
+
+// createDefaults crea el perfil, las configuraciones, las estadísticas y el
+// estado de verificación de email de un usuario recién creado
+func createDefaults(ctx context.Context, repos *repositories.Repositories, user *models.User) error {
+	// Las columnas jsonb no aceptan cadenas vacías
+	if err := repos.Profiles.Create(ctx, &models.UserProfile{
+		UserID:      user.ID,
+		Avatar:      user.PhotoURL,
+		Preferences: "{}",
+		Privacy:     "{}",
+	}); err != nil {
+		return err
+	}
+
+	if err := repos.Settings.Create(ctx, &models.UserSettings{
+		UserID:        user.ID,
+		Notifications: "{}",
+		Privacy:       "{}",
+		Security:      "{}",
+	}); err != nil {
+		return err
+	}
+
+	if err := repos.Stats.Create(ctx, &models.UserStats{
+		UserID:   user.ID,
+		IsActive: true,
+	}); err != nil {
+		return err
+	}
+
+	verification := &models.EmailVerification{
+		UserID:     user.ID,
+		FirebaseID: user.FirebaseID,
+		Email:      user.Email,
+		IsVerified: user.EmailVerified,
+	}
+	if user.EmailVerified {
+		now := time.Now()
+		verification.VerifiedAt = &now
+	}
+	return repos.EmailVerifications.Create(ctx, verification)
+}
@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"it-app_user/internal/dbtest"
+	"it-app_user/internal/models"
+	"it-app_user/internal/repositories"
+)
+
+// newTestUserService crea un UserService sobre una base de datos simulada
+func newTestUserService(t *testing.T) (*UserService, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock := dbtest.NewMockDB(t)
+	repos := repositories.NewRepositories(db)
+	return NewUserService(repositories.NewTxManager(db), NewUsernameService(repos.Users)), mock
+}
+
+func userRows(id int, firebaseID, username string) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "firebase_id", "email", "username", "login_count"}).
+		AddRow(id, firebaseID, "ana@example.com", username, 3)
+}
+
+func noRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id"})
+}
+
+// expectUsernamesAvailable simula que ninguno de los candidatos está registrado
+func expectUsernamesAvailable(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`SELECT "username_canonical" FROM "users" WHERE username_canonical IN`).
+		WillReturnRows(sqlmock.NewRows([]string{"username_canonical"}))
+}
+
+// expectLogin simula el registro del login y la relectura del usuario
+func expectLogin(mock sqlmock.Sqlmock, id int, username string) {
+	mock.ExpectExec(`UPDATE "users" SET .*"last_login_ip"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "user_stats" SET .*"login_count"=login_count \+ 1`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE "users"."id" = \$1`).
+		WithArgs(id).
+		WillReturnRows(userRows(id, "uid-ana", username))
+}
+
+func TestProvisionOnLoginExistingUser(t *testing.T) {
+	service, mock := newTestUserService(t)
+
+	expectUsernamesAvailable(mock)
+	mock.ExpectBegin()
+	// El INSERT ... ON CONFLICT DO NOTHING no inserta nada: la cuenta ya existe
+	mock.ExpectQuery(`INSERT INTO "users" .* ON CONFLICT DO NOTHING RETURNING`).WillReturnRows(noRows())
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE firebase_id = \$1`).
+		WithArgs("uid-ana").
+		WillReturnRows(userRows(7, "uid-ana", "ana"))
+	expectLogin(mock, 7, "ana")
+	mock.ExpectCommit()
+
+	user, created, err := service.ProvisionOnLogin(context.Background(),
+		&models.User{FirebaseID: "uid-ana", Email: "ana@example.com", Username: "Ana"}, "203.0.113.7", "Firefox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created || user.ID != 7 || user.Username != "ana" || user.LoginCount != 3 {
+		t.Errorf("user = %+v, created = %v, want the existing user 7", user, created)
+	}
+}
+
+func TestProvisionOnLoginNewUser(t *testing.T) {
+	service, mock := newTestUserService(t)
+
+	expectUsernamesAvailable(mock)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "users" .* ON CONFLICT DO NOTHING RETURNING`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(8))
+	// Registros por defecto del usuario nuevo
+	mock.ExpectQuery(`INSERT INTO "user_profiles"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`INSERT INTO "user_settings"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`INSERT INTO "user_stats"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`INSERT INTO "email_verifications"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	expectLogin(mock, 8, "josperez")
+	mock.ExpectCommit()
+
+	user, created, err := service.ProvisionOnLogin(context.Background(),
+		&models.User{FirebaseID: "uid-ana", Email: "ana@example.com", Username: "Jos Pérez"}, "203.0.113.7", "Firefox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created || user.ID != 8 {
+		t.Errorf("user = %+v, created = %v, want the new user 8", user, created)
+	}
+}
+
+func TestProvisionOnLoginUsernameTaken(t *testing.T) {
+	service, mock := newTestUserService(t)
+
+	expectUsernamesAvailable(mock)
+	mock.ExpectBegin()
+	// Otro usuario ocupó el username entre la consulta y el INSERT
+	mock.ExpectQuery(`INSERT INTO "users" .* ON CONFLICT DO NOTHING RETURNING`).WillReturnRows(noRows())
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE firebase_id = \$1`).WillReturnRows(noRows())
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE firebase_id = \$1 AND deleted_at IS NOT NULL`).WillReturnRows(noRows())
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE email_canonical = \$1`).WillReturnRows(noRows())
+	mock.ExpectQuery(`INSERT INTO "users" .* ON CONFLICT DO NOTHING RETURNING`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(9))
+	mock.ExpectQuery(`INSERT INTO "user_profiles"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`INSERT INTO "user_settings"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`INSERT INTO "user_stats"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectQuery(`INSERT INTO "email_verifications"`).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	expectLogin(mock, 9, "ana1")
+	mock.ExpectCommit()
+
+	user, created, err := service.ProvisionOnLogin(context.Background(),
+		&models.User{FirebaseID: "uid-ana", Email: "ana@example.com", Username: "ana"}, "203.0.113.7", "Firefox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !created || user.ID != 9 {
+		t.Errorf("user = %+v, created = %v, want a new user with the next username", user, created)
+	}
+}
+
+func TestProvisionOnLoginEmailOfAnotherAccount(t *testing.T) {
+	service, mock := newTestUserService(t)
+
+	expectUsernamesAvailable(mock)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "users" .* ON CONFLICT DO NOTHING RETURNING`).WillReturnRows(noRows())
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE firebase_id = \$1`).WillReturnRows(noRows())
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE firebase_id = \$1 AND deleted_at IS NOT NULL`).WillReturnRows(noRows())
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE email_canonical = \$1`).
+		WillReturnRows(userRows(3, "uid-other", "ana"))
+	mock.ExpectRollback()
+
+	_, _, err := service.ProvisionOnLogin(context.Background(),
+		&models.User{FirebaseID: "uid-ana", Email: "ana@example.com", Username: "ana"}, "203.0.113.7", "Firefox")
+	if !errors.Is(err, ErrUserAlreadyExists) {
+		t.Fatalf("err = %v, want ErrUserAlreadyExists", err)
+	}
+}
+
+func TestProvisionOnLoginDeletedAccount(t *testing.T) {
+	service, mock := newTestUserService(t)
+
+	expectUsernamesAvailable(mock)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "users" .* ON CONFLICT DO NOTHING RETURNING`).WillReturnRows(noRows())
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE firebase_id = \$1`).WillReturnRows(noRows())
+	// La cuenta tiene un borrado lógico pendiente de purga: no se recrea
+	mock.ExpectQuery(`SELECT \* FROM "users" WHERE firebase_id = \$1 AND deleted_at IS NOT NULL`).
+		WillReturnRows(userRows(7, "uid-ana", "ana"))
+	mock.ExpectRollback()
+
+	_, _, err := service.ProvisionOnLogin(context.Background(),
+		&models.User{FirebaseID: "uid-ana", Email: "ana@example.com", Username: "ana"}, "203.0.113.7", "Firefox")
+	if !errors.Is(err, ErrUserDeleted) {
+		t.Fatalf("err = %v, want ErrUserDeleted", err)
+	}
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+
+	"it-app_user/internal/models"
+	"it-app_user/internal/repositories"
+)
+
+// Límites de longitud de username (iguales a la validación de CreateUserRequest)
+const (
+	UsernameMinLength = 3
+	UsernameMaxLength = 50
+)
+
+// Motivos por los que un username no está disponible
+const (
+	UsernameInvalidLength     = "invalid_length"
+	UsernameInvalidCharacters = "invalid_characters"
+	UsernameReserved          = "reserved"
+	UsernameTaken             = "taken"
+)
+
+// reservedUsernames no pueden registrarse porque se confunden con rutas,
+// roles o cuentas del sistema
+var reservedUsernames = map[string]bool{
+	"admin": true, "administrator": true, "root": true, "system": true, "sysadmin": true,
+	"superuser": true, "moderator": true, "mod": true, "staff": true, "official": true,
+	"support": true, "help": true, "info": true, "contact": true, "security": true,
+	"abuse": true, "postmaster": true, "webmaster": true, "hostmaster": true, "noreply": true,
+	"api": true, "www": true, "mail": true, "email": true, "auth": true,
+	"login": true, "logout": true, "signup": true, "signin": true, "register": true,
+	"me": true, "self": true, "user": true, "users": true, "account": true,
+	"settings": true, "profile": true, "password": true, "token": true, "tokens": true,
+	"null": true, "undefined": true, "anonymous": true, "guest": true, "test": true,
+	"innovatech": true, "itapp": true,
+}
+
+// UsernameCheck es el resultado de comprobar un username
+type UsernameCheck struct {
+	Username    string   `json:"username"`
+	Normalized  string   `json:"normalized"`
+	Available   bool     `json:"available"`
+	Reason      string   `json:"reason,omitempty"`
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// UsernameService normaliza, valida y sugiere usernames libres
+type UsernameService struct {
+	userRepo repositories.UserRepositoryInterface
+}
+
+func NewUsernameService(userRepo repositories.UserRepositoryInterface) *UsernameService {
+	return &UsernameService{
+		userRepo: userRepo,
+	}
+}
+
+// NormalizeUsername pliega el texto a ASCII ("José Pérez" → "joseperez"),
+// lo pasa a minúsculas, descarta todo lo que no sea letra o dígito y lo recorta
+// a UsernameMaxLength
+func NormalizeUsername(raw string) string {
+	var b strings.Builder
+	// NFKD separa las letras de sus acentos (é → e + ´) y los descarta el filtro de abajo
+	for _, r := range norm.NFKD.String(raw) {
+		r = unicode.ToLower(r)
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == 'ß':
+			b.WriteString("ss")
+		case r == 'æ':
+			b.WriteString("ae")
+		case r == 'ø':
+			b.WriteRune('o')
+		case r == 'ł':
+			b.WriteRune('l')
+		}
+	}
+	username := b.String()
+	if len(username) > UsernameMaxLength {
+		username = username[:UsernameMaxLength]
+	}
+	return username
+}
+
+// IsReservedUsername indica si el username (normalizado) está reservado.
+// También lo están las variantes con sufijo numérico ("admin1", "support24").
+func IsReservedUsername(username string) bool {
+	return reservedUsernames[strings.TrimRight(NormalizeUsername(username), "0123456789")]
+}
+
+// validateUsername devuelve el motivo por el que un username no es válido, o ""
+func validateUsername(username string) string {
+	if len(username) < UsernameMinLength || len(username) > UsernameMaxLength {
+		return UsernameInvalidLength
+	}
+	for _, r := range username {
+		if !(r >= 'a' && r <= 'z') && !(r >= 'A' && r <= 'Z') && !(r >= '0' && r <= '9') {
+			return UsernameInvalidCharacters
+		}
+	}
+	if IsReservedUsername(username) {
+		return UsernameReserved
+	}
+	return ""
+}
+
+// Check indica si el username puede registrarse tal cual. Si no puede,
+// incluye alternativas libres basadas en su forma normalizada.
+func (s *UsernameService) Check(ctx context.Context, username string) (*UsernameCheck, error) {
+	check := &UsernameCheck{
+		Username:   username,
+		Normalized: NormalizeUsername(username),
+		Reason:     validateUsername(username),
+	}
+
+	if check.Reason == "" {
+		taken, err := s.userRepo.ExistingUsernames(ctx, []string{username})
+		if err != nil {
+			return nil, err
+		}
+		if len(taken) > 0 {
+			check.Reason = UsernameTaken
+		}
+	}
+
+	check.Available = check.Reason == ""
+	if !check.Available {
+		suggestions, err := s.Suggest(ctx, username, 5)
+		if err != nil {
+			return nil, err
+		}
+		check.Suggestions = suggestions
+	}
+
+	return check, nil
+}
+
+// Suggest devuelve hasta n usernames libres, válidos y no reservados
+// derivados de base
+func (s *UsernameService) Suggest(ctx context.Context, base string, n int) ([]string, error) {
+	candidates := usernameVariants(NormalizeUsername(base), 3*n)
+	return s.filterAvailable(ctx, candidates, n)
+}
+
+// Candidates devuelve, en orden de preferencia, usernames libres para
+// aprovisionar un usuario: el sugerido (normalizado), variantes con sufijo
+// numérico y, por último, uno derivado del Firebase UID. Entre la consulta y el
+// INSERT otro usuario puede ocupar alguno, así que el llamador debe seguir
+// probando el siguiente ante un conflicto.
+func (s *UsernameService) Candidates(ctx context.Context, suggested, firebaseID string) ([]string, error) {
+	fallback := "user" + NormalizeUsername(firebaseID)
+	if len(fallback) > UsernameMaxLength {
+		fallback = fallback[:UsernameMaxLength]
+	}
+
+	base := NormalizeUsername(suggested)
+	if validateUsername(base) != "" {
+		base = fallback
+	}
+
+	candidates := usernameVariants(base, 10)
+	if base != fallback {
+		candidates = append(candidates, fallback)
+	}
+	available, err := s.filterAvailable(ctx, candidates, 5)
+	if err != nil {
+		return nil, err
+	}
+
+	// Último recurso si todo lo anterior está ocupado
+	if len(available) == 0 {
+		available = append(available, fmt.Sprintf("%s%06d", truncate(fallback, UsernameMaxLength-6), rand.Intn(1000000)))
+	}
+	return available, nil
+}
+
+// filterAvailable descarta los candidatos inválidos, reservados o ya
+// registrados (en una sola consulta) y devuelve como máximo n
+func (s *UsernameService) filterAvailable(ctx context.Context, candidates []string, n int) ([]string, error) {
+	valid := make([]string, 0, len(candidates))
+	seen := make(map[string]bool)
+	for _, c := range candidates {
+		if !seen[c] && validateUsername(c) == "" {
+			seen[c] = true
+			valid = append(valid, c)
+		}
+	}
+	if len(valid) == 0 {
+		return nil, nil
+	}
+
+	taken, err := s.userRepo.ExistingUsernames(ctx, valid)
+	if err != nil {
+		return nil, err
+	}
+	takenSet := make(map[string]bool, len(taken))
+	for _, t := range taken {
+		takenSet[t] = true
+	}
+
+	available := make([]string, 0, n)
+	for _, c := range valid {
+		if len(available) == n {
+			break
+		}
+		if !takenSet[models.CanonicalUsername(c)] {
+			available = append(available, c)
+		}
+	}
+	return available, nil
+}
+
+// usernameVariants devuelve base seguido de count variantes con sufijo numérico
+func usernameVariants(base string, count int) []string {
+	if len(base) < UsernameMinLength {
+		base = "user" + base
+	}
+	variants := []string{base}
+	stem := truncate(base, UsernameMaxLength-4)
+	for i := 0; i < count; i++ {
+		// Primero sufijos cortos y luego aleatorios, para no probar siempre los mismos
+		suffix := fmt.Sprintf("%d", i+1)
+		if i >= 3 {
+			suffix = fmt.Sprintf("%04d", rand.Intn(10000))
+		}
+		variants = append(variants, stem+suffix)
+	}
+	return variants
+}
+
+func truncate(value string, max int) string {
+	if len(value) > max {
+		return value[:max]
+	}
+	return value
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"it-app_user/internal/repositories"
+)
+
+// takenUsernameRepo simula los usernames ya registrados (en minúsculas); con
+// all simula que todos lo están
+type takenUsernameRepo struct {
+	repositories.UserRepositoryInterface
+	taken   map[string]bool
+	all     bool
+	queries int
+}
+
+func (r *takenUsernameRepo) ExistingUsernames(ctx context.Context, usernames []string) ([]string, error) {
+	r.queries++
+	var existing []string
+	for _, u := range usernames {
+		if r.all || r.taken[strings.ToLower(u)] {
+			existing = append(existing, strings.ToLower(u))
+		}
+	}
+	return existing, nil
+}
+
+func newTakenUsernameRepo(taken ...string) *takenUsernameRepo {
+	repo := &takenUsernameRepo{taken: make(map[string]bool)}
+	for _, u := range taken {
+		repo.taken[u] = true
+	}
+	return repo
+}
+
+func TestNormalizeUsername(t *testing.T) {
+	tests := map[string]string{
+		"Ana":                   "ana",
+		"José Pérez":            "joseperez",
+		"jürgen.straße":         "jurgenstrasse",
+		"Søren_Łukasz":          "sorenlukasz",
+		"ana-maría_2024!":       "anamaria2024",
+		"李小龙":                   "",
+		strings.Repeat("a", 60): strings.Repeat("a", UsernameMaxLength),
+	}
+	for in, want := range tests {
+		if got := NormalizeUsername(in); got != want {
+			t.Errorf("NormalizeUsername(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestIsReservedUsername(t *testing.T) {
+	tests := map[string]bool{
+		"admin":     true,
+		"Admin":     true,
+		"admin1":    true,
+		"support24": true,
+		"ad.min":    true,
+		"adminana":  false,
+		"ana":       false,
+	}
+	for username, want := range tests {
+		if got := IsReservedUsername(username); got != want {
+			t.Errorf("IsReservedUsername(%q) = %v, want %v", username, got, want)
+		}
+	}
+}
+
+func TestUsernameCheck(t *testing.T) {
+	service := NewUsernameService(newTakenUsernameRepo("ana", "ana1"))
+
+	tests := []struct {
+		username string
+		reason   string
+	}{
+		{username: "bob"},
+		{username: "Bob2"},
+		{username: "ab", reason: UsernameInvalidLength},
+		{username: strings.Repeat("b", UsernameMaxLength+1), reason: UsernameInvalidLength},
+		{username: "ana.maria", reason: UsernameInvalidCharacters},
+		{username: "josé", reason: UsernameInvalidCharacters},
+		{username: "admin", reason: UsernameReserved},
+		{username: "ana", reason: UsernameTaken},
+		{username: "ANA", reason: UsernameTaken},
+	}
+	for _, tt := range tests {
+		check, err := service.Check(context.Background(), tt.username)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if check.Reason != tt.reason || check.Available != (tt.reason == "") {
+			t.Errorf("Check(%q) = available %v, reason %q, want reason %q", tt.username, check.Available, check.Reason, tt.reason)
+		}
+		if check.Available && len(check.Suggestions) > 0 {
+			t.Errorf("Check(%q) suggested %v for an available username", tt.username, check.Suggestions)
+		}
+		if check.Reason == UsernameTaken && len(check.Suggestions) == 0 {
+			t.Errorf("Check(%q) has no suggestions", tt.username)
+		}
+	}
+}
+
+func TestUsernameSuggest(t *testing.T) {
+	service := NewUsernameService(newTakenUsernameRepo("ana", "ana1", "ana3"))
+
+	suggestions, err := service.Suggest(context.Background(), "Ana", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Las sugerencias libres más cortas van primero
+	if len(suggestions) != 2 || suggestions[0] != "ana2" {
+		t.Errorf("Suggest = %v, want 2 free suggestions starting with ana2", suggestions)
+	}
+	for _, s := range suggestions {
+		if validateUsername(s) != "" || s == "ana1" || s == "ana3" {
+			t.Errorf("suggestion %q is not available", s)
+		}
+	}
+
+	// Los usernames reservados no se sugieren ni con sufijo
+	suggestions, err = service.Suggest(context.Background(), "admin", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(suggestions) != 0 {
+		t.Errorf("Suggest(admin) = %v, want none", suggestions)
+	}
+}
+
+func TestUsernameCandidates(t *testing.T) {
+	tests := []struct {
+		name      string
+		repo      *takenUsernameRepo
+		suggested string
+		first     string
+	}{
+		{name: "suggested name", repo: newTakenUsernameRepo(), suggested: "José Pérez", first: "joseperez"},
+		{name: "suggested name taken", repo: newTakenUsernameRepo("joseperez"), suggested: "José Pérez", first: "joseperez1"},
+		{name: "reserved name", repo: newTakenUsernameRepo(), suggested: "Admin", first: "useruid42"},
+		{name: "too short", repo: newTakenUsernameRepo(), suggested: "J.", first: "useruid42"},
+	}
+	for _, tt := range tests {
+		repo := tt.repo
+		candidates, err := NewUsernameService(repo).Candidates(context.Background(), tt.suggested, "UID-42")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(candidates) == 0 || candidates[0] != tt.first {
+			t.Errorf("%s: candidates = %v, want %q first", tt.name, candidates, tt.first)
+			continue
+		}
+		if len(candidates) > 5 {
+			t.Errorf("%s: %d candidates, want at most 5", tt.name, len(candidates))
+		}
+		// Se consulta la disponibilidad de todos los candidatos de una vez
+		if repo.queries != 1 {
+			t.Errorf("%s: %d availability queries, want 1", tt.name, repo.queries)
+		}
+	}
+}
+
+func TestUsernameCandidatesAllTaken(t *testing.T) {
+	candidates, err := NewUsernameService(&takenUsernameRepo{all: true}).Candidates(context.Background(), "ana", "UID-42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Como último recurso se usa el Firebase UID con un sufijo aleatorio
+	if len(candidates) != 1 || len(candidates[0]) != len("useruid42")+6 || !strings.HasPrefix(candidates[0], "useruid42") {
+		t.Errorf("candidates = %v, want a single useruid42 variant", candidates)
+	}
+}
@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	gcs "cloud.google.com/go/storage"
+)
+
+// GCSStore guarda los archivos en un bucket de Cloud Storage. Las URLs
+// firmadas requieren que la cuenta de servicio pueda firmar (clave propia o
+// el rol iam.serviceAccountTokenCreator sobre sí misma).
+type GCSStore struct {
+	client *gcs.Client
+	bucket string
+}
+
+// NewGCSStore crea el cliente con las credenciales por defecto del entorno
+func NewGCSStore(ctx context.Context, bucket string) (*GCSStore, error) {
+	client, err := gcs.NewClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &GCSStore{client: client, bucket: bucket}, nil
+}
+
+// Put sube el archivo. Si la lectura falla cancela el contexto del writer
+// para abortar la subida: cerrarlo sin cancelar finalizaría el objeto truncado.
+func (s *GCSStore) Put(ctx context.Context, key string, r io.Reader, contentType string) (int64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	writer := s.client.Bucket(s.bucket).Object(key).NewWriter(ctx)
+	writer.ContentType = contentType
+	n, err := io.Copy(writer, r)
+	if err != nil {
+		cancel()
+		writer.Close()
+		return 0, err
+	}
+	return n, writer.Close()
+}
+
+func (s *GCSStore) Delete(ctx context.Context, key string) error {
+	err := s.client.Bucket(s.bucket).Object(key).Delete(ctx)
+	if errors.Is(err, gcs.ErrObjectNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *GCSStore) SignedURL(ctx context.Context, key string, expires time.Time) (string, error) {
+	return s.client.Bucket(s.bucket).SignedURL(key, &gcs.SignedURLOptions{
+		Scheme:  gcs.SigningSchemeV4,
+		Method:  http.MethodGet,
+		Expires: expires,
+	})
+}
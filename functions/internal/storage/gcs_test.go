@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	gcs "cloud.google.com/go/storage"
+	"google.golang.org/api/option"
+)
+
+// failingReader entrega una parte del archivo y después falla, como una
+// exportación que se corta a mitad de camino
+type failingReader struct {
+	data io.Reader
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	n, err := r.data.Read(p)
+	if err == io.EOF {
+		return n, errors.New("export failed")
+	}
+	return n, err
+}
+
+func TestGCSStorePutAbortsOnReadError(t *testing.T) {
+	// Cuenta los objetos que Cloud Storage llegó a crear
+	var uploads atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			return
+		}
+		uploads.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"bucket":"exports","name":"user-5.json"}`)
+	}))
+	t.Cleanup(server.Close)
+
+	ctx := context.Background()
+	client, err := gcs.NewClient(ctx, option.WithEndpoint(server.URL+"/storage/v1/"), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &GCSStore{client: client, bucket: "exports"}
+
+	if _, err := store.Put(ctx, "user-5.json", &failingReader{data: strings.NewReader(`{"user":`)}, "application/json"); err == nil {
+		t.Fatal("Put() = nil, want the read error")
+	}
+	if n := uploads.Load(); n != 0 {
+		t.Errorf("uploads = %d, want the truncated object to be discarded", n)
+	}
+}
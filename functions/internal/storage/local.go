@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalDownloadPath es la ruta que sirve las descargas firmadas de LocalStore
+const LocalDownloadPath = "/files/download"
+
+// LocalStore guarda los archivos en un directorio local y los sirve en
+// LocalDownloadPath con una firma HMAC que expira. Pensado para desarrollo:
+// en Cloud Run/Functions el disco es efímero y no se comparte entre instancias.
+type LocalStore struct {
+	dir       string
+	publicURL string
+	secret    []byte
+
+	// OnDownload se llama antes de servir una descarga válida (p. ej. para
+	// auditarla); si devuelve un error la descarga responde 404
+	OnDownload func(r *http.Request, key string) error
+}
+
+// NewLocalStore crea el directorio si no existe. publicURL es la URL base del
+// servicio con la que se construyen los links de descarga.
+func NewLocalStore(dir, publicURL string, secret []byte) (*LocalStore, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("local storage requires a signing secret")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create storage directory: %w", err)
+	}
+	return &LocalStore{
+		dir:       dir,
+		publicURL: strings.TrimRight(publicURL, "/"),
+		secret:    secret,
+	}, nil
+}
+
+// path resuelve key dentro del directorio sin permitir salir de él
+func (s *LocalStore) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "..") {
+		return "", fmt.Errorf("invalid key %q", key)
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(clean)), nil
+}
+
+func (s *LocalStore) Put(ctx context.Context, key string, r io.Reader, contentType string) (int64, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return 0, err
+	}
+
+	// Escribir en un temporal y renombrar para no servir archivos a medio escribir
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+	return n, os.Rename(tmp.Name(), path)
+}
+
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStore) SignedURL(ctx context.Context, key string, expires time.Time) (string, error) {
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	query := url.Values{
+		"key":     {key},
+		"expires": {exp},
+		"sig":     {s.sign(key, exp)},
+	}
+	return s.publicURL + LocalDownloadPath + "?" + query.Encode(), nil
+}
+
+func (s *LocalStore) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ServeHTTP maneja GET LocalDownloadPath: valida la firma y la expiración y sirve el archivo
+func (s *LocalStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	key, exp, sig := query.Get("key"), query.Get("expires"), query.Get("sig")
+
+	expires, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || !hmac.Equal([]byte(sig), []byte(s.sign(key, exp))) {
+		http.Error(w, "Invalid signature", http.StatusForbidden)
+		return
+	}
+	if time.Now().Unix() > expires {
+		http.Error(w, "Link expired", http.StatusGone)
+		return
+	}
+
+	path, err := s.path(key)
+	if err != nil {
+		http.Error(w, "Invalid signature", http.StatusForbidden)
+		return
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		http.Error(w, "File not found", http.StatusNotFound)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		http.Error(w, "File not found", http.StatusNotFound)
+		return
+	}
+
+	if s.OnDownload != nil {
+		if err := s.OnDownload(r, key); err != nil {
+			http.Error(w, "File not found", http.StatusNotFound)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(path)))
+	w.Header().Set("Cache-Control", "private, no-store")
+	http.ServeContent(w, r, filepath.Base(path), info.ModTime(), file)
+}
@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLocalStore(t *testing.T) *LocalStore {
+	t.Helper()
+	store, err := NewLocalStore(t.TempDir(), "https://api.example.com/", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+// download sirve el link firmado con el handler del almacenamiento
+func download(store *LocalStore, link string) *httptest.ResponseRecorder {
+	u, _ := url.Parse(link)
+	w := httptest.NewRecorder()
+	store.ServeHTTP(w, httptest.NewRequest(http.MethodGet, u.RequestURI(), nil))
+	return w
+}
+
+func TestNewLocalStoreRequiresSecret(t *testing.T) {
+	if _, err := NewLocalStore(t.TempDir(), "", nil); err == nil {
+		t.Fatal("NewLocalStore accepted an empty signing secret")
+	}
+}
+
+func TestLocalStoreDownload(t *testing.T) {
+	store := newTestLocalStore(t)
+	var downloaded string
+	store.OnDownload = func(r *http.Request, key string) error {
+		downloaded = key
+		return nil
+	}
+
+	ctx := context.Background()
+	key := "data-exports/5/data-export-9.zip"
+	size, err := store.Put(ctx, key, strings.NewReader("zip data"), "application/zip")
+	if err != nil || size != int64(len("zip data")) {
+		t.Fatalf("Put() = %d, %v", size, err)
+	}
+
+	link, err := store.SignedURL(ctx, key, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(link, "https://api.example.com"+LocalDownloadPath+"?") {
+		t.Errorf("link = %q", link)
+	}
+
+	w := download(store, link)
+	if w.Code != http.StatusOK || w.Body.String() != "zip data" {
+		t.Fatalf("download = %d %q, want 200 with the file", w.Code, w.Body.String())
+	}
+	if disposition := w.Header().Get("Content-Disposition"); disposition != `attachment; filename="data-export-9.zip"` {
+		t.Errorf("Content-Disposition = %q", disposition)
+	}
+	if downloaded != key {
+		t.Errorf("OnDownload key = %q, want %q", downloaded, key)
+	}
+
+	// Tras borrar el archivo el link sigue siendo válido pero no hay nada que servir
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Errorf("deleting a missing file: %v", err)
+	}
+	if w := download(store, link); w.Code != http.StatusNotFound {
+		t.Errorf("download after delete = %d, want 404", w.Code)
+	}
+}
+
+func TestLocalStoreDownloadRejectedByHook(t *testing.T) {
+	store := newTestLocalStore(t)
+	store.OnDownload = func(r *http.Request, key string) error { return errors.New("unknown key") }
+
+	ctx := context.Background()
+	key := "other/file.zip"
+	if _, err := store.Put(ctx, key, strings.NewReader("zip data"), "application/zip"); err != nil {
+		t.Fatal(err)
+	}
+	link, err := store.SignedURL(ctx, key, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := download(store, link); w.Code != http.StatusNotFound || strings.Contains(w.Body.String(), "zip data") {
+		t.Errorf("download = %d %q, want 404 without the file", w.Code, w.Body.String())
+	}
+}
+
+func TestLocalStoreRejectsInvalidLinks(t *testing.T) {
+	store := newTestLocalStore(t)
+	ctx := context.Background()
+	key := "data-exports/5/data-export-9.zip"
+	if _, err := store.Put(ctx, key, strings.NewReader("zip data"), "application/zip"); err != nil {
+		t.Fatal(err)
+	}
+
+	valid, _ := store.SignedURL(ctx, key, time.Now().Add(time.Hour))
+	expired, _ := store.SignedURL(ctx, key, time.Now().Add(-time.Minute))
+	otherKey := strings.Replace(valid, "data-export-9", "data-export-10", 1)
+	longer := strings.Replace(valid, "expires=", "expires=9", 1)
+
+	other, err := NewLocalStore(t.TempDir(), "https://api.example.com", []byte("other secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherSecret, _ := other.SignedURL(ctx, key, time.Now().Add(time.Hour))
+
+	tests := []struct {
+		name   string
+		link   string
+		status int
+	}{
+		{name: "expired", link: expired, status: http.StatusGone},
+		{name: "another key", link: otherKey, status: http.StatusForbidden},
+		{name: "extended expiration", link: longer, status: http.StatusForbidden},
+		{name: "another secret", link: otherSecret, status: http.StatusForbidden},
+		{name: "no signature", link: LocalDownloadPath + "?key=" + url.QueryEscape(key), status: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if w := download(store, tt.link); w.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.name, w.Code, tt.status)
+		}
+	}
+}
+
+func TestLocalStoreRejectsPathTraversal(t *testing.T) {
+	store := newTestLocalStore(t)
+	ctx := context.Background()
+
+	for _, key := range []string{"", "/", "../secret", "exports/../../secret"} {
+		if _, err := store.Put(ctx, key, strings.NewReader("data"), "text/plain"); err == nil {
+			t.Errorf("Put(%q) succeeded", key)
+		}
+		if err := store.Delete(ctx, key); err == nil {
+			t.Errorf("Delete(%q) succeeded", key)
+		}
+	}
+
+	// Una firma válida no permite salir del directorio
+	link, _ := store.SignedURL(ctx, "../secret", time.Now().Add(time.Hour))
+	if w := download(store, link); w.Code != http.StatusForbidden {
+		t.Errorf("traversal download = %d, want 403", w.Code)
+	}
+}
@@ -0,0 +1,18 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Store guarda archivos generados por el servicio (p. ej. exportaciones de
+// datos) y emite URLs de descarga firmadas que expiran
+type Store interface {
+	// Put guarda el contenido de r en key y devuelve su tamaño en bytes
+	Put(ctx context.Context, key string, r io.Reader, contentType string) (int64, error)
+	// Delete elimina key; no es un error si no existe
+	Delete(ctx context.Context, key string) error
+	// SignedURL devuelve una URL de descarga de key válida hasta expires
+	SignedURL(ctx context.Context, key string, expires time.Time) (string, error)
+}
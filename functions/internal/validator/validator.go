@@ -1,25 +1,92 @@
 package validator
 
 import (
+	"context"
 	"fmt"
+	"reflect"
 	"strings"
 
+	"github.com/go-playground/locales/de"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/es"
+	"github.com/go-playground/locales/fr"
+	"github.com/go-playground/locales/it"
+	"github.com/go-playground/locales/pt"
+	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
+	de_translations "github.com/go-playground/validator/v10/translations/de"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	es_translations "github.com/go-playground/validator/v10/translations/es"
+	fr_translations "github.com/go-playground/validator/v10/translations/fr"
+	it_translations "github.com/go-playground/validator/v10/translations/it"
+	pt_translations "github.com/go-playground/validator/v10/translations/pt"
+
+	"it-app_user/internal/i18n"
 )
 
-var validate *validator.Validate
+var (
+	validate *validator.Validate
+	uni      *ut.UniversalTranslator
+)
 
 func init() {
 	validate = validator.New()
+
+	// Usar el nombre JSON del campo en los mensajes de error
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" || name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	enLocale := en.New()
+	uni = ut.New(enLocale, enLocale, es.New(), fr.New(), de.New(), it.New(), pt.New())
+
+	registerTranslations("en", en_translations.RegisterDefaultTranslations)
+	registerTranslations("es", es_translations.RegisterDefaultTranslations)
+	registerTranslations("fr", fr_translations.RegisterDefaultTranslations)
+	registerTranslations("de", de_translations.RegisterDefaultTranslations)
+	registerTranslations("it", it_translations.RegisterDefaultTranslations)
+	registerTranslations("pt", pt_translations.RegisterDefaultTranslations)
 }
 
+func registerTranslations(lang string, register func(*validator.Validate, ut.Translator) error) {
+	trans, _ := uni.GetTranslator(lang)
+	if err := register(validate, trans); err != nil {
+		panic(fmt.Sprintf("validator: failed to register %s translations: %v", lang, err))
+	}
+}
+
+// ValidateStruct valida una estructura y devuelve los errores en el idioma por defecto
 func ValidateStruct(s interface{}) error {
-	if err := validate.Struct(s); err != nil {
-		var errors []string
-		for _, err := range err.(validator.ValidationErrors) {
-			errors = append(errors, fmt.Sprintf("Field '%s' failed validation: %s", err.Field(), err.Tag()))
-		}
-		return fmt.Errorf("validation failed: %s", strings.Join(errors, ", "))
+	return ValidateStructLang(s, i18n.DefaultLanguage)
+}
+
+// ValidateStructCtx valida una estructura usando el idioma de la request
+func ValidateStructCtx(ctx context.Context, s interface{}) error {
+	return ValidateStructLang(s, i18n.FromContext(ctx))
+}
+
+// ValidateStructLang valida una estructura y traduce los errores al idioma indicado
+func ValidateStructLang(s interface{}, lang string) error {
+	err := validate.Struct(s)
+	if err == nil {
+		return nil
+	}
+
+	validationErrors, ok := err.(validator.ValidationErrors)
+	if !ok {
+		return err
 	}
-	return nil
-}
\ No newline at end of file
+
+	lang = i18n.Normalize(lang)
+	trans, _ := uni.GetTranslator(lang)
+
+	var errors []string
+	for _, fieldErr := range validationErrors {
+		errors = append(errors, fieldErr.Translate(trans))
+	}
+	return fmt.Errorf("%s: %s", i18n.Translate(lang, "validation failed"), strings.Join(errors, ", "))
+}
@@ -0,0 +1,53 @@
+package validator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"it-app_user/internal/i18n"
+)
+
+type testRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Username string `json:"username,omitempty" validate:"required"`
+	Internal string `json:"-" validate:"required"`
+}
+
+func TestValidateStructLang(t *testing.T) {
+	tests := []struct {
+		lang   string
+		prefix string
+		want   string
+	}{
+		{lang: "es", prefix: "la validación falló: ", want: "email es un campo requerido"},
+		{lang: "en", prefix: "validation failed: ", want: "email is a required field"},
+		{lang: "en-GB", prefix: "validation failed: ", want: "email is a required field"},
+		{lang: "ja", prefix: "la validación falló: ", want: "email es un campo requerido"},
+	}
+	for _, tt := range tests {
+		err := ValidateStructLang(testRequest{}, tt.lang)
+		if err == nil {
+			t.Fatalf("%s: expected a validation error", tt.lang)
+		}
+		msg := err.Error()
+		if !strings.HasPrefix(msg, tt.prefix) || !strings.Contains(msg, tt.want) {
+			t.Errorf("%s: error = %q, want prefix %q and %q", tt.lang, msg, tt.prefix, tt.want)
+		}
+		// Los campos usan su nombre JSON, o el del struct si no se serializan
+		if !strings.Contains(msg, "username") || !strings.Contains(msg, "Internal") {
+			t.Errorf("%s: error = %q, want the username and Internal fields", tt.lang, msg)
+		}
+	}
+}
+
+func TestValidateStructCtx(t *testing.T) {
+	ctx := i18n.WithLanguage(context.Background(), "en")
+	err := ValidateStructCtx(ctx, testRequest{Email: "not-an-email", Username: "ana", Internal: "x"})
+	if err == nil || err.Error() != "validation failed: email must be a valid email address" {
+		t.Fatalf("err = %v, want the English email error", err)
+	}
+	if err := ValidateStructCtx(ctx, testRequest{Email: "ana@example.com", Username: "ana", Internal: "x"}); err != nil {
+		t.Fatalf("valid request: %v", err)
+	}
+}
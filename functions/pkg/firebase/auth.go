@@ -2,8 +2,10 @@ package firebase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 
 	firebase "firebase.google.com/go/v4"
@@ -16,19 +18,24 @@ type Auth struct {
 	projectID string
 }
 
-func NewAuth(serviceAccountPath string) (*Auth, error) {
-	// Verificar si el archivo existe
-	if _, err := os.Stat(serviceAccountPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("firebase service account file not found: %s", serviceAccountPath)
+// NewAuth inicializa Firebase Auth. Si serviceAccountPath está vacío se usan
+// las credenciales por defecto del entorno (Application Default Credentials).
+func NewAuth(serviceAccountPath, projectID string) (*Auth, error) {
+	var opts []option.ClientOption
+	if serviceAccountPath != "" {
+		// Verificar si el archivo existe
+		if _, err := os.Stat(serviceAccountPath); os.IsNotExist(err) {
+			return nil, fmt.Errorf("firebase service account file not found: %s", serviceAccountPath)
+		}
+		opts = append(opts, option.WithCredentialsFile(serviceAccountPath))
 	}
 
 	// Configurar Firebase
-	opt := option.WithCredentialsFile(serviceAccountPath)
 	config := &firebase.Config{
-		ProjectID: os.Getenv("FIREBASE_PROJECT_ID"),
+		ProjectID: projectID,
 	}
 	
-	app, err := firebase.NewApp(context.Background(), config, opt)
+	app, err := firebase.NewApp(context.Background(), config, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize Firebase app: %w", err)
 	}
@@ -53,6 +60,17 @@ func (a *Auth) VerifyIDToken(ctx context.Context, idToken string) (*auth.Token,
 	return token, nil
 }
 
+// VerifyIDTokenAndCheckRevoked verifica el ID token como VerifyIDToken y
+// además consulta la cuenta en Firebase: falla si el token se emitió antes de
+// la última revocación o si la cuenta está deshabilitada o ya no existe
+func (a *Auth) VerifyIDTokenAndCheckRevoked(ctx context.Context, idToken string) (*auth.Token, error) {
+	token, err := a.client.VerifyIDTokenAndCheckRevoked(ctx, idToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify ID token: %w", err)
+	}
+	return token, nil
+}
+
 func (a *Auth) GetUser(ctx context.Context, uid string) (*auth.UserRecord, error) {
 	user, err := a.client.GetUser(ctx, uid)
 	if err != nil {
@@ -93,6 +111,15 @@ func (a *Auth) CreateCustomTokenWithClaims(ctx context.Context, uid string, clai
 	return token, nil
 }
 
+// SetCustomUserClaims reemplaza los custom claims del usuario; los tokens
+// nuevos los incluyen (los ya emitidos no cambian hasta refrescarse)
+func (a *Auth) SetCustomUserClaims(ctx context.Context, uid string, claims map[string]interface{}) error {
+	if err := a.client.SetCustomUserClaims(ctx, uid, claims); err != nil {
+		return fmt.Errorf("failed to set custom claims: %w", err)
+	}
+	return nil
+}
+
 func (a *Auth) UpdateUser(ctx context.Context, uid string, user *auth.UserToUpdate) (*auth.UserRecord, error) {
 	updatedUser, err := a.client.UpdateUser(ctx, uid, user)
 	if err != nil {
@@ -101,6 +128,16 @@ func (a *Auth) UpdateUser(ctx context.Context, uid string, user *auth.UserToUpda
 	return updatedUser, nil
 }
 
+// PasswordResetLink genera el link de Firebase para elegir una contraseña
+// nueva; sirve también para que un usuario sin contraseña defina la primera
+func (a *Auth) PasswordResetLink(ctx context.Context, email string) (string, error) {
+	link, err := a.client.PasswordResetLink(ctx, email)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate password reset link: %w", err)
+	}
+	return link, nil
+}
+
 func (a *Auth) DeleteUser(ctx context.Context, uid string) error {
 	err := a.client.DeleteUser(ctx, uid)
 	if err != nil {
@@ -109,6 +146,74 @@ func (a *Auth) DeleteUser(ctx context.Context, uid string) error {
 	return nil
 }
 
+// Users devuelve un iterador sobre todos los usuarios del proyecto; con
+// iterator.NewPager se recorren por páginas de hasta 1000
+func (a *Auth) Users(ctx context.Context) *auth.UserIterator {
+	return a.client.Users(ctx, "")
+}
+
+// GetUsers obtiene hasta 100 usuarios por UID; los que no existen vuelven en NotFound
+func (a *Auth) GetUsers(ctx context.Context, uids []string) (*auth.GetUsersResult, error) {
+	identifiers := make([]auth.UserIdentifier, len(uids))
+	for i, uid := range uids {
+		identifiers[i] = auth.UIDIdentifier{UID: uid}
+	}
+	result, err := a.client.GetUsers(ctx, identifiers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	return result, nil
+}
+
+// IsUserNotFound indica si err (aunque esté envuelto por los métodos de Auth)
+// corresponde a un usuario que no existe en Firebase
+func IsUserNotFound(err error) bool {
+	return matchError(err, auth.IsUserNotFound)
+}
+
+// IsIDTokenInvalid indica si err corresponde a un ID token inválido, vencido,
+// revocado o de una cuenta deshabilitada, y no a una falla al verificarlo
+func IsIDTokenInvalid(err error) bool {
+	return matchError(err, auth.IsIDTokenInvalid)
+}
+
+// matchError aplica un predicado del SDK a err y a los errores que envuelve:
+// los del SDK no reconocen errores envueltos con %w
+func matchError(err error, is func(error) bool) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if is(err) {
+			return true
+		}
+	}
+	return false
+}
+
 func (a *Auth) GetProjectID() string {
 	return a.projectID
 }
+
+// idTokenCertsURL publica las claves con las que se firman los ID tokens de Firebase
+const idTokenCertsURL = "https://www.googleapis.com/robot/v1/metadata/x509/securetoken@system.gserviceaccount.com"
+
+// HealthCheck verifica que las credenciales sean válidas (consultando un UID
+// inexistente: "user not found" indica que la llamada fue autorizada) y que
+// las claves públicas para verificar ID tokens sean alcanzables
+func (a *Auth) HealthCheck(ctx context.Context) error {
+	if _, err := a.client.GetUser(ctx, "health-check-probe"); err != nil && !auth.IsUserNotFound(err) {
+		return fmt.Errorf("credentials check failed: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, idTokenCertsURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("public keys unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("public keys endpoint returned %d", resp.StatusCode)
+	}
+	return nil
+}
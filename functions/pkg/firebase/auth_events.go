@@ -0,0 +1,39 @@
+package firebase
+
+import "time"
+
+// Tipos de CloudEvent del ciclo de vida de usuarios de Firebase Auth. El
+// Functions Framework convierte a estos tipos los eventos de los triggers
+// providers/firebase.auth/eventTypes/user.create y user.delete.
+const (
+	AuthEventUserCreated = "google.firebase.auth.user.v1.created"
+	AuthEventUserDeleted = "google.firebase.auth.user.v1.deleted"
+)
+
+// AuthEventUser es el usuario de Firebase Auth que llega en los datos del evento
+type AuthEventUser struct {
+	UID           string                  `json:"uid"`
+	Email         string                  `json:"email"`
+	EmailVerified bool                    `json:"emailVerified"`
+	DisplayName   string                  `json:"displayName"`
+	PhotoURL      string                  `json:"photoURL"`
+	PhoneNumber   string                  `json:"phoneNumber"`
+	Disabled      bool                    `json:"disabled"`
+	Metadata      AuthEventUserMetadata   `json:"metadata"`
+	ProviderData  []AuthEventProviderInfo `json:"providerData"`
+}
+
+// AuthEventUserMetadata son las fechas de creación y último inicio de sesión
+type AuthEventUserMetadata struct {
+	CreateTime     *time.Time `json:"createTime"`
+	LastSignInTime *time.Time `json:"lastSignInTime"`
+}
+
+// AuthEventProviderInfo es un proveedor vinculado a la cuenta
+type AuthEventProviderInfo struct {
+	UID         string `json:"uid"`
+	Email       string `json:"email"`
+	ProviderID  string `json:"providerId"`
+	DisplayName string `json:"displayName"`
+	PhotoURL    string `json:"photoURL"`
+}
@@ -0,0 +1,229 @@
+package firebase
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Tipos de evento de las blocking functions de Identity Platform (claim event_type)
+const (
+	BlockingEventBeforeCreate = "beforeCreate"
+	BlockingEventBeforeSignIn = "beforeSignIn"
+)
+
+// ErrInvalidBlockingToken indica que el JWT de una blocking function no es válido
+var ErrInvalidBlockingToken = errors.New("invalid blocking function token")
+
+// BlockingRequest es el body que Identity Platform envía a una blocking function
+type BlockingRequest struct {
+	Data struct {
+		JWT string `json:"jwt"`
+	} `json:"data"`
+}
+
+// BlockingEvent son los claims del JWT de una blocking function
+type BlockingEvent struct {
+	jwt.RegisteredClaims
+	EventID      string             `json:"event_id"`
+	EventType    string             `json:"event_type"`
+	IPAddress    string             `json:"ip_address"`
+	UserAgent    string             `json:"user_agent"`
+	Locale       string             `json:"locale"`
+	SignInMethod string             `json:"sign_in_method"`
+	TenantID     string             `json:"tenant_id"`
+	UserRecord   BlockingUserRecord `json:"user_record"`
+}
+
+// BlockingUserRecord es el usuario que se está creando o iniciando sesión
+type BlockingUserRecord struct {
+	UID           string                 `json:"uid"`
+	Email         string                 `json:"email"`
+	EmailVerified bool                   `json:"email_verified"`
+	DisplayName   string                 `json:"display_name"`
+	PhotoURL      string                 `json:"photo_url"`
+	PhoneNumber   string                 `json:"phone_number"`
+	Disabled      bool                   `json:"disabled"`
+	CustomClaims  map[string]interface{} `json:"custom_claims"`
+	TenantID      string                 `json:"tenant_id"`
+}
+
+// BlockingResponse es la respuesta de una blocking function que deja pasar el evento
+type BlockingResponse struct {
+	UserRecord *BlockingUserUpdate `json:"userRecord,omitempty"`
+}
+
+// BlockingUserUpdate son los cambios a aplicar al usuario. UpdateMask lista
+// los campos presentes; SessionClaims solo se aceptan en beforeSignIn.
+type BlockingUserUpdate struct {
+	UpdateMask    string                 `json:"updateMask"`
+	CustomClaims  map[string]interface{} `json:"customClaims,omitempty"`
+	SessionClaims map[string]interface{} `json:"sessionClaims,omitempty"`
+}
+
+// KeySource devuelve las claves públicas RSA con las que se firman los JWT, por kid
+type KeySource func(ctx context.Context) (map[string]*rsa.PublicKey, error)
+
+// BlockingTokenVerifier verifica los JWT que Identity Platform envía a las
+// blocking functions. No necesita credenciales: solo el Project ID y las
+// claves públicas de Google.
+type BlockingTokenVerifier struct {
+	projectID string
+	audiences []string
+	keys      KeySource
+}
+
+// NewBlockingTokenVerifier crea el verificador. audiences son las URLs
+// exactas con las que se registraron las blocking functions (su functionUri),
+// que Identity Platform pone como audiencia del token.
+func NewBlockingTokenVerifier(projectID string, audiences []string) *BlockingTokenVerifier {
+	return &BlockingTokenVerifier{
+		projectID: projectID,
+		audiences: audiences,
+		keys:      newCertKeySource(idTokenCertsURL).Keys,
+	}
+}
+
+// SetKeySource reemplaza las claves públicas de Google (p. ej. por claves de prueba)
+func (v *BlockingTokenVerifier) SetKeySource(keys KeySource) {
+	v.keys = keys
+}
+
+// Verify valida la firma, la vigencia, el emisor, la audiencia y el tipo de
+// evento del JWT y devuelve sus claims. Los errores envuelven ErrInvalidBlockingToken.
+func (v *BlockingTokenVerifier) Verify(ctx context.Context, token, eventType string) (*BlockingEvent, error) {
+	event := &BlockingEvent{}
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{"RS256"}))
+	_, err := parser.ParseWithClaims(token, event, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		keys, err := v.keys(ctx)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key ID %q", kid)
+		}
+		return key, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBlockingToken, err)
+	}
+
+	if event.Issuer != "https://securetoken.google.com/"+v.projectID {
+		return nil, fmt.Errorf("%w: unexpected issuer %q", ErrInvalidBlockingToken, event.Issuer)
+	}
+	if !v.validAudience(event.Audience) {
+		return nil, fmt.Errorf("%w: unexpected audience %v", ErrInvalidBlockingToken, event.Audience)
+	}
+	if event.EventType != eventType {
+		return nil, fmt.Errorf("%w: expected event %s, got %q", ErrInvalidBlockingToken, eventType, event.EventType)
+	}
+	if event.Subject == "" || event.UserRecord.UID != event.Subject {
+		return nil, fmt.Errorf("%w: subject does not match the user record", ErrInvalidBlockingToken)
+	}
+	return event, nil
+}
+
+// validAudience comprueba que alguna audiencia sea exactamente una de las
+// URLs configuradas: un prefijo aceptaría tokens emitidos para otras
+// funciones del mismo host
+func (v *BlockingTokenVerifier) validAudience(audiences jwt.ClaimStrings) bool {
+	for _, aud := range audiences {
+		for _, expected := range v.audiences {
+			if aud == expected {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// certKeySource obtiene las claves públicas de un endpoint de certificados
+// X.509 de Google y las guarda mientras lo indique su Cache-Control
+type certKeySource struct {
+	url       string
+	client    *http.Client
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	expiresAt time.Time
+}
+
+func newCertKeySource(url string) *certKeySource {
+	return &certKeySource{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Keys devuelve las claves en caché o las vuelve a descargar si vencieron
+func (s *certKeySource) Keys(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.keys != nil && time.Now().Before(s.expiresAt) {
+		return s.keys, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch public keys: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("public keys endpoint returned %d", resp.StatusCode)
+	}
+
+	var certs map[string]string
+	if err := json.NewDecoder(resp.Body).Decode(&certs); err != nil {
+		return nil, fmt.Errorf("failed to decode public keys: %w", err)
+	}
+	keys := make(map[string]*rsa.PublicKey, len(certs))
+	for kid, certPEM := range certs {
+		block, _ := pem.Decode([]byte(certPEM))
+		if block == nil {
+			return nil, fmt.Errorf("invalid certificate for key %q", kid)
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate for key %q: %w", kid, err)
+		}
+		key, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("key %q is not an RSA key", kid)
+		}
+		keys[kid] = key
+	}
+
+	s.keys = keys
+	s.expiresAt = time.Now().Add(maxAge(resp.Header.Get("Cache-Control")))
+	return keys, nil
+}
+
+// maxAge obtiene el max-age de un Cache-Control (por defecto una hora)
+func maxAge(cacheControl string) time.Duration {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		if name != "max-age" {
+			continue
+		}
+		if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return time.Hour
+}
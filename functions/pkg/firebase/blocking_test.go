@@ -0,0 +1,164 @@
+package firebase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	testProjectID      = "innovatech-test"
+	testKeyID          = "test-key"
+	testBeforeCreate   = "https://us-central1-innovatech-test.cloudfunctions.net/user-api/auth/blocking/before-create"
+	testBeforeSignIn   = "https://us-central1-innovatech-test.cloudfunctions.net/user-api/auth/blocking/before-sign-in"
+	testBlockingIssuer = "https://securetoken.google.com/" + testProjectID
+)
+
+// testSigningKey es la clave RSA con la que se firman los tokens de prueba
+var testSigningKey = func() *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	return key
+}()
+
+// newTestVerifier crea un verificador que acepta las claves de prueba
+func newTestVerifier() *BlockingTokenVerifier {
+	v := NewBlockingTokenVerifier(testProjectID, []string{testBeforeCreate, testBeforeSignIn})
+	v.SetKeySource(func(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+		return map[string]*rsa.PublicKey{testKeyID: &testSigningKey.PublicKey}, nil
+	})
+	return v
+}
+
+// newTestEvent devuelve claims válidos de un evento beforeCreate
+func newTestEvent() *BlockingEvent {
+	now := time.Now()
+	return &BlockingEvent{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    testBlockingIssuer,
+			Audience:  jwt.ClaimStrings{testBeforeCreate},
+			Subject:   "uid-ana",
+			IssuedAt:  jwt.NewNumericDate(now.Add(-time.Minute)),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+		EventID:    "evt-1",
+		EventType:  BlockingEventBeforeCreate,
+		IPAddress:  "203.0.113.9",
+		UserAgent:  "Mozilla/5.0",
+		UserRecord: BlockingUserRecord{UID: "uid-ana", Email: "ana@example.com"},
+	}
+}
+
+// signTestEvent firma los claims con la clave de prueba
+func signTestEvent(t *testing.T, event *BlockingEvent, kid string) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, event)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(testSigningKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestBlockingTokenVerifierAccepts(t *testing.T) {
+	v := newTestVerifier()
+
+	event, err := v.Verify(context.Background(), signTestEvent(t, newTestEvent(), testKeyID), BlockingEventBeforeCreate)
+	if err != nil {
+		t.Fatalf("beforeCreate: %v", err)
+	}
+	if event.UserRecord.Email != "ana@example.com" || event.IPAddress != "203.0.113.9" {
+		t.Errorf("beforeCreate claims = %+v", event)
+	}
+
+	signIn := newTestEvent()
+	signIn.EventType = BlockingEventBeforeSignIn
+	signIn.Audience = jwt.ClaimStrings{testBeforeSignIn}
+	if _, err := v.Verify(context.Background(), signTestEvent(t, signIn, testKeyID), BlockingEventBeforeSignIn); err != nil {
+		t.Fatalf("beforeSignIn: %v", err)
+	}
+}
+
+func TestBlockingTokenVerifierRejects(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(e *BlockingEvent)
+		kid    string
+	}{
+		{name: "wrong issuer", modify: func(e *BlockingEvent) { e.Issuer = "https://securetoken.google.com/other-project" }},
+		{name: "wrong audience", modify: func(e *BlockingEvent) {
+			e.Audience = jwt.ClaimStrings{"https://us-central1-other-project.cloudfunctions.net/before-create"}
+		}},
+		{name: "audience prefix of a configured URL", modify: func(e *BlockingEvent) {
+			e.Audience = jwt.ClaimStrings{"https://us-central1-innovatech-test.cloudfunctions.net/user-api"}
+		}},
+		{name: "configured URL as a prefix of the audience", modify: func(e *BlockingEvent) {
+			e.Audience = jwt.ClaimStrings{testBeforeCreate + "-other"}
+		}},
+		{name: "other function of the project", modify: func(e *BlockingEvent) {
+			e.Audience = jwt.ClaimStrings{"https://us-central1-innovatech-test.cloudfunctions.net/other-function"}
+		}},
+		{name: "wrong event type", modify: func(e *BlockingEvent) { e.EventType = BlockingEventBeforeSignIn }},
+		{name: "missing subject", modify: func(e *BlockingEvent) { e.Subject = "" }},
+		{name: "subject does not match the user record", modify: func(e *BlockingEvent) { e.UserRecord.UID = "uid-eva" }},
+		{name: "expired", modify: func(e *BlockingEvent) {
+			e.IssuedAt = jwt.NewNumericDate(time.Now().Add(-2 * time.Hour))
+			e.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Hour))
+		}},
+		{name: "issued in the future", modify: func(e *BlockingEvent) { e.IssuedAt = jwt.NewNumericDate(time.Now().Add(time.Hour)) }},
+		{name: "unknown key", kid: "other-key"},
+	}
+	v := newTestVerifier()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := newTestEvent()
+			if tt.modify != nil {
+				tt.modify(event)
+			}
+			kid := tt.kid
+			if kid == "" {
+				kid = testKeyID
+			}
+			_, err := v.Verify(context.Background(), signTestEvent(t, event, kid), BlockingEventBeforeCreate)
+			if !errors.Is(err, ErrInvalidBlockingToken) {
+				t.Fatalf("err = %v, want ErrInvalidBlockingToken", err)
+			}
+		})
+	}
+}
+
+func TestBlockingTokenVerifierRejectsOtherKeys(t *testing.T) {
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, newTestEvent())
+	token.Header["kid"] = testKeyID
+	signed, err := token.SignedString(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newTestVerifier().Verify(context.Background(), signed, BlockingEventBeforeCreate); !errors.Is(err, ErrInvalidBlockingToken) {
+		t.Fatalf("err = %v, want ErrInvalidBlockingToken for a token signed with another key", err)
+	}
+
+	// Un token HS256 no se acepta aunque tenga un kid conocido: el parser solo admite RS256
+	hmac := jwt.NewWithClaims(jwt.SigningMethodHS256, newTestEvent())
+	hmac.Header["kid"] = testKeyID
+	signed, err = hmac.SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newTestVerifier().Verify(context.Background(), signed, BlockingEventBeforeCreate); !errors.Is(err, ErrInvalidBlockingToken) {
+		t.Fatalf("err = %v, want ErrInvalidBlockingToken for an HS256 token", err)
+	}
+}